@@ -0,0 +1,93 @@
+package main
+
+import (
+    "context"
+    "crypto/ed25519"
+    "encoding/hex"
+    "flag"
+    "fmt"
+    "log"
+    "os"
+    "time"
+
+    "github.com/congo-pay/congo_pay/internal/config"
+    "github.com/congo-pay/congo_pay/internal/infra"
+    "github.com/congo-pay/congo_pay/internal/ledger"
+)
+
+// ledger-verify walks the ledger hash chain and exits non-zero on the first broken link or
+// when transactions were written outside the chain.
+func main() {
+    pubHex := flag.String("pubkey", "", "hex-encoded Ed25519 public key for checkpoint signatures (defaults to the key derived from LEDGER_SIGNING_KEY)")
+    timeout := flag.Duration("timeout", 10*time.Minute, "maximum time allowed for the walk")
+    checkpoint := flag.Bool("checkpoint", false, "write a signed checkpoint of the current head after a successful walk")
+    flag.Parse()
+
+    cfg := config.Load()
+    if cfg.DatabaseURL == "" {
+        log.Fatal("DATABASE_URL is required")
+    }
+
+    var (
+        pub    ed25519.PublicKey
+        signer *ledger.CheckpointSigner
+        err    error
+    )
+    if cfg.LedgerSigningKey != "" {
+        signer, err = ledger.NewCheckpointSigner(cfg.LedgerSigningKeyID, cfg.LedgerSigningKey)
+        if err != nil {
+            log.Fatalf("checkpoint key: %v", err)
+        }
+        pub = signer.PublicKey()
+    }
+    if *pubHex != "" {
+        raw, err := hex.DecodeString(*pubHex)
+        if err != nil || len(raw) != ed25519.PublicKeySize {
+            log.Fatalf("pubkey must be %d hex-encoded bytes", ed25519.PublicKeySize)
+        }
+        pub = ed25519.PublicKey(raw)
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+    defer cancel()
+
+    db, err := infra.NewPostgresPool(ctx, cfg.DatabaseURL)
+    if err != nil {
+        log.Fatalf("postgres init failed: %v", err)
+    }
+    defer db.Close()
+
+    l := ledger.NewPostgresLedger(db)
+    report, err := l.VerifyChain(ctx, pub)
+    if err != nil {
+        log.Fatalf("verify failed: %v", err)
+    }
+
+    fmt.Printf("links checked:        %d\n", report.Checked)
+    fmt.Printf("head:                 seq=%d hash=%s\n", report.HeadSeq, hex.EncodeToString(report.HeadHash))
+    fmt.Printf("checkpoints verified: %d\n", report.CheckpointsVerified)
+    if pub == nil {
+        fmt.Println("checkpoint signatures: skipped (no public key)")
+    }
+    if report.Break != nil {
+        fmt.Printf("BROKEN at seq=%d tx=%s: %s\n", report.Break.Seq, report.Break.TransactionID, report.Break.Reason)
+    }
+    if report.Unchained > 0 {
+        fmt.Printf("BROKEN: %d unchained transactions after chain start\n", report.Unchained)
+    }
+    if !report.OK() {
+        os.Exit(1)
+    }
+    fmt.Println("chain OK")
+
+    if *checkpoint {
+        if signer == nil {
+            log.Fatal("LEDGER_SIGNING_KEY is required to write a checkpoint")
+        }
+        cp, err := l.Checkpoint(ctx, signer)
+        if err != nil {
+            log.Fatalf("checkpoint failed: %v", err)
+        }
+        fmt.Printf("checkpoint %s written at seq=%d\n", cp.ID, cp.Seq)
+    }
+}
@@ -10,6 +10,7 @@ require (
     github.com/google/uuid v1.6.0
     github.com/jackc/pgx/v5 v5.7.6
     github.com/redis/go-redis/v9 v9.14.0
+    github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
     golang.org/x/crypto v0.37.0
 )
 
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Commission posting modes.
+const (
+	// CommissionRealtime credits the agent's commission account with every cash transaction.
+	CommissionRealtime = "realtime"
+	// CommissionDaily credits the day's commissions once the business day has closed.
+	CommissionDaily = "daily"
+)
+
+// CommissionExpenseAccountCode funds agent commissions; it is allowed to go negative.
+const CommissionExpenseAccountCode = "expense:agent_commissions"
+
+// CommissionAccountCode is the ledger account accruing an agent's commissions.
+func CommissionAccountCode(agentID string) string {
+	return "agent_commission:" + agentID
+}
+
+// CommissionBand pays a commission on transactions of one kind whose amount lies in
+// [Min, Max]. A zero Max means no upper bound.
+type CommissionBand struct {
+	Kind        string `json:"kind"`
+	Min         int64  `json:"min"`
+	Max         int64  `json:"max,omitempty"`
+	Fixed       int64  `json:"fixed,omitempty"`
+	BasisPoints int    `json:"bps,omitempty"`
+}
+
+// CommissionSchedule is the tiered commission grid applied to cash-ins and cash-outs.
+type CommissionSchedule struct {
+	Bands []CommissionBand `json:"bands"`
+}
+
+// DefaultCommissionSchedule pays flat amounts on small transactions and a percentage above
+// 20 000 CFA, with cash-outs paying more than cash-ins.
+func DefaultCommissionSchedule() CommissionSchedule {
+	return CommissionSchedule{Bands: []CommissionBand{
+		{Kind: KindCashIn, Min: 1, Max: 5_000, Fixed: 50},
+		{Kind: KindCashIn, Min: 5_001, Max: 20_000, Fixed: 100},
+		{Kind: KindCashIn, Min: 20_001, Max: 100_000, BasisPoints: 50},
+		{Kind: KindCashIn, Min: 100_001, BasisPoints: 40},
+		{Kind: KindCashOut, Min: 1, Max: 5_000, Fixed: 75},
+		{Kind: KindCashOut, Min: 5_001, Max: 20_000, Fixed: 150},
+		{Kind: KindCashOut, Min: 20_001, Max: 100_000, BasisPoints: 75},
+		{Kind: KindCashOut, Min: 100_001, BasisPoints: 60},
+	}}
+}
+
+// LoadCommissionSchedule reads a JSON schedule from path, or returns the default schedule
+// when path is empty.
+func LoadCommissionSchedule(path string) (CommissionSchedule, error) {
+	if path == "" {
+		return DefaultCommissionSchedule(), nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return CommissionSchedule{}, err
+	}
+	defer f.Close()
+	return ParseCommissionSchedule(f)
+}
+
+// ParseCommissionSchedule decodes and validates a JSON schedule.
+func ParseCommissionSchedule(r io.Reader) (CommissionSchedule, error) {
+	var s CommissionSchedule
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return CommissionSchedule{}, fmt.Errorf("commission schedule: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return CommissionSchedule{}, err
+	}
+	return s, nil
+}
+
+// Validate rejects unknown kinds, negative rates and overlapping bands.
+func (s CommissionSchedule) Validate() error {
+	byKind := make(map[string][]CommissionBand)
+	for i, b := range s.Bands {
+		if b.Kind != KindCashIn && b.Kind != KindCashOut {
+			return fmt.Errorf("commission band %d: unknown kind %q", i, b.Kind)
+		}
+		if b.Min < 0 || b.Max != 0 && b.Max < b.Min {
+			return fmt.Errorf("commission band %d: invalid amount range", i)
+		}
+		if b.Fixed < 0 || b.BasisPoints < 0 || b.BasisPoints > 10_000 {
+			return fmt.Errorf("commission band %d: invalid rate", i)
+		}
+		byKind[b.Kind] = append(byKind[b.Kind], b)
+	}
+	for kind, bands := range byKind {
+		sort.Slice(bands, func(i, j int) bool { return bands[i].Min < bands[j].Min })
+		for i := 1; i < len(bands); i++ {
+			prev := bands[i-1]
+			if prev.Max == 0 || prev.Max >= bands[i].Min {
+				return fmt.Errorf("commission bands for %s overlap at %d", kind, bands[i].Min)
+			}
+		}
+	}
+	return nil
+}
+
+// Compute returns the commission due on a transaction, rounded half up to the CFA unit.
+// Amounts outside every band earn nothing.
+func (s CommissionSchedule) Compute(kind string, amount int64) int64 {
+	for _, b := range s.Bands {
+		if b.Kind != kind || amount < b.Min || b.Max != 0 && amount > b.Max {
+			continue
+		}
+		return b.Fixed + (amount*int64(b.BasisPoints)+5_000)/10_000
+	}
+	return 0
+}
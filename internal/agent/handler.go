@@ -0,0 +1,728 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/otp"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// Handler exposes agent network and cash-in/cash-out endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds an agent HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type onboardRequest struct {
+	BusinessName string `json:"business_name"`
+	Location     string `json:"location"`
+	City         string `json:"city"`
+}
+
+type agentResponse struct {
+	ID             string    `json:"id"`
+	AgentCode      string    `json:"agent_code"`
+	OwnerUserID    string    `json:"owner_user_id"`
+	BusinessName   string    `json:"business_name"`
+	Location       string    `json:"location"`
+	City           string    `json:"city"`
+	FloatWalletID  string    `json:"float_wallet_id"`
+	Status         string    `json:"status"`
+	MinFloat       int64     `json:"min_float"`
+	MaxFloat       int64     `json:"max_float"`
+	LiquidityState string    `json:"liquidity_state"`
+	SuperAgentID   string    `json:"super_agent_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func toAgentResponse(a Agent) agentResponse {
+	return agentResponse{
+		ID:             a.ID,
+		AgentCode:      a.AgentCode,
+		OwnerUserID:    a.OwnerUserID,
+		BusinessName:   a.BusinessName,
+		Location:       a.Location,
+		City:           a.City,
+		FloatWalletID:  a.FloatWalletID,
+		Status:         a.Status,
+		MinFloat:       a.MinFloat,
+		MaxFloat:       a.MaxFloat,
+		LiquidityState: a.LiquidityState,
+		SuperAgentID:   a.SuperAgentID,
+		CreatedAt:      a.CreatedAt,
+	}
+}
+
+// publicAgentResponse is what customers see when looking up an agent code.
+type publicAgentResponse struct {
+	AgentCode    string `json:"agent_code"`
+	BusinessName string `json:"business_name"`
+	Location     string `json:"location"`
+	City         string `json:"city"`
+	Status       string `json:"status"`
+}
+
+type receiptResponse struct {
+	ID               string    `json:"id"`
+	Kind             string    `json:"kind"`
+	AgentID          string    `json:"agent_id"`
+	AgentCode        string    `json:"agent_code"`
+	AgentName        string    `json:"agent_name"`
+	CustomerPhone    string    `json:"customer_phone"`
+	CustomerWalletID string    `json:"customer_wallet_id"`
+	Amount           int64     `json:"amount"`
+	Method           string    `json:"method,omitempty"`
+	ClientTxID       string    `json:"client_tx_id"`
+	TransactionID    string    `json:"transaction_id"`
+	Commission       int64     `json:"commission,omitempty"`
+	CommissionPosted bool      `json:"commission_posted,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func toReceiptResponse(t CashTransaction) receiptResponse {
+	return receiptResponse{
+		ID:               t.ID,
+		Kind:             t.Kind,
+		AgentID:          t.AgentID,
+		AgentCode:        t.AgentCode,
+		AgentName:        t.AgentName,
+		CustomerPhone:    t.CustomerPhone,
+		CustomerWalletID: t.CustomerWalletID,
+		Amount:           t.Amount,
+		Method:           t.Method,
+		ClientTxID:       t.ClientTxID,
+		TransactionID:    t.TransactionID,
+		CreatedAt:        t.CreatedAt,
+	}
+}
+
+// toAgentReceiptResponse adds the agent's commission, which customers do not see.
+func toAgentReceiptResponse(t CashTransaction) receiptResponse {
+	resp := toReceiptResponse(t)
+	resp.Commission = t.Commission
+	resp.CommissionPosted = t.CommissionTxID != ""
+	return resp
+}
+
+func toReceiptList(txs []CashTransaction) []receiptResponse {
+	out := make([]receiptResponse, 0, len(txs))
+	for _, t := range txs {
+		out = append(out, toReceiptResponse(t))
+	}
+	return out
+}
+
+// Onboard registers the authenticated user as a pending agent.
+func (h *Handler) Onboard(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req onboardRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	a, err := h.service.Onboard(c.UserContext(), OnboardInput{
+		OwnerUserID:  uid,
+		BusinessName: req.BusinessName,
+		Location:     req.Location,
+		City:         req.City,
+	})
+	if err != nil {
+		return agentError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toAgentResponse(a))
+}
+
+// List returns agents operated by the authenticated user.
+func (h *Handler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	agents, err := h.service.ListByOwner(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]agentResponse, 0, len(agents))
+	for _, a := range agents {
+		out = append(out, toAgentResponse(a))
+	}
+	return c.JSON(fiber.Map{"agents": out})
+}
+
+// Get returns an agent operated by the authenticated user.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	a, err := h.service.owned(c.UserContext(), c.Params("agentId"), uid)
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(toAgentResponse(a))
+}
+
+// Lookup resolves an agent code to its public details.
+func (h *Handler) Lookup(c *fiber.Ctx) error {
+	a, err := h.service.GetByCode(c.UserContext(), c.Params("agentCode"))
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(publicAgentResponse{
+		AgentCode:    a.AgentCode,
+		BusinessName: a.BusinessName,
+		Location:     a.Location,
+		City:         a.City,
+		Status:       a.Status,
+	})
+}
+
+type cashRequest struct {
+	CustomerPhone  string `json:"customer_phone"`
+	Amount         int64  `json:"amount"`
+	PIN            string `json:"pin"`
+	WithdrawalCode string `json:"withdrawal_code"`
+	ClientTxID     string `json:"client_tx_id"`
+}
+
+// CashIn credits a customer against cash handed to the agent.
+func (h *Handler) CashIn(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req cashRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	tx, err := h.service.CashIn(c.UserContext(), CashInInput{
+		AgentID:        c.Params("agentId"),
+		OperatorUserID: uid,
+		CustomerPhone:  req.CustomerPhone,
+		Amount:         req.Amount,
+		ClientTxID:     req.ClientTxID,
+	})
+	return cashResponse(c, tx, err)
+}
+
+// CashOut debits a customer who confirmed with their PIN or a withdrawal code.
+func (h *Handler) CashOut(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req cashRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	tx, err := h.service.CashOut(c.UserContext(), CashOutInput{
+		AgentID:        c.Params("agentId"),
+		OperatorUserID: uid,
+		CustomerPhone:  req.CustomerPhone,
+		Amount:         req.Amount,
+		PIN:            req.PIN,
+		WithdrawalCode: req.WithdrawalCode,
+		ClientTxID:     req.ClientTxID,
+	})
+	return cashResponse(c, tx, err)
+}
+
+func cashResponse(c *fiber.Ctx, tx CashTransaction, err error) error {
+	if errors.Is(err, ledger.ErrDuplicateTransaction) && tx.ID != "" {
+		// Retries with the same client_tx_id get the original receipt back.
+		return c.Status(http.StatusOK).JSON(toAgentReceiptResponse(tx))
+	}
+	if err != nil {
+		return agentError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toAgentReceiptResponse(tx))
+}
+
+// Transactions lists cash transactions of an agent operated by the authenticated user.
+func (h *Handler) Transactions(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	txs, err := h.service.Transactions(c.UserContext(), c.Params("agentId"), uid, c.QueryInt("limit", 50))
+	if err != nil {
+		return agentError(err)
+	}
+	out := make([]receiptResponse, 0, len(txs))
+	for _, t := range txs {
+		out = append(out, toAgentReceiptResponse(t))
+	}
+	return c.JSON(fiber.Map{"transactions": out})
+}
+
+type statementLineResponse struct {
+	At            time.Time `json:"at"`
+	Kind          string    `json:"kind"`
+	TransactionID string    `json:"transaction_id"`
+	CustomerPhone string    `json:"customer_phone,omitempty"`
+	Amount        int64     `json:"amount"`
+	Commission    int64     `json:"commission,omitempty"`
+	Balance       int64     `json:"float_balance"`
+}
+
+// Statement returns an agent's daily statement; format=csv downloads it.
+func (h *Handler) Statement(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	st, err := h.service.Statement(c.UserContext(), c.Params("agentId"), uid, c.Params("date"))
+	if err != nil {
+		return agentError(err)
+	}
+	if c.Query("format") == "csv" {
+		var buf bytes.Buffer
+		if err := WriteStatementCSV(&buf, st); err != nil {
+			return fiber.NewError(http.StatusInternalServerError, err.Error())
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="agent-%s-%s.csv"`, st.AgentCode, st.Date.Format("2006-01-02")))
+		return c.Send(buf.Bytes())
+	}
+	lines := make([]statementLineResponse, 0, len(st.Lines))
+	for _, l := range st.Lines {
+		lines = append(lines, statementLineResponse(l))
+	}
+	return c.JSON(fiber.Map{
+		"agent_id":       st.AgentID,
+		"agent_code":     st.AgentCode,
+		"agent_name":     st.AgentName,
+		"date":           st.Date.Format("2006-01-02"),
+		"opening_float":  st.OpeningFloat,
+		"closing_float":  st.ClosingFloat,
+		"cash_in_count":  st.CashInCount,
+		"cash_in_total":  st.CashInTotal,
+		"cash_out_count": st.CashOutCount,
+		"cash_out_total": st.CashOutTotal,
+		"other_credits":  st.OtherCredits,
+		"other_debits":   st.OtherDebits,
+		"commissions":    st.Commissions,
+		"lines":          lines,
+	})
+}
+
+type withdrawalCodeRequest struct {
+	WalletID string `json:"wallet_id"`
+	Amount   int64  `json:"amount"`
+	OTPCode  string `json:"otp_code"`
+}
+
+// IssueWithdrawalCode creates a one-time code the customer reads out to an agent.
+func (h *Handler) IssueWithdrawalCode(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req withdrawalCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	code, plain, err := h.service.IssueWithdrawalCode(c.UserContext(), IssueCodeInput{UserID: uid, WalletID: req.WalletID, Amount: req.Amount, OTPCode: req.OTPCode})
+	if err != nil {
+		return agentError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"code":       plain,
+		"wallet_id":  code.WalletID,
+		"amount":     code.Amount,
+		"expires_at": code.ExpiresAt,
+	})
+}
+
+// CustomerTransactions lists the authenticated user's cash-ins and cash-outs.
+func (h *Handler) CustomerTransactions(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	txs, err := h.service.CustomerTransactions(c.UserContext(), uid, c.QueryInt("limit", 50))
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"transactions": toReceiptList(txs)})
+}
+
+// Receipt returns a cash transaction receipt to the customer or the agent operator.
+func (h *Handler) Receipt(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	tx, err := h.service.Receipt(c.UserContext(), c.Params("transactionId"), uid)
+	if err != nil {
+		return agentError(err)
+	}
+	if tx.CustomerUserID != uid {
+		return c.JSON(toAgentReceiptResponse(tx))
+	}
+	return c.JSON(toReceiptResponse(tx))
+}
+
+type statusRequest struct {
+	Status string `json:"status"`
+}
+
+// AdminGet returns any agent (back-office).
+func (h *Handler) AdminGet(c *fiber.Ctx) error {
+	a, err := h.service.Get(c.UserContext(), c.Params("agentId"))
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(toAgentResponse(a))
+}
+
+// AdminSetStatus activates, suspends or closes an agent (back-office).
+func (h *Handler) AdminSetStatus(c *fiber.Ctx) error {
+	var req statusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	a, err := h.service.SetStatus(c.UserContext(), c.Params("agentId"), req.Status)
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(toAgentResponse(a))
+}
+
+type thresholdsRequest struct {
+	MinFloat int64 `json:"min_float"`
+	MaxFloat int64 `json:"max_float"`
+}
+
+// SetThresholds sets the float levels that trigger low and high liquidity alerts.
+func (h *Handler) SetThresholds(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req thresholdsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	a, err := h.service.SetThresholds(c.UserContext(), c.Params("agentId"), uid, req.MinFloat, req.MaxFloat)
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(toAgentResponse(a))
+}
+
+type rebalanceRequest struct {
+	Source    string `json:"source"`
+	Direction string `json:"direction"`
+	Amount    int64  `json:"amount"`
+	Note      string `json:"note"`
+}
+
+type decisionRequest struct {
+	Note string `json:"note"`
+}
+
+type rebalanceResponse struct {
+	ID            string     `json:"id"`
+	AgentID       string     `json:"agent_id"`
+	Source        string     `json:"source"`
+	SuperAgentID  string     `json:"super_agent_id,omitempty"`
+	Direction     string     `json:"direction"`
+	Amount        int64      `json:"amount"`
+	Note          string     `json:"note,omitempty"`
+	Status        string     `json:"status"`
+	RequestedBy   string     `json:"requested_by"`
+	DecidedBy     string     `json:"decided_by,omitempty"`
+	DecisionNote  string     `json:"decision_note,omitempty"`
+	TransactionID string     `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+}
+
+func toRebalanceResponse(r RebalanceRequest) rebalanceResponse {
+	resp := rebalanceResponse{
+		ID:            r.ID,
+		AgentID:       r.AgentID,
+		Source:        r.Source,
+		SuperAgentID:  r.SuperAgentID,
+		Direction:     r.Direction,
+		Amount:        r.Amount,
+		Note:          r.Note,
+		Status:        r.Status,
+		RequestedBy:   r.RequestedBy,
+		DecidedBy:     r.DecidedBy,
+		DecisionNote:  r.DecisionNote,
+		TransactionID: r.TransactionID,
+		CreatedAt:     r.CreatedAt,
+	}
+	if !r.DecidedAt.IsZero() {
+		decided := r.DecidedAt
+		resp.DecidedAt = &decided
+	}
+	return resp
+}
+
+func toRebalanceList(rs []RebalanceRequest) []rebalanceResponse {
+	out := make([]rebalanceResponse, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, toRebalanceResponse(r))
+	}
+	return out
+}
+
+// RequestRebalance asks the super-agent or the bank to top up or take back float.
+func (h *Handler) RequestRebalance(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req rebalanceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	r, err := h.service.RequestRebalance(c.UserContext(), RebalanceInput{
+		AgentID:         c.Params("agentId"),
+		RequestorUserID: uid,
+		Source:          req.Source,
+		Direction:       req.Direction,
+		Amount:          req.Amount,
+		Note:            req.Note,
+	})
+	if err != nil {
+		return agentError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toRebalanceResponse(r))
+}
+
+// Rebalances lists an agent's rebalancing requests; ?incoming=true lists those addressed
+// to it as a super-agent.
+func (h *Handler) Rebalances(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	rs, err := h.service.Rebalances(c.UserContext(), c.Params("agentId"), uid, c.QueryBool("incoming"), c.Query("status"), c.QueryInt("limit", 50))
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(fiber.Map{"rebalance_requests": toRebalanceList(rs)})
+}
+
+// GetRebalance returns a rebalancing request to its requester or super-agent.
+func (h *Handler) GetRebalance(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	r, err := h.service.GetRebalance(c.UserContext(), c.Params("requestId"), uid)
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(toRebalanceResponse(r))
+}
+
+// ApproveRebalance approves a request addressed to the caller's super-agent.
+func (h *Handler) ApproveRebalance(c *fiber.Ctx) error {
+	return h.decideRebalance(c, h.service.ApproveRebalance)
+}
+
+// RejectRebalance declines a request addressed to the caller's super-agent.
+func (h *Handler) RejectRebalance(c *fiber.Ctx) error {
+	return h.decideRebalance(c, h.service.RejectRebalance)
+}
+
+// CancelRebalance withdraws the caller's pending request.
+func (h *Handler) CancelRebalance(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	r, err := h.service.CancelRebalance(c.UserContext(), c.Params("requestId"), uid)
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(toRebalanceResponse(r))
+}
+
+// AdminRebalances lists rebalancing requests, bank requests unless ?source= says otherwise.
+func (h *Handler) AdminRebalances(c *fiber.Ctx) error {
+	rs, err := h.service.AdminRebalances(c.UserContext(), RebalanceFilter{
+		AgentID: c.Query("agent_id"),
+		Source:  c.Query("source", SourceBank),
+		Status:  c.Query("status"),
+		Limit:   c.QueryInt("limit", 100),
+	})
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(fiber.Map{"rebalance_requests": toRebalanceList(rs)})
+}
+
+// AdminApproveRebalance approves a bank rebalancing request (back-office).
+func (h *Handler) AdminApproveRebalance(c *fiber.Ctx) error {
+	return h.decideRebalance(c, h.service.AdminApproveRebalance)
+}
+
+// AdminRejectRebalance declines a bank rebalancing request (back-office).
+func (h *Handler) AdminRejectRebalance(c *fiber.Ctx) error {
+	return h.decideRebalance(c, h.service.AdminRejectRebalance)
+}
+
+func (h *Handler) decideRebalance(c *fiber.Ctx, decide func(ctx context.Context, id, userID, note string) (RebalanceRequest, error)) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req decisionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		}
+	}
+	r, err := decide(c.UserContext(), c.Params("requestId"), uid, req.Note)
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(toRebalanceResponse(r))
+}
+
+type registrationRequest struct {
+	Phone            string `json:"phone"`
+	FullName         string `json:"full_name"`
+	DocumentType     string `json:"document_type"`
+	DocumentNumber   string `json:"document_number"`
+	DocumentFrontRef string `json:"document_front_ref"`
+	DocumentBackRef  string `json:"document_back_ref"`
+	SelfieRef        string `json:"selfie_ref"`
+}
+
+type confirmRegistrationRequest struct {
+	OTP string `json:"otp"`
+	PIN string `json:"pin"`
+}
+
+type registrationResponse struct {
+	ID             string     `json:"id"`
+	AgentID        string     `json:"agent_id"`
+	Phone          string     `json:"phone"`
+	FullName       string     `json:"full_name"`
+	DocumentType   string     `json:"document_type"`
+	DocumentNumber string     `json:"document_number"`
+	Status         string     `json:"status"`
+	UserID         string     `json:"user_id,omitempty"`
+	WalletID       string     `json:"wallet_id,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+func toRegistrationResponse(r Registration) registrationResponse {
+	resp := registrationResponse{
+		ID:             r.ID,
+		AgentID:        r.AgentID,
+		Phone:          r.Phone,
+		FullName:       r.FullName,
+		DocumentType:   r.DocumentType,
+		DocumentNumber: r.DocumentNumber,
+		Status:         r.Status,
+		UserID:         r.UserID,
+		WalletID:       r.WalletID,
+		ExpiresAt:      r.ExpiresAt,
+		CreatedAt:      r.CreatedAt,
+	}
+	if !r.CompletedAt.IsZero() {
+		completed := r.CompletedAt
+		resp.CompletedAt = &completed
+	}
+	return resp
+}
+
+// StartRegistration captures a new customer's KYC and texts them an OTP.
+func (h *Handler) StartRegistration(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req registrationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	reg, err := h.service.StartRegistration(c.UserContext(), RegistrationInput{
+		AgentID:          c.Params("agentId"),
+		OperatorUserID:   uid,
+		Phone:            req.Phone,
+		FullName:         req.FullName,
+		DocumentType:     req.DocumentType,
+		DocumentNumber:   req.DocumentNumber,
+		DocumentFrontRef: req.DocumentFrontRef,
+		DocumentBackRef:  req.DocumentBackRef,
+		SelfieRef:        req.SelfieRef,
+	})
+	if err != nil {
+		return agentError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toRegistrationResponse(reg))
+}
+
+// ConfirmRegistration completes a registration with the customer's OTP and chosen PIN.
+func (h *Handler) ConfirmRegistration(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req confirmRegistrationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	reg, user, err := h.service.ConfirmRegistration(c.UserContext(), ConfirmRegistrationInput{
+		AgentID:        c.Params("agentId"),
+		OperatorUserID: uid,
+		RegistrationID: c.Params("registrationId"),
+		OTP:            req.OTP,
+		PIN:            req.PIN,
+	})
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(fiber.Map{
+		"registration": toRegistrationResponse(reg),
+		"user_id":      user.ID,
+		"tier":         user.Tier,
+		"wallet_id":    reg.WalletID,
+	})
+}
+
+// Registrations lists the customers an agent has registered.
+func (h *Handler) Registrations(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	regs, err := h.service.Registrations(c.UserContext(), c.Params("agentId"), uid, c.QueryInt("limit", 50))
+	if err != nil {
+		return agentError(err)
+	}
+	out := make([]registrationResponse, 0, len(regs))
+	for _, r := range regs {
+		out = append(out, toRegistrationResponse(r))
+	}
+	return c.JSON(fiber.Map{"registrations": out})
+}
+
+type superAgentRequest struct {
+	SuperAgentID string `json:"super_agent_id"`
+}
+
+// AdminSetSuperAgent attaches an agent to a super-agent, or detaches it (back-office).
+func (h *Handler) AdminSetSuperAgent(c *fiber.Ctx) error {
+	var req superAgentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	a, err := h.service.SetSuperAgent(c.UserContext(), c.Params("agentId"), req.SuperAgentID)
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(toAgentResponse(a))
+}
+
+type pinUnlockRequest struct {
+	Phone          string `json:"phone"`
+	DocumentNumber string `json:"document_number"`
+}
+
+// UnlockCustomerPIN unlocks a customer's PIN after the agent checked their ID document.
+func (h *Handler) UnlockCustomerPIN(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req pinUnlockRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	user, err := h.service.UnlockCustomerPIN(c.UserContext(), c.Params("agentId"), uid, req.Phone, req.DocumentNumber)
+	if err != nil {
+		return agentError(err)
+	}
+	return c.JSON(fiber.Map{"user_id": user.ID, "phone": user.Phone, "pin_unlocked": true})
+}
+
+func agentError(err error) error {
+	switch {
+	case errors.Is(err, ErrAgentNotFound), errors.Is(err, ErrTransactionNotFound), errors.Is(err, ErrRebalanceNotFound), errors.Is(err, ErrRegistrationNotFound), errors.Is(err, identity.ErrKYCNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotOwner), errors.Is(err, ErrNotParty), errors.Is(err, ErrNotApprover), errors.Is(err, wallet.ErrNotOwner), errors.Is(err, ErrIdentityMismatch), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, identity.ErrPINLocked), errors.Is(err, otp.ErrResendTooSoon), errors.Is(err, otp.ErrQuotaExceeded):
+		return fiber.NewError(http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, identity.ErrPINBlocked):
+		return fiber.NewError(http.StatusLocked, err.Error())
+	case errors.Is(err, identity.ErrInvalidPIN), errors.Is(err, identity.ErrWeakPIN), errors.Is(err, ErrInvalidWithdrawalCode), errors.Is(err, ErrConfirmationRequired), errors.Is(err, ErrInvalidOTP):
+		return fiber.NewError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return fiber.NewError(http.StatusBadRequest, "insufficient funds")
+	case errors.Is(err, ErrNotPending), errors.Is(err, ErrNoSuperAgent), errors.Is(err, identity.ErrUserExists):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, ErrAgentInactive), errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked), errors.Is(err, ledger.ErrAccountRestricted):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,119 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// SetThresholds sets the float bounds outside which the operator is alerted. A zero bound
+// is disabled. The current float is evaluated straight away.
+func (s *Service) SetThresholds(ctx context.Context, agentID, requestorUserID string, minFloat, maxFloat int64) (Agent, error) {
+	a, err := s.owned(ctx, agentID, requestorUserID)
+	if err != nil {
+		return Agent{}, err
+	}
+	if minFloat < 0 || maxFloat < 0 {
+		return Agent{}, fmt.Errorf("thresholds cannot be negative")
+	}
+	if maxFloat > 0 && minFloat >= maxFloat {
+		return Agent{}, fmt.Errorf("minimum float must be below maximum float")
+	}
+	if err := s.repo.UpdateThresholds(ctx, a.ID, minFloat, maxFloat); err != nil {
+		return Agent{}, err
+	}
+	a.MinFloat, a.MaxFloat = minFloat, maxFloat
+	balance, err := s.ledger.Balance(ctx, wallet.AccountCode(a.FloatWalletID))
+	if err != nil {
+		return Agent{}, err
+	}
+	return s.evaluateLiquidity(ctx, a, balance)
+}
+
+// WatchBalances is a ledger.BalanceObserver that re-evaluates the liquidity of every agent
+// float touched by a posting.
+func (s *Service) WatchBalances(ctx context.Context, _ string, balances map[string]int64) {
+	for code, balance := range balances {
+		s.floatsMu.RLock()
+		agentID, ok := s.floats[code]
+		s.floatsMu.RUnlock()
+		if !ok {
+			continue
+		}
+		a, err := s.repo.Get(ctx, agentID)
+		if err != nil {
+			continue
+		}
+		_, _ = s.evaluateLiquidity(ctx, a, balance)
+	}
+}
+
+func (s *Service) watchFloat(floatWalletID, agentID string) {
+	s.floatsMu.Lock()
+	defer s.floatsMu.Unlock()
+	s.floats[wallet.AccountCode(floatWalletID)] = agentID
+}
+
+func (s *Service) refreshFloats(ctx context.Context) error {
+	floats, err := s.repo.FloatWallets(ctx)
+	if err != nil {
+		return err
+	}
+	for walletID, agentID := range floats {
+		s.watchFloat(walletID, agentID)
+	}
+	return nil
+}
+
+func liquidityState(a Agent, balance int64) string {
+	switch {
+	case a.MinFloat > 0 && balance < a.MinFloat:
+		return LiquidityLow
+	case a.MaxFloat > 0 && balance > a.MaxFloat:
+		return LiquidityHigh
+	default:
+		return LiquidityOK
+	}
+}
+
+// evaluateLiquidity records the agent's liquidity state and alerts the operator, and its
+// super-agent if any, only when the state changes so a float hovering below its minimum
+// does not raise an alert on every cash-in.
+func (s *Service) evaluateLiquidity(ctx context.Context, a Agent, balance int64) (Agent, error) {
+	state := liquidityState(a, balance)
+	if state == a.LiquidityState {
+		return a, nil
+	}
+	changed, err := s.repo.SetLiquidityState(ctx, a.ID, a.LiquidityState, state)
+	if err != nil {
+		return Agent{}, err
+	}
+	if !changed {
+		// A concurrent posting already moved the state and sent the alert.
+		return s.repo.Get(ctx, a.ID)
+	}
+	a.LiquidityState = state
+	if s.notifier == nil {
+		return a, nil
+	}
+	var body string
+	switch state {
+	case LiquidityLow:
+		body = fmt.Sprintf("Float of agent %s is low: %d, below the minimum of %d. Request a top-up to keep serving cash-outs.", a.AgentCode, balance, a.MinFloat)
+	case LiquidityHigh:
+		body = fmt.Sprintf("Float of agent %s is high: %d, above the maximum of %d. Consider returning the excess.", a.AgentCode, balance, a.MaxFloat)
+	default:
+		body = fmt.Sprintf("Float of agent %s is back within limits: %d.", a.AgentCode, balance)
+	}
+	msg := notification.Message{Kind: notification.KindAgentLiquidity, Destination: a.OwnerUserID, Body: body}
+	_ = s.notifier.Send(ctx, msg)
+	if a.SuperAgentID != "" {
+		if super, err := s.repo.Get(ctx, a.SuperAgentID); err == nil {
+			msg.Destination = super.OwnerUserID
+			_ = s.notifier.Send(ctx, msg)
+		}
+	}
+	return a, nil
+}
@@ -0,0 +1,387 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mu            sync.RWMutex
+	agents        map[string]Agent
+	transactions  map[string]CashTransaction
+	codes         map[string]WithdrawalCode
+	rebalances    map[string]RebalanceRequest
+	registrations map[string]Registration
+}
+
+// NewMemoryRepository builds an in-memory agent store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		agents:        make(map[string]Agent),
+		transactions:  make(map[string]CashTransaction),
+		codes:         make(map[string]WithdrawalCode),
+		rebalances:    make(map[string]RebalanceRequest),
+		registrations: make(map[string]Registration),
+	}
+}
+
+func (r *memoryRepository) Create(_ context.Context, a Agent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.agents {
+		if existing.AgentCode == a.AgentCode {
+			return errors.New("agent code taken")
+		}
+	}
+	r.agents[a.ID] = a
+	return nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id string) (Agent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	a, ok := r.agents[id]
+	if !ok {
+		return Agent{}, ErrAgentNotFound
+	}
+	return a, nil
+}
+
+func (r *memoryRepository) GetByCode(_ context.Context, code string) (Agent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, a := range r.agents {
+		if a.AgentCode == code {
+			return a, nil
+		}
+	}
+	return Agent{}, ErrAgentNotFound
+}
+
+func (r *memoryRepository) ListByOwner(_ context.Context, ownerUserID string) ([]Agent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Agent
+	for _, a := range r.agents {
+		if a.OwnerUserID == ownerUserID {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *memoryRepository) UpdateStatus(_ context.Context, id, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.agents[id]
+	if !ok {
+		return ErrAgentNotFound
+	}
+	a.Status = status
+	r.agents[id] = a
+	return nil
+}
+
+func (r *memoryRepository) update(id string, mutate func(*Agent)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.agents[id]
+	if !ok {
+		return ErrAgentNotFound
+	}
+	mutate(&a)
+	r.agents[id] = a
+	return nil
+}
+
+func (r *memoryRepository) UpdateThresholds(_ context.Context, id string, minFloat, maxFloat int64) error {
+	return r.update(id, func(a *Agent) { a.MinFloat, a.MaxFloat = minFloat, maxFloat })
+}
+
+func (r *memoryRepository) SetSuperAgent(_ context.Context, id, superAgentID string) error {
+	return r.update(id, func(a *Agent) { a.SuperAgentID = superAgentID })
+}
+
+func (r *memoryRepository) SetLiquidityState(_ context.Context, id, fromState, state string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.agents[id]
+	if !ok {
+		return false, ErrAgentNotFound
+	}
+	if a.LiquidityState != fromState {
+		return false, nil
+	}
+	a.LiquidityState = state
+	r.agents[id] = a
+	return true, nil
+}
+
+func (r *memoryRepository) FloatWallets(_ context.Context) (map[string]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make(map[string]string, len(r.agents))
+	for _, a := range r.agents {
+		out[a.FloatWalletID] = a.ID
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) CreateTransaction(_ context.Context, t CashTransaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.transactions {
+		if existing.AgentID == t.AgentID && existing.ClientTxID == t.ClientTxID {
+			return errors.New("duplicate client transaction id")
+		}
+	}
+	r.transactions[t.ID] = t
+	return nil
+}
+
+func (r *memoryRepository) GetTransaction(_ context.Context, id string) (CashTransaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.transactions[id]
+	if !ok {
+		return CashTransaction{}, ErrTransactionNotFound
+	}
+	return t, nil
+}
+
+func (r *memoryRepository) FindTransaction(_ context.Context, agentID, clientTxID string) (CashTransaction, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.transactions {
+		if t.AgentID == agentID && t.ClientTxID == clientTxID {
+			return t, nil
+		}
+	}
+	return CashTransaction{}, ErrTransactionNotFound
+}
+
+func (r *memoryRepository) ListByAgent(_ context.Context, agentID string, limit int) ([]CashTransaction, error) {
+	return r.listTransactions(func(t CashTransaction) bool { return t.AgentID == agentID }, limit), nil
+}
+
+func (r *memoryRepository) ListByCustomer(_ context.Context, userID string, limit int) ([]CashTransaction, error) {
+	return r.listTransactions(func(t CashTransaction) bool { return t.CustomerUserID == userID }, limit), nil
+}
+
+func (r *memoryRepository) ListByAgentBetween(_ context.Context, agentID string, from, to time.Time) ([]CashTransaction, error) {
+	out := r.listTransactions(func(t CashTransaction) bool {
+		return t.AgentID == agentID && !t.CreatedAt.Before(from) && t.CreatedAt.Before(to)
+	}, 0)
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *memoryRepository) ListUnpostedCommissions(_ context.Context, before time.Time, limit int) ([]CashTransaction, error) {
+	out := r.listTransactions(func(t CashTransaction) bool {
+		return t.Commission > 0 && t.CommissionTxID == "" && t.CreatedAt.Before(before)
+	}, 0)
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) MarkCommissionPosted(_ context.Context, id, ledgerTxID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if t, ok := r.transactions[id]; ok && t.CommissionTxID == "" {
+		t.CommissionTxID = ledgerTxID
+		r.transactions[id] = t
+	}
+	return nil
+}
+
+func (r *memoryRepository) listTransactions(match func(CashTransaction) bool, limit int) []CashTransaction {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []CashTransaction
+	for _, t := range r.transactions {
+		if match(t) {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (r *memoryRepository) CreateWithdrawalCode(_ context.Context, c WithdrawalCode) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, existing := range r.codes {
+		if existing.UserID == c.UserID && existing.Status == CodeActive {
+			existing.Status = CodeVoid
+			r.codes[id] = existing
+		}
+	}
+	r.codes[c.ID] = c
+	return nil
+}
+
+func (r *memoryRepository) ActiveWithdrawalCode(_ context.Context, userID string) (WithdrawalCode, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, c := range r.codes {
+		if c.UserID == userID && c.Status == CodeActive {
+			return c, nil
+		}
+	}
+	return WithdrawalCode{}, ErrCodeNotFound
+}
+
+func (r *memoryRepository) RecordCodeAttempt(_ context.Context, id string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.codes[id]
+	if !ok {
+		return 0, ErrCodeNotFound
+	}
+	c.Attempts++
+	r.codes[id] = c
+	return c.Attempts, nil
+}
+
+func (r *memoryRepository) SetCodeStatus(_ context.Context, id, fromStatus, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.codes[id]
+	if !ok || c.Status != fromStatus {
+		return ErrInvalidWithdrawalCode
+	}
+	c.Status = status
+	r.codes[id] = c
+	return nil
+}
+
+func (r *memoryRepository) CreateRebalance(_ context.Context, rb RebalanceRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rebalances[rb.ID] = rb
+	return nil
+}
+
+func (r *memoryRepository) GetRebalance(_ context.Context, id string) (RebalanceRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rb, ok := r.rebalances[id]
+	if !ok {
+		return RebalanceRequest{}, ErrRebalanceNotFound
+	}
+	return rb, nil
+}
+
+func (r *memoryRepository) ListRebalances(_ context.Context, f RebalanceFilter) ([]RebalanceRequest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []RebalanceRequest
+	for _, rb := range r.rebalances {
+		if f.AgentID != "" && rb.AgentID != f.AgentID ||
+			f.SuperAgentID != "" && rb.SuperAgentID != f.SuperAgentID ||
+			f.Source != "" && rb.Source != f.Source ||
+			f.Status != "" && rb.Status != f.Status {
+			continue
+		}
+		out = append(out, rb)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[:f.Limit]
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) TransitionRebalance(_ context.Context, fromStatus string, rb RebalanceRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.rebalances[rb.ID]
+	if !ok {
+		return ErrRebalanceNotFound
+	}
+	if current.Status != fromStatus {
+		return ErrNotPending
+	}
+	r.rebalances[rb.ID] = rb
+	return nil
+}
+
+func (r *memoryRepository) CreateRegistration(_ context.Context, reg Registration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, other := range r.registrations {
+		if other.Phone == reg.Phone && other.Status == RegistrationPending {
+			other.Status = RegistrationVoid
+			r.registrations[id] = other
+		}
+	}
+	r.registrations[reg.ID] = reg
+	return nil
+}
+
+func (r *memoryRepository) GetRegistration(_ context.Context, id string) (Registration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reg, ok := r.registrations[id]
+	if !ok {
+		return Registration{}, ErrRegistrationNotFound
+	}
+	return reg, nil
+}
+
+func (r *memoryRepository) ListRegistrations(_ context.Context, agentID string, limit int) ([]Registration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Registration
+	for _, reg := range r.registrations {
+		if reg.AgentID == agentID {
+			out = append(out, reg)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *memoryRepository) SetRegistrationStatus(_ context.Context, id, fromStatus, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reg, ok := r.registrations[id]
+	if !ok {
+		return ErrRegistrationNotFound
+	}
+	if reg.Status != fromStatus {
+		return ErrInvalidOTP
+	}
+	reg.Status = status
+	r.registrations[id] = reg
+	return nil
+}
+
+func (r *memoryRepository) CompleteRegistration(_ context.Context, id, userID, walletID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reg, ok := r.registrations[id]
+	if !ok {
+		return ErrRegistrationNotFound
+	}
+	if reg.Status != RegistrationPending {
+		return ErrInvalidOTP
+	}
+	reg.Status = RegistrationCompleted
+	reg.UserID, reg.WalletID, reg.CompletedAt = userID, walletID, at
+	r.registrations[id] = reg
+	return nil
+}
@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"errors"
+	"time"
+)
+
+// Agent statuses. Applications start pending until the back office activates them.
+const (
+	StatusPending   = "pending"
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusClosed    = "closed"
+)
+
+// Cash transaction kinds.
+const (
+	KindCashIn  = "cash_in"
+	KindCashOut = "cash_out"
+)
+
+// Cash-out confirmation methods.
+const (
+	MethodPIN            = "pin"
+	MethodWithdrawalCode = "withdrawal_code"
+)
+
+// Float liquidity states.
+const (
+	LiquidityOK   = "ok"
+	LiquidityLow  = "low"
+	LiquidityHigh = "high"
+)
+
+// Rebalancing sources, directions and statuses.
+const (
+	SourceSuperAgent = "super_agent"
+	SourceBank       = "bank"
+
+	// DirectionTopUp moves float from the source to the agent.
+	DirectionTopUp = "topup"
+	// DirectionReturn moves excess float from the agent back to the source.
+	DirectionReturn = "return"
+
+	RebalancePending   = "pending"
+	RebalanceApproved  = "approved"
+	RebalanceRejected  = "rejected"
+	RebalanceCancelled = "cancelled"
+)
+
+// Assisted registration statuses.
+const (
+	RegistrationPending   = "pending"
+	RegistrationCompleted = "completed"
+	// RegistrationVoid covers expired, superseded and locked-out registrations.
+	RegistrationVoid = "void"
+)
+
+// Withdrawal code statuses.
+const (
+	CodeActive   = "active"
+	CodeRedeemed = "redeemed"
+	CodeVoid     = "void"
+)
+
+var (
+	// ErrAgentNotFound indicates no agent matches the lookup.
+	ErrAgentNotFound = errors.New("agent not found")
+	// ErrAgentInactive indicates the agent cannot serve customers.
+	ErrAgentInactive = errors.New("agent not active")
+	// ErrNotOwner indicates the caller does not operate the agent.
+	ErrNotOwner = errors.New("not owner of agent")
+	// ErrTransactionNotFound indicates no cash transaction matches the lookup.
+	ErrTransactionNotFound = errors.New("cash transaction not found")
+	// ErrNotParty indicates the caller is neither the customer nor the agent on a receipt.
+	ErrNotParty = errors.New("not a party to this transaction")
+	// ErrConfirmationRequired indicates a cash-out carried neither a PIN nor a withdrawal code.
+	ErrConfirmationRequired = errors.New("customer PIN or withdrawal code required")
+	// ErrInvalidWithdrawalCode indicates the code is wrong, expired, used or for another amount.
+	ErrInvalidWithdrawalCode = errors.New("invalid or expired withdrawal code")
+	// ErrCodeNotFound indicates the customer has no active withdrawal code.
+	ErrCodeNotFound = errors.New("withdrawal code not found")
+	// ErrRebalanceNotFound indicates no rebalancing request matches the lookup.
+	ErrRebalanceNotFound = errors.New("rebalancing request not found")
+	// ErrNotPending indicates the rebalancing request was already decided or cancelled.
+	ErrNotPending = errors.New("rebalancing request is not pending")
+	// ErrNotApprover indicates the caller cannot decide the rebalancing request.
+	ErrNotApprover = errors.New("not the approver of this rebalancing request")
+	// ErrNoSuperAgent indicates the agent is not attached to a super-agent.
+	ErrNoSuperAgent = errors.New("agent has no super-agent")
+	// ErrRegistrationNotFound indicates no assisted registration matches the lookup.
+	ErrRegistrationNotFound = errors.New("registration not found")
+	// ErrInvalidOTP indicates a wrong, expired or exhausted registration OTP.
+	ErrInvalidOTP = errors.New("invalid or expired OTP")
+)
+
+// Agent is a cash point that exchanges physical cash for e-money out of a float wallet.
+type Agent struct {
+	ID           string
+	AgentCode    string
+	OwnerUserID  string
+	BusinessName string
+	// Location is a free-text landmark customers recognise, e.g. "Marché Total, Bacongo".
+	Location      string
+	City          string
+	FloatWalletID string
+	Status        string
+	// MinFloat and MaxFloat bound the float before liquidity alerts fire; zero disables a bound.
+	MinFloat       int64
+	MaxFloat       int64
+	LiquidityState string
+	// SuperAgentID is the agent that tops up this agent's float, if any.
+	SuperAgentID string
+	CreatedAt    time.Time
+}
+
+// CashTransaction is the receipt of a cash-in or cash-out, shared by the agent and customer.
+type CashTransaction struct {
+	ID               string
+	Kind             string
+	AgentID          string
+	AgentCode        string
+	AgentName        string
+	CustomerUserID   string
+	CustomerPhone    string
+	CustomerWalletID string
+	Amount           int64
+	// Method records how the customer confirmed a cash-out; empty for cash-in.
+	Method        string
+	ClientTxID    string
+	TransactionID string
+	// Commission is what the agent earns on the transaction; CommissionTxID is set once it
+	// has been credited to the agent's commission account.
+	Commission     int64
+	CommissionTxID string
+	CreatedAt      time.Time
+}
+
+// WithdrawalCode lets a customer pre-authorise a cash-out without keying their PIN into the
+// agent's device. Only a hash of the code is stored.
+type WithdrawalCode struct {
+	ID        string
+	UserID    string
+	WalletID  string
+	CodeHash  string
+	Amount    int64
+	Status    string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// RebalanceRequest asks a super-agent or the bank to top up, or take back, an agent's float.
+type RebalanceRequest struct {
+	ID            string
+	AgentID       string
+	Source        string
+	SuperAgentID  string
+	Direction     string
+	Amount        int64
+	Note          string
+	Status        string
+	RequestedBy   string
+	DecidedBy     string
+	DecisionNote  string
+	TransactionID string
+	CreatedAt     time.Time
+	DecidedAt     time.Time
+}
+
+// Registration is a customer onboarding captured by an agent, waiting for the customer to
+// confirm the phone number with the SMS OTP and choose a PIN. It expires with the OTP.
+type Registration struct {
+	ID               string
+	AgentID          string
+	OperatorUserID   string
+	Phone            string
+	FullName         string
+	DocumentType     string
+	DocumentNumber   string
+	DocumentFrontRef string
+	DocumentBackRef  string
+	SelfieRef        string
+	Status           string
+	// UserID and WalletID are set once the registration completes.
+	UserID      string
+	WalletID    string
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+	CompletedAt time.Time
+}
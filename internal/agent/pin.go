@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+)
+
+// ErrIdentityMismatch indicates the document the customer presented does not match their
+// KYC profile.
+var ErrIdentityMismatch = errors.New("document does not match the customer's KYC profile")
+
+// UnlockCustomerPIN clears a customer's locked or blocked PIN after the agent has checked
+// the customer's ID document in person. The document number must match the KYC profile
+// captured at registration; customers without one must go to support.
+func (s *Service) UnlockCustomerPIN(ctx context.Context, agentID, operatorUserID, phone, documentNumber string) (identity.User, error) {
+	a, err := s.owned(ctx, agentID, operatorUserID)
+	if err != nil {
+		return identity.User{}, err
+	}
+	if a.Status != StatusActive {
+		return identity.User{}, ErrAgentInactive
+	}
+	customer, err := s.users.LookupByPhone(ctx, phone)
+	if err != nil {
+		return identity.User{}, err
+	}
+	if customer.ID == a.OwnerUserID {
+		return identity.User{}, fmt.Errorf("agents cannot unlock their own PIN")
+	}
+	kyc, err := s.users.KYC(ctx, customer.ID)
+	if err != nil {
+		return identity.User{}, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(documentNumber), kyc.DocumentNumber) {
+		return identity.User{}, ErrIdentityMismatch
+	}
+	return s.users.UnlockPIN(ctx, customer.ID, "agent:"+a.ID, fmt.Sprintf("identity checked by agent %s (%s)", a.AgentCode, kyc.DocumentType))
+}
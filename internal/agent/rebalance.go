@@ -0,0 +1,309 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const rebalanceKind = "agent_rebalance"
+
+// BankFloatAccountCode is the settlement account that funds bank top-ups and receives
+// returned float. It may go negative until treasury settles with the bank.
+const BankFloatAccountCode = "settlement:bank_float"
+
+// SetSuperAgent attaches an agent to the super-agent that tops up its float, or detaches it
+// when superAgentID is empty (back-office).
+func (s *Service) SetSuperAgent(ctx context.Context, agentID, superAgentID string) (Agent, error) {
+	a, err := s.repo.Get(ctx, agentID)
+	if err != nil {
+		return Agent{}, err
+	}
+	if superAgentID != "" {
+		if superAgentID == a.ID {
+			return Agent{}, fmt.Errorf("an agent cannot be its own super-agent")
+		}
+		super, err := s.repo.Get(ctx, superAgentID)
+		if err != nil {
+			return Agent{}, err
+		}
+		if super.SuperAgentID == a.ID {
+			return Agent{}, fmt.Errorf("super-agent is attached to this agent")
+		}
+	}
+	if err := s.repo.SetSuperAgent(ctx, a.ID, superAgentID); err != nil {
+		return Agent{}, err
+	}
+	a.SuperAgentID = superAgentID
+	return a, nil
+}
+
+// RebalanceInput captures an agent's request to move float.
+type RebalanceInput struct {
+	AgentID         string
+	RequestorUserID string
+	Source          string
+	Direction       string
+	Amount          int64
+	Note            string
+}
+
+// RequestRebalance asks the agent's super-agent, or the bank through the back office, to
+// top up or take back float. Nothing moves until the request is approved.
+func (s *Service) RequestRebalance(ctx context.Context, input RebalanceInput) (RebalanceRequest, error) {
+	a, err := s.owned(ctx, input.AgentID, input.RequestorUserID)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	if a.Status != StatusActive {
+		return RebalanceRequest{}, ErrAgentInactive
+	}
+	if input.Amount <= 0 {
+		return RebalanceRequest{}, fmt.Errorf("amount must be positive")
+	}
+	switch input.Direction {
+	case DirectionTopUp, DirectionReturn:
+	default:
+		return RebalanceRequest{}, fmt.Errorf("unknown direction %q", input.Direction)
+	}
+	r := RebalanceRequest{
+		ID:          uuid.NewString(),
+		AgentID:     a.ID,
+		Source:      input.Source,
+		Direction:   input.Direction,
+		Amount:      input.Amount,
+		Note:        strings.TrimSpace(input.Note),
+		Status:      RebalancePending,
+		RequestedBy: input.RequestorUserID,
+		CreatedAt:   s.now(),
+	}
+	var approver string
+	switch input.Source {
+	case SourceSuperAgent:
+		if a.SuperAgentID == "" {
+			return RebalanceRequest{}, ErrNoSuperAgent
+		}
+		super, err := s.repo.Get(ctx, a.SuperAgentID)
+		if err != nil {
+			return RebalanceRequest{}, err
+		}
+		r.SuperAgentID = super.ID
+		approver = super.OwnerUserID
+	case SourceBank:
+	default:
+		return RebalanceRequest{}, fmt.Errorf("unknown source %q", input.Source)
+	}
+	if err := s.repo.CreateRebalance(ctx, r); err != nil {
+		return RebalanceRequest{}, err
+	}
+	if s.notifier != nil && approver != "" {
+		body := fmt.Sprintf("Agent %s requests a float %s of %d. Ref %s", a.AgentCode, r.Direction, r.Amount, r.ID)
+		_ = s.notifier.Send(ctx, notification.Message{Kind: notification.KindAgentRebalance, Destination: approver, Body: body})
+	}
+	return r, nil
+}
+
+// GetRebalance returns a request visible to the requesting agent's operator or to the
+// super-agent operator asked to fund it.
+func (s *Service) GetRebalance(ctx context.Context, id, requestorUserID string) (RebalanceRequest, error) {
+	r, err := s.repo.GetRebalance(ctx, id)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	if _, err := s.owned(ctx, r.AgentID, requestorUserID); err == nil {
+		return r, nil
+	}
+	if r.SuperAgentID != "" {
+		if _, err := s.owned(ctx, r.SuperAgentID, requestorUserID); err == nil {
+			return r, nil
+		}
+	}
+	return RebalanceRequest{}, ErrNotParty
+}
+
+// Rebalances lists an agent's requests, or with incoming set the requests addressed to it
+// as a super-agent.
+func (s *Service) Rebalances(ctx context.Context, agentID, requestorUserID string, incoming bool, status string, limit int) ([]RebalanceRequest, error) {
+	a, err := s.owned(ctx, agentID, requestorUserID)
+	if err != nil {
+		return nil, err
+	}
+	filter := RebalanceFilter{AgentID: a.ID, Status: status, Limit: limit}
+	if incoming {
+		filter = RebalanceFilter{SuperAgentID: a.ID, Source: SourceSuperAgent, Status: status, Limit: limit}
+	}
+	return s.repo.ListRebalances(ctx, filter)
+}
+
+// AdminRebalances lists requests for the back office, bank requests by default.
+func (s *Service) AdminRebalances(ctx context.Context, filter RebalanceFilter) ([]RebalanceRequest, error) {
+	return s.repo.ListRebalances(ctx, filter)
+}
+
+// ApproveRebalance lets the super-agent operator approve a request addressed to them and
+// moves the float.
+func (s *Service) ApproveRebalance(ctx context.Context, id, approverUserID, note string) (RebalanceRequest, error) {
+	r, err := s.superAgentDecision(ctx, id, approverUserID)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	return s.approve(ctx, r, approverUserID, note)
+}
+
+// RejectRebalance lets the super-agent operator decline a request addressed to them.
+func (s *Service) RejectRebalance(ctx context.Context, id, approverUserID, note string) (RebalanceRequest, error) {
+	r, err := s.superAgentDecision(ctx, id, approverUserID)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	return s.decide(ctx, r, RebalanceRejected, approverUserID, note, "")
+}
+
+// AdminApproveRebalance approves a bank request (back-office).
+func (s *Service) AdminApproveRebalance(ctx context.Context, id, adminUserID, note string) (RebalanceRequest, error) {
+	r, err := s.bankDecision(ctx, id)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	return s.approve(ctx, r, adminUserID, note)
+}
+
+// AdminRejectRebalance declines a bank request (back-office).
+func (s *Service) AdminRejectRebalance(ctx context.Context, id, adminUserID, note string) (RebalanceRequest, error) {
+	r, err := s.bankDecision(ctx, id)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	return s.decide(ctx, r, RebalanceRejected, adminUserID, note, "")
+}
+
+// CancelRebalance withdraws a pending request on behalf of the requesting agent.
+func (s *Service) CancelRebalance(ctx context.Context, id, requestorUserID string) (RebalanceRequest, error) {
+	r, err := s.repo.GetRebalance(ctx, id)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	if _, err := s.owned(ctx, r.AgentID, requestorUserID); err != nil {
+		return RebalanceRequest{}, err
+	}
+	if r.Status != RebalancePending {
+		return RebalanceRequest{}, ErrNotPending
+	}
+	r.Status = RebalanceCancelled
+	r.DecidedBy = requestorUserID
+	r.DecidedAt = s.now()
+	if err := s.repo.TransitionRebalance(ctx, RebalancePending, r); err != nil {
+		return RebalanceRequest{}, err
+	}
+	return r, nil
+}
+
+func (s *Service) superAgentDecision(ctx context.Context, id, approverUserID string) (RebalanceRequest, error) {
+	r, err := s.repo.GetRebalance(ctx, id)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	if r.Source != SourceSuperAgent {
+		return RebalanceRequest{}, ErrNotApprover
+	}
+	if _, err := s.owned(ctx, r.SuperAgentID, approverUserID); err != nil {
+		return RebalanceRequest{}, ErrNotApprover
+	}
+	if r.Status != RebalancePending {
+		return RebalanceRequest{}, ErrNotPending
+	}
+	return r, nil
+}
+
+func (s *Service) bankDecision(ctx context.Context, id string) (RebalanceRequest, error) {
+	r, err := s.repo.GetRebalance(ctx, id)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	if r.Source != SourceBank {
+		return RebalanceRequest{}, ErrNotApprover
+	}
+	if r.Status != RebalancePending {
+		return RebalanceRequest{}, ErrNotPending
+	}
+	return r, nil
+}
+
+// approve claims the request before posting so two approvers cannot both move the float,
+// and puts it back to pending if the posting fails.
+func (s *Service) approve(ctx context.Context, r RebalanceRequest, approverUserID, note string) (RebalanceRequest, error) {
+	a, err := s.repo.Get(ctx, r.AgentID)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	float, err := s.float(ctx, a)
+	if err != nil {
+		return RebalanceRequest{}, err
+	}
+	source := ledger.Posting{AccountCode: BankFloatAccountCode, AllowOverdraft: true}
+	if r.Source == SourceSuperAgent {
+		super, err := s.repo.Get(ctx, r.SuperAgentID)
+		if err != nil {
+			return RebalanceRequest{}, err
+		}
+		if super.Status != StatusActive {
+			return RebalanceRequest{}, ErrAgentInactive
+		}
+		source = ledger.Posting{AccountCode: wallet.AccountCode(super.FloatWalletID)}
+	}
+	agentLeg := ledger.Posting{AccountCode: float.AccountCode}
+	if r.Direction == DirectionTopUp {
+		source.Amount, agentLeg.Amount = -r.Amount, r.Amount
+	} else {
+		if err := float.CanDebit(); err != nil {
+			return RebalanceRequest{}, err
+		}
+		source.Amount, agentLeg.Amount = r.Amount, -r.Amount
+	}
+
+	claimed := r
+	claimed.Status = RebalanceApproved
+	claimed.DecidedBy = approverUserID
+	claimed.DecisionNote = strings.TrimSpace(note)
+	claimed.DecidedAt = s.now()
+	if err := s.repo.TransitionRebalance(ctx, RebalancePending, claimed); err != nil {
+		return RebalanceRequest{}, err
+	}
+	res, err := s.ledger.Post(ctx, rebalanceKind, "rebalance:"+r.ID, []ledger.Posting{source, agentLeg})
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		_ = s.repo.TransitionRebalance(ctx, RebalanceApproved, r)
+		return RebalanceRequest{}, err
+	}
+	return s.decide(ctx, claimed, RebalanceApproved, approverUserID, note, res.TransactionID)
+}
+
+// decide records a final decision and tells the requesting operator. An approval arrives
+// here already claimed, so only the transaction ID is added.
+func (s *Service) decide(ctx context.Context, r RebalanceRequest, status, deciderUserID, note, transactionID string) (RebalanceRequest, error) {
+	from := RebalancePending
+	if r.Status == RebalanceApproved {
+		from = RebalanceApproved
+	}
+	r.Status = status
+	r.DecidedBy = deciderUserID
+	r.DecisionNote = strings.TrimSpace(note)
+	r.TransactionID = transactionID
+	if r.DecidedAt.IsZero() {
+		r.DecidedAt = s.now()
+	}
+	if err := s.repo.TransitionRebalance(ctx, from, r); err != nil {
+		return RebalanceRequest{}, err
+	}
+	if s.notifier != nil {
+		body := fmt.Sprintf("Your float %s request of %d was %s. Ref %s", r.Direction, r.Amount, status, r.ID)
+		_ = s.notifier.Send(ctx, notification.Message{Kind: notification.KindAgentRebalance, Destination: r.RequestedBy, Body: body})
+	}
+	return r, nil
+}
@@ -0,0 +1,187 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/otp"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// RegistrationInput is what an agent captures when registering a customer in the field.
+type RegistrationInput struct {
+	AgentID          string
+	OperatorUserID   string
+	Phone            string
+	FullName         string
+	DocumentType     string
+	DocumentNumber   string
+	DocumentFrontRef string
+	DocumentBackRef  string
+	SelfieRef        string
+}
+
+func (in RegistrationInput) kyc() identity.KYCProfile {
+	return identity.KYCProfile{
+		FullName:         strings.TrimSpace(in.FullName),
+		DocumentType:     in.DocumentType,
+		DocumentNumber:   strings.TrimSpace(in.DocumentNumber),
+		DocumentFrontRef: in.DocumentFrontRef,
+		DocumentBackRef:  in.DocumentBackRef,
+		SelfieRef:        in.SelfieRef,
+	}
+}
+
+// StartRegistration records the KYC an agent captured for a new customer and texts the
+// customer an OTP through the OTP service, so its resend cooldown and per-phone quota
+// apply. Starting again for the same phone supersedes the earlier attempt.
+func (s *Service) StartRegistration(ctx context.Context, input RegistrationInput) (Registration, error) {
+	a, err := s.owned(ctx, input.AgentID, input.OperatorUserID)
+	if err != nil {
+		return Registration{}, err
+	}
+	if a.Status != StatusActive {
+		return Registration{}, ErrAgentInactive
+	}
+	phone, err := identity.NormalizePhone(input.Phone)
+	if err != nil {
+		return Registration{}, err
+	}
+	if _, err := s.users.LookupByPhone(ctx, phone); err == nil {
+		return Registration{}, identity.ErrUserExists
+	} else if !errors.Is(err, identity.ErrUserNotFound) {
+		return Registration{}, err
+	}
+	kyc := input.kyc()
+	if err := kyc.Validate(); err != nil {
+		return Registration{}, err
+	}
+
+	sent, err := s.otps.Send(ctx, otp.SendInput{Purpose: otp.PurposeAgentRegistration, Phone: phone})
+	if err != nil {
+		return Registration{}, err
+	}
+	now := s.now()
+	reg := Registration{
+		ID:               uuid.NewString(),
+		AgentID:          a.ID,
+		OperatorUserID:   input.OperatorUserID,
+		Phone:            phone,
+		FullName:         kyc.FullName,
+		DocumentType:     kyc.DocumentType,
+		DocumentNumber:   kyc.DocumentNumber,
+		DocumentFrontRef: kyc.DocumentFrontRef,
+		DocumentBackRef:  kyc.DocumentBackRef,
+		SelfieRef:        kyc.SelfieRef,
+		Status:           RegistrationPending,
+		ExpiresAt:        sent.ExpiresAt,
+		CreatedAt:        now,
+	}
+	if err := s.repo.CreateRegistration(ctx, reg); err != nil {
+		return Registration{}, err
+	}
+	return reg, nil
+}
+
+// ConfirmRegistrationInput completes an assisted registration on the agent's device.
+type ConfirmRegistrationInput struct {
+	AgentID        string
+	OperatorUserID string
+	RegistrationID string
+	OTP            string
+	// PIN is chosen by the customer.
+	PIN string
+}
+
+// ConfirmRegistration checks the customer's OTP, creates the user with the captured KYC,
+// links it to the agent and provisions the customer's wallet. It can be retried after a
+// failure part way: the customer this agent already created is reused once their PIN
+// matches, since the OTP was used up by the first attempt, and so is their wallet.
+func (s *Service) ConfirmRegistration(ctx context.Context, input ConfirmRegistrationInput) (Registration, identity.User, error) {
+	a, err := s.owned(ctx, input.AgentID, input.OperatorUserID)
+	if err != nil {
+		return Registration{}, identity.User{}, err
+	}
+	if a.Status != StatusActive {
+		return Registration{}, identity.User{}, ErrAgentInactive
+	}
+	reg, err := s.repo.GetRegistration(ctx, input.RegistrationID)
+	if err != nil {
+		return Registration{}, identity.User{}, err
+	}
+	if reg.AgentID != a.ID {
+		return Registration{}, identity.User{}, ErrRegistrationNotFound
+	}
+	if reg.Status != RegistrationPending {
+		return Registration{}, identity.User{}, ErrInvalidOTP
+	}
+	if !s.now().Before(reg.ExpiresAt) {
+		_ = s.repo.SetRegistrationStatus(ctx, reg.ID, RegistrationPending, RegistrationVoid)
+		return Registration{}, identity.User{}, ErrInvalidOTP
+	}
+	existing, err := s.users.LookupByPhone(ctx, reg.Phone)
+	switch {
+	case err == nil && existing.OnboardingAgentID != a.ID:
+		return Registration{}, identity.User{}, identity.ErrUserExists
+	case errors.Is(err, identity.ErrUserNotFound):
+		// The PIN is checked first so a rejected one does not use up the code and the
+		// customer can pick another.
+		if err := identity.ValidateNewPIN(input.PIN); err != nil {
+			return Registration{}, identity.User{}, err
+		}
+		if err := s.otps.Verify(ctx, otp.PurposeAgentRegistration, reg.Phone, input.OTP); err != nil {
+			if errors.Is(err, otp.ErrInvalidCode) {
+				return Registration{}, identity.User{}, ErrInvalidOTP
+			}
+			return Registration{}, identity.User{}, err
+		}
+	case err != nil:
+		return Registration{}, identity.User{}, err
+	}
+
+	user, err := s.users.RegisterAssisted(ctx, identity.AssistedRegistration{
+		Credentials:       identity.Credentials{Phone: reg.Phone, PIN: input.PIN},
+		OnboardingAgentID: a.ID,
+		KYC: identity.KYCProfile{
+			FullName:         reg.FullName,
+			DocumentType:     reg.DocumentType,
+			DocumentNumber:   reg.DocumentNumber,
+			DocumentFrontRef: reg.DocumentFrontRef,
+			DocumentBackRef:  reg.DocumentBackRef,
+			SelfieRef:        reg.SelfieRef,
+		},
+	})
+	if err != nil {
+		return Registration{}, identity.User{}, err
+	}
+	owned, err := s.wallets.ListByOwner(ctx, user.ID)
+	if err != nil {
+		return Registration{}, identity.User{}, err
+	}
+	var w wallet.Wallet
+	if len(owned) > 0 {
+		w = owned[0]
+	} else if w, err = s.wallets.Create(ctx, wallet.CreateInput{OwnerID: user.ID, Currency: "XAF"}); err != nil {
+		return Registration{}, identity.User{}, err
+	}
+	now := s.now()
+	if err := s.repo.CompleteRegistration(ctx, reg.ID, user.ID, w.ID, now); err != nil {
+		return Registration{}, identity.User{}, err
+	}
+	reg.Status = RegistrationCompleted
+	reg.UserID, reg.WalletID, reg.CompletedAt = user.ID, w.ID, now
+	return reg, user, nil
+}
+
+// Registrations lists the customers an agent has registered, including pending attempts.
+func (s *Service) Registrations(ctx context.Context, agentID, requestorUserID string, limit int) ([]Registration, error) {
+	a, err := s.owned(ctx, agentID, requestorUserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListRegistrations(ctx, a.ID, limit)
+}
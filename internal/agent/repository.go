@@ -0,0 +1,676 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists agents, their cash transactions and customers' withdrawal codes.
+type Repository interface {
+	Create(ctx context.Context, a Agent) error
+	Get(ctx context.Context, id string) (Agent, error)
+	GetByCode(ctx context.Context, code string) (Agent, error)
+	ListByOwner(ctx context.Context, ownerUserID string) ([]Agent, error)
+	UpdateStatus(ctx context.Context, id, status string) error
+	UpdateThresholds(ctx context.Context, id string, minFloat, maxFloat int64) error
+	SetSuperAgent(ctx context.Context, id, superAgentID string) error
+	// SetLiquidityState moves an agent's liquidity state, returning false when another
+	// writer changed it first.
+	SetLiquidityState(ctx context.Context, id, fromState, state string) (bool, error)
+	// FloatWallets maps the float wallet ID of every agent to the agent ID.
+	FloatWallets(ctx context.Context) (map[string]string, error)
+
+	CreateTransaction(ctx context.Context, tx CashTransaction) error
+	GetTransaction(ctx context.Context, id string) (CashTransaction, error)
+	// FindTransaction returns the cash transaction an agent recorded with a client transaction ID.
+	FindTransaction(ctx context.Context, agentID, clientTxID string) (CashTransaction, error)
+	ListByAgent(ctx context.Context, agentID string, limit int) ([]CashTransaction, error)
+	ListByCustomer(ctx context.Context, userID string, limit int) ([]CashTransaction, error)
+	// ListByAgentBetween returns an agent's cash transactions created in [from, to), oldest first.
+	ListByAgentBetween(ctx context.Context, agentID string, from, to time.Time) ([]CashTransaction, error)
+	// ListUnpostedCommissions returns transactions created before a cutoff whose commission has
+	// not been credited yet, oldest first.
+	ListUnpostedCommissions(ctx context.Context, before time.Time, limit int) ([]CashTransaction, error)
+	MarkCommissionPosted(ctx context.Context, id, ledgerTxID string) error
+
+	// CreateWithdrawalCode stores a code and voids any other active code of the same user.
+	CreateWithdrawalCode(ctx context.Context, code WithdrawalCode) error
+	ActiveWithdrawalCode(ctx context.Context, userID string) (WithdrawalCode, error)
+	// RecordCodeAttempt counts a failed redemption and returns the new attempt count.
+	RecordCodeAttempt(ctx context.Context, id string) (int, error)
+	// SetCodeStatus moves a code from one status to another, failing with
+	// ErrInvalidWithdrawalCode when it is no longer in fromStatus.
+	SetCodeStatus(ctx context.Context, id, fromStatus, status string) error
+
+	// CreateRegistration stores a pending registration and voids other pending
+	// registrations of the same phone number.
+	CreateRegistration(ctx context.Context, r Registration) error
+	GetRegistration(ctx context.Context, id string) (Registration, error)
+	ListRegistrations(ctx context.Context, agentID string, limit int) ([]Registration, error)
+	// SetRegistrationStatus moves a registration out of fromStatus, failing with
+	// ErrInvalidOTP when it is no longer there.
+	SetRegistrationStatus(ctx context.Context, id, fromStatus, status string) error
+	// CompleteRegistration records the created user and wallet of a pending registration.
+	CompleteRegistration(ctx context.Context, id, userID, walletID string, at time.Time) error
+
+	CreateRebalance(ctx context.Context, r RebalanceRequest) error
+	GetRebalance(ctx context.Context, id string) (RebalanceRequest, error)
+	ListRebalances(ctx context.Context, filter RebalanceFilter) ([]RebalanceRequest, error)
+	// TransitionRebalance stores r if the request is still in fromStatus, failing with
+	// ErrNotPending otherwise.
+	TransitionRebalance(ctx context.Context, fromStatus string, r RebalanceRequest) error
+}
+
+// RebalanceFilter narrows rebalancing request listings; empty fields match everything.
+type RebalanceFilter struct {
+	AgentID      string
+	SuperAgentID string
+	Source       string
+	Status       string
+	Limit        int
+}
+
+// PostgresRepository stores agents in PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed agent repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const agentColumns = `id::text, agent_code, owner_user_id::text, business_name, location, city, float_wallet_id::text, status,
+        min_float, max_float, liquidity_state, COALESCE(super_agent_id::text, ''), created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAgent(row rowScanner) (Agent, error) {
+	var a Agent
+	err := row.Scan(&a.ID, &a.AgentCode, &a.OwnerUserID, &a.BusinessName, &a.Location, &a.City, &a.FloatWalletID, &a.Status,
+		&a.MinFloat, &a.MaxFloat, &a.LiquidityState, &a.SuperAgentID, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Agent{}, ErrAgentNotFound
+		}
+		return Agent{}, err
+	}
+	a.CreatedAt = a.CreatedAt.UTC()
+	return a, nil
+}
+
+// Create inserts an agent.
+func (r *PostgresRepository) Create(ctx context.Context, a Agent) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO agents
+        (id, agent_code, owner_user_id, business_name, location, city, float_wallet_id, status,
+         min_float, max_float, liquidity_state, super_agent_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		a.ID, a.AgentCode, a.OwnerUserID, a.BusinessName, a.Location, a.City, a.FloatWalletID, a.Status,
+		a.MinFloat, a.MaxFloat, a.LiquidityState, nullable(a.SuperAgentID), a.CreatedAt.UTC())
+	return err
+}
+
+// Get fetches an agent by ID.
+func (r *PostgresRepository) Get(ctx context.Context, id string) (Agent, error) {
+	agentID, err := uuid.Parse(id)
+	if err != nil {
+		return Agent{}, ErrAgentNotFound
+	}
+	return scanAgent(r.db.QueryRow(ctx, `SELECT `+agentColumns+` FROM agents WHERE id = $1`, agentID))
+}
+
+// GetByCode fetches an agent by its agent code.
+func (r *PostgresRepository) GetByCode(ctx context.Context, code string) (Agent, error) {
+	return scanAgent(r.db.QueryRow(ctx, `SELECT `+agentColumns+` FROM agents WHERE agent_code = $1`, code))
+}
+
+// ListByOwner returns agents operated by a user.
+func (r *PostgresRepository) ListByOwner(ctx context.Context, ownerUserID string) ([]Agent, error) {
+	ownerID, err := uuid.Parse(ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+agentColumns+` FROM agents WHERE owner_user_id = $1 ORDER BY created_at`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Agent
+	for rows.Next() {
+		a, err := scanAgent(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// UpdateStatus sets an agent status.
+func (r *PostgresRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	agentID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrAgentNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE agents SET status = $1 WHERE id = $2`, status, agentID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrAgentNotFound
+	}
+	return nil
+}
+
+// UpdateThresholds sets an agent's float alert thresholds.
+func (r *PostgresRepository) UpdateThresholds(ctx context.Context, id string, minFloat, maxFloat int64) error {
+	agentID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrAgentNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE agents SET min_float = $1, max_float = $2 WHERE id = $3`, minFloat, maxFloat, agentID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrAgentNotFound
+	}
+	return nil
+}
+
+// SetSuperAgent attaches an agent to a super-agent, or detaches it when superAgentID is empty.
+func (r *PostgresRepository) SetSuperAgent(ctx context.Context, id, superAgentID string) error {
+	agentID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrAgentNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE agents SET super_agent_id = $1 WHERE id = $2`, nullable(superAgentID), agentID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrAgentNotFound
+	}
+	return nil
+}
+
+// SetLiquidityState moves an agent's liquidity state if it is still fromState.
+func (r *PostgresRepository) SetLiquidityState(ctx context.Context, id, fromState, state string) (bool, error) {
+	agentID, err := uuid.Parse(id)
+	if err != nil {
+		return false, ErrAgentNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE agents SET liquidity_state = $1 WHERE id = $2 AND liquidity_state = $3`, state, agentID, fromState)
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() == 1, nil
+}
+
+// FloatWallets maps every agent's float wallet ID to the agent ID.
+func (r *PostgresRepository) FloatWallets(ctx context.Context) (map[string]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT float_wallet_id::text, id::text FROM agents`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[string]string)
+	for rows.Next() {
+		var walletID, agentID string
+		if err := rows.Scan(&walletID, &agentID); err != nil {
+			return nil, err
+		}
+		out[walletID] = agentID
+	}
+	return out, rows.Err()
+}
+
+const transactionColumns = `t.id::text, t.kind, t.agent_id::text, a.agent_code, a.business_name, t.customer_user_id::text,
+        t.customer_phone, t.customer_wallet_id::text, t.amount, t.method, t.client_tx_id, t.transaction_id::text,
+        t.commission, COALESCE(t.commission_tx_id::text, ''), t.created_at`
+
+const transactionFrom = ` FROM agent_cash_transactions t JOIN agents a ON a.id = t.agent_id`
+
+func scanTransaction(row rowScanner) (CashTransaction, error) {
+	var t CashTransaction
+	err := row.Scan(&t.ID, &t.Kind, &t.AgentID, &t.AgentCode, &t.AgentName, &t.CustomerUserID,
+		&t.CustomerPhone, &t.CustomerWalletID, &t.Amount, &t.Method, &t.ClientTxID, &t.TransactionID,
+		&t.Commission, &t.CommissionTxID, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return CashTransaction{}, ErrTransactionNotFound
+		}
+		return CashTransaction{}, err
+	}
+	t.CreatedAt = t.CreatedAt.UTC()
+	return t, nil
+}
+
+func collectTransactions(rows pgx.Rows) ([]CashTransaction, error) {
+	defer rows.Close()
+	var out []CashTransaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// CreateTransaction stores a cash-in or cash-out receipt.
+func (r *PostgresRepository) CreateTransaction(ctx context.Context, t CashTransaction) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO agent_cash_transactions
+        (id, kind, agent_id, customer_user_id, customer_phone, customer_wallet_id, amount, method, client_tx_id,
+         transaction_id, commission, commission_tx_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		t.ID, t.Kind, t.AgentID, t.CustomerUserID, t.CustomerPhone, t.CustomerWalletID, t.Amount, t.Method,
+		t.ClientTxID, t.TransactionID, t.Commission, nullable(t.CommissionTxID), t.CreatedAt.UTC())
+	return err
+}
+
+// GetTransaction fetches a cash transaction by ID.
+func (r *PostgresRepository) GetTransaction(ctx context.Context, id string) (CashTransaction, error) {
+	txID, err := uuid.Parse(id)
+	if err != nil {
+		return CashTransaction{}, ErrTransactionNotFound
+	}
+	return scanTransaction(r.db.QueryRow(ctx, `SELECT `+transactionColumns+transactionFrom+` WHERE t.id = $1`, txID))
+}
+
+// FindTransaction fetches an agent's cash transaction by client transaction ID.
+func (r *PostgresRepository) FindTransaction(ctx context.Context, agentID, clientTxID string) (CashTransaction, error) {
+	id, err := uuid.Parse(agentID)
+	if err != nil {
+		return CashTransaction{}, ErrTransactionNotFound
+	}
+	return scanTransaction(r.db.QueryRow(ctx, `SELECT `+transactionColumns+transactionFrom+`
+        WHERE t.agent_id = $1 AND t.client_tx_id = $2`, id, clientTxID))
+}
+
+// ListByAgent returns an agent's most recent cash transactions.
+func (r *PostgresRepository) ListByAgent(ctx context.Context, agentID string, limit int) ([]CashTransaction, error) {
+	id, err := uuid.Parse(agentID)
+	if err != nil {
+		return nil, ErrAgentNotFound
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+transactionColumns+transactionFrom+`
+        WHERE t.agent_id = $1 ORDER BY t.created_at DESC LIMIT $2`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectTransactions(rows)
+}
+
+// ListByCustomer returns a customer's most recent cash transactions.
+func (r *PostgresRepository) ListByCustomer(ctx context.Context, userID string, limit int) ([]CashTransaction, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+transactionColumns+transactionFrom+`
+        WHERE t.customer_user_id = $1 ORDER BY t.created_at DESC LIMIT $2`, uid, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectTransactions(rows)
+}
+
+// ListByAgentBetween returns an agent's cash transactions in a time window, oldest first.
+func (r *PostgresRepository) ListByAgentBetween(ctx context.Context, agentID string, from, to time.Time) ([]CashTransaction, error) {
+	id, err := uuid.Parse(agentID)
+	if err != nil {
+		return nil, ErrAgentNotFound
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+transactionColumns+transactionFrom+`
+        WHERE t.agent_id = $1 AND t.created_at >= $2 AND t.created_at < $3
+        ORDER BY t.created_at`, id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return collectTransactions(rows)
+}
+
+// ListUnpostedCommissions returns transactions whose commission is still owed.
+func (r *PostgresRepository) ListUnpostedCommissions(ctx context.Context, before time.Time, limit int) ([]CashTransaction, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+transactionColumns+transactionFrom+`
+        WHERE t.commission > 0 AND t.commission_tx_id IS NULL AND t.created_at < $1
+        ORDER BY t.created_at LIMIT $2`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return collectTransactions(rows)
+}
+
+// MarkCommissionPosted records the ledger transaction that credited the commission.
+func (r *PostgresRepository) MarkCommissionPosted(ctx context.Context, id, ledgerTxID string) error {
+	txID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrTransactionNotFound
+	}
+	_, err = r.db.Exec(ctx, `UPDATE agent_cash_transactions SET commission_tx_id = $1
+        WHERE id = $2 AND commission_tx_id IS NULL`, ledgerTxID, txID)
+	return err
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+const codeColumns = `id::text, user_id::text, wallet_id::text, code_hash, amount, status, attempts, expires_at, created_at`
+
+func scanCode(row rowScanner) (WithdrawalCode, error) {
+	var c WithdrawalCode
+	err := row.Scan(&c.ID, &c.UserID, &c.WalletID, &c.CodeHash, &c.Amount, &c.Status, &c.Attempts, &c.ExpiresAt, &c.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return WithdrawalCode{}, ErrCodeNotFound
+		}
+		return WithdrawalCode{}, err
+	}
+	c.ExpiresAt = c.ExpiresAt.UTC()
+	c.CreatedAt = c.CreatedAt.UTC()
+	return c, nil
+}
+
+// CreateWithdrawalCode voids the user's active code, if any, and stores the new one.
+func (r *PostgresRepository) CreateWithdrawalCode(ctx context.Context, c WithdrawalCode) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `UPDATE agent_withdrawal_codes SET status = $1
+        WHERE user_id = $2 AND status = $3`, CodeVoid, c.UserID, CodeActive); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO agent_withdrawal_codes
+        (id, user_id, wallet_id, code_hash, amount, status, attempts, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		c.ID, c.UserID, c.WalletID, c.CodeHash, c.Amount, c.Status, c.Attempts, c.ExpiresAt.UTC(), c.CreatedAt.UTC()); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ActiveWithdrawalCode fetches the user's active withdrawal code.
+func (r *PostgresRepository) ActiveWithdrawalCode(ctx context.Context, userID string) (WithdrawalCode, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return WithdrawalCode{}, ErrCodeNotFound
+	}
+	return scanCode(r.db.QueryRow(ctx, `SELECT `+codeColumns+` FROM agent_withdrawal_codes
+        WHERE user_id = $1 AND status = $2`, uid, CodeActive))
+}
+
+// RecordCodeAttempt increments a code's failed attempt counter.
+func (r *PostgresRepository) RecordCodeAttempt(ctx context.Context, id string) (int, error) {
+	codeID, err := uuid.Parse(id)
+	if err != nil {
+		return 0, ErrCodeNotFound
+	}
+	var attempts int
+	err = r.db.QueryRow(ctx, `UPDATE agent_withdrawal_codes SET attempts = attempts + 1
+        WHERE id = $1 RETURNING attempts`, codeID).Scan(&attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrCodeNotFound
+	}
+	return attempts, err
+}
+
+// SetCodeStatus moves a code between statuses if it is still in fromStatus.
+func (r *PostgresRepository) SetCodeStatus(ctx context.Context, id, fromStatus, status string) error {
+	codeID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrCodeNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE agent_withdrawal_codes SET status = $1
+        WHERE id = $2 AND status = $3`, status, codeID, fromStatus)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrInvalidWithdrawalCode
+	}
+	return nil
+}
+
+const rebalanceColumns = `id::text, agent_id::text, source, COALESCE(super_agent_id::text, ''), direction, amount, note, status,
+        requested_by::text, COALESCE(decided_by::text, ''), decision_note, COALESCE(transaction_id::text, ''), created_at,
+        COALESCE(decided_at, 'epoch'::timestamptz)`
+
+func scanRebalance(row rowScanner) (RebalanceRequest, error) {
+	var rb RebalanceRequest
+	err := row.Scan(&rb.ID, &rb.AgentID, &rb.Source, &rb.SuperAgentID, &rb.Direction, &rb.Amount, &rb.Note, &rb.Status,
+		&rb.RequestedBy, &rb.DecidedBy, &rb.DecisionNote, &rb.TransactionID, &rb.CreatedAt, &rb.DecidedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return RebalanceRequest{}, ErrRebalanceNotFound
+		}
+		return RebalanceRequest{}, err
+	}
+	rb.CreatedAt = rb.CreatedAt.UTC()
+	if rb.DecidedAt.Unix() == 0 {
+		rb.DecidedAt = time.Time{}
+	} else {
+		rb.DecidedAt = rb.DecidedAt.UTC()
+	}
+	return rb, nil
+}
+
+// filterUUID parses an optional ID filter. An empty filter becomes NULL so it
+// matches everything; ok is false when the filter cannot match any row.
+func filterUUID(s string) (any, bool) {
+	if s == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return nil, false
+	}
+	return id, true
+}
+
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// CreateRebalance stores a rebalancing request.
+func (r *PostgresRepository) CreateRebalance(ctx context.Context, rb RebalanceRequest) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO agent_rebalance_requests
+        (id, agent_id, source, super_agent_id, direction, amount, note, status, requested_by, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		rb.ID, rb.AgentID, rb.Source, nullable(rb.SuperAgentID), rb.Direction, rb.Amount, rb.Note, rb.Status,
+		rb.RequestedBy, rb.CreatedAt.UTC())
+	return err
+}
+
+// GetRebalance fetches a rebalancing request by ID.
+func (r *PostgresRepository) GetRebalance(ctx context.Context, id string) (RebalanceRequest, error) {
+	rbID, err := uuid.Parse(id)
+	if err != nil {
+		return RebalanceRequest{}, ErrRebalanceNotFound
+	}
+	return scanRebalance(r.db.QueryRow(ctx, `SELECT `+rebalanceColumns+` FROM agent_rebalance_requests WHERE id = $1`, rbID))
+}
+
+// ListRebalances returns matching rebalancing requests, newest first.
+func (r *PostgresRepository) ListRebalances(ctx context.Context, f RebalanceFilter) ([]RebalanceRequest, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	agentID, ok := filterUUID(f.AgentID)
+	if !ok {
+		return nil, nil
+	}
+	superAgentID, ok := filterUUID(f.SuperAgentID)
+	if !ok {
+		return nil, nil
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+rebalanceColumns+` FROM agent_rebalance_requests
+        WHERE ($1::uuid IS NULL OR agent_id = $1) AND ($2::uuid IS NULL OR super_agent_id = $2)
+          AND ($3 = '' OR source = $3) AND ($4 = '' OR status = $4)
+        ORDER BY created_at DESC LIMIT $5`, agentID, superAgentID, f.Source, f.Status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []RebalanceRequest
+	for rows.Next() {
+		rb, err := scanRebalance(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rb)
+	}
+	return out, rows.Err()
+}
+
+// TransitionRebalance records a decision if the request is still in fromStatus.
+func (r *PostgresRepository) TransitionRebalance(ctx context.Context, fromStatus string, rb RebalanceRequest) error {
+	id, err := uuid.Parse(rb.ID)
+	if err != nil {
+		return ErrRebalanceNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE agent_rebalance_requests
+        SET status = $1, decided_by = $2, decision_note = $3, transaction_id = $4, decided_at = $5
+        WHERE id = $6 AND status = $7`,
+		rb.Status, nullable(rb.DecidedBy), rb.DecisionNote, nullable(rb.TransactionID), nullableTime(rb.DecidedAt), id, fromStatus)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotPending
+	}
+	return nil
+}
+
+const registrationColumns = `id::text, agent_id::text, operator_user_id::text, phone, full_name, document_type, document_number,
+        document_front_ref, document_back_ref, selfie_ref, status, COALESCE(user_id::text, ''),
+        COALESCE(wallet_id::text, ''), expires_at, created_at, COALESCE(completed_at, 'epoch'::timestamptz)`
+
+func scanRegistration(row rowScanner) (Registration, error) {
+	var reg Registration
+	err := row.Scan(&reg.ID, &reg.AgentID, &reg.OperatorUserID, &reg.Phone, &reg.FullName, &reg.DocumentType, &reg.DocumentNumber,
+		&reg.DocumentFrontRef, &reg.DocumentBackRef, &reg.SelfieRef, &reg.Status, &reg.UserID,
+		&reg.WalletID, &reg.ExpiresAt, &reg.CreatedAt, &reg.CompletedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Registration{}, ErrRegistrationNotFound
+		}
+		return Registration{}, err
+	}
+	reg.ExpiresAt = reg.ExpiresAt.UTC()
+	reg.CreatedAt = reg.CreatedAt.UTC()
+	if reg.CompletedAt.Unix() == 0 {
+		reg.CompletedAt = time.Time{}
+	} else {
+		reg.CompletedAt = reg.CompletedAt.UTC()
+	}
+	return reg, nil
+}
+
+// CreateRegistration stores a pending registration, voiding older pending ones for the phone.
+func (r *PostgresRepository) CreateRegistration(ctx context.Context, reg Registration) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if _, err := tx.Exec(ctx, `UPDATE agent_registrations SET status = $1 WHERE phone = $2 AND status = $3`,
+		RegistrationVoid, reg.Phone, RegistrationPending); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO agent_registrations
+        (id, agent_id, operator_user_id, phone, full_name, document_type, document_number, document_front_ref,
+         document_back_ref, selfie_ref, status, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`,
+		reg.ID, reg.AgentID, reg.OperatorUserID, reg.Phone, reg.FullName, reg.DocumentType, reg.DocumentNumber, reg.DocumentFrontRef,
+		reg.DocumentBackRef, reg.SelfieRef, reg.Status, reg.ExpiresAt.UTC(), reg.CreatedAt.UTC()); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetRegistration fetches a registration by ID.
+func (r *PostgresRepository) GetRegistration(ctx context.Context, id string) (Registration, error) {
+	regID, err := uuid.Parse(id)
+	if err != nil {
+		return Registration{}, ErrRegistrationNotFound
+	}
+	return scanRegistration(r.db.QueryRow(ctx, `SELECT `+registrationColumns+` FROM agent_registrations WHERE id = $1`, regID))
+}
+
+// ListRegistrations returns an agent's registrations, newest first.
+func (r *PostgresRepository) ListRegistrations(ctx context.Context, agentID string, limit int) ([]Registration, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	id, err := uuid.Parse(agentID)
+	if err != nil {
+		return nil, ErrAgentNotFound
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+registrationColumns+` FROM agent_registrations
+        WHERE agent_id = $1 ORDER BY created_at DESC LIMIT $2`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Registration
+	for rows.Next() {
+		reg, err := scanRegistration(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, reg)
+	}
+	return out, rows.Err()
+}
+
+// SetRegistrationStatus moves a registration out of fromStatus.
+func (r *PostgresRepository) SetRegistrationStatus(ctx context.Context, id, fromStatus, status string) error {
+	regID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrRegistrationNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE agent_registrations SET status = $1 WHERE id = $2 AND status = $3`, status, regID, fromStatus)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+// CompleteRegistration records the user and wallet created for a pending registration.
+func (r *PostgresRepository) CompleteRegistration(ctx context.Context, id, userID, walletID string, at time.Time) error {
+	regID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrRegistrationNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE agent_registrations SET status = $1, user_id = $2, wallet_id = $3, completed_at = $4
+        WHERE id = $5 AND status = $6`, RegistrationCompleted, userID, nullable(walletID), at.UTC(), regID, RegistrationPending)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrInvalidOTP
+	}
+	return nil
+}
@@ -0,0 +1,608 @@
+package agent
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/clock"
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/otp"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const (
+	agentCodeDigits      = 6
+	withdrawalCodeDigits = 6
+	defaultCity          = "Brazzaville"
+	commissionKind       = "agent_commission"
+	commissionBatchSize  = 1_000
+
+	// WithdrawalCodeTTL bounds how long a customer's withdrawal code can be redeemed.
+	WithdrawalCodeTTL = 30 * time.Minute
+	// MaxCodeAttempts voids a withdrawal code after this many wrong guesses.
+	MaxCodeAttempts = 3
+)
+
+// Service manages the agent network and agent-assisted cash-in and cash-out.
+type Service struct {
+	repo     Repository
+	ledger   ledger.Ledger
+	wallets  *wallet.Service
+	users    *identity.Service
+	otps     *otp.Service
+	notifier notification.Notifier
+	guard    *guard.Guard
+	// commissions prices each cash transaction; commissionMode says when it is credited.
+	commissions    CommissionSchedule
+	commissionMode string
+	// floats maps float wallet ledger accounts to agent IDs so the liquidity watcher can
+	// recognise agent postings without a database lookup.
+	floatsMu sync.RWMutex
+	floats   map[string]string
+	now      func() time.Time
+}
+
+// NewService builds an agent service, ensuring the commission expense and bank float
+// accounts exist.
+func NewService(ctx context.Context, repo Repository, ledgerBackend ledger.Ledger, wallets *wallet.Service, users *identity.Service, otps *otp.Service, notifier notification.Notifier, outgoing *guard.Guard, commissions CommissionSchedule, commissionMode string) (*Service, error) {
+	switch commissionMode {
+	case CommissionRealtime, CommissionDaily:
+	default:
+		return nil, fmt.Errorf("unknown commission mode %q", commissionMode)
+	}
+	if err := commissions.Validate(); err != nil {
+		return nil, err
+	}
+	for _, code := range []string{CommissionExpenseAccountCode, BankFloatAccountCode} {
+		if err := ledgerBackend.EnsureAccount(ctx, code); err != nil {
+			return nil, err
+		}
+	}
+	s := &Service{
+		repo:           repo,
+		ledger:         ledgerBackend,
+		wallets:        wallets,
+		users:          users,
+		otps:           otps,
+		notifier:       notifier,
+		guard:          outgoing,
+		commissions:    commissions,
+		commissionMode: commissionMode,
+		floats:         make(map[string]string),
+		now:            func() time.Time { return time.Now().UTC() },
+	}
+	if err := s.refreshFloats(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// OnboardInput captures an agent application.
+type OnboardInput struct {
+	OwnerUserID  string
+	BusinessName string
+	Location     string
+	// City defaults to Brazzaville.
+	City string
+}
+
+// Onboard registers a pending agent with a dedicated float wallet. The agent can serve
+// customers once the back office activates it.
+func (s *Service) Onboard(ctx context.Context, input OnboardInput) (Agent, error) {
+	name := strings.TrimSpace(input.BusinessName)
+	if name == "" {
+		return Agent{}, fmt.Errorf("business name is required")
+	}
+	location := strings.TrimSpace(input.Location)
+	if location == "" {
+		return Agent{}, fmt.Errorf("location is required")
+	}
+	city := strings.TrimSpace(input.City)
+	if city == "" {
+		city = defaultCity
+	}
+
+	float, err := s.wallets.Create(ctx, wallet.CreateInput{OwnerID: input.OwnerUserID, Name: name + " float"})
+	if err != nil {
+		return Agent{}, err
+	}
+	code, err := s.newAgentCode(ctx)
+	if err != nil {
+		return Agent{}, err
+	}
+	id := uuid.NewString()
+	if err := s.ledger.EnsureAccount(ctx, CommissionAccountCode(id)); err != nil {
+		return Agent{}, err
+	}
+	a := Agent{
+		ID:             id,
+		AgentCode:      code,
+		OwnerUserID:    input.OwnerUserID,
+		BusinessName:   name,
+		Location:       location,
+		City:           city,
+		FloatWalletID:  float.ID,
+		Status:         StatusPending,
+		LiquidityState: LiquidityOK,
+		CreatedAt:      s.now(),
+	}
+	if err := s.repo.Create(ctx, a); err != nil {
+		return Agent{}, err
+	}
+	s.watchFloat(float.ID, a.ID)
+	return a, nil
+}
+
+func (s *Service) newAgentCode(ctx context.Context) (string, error) {
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := randomDigits(agentCodeDigits)
+		if err != nil {
+			return "", err
+		}
+		if _, err := s.repo.GetByCode(ctx, code); errors.Is(err, ErrAgentNotFound) {
+			return code, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("could not allocate an agent code")
+}
+
+func randomDigits(n int) (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < n; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	v, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", n, v.Int64()), nil
+}
+
+// Get fetches an agent by ID.
+func (s *Service) Get(ctx context.Context, id string) (Agent, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// GetByCode fetches an agent by its agent code.
+func (s *Service) GetByCode(ctx context.Context, code string) (Agent, error) {
+	return s.repo.GetByCode(ctx, strings.TrimSpace(code))
+}
+
+// ListByOwner returns agents operated by a user.
+func (s *Service) ListByOwner(ctx context.Context, ownerUserID string) ([]Agent, error) {
+	return s.repo.ListByOwner(ctx, ownerUserID)
+}
+
+// SetStatus changes an agent status (back-office use).
+func (s *Service) SetStatus(ctx context.Context, id, status string) (Agent, error) {
+	switch status {
+	case StatusActive, StatusSuspended, StatusClosed:
+	default:
+		return Agent{}, fmt.Errorf("unknown agent status %q", status)
+	}
+	a, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Agent{}, err
+	}
+	if a.Status == StatusClosed {
+		return Agent{}, fmt.Errorf("closed agents cannot change status")
+	}
+	if err := s.repo.UpdateStatus(ctx, id, status); err != nil {
+		return Agent{}, err
+	}
+	a.Status = status
+	return a, nil
+}
+
+func (s *Service) owned(ctx context.Context, agentID, requestorUserID string) (Agent, error) {
+	a, err := s.repo.Get(ctx, agentID)
+	if err != nil {
+		return Agent{}, err
+	}
+	if a.OwnerUserID != requestorUserID {
+		return Agent{}, ErrNotOwner
+	}
+	return a, nil
+}
+
+// CashInInput captures a customer handing cash to an agent for e-money.
+type CashInInput struct {
+	AgentID        string
+	OperatorUserID string
+	CustomerPhone  string
+	Amount         int64
+	ClientTxID     string
+}
+
+// CashIn moves e-money from the agent's float to the customer's primary wallet.
+func (s *Service) CashIn(ctx context.Context, input CashInInput) (CashTransaction, error) {
+	a, existing, err := s.begin(ctx, input.AgentID, input.OperatorUserID, input.Amount, &input.ClientTxID)
+	if err != nil || existing.ID != "" {
+		return existing, err
+	}
+	customer, customerWallet, err := s.customer(ctx, a, input.CustomerPhone)
+	if err != nil {
+		return CashTransaction{}, err
+	}
+	if err := customerWallet.CanCredit(); err != nil {
+		return CashTransaction{}, err
+	}
+	float, err := s.float(ctx, a)
+	if err != nil {
+		return CashTransaction{}, err
+	}
+	if err := float.CanDebit(); err != nil {
+		return CashTransaction{}, err
+	}
+	legs := []ledger.Posting{
+		{AccountCode: float.AccountCode, Amount: -input.Amount},
+		{AccountCode: customerWallet.AccountCode, Amount: input.Amount},
+	}
+	return s.record(ctx, a, KindCashIn, "", customer, customerWallet, input.Amount, input.ClientTxID, legs)
+}
+
+// CashOutInput captures a customer exchanging e-money for cash at an agent. The customer
+// confirms either by keying their PIN on the agent's device or with a withdrawal code.
+type CashOutInput struct {
+	AgentID        string
+	OperatorUserID string
+	CustomerPhone  string
+	Amount         int64
+	PIN            string
+	WithdrawalCode string
+	ClientTxID     string
+}
+
+// CashOut moves e-money from the customer's wallet to the agent's float once the customer
+// has confirmed the withdrawal.
+func (s *Service) CashOut(ctx context.Context, input CashOutInput) (CashTransaction, error) {
+	a, existing, err := s.begin(ctx, input.AgentID, input.OperatorUserID, input.Amount, &input.ClientTxID)
+	if err != nil || existing.ID != "" {
+		return existing, err
+	}
+	customer, err := s.users.LookupByPhone(ctx, input.CustomerPhone)
+	if err != nil {
+		return CashTransaction{}, err
+	}
+	if customer.ID == a.OwnerUserID {
+		return CashTransaction{}, fmt.Errorf("agents cannot serve their own account")
+	}
+	// The customer is screened, not the agent operating the till, and before a code is
+	// redeemed or a PIN checked, whichever way the customer confirms.
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: customer.ID, Op: risk.OpWithdrawal, Amount: input.Amount, Preauthorized: true}); err != nil {
+		return CashTransaction{}, err
+	}
+
+	var (
+		method         string
+		customerWallet wallet.Wallet
+		code           WithdrawalCode
+	)
+	switch {
+	case input.WithdrawalCode != "":
+		method = MethodWithdrawalCode
+		code, err = s.redeem(ctx, customer.ID, input.WithdrawalCode, input.Amount)
+		if err != nil {
+			return CashTransaction{}, err
+		}
+		customerWallet, err = s.wallets.Get(ctx, code.WalletID)
+	case input.PIN != "":
+		method = MethodPIN
+		if _, err := s.users.VerifyPIN(ctx, customer.ID, input.PIN); err != nil {
+			return CashTransaction{}, err
+		}
+		customerWallet, err = s.wallets.GetByOwner(ctx, customer.ID)
+	default:
+		return CashTransaction{}, ErrConfirmationRequired
+	}
+
+	var tx CashTransaction
+	if err == nil {
+		tx, err = s.cashOut(ctx, a, method, customer, customerWallet, input.Amount, input.ClientTxID)
+	}
+	if err != nil && code.ID != "" && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		// The withdrawal did not happen, so the customer keeps their code.
+		_ = s.repo.SetCodeStatus(ctx, code.ID, CodeRedeemed, CodeActive)
+	}
+	return tx, err
+}
+
+func (s *Service) cashOut(ctx context.Context, a Agent, method string, customer identity.User, customerWallet wallet.Wallet, amount int64, clientTxID string) (CashTransaction, error) {
+	if err := customerWallet.CanDebit(); err != nil {
+		return CashTransaction{}, err
+	}
+	float, err := s.float(ctx, a)
+	if err != nil {
+		return CashTransaction{}, err
+	}
+	if err := float.CanCredit(); err != nil {
+		return CashTransaction{}, ErrAgentInactive
+	}
+	legs := []ledger.Posting{
+		{AccountCode: customerWallet.AccountCode, Amount: -amount},
+		{AccountCode: float.AccountCode, Amount: amount},
+	}
+	return s.record(ctx, a, KindCashOut, method, customer, customerWallet, amount, clientTxID, legs)
+}
+
+// begin validates a cash request and returns the original receipt when the agent retries a
+// client transaction ID it already used.
+func (s *Service) begin(ctx context.Context, agentID, operatorUserID string, amount int64, clientTxID *string) (Agent, CashTransaction, error) {
+	if amount <= 0 {
+		return Agent{}, CashTransaction{}, fmt.Errorf("amount must be positive")
+	}
+	a, err := s.owned(ctx, agentID, operatorUserID)
+	if err != nil {
+		return Agent{}, CashTransaction{}, err
+	}
+	if *clientTxID == "" {
+		*clientTxID = uuid.NewString()
+	}
+	if existing, err := s.repo.FindTransaction(ctx, a.ID, *clientTxID); err == nil {
+		return a, existing, ledger.ErrDuplicateTransaction
+	} else if !errors.Is(err, ErrTransactionNotFound) {
+		return Agent{}, CashTransaction{}, err
+	}
+	if a.Status != StatusActive {
+		return Agent{}, CashTransaction{}, ErrAgentInactive
+	}
+	return a, CashTransaction{}, nil
+}
+
+func (s *Service) customer(ctx context.Context, a Agent, phone string) (identity.User, wallet.Wallet, error) {
+	customer, err := s.users.LookupByPhone(ctx, phone)
+	if err != nil {
+		return identity.User{}, wallet.Wallet{}, err
+	}
+	if customer.ID == a.OwnerUserID {
+		return identity.User{}, wallet.Wallet{}, fmt.Errorf("agents cannot serve their own account")
+	}
+	w, err := s.wallets.GetByOwner(ctx, customer.ID)
+	if err != nil {
+		return identity.User{}, wallet.Wallet{}, err
+	}
+	return customer, w, nil
+}
+
+func (s *Service) float(ctx context.Context, a Agent) (wallet.Wallet, error) {
+	return s.wallets.Get(ctx, a.FloatWalletID)
+}
+
+func (s *Service) record(ctx context.Context, a Agent, kind, method string, customer identity.User, customerWallet wallet.Wallet, amount int64, clientTxID string, legs []ledger.Posting) (CashTransaction, error) {
+	res, err := s.ledger.Post(ctx, kind, a.ID+":"+clientTxID, legs)
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		return CashTransaction{}, err
+	}
+	tx := CashTransaction{
+		ID:               uuid.NewString(),
+		Kind:             kind,
+		AgentID:          a.ID,
+		AgentCode:        a.AgentCode,
+		AgentName:        a.BusinessName,
+		CustomerUserID:   customer.ID,
+		CustomerPhone:    customer.Phone,
+		CustomerWalletID: customerWallet.ID,
+		Amount:           amount,
+		Method:           method,
+		ClientTxID:       clientTxID,
+		TransactionID:    res.TransactionID,
+		Commission:       s.commissions.Compute(kind, amount),
+		CreatedAt:        s.now(),
+	}
+	if err := s.repo.CreateTransaction(ctx, tx); err != nil {
+		return CashTransaction{}, err
+	}
+	if s.commissionMode == CommissionRealtime && tx.Commission > 0 {
+		// A failure here leaves the commission unposted; the daily sweep credits it.
+		if txID, err := s.postCommission(ctx, tx); err == nil {
+			tx.CommissionTxID = txID
+		}
+	}
+
+	if s.notifier != nil {
+		customerBody := fmt.Sprintf("Cash-in of %d at %s (agent %s). Ref %s", amount, a.BusinessName, a.AgentCode, tx.TransactionID)
+		agentBody := fmt.Sprintf("Cash-in of %d for %s. Ref %s", amount, customer.Phone, tx.TransactionID)
+		if kind == KindCashOut {
+			customerBody = fmt.Sprintf("Cash-out of %d at %s (agent %s). Ref %s", amount, a.BusinessName, a.AgentCode, tx.TransactionID)
+			agentBody = fmt.Sprintf("Cash-out of %d for %s. Ref %s", amount, customer.Phone, tx.TransactionID)
+		}
+		_ = s.notifier.Send(ctx, notification.Message{Kind: notification.KindAgentCash, Destination: customer.ID, Body: customerBody})
+		_ = s.notifier.Send(ctx, notification.Message{Kind: notification.KindAgentCash, Destination: a.OwnerUserID, Body: agentBody})
+	}
+	return tx, nil
+}
+
+// IssueCodeInput captures a customer's request for a withdrawal code.
+type IssueCodeInput struct {
+	UserID string
+	// WalletID defaults to the customer's primary wallet.
+	WalletID string
+	Amount   int64
+	// OTPCode confirms codes for at least the high-value amount; the cash-out itself is
+	// then not confirmed again.
+	OTPCode string
+}
+
+// IssueWithdrawalCode creates a single-use code for a cash-out of exactly Amount, replacing
+// any code the customer still holds. The plain code is returned once and never stored.
+func (s *Service) IssueWithdrawalCode(ctx context.Context, input IssueCodeInput) (WithdrawalCode, string, error) {
+	if input.Amount <= 0 {
+		return WithdrawalCode{}, "", fmt.Errorf("amount must be positive")
+	}
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: input.UserID, Op: risk.OpWithdrawal, Amount: input.Amount, OTPCode: input.OTPCode}); err != nil {
+		return WithdrawalCode{}, "", err
+	}
+	var (
+		w   wallet.Wallet
+		err error
+	)
+	if input.WalletID != "" {
+		w, err = s.wallets.Get(ctx, input.WalletID)
+	} else {
+		w, err = s.wallets.GetByOwner(ctx, input.UserID)
+	}
+	if err != nil {
+		return WithdrawalCode{}, "", err
+	}
+	if w.OwnerID != input.UserID {
+		return WithdrawalCode{}, "", wallet.ErrNotOwner
+	}
+	if err := w.CanDebit(); err != nil {
+		return WithdrawalCode{}, "", err
+	}
+
+	plain, err := randomDigits(withdrawalCodeDigits)
+	if err != nil {
+		return WithdrawalCode{}, "", err
+	}
+	now := s.now()
+	code := WithdrawalCode{
+		ID:        uuid.NewString(),
+		UserID:    input.UserID,
+		WalletID:  w.ID,
+		CodeHash:  hashCode(input.UserID, plain),
+		Amount:    input.Amount,
+		Status:    CodeActive,
+		ExpiresAt: now.Add(WithdrawalCodeTTL),
+		CreatedAt: now,
+	}
+	if err := s.repo.CreateWithdrawalCode(ctx, code); err != nil {
+		return WithdrawalCode{}, "", err
+	}
+	return code, plain, nil
+}
+
+// hashCode binds a code to its owner so equal codes of different customers hash differently.
+func hashCode(userID, code string) string {
+	sum := sha256.Sum256([]byte(userID + ":" + strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// redeem claims the customer's active withdrawal code for a cash-out.
+func (s *Service) redeem(ctx context.Context, userID, plain string, amount int64) (WithdrawalCode, error) {
+	code, err := s.repo.ActiveWithdrawalCode(ctx, userID)
+	if errors.Is(err, ErrCodeNotFound) {
+		return WithdrawalCode{}, ErrInvalidWithdrawalCode
+	} else if err != nil {
+		return WithdrawalCode{}, err
+	}
+	if !s.now().Before(code.ExpiresAt) {
+		_ = s.repo.SetCodeStatus(ctx, code.ID, CodeActive, CodeVoid)
+		return WithdrawalCode{}, ErrInvalidWithdrawalCode
+	}
+	if subtle.ConstantTimeCompare([]byte(code.CodeHash), []byte(hashCode(userID, plain))) != 1 {
+		attempts, err := s.repo.RecordCodeAttempt(ctx, code.ID)
+		if err != nil {
+			return WithdrawalCode{}, err
+		}
+		if attempts >= MaxCodeAttempts {
+			_ = s.repo.SetCodeStatus(ctx, code.ID, CodeActive, CodeVoid)
+		}
+		return WithdrawalCode{}, ErrInvalidWithdrawalCode
+	}
+	if code.Amount != amount {
+		return WithdrawalCode{}, fmt.Errorf("%w: code is for %d", ErrInvalidWithdrawalCode, code.Amount)
+	}
+	if err := s.repo.SetCodeStatus(ctx, code.ID, CodeActive, CodeRedeemed); err != nil {
+		return WithdrawalCode{}, err
+	}
+	return code, nil
+}
+
+// Receipt returns a cash transaction to either the customer or the agent operator.
+func (s *Service) Receipt(ctx context.Context, id, requestorUserID string) (CashTransaction, error) {
+	tx, err := s.repo.GetTransaction(ctx, id)
+	if err != nil {
+		return CashTransaction{}, err
+	}
+	if tx.CustomerUserID == requestorUserID {
+		return tx, nil
+	}
+	a, err := s.repo.Get(ctx, tx.AgentID)
+	if err != nil {
+		return CashTransaction{}, err
+	}
+	if a.OwnerUserID != requestorUserID {
+		return CashTransaction{}, ErrNotParty
+	}
+	return tx, nil
+}
+
+// Transactions lists recent cash transactions of an agent operated by the requestor.
+func (s *Service) Transactions(ctx context.Context, agentID, requestorUserID string, limit int) ([]CashTransaction, error) {
+	a, err := s.owned(ctx, agentID, requestorUserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListByAgent(ctx, a.ID, limit)
+}
+
+// CustomerTransactions lists a customer's recent cash-ins and cash-outs.
+func (s *Service) CustomerTransactions(ctx context.Context, userID string, limit int) ([]CashTransaction, error) {
+	return s.repo.ListByCustomer(ctx, userID, limit)
+}
+
+// postCommission credits a cash transaction's commission to the agent's commission account
+// and marks it as paid. The posting is keyed on the cash transaction alone, so the real-time
+// path and the sweep can both retry it without paying twice.
+func (s *Service) postCommission(ctx context.Context, tx CashTransaction) (string, error) {
+	account := CommissionAccountCode(tx.AgentID)
+	if err := s.ledger.EnsureAccount(ctx, account); err != nil {
+		return "", err
+	}
+	res, err := s.ledger.Post(ctx, commissionKind, "commission:"+tx.ID, []ledger.Posting{
+		{AccountCode: CommissionExpenseAccountCode, Amount: -tx.Commission, AllowOverdraft: true},
+		{AccountCode: account, Amount: tx.Commission},
+	})
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		return "", err
+	}
+	if err := s.repo.MarkCommissionPosted(ctx, tx.ID, res.TransactionID); err != nil {
+		return "", err
+	}
+	return res.TransactionID, nil
+}
+
+// RunDue credits commissions still owed for business days that closed before now: the
+// daily batch in daily mode, and a retry for failed real-time postings otherwise. Each cash
+// transaction gets its own posting. It returns how many postings it made.
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	owed, err := s.repo.ListUnpostedCommissions(ctx, businessDay(now), commissionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, tx := range owed {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		if _, err := s.postCommission(ctx, tx); err != nil {
+			return n, fmt.Errorf("agent %s commission for %s: %w", tx.AgentID, tx.ID, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// businessDay returns the start of the WAT calendar day containing t.
+func businessDay(t time.Time) time.Time {
+	local := t.In(clock.WAT)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, clock.WAT)
+}
@@ -0,0 +1,835 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/logging"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/otp"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []notification.Message
+}
+
+func (n *recordingNotifier) Send(_ context.Context, msg notification.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+// take returns and forgets the messages of the given kind.
+func (n *recordingNotifier) take(kind string) []notification.Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var out, rest []notification.Message
+	for _, m := range n.sent {
+		if m.Kind == kind {
+			out = append(out, m)
+		} else {
+			rest = append(rest, m)
+		}
+	}
+	n.sent = rest
+	return out
+}
+
+type fixture struct {
+	svc      *Service
+	led      ledger.Ledger
+	wallets  *wallet.Service
+	users    *identity.Service
+	notifier *recordingNotifier
+	swaps    *risk.StaticSIMSwapChecker
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	return newFixtureWithMode(t, CommissionRealtime)
+}
+
+func newFixtureWithMode(t *testing.T, mode string) fixture {
+	t.Helper()
+	led := ledger.NewObserved(ledger.NewInMemory())
+	wallets := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	notifier := &recordingNotifier{}
+	swaps := risk.NewStaticSIMSwapChecker()
+	risks := risk.NewService(risk.NewMemoryRepository(), swaps, users, nil, risk.DefaultPolicy(), logging.Discard())
+	otps := otp.NewService(otp.NewMemoryStore(), notifier, "test-secret")
+	svc, err := NewService(context.Background(), NewMemoryRepository(), led, wallets, users, otps, notifier, guard.New(risks, nil, nil, 0), DefaultCommissionSchedule(), mode)
+	if err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	led.Subscribe(svc.WatchBalances)
+	return fixture{svc: svc, led: led, wallets: wallets, users: users, notifier: notifier, swaps: swaps}
+}
+
+func (f fixture) user(t *testing.T, phone string, balance int64) (identity.User, wallet.Wallet) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := f.users.Register(ctx, identity.Credentials{Phone: phone, PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register %s: %v", phone, err)
+	}
+	w, err := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: u.ID})
+	if err != nil {
+		t.Fatalf("wallet: %v", err)
+	}
+	ledger.SeedBalance(f.led, w.AccountCode, balance)
+	return u, w
+}
+
+// agent onboards and activates an agent whose float holds the given balance.
+func (f fixture) agent(t *testing.T, phone string, float int64) (identity.User, Agent) {
+	t.Helper()
+	ctx := context.Background()
+	owner, _ := f.user(t, phone, 0)
+	a, err := f.svc.Onboard(ctx, OnboardInput{OwnerUserID: owner.ID, BusinessName: "Chez Mireille", Location: "Marché Total, Bacongo"})
+	if err != nil {
+		t.Fatalf("onboard: %v", err)
+	}
+	if a.Status != StatusPending || len(a.AgentCode) != agentCodeDigits || a.City != defaultCity {
+		t.Fatalf("unexpected agent: %+v", a)
+	}
+	if a, err = f.svc.SetStatus(ctx, a.ID, StatusActive); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	floatWallet, err := f.wallets.Get(ctx, a.FloatWalletID)
+	if err != nil {
+		t.Fatalf("float wallet: %v", err)
+	}
+	ledger.SeedBalance(f.led, floatWallet.AccountCode, float)
+	return owner, a
+}
+
+func (f fixture) balance(t *testing.T, walletID string) int64 {
+	t.Helper()
+	w, err := f.wallets.Get(context.Background(), walletID)
+	if err != nil {
+		t.Fatalf("wallet %s: %v", walletID, err)
+	}
+	bal, err := f.led.Balance(context.Background(), w.AccountCode)
+	if err != nil {
+		t.Fatalf("balance: %v", err)
+	}
+	return bal
+}
+
+func TestServiceCashIn(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 50_000)
+	customer, customerWallet := f.user(t, "+242060000002", 0)
+
+	in := CashInInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: "+242 06 000 0002", Amount: 20_000, ClientTxID: "ci-1"}
+	tx, err := f.svc.CashIn(ctx, in)
+	if err != nil {
+		t.Fatalf("cash-in: %v", err)
+	}
+	if tx.Kind != KindCashIn || tx.CustomerUserID != customer.ID || tx.AgentCode != a.AgentCode || tx.TransactionID == "" {
+		t.Fatalf("unexpected receipt: %+v", tx)
+	}
+	if got := f.balance(t, customerWallet.ID); got != 20_000 {
+		t.Fatalf("customer balance = %d, want 20000", got)
+	}
+	if got := f.balance(t, a.FloatWalletID); got != 30_000 {
+		t.Fatalf("float balance = %d, want 30000", got)
+	}
+
+	// A retry returns the original receipt without moving money again.
+	again, err := f.svc.CashIn(ctx, in)
+	if !errors.Is(err, ledger.ErrDuplicateTransaction) || again.ID != tx.ID {
+		t.Fatalf("expected original receipt on retry, got %+v, %v", again, err)
+	}
+	if got := f.balance(t, a.FloatWalletID); got != 30_000 {
+		t.Fatalf("float balance after retry = %d, want 30000", got)
+	}
+
+	// The float cannot go negative, and only the operator can use it.
+	if _, err := f.svc.CashIn(ctx, CashInInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 40_000}); !errors.Is(err, ledger.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient float, got %v", err)
+	}
+	if _, err := f.svc.CashIn(ctx, CashInInput{AgentID: a.ID, OperatorUserID: customer.ID, CustomerPhone: customer.Phone, Amount: 1_000}); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("expected ErrNotOwner, got %v", err)
+	}
+
+	// Both parties can read the receipt; nobody else can.
+	for _, uid := range []string{owner.ID, customer.ID} {
+		if _, err := f.svc.Receipt(ctx, tx.ID, uid); err != nil {
+			t.Fatalf("receipt for %s: %v", uid, err)
+		}
+	}
+	stranger, _ := f.user(t, "+242060000003", 0)
+	if _, err := f.svc.Receipt(ctx, tx.ID, stranger.ID); !errors.Is(err, ErrNotParty) {
+		t.Fatalf("expected ErrNotParty, got %v", err)
+	}
+
+	if _, err := f.svc.SetStatus(ctx, a.ID, StatusSuspended); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+	if _, err := f.svc.CashIn(ctx, CashInInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 1_000}); !errors.Is(err, ErrAgentInactive) {
+		t.Fatalf("expected suspended agent to be refused, got %v", err)
+	}
+}
+
+func TestServiceCashOutWithPIN(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 0)
+	customer, customerWallet := f.user(t, "+242060000002", 25_000)
+
+	out := CashOutInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 10_000}
+	if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, ErrConfirmationRequired) {
+		t.Fatalf("expected confirmation to be required, got %v", err)
+	}
+	out.PIN = "0000"
+	if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, identity.ErrInvalidPIN) {
+		t.Fatalf("expected wrong PIN to be refused, got %v", err)
+	}
+	out.PIN = "7305"
+	tx, err := f.svc.CashOut(ctx, out)
+	if err != nil {
+		t.Fatalf("cash-out: %v", err)
+	}
+	if tx.Kind != KindCashOut || tx.Method != MethodPIN {
+		t.Fatalf("unexpected receipt: %+v", tx)
+	}
+	if got := f.balance(t, customerWallet.ID); got != 15_000 {
+		t.Fatalf("customer balance = %d, want 15000", got)
+	}
+	if got := f.balance(t, a.FloatWalletID); got != 10_000 {
+		t.Fatalf("float balance = %d, want 10000", got)
+	}
+
+	txs, err := f.svc.CustomerTransactions(ctx, customer.ID, 10)
+	if err != nil || len(txs) != 1 || txs[0].ID != tx.ID {
+		t.Fatalf("customer transactions = %+v, %v", txs, err)
+	}
+
+	// A fresh SIM swap blocks the customer's cash-out even with the right PIN.
+	f.swaps.Set(customer.Phone, time.Now())
+	if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, guard.ErrDenied) || !errors.Is(err, risk.ErrRestricted) {
+		t.Fatalf("expected the SIM swap hold, got %v", err)
+	}
+	if got := f.balance(t, customerWallet.ID); got != 15_000 {
+		t.Fatalf("customer balance = %d, want 15000", got)
+	}
+}
+
+func TestServiceCashOutWithWithdrawalCode(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 0)
+	customer, customerWallet := f.user(t, "+242060000002", 25_000)
+
+	_, plain, err := f.svc.IssueWithdrawalCode(ctx, IssueCodeInput{UserID: customer.ID, Amount: 5_000})
+	if err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+	out := CashOutInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 6_000, WithdrawalCode: plain}
+	if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, ErrInvalidWithdrawalCode) {
+		t.Fatalf("expected amount mismatch to be refused, got %v", err)
+	}
+	out.Amount = 5_000
+	tx, err := f.svc.CashOut(ctx, out)
+	if err != nil {
+		t.Fatalf("cash-out: %v", err)
+	}
+	if tx.Method != MethodWithdrawalCode || tx.CustomerWalletID != customerWallet.ID {
+		t.Fatalf("unexpected receipt: %+v", tx)
+	}
+
+	// Codes are single use.
+	out.ClientTxID = "second"
+	if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, ErrInvalidWithdrawalCode) {
+		t.Fatalf("expected redeemed code to be refused, got %v", err)
+	}
+
+	// Wrong guesses void the code after MaxCodeAttempts.
+	_, plain, err = f.svc.IssueWithdrawalCode(ctx, IssueCodeInput{UserID: customer.ID, Amount: 5_000})
+	if err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+	wrong := "000000"
+	if wrong == plain {
+		wrong = "111111"
+	}
+	for i := 0; i < MaxCodeAttempts; i++ {
+		out.WithdrawalCode = wrong
+		if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, ErrInvalidWithdrawalCode) {
+			t.Fatalf("attempt %d: expected wrong code to be refused, got %v", i, err)
+		}
+	}
+	out.WithdrawalCode = plain
+	if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, ErrInvalidWithdrawalCode) {
+		t.Fatalf("expected voided code to be refused, got %v", err)
+	}
+
+	// Codes expire, and a failed posting hands the code back.
+	_, plain, err = f.svc.IssueWithdrawalCode(ctx, IssueCodeInput{UserID: customer.ID, Amount: 50_000})
+	if err != nil {
+		t.Fatalf("issue code: %v", err)
+	}
+	out = CashOutInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 50_000, WithdrawalCode: plain}
+	if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, ledger.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if _, err := f.svc.repo.ActiveWithdrawalCode(ctx, customer.ID); err != nil {
+		t.Fatalf("expected code to stay active after a failed cash-out: %v", err)
+	}
+	f.svc.now = func() time.Time { return time.Now().UTC().Add(WithdrawalCodeTTL + time.Minute) }
+	if _, err := f.svc.CashOut(ctx, out); !errors.Is(err, ErrInvalidWithdrawalCode) {
+		t.Fatalf("expected expired code to be refused, got %v", err)
+	}
+	if got := f.balance(t, customerWallet.ID); got != 20_000 {
+		t.Fatalf("customer balance = %d, want 20000", got)
+	}
+}
+
+func TestCommissionSchedule(t *testing.T) {
+	schedule := DefaultCommissionSchedule()
+	cases := []struct {
+		kind   string
+		amount int64
+		want   int64
+	}{
+		{KindCashIn, 5_000, 50},
+		{KindCashIn, 5_001, 100},
+		{KindCashIn, 50_000, 250},
+		{KindCashIn, 250_000, 1_000},
+		{KindCashOut, 3_000, 75},
+		{KindCashOut, 30_001, 225},
+		{"p2p", 30_000, 0},
+	}
+	for _, c := range cases {
+		if got := schedule.Compute(c.kind, c.amount); got != c.want {
+			t.Fatalf("commission(%s, %d) = %d, want %d", c.kind, c.amount, got, c.want)
+		}
+	}
+
+	overlapping := `{"bands": [{"kind": "cash_in", "min": 1, "max": 10000, "fixed": 50}, {"kind": "cash_in", "min": 10000, "bps": 50}]}`
+	if _, err := ParseCommissionSchedule(strings.NewReader(overlapping)); err == nil {
+		t.Fatal("expected overlapping bands to be rejected")
+	}
+	custom := `{"bands": [{"kind": "cash_out", "min": 1, "bps": 100}]}`
+	parsed, err := ParseCommissionSchedule(strings.NewReader(custom))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := parsed.Compute(KindCashOut, 12_345); got != 123 {
+		t.Fatalf("custom commission = %d, want 123", got)
+	}
+}
+
+func TestServiceCommissionsRealtime(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 50_000)
+	customer, _ := f.user(t, "+242060000002", 0)
+
+	tx, err := f.svc.CashIn(ctx, CashInInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 10_000})
+	if err != nil {
+		t.Fatalf("cash-in: %v", err)
+	}
+	if tx.Commission != 100 || tx.CommissionTxID == "" {
+		t.Fatalf("expected commission posted with the cash-in, got %+v", tx)
+	}
+	if bal, _ := f.led.Balance(ctx, CommissionAccountCode(a.ID)); bal != 100 {
+		t.Fatalf("commission balance = %d, want 100", bal)
+	}
+	if bal, _ := f.led.Balance(ctx, CommissionExpenseAccountCode); bal != -100 {
+		t.Fatalf("commission expense = %d, want -100", bal)
+	}
+
+	// Nothing is left for the sweep to pay.
+	if n, err := f.svc.RunDue(ctx, time.Now().UTC().Add(48*time.Hour)); err != nil || n != 0 {
+		t.Fatalf("RunDue = %d, %v; want nothing to post", n, err)
+	}
+}
+
+// unmarkedRepository loses the first commission mark, as if the database failed right after
+// the ledger posting.
+type unmarkedRepository struct {
+	Repository
+	failed bool
+}
+
+func (r *unmarkedRepository) MarkCommissionPosted(ctx context.Context, id, ledgerTxID string) error {
+	if !r.failed {
+		r.failed = true
+		return errors.New("connection reset")
+	}
+	return r.Repository.MarkCommissionPosted(ctx, id, ledgerTxID)
+}
+
+func TestServiceCommissionsSweepDoesNotRepayPostedCommission(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 50_000)
+	customer, _ := f.user(t, "+242060000002", 0)
+	f.svc.repo = &unmarkedRepository{Repository: f.svc.repo}
+
+	tx, err := f.svc.CashIn(ctx, CashInInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 10_000})
+	if err != nil {
+		t.Fatalf("cash-in: %v", err)
+	}
+	if tx.CommissionTxID != "" {
+		t.Fatalf("commission should be unmarked after the failed write: %+v", tx)
+	}
+	if n, err := f.svc.RunDue(ctx, time.Now().UTC().Add(24*time.Hour)); err != nil || n != 1 {
+		t.Fatalf("RunDue = %d, %v; want the commission marked", n, err)
+	}
+	if bal, _ := f.led.Balance(ctx, CommissionAccountCode(a.ID)); bal != 100 {
+		t.Fatalf("commission balance = %d, want 100 paid once", bal)
+	}
+}
+
+func TestServiceCommissionsDailyBatch(t *testing.T) {
+	f := newFixtureWithMode(t, CommissionDaily)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 50_000)
+	customer, _ := f.user(t, "+242060000002", 0)
+
+	for _, amount := range []int64{3_000, 10_000} {
+		tx, err := f.svc.CashIn(ctx, CashInInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: amount})
+		if err != nil {
+			t.Fatalf("cash-in: %v", err)
+		}
+		if tx.CommissionTxID != "" {
+			t.Fatalf("daily mode posted commission immediately: %+v", tx)
+		}
+	}
+	// The business day has not closed yet.
+	if n, err := f.svc.RunDue(ctx, time.Now().UTC()); err != nil || n != 0 {
+		t.Fatalf("RunDue before day end = %d, %v", n, err)
+	}
+	if n, err := f.svc.RunDue(ctx, time.Now().UTC().Add(24*time.Hour)); err != nil || n != 2 {
+		t.Fatalf("RunDue after day end = %d, %v; want one posting per transaction", n, err)
+	}
+	if bal, _ := f.led.Balance(ctx, CommissionAccountCode(a.ID)); bal != 150 {
+		t.Fatalf("commission balance = %d, want 150", bal)
+	}
+	if n, err := f.svc.RunDue(ctx, time.Now().UTC().Add(24*time.Hour)); err != nil || n != 0 {
+		t.Fatalf("second RunDue = %d, %v; want nothing left", n, err)
+	}
+}
+
+func TestServiceStatement(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 50_000)
+	customer, _ := f.user(t, "+242060000002", 30_000)
+
+	if _, err := f.svc.CashIn(ctx, CashInInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 20_000}); err != nil {
+		t.Fatalf("cash-in: %v", err)
+	}
+	if _, err := f.svc.CashOut(ctx, CashOutInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: customer.Phone, Amount: 5_000, PIN: "7305"}); err != nil {
+		t.Fatalf("cash-out: %v", err)
+	}
+
+	today := businessDay(time.Now()).Format("2006-01-02")
+	st, err := f.svc.Statement(ctx, a.ID, owner.ID, today)
+	if err != nil {
+		t.Fatalf("statement: %v", err)
+	}
+	if st.OpeningFloat != 50_000 || st.ClosingFloat != 35_000 || len(st.Lines) != 2 {
+		t.Fatalf("unexpected statement: %+v", st)
+	}
+	if st.CashInCount != 1 || st.CashInTotal != 20_000 || st.CashOutCount != 1 || st.CashOutTotal != 5_000 || st.Commissions != 175 {
+		t.Fatalf("unexpected totals: %+v", st)
+	}
+	if st.Lines[0].Kind != KindCashIn || st.Lines[0].Amount != -20_000 || st.Lines[1].Balance != 35_000 {
+		t.Fatalf("unexpected lines: %+v", st.Lines)
+	}
+	if _, err := f.svc.Statement(ctx, a.ID, customer.ID, today); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("expected ErrNotOwner, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteStatementCSV(&buf, st); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 6 || rows[1][1] != "opening_float" || rows[4][5] != "175" || rows[5][6] != "35000" {
+		t.Fatalf("unexpected csv: %v", rows)
+	}
+}
+
+func TestServiceLiquidityAlerts(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 60_000)
+	f.user(t, "+242060000002", 0)
+
+	a, err := f.svc.SetThresholds(ctx, a.ID, owner.ID, 20_000, 100_000)
+	if err != nil {
+		t.Fatalf("thresholds: %v", err)
+	}
+	if a.LiquidityState != LiquidityOK {
+		t.Fatalf("state = %q, want ok", a.LiquidityState)
+	}
+	if _, err := f.svc.SetThresholds(ctx, a.ID, owner.ID, 50_000, 40_000); err == nil {
+		t.Fatalf("expected min above max to be rejected")
+	}
+
+	cashIn := func(ref string, amount int64) {
+		t.Helper()
+		in := CashInInput{AgentID: a.ID, OperatorUserID: owner.ID, CustomerPhone: "+242060000002", Amount: amount, ClientTxID: ref}
+		if _, err := f.svc.CashIn(ctx, in); err != nil {
+			t.Fatalf("cash-in %s: %v", ref, err)
+		}
+	}
+	cashIn("ci-1", 30_000)
+	if got := f.notifier.take(notification.KindAgentLiquidity); len(got) != 0 {
+		t.Fatalf("unexpected alerts above the minimum: %+v", got)
+	}
+	cashIn("ci-2", 15_000)
+	alerts := f.notifier.take(notification.KindAgentLiquidity)
+	if len(alerts) != 1 || alerts[0].Destination != owner.ID || !strings.Contains(alerts[0].Body, "low") {
+		t.Fatalf("low alerts = %+v", alerts)
+	}
+	// Staying low does not alert again.
+	cashIn("ci-3", 5_000)
+	if got := f.notifier.take(notification.KindAgentLiquidity); len(got) != 0 {
+		t.Fatalf("repeated low alerts: %+v", got)
+	}
+	if got, _ := f.svc.Get(ctx, a.ID); got.LiquidityState != LiquidityLow {
+		t.Fatalf("state = %q, want low", got.LiquidityState)
+	}
+
+	// A top-up from outside the agent flows is still seen by the watcher.
+	_, opWallet := f.user(t, "+242060000003", 200_000)
+	floatWallet, _ := f.wallets.Get(ctx, a.FloatWalletID)
+	if _, err := f.led.Transfer(ctx, opWallet.AccountCode, floatWallet.AccountCode, "p2p", "topup-1", 150_000); err != nil {
+		t.Fatalf("top-up: %v", err)
+	}
+	alerts = f.notifier.take(notification.KindAgentLiquidity)
+	if len(alerts) != 1 || !strings.Contains(alerts[0].Body, "high") {
+		t.Fatalf("high alerts = %+v", alerts)
+	}
+	if got, _ := f.svc.Get(ctx, a.ID); got.LiquidityState != LiquidityHigh {
+		t.Fatalf("state = %q, want high", got.LiquidityState)
+	}
+}
+
+func TestServiceRebalanceFromSuperAgent(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 5_000)
+	superOwner, super := f.agent(t, "+242060000002", 500_000)
+
+	if _, err := f.svc.RequestRebalance(ctx, RebalanceInput{AgentID: a.ID, RequestorUserID: owner.ID, Source: SourceSuperAgent, Direction: DirectionTopUp, Amount: 100_000}); !errors.Is(err, ErrNoSuperAgent) {
+		t.Fatalf("expected ErrNoSuperAgent, got %v", err)
+	}
+	if _, err := f.svc.SetSuperAgent(ctx, a.ID, super.ID); err != nil {
+		t.Fatalf("set super-agent: %v", err)
+	}
+	if _, err := f.svc.SetSuperAgent(ctx, super.ID, a.ID); err == nil {
+		t.Fatalf("expected a super-agent cycle to be rejected")
+	}
+
+	r, err := f.svc.RequestRebalance(ctx, RebalanceInput{AgentID: a.ID, RequestorUserID: owner.ID, Source: SourceSuperAgent, Direction: DirectionTopUp, Amount: 100_000, Note: "market day"})
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if r.Status != RebalancePending || r.SuperAgentID != super.ID {
+		t.Fatalf("unexpected request: %+v", r)
+	}
+	if got := f.notifier.take(notification.KindAgentRebalance); len(got) != 1 || got[0].Destination != superOwner.ID {
+		t.Fatalf("approver notifications = %+v", got)
+	}
+	incoming, err := f.svc.Rebalances(ctx, super.ID, superOwner.ID, true, RebalancePending, 10)
+	if err != nil || len(incoming) != 1 {
+		t.Fatalf("incoming = %v, %v", incoming, err)
+	}
+
+	if _, err := f.svc.ApproveRebalance(ctx, r.ID, owner.ID, ""); !errors.Is(err, ErrNotApprover) {
+		t.Fatalf("expected requester approval to fail, got %v", err)
+	}
+	if _, err := f.svc.AdminApproveRebalance(ctx, r.ID, "admin", ""); !errors.Is(err, ErrNotApprover) {
+		t.Fatalf("expected back-office approval of a super-agent request to fail, got %v", err)
+	}
+	approved, err := f.svc.ApproveRebalance(ctx, r.ID, superOwner.ID, "ok")
+	if err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if approved.Status != RebalanceApproved || approved.TransactionID == "" || approved.DecidedBy != superOwner.ID {
+		t.Fatalf("unexpected approval: %+v", approved)
+	}
+	if got := f.balance(t, a.FloatWalletID); got != 105_000 {
+		t.Fatalf("agent float = %d, want 105000", got)
+	}
+	if got := f.balance(t, super.FloatWalletID); got != 400_000 {
+		t.Fatalf("super-agent float = %d, want 400000", got)
+	}
+	if got := f.notifier.take(notification.KindAgentRebalance); len(got) != 1 || got[0].Destination != owner.ID {
+		t.Fatalf("decision notifications = %+v", got)
+	}
+	if _, err := f.svc.ApproveRebalance(ctx, r.ID, superOwner.ID, ""); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected second approval to fail, got %v", err)
+	}
+
+	// Returning more float than the agent holds leaves the request pending.
+	back, err := f.svc.RequestRebalance(ctx, RebalanceInput{AgentID: a.ID, RequestorUserID: owner.ID, Source: SourceSuperAgent, Direction: DirectionReturn, Amount: 200_000})
+	if err != nil {
+		t.Fatalf("return request: %v", err)
+	}
+	if _, err := f.svc.ApproveRebalance(ctx, back.ID, superOwner.ID, ""); !errors.Is(err, ledger.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	if got, _ := f.svc.GetRebalance(ctx, back.ID, owner.ID); got.Status != RebalancePending {
+		t.Fatalf("status after failed approval = %q, want pending", got.Status)
+	}
+	if _, err := f.svc.RejectRebalance(ctx, back.ID, superOwner.ID, "too much"); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+	if _, err := f.svc.CancelRebalance(ctx, back.ID, owner.ID); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected cancel after rejection to fail, got %v", err)
+	}
+}
+
+func TestServiceRebalanceFromBank(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 300_000)
+	outsider, _ := f.user(t, "+242060000002", 0)
+
+	r, err := f.svc.RequestRebalance(ctx, RebalanceInput{AgentID: a.ID, RequestorUserID: owner.ID, Source: SourceBank, Direction: DirectionReturn, Amount: 250_000})
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if _, err := f.svc.GetRebalance(ctx, r.ID, outsider.ID); !errors.Is(err, ErrNotParty) {
+		t.Fatalf("expected outsider lookup to fail, got %v", err)
+	}
+	if _, err := f.svc.ApproveRebalance(ctx, r.ID, outsider.ID, ""); !errors.Is(err, ErrNotApprover) {
+		t.Fatalf("expected non-admin approval to fail, got %v", err)
+	}
+	pending, err := f.svc.AdminRebalances(ctx, RebalanceFilter{Source: SourceBank, Status: RebalancePending})
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("pending = %v, %v", pending, err)
+	}
+	if _, err := f.svc.AdminApproveRebalance(ctx, r.ID, "admin-1", ""); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if got := f.balance(t, a.FloatWalletID); got != 50_000 {
+		t.Fatalf("agent float = %d, want 50000", got)
+	}
+	if bank, _ := f.led.Balance(ctx, BankFloatAccountCode); bank != 250_000 {
+		t.Fatalf("bank float = %d, want 250000", bank)
+	}
+
+	topup, err := f.svc.RequestRebalance(ctx, RebalanceInput{AgentID: a.ID, RequestorUserID: owner.ID, Source: SourceBank, Direction: DirectionTopUp, Amount: 10_000})
+	if err != nil {
+		t.Fatalf("top-up request: %v", err)
+	}
+	cancelled, err := f.svc.CancelRebalance(ctx, topup.ID, owner.ID)
+	if err != nil || cancelled.Status != RebalanceCancelled {
+		t.Fatalf("cancel = %+v, %v", cancelled, err)
+	}
+	if _, err := f.svc.AdminApproveRebalance(ctx, topup.ID, "admin-1", ""); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected approval after cancel to fail, got %v", err)
+	}
+}
+
+func TestServiceAssistedRegistration(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 0)
+	f.user(t, "+242060000002", 0)
+
+	input := RegistrationInput{
+		AgentID:          a.ID,
+		OperatorUserID:   owner.ID,
+		Phone:            "+242 06 000 0003",
+		FullName:         "Grâce Mabiala",
+		DocumentType:     identity.DocumentNationalID,
+		DocumentNumber:   "CG-0042-1988",
+		DocumentFrontRef: "kyc/front.jpg",
+		SelfieRef:        "kyc/selfie.jpg",
+	}
+	taken := input
+	taken.Phone = "+242060000002"
+	if _, err := f.svc.StartRegistration(ctx, taken); !errors.Is(err, identity.ErrUserExists) {
+		t.Fatalf("expected ErrUserExists, got %v", err)
+	}
+	missing := input
+	missing.SelfieRef = ""
+	if _, err := f.svc.StartRegistration(ctx, missing); err == nil {
+		t.Fatalf("expected missing selfie to be rejected")
+	}
+
+	reg, err := f.svc.StartRegistration(ctx, input)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if reg.Status != RegistrationPending || reg.Phone != "+242060000003" {
+		t.Fatalf("unexpected registration: %+v", reg)
+	}
+	sms := f.notifier.take(notification.KindOTP)
+	if len(sms) != 1 || sms[0].Destination != reg.Phone {
+		t.Fatalf("OTP messages = %+v", sms)
+	}
+	code := f.registrationCode(t, sms[0])
+
+	confirm := ConfirmRegistrationInput{AgentID: a.ID, OperatorUserID: owner.ID, RegistrationID: reg.ID, OTP: "000000", PIN: "7305"}
+	if code == confirm.OTP {
+		confirm.OTP = "111111"
+	}
+	if _, _, err := f.svc.ConfirmRegistration(ctx, confirm); !errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("expected ErrInvalidOTP, got %v", err)
+	}
+	confirm.OTP = code
+	weak := confirm
+	weak.PIN = "1234"
+	if _, _, err := f.svc.ConfirmRegistration(ctx, weak); !errors.Is(err, identity.ErrWeakPIN) {
+		t.Fatalf("expected ErrWeakPIN, got %v", err)
+	}
+	done, user, err := f.svc.ConfirmRegistration(ctx, confirm)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if done.Status != RegistrationCompleted || done.UserID != user.ID || done.WalletID == "" {
+		t.Fatalf("unexpected completion: %+v", done)
+	}
+	if user.OnboardingAgentID != a.ID || user.Phone != reg.Phone {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+	kyc, err := f.users.KYC(ctx, user.ID)
+	if err != nil || kyc.DocumentNumber != "CG-0042-1988" || kyc.CapturedByAgentID != a.ID {
+		t.Fatalf("kyc = %+v, %v", kyc, err)
+	}
+	if w, err := f.wallets.GetByOwner(ctx, user.ID); err != nil || w.ID != done.WalletID {
+		t.Fatalf("wallet = %+v, %v", w, err)
+	}
+	if _, err := f.users.Authenticate(ctx, identity.Credentials{Phone: reg.Phone, PIN: "7305", DeviceID: "phone-1"}); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if _, _, err := f.svc.ConfirmRegistration(ctx, confirm); !errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("expected a completed registration to be final, got %v", err)
+	}
+
+	// The agent can unlock the customer's PIN after checking the ID document.
+	for i := 0; i < 3; i++ {
+		_, _ = f.users.VerifyPIN(ctx, user.ID, "0000")
+	}
+	if _, err := f.users.VerifyPIN(ctx, user.ID, "7305"); !errors.Is(err, identity.ErrPINLocked) {
+		t.Fatalf("expected a locked PIN, got %v", err)
+	}
+	if _, err := f.svc.UnlockCustomerPIN(ctx, a.ID, owner.ID, reg.Phone, "CG-9999"); !errors.Is(err, ErrIdentityMismatch) {
+		t.Fatalf("expected ErrIdentityMismatch, got %v", err)
+	}
+	if _, err := f.svc.UnlockCustomerPIN(ctx, a.ID, owner.ID, reg.Phone, "cg-0042-1988"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if _, err := f.users.VerifyPIN(ctx, user.ID, "7305"); err != nil {
+		t.Fatalf("verify after unlock: %v", err)
+	}
+
+	// Codes go through the OTP service: wrong guesses use the code up and resends wait
+	// out its cooldown.
+	input.Phone = "+242060000004"
+	locked, err := f.svc.StartRegistration(ctx, input)
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	lockedCode := f.registrationCode(t, f.notifier.take(notification.KindOTP)[0])
+	if _, err := f.svc.StartRegistration(ctx, input); !errors.Is(err, otp.ErrResendTooSoon) {
+		t.Fatalf("expected ErrResendTooSoon, got %v", err)
+	}
+	for i := 0; i < otp.DefaultPolicy().MaxAttempts; i++ {
+		if _, _, err := f.svc.ConfirmRegistration(ctx, ConfirmRegistrationInput{AgentID: a.ID, OperatorUserID: owner.ID, RegistrationID: locked.ID, OTP: "abc", PIN: "7305"}); !errors.Is(err, ErrInvalidOTP) {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+	}
+	if _, _, err := f.svc.ConfirmRegistration(ctx, ConfirmRegistrationInput{AgentID: a.ID, OperatorUserID: owner.ID, RegistrationID: locked.ID, OTP: lockedCode, PIN: "7305"}); !errors.Is(err, ErrInvalidOTP) {
+		t.Fatalf("expected a used up code to fail, got %v", err)
+	}
+	regs, err := f.svc.Registrations(ctx, a.ID, owner.ID, 10)
+	if err != nil || len(regs) != 2 {
+		t.Fatalf("registrations = %v, %v", regs, err)
+	}
+}
+
+// incompleteRepository fails to record completed registrations.
+type incompleteRepository struct {
+	Repository
+}
+
+func (r incompleteRepository) CompleteRegistration(context.Context, string, string, string, time.Time) error {
+	return errors.New("database unavailable")
+}
+
+func TestServiceAssistedRegistrationRetry(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, a := f.agent(t, "+242060000001", 0)
+
+	reg, err := f.svc.StartRegistration(ctx, RegistrationInput{
+		AgentID:          a.ID,
+		OperatorUserID:   owner.ID,
+		Phone:            "+242060000005",
+		FullName:         "Prisca Nkounkou",
+		DocumentType:     identity.DocumentNationalID,
+		DocumentNumber:   "CG-0077-1990",
+		DocumentFrontRef: "kyc/front.jpg",
+		SelfieRef:        "kyc/selfie.jpg",
+	})
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	confirm := ConfirmRegistrationInput{AgentID: a.ID, OperatorUserID: owner.ID, RegistrationID: reg.ID, PIN: "7305"}
+	confirm.OTP = f.registrationCode(t, f.notifier.take(notification.KindOTP)[0])
+
+	repo := f.svc.repo
+	f.svc.repo = incompleteRepository{Repository: repo}
+	if _, _, err := f.svc.ConfirmRegistration(ctx, confirm); err == nil {
+		t.Fatal("expected the first attempt to fail")
+	}
+	f.svc.repo = repo
+
+	// The code was used up, so the retry is confirmed by the customer's PIN instead.
+	wrong := confirm
+	wrong.PIN = "4831"
+	if _, _, err := f.svc.ConfirmRegistration(ctx, wrong); !errors.Is(err, identity.ErrInvalidPIN) {
+		t.Fatalf("expected ErrInvalidPIN, got %v", err)
+	}
+	done, user, err := f.svc.ConfirmRegistration(ctx, confirm)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if done.Status != RegistrationCompleted || done.UserID != user.ID {
+		t.Fatalf("unexpected completion: %+v", done)
+	}
+	owned, err := f.wallets.ListByOwner(ctx, user.ID)
+	if err != nil || len(owned) != 1 || owned[0].ID != done.WalletID {
+		t.Fatalf("wallets = %+v, %v", owned, err)
+	}
+}
+
+// registrationCode reads the code out of a registration text.
+func (f fixture) registrationCode(t *testing.T, sms notification.Message) string {
+	t.Helper()
+	fields := strings.Fields(sms.Body)
+	if len(fields) < 4 {
+		t.Fatalf("unexpected OTP text %q", sms.Body)
+	}
+	return fields[3]
+}
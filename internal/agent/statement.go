@@ -0,0 +1,138 @@
+package agent
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/clock"
+)
+
+// StatementLine is one movement of an agent's float.
+type StatementLine struct {
+	At            time.Time
+	Kind          string
+	TransactionID string
+	// CustomerPhone and Commission are set for cash-ins and cash-outs.
+	CustomerPhone string
+	// Amount is signed from the float's point of view: cash-ins reduce it.
+	Amount     int64
+	Commission int64
+	Balance    int64
+}
+
+// Statement summarises an agent's float and commissions over one WAT business day.
+type Statement struct {
+	AgentID      string
+	AgentCode    string
+	AgentName    string
+	Date         time.Time
+	OpeningFloat int64
+	ClosingFloat int64
+	CashInCount  int
+	CashInTotal  int64
+	CashOutCount int
+	CashOutTotal int64
+	// OtherCredits and OtherDebits cover float movements that are not cash transactions,
+	// such as top-ups from the operator's own wallet.
+	OtherCredits int64
+	OtherDebits  int64
+	Commissions  int64
+	Lines        []StatementLine
+}
+
+// Statement builds the daily statement of an agent operated by the requestor. date is a
+// YYYY-MM-DD business day in WAT.
+func (s *Service) Statement(ctx context.Context, agentID, requestorUserID, date string) (Statement, error) {
+	a, err := s.owned(ctx, agentID, requestorUserID)
+	if err != nil {
+		return Statement{}, err
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, clock.WAT)
+	if err != nil {
+		return Statement{}, fmt.Errorf("date must be YYYY-MM-DD")
+	}
+	return s.buildStatement(ctx, a, day)
+}
+
+func (s *Service) buildStatement(ctx context.Context, a Agent, day time.Time) (Statement, error) {
+	from, to := day.UTC(), day.AddDate(0, 0, 1).UTC()
+	float, err := s.float(ctx, a)
+	if err != nil {
+		return Statement{}, err
+	}
+	activity, err := s.ledger.Activity(ctx, float.AccountCode, from, to)
+	if err != nil {
+		return Statement{}, err
+	}
+	txs, err := s.repo.ListByAgentBetween(ctx, a.ID, from, to)
+	if err != nil {
+		return Statement{}, err
+	}
+	byLedgerTx := make(map[string]CashTransaction, len(txs))
+	for _, tx := range txs {
+		byLedgerTx[tx.TransactionID] = tx
+	}
+
+	st := Statement{
+		AgentID:      a.ID,
+		AgentCode:    a.AgentCode,
+		AgentName:    a.BusinessName,
+		Date:         day,
+		OpeningFloat: activity.Opening,
+		ClosingFloat: activity.Closing,
+	}
+	balance := activity.Opening
+	for _, e := range activity.Entries {
+		balance += e.Amount
+		line := StatementLine{At: e.CreatedAt, Kind: e.Kind, TransactionID: e.TransactionID, Amount: e.Amount, Balance: balance}
+		if tx, ok := byLedgerTx[e.TransactionID]; ok {
+			line.Kind = tx.Kind
+			line.CustomerPhone = tx.CustomerPhone
+			line.Commission = tx.Commission
+			st.Commissions += tx.Commission
+			if tx.Kind == KindCashIn {
+				st.CashInCount++
+				st.CashInTotal += tx.Amount
+			} else {
+				st.CashOutCount++
+				st.CashOutTotal += tx.Amount
+			}
+		} else if e.Amount > 0 {
+			st.OtherCredits += e.Amount
+		} else {
+			st.OtherDebits -= e.Amount
+		}
+		st.Lines = append(st.Lines, line)
+	}
+	return st, nil
+}
+
+// WriteStatementCSV exports a statement with the opening float first, one row per movement
+// and the commission total and closing float last.
+func WriteStatementCSV(w io.Writer, st Statement) error {
+	cw := csv.NewWriter(w)
+	itoa := func(v int64) string { return strconv.FormatInt(v, 10) }
+	day := st.Date.Format("2006-01-02")
+	rows := [][]string{
+		{"time", "type", "reference", "customer_phone", "amount", "commission", "float_balance"},
+		{day, "opening_float", st.AgentCode, "", "", "", itoa(st.OpeningFloat)},
+	}
+	for _, l := range st.Lines {
+		rows = append(rows, []string{
+			l.At.In(clock.WAT).Format(time.RFC3339), l.Kind, l.TransactionID, l.CustomerPhone,
+			itoa(l.Amount), itoa(l.Commission), itoa(l.Balance),
+		})
+	}
+	rows = append(rows,
+		[]string{day, "commissions", st.AgentCode, "", "", itoa(st.Commissions), ""},
+		[]string{day, "closing_float", st.AgentCode, "", "", "", itoa(st.ClosingFloat)},
+	)
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
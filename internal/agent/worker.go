@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunWorker credits owed agent commissions and refreshes the liquidity watcher's view of
+// float wallets every interval until ctx is cancelled.
+func RunWorker(ctx context.Context, svc *Service, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Agents onboarded by other instances only become visible to the watcher here.
+			if err := svc.refreshFloats(ctx); err != nil && logger != nil {
+				logger.Error("agent float refresh failed", slog.Any("error", err))
+			}
+			n, err := svc.RunDue(ctx, svc.now())
+			if logger == nil {
+				continue
+			}
+			if err != nil {
+				logger.Error("agent commission sweep failed", slog.Any("error", err))
+				continue
+			}
+			if n > 0 {
+				logger.Info("agent commissions posted", slog.Int("count", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "time"
+)
+
+// ErrInvalidToken indicates a token failed signature or claim validation.
+var ErrInvalidToken = errors.New("invalid token")
+
+// ClaimsPolicy is what the registered claims of a token must satisfy.
+type ClaimsPolicy struct {
+    Issuer   string
+    Audience string
+    // Leeway absorbs clock skew between the issuer and the verifier.
+    Leeway time.Duration
+}
+
+// Validate checks exp, nbf, iat, iss and aud at now. exp and iat are required.
+func (p ClaimsPolicy) Validate(claims map[string]any, now time.Time) error {
+    exp, ok := numericDate(claims, "exp")
+    if !ok {
+        return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+    }
+    if !now.Before(exp.Add(p.Leeway)) {
+        return fmt.Errorf("%w: expired", ErrInvalidToken)
+    }
+    iat, ok := numericDate(claims, "iat")
+    if !ok {
+        return fmt.Errorf("%w: missing iat", ErrInvalidToken)
+    }
+    if iat.After(now.Add(p.Leeway)) {
+        return fmt.Errorf("%w: issued in the future", ErrInvalidToken)
+    }
+    if _, present := claims["nbf"]; present {
+        nbf, ok := numericDate(claims, "nbf")
+        if !ok {
+            return fmt.Errorf("%w: malformed nbf", ErrInvalidToken)
+        }
+        if now.Add(p.Leeway).Before(nbf) {
+            return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+        }
+    }
+    if iss, _ := claims["iss"].(string); iss != p.Issuer {
+        return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+    }
+    if !hasAudience(claims["aud"], p.Audience) {
+        return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+    }
+    return nil
+}
+
+func numericDate(claims map[string]any, name string) (time.Time, bool) {
+    v, ok := claims[name].(float64)
+    if !ok || math.IsNaN(v) || math.IsInf(v, 0) {
+        return time.Time{}, false
+    }
+    return time.Unix(int64(v), 0), true
+}
+
+// hasAudience accepts aud as a single string or an array of strings.
+func hasAudience(aud any, want string) bool {
+    switch v := aud.(type) {
+    case string:
+        return v == want
+    case []any:
+        for _, a := range v {
+            if s, _ := a.(string); s == want {
+                return true
+            }
+        }
+    }
+    return false
+}
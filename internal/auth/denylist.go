@@ -0,0 +1,82 @@
+package auth
+
+import (
+    "context"
+    "sync"
+    "time"
+
+    "github.com/redis/go-redis/v9"
+)
+
+// Denylist records access tokens that were signed out before they expired. Entries only
+// need to live as long as the token would, so each is stored with the token's remaining
+// lifetime as its TTL.
+type Denylist interface {
+    Add(ctx context.Context, jti string, ttl time.Duration) error
+    Contains(ctx context.Context, jti string) (bool, error)
+}
+
+type memoryDenylist struct {
+    mu      sync.Mutex
+    now     func() time.Time
+    entries map[string]time.Time
+}
+
+// NewMemoryDenylist returns an in-process denylist for single-instance deployments and
+// tests.
+func NewMemoryDenylist() Denylist {
+    return newMemoryDenylist(func() time.Time { return time.Now().UTC() })
+}
+
+func newMemoryDenylist(now func() time.Time) *memoryDenylist {
+    return &memoryDenylist{now: now, entries: make(map[string]time.Time)}
+}
+
+// Add drops entries that have run out, so the map stays bounded by the tokens still alive.
+func (d *memoryDenylist) Add(_ context.Context, jti string, ttl time.Duration) error {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    now := d.now()
+    for id, until := range d.entries {
+        if !now.Before(until) {
+            delete(d.entries, id)
+        }
+    }
+    if ttl > 0 {
+        d.entries[jti] = now.Add(ttl)
+    }
+    return nil
+}
+
+func (d *memoryDenylist) Contains(_ context.Context, jti string) (bool, error) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    until, ok := d.entries[jti]
+    return ok && d.now().Before(until), nil
+}
+
+// RedisDenylist shares the denylist across instances as expiring keys.
+type RedisDenylist struct {
+    client *redis.Client
+}
+
+// NewRedisDenylist builds a Redis-backed denylist.
+func NewRedisDenylist(client *redis.Client) *RedisDenylist {
+    return &RedisDenylist{client: client}
+}
+
+func denylistKey(jti string) string {
+    return "auth:denylist:" + jti
+}
+
+func (d *RedisDenylist) Add(ctx context.Context, jti string, ttl time.Duration) error {
+    if ttl <= 0 {
+        return nil
+    }
+    return d.client.Set(ctx, denylistKey(jti), 1, ttl).Err()
+}
+
+func (d *RedisDenylist) Contains(ctx context.Context, jti string) (bool, error) {
+    n, err := d.client.Exists(ctx, denylistKey(jti)).Result()
+    return n > 0, err
+}
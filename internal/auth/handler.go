@@ -1,11 +1,15 @@
 package auth
 
 import (
+    "errors"
     "net/http"
+    "time"
 
     "github.com/gofiber/fiber/v2"
 
     "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/otp"
+    "github.com/congo-pay/congo_pay/internal/risk"
     "github.com/congo-pay/congo_pay/internal/wallet"
 )
 
@@ -14,16 +18,20 @@ type Handler struct {
     ids *identity.Service
     svc *Service
     wallets *wallet.Service
+    otps *otp.Service
+    risks *risk.Service
 }
 
-func NewHandler(ids *identity.Service, svc *Service, wallets *wallet.Service) *Handler {
-    return &Handler{ids: ids, svc: svc, wallets: wallets}
+func NewHandler(ids *identity.Service, svc *Service, wallets *wallet.Service, otps *otp.Service, risks *risk.Service) *Handler {
+    return &Handler{ids: ids, svc: svc, wallets: wallets, otps: otps, risks: risks}
 }
 
 type loginRequest struct {
-    Phone    string `json:"phone"`
-    PIN      string `json:"pin"`
-    DeviceID string `json:"device_id"`
+    Phone      string `json:"phone"`
+    PIN        string `json:"pin"`
+    DeviceID   string `json:"device_id"`
+    DeviceName string `json:"device_name"`
+    Platform   string `json:"platform"`
 }
 
 type loginResponse struct {
@@ -33,6 +41,9 @@ type loginResponse struct {
     ExpiresIn    int64  `json:"expires_in"`
     TokenVersion int    `json:"token_version"`
     WalletID     string `json:"wallet_id,omitempty"`
+    SessionID    string `json:"session_id"`
+    // RestrictedUntil is set while withdrawals are blocked after a SIM swap.
+    RestrictedUntil *time.Time `json:"restricted_until,omitempty"`
 }
 
 // Login validates credentials and returns a token pair.
@@ -41,55 +52,165 @@ func (h *Handler) Login(c *fiber.Ctx) error {
     if err := c.BodyParser(&req); err != nil {
         return fiber.NewError(http.StatusBadRequest, err.Error())
     }
-    user, err := h.ids.Authenticate(c.UserContext(), identity.Credentials{Phone: req.Phone, PIN: req.PIN, DeviceID: req.DeviceID})
+    user, err := h.ids.Authenticate(c.UserContext(), identity.Credentials{Phone: req.Phone, PIN: req.PIN, DeviceID: req.DeviceID, DeviceName: req.DeviceName, Platform: req.Platform})
     if err != nil {
-        return fiber.NewError(http.StatusUnauthorized, err.Error())
+        return fiber.NewError(identity.AuthErrorStatus(err), err.Error())
+    }
+    return h.issue(c, user)
+}
+
+type deviceChangeRequest struct {
+    loginRequest
+    OTPCode string `json:"otp_code"`
+}
+
+// DeviceChange trusts a new device with the PIN and a device_change code texted to the
+// phone, and logs in on it. Outgoing transfers from it are held during the cooling-off.
+func (h *Handler) DeviceChange(c *fiber.Ctx) error {
+    var req deviceChangeRequest
+    if err := c.BodyParser(&req); err != nil {
+        return fiber.NewError(http.StatusBadRequest, err.Error())
+    }
+    if err := h.otps.Verify(c.UserContext(), otp.PurposeDeviceChange, req.Phone, req.OTPCode); err != nil {
+        if errors.Is(err, otp.ErrInvalidCode) {
+            return fiber.NewError(http.StatusUnauthorized, err.Error())
+        }
+        return fiber.NewError(http.StatusBadRequest, err.Error())
     }
-    pair, err := h.svc.Login(user)
+    user, err := h.ids.ChangeDevice(c.UserContext(), identity.Credentials{Phone: req.Phone, PIN: req.PIN, DeviceID: req.DeviceID, DeviceName: req.DeviceName, Platform: req.Platform})
+    if err != nil {
+        return fiber.NewError(identity.AuthErrorStatus(err), err.Error())
+    }
+    return h.issue(c, user)
+}
+
+// issue screens a freshly authenticated user for SIM swaps and returns their token pair.
+func (h *Handler) issue(c *fiber.Ctx, user identity.User) error {
+    resp := loginResponse{UserID: user.ID, TokenVersion: user.TokenVersion}
+    if h.risks != nil {
+        restriction, err := h.risks.CheckLogin(c.UserContext(), user)
+        if err != nil {
+            return fiber.NewError(http.StatusInternalServerError, err.Error())
+        }
+        if restriction.ID != "" {
+            resp.RestrictedUntil = &restriction.RestrictedUntil
+        }
+    }
+    pair, err := h.svc.Login(c.UserContext(), user, ClientInfo{UserAgent: c.Get(fiber.HeaderUserAgent), IP: c.IP()})
     if err != nil {
         return fiber.NewError(http.StatusInternalServerError, err.Error())
     }
-    var wid string
+    resp.AccessToken, resp.RefreshToken, resp.ExpiresIn, resp.SessionID = pair.AccessToken, pair.RefreshToken, pair.ExpiresIn, pair.SessionID
     if h.wallets != nil {
         if w, err := h.wallets.GetByOwner(c.UserContext(), user.ID); err == nil {
-            wid = w.ID
+            resp.WalletID = w.ID
         }
     }
-    return c.Status(http.StatusOK).JSON(loginResponse{UserID: user.ID, AccessToken: pair.AccessToken, RefreshToken: pair.RefreshToken, ExpiresIn: pair.ExpiresIn, TokenVersion: user.TokenVersion, WalletID: wid})
+    return c.Status(http.StatusOK).JSON(resp)
 }
 
 type refreshRequest struct {
     RefreshToken string `json:"refresh_token"`
 }
 
-// Refresh issues a new access token using a valid refresh token.
+// Refresh exchanges a refresh token for a new access token and a rotated refresh token.
+// The old refresh token stops working.
 func (h *Handler) Refresh(c *fiber.Ctx) error {
     var req refreshRequest
     if err := c.BodyParser(&req); err != nil {
         return fiber.NewError(http.StatusBadRequest, err.Error())
     }
-    token, exp, err := h.svc.Refresh(c.UserContext(), req.RefreshToken)
+    pair, err := h.svc.Refresh(c.UserContext(), req.RefreshToken)
     if err != nil {
         return fiber.NewError(http.StatusUnauthorized, err.Error())
     }
-    return c.Status(http.StatusOK).JSON(fiber.Map{"access_token": token, "expires_in": exp})
+    return c.Status(http.StatusOK).JSON(pair)
+}
+
+// JWKS serves the public keys access tokens are signed with.
+func (h *Handler) JWKS(c *fiber.Ctx) error {
+    set, err := h.svc.JWKS()
+    if err != nil {
+        return fiber.NewError(http.StatusInternalServerError, err.Error())
+    }
+    // Keys are published an overlap ahead of use, so a short cache is safe.
+    c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+    return c.JSON(set)
+}
+
+type sessionResponse struct {
+    ID         string    `json:"id"`
+    DeviceID   string    `json:"device_id"`
+    UserAgent  string    `json:"user_agent,omitempty"`
+    IP         string    `json:"ip,omitempty"`
+    Current    bool      `json:"current"`
+    CreatedAt  time.Time `json:"created_at"`
+    LastUsedAt time.Time `json:"last_used_at"`
+    ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Sessions lists the caller's signed-in sessions.
+func (h *Handler) Sessions(c *fiber.Ctx) error {
+    uid, _ := c.Locals("user_id").(string)
+    current, _ := c.Locals("session_id").(string)
+    sessions, err := h.svc.Sessions(c.UserContext(), uid)
+    if err != nil {
+        return fiber.NewError(http.StatusInternalServerError, err.Error())
+    }
+    out := make([]sessionResponse, 0, len(sessions))
+    for _, s := range sessions {
+        out = append(out, sessionResponse{
+            ID:         s.ID,
+            DeviceID:   s.DeviceID,
+            UserAgent:  s.UserAgent,
+            IP:         s.IP,
+            Current:    s.ID == current,
+            CreatedAt:  s.CreatedAt,
+            LastUsedAt: s.LastUsedAt,
+            ExpiresAt:  s.ExpiresAt,
+        })
+    }
+    return c.JSON(fiber.Map{"sessions": out})
+}
+
+// RevokeSession signs one of the caller's sessions out; its tokens stop working at once.
+func (h *Handler) RevokeSession(c *fiber.Ctx) error {
+    uid, _ := c.Locals("user_id").(string)
+    if err := h.svc.RevokeSession(c.UserContext(), uid, c.Params("id")); err != nil {
+        if errors.Is(err, ErrSessionNotFound) {
+            return fiber.NewError(http.StatusNotFound, err.Error())
+        }
+        return fiber.NewError(http.StatusInternalServerError, err.Error())
+    }
+    return c.JSON(fiber.Map{"id": c.Params("id"), "status": "revoked"})
 }
 
 type logoutRequest struct {
-    UserID string `json:"user_id"`
+    // AllSessions signs the user out everywhere instead of only the calling session.
+    AllSessions bool `json:"all_sessions"`
 }
 
-// Logout invalidates existing tokens by bumping the token version.
+// Logout signs the caller out using the access token the request was made with; the body
+// is optional.
 func (h *Handler) Logout(c *fiber.Ctx) error {
     var req logoutRequest
-    if err := c.BodyParser(&req); err != nil {
-        return fiber.NewError(http.StatusBadRequest, err.Error())
+    if len(c.Body()) > 0 {
+        if err := c.BodyParser(&req); err != nil {
+            return fiber.NewError(http.StatusBadRequest, err.Error())
+        }
     }
-    if req.UserID == "" {
-        return fiber.NewError(http.StatusBadRequest, "user_id is required")
+    token := AccessToken{
+        UserID:    c.Locals("user_id").(string),
+        SessionID: c.Locals("session_id").(string),
+        JTI:       c.Locals("jti").(string),
+        ExpiresAt: c.Locals("token_expires_at").(time.Time),
     }
-    if err := h.svc.Logout(c.UserContext(), req.UserID); err != nil {
-        return fiber.NewError(http.StatusBadRequest, err.Error())
+    if err := h.svc.Logout(c.UserContext(), token, req.AllSessions); err != nil {
+        return fiber.NewError(http.StatusInternalServerError, err.Error())
+    }
+    scope := "session"
+    if req.AllSessions {
+        scope = "all"
     }
-    return c.Status(http.StatusOK).JSON(fiber.Map{"status": "logged_out"})
+    return c.JSON(fiber.Map{"status": "logged_out", "scope": scope})
 }
@@ -13,34 +13,59 @@ var (
     b64 = base64.RawURLEncoding
 )
 
-// SignHS256 creates a compact JWT string using HS256.
-func SignHS256(claims map[string]any, secret []byte) (string, error) {
-    header := map[string]string{"alg": "HS256", "typ": "JWT"}
+// tokenHeader is the JOSE header of a compact JWT.
+type tokenHeader struct {
+    Alg string `json:"alg"`
+    Typ string `json:"typ,omitempty"`
+    Kid string `json:"kid,omitempty"`
+}
+
+// encodeToken joins the header and claims into the unsigned part of a compact JWT.
+func encodeToken(header tokenHeader, claims map[string]any) (string, error) {
     h, err := json.Marshal(header)
     if err != nil { return "", err }
     c, err := json.Marshal(claims)
     if err != nil { return "", err }
-    unsigned := b64.EncodeToString(h) + "." + b64.EncodeToString(c)
+    return b64.EncodeToString(h) + "." + b64.EncodeToString(c), nil
+}
+
+// decodeToken splits a compact JWT into its header, signed part, signature and claims,
+// without verifying anything.
+func decodeToken(token string) (tokenHeader, string, []byte, map[string]any, error) {
+    var header tokenHeader
+    parts := strings.Split(token, ".")
+    if len(parts) != 3 { return header, "", nil, nil, errors.New("invalid token format") }
+    h, err := b64.DecodeString(parts[0])
+    if err != nil { return header, "", nil, nil, errors.New("invalid header encoding") }
+    if err := json.Unmarshal(h, &header); err != nil { return header, "", nil, nil, errors.New("invalid header json") }
+    sig, err := b64.DecodeString(parts[2])
+    if err != nil { return header, "", nil, nil, errors.New("invalid signature encoding") }
+    payload, err := b64.DecodeString(parts[1])
+    if err != nil { return header, "", nil, nil, errors.New("invalid payload encoding") }
+    var claims map[string]any
+    if err := json.Unmarshal(payload, &claims); err != nil { return header, "", nil, nil, errors.New("invalid claims json") }
+    return header, parts[0] + "." + parts[1], sig, claims, nil
+}
+
+// SignHS256 creates a compact JWT string using HS256.
+func SignHS256(claims map[string]any, secret []byte) (string, error) {
+    unsigned, err := encodeToken(tokenHeader{Alg: "HS256", Typ: "JWT"}, claims)
+    if err != nil { return "", err }
     mac := hmac.New(sha256.New, secret)
     mac.Write([]byte(unsigned))
     sig := mac.Sum(nil)
     return unsigned + "." + b64.EncodeToString(sig), nil
 }
 
-// ParseAndVerifyHS256 verifies token signature and returns claims.
+// ParseAndVerifyHS256 verifies an HS256 token's signature and returns its claims. Tokens
+// declaring any other algorithm are rejected. Registered claims are not checked here; see
+// ClaimsPolicy.
 func ParseAndVerifyHS256(token string, secret []byte) (map[string]any, error) {
-    parts := strings.Split(token, ".")
-    if len(parts) != 3 { return nil, errors.New("invalid token format") }
-    unsigned := parts[0] + "." + parts[1]
-    sigBytes, err := b64.DecodeString(parts[2])
-    if err != nil { return nil, errors.New("invalid signature encoding") }
+    header, unsigned, sigBytes, claims, err := decodeToken(token)
+    if err != nil { return nil, err }
+    if header.Alg != "HS256" { return nil, errors.New("unexpected signing algorithm") }
     mac := hmac.New(sha256.New, secret)
     mac.Write([]byte(unsigned))
     if !hmac.Equal(sigBytes, mac.Sum(nil)) { return nil, errors.New("signature mismatch") }
-    payload, err := b64.DecodeString(parts[1])
-    if err != nil { return nil, errors.New("invalid payload encoding") }
-    var claims map[string]any
-    if err := json.Unmarshal(payload, &claims); err != nil { return nil, errors.New("invalid claims json") }
     return claims, nil
 }
-
@@ -0,0 +1,165 @@
+package auth
+
+import (
+    "context"
+    "crypto"
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/rand"
+    "crypto/sha256"
+    "crypto/x509"
+    "errors"
+    "sync"
+    "time"
+
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// KeyStore persists signing keys so every instance signs and publishes the same set.
+type KeyStore interface {
+    // List returns every stored key.
+    List(ctx context.Context) ([]Key, error)
+    Add(ctx context.Context, k Key) error
+    // DeleteExpired drops keys no longer published at now.
+    DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type memoryKeyStore struct {
+    mu   sync.RWMutex
+    keys map[string]Key
+}
+
+// NewMemoryKeyStore builds an in-memory key store for tests and local development. Keys
+// do not survive a restart, so tokens issued before one stop verifying.
+func NewMemoryKeyStore() KeyStore {
+    return &memoryKeyStore{keys: make(map[string]Key)}
+}
+
+func (s *memoryKeyStore) List(_ context.Context) ([]Key, error) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    out := make([]Key, 0, len(s.keys))
+    for _, k := range s.keys {
+        out = append(out, k)
+    }
+    return out, nil
+}
+
+func (s *memoryKeyStore) Add(_ context.Context, k Key) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.keys[k.ID] = k
+    return nil
+}
+
+func (s *memoryKeyStore) DeleteExpired(_ context.Context, now time.Time) error {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    for id, k := range s.keys {
+        if !now.Before(k.ExpiresAt) {
+            delete(s.keys, id)
+        }
+    }
+    return nil
+}
+
+// PostgresKeyStore implements KeyStore using PostgreSQL. Private keys are stored as
+// PKCS#8, sealed with AES-GCM under a key derived from a configured secret.
+type PostgresKeyStore struct {
+    db   *pgxpool.Pool
+    aead cipher.AEAD
+}
+
+// NewPostgresKeyStore builds a Postgres-backed key store sealing keys with secret.
+func NewPostgresKeyStore(db *pgxpool.Pool, secret string) (*PostgresKeyStore, error) {
+    if secret == "" {
+        return nil, errors.New("a key encryption secret is required")
+    }
+    kek := sha256.Sum256([]byte("jwt-signing-keys:" + secret))
+    block, err := aes.NewCipher(kek[:])
+    if err != nil {
+        return nil, err
+    }
+    aead, err := cipher.NewGCM(block)
+    if err != nil {
+        return nil, err
+    }
+    return &PostgresKeyStore{db: db, aead: aead}, nil
+}
+
+func (s *PostgresKeyStore) seal(k Key) ([]byte, error) {
+    der, err := x509.MarshalPKCS8PrivateKey(k.Private)
+    if err != nil {
+        return nil, err
+    }
+    nonce := make([]byte, s.aead.NonceSize())
+    if _, err := rand.Read(nonce); err != nil {
+        return nil, err
+    }
+    // The key ID is bound as additional data so sealed keys cannot be swapped between rows.
+    return s.aead.Seal(nonce, nonce, der, []byte(k.ID)), nil
+}
+
+func (s *PostgresKeyStore) open(id string, sealed []byte) (crypto.Signer, error) {
+    n := s.aead.NonceSize()
+    if len(sealed) < n {
+        return nil, errors.New("sealed key too short")
+    }
+    der, err := s.aead.Open(nil, sealed[:n], sealed[n:], []byte(id))
+    if err != nil {
+        return nil, err
+    }
+    parsed, err := x509.ParsePKCS8PrivateKey(der)
+    if err != nil {
+        return nil, err
+    }
+    signer, ok := parsed.(crypto.Signer)
+    if !ok {
+        return nil, ErrUnsupportedAlgorithm
+    }
+    return signer, nil
+}
+
+// List returns every stored key, unsealed.
+func (s *PostgresKeyStore) List(ctx context.Context) ([]Key, error) {
+    rows, err := s.db.Query(ctx, `SELECT kid, alg, private_key, created_at, activates_at, retires_at, expires_at
+        FROM jwt_signing_keys`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []Key
+    for rows.Next() {
+        var (
+            k      Key
+            sealed []byte
+        )
+        if err := rows.Scan(&k.ID, &k.Algorithm, &sealed, &k.CreatedAt, &k.ActivatesAt, &k.RetiresAt, &k.ExpiresAt); err != nil {
+            return nil, err
+        }
+        if k.Private, err = s.open(k.ID, sealed); err != nil {
+            return nil, err
+        }
+        k.CreatedAt, k.ActivatesAt, k.RetiresAt, k.ExpiresAt = k.CreatedAt.UTC(), k.ActivatesAt.UTC(), k.RetiresAt.UTC(), k.ExpiresAt.UTC()
+        out = append(out, k)
+    }
+    return out, rows.Err()
+}
+
+// Add seals and inserts a key.
+func (s *PostgresKeyStore) Add(ctx context.Context, k Key) error {
+    sealed, err := s.seal(k)
+    if err != nil {
+        return err
+    }
+    _, err = s.db.Exec(ctx, `INSERT INTO jwt_signing_keys (kid, alg, private_key, created_at, activates_at, retires_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+        k.ID, k.Algorithm, sealed, k.CreatedAt.UTC(), k.ActivatesAt.UTC(), k.RetiresAt.UTC(), k.ExpiresAt.UTC())
+    return err
+}
+
+// DeleteExpired drops keys no longer published at now.
+func (s *PostgresKeyStore) DeleteExpired(ctx context.Context, now time.Time) error {
+    _, err := s.db.Exec(ctx, `DELETE FROM jwt_signing_keys WHERE expires_at <= $1`, now.UTC())
+    return err
+}
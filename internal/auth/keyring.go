@@ -0,0 +1,200 @@
+package auth
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "sort"
+    "sync"
+    "time"
+)
+
+// KeyRing holds the access token signing keys loaded from a KeyStore: it signs with the
+// active key, verifies against every published key and rotates on schedule.
+type KeyRing struct {
+    store  KeyStore
+    policy KeyPolicy
+    now    func() time.Time
+
+    mu         sync.RWMutex
+    keys       []Key // newest activation first
+    lastReload time.Time
+}
+
+// NewKeyRing builds a key ring over store. Call Rotate before first use.
+func NewKeyRing(store KeyStore, policy KeyPolicy) (*KeyRing, error) {
+    if policy.Algorithm != AlgEdDSA && policy.Algorithm != AlgES256 {
+        return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, policy.Algorithm)
+    }
+    if policy.RotateEvery <= 0 || policy.Overlap <= 0 {
+        return nil, fmt.Errorf("key rotation period and overlap must be positive")
+    }
+    return &KeyRing{store: store, policy: policy, now: func() time.Time { return time.Now().UTC() }}, nil
+}
+
+// Rotate prunes expired keys and, when the last key retires within the overlap, adds its
+// successor so it is published an overlap ahead of signing. Other instances pick the new
+// key up on their next Rotate.
+func (r *KeyRing) Rotate(ctx context.Context) error {
+    now := r.now()
+    if err := r.store.DeleteExpired(ctx, now); err != nil {
+        return err
+    }
+    if err := r.reload(ctx); err != nil {
+        return err
+    }
+    r.mu.RLock()
+    var latest Key
+    if len(r.keys) > 0 {
+        latest = r.keys[0]
+    }
+    r.mu.RUnlock()
+
+    if latest.ID != "" && latest.RetiresAt.After(now.Add(r.policy.Overlap)) {
+        return nil
+    }
+    activates := now
+    if latest.ID != "" && latest.RetiresAt.After(now) {
+        activates = latest.RetiresAt
+    }
+    retires := activates.Add(r.policy.RotateEvery)
+    key, err := newKey(r.policy.Algorithm, now, activates, retires, retires.Add(r.policy.Overlap))
+    if err != nil {
+        return err
+    }
+    if err := r.store.Add(ctx, key); err != nil {
+        return err
+    }
+    return r.reload(ctx)
+}
+
+func (r *KeyRing) reload(ctx context.Context) error {
+    keys, err := r.store.List(ctx)
+    if err != nil {
+        return err
+    }
+    // Ties (two instances rotating at once) resolve the same way everywhere.
+    sort.Slice(keys, func(i, j int) bool {
+        if !keys[i].ActivatesAt.Equal(keys[j].ActivatesAt) {
+            return keys[i].ActivatesAt.After(keys[j].ActivatesAt)
+        }
+        return keys[i].ID > keys[j].ID
+    })
+    r.mu.Lock()
+    r.keys = keys
+    r.lastReload = r.now()
+    r.mu.Unlock()
+    return nil
+}
+
+// signingKey returns the key that signs at now.
+func (r *KeyRing) signingKey(now time.Time) (Key, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    for _, k := range r.keys {
+        if k.Signs(now) {
+            return k, nil
+        }
+    }
+    return Key{}, fmt.Errorf("no active signing key")
+}
+
+// Sign creates a compact JWT over claims with the active key.
+func (r *KeyRing) Sign(claims map[string]any) (string, error) {
+    key, err := r.signingKey(r.now())
+    if err != nil {
+        return "", err
+    }
+    unsigned, err := encodeToken(tokenHeader{Alg: key.Algorithm, Typ: "JWT", Kid: key.ID}, claims)
+    if err != nil {
+        return "", err
+    }
+    sig, err := key.sign([]byte(unsigned))
+    if err != nil {
+        return "", err
+    }
+    return unsigned + "." + b64.EncodeToString(sig), nil
+}
+
+func (r *KeyRing) lookup(kid string) (Key, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    now := r.now()
+    for _, k := range r.keys {
+        if k.ID == kid && now.Before(k.ExpiresAt) {
+            return k, true
+        }
+    }
+    return Key{}, false
+}
+
+// Verify checks a token's signature against the published key named by its kid and
+// returns its claims. The header algorithm must match the key's; registered claims are
+// left to ClaimsPolicy.
+func (r *KeyRing) Verify(ctx context.Context, token string) (map[string]any, error) {
+    header, unsigned, sig, claims, err := decodeToken(token)
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+    }
+    key, ok := r.lookup(header.Kid)
+    if !ok && header.Kid != "" {
+        // Another instance may have added a key since the last rotation check.
+        r.mu.RLock()
+        stale := r.now().Sub(r.lastReload) > 10*time.Second
+        r.mu.RUnlock()
+        if stale {
+            if err := r.reload(ctx); err != nil {
+                return nil, err
+            }
+            key, ok = r.lookup(header.Kid)
+        }
+    }
+    if !ok {
+        return nil, fmt.Errorf("%w: unknown key", ErrInvalidToken)
+    }
+    if header.Alg != key.Algorithm {
+        return nil, fmt.Errorf("%w: unexpected signing algorithm", ErrInvalidToken)
+    }
+    if !key.verify([]byte(unsigned), sig) {
+        return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+    }
+    return claims, nil
+}
+
+// JWKS returns the public halves of every published key.
+func (r *KeyRing) JWKS() (JWKS, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    now := r.now()
+    set := JWKS{Keys: []JWK{}}
+    for _, k := range r.keys {
+        if !now.Before(k.ExpiresAt) {
+            continue
+        }
+        jwk, err := k.JWK()
+        if err != nil {
+            return JWKS{}, err
+        }
+        set.Keys = append(set.Keys, jwk)
+    }
+    return set, nil
+}
+
+// RunKeyRotation calls Rotate every interval until ctx is cancelled.
+func RunKeyRotation(ctx context.Context, ring *KeyRing, interval time.Duration, logger *slog.Logger) {
+    if interval <= 0 {
+        interval = time.Minute
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := ring.Rotate(ctx); err != nil && logger != nil {
+                logger.Error("jwt key rotation failed", slog.Any("error", err))
+            }
+        }
+    }
+}
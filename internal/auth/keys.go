@@ -0,0 +1,157 @@
+package auth
+
+import (
+    "crypto"
+    "crypto/ecdsa"
+    "crypto/ed25519"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/sha256"
+    "errors"
+    "fmt"
+    "math/big"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Supported asymmetric signing algorithms.
+const (
+    AlgEdDSA = "EdDSA"
+    AlgES256 = "ES256"
+)
+
+// ErrUnsupportedAlgorithm indicates a signing algorithm other than EdDSA or ES256.
+var ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+
+// Key is an access token signing key. A key is published in the JWKS from creation until
+// ExpiresAt, and signs tokens from ActivatesAt until RetiresAt. The gaps on both sides let
+// verifiers pick up a new key before it is used and keep an old one until its tokens lapse.
+type Key struct {
+    ID          string
+    Algorithm   string
+    Private     crypto.Signer
+    CreatedAt   time.Time
+    ActivatesAt time.Time
+    RetiresAt   time.Time
+    ExpiresAt   time.Time
+}
+
+// Signs reports whether the key is the one to sign with at t.
+func (k Key) Signs(t time.Time) bool {
+    return !t.Before(k.ActivatesAt) && t.Before(k.RetiresAt)
+}
+
+// KeyPolicy tunes access token signing keys.
+type KeyPolicy struct {
+    Algorithm string
+    // RotateEvery is how long each key signs tokens.
+    RotateEvery time.Duration
+    // Overlap is how long a key is published before it signs and after it retires; it must
+    // exceed the access token lifetime.
+    Overlap time.Duration
+}
+
+// newKey generates a key of the given algorithm.
+func newKey(alg string, createdAt, activatesAt, retiresAt, expiresAt time.Time) (Key, error) {
+    var (
+        private crypto.Signer
+        err     error
+    )
+    switch alg {
+    case AlgEdDSA:
+        _, private, err = ed25519.GenerateKey(rand.Reader)
+    case AlgES256:
+        private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    default:
+        return Key{}, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, alg)
+    }
+    if err != nil {
+        return Key{}, err
+    }
+    return Key{
+        ID:          uuid.NewString(),
+        Algorithm:   alg,
+        Private:     private,
+        CreatedAt:   createdAt,
+        ActivatesAt: activatesAt,
+        RetiresAt:   retiresAt,
+        ExpiresAt:   expiresAt,
+    }, nil
+}
+
+// sign produces the JWS signature of msg.
+func (k Key) sign(msg []byte) ([]byte, error) {
+    switch priv := k.Private.(type) {
+    case ed25519.PrivateKey:
+        return ed25519.Sign(priv, msg), nil
+    case *ecdsa.PrivateKey:
+        digest := sha256.Sum256(msg)
+        r, s, err := ecdsa.Sign(rand.Reader, priv, digest[:])
+        if err != nil {
+            return nil, err
+        }
+        // JWS wants the fixed-size r || s form, not ASN.1.
+        sig := make([]byte, 64)
+        r.FillBytes(sig[:32])
+        s.FillBytes(sig[32:])
+        return sig, nil
+    default:
+        return nil, ErrUnsupportedAlgorithm
+    }
+}
+
+// verify checks a JWS signature of msg against the key's public half.
+func (k Key) verify(msg, sig []byte) bool {
+    switch pub := k.Private.Public().(type) {
+    case ed25519.PublicKey:
+        return ed25519.Verify(pub, msg, sig)
+    case *ecdsa.PublicKey:
+        if len(sig) != 64 {
+            return false
+        }
+        digest := sha256.Sum256(msg)
+        r := new(big.Int).SetBytes(sig[:32])
+        s := new(big.Int).SetBytes(sig[32:])
+        return ecdsa.Verify(pub, digest[:], r, s)
+    default:
+        return false
+    }
+}
+
+// JWK is the public half of a signing key in JSON Web Key form.
+type JWK struct {
+    Kty string `json:"kty"`
+    Crv string `json:"crv"`
+    X   string `json:"x"`
+    Y   string `json:"y,omitempty"`
+    Kid string `json:"kid"`
+    Alg string `json:"alg"`
+    Use string `json:"use"`
+}
+
+// JWKS is a JSON Web Key Set as served at /.well-known/jwks.json.
+type JWKS struct {
+    Keys []JWK `json:"keys"`
+}
+
+// JWK returns the key's public half.
+func (k Key) JWK() (JWK, error) {
+    jwk := JWK{Kid: k.ID, Alg: k.Algorithm, Use: "sig"}
+    switch pub := k.Private.Public().(type) {
+    case ed25519.PublicKey:
+        jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", b64.EncodeToString(pub)
+    case *ecdsa.PublicKey:
+        ecdhPub, err := pub.ECDH()
+        if err != nil {
+            return JWK{}, err
+        }
+        // Uncompressed point: 0x04 || X || Y.
+        point := ecdhPub.Bytes()
+        jwk.Kty, jwk.Crv = "EC", "P-256"
+        jwk.X, jwk.Y = b64.EncodeToString(point[1:33]), b64.EncodeToString(point[33:])
+    default:
+        return JWK{}, ErrUnsupportedAlgorithm
+    }
+    return jwk, nil
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+    "context"
+    "sort"
+    "sync"
+    "time"
+)
+
+type memoryRepository struct {
+    mu       sync.RWMutex
+    sessions map[string]Session
+}
+
+// NewMemoryRepository builds an in-memory session store for tests and local development.
+func NewMemoryRepository() Repository {
+    return &memoryRepository{sessions: make(map[string]Session)}
+}
+
+func (r *memoryRepository) Create(_ context.Context, s Session) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.sessions[s.ID] = s
+    return nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id string) (Session, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    s, ok := r.sessions[id]
+    if !ok {
+        return Session{}, ErrSessionNotFound
+    }
+    return s, nil
+}
+
+func (r *memoryRepository) ListByUser(_ context.Context, userID string, now time.Time) ([]Session, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    var out []Session
+    for _, s := range r.sessions {
+        if s.UserID == userID && s.Active(now) {
+            out = append(out, s)
+        }
+    }
+    sort.Slice(out, func(i, j int) bool { return out[i].LastUsedAt.After(out[j].LastUsedAt) })
+    return out, nil
+}
+
+func (r *memoryRepository) Rotate(_ context.Context, id, fromJTI, toJTI string, usedAt, expiresAt time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    s, ok := r.sessions[id]
+    if !ok || s.RefreshJTI != fromJTI || !s.RevokedAt.IsZero() {
+        return ErrRefreshReused
+    }
+    s.RefreshJTI, s.LastUsedAt, s.ExpiresAt = toJTI, usedAt, expiresAt
+    r.sessions[id] = s
+    return nil
+}
+
+func (r *memoryRepository) Revoke(_ context.Context, id, reason string, at time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    s, ok := r.sessions[id]
+    if !ok {
+        return ErrSessionNotFound
+    }
+    if s.RevokedAt.IsZero() {
+        s.RevokedAt, s.RevokeReason = at, reason
+        r.sessions[id] = s
+    }
+    return nil
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+    "context"
+    "errors"
+    "time"
+
+    "github.com/google/uuid"
+    "github.com/jackc/pgx/v5"
+    "github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists sessions.
+type Repository interface {
+    Create(ctx context.Context, s Session) error
+    Get(ctx context.Context, id string) (Session, error)
+    // ListByUser returns a user's sessions that are neither revoked nor expired at now,
+    // most recently used first.
+    ListByUser(ctx context.Context, userID string, now time.Time) ([]Session, error)
+    // Rotate swaps the session's refresh token id from fromJTI to toJTI and extends it,
+    // failing with ErrRefreshReused when fromJTI is no longer current.
+    Rotate(ctx context.Context, id, fromJTI, toJTI string, usedAt, expiresAt time.Time) error
+    // Revoke marks a session revoked; revoking it again keeps the first reason.
+    Revoke(ctx context.Context, id, reason string, at time.Time) error
+}
+
+// PostgresRepository implements Repository using PostgreSQL.
+type PostgresRepository struct {
+    db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed session repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+    return &PostgresRepository{db: db}
+}
+
+const sessionColumns = `id::text, user_id::text, device_id, refresh_jti, user_agent, ip, created_at, last_used_at,
+        expires_at, COALESCE(revoked_at, 'epoch'::timestamptz), revoke_reason`
+
+type rowScanner interface {
+    Scan(dest ...any) error
+}
+
+func scanSession(row rowScanner) (Session, error) {
+    var s Session
+    err := row.Scan(&s.ID, &s.UserID, &s.DeviceID, &s.RefreshJTI, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastUsedAt,
+        &s.ExpiresAt, &s.RevokedAt, &s.RevokeReason)
+    if err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            return Session{}, ErrSessionNotFound
+        }
+        return Session{}, err
+    }
+    s.CreatedAt = s.CreatedAt.UTC()
+    s.LastUsedAt = s.LastUsedAt.UTC()
+    s.ExpiresAt = s.ExpiresAt.UTC()
+    if s.RevokedAt.Unix() == 0 {
+        s.RevokedAt = time.Time{}
+    } else {
+        s.RevokedAt = s.RevokedAt.UTC()
+    }
+    return s, nil
+}
+
+// Create inserts a session.
+func (r *PostgresRepository) Create(ctx context.Context, s Session) error {
+    _, err := r.db.Exec(ctx, `INSERT INTO sessions
+        (id, user_id, device_id, refresh_jti, user_agent, ip, created_at, last_used_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+        s.ID, s.UserID, s.DeviceID, s.RefreshJTI, s.UserAgent, s.IP, s.CreatedAt.UTC(), s.LastUsedAt.UTC(), s.ExpiresAt.UTC())
+    return err
+}
+
+// Get fetches a session by ID.
+func (r *PostgresRepository) Get(ctx context.Context, id string) (Session, error) {
+    sessionID, err := uuid.Parse(id)
+    if err != nil {
+        return Session{}, ErrSessionNotFound
+    }
+    return scanSession(r.db.QueryRow(ctx, `SELECT `+sessionColumns+` FROM sessions WHERE id = $1`, sessionID))
+}
+
+// ListByUser returns a user's live sessions, most recently used first.
+func (r *PostgresRepository) ListByUser(ctx context.Context, userID string, now time.Time) ([]Session, error) {
+    uid, err := uuid.Parse(userID)
+    if err != nil {
+        return nil, err
+    }
+    rows, err := r.db.Query(ctx, `SELECT `+sessionColumns+` FROM sessions
+        WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+        ORDER BY last_used_at DESC`, uid, now.UTC())
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []Session
+    for rows.Next() {
+        s, err := scanSession(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, s)
+    }
+    return out, rows.Err()
+}
+
+// Rotate swaps the current refresh token id in a single conditional update.
+func (r *PostgresRepository) Rotate(ctx context.Context, id, fromJTI, toJTI string, usedAt, expiresAt time.Time) error {
+    sessionID, err := uuid.Parse(id)
+    if err != nil {
+        return ErrSessionNotFound
+    }
+    cmd, err := r.db.Exec(ctx, `UPDATE sessions SET refresh_jti = $1, last_used_at = $2, expires_at = $3
+        WHERE id = $4 AND refresh_jti = $5 AND revoked_at IS NULL`, toJTI, usedAt.UTC(), expiresAt.UTC(), sessionID, fromJTI)
+    if err != nil {
+        return err
+    }
+    if cmd.RowsAffected() == 0 {
+        return ErrRefreshReused
+    }
+    return nil
+}
+
+// Revoke marks a session revoked.
+func (r *PostgresRepository) Revoke(ctx context.Context, id, reason string, at time.Time) error {
+    sessionID, err := uuid.Parse(id)
+    if err != nil {
+        return ErrSessionNotFound
+    }
+    cmd, err := r.db.Exec(ctx, `UPDATE sessions SET revoked_at = COALESCE(revoked_at, $1),
+            revoke_reason = CASE WHEN revoked_at IS NULL THEN $2 ELSE revoke_reason END
+        WHERE id = $3`, at.UTC(), reason, sessionID)
+    if err != nil {
+        return err
+    }
+    if cmd.RowsAffected() == 0 {
+        return ErrSessionNotFound
+    }
+    return nil
+}
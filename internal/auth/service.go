@@ -3,93 +3,268 @@ package auth
 import (
     "context"
     "errors"
+    "fmt"
+    "strings"
     "time"
 
+    "github.com/google/uuid"
+
     "github.com/congo-pay/congo_pay/internal/config"
     "github.com/congo-pay/congo_pay/internal/identity"
 )
 
 type Service struct {
-    cfg    config.Config
-    idRepo identity.Repository
+    cfg      config.Config
+    idRepo   identity.Repository
+    sessions Repository
+    keys     *KeyRing
+    denylist Denylist
+    now      func() time.Time
+}
+
+// NewService builds the token service. Access tokens are signed with the key ring so
+// other services can verify them from the JWKS; refresh tokens are only ever read back
+// here and stay HS256 under the refresh secret. Signed-out access tokens are held in
+// denylist until they expire.
+func NewService(cfg config.Config, idRepo identity.Repository, sessions Repository, keys *KeyRing, denylist Denylist) *Service {
+    return &Service{cfg: cfg, idRepo: idRepo, sessions: sessions, keys: keys, denylist: denylist, now: time.Now}
 }
 
-func NewService(cfg config.Config, idRepo identity.Repository) *Service {
-    return &Service{cfg: cfg, idRepo: idRepo}
+// accessPolicy is what access tokens must satisfy.
+func (s *Service) accessPolicy() ClaimsPolicy {
+    return ClaimsPolicy{Issuer: s.cfg.JWTIssuer, Audience: s.cfg.JWTAudience, Leeway: s.cfg.JWTClockSkew}
+}
+
+// refreshPolicy is what refresh tokens must satisfy; they are addressed to the issuer.
+func (s *Service) refreshPolicy() ClaimsPolicy {
+    return ClaimsPolicy{Issuer: s.cfg.JWTIssuer, Audience: s.cfg.JWTIssuer, Leeway: s.cfg.JWTClockSkew}
 }
 
 type TokenPair struct {
     AccessToken  string `json:"access_token"`
     RefreshToken string `json:"refresh_token"`
     ExpiresIn    int64  `json:"expires_in"`
+    SessionID    string `json:"session_id"`
+}
+
+// AccessToken identifies the verified access token a request was made with.
+type AccessToken struct {
+    UserID    string
+    SessionID string
+    JTI       string
+    ExpiresAt time.Time
 }
 
-// Login validates credentials (by delegating to identity.Service) and issues tokens.
-func (s *Service) Login(user identity.User) (TokenPair, error) {
-    access, accessExp, err := s.sign(user, s.cfg.JWTSecret, s.cfg.AccessTokenTTL)
+// Login opens a session for an authenticated user (see identity.Service) and issues its
+// first token pair.
+func (s *Service) Login(ctx context.Context, user identity.User, client ClientInfo) (TokenPair, error) {
+    now := s.now()
+    session := Session{
+        ID:         uuid.NewString(),
+        UserID:     user.ID,
+        DeviceID:   user.DeviceID,
+        RefreshJTI: uuid.NewString(),
+        UserAgent:  client.UserAgent,
+        IP:         client.IP,
+        CreatedAt:  now,
+        LastUsedAt: now,
+        ExpiresAt:  now.Add(s.cfg.RefreshTokenTTL),
+    }
+    if err := s.sessions.Create(ctx, session); err != nil {
+        return TokenPair{}, err
+    }
+    return s.issue(user, session)
+}
+
+// issue signs an access token and the session's current refresh token.
+func (s *Service) issue(user identity.User, session Session) (TokenPair, error) {
+    now := s.now()
+    access, err := s.keys.Sign(s.claims(user, session.ID, uuid.NewString(), s.cfg.JWTAudience, now, s.cfg.AccessTokenTTL))
     if err != nil {
         return TokenPair{}, err
     }
-    refresh, _, err := s.sign(user, s.cfg.RefreshSecret, s.cfg.RefreshTokenTTL)
+    refresh, err := SignHS256(s.claims(user, session.ID, session.RefreshJTI, s.cfg.JWTIssuer, now, s.cfg.RefreshTokenTTL), []byte(s.cfg.RefreshSecret))
     if err != nil {
         return TokenPair{}, err
     }
-    return TokenPair{AccessToken: access, RefreshToken: refresh, ExpiresIn: int64(accessExp.Sub(time.Now()).Seconds())}, nil
+    return TokenPair{AccessToken: access, RefreshToken: refresh, ExpiresIn: int64(s.cfg.AccessTokenTTL.Seconds()), SessionID: session.ID}, nil
 }
 
-func (s *Service) sign(user identity.User, secret string, ttl time.Duration) (string, time.Time, error) {
-    now := time.Now()
-    exp := now.Add(ttl)
-    claims := map[string]any{
+func (s *Service) claims(user identity.User, sessionID, jti, audience string, now time.Time, ttl time.Duration) map[string]any {
+    return map[string]any{
+        "iss": s.cfg.JWTIssuer,
+        "aud": audience,
         "sub": user.ID,
         "phone": user.Phone,
         "tier": user.Tier,
         "ver": user.TokenVersion,
+        "dev": user.DeviceID,
+        "roles": user.Roles,
+        "scope": strings.Join(identity.ScopesFor(user.Roles), " "),
+        "sid": sessionID,
+        "jti": jti,
         "iat": now.Unix(),
-        "exp": exp.Unix(),
+        "nbf": now.Unix(),
+        "exp": now.Add(ttl).Unix(),
+    }
+}
+
+// VerifyAccess checks an access token's signature and registered claims, rejects tokens
+// on the denylist and returns its claims.
+func (s *Service) VerifyAccess(ctx context.Context, token string) (map[string]any, error) {
+    claims, err := s.keys.Verify(ctx, token)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.accessPolicy().Validate(claims, s.now()); err != nil {
+        return nil, err
     }
-    signed, err := SignHS256(claims, []byte(secret))
+    jti, _ := claims["jti"].(string)
+    if jti == "" {
+        return nil, fmt.Errorf("%w: missing jti", ErrInvalidToken)
+    }
+    denied, err := s.denylist.Contains(ctx, jti)
     if err != nil {
-        return "", time.Time{}, err
+        return nil, err
+    }
+    if denied {
+        return nil, ErrTokenRevoked
     }
-    return signed, exp, nil
+    return claims, nil
 }
 
-// Refresh verifies the refresh token and returns a new access token if valid.
-func (s *Service) Refresh(ctx context.Context, refreshToken string) (string, int64, error) {
+// JWKS returns the public keys access tokens are verified with.
+func (s *Service) JWKS() (JWKS, error) {
+    return s.keys.JWKS()
+}
+
+// Refresh exchanges the session's current refresh token for a new pair, rotating the
+// refresh token. Presenting a token that was already rotated means it leaked, so the
+// whole session is revoked.
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (TokenPair, error) {
     claims, err := ParseAndVerifyHS256(refreshToken, []byte(s.cfg.RefreshSecret))
+    if err != nil || s.refreshPolicy().Validate(claims, s.now()) != nil {
+        return TokenPair{}, ErrInvalidRefreshToken
+    }
+    sid, _ := claims["sid"].(string)
+    jti, _ := claims["jti"].(string)
+    if sid == "" || jti == "" {
+        return TokenPair{}, ErrInvalidRefreshToken
+    }
+    session, err := s.sessions.Get(ctx, sid)
+    if errors.Is(err, ErrSessionNotFound) {
+        return TokenPair{}, ErrInvalidRefreshToken
+    }
     if err != nil {
-        return "", 0, errors.New("invalid refresh token")
+        return TokenPair{}, err
+    }
+    now := s.now()
+    if !session.RevokedAt.IsZero() {
+        return TokenPair{}, ErrSessionRevoked
     }
-    sub, _ := claims["sub"].(string)
+    if !now.Before(session.ExpiresAt) {
+        return TokenPair{}, ErrInvalidRefreshToken
+    }
+    if jti != session.RefreshJTI {
+        s.revokeForReuse(ctx, session)
+        return TokenPair{}, ErrRefreshReused
+    }
+
     verFloat, _ := claims["ver"].(float64)
-    ver := int(verFloat)
+    user, err := s.idRepo.FindByID(ctx, session.UserID)
+    if err != nil {
+        return TokenPair{}, errors.New("user not found")
+    }
+    if user.TokenVersion != int(verFloat) {
+        return TokenPair{}, errors.New("token version invalidated")
+    }
+    // Sessions end with their device.
+    device, err := s.idRepo.FindDevice(ctx, user.ID, session.DeviceID)
+    if err != nil || !device.Active() {
+        return TokenPair{}, errors.New("device revoked")
+    }
+
+    next := uuid.NewString()
+    if err := s.sessions.Rotate(ctx, session.ID, jti, next, now, now.Add(s.cfg.RefreshTokenTTL)); err != nil {
+        if errors.Is(err, ErrRefreshReused) {
+            s.revokeForReuse(ctx, session)
+        }
+        return TokenPair{}, err
+    }
+    session.RefreshJTI = next
+    user.DeviceID = session.DeviceID
+    return s.issue(user, session)
+}
+
+// revokeForReuse ends a session whose refresh token was replayed and records it in the
+// user's security audit trail.
+func (s *Service) revokeForReuse(ctx context.Context, session Session) {
+    now := s.now()
+    _ = s.sessions.Revoke(ctx, session.ID, RevokedForReuse, now)
+    _ = s.idRepo.AddSecurityEvent(ctx, identity.SecurityEvent{
+        ID:        uuid.NewString(),
+        UserID:    session.UserID,
+        Kind:      identity.EventRefreshReused,
+        Detail:    fmt.Sprintf("session %s revoked", session.ID),
+        Actor:     "system",
+        CreatedAt: now,
+    })
+}
 
-    user, err := s.idRepo.FindByID(ctx, sub)
+// Sessions lists the user's live sessions, most recently used first.
+func (s *Service) Sessions(ctx context.Context, userID string) ([]Session, error) {
+    return s.sessions.ListByUser(ctx, userID, s.now())
+}
+
+// RevokeSession signs one of the user's sessions out.
+func (s *Service) RevokeSession(ctx context.Context, userID, sessionID string) error {
+    session, err := s.sessions.Get(ctx, sessionID)
     if err != nil {
-        return "", 0, errors.New("user not found")
+        return err
     }
-    if user.TokenVersion != ver {
-        return "", 0, errors.New("token version invalidated")
+    if session.UserID != userID {
+        return ErrSessionNotFound
     }
+    return s.sessions.Revoke(ctx, session.ID, RevokedByUser, s.now())
+}
 
-    // Issue new access token with same version
-    accessClaims := map[string]any{
-        "sub": sub,
-        "ver": ver,
-        "iat": time.Now().Unix(),
-        "exp": time.Now().Add(s.cfg.AccessTokenTTL).Unix(),
+// CheckSession fails unless sessionID is a live session of the user; access tokens die
+// with their session.
+func (s *Service) CheckSession(ctx context.Context, userID, sessionID string) error {
+    session, err := s.sessions.Get(ctx, sessionID)
+    if errors.Is(err, ErrSessionNotFound) {
+        return ErrSessionRevoked
     }
-    signed, err := SignHS256(accessClaims, []byte(s.cfg.JWTSecret))
     if err != nil {
-        return "", 0, err
+        return err
+    }
+    if session.UserID != userID || !session.Active(s.now()) {
+        return ErrSessionRevoked
     }
-    return signed, int64(s.cfg.AccessTokenTTL.Seconds()), nil
+    return nil
 }
 
-// Logout increments token version so older tokens become invalid.
-func (s *Service) Logout(ctx context.Context, userID string) error {
-    user, err := s.idRepo.FindByID(ctx, userID)
+// Logout signs the caller out of the session token belongs to, or out of every session
+// when all is set. The access token itself is denylisted for the rest of its lifetime;
+// signing out everywhere also bumps the token version so every other token dies with it.
+func (s *Service) Logout(ctx context.Context, token AccessToken, all bool) error {
+    now := s.now()
+    if err := s.denylist.Add(ctx, token.JTI, token.ExpiresAt.Sub(now)); err != nil {
+        return err
+    }
+    if !all {
+        return s.RevokeSession(ctx, token.UserID, token.SessionID)
+    }
+    sessions, err := s.sessions.ListByUser(ctx, token.UserID, now)
+    if err != nil {
+        return err
+    }
+    for _, session := range sessions {
+        if err := s.sessions.Revoke(ctx, session.ID, RevokedByUser, now); err != nil {
+            return err
+        }
+    }
+    user, err := s.idRepo.FindByID(ctx, token.UserID)
     if err != nil {
         return err
     }
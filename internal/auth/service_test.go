@@ -0,0 +1,387 @@
+package auth
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "errors"
+    "slices"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/congo-pay/congo_pay/internal/config"
+    "github.com/congo-pay/congo_pay/internal/identity"
+)
+
+type fixture struct {
+    svc   *Service
+    ids   *identity.Service
+    users identity.Repository
+}
+
+func testConfig() config.Config {
+    return config.Config{
+        RefreshSecret:   "refresh-secret",
+        AccessTokenTTL:  15 * time.Minute,
+        RefreshTokenTTL: 720 * time.Hour,
+        JWTIssuer:       "congo-pay",
+        JWTAudience:     "congo-pay-api",
+        JWTClockSkew:    30 * time.Second,
+    }
+}
+
+func newKeyRing(t *testing.T, alg string) *KeyRing {
+    t.Helper()
+    ring, err := NewKeyRing(NewMemoryKeyStore(), KeyPolicy{Algorithm: alg, RotateEvery: 30 * 24 * time.Hour, Overlap: 24 * time.Hour})
+    if err != nil {
+        t.Fatalf("key ring: %v", err)
+    }
+    if err := ring.Rotate(context.Background()); err != nil {
+        t.Fatalf("rotate: %v", err)
+    }
+    return ring
+}
+
+func newFixture(t *testing.T) fixture {
+    users := identity.NewMemoryRepository()
+    return fixture{svc: NewService(testConfig(), users, NewMemoryRepository(), newKeyRing(t, AlgEdDSA), NewMemoryDenylist()), ids: identity.NewService(users, nil), users: users}
+}
+
+func (f fixture) login(t *testing.T, phone, device string) (identity.User, TokenPair) {
+    t.Helper()
+    ctx := context.Background()
+    if _, err := f.ids.LookupByPhone(ctx, phone); err != nil {
+        if _, err := f.ids.Register(ctx, identity.Credentials{Phone: phone, PIN: "7305", DeviceID: device}); err != nil {
+            t.Fatalf("register: %v", err)
+        }
+    }
+    user, err := f.ids.Authenticate(ctx, identity.Credentials{Phone: phone, PIN: "7305", DeviceID: device})
+    if err != nil {
+        t.Fatalf("authenticate: %v", err)
+    }
+    pair, err := f.svc.Login(ctx, user, ClientInfo{UserAgent: "test", IP: "10.0.0.1"})
+    if err != nil {
+        t.Fatalf("login: %v", err)
+    }
+    return user, pair
+}
+
+func TestRefreshRotation(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture(t)
+    user, first := f.login(t, "+242060000047", "device-1")
+
+    second, err := f.svc.Refresh(ctx, first.RefreshToken)
+    if err != nil {
+        t.Fatalf("refresh: %v", err)
+    }
+    if second.SessionID != first.SessionID || second.RefreshToken == first.RefreshToken {
+        t.Fatalf("expected a rotated refresh token in the same session, got %+v", second)
+    }
+    third, err := f.svc.Refresh(ctx, second.RefreshToken)
+    if err != nil {
+        t.Fatalf("second refresh: %v", err)
+    }
+
+    // Replaying a rotated token revokes the whole session, including the latest token.
+    if _, err := f.svc.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrRefreshReused) {
+        t.Fatalf("expected ErrRefreshReused, got %v", err)
+    }
+    if _, err := f.svc.Refresh(ctx, third.RefreshToken); !errors.Is(err, ErrSessionRevoked) {
+        t.Fatalf("expected ErrSessionRevoked, got %v", err)
+    }
+    if err := f.svc.CheckSession(ctx, user.ID, first.SessionID); !errors.Is(err, ErrSessionRevoked) {
+        t.Fatalf("expected access tokens of the session to die, got %v", err)
+    }
+    events, err := f.ids.SecurityEvents(ctx, user.ID, 0)
+    if err != nil || len(events) == 0 || events[0].Kind != identity.EventRefreshReused {
+        t.Fatalf("expected a refresh_reused security event, got %+v (%v)", events, err)
+    }
+
+    if _, err := f.svc.Refresh(ctx, "not-a-token"); !errors.Is(err, ErrInvalidRefreshToken) {
+        t.Fatalf("expected ErrInvalidRefreshToken, got %v", err)
+    }
+}
+
+func TestSessions(t *testing.T) {
+    ctx := context.Background()
+    f := newFixture(t)
+    user, phone := f.login(t, "+242060000048", "device-1")
+    _, web := f.login(t, "+242060000048", "device-1")
+    other, _ := f.login(t, "+242060000049", "device-9")
+
+    sessions, err := f.svc.Sessions(ctx, user.ID)
+    if err != nil || len(sessions) != 2 {
+        t.Fatalf("expected two sessions, got %d (%v)", len(sessions), err)
+    }
+    if err := f.svc.RevokeSession(ctx, other.ID, web.SessionID); !errors.Is(err, ErrSessionNotFound) {
+        t.Fatalf("expected another user's session to be hidden, got %v", err)
+    }
+
+    if err := f.svc.RevokeSession(ctx, user.ID, web.SessionID); err != nil {
+        t.Fatalf("revoke: %v", err)
+    }
+    if _, err := f.svc.Refresh(ctx, web.RefreshToken); !errors.Is(err, ErrSessionRevoked) {
+        t.Fatalf("expected ErrSessionRevoked, got %v", err)
+    }
+    if err := f.svc.CheckSession(ctx, user.ID, phone.SessionID); err != nil {
+        t.Fatalf("other session must survive: %v", err)
+    }
+    sessions, _ = f.svc.Sessions(ctx, user.ID)
+    if len(sessions) != 1 || sessions[0].ID != phone.SessionID {
+        t.Fatalf("expected only the phone session, got %+v", sessions)
+    }
+}
+
+func TestLogout(t *testing.T) {
+    f := newFixture(t)
+    ctx := context.Background()
+    user, first := f.login(t, "+242060000030", "device-1")
+    _, second := f.login(t, user.Phone, "device-1")
+    token := func(pair TokenPair) AccessToken {
+        claims, err := f.svc.VerifyAccess(ctx, pair.AccessToken)
+        if err != nil {
+            t.Fatalf("verify: %v", err)
+        }
+        return AccessToken{UserID: user.ID, SessionID: pair.SessionID, JTI: claims["jti"].(string), ExpiresAt: time.Unix(int64(claims["exp"].(float64)), 0)}
+    }
+
+    if err := f.svc.Logout(ctx, token(first), false); err != nil {
+        t.Fatalf("logout: %v", err)
+    }
+    if _, err := f.svc.VerifyAccess(ctx, first.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+        t.Fatalf("expected the access token to be denylisted, got %v", err)
+    }
+    if err := f.svc.CheckSession(ctx, user.ID, first.SessionID); !errors.Is(err, ErrSessionRevoked) {
+        t.Fatalf("expected the session to end, got %v", err)
+    }
+    if err := f.svc.CheckSession(ctx, user.ID, second.SessionID); err != nil {
+        t.Fatalf("other sessions stay signed in: %v", err)
+    }
+
+    _, third := f.login(t, user.Phone, "device-1")
+    if err := f.svc.Logout(ctx, token(second), true); err != nil {
+        t.Fatalf("logout everywhere: %v", err)
+    }
+    if _, err := f.svc.VerifyAccess(ctx, second.AccessToken); !errors.Is(err, ErrTokenRevoked) {
+        t.Fatalf("expected the access token to be denylisted, got %v", err)
+    }
+    if sessions, err := f.svc.Sessions(ctx, user.ID); err != nil || len(sessions) != 0 {
+        t.Fatalf("sessions = %+v, %v", sessions, err)
+    }
+    if _, err := f.svc.Refresh(ctx, third.RefreshToken); err == nil {
+        t.Fatal("refresh tokens must die with their sessions")
+    }
+}
+
+func TestMemoryDenylist(t *testing.T) {
+    now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+    list := newMemoryDenylist(func() time.Time { return now })
+    ctx := context.Background()
+    _ = list.Add(ctx, "a", time.Minute)
+    _ = list.Add(ctx, "expired", 0)
+    if ok, _ := list.Contains(ctx, "a"); !ok {
+        t.Fatal("expected a to be denied")
+    }
+    if ok, _ := list.Contains(ctx, "expired"); ok {
+        t.Fatal("tokens past expiry need no entry")
+    }
+    now = now.Add(time.Minute)
+    if ok, _ := list.Contains(ctx, "a"); ok {
+        t.Fatal("entries lapse with the token")
+    }
+    _ = list.Add(ctx, "b", time.Minute)
+    if len(list.entries) != 1 {
+        t.Fatalf("lapsed entries must be pruned, got %v", list.entries)
+    }
+}
+
+func TestAccessTokenVerification(t *testing.T) {
+    ctx := context.Background()
+    for _, alg := range []string{AlgEdDSA, AlgES256} {
+        t.Run(alg, func(t *testing.T) {
+            ring := newKeyRing(t, alg)
+            users := identity.NewMemoryRepository()
+            svc := NewService(testConfig(), users, NewMemoryRepository(), ring, NewMemoryDenylist())
+            now := time.Now()
+            svc.now = func() time.Time { return now }
+
+            user := identity.User{ID: "user-1", Phone: "+242060000050", DeviceID: "device-1"}
+            pair, err := svc.issue(user, Session{ID: "session-1", RefreshJTI: "jti-1"})
+            if err != nil {
+                t.Fatalf("issue: %v", err)
+            }
+            claims, err := svc.VerifyAccess(ctx, pair.AccessToken)
+            if err != nil {
+                t.Fatalf("verify: %v", err)
+            }
+            if claims["sub"] != "user-1" || claims["aud"] != "congo-pay-api" || claims["iss"] != "congo-pay" {
+                t.Fatalf("unexpected claims %v", claims)
+            }
+            jwks, err := svc.JWKS()
+            if err != nil || len(jwks.Keys) != 1 || jwks.Keys[0].Alg != alg {
+                t.Fatalf("unexpected jwks %+v (%v)", jwks, err)
+            }
+
+            // Refresh tokens are not access tokens.
+            if _, err := svc.VerifyAccess(ctx, pair.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+                t.Fatalf("expected a refresh token to be rejected, got %v", err)
+            }
+            // Neither is an HS256 token naming our key and keyed with its public half.
+            jwk := jwks.Keys[0]
+            unsigned, _ := encodeToken(tokenHeader{Alg: "HS256", Typ: "JWT", Kid: jwk.Kid}, claims)
+            mac := hmac.New(sha256.New, []byte(jwk.X))
+            mac.Write([]byte(unsigned))
+            forged := unsigned + "." + b64.EncodeToString(mac.Sum(nil))
+            if _, err := svc.VerifyAccess(ctx, forged); !errors.Is(err, ErrInvalidToken) {
+                t.Fatalf("expected an HS256 token to be rejected, got %v", err)
+            }
+
+            now = now.Add(15*time.Minute + 31*time.Second)
+            if _, err := svc.VerifyAccess(ctx, pair.AccessToken); !errors.Is(err, ErrInvalidToken) {
+                t.Fatalf("expected an expired token to be rejected, got %v", err)
+            }
+        })
+    }
+}
+
+func TestRoleClaims(t *testing.T) {
+    f := newFixture(t)
+    ctx := context.Background()
+    user, pair := f.login(t, "+242060000020", "device-1")
+    claims, err := f.svc.VerifyAccess(ctx, pair.AccessToken)
+    if err != nil {
+        t.Fatalf("verify: %v", err)
+    }
+    if claims["scope"] != identity.ScopeWallet {
+        t.Fatalf("customer scope = %v", claims["scope"])
+    }
+
+    if _, err := f.ids.GrantRole(ctx, user.ID, identity.RoleSupport, "admin:test"); err != nil {
+        t.Fatalf("grant: %v", err)
+    }
+    if _, err := f.svc.Refresh(ctx, pair.RefreshToken); err == nil {
+        t.Fatal("a role change must invalidate outstanding tokens")
+    }
+    _, pair = f.login(t, user.Phone, "device-1")
+    claims, err = f.svc.VerifyAccess(ctx, pair.AccessToken)
+    if err != nil {
+        t.Fatalf("verify: %v", err)
+    }
+    scopes := strings.Fields(claims["scope"].(string))
+    if !slices.Contains(scopes, identity.ScopeBackOffice) || slices.Contains(scopes, identity.ScopeRolesWrite) {
+        t.Fatalf("support scopes = %v", scopes)
+    }
+    if roles, _ := claims["roles"].([]any); len(roles) != 2 {
+        t.Fatalf("roles claim = %v", claims["roles"])
+    }
+}
+
+func TestClaimsPolicy(t *testing.T) {
+    now := time.Unix(1_800_000_000, 0)
+    policy := ClaimsPolicy{Issuer: "congo-pay", Audience: "congo-pay-api", Leeway: 30 * time.Second}
+    valid := func() map[string]any {
+        return map[string]any{
+            "iss": "congo-pay",
+            "aud": []any{"ledger", "congo-pay-api"},
+            "iat": float64(now.Unix()),
+            "nbf": float64(now.Unix()),
+            "exp": float64(now.Add(time.Minute).Unix()),
+        }
+    }
+    if err := policy.Validate(valid(), now); err != nil {
+        t.Fatalf("valid claims: %v", err)
+    }
+    cases := map[string]func(map[string]any){
+        "missing exp":      func(c map[string]any) { delete(c, "exp") },
+        "expired":          func(c map[string]any) { c["exp"] = float64(now.Add(-31 * time.Second).Unix()) },
+        "missing iat":      func(c map[string]any) { delete(c, "iat") },
+        "future iat":       func(c map[string]any) { c["iat"] = float64(now.Add(time.Minute).Unix()) },
+        "not yet valid":    func(c map[string]any) { c["nbf"] = float64(now.Add(time.Minute).Unix()) },
+        "wrong issuer":     func(c map[string]any) { c["iss"] = "someone-else" },
+        "wrong audience":   func(c map[string]any) { c["aud"] = "ledger" },
+        "missing audience": func(c map[string]any) { delete(c, "aud") },
+    }
+    for name, mutate := range cases {
+        claims := valid()
+        mutate(claims)
+        if err := policy.Validate(claims, now); !errors.Is(err, ErrInvalidToken) {
+            t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+        }
+    }
+    // Skew within the leeway is tolerated.
+    claims := valid()
+    claims["exp"] = float64(now.Add(-20 * time.Second).Unix())
+    if err := policy.Validate(claims, now); err != nil {
+        t.Fatalf("expected leeway to absorb skew: %v", err)
+    }
+}
+
+func TestKeyRotation(t *testing.T) {
+    ctx := context.Background()
+    store := NewMemoryKeyStore()
+    ring, err := NewKeyRing(store, KeyPolicy{Algorithm: AlgEdDSA, RotateEvery: 7 * 24 * time.Hour, Overlap: 24 * time.Hour})
+    if err != nil {
+        t.Fatalf("key ring: %v", err)
+    }
+    now := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+    ring.now = func() time.Time { return now }
+    if err := ring.Rotate(ctx); err != nil {
+        t.Fatalf("rotate: %v", err)
+    }
+    claims := map[string]any{"sub": "user-1"}
+    first, err := ring.Sign(claims)
+    if err != nil {
+        t.Fatalf("sign: %v", err)
+    }
+
+    // Nothing to do until the key retires within the overlap.
+    now = now.Add(5 * 24 * time.Hour)
+    if err := ring.Rotate(ctx); err != nil {
+        t.Fatalf("rotate: %v", err)
+    }
+    if jwks, _ := ring.JWKS(); len(jwks.Keys) != 1 {
+        t.Fatalf("expected one published key, got %d", len(jwks.Keys))
+    }
+
+    // The successor is published a day ahead but the first key still signs.
+    now = now.Add(24*time.Hour + time.Minute)
+    if err := ring.Rotate(ctx); err != nil {
+        t.Fatalf("rotate: %v", err)
+    }
+    if jwks, _ := ring.JWKS(); len(jwks.Keys) != 2 {
+        t.Fatalf("expected the successor to be published, got %d keys", len(jwks.Keys))
+    }
+    again, _ := ring.Sign(claims)
+    if kid(again) != kid(first) {
+        t.Fatal("expected the first key to keep signing until it retires")
+    }
+
+    // After retirement the successor signs and old tokens still verify.
+    now = now.Add(24 * time.Hour)
+    second, _ := ring.Sign(claims)
+    if kid(second) == kid(first) {
+        t.Fatal("expected the successor to sign after retirement")
+    }
+    if _, err := ring.Verify(ctx, first); err != nil {
+        t.Fatalf("old token during overlap: %v", err)
+    }
+
+    // Once the overlap ends the old key is gone.
+    now = now.Add(24 * time.Hour)
+    if err := ring.Rotate(ctx); err != nil {
+        t.Fatalf("rotate: %v", err)
+    }
+    if _, err := ring.Verify(ctx, first); !errors.Is(err, ErrInvalidToken) {
+        t.Fatalf("expected the retired key to be dropped, got %v", err)
+    }
+    if _, err := ring.Verify(ctx, second); err != nil {
+        t.Fatalf("current token: %v", err)
+    }
+}
+
+func kid(token string) string {
+    header, _, _, _, _ := decodeToken(token)
+    return header.Kid
+}
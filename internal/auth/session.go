@@ -0,0 +1,52 @@
+package auth
+
+import (
+    "errors"
+    "time"
+)
+
+var (
+    // ErrInvalidRefreshToken indicates the refresh token is malformed, expired or unknown.
+    ErrInvalidRefreshToken = errors.New("invalid refresh token")
+    // ErrSessionNotFound indicates no session matches the lookup.
+    ErrSessionNotFound = errors.New("session not found")
+    // ErrSessionRevoked indicates the session was signed out.
+    ErrSessionRevoked = errors.New("session revoked")
+    // ErrRefreshReused indicates a rotated refresh token was presented again.
+    ErrRefreshReused = errors.New("refresh token reused")
+    // ErrTokenRevoked indicates an access token was signed out before it expired.
+    ErrTokenRevoked = errors.New("token revoked")
+)
+
+// Session reasons recorded on revocation.
+const (
+    RevokedByUser   = "signed_out"
+    RevokedForReuse = "refresh_token_reused"
+)
+
+// Session is one sign-in: the family of refresh tokens descending from a login. Each
+// refresh replaces RefreshJTI, so only the latest token in the family is accepted.
+type Session struct {
+    ID           string
+    UserID       string
+    DeviceID     string
+    RefreshJTI   string
+    UserAgent    string
+    IP           string
+    CreatedAt    time.Time
+    LastUsedAt   time.Time
+    ExpiresAt    time.Time
+    RevokedAt    time.Time
+    RevokeReason string
+}
+
+// Active reports whether the session can still be used at t.
+func (s Session) Active(t time.Time) bool {
+    return s.RevokedAt.IsZero() && t.Before(s.ExpiresAt)
+}
+
+// ClientInfo describes the client a session is opened from, for display in session lists.
+type ClientInfo struct {
+    UserAgent string
+    IP        string
+}
@@ -0,0 +1,222 @@
+package billers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// Handler exposes the biller catalog and bill payment endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds a bill payment HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type billerResponse struct {
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	Category        string  `json:"category"`
+	ReferenceFormat string  `json:"reference_format"`
+	ReferenceLabel  string  `json:"reference_label,omitempty"`
+	Fee             FeeRule `json:"fee"`
+	MinAmount       int64   `json:"min_amount"`
+	MaxAmount       int64   `json:"max_amount,omitempty"`
+	PartialPayments bool    `json:"partial_payments"`
+}
+
+func toBillerResponse(i Info) billerResponse {
+	return billerResponse{
+		ID:              i.ID,
+		Name:            i.Name,
+		Category:        i.Category,
+		ReferenceFormat: i.ReferenceFormat,
+		ReferenceLabel:  i.ReferenceLabel,
+		Fee:             i.Fee,
+		MinAmount:       i.MinAmount,
+		MaxAmount:       i.MaxAmount,
+		PartialPayments: i.PartialPayments,
+	}
+}
+
+type validateRequest struct {
+	Reference string `json:"reference"`
+	Amount    int64  `json:"amount"`
+}
+
+type payRequest struct {
+	BillerID     string `json:"biller_id"`
+	Reference    string `json:"reference"`
+	Amount       int64  `json:"amount"`
+	FromWalletID string `json:"from_wallet_id"`
+	ClientTxID   string `json:"client_tx_id"`
+	OTPCode      string `json:"otp_code"`
+}
+
+type receiptResponse struct {
+	ID            string    `json:"id"`
+	BillerID      string    `json:"biller_id"`
+	BillerName    string    `json:"biller_name"`
+	PayerWalletID string    `json:"payer_wallet_id"`
+	Reference     string    `json:"reference"`
+	CustomerName  string    `json:"customer_name,omitempty"`
+	Amount        int64     `json:"amount"`
+	Fee           int64     `json:"fee"`
+	Total         int64     `json:"total"`
+	Status        string    `json:"status"`
+	ReceiptToken  string    `json:"receipt_token,omitempty"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	ClientTxID    string    `json:"client_tx_id"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func toReceiptResponse(p Payment) receiptResponse {
+	return receiptResponse{
+		ID:            p.ID,
+		BillerID:      p.BillerID,
+		BillerName:    p.BillerName,
+		PayerWalletID: p.PayerWalletID,
+		Reference:     p.Reference,
+		CustomerName:  p.CustomerName,
+		Amount:        p.Amount,
+		Fee:           p.Fee,
+		Total:         p.Amount + p.Fee,
+		Status:        p.Status,
+		ReceiptToken:  p.ReceiptToken,
+		FailureReason: p.FailureReason,
+		ClientTxID:    p.ClientTxID,
+		TransactionID: p.TransactionID,
+		CreatedAt:     p.CreatedAt,
+	}
+}
+
+// List returns the biller catalog; ?category=water narrows it.
+func (h *Handler) List(c *fiber.Ctx) error {
+	billers := h.service.Billers(c.Query("category"))
+	out := make([]billerResponse, 0, len(billers))
+	for _, b := range billers {
+		out = append(out, toBillerResponse(b))
+	}
+	return c.JSON(fiber.Map{"billers": out})
+}
+
+// Get returns one biller.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	info, err := h.service.Biller(c.Params("billerId"))
+	if err != nil {
+		return billerError(err)
+	}
+	return c.JSON(toBillerResponse(info))
+}
+
+// Validate looks up a customer reference and quotes the fee before payment.
+func (h *Handler) Validate(c *fiber.Ctx) error {
+	var req validateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	info, bill, err := h.service.Validate(c.UserContext(), c.Params("billerId"), req.Reference)
+	if err != nil {
+		return billerError(err)
+	}
+	resp := fiber.Map{
+		"biller_id":     info.ID,
+		"reference":     bill.Reference,
+		"customer_name": bill.CustomerName,
+		"amount_due":    bill.AmountDue,
+	}
+	if !bill.DueDate.IsZero() {
+		resp["due_date"] = bill.DueDate.Format("2006-01-02")
+	}
+	if amount, err := resolveAmount(info, bill, req.Amount); err == nil {
+		fee := info.Fee.Compute(amount)
+		resp["amount"] = amount
+		resp["fee"] = fee
+		resp["total"] = amount + fee
+	}
+	return c.JSON(resp)
+}
+
+// Pay pays a bill from the authenticated user's wallet and returns the receipt.
+func (h *Handler) Pay(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req payRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	p, err := h.service.Pay(c.UserContext(), PayInput{
+		PayerUserID:  uid,
+		FromWalletID: req.FromWalletID,
+		BillerID:     req.BillerID,
+		Reference:    req.Reference,
+		Amount:       req.Amount,
+		ClientTxID:   req.ClientTxID,
+		OTPCode:      req.OTPCode,
+	})
+	if errors.Is(err, ledger.ErrDuplicateTransaction) && p.ID != "" {
+		// Retries with the same client_tx_id get the original receipt back.
+		return c.Status(http.StatusOK).JSON(toReceiptResponse(p))
+	}
+	if errors.Is(err, ErrBillerUnavailable) && p.ID != "" {
+		// The debit was reversed; the failed receipt tells the customer so.
+		return c.Status(http.StatusBadGateway).JSON(toReceiptResponse(p))
+	}
+	if err != nil {
+		return billerError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toReceiptResponse(p))
+}
+
+// Payments returns the authenticated user's bill payments.
+func (h *Handler) Payments(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	payments, err := h.service.Payments(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]receiptResponse, 0, len(payments))
+	for _, p := range payments {
+		out = append(out, toReceiptResponse(p))
+	}
+	return c.JSON(fiber.Map{"payments": out})
+}
+
+// Receipt returns a bill payment receipt to its payer.
+func (h *Handler) Receipt(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	p, err := h.service.Receipt(c.UserContext(), c.Params("paymentId"), uid)
+	if err != nil {
+		return billerError(err)
+	}
+	return c.JSON(toReceiptResponse(p))
+}
+
+func billerError(err error) error {
+	switch {
+	case errors.Is(err, ErrBillerNotFound), errors.Is(err, ErrPaymentNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalidReference):
+		return fiber.NewError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, ErrBillerUnavailable):
+		return fiber.NewError(http.StatusBadGateway, err.Error())
+	case errors.Is(err, wallet.ErrNotOwner), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return fiber.NewError(http.StatusBadRequest, "insufficient funds")
+	case errors.Is(err, ledger.ErrAccountRestricted),
+		errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
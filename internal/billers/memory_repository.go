@@ -0,0 +1,90 @@
+package billers
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mu       sync.RWMutex
+	payments map[string]Payment
+}
+
+// NewMemoryRepository builds an in-memory bill payment store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{payments: make(map[string]Payment)}
+}
+
+func (m *memoryRepository) CreatePayment(_ context.Context, p Payment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payments[p.ID] = p
+	return nil
+}
+
+func (m *memoryRepository) UpdatePayment(_ context.Context, p Payment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur, ok := m.payments[p.ID]
+	if !ok {
+		return ErrPaymentNotFound
+	}
+	cur.Status, cur.ReceiptToken, cur.FailureReason = p.Status, p.ReceiptToken, p.FailureReason
+	m.payments[p.ID] = cur
+	return nil
+}
+
+func (m *memoryRepository) GetPayment(_ context.Context, id string) (Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.payments[id]
+	if !ok {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return p, nil
+}
+
+func (m *memoryRepository) FindPayment(_ context.Context, payerUserID, clientTxID string) (Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, p := range m.payments {
+		if p.PayerUserID == payerUserID && p.ClientTxID == clientTxID {
+			return p, nil
+		}
+	}
+	return Payment{}, ErrPaymentNotFound
+}
+
+func (m *memoryRepository) ListPayments(_ context.Context, payerUserID string, limit int) ([]Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Payment
+	for _, p := range m.payments {
+		if p.PayerUserID == payerUserID {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *memoryRepository) ListPending(_ context.Context, before time.Time, limit int) ([]Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Payment
+	for _, p := range m.payments {
+		if p.Status == PaymentPending && p.CreatedAt.Before(before) {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,156 @@
+package billers
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Biller categories.
+const (
+	CategoryElectricity = "electricity"
+	CategoryWater       = "water"
+	CategorySchool      = "school"
+	CategoryTV          = "tv"
+	CategoryTelecom     = "telecom"
+)
+
+// Bill payment statuses. A payment is pending between the ledger debit and the biller's
+// confirmation; a failed payment has been refunded to the payer.
+const (
+	PaymentPending   = "pending"
+	PaymentCompleted = "completed"
+	PaymentFailed    = "failed"
+)
+
+// PendingTimeout is how long a payment may stay pending, for example after a crash during
+// confirmation, before RunDue confirms it again or refunds it.
+const PendingTimeout = 10 * time.Minute
+
+// FeeRevenueAccountCode collects convenience fees charged on bill payments.
+const FeeRevenueAccountCode = "revenue:bill_fees"
+
+var (
+	// ErrBillerNotFound indicates no active biller has the requested ID.
+	ErrBillerNotFound = errors.New("biller not found")
+	// ErrInvalidReference indicates the customer reference is malformed or unknown to the biller.
+	ErrInvalidReference = errors.New("invalid customer reference")
+	// ErrBillerUnavailable indicates the biller could not confirm the payment.
+	ErrBillerUnavailable = errors.New("biller unavailable")
+	// ErrPaymentNotFound indicates no bill payment is visible to the caller.
+	ErrPaymentNotFound = errors.New("bill payment not found")
+)
+
+// FeeRule prices the convenience fee added on top of a bill: Fixed plus BasisPoints of the
+// amount, clamped to [Min, Max] (Max zero means uncapped).
+type FeeRule struct {
+	Fixed       int64 `json:"fixed"`
+	BasisPoints int   `json:"basis_points"`
+	Min         int64 `json:"min"`
+	Max         int64 `json:"max"`
+}
+
+// Compute returns the fee for an amount, rounding the proportional part half up.
+func (r FeeRule) Compute(amount int64) int64 {
+	fee := r.Fixed
+	if r.BasisPoints > 0 {
+		fee += (amount*int64(r.BasisPoints) + 5_000) / 10_000
+	}
+	if fee < r.Min {
+		fee = r.Min
+	}
+	if r.Max > 0 && fee > r.Max {
+		fee = r.Max
+	}
+	return fee
+}
+
+// Info describes a biller in the catalog.
+type Info struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	// ReferenceFormat is a regular expression the customer reference must fully match.
+	ReferenceFormat string `json:"reference_format"`
+	// ReferenceLabel tells customers what to enter, e.g. "Meter number".
+	ReferenceLabel string  `json:"reference_label"`
+	Fee            FeeRule `json:"fee"`
+	// SettlementAccount is the ledger account credited with bill amounts; it defaults to
+	// "biller:<id>" and must use the biller: prefix.
+	SettlementAccount string `json:"settlement_account"`
+	MinAmount         int64  `json:"min_amount"`
+	MaxAmount         int64  `json:"max_amount"`
+	// PartialPayments allows paying less or more than the amount due on a bill.
+	PartialPayments bool `json:"partial_payments"`
+
+	pattern *regexp.Regexp
+}
+
+// validate checks the catalog entry and compiles its reference format.
+func (i *Info) validate() error {
+	if i.ID == "" || i.Name == "" {
+		return fmt.Errorf("biller id and name are required")
+	}
+	switch i.Category {
+	case CategoryElectricity, CategoryWater, CategorySchool, CategoryTV, CategoryTelecom:
+	default:
+		return fmt.Errorf("biller %s: unknown category %q", i.ID, i.Category)
+	}
+	pattern, err := regexp.Compile(`^(?:` + i.ReferenceFormat + `)$`)
+	if err != nil {
+		return fmt.Errorf("biller %s: reference format: %w", i.ID, err)
+	}
+	i.pattern = pattern
+	if i.SettlementAccount == "" {
+		i.SettlementAccount = "biller:" + i.ID
+	}
+	if !strings.HasPrefix(i.SettlementAccount, "biller:") {
+		return fmt.Errorf("biller %s: settlement account must start with biller:", i.ID)
+	}
+	if i.Fee.Fixed < 0 || i.Fee.BasisPoints < 0 || i.Fee.BasisPoints > 1_000 || i.Fee.Min < 0 || i.Fee.Max < 0 {
+		return fmt.Errorf("biller %s: invalid fee rule", i.ID)
+	}
+	if i.MinAmount <= 0 {
+		i.MinAmount = 1
+	}
+	if i.MaxAmount > 0 && i.MaxAmount < i.MinAmount {
+		return fmt.Errorf("biller %s: max amount below min amount", i.ID)
+	}
+	return nil
+}
+
+// MatchReference reports whether a reference has the biller's format.
+func (i Info) MatchReference(reference string) bool {
+	return i.pattern != nil && i.pattern.MatchString(reference)
+}
+
+// Bill is what a biller knows about a customer reference.
+type Bill struct {
+	Reference    string
+	CustomerName string
+	// AmountDue is zero for open-amount billers such as prepaid electricity.
+	AmountDue int64
+	DueDate   time.Time
+}
+
+// Payment is a completed or failed bill payment and its receipt.
+type Payment struct {
+	ID            string
+	BillerID      string
+	BillerName    string
+	PayerUserID   string
+	PayerWalletID string
+	Reference     string
+	CustomerName  string
+	Amount        int64
+	Fee           int64
+	Status        string
+	// ReceiptToken is the biller's confirmation, e.g. a prepaid meter token.
+	ReceiptToken  string
+	FailureReason string
+	ClientTxID    string
+	TransactionID string
+	CreatedAt     time.Time
+}
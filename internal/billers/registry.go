@@ -0,0 +1,81 @@
+package billers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Confirmation describes a paid bill sent to the biller.
+type Confirmation struct {
+	PaymentID    string
+	Reference    string
+	Amount       int64
+	CustomerName string
+}
+
+// Biller is implemented by each biller integration.
+type Biller interface {
+	// ValidateReference looks up a customer reference. It returns ErrInvalidReference when
+	// the biller does not know it.
+	ValidateReference(ctx context.Context, reference string) (Bill, error)
+	// ConfirmPayment tells the biller a bill was paid and returns the receipt token to hand
+	// to the customer. It must be safe to call again with the same PaymentID.
+	ConfirmPayment(ctx context.Context, c Confirmation) (string, error)
+}
+
+type entry struct {
+	info   Info
+	biller Biller
+}
+
+// Registry is the catalog of billers customers can pay.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]entry
+}
+
+// NewRegistry builds an empty biller registry.
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]entry)}
+}
+
+// Register adds a biller to the catalog, replacing any biller with the same ID.
+func (r *Registry) Register(info Info, biller Biller) error {
+	if biller == nil {
+		return fmt.Errorf("biller %s: integration is required", info.ID)
+	}
+	if err := info.validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[info.ID] = entry{info: info, biller: biller}
+	return nil
+}
+
+// Get returns a biller's catalog entry and integration.
+func (r *Registry) Get(id string) (Info, Biller, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[id]
+	if !ok {
+		return Info{}, nil, ErrBillerNotFound
+	}
+	return e.info, e.biller, nil
+}
+
+// List returns the catalog sorted by name, optionally narrowed to a category.
+func (r *Registry) List(category string) []Info {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]Info, 0, len(r.entries))
+	for _, e := range r.entries {
+		if category == "" || e.info.Category == category {
+			out = append(out, e.info)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
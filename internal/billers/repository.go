@@ -0,0 +1,152 @@
+package billers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists bill payments.
+type Repository interface {
+	CreatePayment(ctx context.Context, p Payment) error
+	UpdatePayment(ctx context.Context, p Payment) error
+	GetPayment(ctx context.Context, id string) (Payment, error)
+	// FindPayment returns the payment a payer made with a client transaction ID.
+	FindPayment(ctx context.Context, payerUserID, clientTxID string) (Payment, error)
+	ListPayments(ctx context.Context, payerUserID string, limit int) ([]Payment, error)
+	// ListPending returns payments still pending that were made before, oldest first.
+	ListPending(ctx context.Context, before time.Time, limit int) ([]Payment, error)
+}
+
+// PostgresRepository stores bill payments in PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed bill payment repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const paymentColumns = `id::text, biller_id, biller_name, payer_user_id::text, payer_wallet_id::text, reference,
+        customer_name, amount, fee, status, receipt_token, failure_reason, client_tx_id,
+        COALESCE(transaction_id::text, ''), created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row rowScanner) (Payment, error) {
+	var p Payment
+	err := row.Scan(&p.ID, &p.BillerID, &p.BillerName, &p.PayerUserID, &p.PayerWalletID, &p.Reference,
+		&p.CustomerName, &p.Amount, &p.Fee, &p.Status, &p.ReceiptToken, &p.FailureReason, &p.ClientTxID,
+		&p.TransactionID, &p.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Payment{}, ErrPaymentNotFound
+		}
+		return Payment{}, err
+	}
+	p.CreatedAt = p.CreatedAt.UTC()
+	return p, nil
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// CreatePayment stores a bill payment.
+func (r *PostgresRepository) CreatePayment(ctx context.Context, p Payment) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO bill_payments
+        (id, biller_id, biller_name, payer_user_id, payer_wallet_id, reference, customer_name, amount, fee, status,
+         receipt_token, failure_reason, client_tx_id, transaction_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		p.ID, p.BillerID, p.BillerName, p.PayerUserID, p.PayerWalletID, p.Reference, p.CustomerName, p.Amount, p.Fee,
+		p.Status, p.ReceiptToken, p.FailureReason, p.ClientTxID, nullable(p.TransactionID), p.CreatedAt.UTC())
+	return err
+}
+
+// UpdatePayment stores a payment's outcome.
+func (r *PostgresRepository) UpdatePayment(ctx context.Context, p Payment) error {
+	paymentID, err := uuid.Parse(p.ID)
+	if err != nil {
+		return ErrPaymentNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE bill_payments SET status = $1, receipt_token = $2, failure_reason = $3
+        WHERE id = $4`, p.Status, p.ReceiptToken, p.FailureReason, paymentID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrPaymentNotFound
+	}
+	return nil
+}
+
+// GetPayment fetches a bill payment by ID.
+func (r *PostgresRepository) GetPayment(ctx context.Context, id string) (Payment, error) {
+	paymentID, err := uuid.Parse(id)
+	if err != nil {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return scanPayment(r.db.QueryRow(ctx, `SELECT `+paymentColumns+` FROM bill_payments WHERE id = $1`, paymentID))
+}
+
+// FindPayment fetches a payer's bill payment by client transaction ID.
+func (r *PostgresRepository) FindPayment(ctx context.Context, payerUserID, clientTxID string) (Payment, error) {
+	payerID, err := uuid.Parse(payerUserID)
+	if err != nil {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return scanPayment(r.db.QueryRow(ctx, `SELECT `+paymentColumns+` FROM bill_payments
+        WHERE payer_user_id = $1 AND client_tx_id = $2`, payerID, clientTxID))
+}
+
+// ListPayments returns a payer's most recent bill payments.
+func (r *PostgresRepository) ListPayments(ctx context.Context, payerUserID string, limit int) ([]Payment, error) {
+	payerID, err := uuid.Parse(payerUserID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+paymentColumns+` FROM bill_payments
+        WHERE payer_user_id = $1 ORDER BY created_at DESC LIMIT $2`, payerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// ListPending returns payments still pending that were made before, oldest first.
+func (r *PostgresRepository) ListPending(ctx context.Context, before time.Time, limit int) ([]Payment, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+paymentColumns+` FROM bill_payments
+        WHERE status = $1 AND created_at < $2 ORDER BY created_at LIMIT $3`, PaymentPending, before.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
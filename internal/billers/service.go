@@ -0,0 +1,301 @@
+package billers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const (
+	paymentKind  = "bill_payment"
+	reversalKind = "bill_payment_reversal"
+	listLimit    = 50
+)
+
+// Service validates customer references and pays bills through registered billers.
+type Service struct {
+	registry *Registry
+	repo     Repository
+	ledger   ledger.Ledger
+	wallets  *wallet.Service
+	notifier notification.Notifier
+	guard    *guard.Guard
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewService prepares a bill payment service, ensuring the fee revenue account and every
+// registered biller's settlement account exist.
+func NewService(ctx context.Context, registry *Registry, repo Repository, ledgerBackend ledger.Ledger, wallets *wallet.Service, notifier notification.Notifier, outgoing *guard.Guard, logger *slog.Logger) (*Service, error) {
+	if err := ledgerBackend.EnsureAccount(ctx, FeeRevenueAccountCode); err != nil {
+		return nil, err
+	}
+	for _, info := range registry.List("") {
+		if err := ledgerBackend.EnsureAccount(ctx, info.SettlementAccount); err != nil {
+			return nil, err
+		}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Service{
+		registry: registry,
+		repo:     repo,
+		ledger:   ledgerBackend,
+		wallets:  wallets,
+		notifier: notifier,
+		guard:    outgoing,
+		logger:   logger,
+		now:      func() time.Time { return time.Now().UTC() },
+	}, nil
+}
+
+// Billers lists the catalog, optionally narrowed to a category.
+func (s *Service) Billers(category string) []Info {
+	return s.registry.List(category)
+}
+
+// Biller returns one catalog entry.
+func (s *Service) Biller(id string) (Info, error) {
+	info, _, err := s.registry.Get(id)
+	return info, err
+}
+
+// Validate checks a customer reference against the biller's format and its own records.
+func (s *Service) Validate(ctx context.Context, billerID, reference string) (Info, Bill, error) {
+	info, biller, err := s.registry.Get(billerID)
+	if err != nil {
+		return Info{}, Bill{}, err
+	}
+	reference = strings.TrimSpace(reference)
+	if !info.MatchReference(reference) {
+		return Info{}, Bill{}, fmt.Errorf("%w: expected %s", ErrInvalidReference, info.ReferenceLabel)
+	}
+	bill, err := biller.ValidateReference(ctx, reference)
+	if err != nil {
+		return Info{}, Bill{}, err
+	}
+	return info, bill, nil
+}
+
+// resolveAmount applies the biller's amount rules; zero means the amount due.
+func resolveAmount(info Info, bill Bill, amount int64) (int64, error) {
+	if amount == 0 {
+		amount = bill.AmountDue
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("amount is required")
+	}
+	if bill.AmountDue > 0 && !info.PartialPayments && amount != bill.AmountDue {
+		return 0, fmt.Errorf("amount must equal the amount due of %d", bill.AmountDue)
+	}
+	if amount < info.MinAmount || info.MaxAmount > 0 && amount > info.MaxAmount {
+		return 0, fmt.Errorf("amount must be between %d and %d", info.MinAmount, info.MaxAmount)
+	}
+	return amount, nil
+}
+
+// PayInput captures a bill payment. Amount defaults to the bill's amount due;
+// FromWalletID defaults to the payer's primary wallet.
+type PayInput struct {
+	PayerUserID  string
+	FromWalletID string
+	BillerID     string
+	Reference    string
+	Amount       int64
+	ClientTxID   string
+	// OTPCode confirms bills whose total with the fee reaches the high-value amount.
+	OTPCode string
+}
+
+// Pay debits the payer for the bill plus fee, confirms the payment with the biller and
+// returns the receipt. If the biller cannot confirm, the debit is reversed and the failed
+// payment is returned with ErrBillerUnavailable. A repeated ClientTxID returns the original
+// payment with ledger.ErrDuplicateTransaction.
+func (s *Service) Pay(ctx context.Context, input PayInput) (Payment, error) {
+	if input.ClientTxID == "" {
+		input.ClientTxID = uuid.NewString()
+	}
+	if existing, err := s.repo.FindPayment(ctx, input.PayerUserID, input.ClientTxID); err == nil {
+		return existing, ledger.ErrDuplicateTransaction
+	} else if !errors.Is(err, ErrPaymentNotFound) {
+		return Payment{}, err
+	}
+
+	info, bill, err := s.Validate(ctx, input.BillerID, input.Reference)
+	if err != nil {
+		return Payment{}, err
+	}
+	_, biller, _ := s.registry.Get(info.ID)
+	amount, err := resolveAmount(info, bill, input.Amount)
+	if err != nil {
+		return Payment{}, err
+	}
+	fee := info.Fee.Compute(amount)
+
+	var payer wallet.Wallet
+	if input.FromWalletID != "" {
+		payer, err = s.wallets.Get(ctx, input.FromWalletID)
+	} else {
+		payer, err = s.wallets.GetByOwner(ctx, input.PayerUserID)
+	}
+	if err != nil {
+		return Payment{}, err
+	}
+	if payer.OwnerID != input.PayerUserID {
+		return Payment{}, wallet.ErrNotOwner
+	}
+	if err := payer.CanDebit(); err != nil {
+		return Payment{}, err
+	}
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: input.PayerUserID, Op: risk.OpPayment, Amount: amount + fee, OTPCode: input.OTPCode}); err != nil {
+		return Payment{}, err
+	}
+
+	legs := paymentLegs(payer.AccountCode, info, amount, fee)
+	res, err := s.ledger.Post(ctx, paymentKind, ledgerKey(input.PayerUserID, input.ClientTxID), legs)
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		return Payment{}, err
+	}
+
+	p := Payment{
+		ID:            uuid.NewString(),
+		BillerID:      info.ID,
+		BillerName:    info.Name,
+		PayerUserID:   input.PayerUserID,
+		PayerWalletID: payer.ID,
+		Reference:     bill.Reference,
+		CustomerName:  bill.CustomerName,
+		Amount:        amount,
+		Fee:           fee,
+		Status:        PaymentPending,
+		ClientTxID:    input.ClientTxID,
+		TransactionID: res.TransactionID,
+		CreatedAt:     s.now(),
+	}
+	if p.Reference == "" {
+		p.Reference = strings.TrimSpace(input.Reference)
+	}
+	if err := s.repo.CreatePayment(ctx, p); err != nil {
+		return Payment{}, err
+	}
+	return s.confirm(ctx, biller, p, legs)
+}
+
+// paymentLegs debits the payer for the bill plus fee; the payer's leg comes first.
+func paymentLegs(payerAccount string, info Info, amount, fee int64) []ledger.Posting {
+	legs := []ledger.Posting{
+		{AccountCode: payerAccount, Amount: -(amount + fee)},
+		{AccountCode: info.SettlementAccount, Amount: amount},
+	}
+	if fee > 0 {
+		legs = append(legs, ledger.Posting{AccountCode: FeeRevenueAccountCode, Amount: fee})
+	}
+	return legs
+}
+
+func ledgerKey(payerUserID, clientTxID string) string {
+	return payerUserID + ":" + clientTxID
+}
+
+// confirm asks the biller to confirm a pending payment posted with legs, completing it or
+// reversing the debit when the biller cannot confirm.
+func (s *Service) confirm(ctx context.Context, biller Biller, p Payment, legs []ledger.Posting) (Payment, error) {
+	token, confirmErr := biller.ConfirmPayment(ctx, Confirmation{PaymentID: p.ID, Reference: p.Reference, Amount: p.Amount, CustomerName: p.CustomerName})
+	if confirmErr != nil {
+		reversal := make([]ledger.Posting, len(legs))
+		for i, leg := range legs {
+			reversal[i] = ledger.Posting{AccountCode: leg.AccountCode, Amount: -leg.Amount, AllowOverdraft: i > 0}
+		}
+		if _, err := s.ledger.Post(ctx, reversalKind, ledgerKey(p.PayerUserID, p.ClientTxID), reversal); err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+			return Payment{}, fmt.Errorf("reverse bill payment %s: %w", p.ID, err)
+		}
+		p.Status = PaymentFailed
+		p.FailureReason = confirmErr.Error()
+		if err := s.repo.UpdatePayment(ctx, p); err != nil {
+			return Payment{}, err
+		}
+		return p, fmt.Errorf("%w: %v", ErrBillerUnavailable, confirmErr)
+	}
+
+	p.Status = PaymentCompleted
+	p.ReceiptToken = token
+	if err := s.repo.UpdatePayment(ctx, p); err != nil {
+		return Payment{}, err
+	}
+	if s.notifier != nil {
+		_ = s.notifier.Send(ctx, notification.Message{
+			Kind:        notification.KindBillPayment,
+			Destination: p.PayerUserID,
+			Body:        fmt.Sprintf("You paid %d (fee %d) to %s for %s. Receipt: %s", p.Amount, p.Fee, p.BillerName, p.Reference, p.ReceiptToken),
+		})
+	}
+	return p, nil
+}
+
+// RunDue resolves payments left pending for PendingTimeout before now, e.g. by a crash
+// between the debit and the biller's confirmation: the biller is asked again, since
+// confirmation is safe to repeat, and the debit is reversed if it still cannot confirm.
+// It returns how many payments it resolved.
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	pending, err := s.repo.ListPending(ctx, now.Add(-PendingTimeout), listLimit)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, p := range pending {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		if err := s.resolve(ctx, p); err != nil {
+			s.logger.Error("pending bill payment not resolved", slog.String("payment_id", p.ID), slog.String("error", err.Error()))
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (s *Service) resolve(ctx context.Context, p Payment) error {
+	info, biller, err := s.registry.Get(p.BillerID)
+	if err != nil {
+		return err
+	}
+	payer, err := s.wallets.Get(ctx, p.PayerWalletID)
+	if err != nil {
+		return err
+	}
+	_, err = s.confirm(ctx, biller, p, paymentLegs(payer.AccountCode, info, p.Amount, p.Fee))
+	if errors.Is(err, ErrBillerUnavailable) {
+		return nil
+	}
+	return err
+}
+
+// Receipt returns a bill payment to its payer.
+func (s *Service) Receipt(ctx context.Context, paymentID, payerUserID string) (Payment, error) {
+	p, err := s.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		return Payment{}, err
+	}
+	if p.PayerUserID != payerUserID {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return p, nil
+}
+
+// Payments returns the payer's recent bill payments.
+func (s *Service) Payments(ctx context.Context, payerUserID string) ([]Payment, error) {
+	return s.repo.ListPayments(ctx, payerUserID, listLimit)
+}
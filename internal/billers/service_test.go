@@ -0,0 +1,189 @@
+package billers
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/logging"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+type failingBiller struct{ FileBiller }
+
+func (failingBiller) ConfirmPayment(context.Context, Confirmation) (string, error) {
+	return "", errors.New("upstream timeout")
+}
+
+type fixture struct {
+	svc     *Service
+	led     ledger.Ledger
+	wallets *wallet.Service
+	users   *identity.Service
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	registry := NewRegistry()
+	if err := LoadCatalogFile("", registry); err != nil {
+		t.Fatalf("load catalog: %v", err)
+	}
+	err := registry.Register(Info{ID: "down", Name: "Down Utility", Category: CategoryWater, ReferenceFormat: "[0-9]+"}, &failingBiller{})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	led := ledger.NewInMemory()
+	wallets := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+	svc, err := NewService(context.Background(), registry, NewMemoryRepository(), led, wallets, nil, nil, logging.Discard())
+	if err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	return fixture{svc: svc, led: led, wallets: wallets, users: identity.NewService(identity.NewMemoryRepository(), nil)}
+}
+
+func (f fixture) user(t *testing.T, phone string, balance int64) (identity.User, wallet.Wallet) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := f.users.Register(ctx, identity.Credentials{Phone: phone, PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register %s: %v", phone, err)
+	}
+	w, err := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: u.ID})
+	if err != nil {
+		t.Fatalf("wallet: %v", err)
+	}
+	ledger.SeedBalance(f.led, w.AccountCode, balance)
+	return u, w
+}
+
+func TestFeeRuleCompute(t *testing.T) {
+	rule := FeeRule{BasisPoints: 100, Min: 50, Max: 500}
+	for amount, want := range map[int64]int64{1_000: 50, 10_050: 101, 100_000: 500} {
+		if got := rule.Compute(amount); got != want {
+			t.Fatalf("fee(%d) = %d, want %d", amount, got, want)
+		}
+	}
+}
+
+func TestServiceValidate(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	if _, _, err := f.svc.Validate(ctx, "lcde-water", "LC-1234"); !errors.Is(err, ErrInvalidReference) {
+		t.Fatalf("expected malformed reference to fail, got %v", err)
+	}
+	if _, _, err := f.svc.Validate(ctx, "lcde-water", "LC-99999999"); !errors.Is(err, ErrInvalidReference) {
+		t.Fatalf("expected unknown reference to fail, got %v", err)
+	}
+	_, bill, err := f.svc.Validate(ctx, "lcde-water", " LC-00001234 ")
+	if err != nil || bill.CustomerName != "MALONGA Prisca" || bill.AmountDue != 18_500 {
+		t.Fatalf("validate: %+v, %v", bill, err)
+	}
+	if _, _, err := f.svc.Validate(ctx, "nope", "x"); !errors.Is(err, ErrBillerNotFound) {
+		t.Fatalf("expected unknown biller, got %v", err)
+	}
+}
+
+func TestServicePayPrepaidElectricity(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	u, w := f.user(t, "+242060000001", 20_000)
+
+	p, err := f.svc.Pay(ctx, PayInput{PayerUserID: u.ID, BillerID: "e2c-prepaid", Reference: "01234567890", Amount: 10_000, ClientTxID: "bill-1"})
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if p.Status != PaymentCompleted || p.Fee != 100 || !regexp.MustCompile(`^\d{4}(-\d{4}){4}$`).MatchString(p.ReceiptToken) {
+		t.Fatalf("unexpected receipt: %+v", p)
+	}
+	for code, want := range map[string]int64{w.AccountCode: 9_900, "biller:e2c-prepaid": 10_000, FeeRevenueAccountCode: 100} {
+		if got, _ := f.led.Balance(ctx, code); got != want {
+			t.Fatalf("balance %s = %d, want %d", code, got, want)
+		}
+	}
+
+	again, err := f.svc.Pay(ctx, PayInput{PayerUserID: u.ID, BillerID: "e2c-prepaid", Reference: "01234567890", Amount: 10_000, ClientTxID: "bill-1"})
+	if !errors.Is(err, ledger.ErrDuplicateTransaction) || again.ReceiptToken != p.ReceiptToken {
+		t.Fatalf("expected duplicate to return the original receipt, got %+v, %v", again, err)
+	}
+
+	if _, err := f.svc.Pay(ctx, PayInput{PayerUserID: u.ID, BillerID: "lcde-water", Reference: "LC-00001234", Amount: 5_000}); err == nil {
+		t.Fatal("expected partial payment of a water bill to be refused")
+	}
+	water, err := f.svc.Pay(ctx, PayInput{PayerUserID: u.ID, BillerID: "lcde-water", Reference: "LC-00005678"})
+	if err != nil || water.Amount != 7_200 || water.Fee != 100 {
+		t.Fatalf("pay amount due: %+v, %v", water, err)
+	}
+}
+
+func TestServicePayReversesWhenBillerFails(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	u, w := f.user(t, "+242060000011", 5_000)
+
+	p, err := f.svc.Pay(ctx, PayInput{PayerUserID: u.ID, BillerID: "down", Reference: "42", Amount: 3_000})
+	if !errors.Is(err, ErrBillerUnavailable) || p.Status != PaymentFailed || p.FailureReason == "" {
+		t.Fatalf("expected failed payment, got %+v, %v", p, err)
+	}
+	for code, want := range map[string]int64{w.AccountCode: 5_000, "biller:down": 0} {
+		if got, _ := f.led.Balance(ctx, code); got != want {
+			t.Fatalf("balance %s = %d, want %d", code, got, want)
+		}
+	}
+}
+
+func TestServiceRunDueResolvesPendingPayments(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	u, w := f.user(t, "+242060000021", 20_000)
+	now := time.Now().UTC()
+
+	// Payments debited but never confirmed, as if the process stopped in between.
+	pending := func(billerID, clientTxID string, amount int64) Payment {
+		t.Helper()
+		info, _, err := f.svc.registry.Get(billerID)
+		if err != nil {
+			t.Fatalf("biller: %v", err)
+		}
+		fee := info.Fee.Compute(amount)
+		res, err := f.led.Post(ctx, paymentKind, ledgerKey(u.ID, clientTxID), paymentLegs(w.AccountCode, info, amount, fee))
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		p := Payment{ID: uuid.NewString(), BillerID: info.ID, BillerName: info.Name, PayerUserID: u.ID, PayerWalletID: w.ID,
+			Reference: "01234567890", Amount: amount, Fee: fee, Status: PaymentPending, ClientTxID: clientTxID,
+			TransactionID: res.TransactionID, CreatedAt: now}
+		if err := f.svc.repo.CreatePayment(ctx, p); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+		return p
+	}
+	paid := pending("e2c-prepaid", "crash-1", 10_000)
+	refunded := pending("down", "crash-2", 3_000)
+
+	if n, err := f.svc.RunDue(ctx, now.Add(time.Minute)); err != nil || n != 0 {
+		t.Fatalf("expected recent payments to be left alone, got %d, %v", n, err)
+	}
+	if n, err := f.svc.RunDue(ctx, now.Add(PendingTimeout+time.Minute)); err != nil || n != 2 {
+		t.Fatalf("expected two resolved payments, got %d, %v", n, err)
+	}
+	if p, _ := f.svc.Receipt(ctx, paid.ID, u.ID); p.Status != PaymentCompleted || p.ReceiptToken == "" {
+		t.Fatalf("expected the payment to be confirmed, got %+v", p)
+	}
+	if p, _ := f.svc.Receipt(ctx, refunded.ID, u.ID); p.Status != PaymentFailed {
+		t.Fatalf("expected the payment to be refunded, got %+v", p)
+	}
+	for code, want := range map[string]int64{w.AccountCode: 9_900, "biller:e2c-prepaid": 10_000, "biller:down": 0} {
+		if got, _ := f.led.Balance(ctx, code); got != want {
+			t.Fatalf("balance %s = %d, want %d", code, got, want)
+		}
+	}
+	if n, err := f.svc.RunDue(ctx, now.Add(PendingTimeout+time.Minute)); err != nil || n != 0 {
+		t.Fatalf("expected nothing left pending, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,8 @@
+// Package clock holds the local wall clock shared by features that follow the business day.
+package clock
+
+import "time"
+
+// WAT is Congo-Brazzaville's local time. The country observes WAT (UTC+1) all year, so a
+// fixed zone avoids depending on the host tz database.
+var WAT = time.FixedZone("WAT", 60*60)
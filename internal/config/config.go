@@ -4,6 +4,7 @@ import (
     "fmt"
     "os"
     "strconv"
+    "strings"
     "time"
 )
 
@@ -18,8 +19,59 @@ type Config struct {
     RefreshSecret string
     AccessTokenTTL  time.Duration
     RefreshTokenTTL time.Duration
+    // JWTAlgorithm signs access tokens: "EdDSA" or "ES256".
+    JWTAlgorithm string
+    // JWTIssuer and JWTAudience are the iss and aud of access tokens.
+    JWTIssuer   string
+    JWTAudience string
+    // JWTClockSkew is the leeway allowed on exp, nbf and iat.
+    JWTClockSkew time.Duration
+    // JWTKeyRotation is how long each signing key signs before its successor takes over.
+    JWTKeyRotation time.Duration
+    // JWTKeyOverlap is how long a key is published before signing and after retiring; it
+    // must exceed AccessTokenTTL.
+    JWTKeyOverlap time.Duration
+    // JWTKeySecret seals signing keys stored in the database.
+    JWTKeySecret string
     SMSProvider   string
     IdempotencyTTL time.Duration
+    // LedgerSigningKey is a hex-encoded Ed25519 seed used to sign ledger checkpoints.
+    LedgerSigningKey         string
+    LedgerSigningKeyID       string
+    LedgerCheckpointInterval time.Duration
+    // AdminUserIDs lists users granted the admin role at startup. Other back-office roles
+    // are managed through PUT /admin/users/:userId/roles.
+    AdminUserIDs []string
+    // MerchantMDRBasisPoints is the default merchant discount rate applied to new merchants.
+    MerchantMDRBasisPoints int
+    // PublicBaseURL prefixes shareable links such as payment request pages.
+    PublicBaseURL string
+    // SchedulerInterval is how often due standing orders are executed.
+    SchedulerInterval time.Duration
+    // EscrowReleaseAfter is the default delay before held escrow funds are released to the payee.
+    EscrowReleaseAfter time.Duration
+    // BillersFile points to a JSON biller catalog; the built-in stub catalog is used when empty.
+    BillersFile string
+    // AgentCommissionMode is "realtime" (credit per cash transaction) or "daily" (one batch per day).
+    AgentCommissionMode string
+    // AgentCommissionsFile points to a JSON commission schedule; a built-in grid is used when empty.
+    AgentCommissionsFile string
+    // KYCStorageDir is where the local blob store keeps KYC document files.
+    KYCStorageDir string
+    // OTPSecret keys the hash under which one-time codes are stored.
+    OTPSecret string
+    // HighValueTransferAmount is the outgoing payment amount from which an SMS code is
+    // required; 0 disables it.
+    HighValueTransferAmount int64
+    // SIMSwapFile points to a JSON list of SIM swaps standing in for the operator lookup;
+    // no swaps are reported when empty.
+    SIMSwapFile string
+    // SIMSwapWindow is how recent a SIM swap must be to restrict the account.
+    SIMSwapWindow time.Duration
+    // SIMSwapRestriction is how long withdrawals and larger P2P transfers stay blocked.
+    SIMSwapRestriction time.Duration
+    // SIMSwapP2PLimit is the total of P2P transfers allowed while restricted.
+    SIMSwapP2PLimit int64
 }
 
 func (c Config) Addr() string {
@@ -54,6 +106,16 @@ func getduration(key string, def time.Duration) time.Duration {
     return def
 }
 
+func getlist(key string) []string {
+    var out []string
+    for _, v := range strings.Split(os.Getenv(key), ",") {
+        if v = strings.TrimSpace(v); v != "" {
+            out = append(out, v)
+        }
+    }
+    return out
+}
+
 func Load() Config {
     return Config{
         AppName:        getenv("APP_NAME", "CongoPay"),
@@ -66,7 +128,32 @@ func Load() Config {
         RefreshSecret:  getenv("REFRESH_SECRET", getenv("JWT_SECRET", "")),
         AccessTokenTTL:  getduration("ACCESS_TTL", 15*time.Minute),
         RefreshTokenTTL: getduration("REFRESH_TTL", 720*time.Hour),
+        JWTAlgorithm:    getenv("JWT_ALG", "EdDSA"),
+        JWTIssuer:       getenv("JWT_ISSUER", "congo-pay"),
+        JWTAudience:     getenv("JWT_AUDIENCE", "congo-pay-api"),
+        JWTClockSkew:    getduration("JWT_CLOCK_SKEW", 30*time.Second),
+        JWTKeyRotation:  getduration("JWT_KEY_ROTATION", 30*24*time.Hour),
+        JWTKeyOverlap:   getduration("JWT_KEY_OVERLAP", 24*time.Hour),
+        JWTKeySecret:    getenv("JWT_KEY_SECRET", getenv("JWT_SECRET", "")),
         SMSProvider:    getenv("SMS_PROVIDER", ""),
         IdempotencyTTL: getduration("IDEMPOTENCY_TTL", 10*time.Minute),
+        LedgerSigningKey:         getenv("LEDGER_SIGNING_KEY", ""),
+        LedgerSigningKeyID:       getenv("LEDGER_SIGNING_KEY_ID", "ledger-1"),
+        LedgerCheckpointInterval: getduration("LEDGER_CHECKPOINT_INTERVAL", time.Hour),
+        AdminUserIDs:             getlist("ADMIN_USER_IDS"),
+        MerchantMDRBasisPoints:   getint("MERCHANT_MDR_BPS", 100),
+        PublicBaseURL:            getenv("PUBLIC_BASE_URL", "http://localhost:8080"),
+        SchedulerInterval:        getduration("SCHEDULER_INTERVAL", time.Minute),
+        EscrowReleaseAfter:       getduration("ESCROW_RELEASE_AFTER", 7*24*time.Hour),
+        BillersFile:              getenv("BILLERS_FILE", ""),
+        AgentCommissionMode:      getenv("AGENT_COMMISSION_MODE", "realtime"),
+        AgentCommissionsFile:     getenv("AGENT_COMMISSIONS_FILE", ""),
+        KYCStorageDir:            getenv("KYC_STORAGE_DIR", "data/kyc"),
+        OTPSecret:                getenv("OTP_SECRET", getenv("JWT_SECRET", "")),
+        HighValueTransferAmount:  int64(getint("HIGH_VALUE_TRANSFER_AMOUNT", 500000)),
+        SIMSwapFile:              getenv("SIM_SWAP_FILE", ""),
+        SIMSwapWindow:            getduration("SIM_SWAP_WINDOW", 72*time.Hour),
+        SIMSwapRestriction:       getduration("SIM_SWAP_RESTRICTION", 48*time.Hour),
+        SIMSwapP2PLimit:          int64(getint("SIM_SWAP_P2P_LIMIT", 10000)),
     }
 }
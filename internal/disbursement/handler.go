@@ -0,0 +1,268 @@
+package disbursement
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// Handler exposes bulk disbursement endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds a disbursement HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type fundingResponse struct {
+	Required      int64 `json:"required"`
+	SourceBalance int64 `json:"source_balance"`
+	Shortfall     int64 `json:"shortfall"`
+}
+
+type batchResponse struct {
+	ID             string           `json:"id"`
+	SourceWalletID string           `json:"source_wallet_id"`
+	Name           string           `json:"name,omitempty"`
+	Status         string           `json:"status"`
+	TotalAmount    int64            `json:"total_amount"`
+	RowCount       int              `json:"row_count"`
+	InvalidCount   int              `json:"invalid_count"`
+	SucceededCount int              `json:"succeeded_count"`
+	FailedCount    int              `json:"failed_count"`
+	Processed      int              `json:"processed"`
+	CreatedAt      time.Time        `json:"created_at"`
+	StartedAt      *time.Time       `json:"started_at,omitempty"`
+	CompletedAt    *time.Time       `json:"completed_at,omitempty"`
+	Funding        *fundingResponse `json:"funding,omitempty"`
+}
+
+func toBatchResponse(b Batch) batchResponse {
+	resp := batchResponse{
+		ID:             b.ID,
+		SourceWalletID: b.SourceWalletID,
+		Name:           b.Name,
+		Status:         b.Status,
+		TotalAmount:    b.TotalAmount,
+		RowCount:       b.RowCount,
+		InvalidCount:   b.InvalidCount,
+		SucceededCount: b.SucceededCount,
+		FailedCount:    b.FailedCount,
+		Processed:      b.Processed(),
+		CreatedAt:      b.CreatedAt,
+	}
+	if !b.StartedAt.IsZero() {
+		startedAt := b.StartedAt
+		resp.StartedAt = &startedAt
+	}
+	if !b.CompletedAt.IsZero() {
+		completedAt := b.CompletedAt
+		resp.CompletedAt = &completedAt
+	}
+	return resp
+}
+
+func (h *Handler) withFunding(c *fiber.Ctx, b Batch) (batchResponse, error) {
+	resp := toBatchResponse(b)
+	if b.Status != BatchValidated {
+		return resp, nil
+	}
+	f, err := h.service.Funding(c.UserContext(), b)
+	if err != nil {
+		return resp, err
+	}
+	resp.Funding = &fundingResponse{Required: f.Required, SourceBalance: f.SourceBalance, Shortfall: f.Shortfall}
+	return resp, nil
+}
+
+type itemResponse struct {
+	Row           int    `json:"row"`
+	Recipient     string `json:"recipient"`
+	WalletID      string `json:"wallet_id,omitempty"`
+	Amount        int64  `json:"amount"`
+	Reference     string `json:"reference,omitempty"`
+	Status        string `json:"status"`
+	Error         string `json:"error,omitempty"`
+	TransactionID string `json:"transaction_id,omitempty"`
+}
+
+func toItemResponses(items []Item) []itemResponse {
+	out := make([]itemResponse, 0, len(items))
+	for _, it := range items {
+		out = append(out, itemResponse{
+			Row:           it.Row,
+			Recipient:     it.Recipient,
+			WalletID:      it.WalletID,
+			Amount:        it.Amount,
+			Reference:     it.Reference,
+			Status:        it.Status,
+			Error:         it.Error,
+			TransactionID: it.TransactionID,
+		})
+	}
+	return out
+}
+
+// Create uploads a batch, either as a multipart "file" (CSV or JSON) or as a JSON body with
+// an "items" array, and returns the validation outcome and funding requirement.
+func (h *Handler) Create(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var (
+		input = CreateInput{OwnerUserID: uid}
+		err   error
+	)
+	if strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		input.SourceWalletID = c.FormValue("source_wallet_id")
+		input.Name = c.FormValue("name")
+		input.Rows, err = parseUpload(c)
+	} else {
+		var body struct {
+			SourceWalletID string     `json:"source_wallet_id"`
+			Name           string     `json:"name"`
+			Items          []RowInput `json:"items"`
+		}
+		err = c.BodyParser(&body)
+		input.SourceWalletID, input.Name, input.Rows = body.SourceWalletID, body.Name, body.Items
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	batch, items, err := h.service.Create(c.UserContext(), input)
+	if err != nil {
+		return disbursementError(err)
+	}
+	resp, err := h.withFunding(c, batch)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	var invalid []Item
+	for _, it := range items {
+		if it.Status == ItemInvalid {
+			invalid = append(invalid, it)
+		}
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"batch":         resp,
+		"invalid_items": toItemResponses(invalid),
+	})
+}
+
+func parseUpload(c *fiber.Ctx) ([]RowInput, error) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("file is required")
+	}
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(header.Filename)) {
+	case ".csv":
+		return ParseCSV(f)
+	case ".json":
+		return ParseJSON(f)
+	default:
+		return nil, fmt.Errorf("file must be .csv or .json")
+	}
+}
+
+// List returns the authenticated user's recent batches.
+func (h *Handler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	batches, err := h.service.List(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]batchResponse, 0, len(batches))
+	for _, b := range batches {
+		out = append(out, toBatchResponse(b))
+	}
+	return c.JSON(fiber.Map{"batches": out})
+}
+
+// Get returns a batch with its progress and, before execution, its funding requirement.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	b, err := h.service.Get(c.UserContext(), c.Params("batchId"), uid)
+	if err != nil {
+		return disbursementError(err)
+	}
+	resp, err := h.withFunding(c, b)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(resp)
+}
+
+// Items lists a batch's rows; ?status=failed narrows to failures.
+func (h *Handler) Items(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	items, err := h.service.Items(c.UserContext(), c.Params("batchId"), uid, c.Query("status"))
+	if err != nil {
+		return disbursementError(err)
+	}
+	return c.JSON(fiber.Map{"items": toItemResponses(items)})
+}
+
+type executeRequest struct {
+	OTPCode string `json:"otp_code"`
+}
+
+// Execute starts paying a validated batch.
+func (h *Handler) Execute(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req executeRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		}
+	}
+	b, err := h.service.Execute(c.UserContext(), c.Params("batchId"), uid, req.OTPCode)
+	if err != nil {
+		return disbursementError(err)
+	}
+	return c.Status(http.StatusAccepted).JSON(toBatchResponse(b))
+}
+
+// Result downloads the per-row outcome as CSV.
+func (h *Handler) Result(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	items, err := h.service.Items(c.UserContext(), c.Params("batchId"), uid, "")
+	if err != nil {
+		return disbursementError(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteResultCSV(&buf, items); err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="disbursement-%s.csv"`, c.Params("batchId")))
+	return c.Send(buf.Bytes())
+}
+
+func disbursementError(err error) error {
+	switch {
+	case errors.Is(err, ErrBatchNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, wallet.ErrNotOwner), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrBatchNotExecutable), errors.Is(err, ErrInsufficientFunding),
+		errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
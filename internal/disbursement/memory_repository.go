@@ -0,0 +1,110 @@
+package disbursement
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mu      sync.RWMutex
+	batches map[string]Batch
+	items   map[string][]Item
+}
+
+// NewMemoryRepository builds an in-memory disbursement store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{batches: make(map[string]Batch), items: make(map[string][]Item)}
+}
+
+func (m *memoryRepository) CreateBatch(_ context.Context, b Batch, items []Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.batches[b.ID] = b
+	m.items[b.ID] = append([]Item(nil), items...)
+	return nil
+}
+
+func (m *memoryRepository) GetBatch(_ context.Context, id string) (Batch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	b, ok := m.batches[id]
+	if !ok {
+		return Batch{}, ErrBatchNotFound
+	}
+	return b, nil
+}
+
+func (m *memoryRepository) ListBatches(_ context.Context, ownerUserID string, limit int) ([]Batch, error) {
+	out := m.filter(func(b Batch) bool { return b.OwnerUserID == ownerUserID })
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *memoryRepository) ListBatchesByStatus(_ context.Context, status string) ([]Batch, error) {
+	out := m.filter(func(b Batch) bool { return b.Status == status })
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (m *memoryRepository) filter(match func(Batch) bool) []Batch {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Batch
+	for _, b := range m.batches {
+		if match(b) {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+func (m *memoryRepository) UpdateBatch(_ context.Context, b Batch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.batches[b.ID]; !ok {
+		return ErrBatchNotFound
+	}
+	m.batches[b.ID] = b
+	return nil
+}
+
+func (m *memoryRepository) StartBatch(_ context.Context, id string, startedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.batches[id]
+	if !ok {
+		return ErrBatchNotFound
+	}
+	if b.Status != BatchValidated {
+		return ErrBatchNotExecutable
+	}
+	b.Status = BatchProcessing
+	b.StartedAt = startedAt
+	m.batches[id] = b
+	return nil
+}
+
+func (m *memoryRepository) ListItems(_ context.Context, batchID string) ([]Item, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Item(nil), m.items[batchID]...), nil
+}
+
+func (m *memoryRepository) UpdateItem(_ context.Context, item Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.items[item.BatchID]
+	for i := range items {
+		if items[i].Row == item.Row {
+			item.UpdatedAt = time.Now().UTC()
+			items[i] = item
+			return nil
+		}
+	}
+	return ErrBatchNotFound
+}
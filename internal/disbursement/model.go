@@ -0,0 +1,79 @@
+package disbursement
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Batch statuses.
+const (
+	// BatchValidated means every row resolved to a creditable wallet; the batch can be executed.
+	BatchValidated = "validated"
+	// BatchInvalid means at least one row failed validation; the batch must be re-uploaded.
+	BatchInvalid             = "invalid"
+	BatchProcessing          = "processing"
+	BatchCompleted           = "completed"
+	BatchCompletedWithErrors = "completed_with_errors"
+)
+
+// Item statuses.
+const (
+	ItemInvalid   = "invalid"
+	ItemPending   = "pending"
+	ItemSucceeded = "succeeded"
+	ItemFailed    = "failed"
+)
+
+var (
+	// ErrBatchNotFound indicates no batch matches the lookup for this owner.
+	ErrBatchNotFound = errors.New("disbursement batch not found")
+	// ErrBatchNotExecutable indicates the batch is invalid or already started.
+	ErrBatchNotExecutable = errors.New("disbursement batch cannot be executed")
+	// ErrInsufficientFunding indicates the source wallet cannot cover the batch total.
+	ErrInsufficientFunding = errors.New("source wallet balance does not cover the batch")
+)
+
+// Batch is an uploaded set of payouts from one source wallet.
+type Batch struct {
+	ID             string
+	OwnerUserID    string
+	SourceWalletID string
+	Name           string
+	Status         string
+	TotalAmount    int64
+	RowCount       int
+	InvalidCount   int
+	SucceededCount int
+	FailedCount    int
+	CreatedAt      time.Time
+	StartedAt      time.Time
+	CompletedAt    time.Time
+}
+
+// Processed returns how many rows have reached a final state during execution.
+func (b Batch) Processed() int {
+	return b.SucceededCount + b.FailedCount
+}
+
+// Item is one row of a batch.
+type Item struct {
+	BatchID string
+	// Row is the 1-based position of the row in the uploaded file, excluding any header.
+	Row       int
+	Recipient string
+	WalletID  string
+	Amount    int64
+	Reference string
+	Status    string
+	// Error explains why the row is invalid or failed.
+	Error         string
+	TransactionID string
+	UpdatedAt     time.Time
+}
+
+// ClientTxID derives the per-row idempotency key from the batch ID, so re-running a batch
+// never pays a row twice.
+func (i Item) ClientTxID() string {
+	return "disbursement:" + i.BatchID + ":" + strconv.Itoa(i.Row)
+}
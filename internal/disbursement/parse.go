@@ -0,0 +1,149 @@
+package disbursement
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// MaxRows bounds the size of a single batch.
+const MaxRows = 5_000
+
+// RowInput is an unvalidated row of an uploaded batch. Recipient is a phone number or a
+// wallet ID; Amount is kept raw so parse errors are reported per row.
+type RowInput struct {
+	Recipient string `json:"recipient"`
+	Amount    string `json:"amount"`
+	Reference string `json:"reference"`
+}
+
+// UnmarshalJSON accepts amounts as JSON numbers or strings.
+func (r *RowInput) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Recipient string          `json:"recipient"`
+		Phone     string          `json:"phone"`
+		WalletID  string          `json:"wallet_id"`
+		Amount    json.RawMessage `json:"amount"`
+		Reference string          `json:"reference"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.Recipient = firstNonEmpty(raw.Recipient, raw.WalletID, raw.Phone)
+	r.Amount = strings.Trim(string(raw.Amount), `"`)
+	r.Reference = raw.Reference
+	return nil
+}
+
+// ParseCSV reads rows from a CSV file with a header naming the recipient (or phone /
+// wallet_id), amount and reference columns.
+func ParseCSV(r io.Reader) ([]RowInput, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	recipientCol, ok := firstColumn(cols, "recipient", "wallet_id", "phone")
+	if !ok {
+		return nil, fmt.Errorf("header must include a recipient, phone or wallet_id column")
+	}
+	amountCol, ok := cols["amount"]
+	if !ok {
+		return nil, fmt.Errorf("header must include an amount column")
+	}
+	referenceCol, hasReference := cols["reference"]
+
+	var rows []RowInput
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("batch exceeds %d rows", MaxRows)
+		}
+		row := RowInput{Recipient: field(record, recipientCol), Amount: field(record, amountCol)}
+		if hasReference {
+			row.Reference = field(record, referenceCol)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ParseJSON reads rows from either a bare JSON array or an object with an "items" array.
+func ParseJSON(r io.Reader) ([]RowInput, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var rows []RowInput
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &rows)
+	} else {
+		var wrapper struct {
+			Items []RowInput `json:"items"`
+		}
+		err = json.Unmarshal(data, &wrapper)
+		rows = wrapper.Items
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxRows {
+		return nil, fmt.Errorf("batch exceeds %d rows", MaxRows)
+	}
+	return rows, nil
+}
+
+func parseAmount(raw string) (int64, error) {
+	amount, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("amount %q is not a whole number", raw)
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("amount must be positive")
+	}
+	return amount, nil
+}
+
+func field(record []string, i int) string {
+	if i < len(record) {
+		return strings.TrimSpace(record[i])
+	}
+	return ""
+}
+
+func firstColumn(cols map[string]int, names ...string) (int, bool) {
+	for _, n := range names {
+		if i, ok := cols[n]; ok {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,224 @@
+package disbursement
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists disbursement batches and their rows.
+type Repository interface {
+	CreateBatch(ctx context.Context, b Batch, items []Item) error
+	GetBatch(ctx context.Context, id string) (Batch, error)
+	ListBatches(ctx context.Context, ownerUserID string, limit int) ([]Batch, error)
+	ListBatchesByStatus(ctx context.Context, status string) ([]Batch, error)
+	UpdateBatch(ctx context.Context, b Batch) error
+	// StartBatch moves a validated batch to processing, failing with ErrBatchNotExecutable
+	// if it is no longer validated, so a batch is started only once.
+	StartBatch(ctx context.Context, id string, startedAt time.Time) error
+	ListItems(ctx context.Context, batchID string) ([]Item, error)
+	UpdateItem(ctx context.Context, item Item) error
+}
+
+// PostgresRepository stores batches in PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed disbursement repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const batchColumns = `id::text, owner_user_id::text, source_wallet_id::text, name, status, total_amount,
+        row_count, invalid_count, succeeded_count, failed_count, created_at, started_at, completed_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBatch(row rowScanner) (Batch, error) {
+	var (
+		b                      Batch
+		startedAt, completedAt *time.Time
+	)
+	err := row.Scan(&b.ID, &b.OwnerUserID, &b.SourceWalletID, &b.Name, &b.Status, &b.TotalAmount,
+		&b.RowCount, &b.InvalidCount, &b.SucceededCount, &b.FailedCount, &b.CreatedAt, &startedAt, &completedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Batch{}, ErrBatchNotFound
+		}
+		return Batch{}, err
+	}
+	b.CreatedAt = b.CreatedAt.UTC()
+	if startedAt != nil {
+		b.StartedAt = startedAt.UTC()
+	}
+	if completedAt != nil {
+		b.CompletedAt = completedAt.UTC()
+	}
+	return b, nil
+}
+
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// CreateBatch stores a batch and all its rows atomically.
+func (r *PostgresRepository) CreateBatch(ctx context.Context, b Batch, items []Item) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `INSERT INTO disbursement_batches
+        (id, owner_user_id, source_wallet_id, name, status, total_amount, row_count, invalid_count, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		b.ID, b.OwnerUserID, b.SourceWalletID, b.Name, b.Status, b.TotalAmount, b.RowCount, b.InvalidCount, b.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	rows := make([][]any, 0, len(items))
+	for _, it := range items {
+		rows = append(rows, []any{b.ID, it.Row, it.Recipient, nullable(it.WalletID), it.Amount, it.Reference, it.Status, it.Error, b.CreatedAt.UTC()})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"disbursement_items"},
+		[]string{"batch_id", "row_number", "recipient", "wallet_id", "amount", "reference", "status", "error", "updated_at"},
+		pgx.CopyFromRows(rows))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// GetBatch fetches a batch by ID.
+func (r *PostgresRepository) GetBatch(ctx context.Context, id string) (Batch, error) {
+	batchID, err := uuid.Parse(id)
+	if err != nil {
+		return Batch{}, ErrBatchNotFound
+	}
+	return scanBatch(r.db.QueryRow(ctx, `SELECT `+batchColumns+` FROM disbursement_batches WHERE id = $1`, batchID))
+}
+
+// ListBatches returns an owner's most recent batches.
+func (r *PostgresRepository) ListBatches(ctx context.Context, ownerUserID string, limit int) ([]Batch, error) {
+	ownerID, err := uuid.Parse(ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	return r.listBatches(ctx, `SELECT `+batchColumns+` FROM disbursement_batches
+        WHERE owner_user_id = $1 ORDER BY created_at DESC LIMIT $2`, ownerID, limit)
+}
+
+// ListBatchesByStatus returns all batches in a status, oldest first.
+func (r *PostgresRepository) ListBatchesByStatus(ctx context.Context, status string) ([]Batch, error) {
+	return r.listBatches(ctx, `SELECT `+batchColumns+` FROM disbursement_batches
+        WHERE status = $1 ORDER BY created_at`, status)
+}
+
+func (r *PostgresRepository) listBatches(ctx context.Context, query string, args ...any) ([]Batch, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Batch
+	for rows.Next() {
+		b, err := scanBatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// UpdateBatch stores batch status, counters and timestamps.
+func (r *PostgresRepository) UpdateBatch(ctx context.Context, b Batch) error {
+	batchID, err := uuid.Parse(b.ID)
+	if err != nil {
+		return ErrBatchNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE disbursement_batches
+        SET status = $1, succeeded_count = $2, failed_count = $3, started_at = $4, completed_at = $5
+        WHERE id = $6`,
+		b.Status, b.SucceededCount, b.FailedCount, nullableTime(b.StartedAt), nullableTime(b.CompletedAt), batchID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrBatchNotFound
+	}
+	return nil
+}
+
+// StartBatch moves a validated batch to processing in one conditional statement.
+func (r *PostgresRepository) StartBatch(ctx context.Context, id string, startedAt time.Time) error {
+	batchID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrBatchNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE disbursement_batches SET status = $1, started_at = $2 WHERE id = $3 AND status = $4`,
+		BatchProcessing, startedAt.UTC(), batchID, BatchValidated)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrBatchNotExecutable
+	}
+	return nil
+}
+
+// ListItems returns a batch's rows in file order.
+func (r *PostgresRepository) ListItems(ctx context.Context, batchID string) ([]Item, error) {
+	id, err := uuid.Parse(batchID)
+	if err != nil {
+		return nil, ErrBatchNotFound
+	}
+	rows, err := r.db.Query(ctx, `SELECT batch_id::text, row_number, recipient, COALESCE(wallet_id::text, ''), amount,
+        reference, status, error, COALESCE(transaction_id::text, ''), updated_at
+        FROM disbursement_items WHERE batch_id = $1 ORDER BY row_number`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Item
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.BatchID, &it.Row, &it.Recipient, &it.WalletID, &it.Amount,
+			&it.Reference, &it.Status, &it.Error, &it.TransactionID, &it.UpdatedAt); err != nil {
+			return nil, err
+		}
+		it.UpdatedAt = it.UpdatedAt.UTC()
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// UpdateItem stores a row's execution outcome.
+func (r *PostgresRepository) UpdateItem(ctx context.Context, item Item) error {
+	batchID, err := uuid.Parse(item.BatchID)
+	if err != nil {
+		return ErrBatchNotFound
+	}
+	_, err = r.db.Exec(ctx, `UPDATE disbursement_items
+        SET status = $1, error = $2, transaction_id = $3, updated_at = $4
+        WHERE batch_id = $5 AND row_number = $6`,
+		item.Status, item.Error, nullable(item.TransactionID), time.Now().UTC(), batchID, item.Row)
+	return err
+}
@@ -0,0 +1,50 @@
+package disbursement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteResultCSV writes the per-row outcome of a batch in upload order.
+func WriteResultCSV(w io.Writer, items []Item) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"row", "recipient", "wallet_id", "amount", "reference", "status", "error", "transaction_id"}); err != nil {
+		return err
+	}
+	for _, it := range items {
+		record := []string{
+			strconv.Itoa(it.Row),
+			safeCell(it.Recipient),
+			it.WalletID,
+			strconv.FormatInt(it.Amount, 10),
+			safeCell(it.Reference),
+			it.Status,
+			it.Error,
+			it.TransactionID,
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// safeCell neutralises uploaded values that spreadsheets would evaluate as formulas, while
+// leaving plain numbers such as +242 phone numbers untouched.
+func safeCell(v string) string {
+	if v == "" {
+		return v
+	}
+	switch v[0] {
+	case '=', '@':
+		return "'" + v
+	case '+', '-':
+		if strings.Trim(v[1:], "0123456789 ") != "" {
+			return "'" + v
+		}
+	}
+	return v
+}
@@ -0,0 +1,346 @@
+package disbursement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/payments"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const listLimit = 50
+
+// Service validates uploaded payout batches and executes them in the background.
+type Service struct {
+	// ctx bounds background execution; batches interrupted by shutdown stay processing and
+	// are picked up again by Resume.
+	ctx      context.Context
+	repo     Repository
+	payments *payments.Service
+	wallets  *wallet.Service
+	users    *identity.Service
+	guard    *guard.Guard
+	logger   *slog.Logger
+	wg       sync.WaitGroup
+}
+
+// NewService constructs a disbursement service. ctx bounds background batch execution.
+func NewService(ctx context.Context, repo Repository, paymentSvc *payments.Service, wallets *wallet.Service, users *identity.Service, outgoing *guard.Guard, logger *slog.Logger) *Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Service{ctx: ctx, repo: repo, payments: paymentSvc, wallets: wallets, users: users, guard: outgoing, logger: logger}
+}
+
+// CreateInput captures an uploaded batch.
+type CreateInput struct {
+	OwnerUserID string
+	// SourceWalletID defaults to the owner's primary wallet.
+	SourceWalletID string
+	Name           string
+	Rows           []RowInput
+}
+
+// Funding describes how much the source wallet must hold to execute a batch.
+type Funding struct {
+	Required      int64
+	SourceBalance int64
+	Shortfall     int64
+}
+
+// Create validates every row up front and stores the batch. A batch with any invalid row is
+// stored with status invalid so the uploader can see every problem at once.
+func (s *Service) Create(ctx context.Context, input CreateInput) (Batch, []Item, error) {
+	if len(input.Rows) == 0 {
+		return Batch{}, nil, fmt.Errorf("batch has no rows")
+	}
+	if len(input.Rows) > MaxRows {
+		return Batch{}, nil, fmt.Errorf("batch exceeds %d rows", MaxRows)
+	}
+	source, err := s.sourceWallet(ctx, input.OwnerUserID, input.SourceWalletID)
+	if err != nil {
+		return Batch{}, nil, err
+	}
+
+	batch := Batch{
+		ID:             uuid.NewString(),
+		OwnerUserID:    input.OwnerUserID,
+		SourceWalletID: source.ID,
+		Name:           strings.TrimSpace(input.Name),
+		RowCount:       len(input.Rows),
+		CreatedAt:      time.Now().UTC(),
+	}
+	resolved := map[string]wallet.Wallet{}
+	items := make([]Item, 0, len(input.Rows))
+	for i, row := range input.Rows {
+		item := Item{
+			BatchID:   batch.ID,
+			Row:       i + 1,
+			Recipient: strings.TrimSpace(row.Recipient),
+			Reference: strings.TrimSpace(row.Reference),
+			Status:    ItemPending,
+		}
+		if err := s.validateRow(ctx, &item, row, source, resolved); err != nil {
+			item.Status = ItemInvalid
+			item.Error = err.Error()
+			batch.InvalidCount++
+		} else {
+			batch.TotalAmount += item.Amount
+		}
+		items = append(items, item)
+	}
+	batch.Status = BatchValidated
+	if batch.InvalidCount > 0 {
+		batch.Status = BatchInvalid
+	}
+	if err := s.repo.CreateBatch(ctx, batch, items); err != nil {
+		return Batch{}, nil, err
+	}
+	return batch, items, nil
+}
+
+func (s *Service) sourceWallet(ctx context.Context, ownerUserID, walletID string) (wallet.Wallet, error) {
+	var (
+		w   wallet.Wallet
+		err error
+	)
+	if walletID != "" {
+		w, err = s.wallets.Get(ctx, walletID)
+	} else {
+		w, err = s.wallets.GetByOwner(ctx, ownerUserID)
+	}
+	if err != nil {
+		return wallet.Wallet{}, err
+	}
+	if w.OwnerID != ownerUserID {
+		return wallet.Wallet{}, wallet.ErrNotOwner
+	}
+	return w, w.CanDebit()
+}
+
+func (s *Service) validateRow(ctx context.Context, item *Item, row RowInput, source wallet.Wallet, resolved map[string]wallet.Wallet) error {
+	amount, err := parseAmount(row.Amount)
+	if err != nil {
+		return err
+	}
+	item.Amount = amount
+	if item.Recipient == "" {
+		return fmt.Errorf("recipient is required")
+	}
+	if len(item.Reference) > 140 {
+		return fmt.Errorf("reference must be at most 140 characters")
+	}
+
+	target, ok := resolved[item.Recipient]
+	if !ok {
+		if target, err = s.resolveRecipient(ctx, item.Recipient); err != nil {
+			return err
+		}
+		resolved[item.Recipient] = target
+	}
+	if target.ID == source.ID {
+		return fmt.Errorf("recipient is the source wallet")
+	}
+	if err := target.CanCredit(); err != nil {
+		return fmt.Errorf("recipient wallet cannot receive funds: %w", err)
+	}
+	item.WalletID = target.ID
+	return nil
+}
+
+func (s *Service) resolveRecipient(ctx context.Context, recipient string) (wallet.Wallet, error) {
+	if _, err := uuid.Parse(recipient); err == nil {
+		w, err := s.wallets.Get(ctx, recipient)
+		if err != nil {
+			return wallet.Wallet{}, fmt.Errorf("wallet %s not found", recipient)
+		}
+		return w, nil
+	}
+	user, err := s.users.LookupByPhone(ctx, recipient)
+	if err != nil {
+		return wallet.Wallet{}, fmt.Errorf("no registered user for phone %s", recipient)
+	}
+	w, err := s.wallets.GetByOwner(ctx, user.ID)
+	if err != nil {
+		return wallet.Wallet{}, fmt.Errorf("user %s has no wallet", recipient)
+	}
+	return w, nil
+}
+
+// Get returns a batch owned by the user.
+func (s *Service) Get(ctx context.Context, batchID, ownerUserID string) (Batch, error) {
+	b, err := s.repo.GetBatch(ctx, batchID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if b.OwnerUserID != ownerUserID {
+		return Batch{}, ErrBatchNotFound
+	}
+	return b, nil
+}
+
+// List returns the user's most recent batches.
+func (s *Service) List(ctx context.Context, ownerUserID string) ([]Batch, error) {
+	return s.repo.ListBatches(ctx, ownerUserID, listLimit)
+}
+
+// Items returns a batch's rows, optionally filtered by status.
+func (s *Service) Items(ctx context.Context, batchID, ownerUserID, status string) ([]Item, error) {
+	if _, err := s.Get(ctx, batchID, ownerUserID); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListItems(ctx, batchID)
+	if err != nil || status == "" {
+		return items, err
+	}
+	filtered := items[:0]
+	for _, it := range items {
+		if it.Status == status {
+			filtered = append(filtered, it)
+		}
+	}
+	return filtered, nil
+}
+
+// Funding compares the batch total with the current source wallet balance.
+func (s *Service) Funding(ctx context.Context, b Batch) (Funding, error) {
+	balance, err := s.wallets.Balance(ctx, b.SourceWalletID)
+	if err != nil {
+		return Funding{}, err
+	}
+	f := Funding{Required: b.TotalAmount, SourceBalance: balance.Amount}
+	if f.Required > f.SourceBalance {
+		f.Shortfall = f.Required - f.SourceBalance
+	}
+	return f, nil
+}
+
+// Execute starts paying a validated batch in the background once the source wallet covers it.
+// otpCode confirms batches totalling at least the high-value amount.
+func (s *Service) Execute(ctx context.Context, batchID, ownerUserID, otpCode string) (Batch, error) {
+	b, err := s.Get(ctx, batchID, ownerUserID)
+	if err != nil {
+		return Batch{}, err
+	}
+	if b.Status != BatchValidated {
+		return b, ErrBatchNotExecutable
+	}
+	funding, err := s.Funding(ctx, b)
+	if err != nil {
+		return Batch{}, err
+	}
+	if funding.Shortfall > 0 {
+		return b, fmt.Errorf("%w: short by %d", ErrInsufficientFunding, funding.Shortfall)
+	}
+	// The whole batch is screened when the owner starts it; rows then pay out unattended.
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: b.OwnerUserID, Op: risk.OpPayment, Amount: funding.Required, OTPCode: otpCode}); err != nil {
+		return b, err
+	}
+
+	startedAt := time.Now().UTC()
+	if err := s.repo.StartBatch(ctx, b.ID, startedAt); err != nil {
+		return Batch{}, err
+	}
+	b.Status = BatchProcessing
+	b.StartedAt = startedAt
+	s.start(b.ID)
+	return b, nil
+}
+
+// Resume restarts batches left processing by a previous shutdown. Rows already paid are
+// recognised through their idempotency keys and are not paid again.
+func (s *Service) Resume(ctx context.Context) error {
+	batches, err := s.repo.ListBatchesByStatus(ctx, BatchProcessing)
+	if err != nil {
+		return err
+	}
+	for _, b := range batches {
+		s.start(b.ID)
+	}
+	return nil
+}
+
+func (s *Service) start(batchID string) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.process(s.ctx, batchID); err != nil {
+			s.logger.Error("disbursement batch stopped", slog.String("batch_id", batchID), slog.String("error", err.Error()))
+		}
+	}()
+}
+
+func (s *Service) process(ctx context.Context, batchID string) error {
+	b, err := s.repo.GetBatch(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	items, err := s.repo.ListItems(ctx, batchID)
+	if err != nil {
+		return err
+	}
+	b.SucceededCount, b.FailedCount = 0, 0
+	for _, it := range items {
+		switch it.Status {
+		case ItemSucceeded:
+			b.SucceededCount++
+		case ItemFailed:
+			b.FailedCount++
+		}
+	}
+
+	for _, it := range items {
+		if it.Status != ItemPending {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		res, err := s.payments.Transfer(ctx, payments.TransferInput{
+			FromWalletID:    b.SourceWalletID,
+			ToWalletID:      it.WalletID,
+			Amount:          it.Amount,
+			ClientTxID:      it.ClientTxID(),
+			RequestorUserID: b.OwnerUserID,
+		})
+		switch {
+		case err == nil:
+			it.Status = ItemSucceeded
+			it.TransactionID = res.TransactionID
+			b.SucceededCount++
+		case errors.Is(err, ledger.ErrDuplicateTransaction):
+			// Paid before an interruption; the ledger already holds the posting.
+			it.Status = ItemSucceeded
+			it.TransactionID = res.TransactionID
+			b.SucceededCount++
+		default:
+			it.Status = ItemFailed
+			it.Error = err.Error()
+			b.FailedCount++
+		}
+		if err := s.repo.UpdateItem(ctx, it); err != nil {
+			return err
+		}
+		if err := s.repo.UpdateBatch(ctx, b); err != nil {
+			return err
+		}
+	}
+
+	b.Status = BatchCompleted
+	if b.FailedCount > 0 {
+		b.Status = BatchCompletedWithErrors
+	}
+	b.CompletedAt = time.Now().UTC()
+	return s.repo.UpdateBatch(ctx, b)
+}
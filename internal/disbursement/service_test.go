@@ -0,0 +1,181 @@
+package disbursement
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/payments"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+type fixture struct {
+	svc     *Service
+	repo    Repository
+	led     ledger.Ledger
+	wallets *wallet.Service
+	users   *identity.Service
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	led := ledger.NewInMemory()
+	wallets := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	repo := NewMemoryRepository()
+	svc := NewService(context.Background(), repo, payments.NewService(led, wallets, nil, nil), wallets, users, nil, nil)
+	return fixture{svc: svc, repo: repo, led: led, wallets: wallets, users: users}
+}
+
+func (f fixture) user(t *testing.T, phone string, balance int64) (identity.User, wallet.Wallet) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := f.users.Register(ctx, identity.Credentials{Phone: phone, PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register %s: %v", phone, err)
+	}
+	w, err := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: u.ID})
+	if err != nil {
+		t.Fatalf("wallet: %v", err)
+	}
+	ledger.SeedBalance(f.led, w.AccountCode, balance)
+	return u, w
+}
+
+func TestParseCSVAndJSON(t *testing.T) {
+	rows, err := ParseCSV(strings.NewReader("phone,amount,reference\n+242060000001, 1500,May stipend\n+242060000002,abc,\n"))
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Recipient != "+242060000001" || rows[0].Amount != "1500" || rows[0].Reference != "May stipend" {
+		t.Fatalf("unexpected csv rows: %+v", rows)
+	}
+	if _, err := ParseCSV(strings.NewReader("name,amount\nx,1\n")); err == nil {
+		t.Fatal("expected missing recipient column to fail")
+	}
+
+	rows, err = ParseJSON(strings.NewReader(`{"items":[{"wallet_id":"w-1","amount":2000},{"phone":"+242060000003","amount":"300","reference":"r"}]}`))
+	if err != nil {
+		t.Fatalf("parse json: %v", err)
+	}
+	if len(rows) != 2 || rows[0].Recipient != "w-1" || rows[0].Amount != "2000" || rows[1].Amount != "300" {
+		t.Fatalf("unexpected json rows: %+v", rows)
+	}
+}
+
+func TestServiceCreateReportsEveryInvalidRow(t *testing.T) {
+	f := newFixture(t)
+	employer, _ := f.user(t, "+242060000100", 100_000)
+	f.user(t, "+242060000101", 0)
+
+	batch, items, err := f.svc.Create(context.Background(), CreateInput{
+		OwnerUserID: employer.ID,
+		Rows: []RowInput{
+			{Recipient: "+242060000101", Amount: "1000"},
+			{Recipient: "+242069999999", Amount: "1000"},
+			{Recipient: "+242060000101", Amount: "-5"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if batch.Status != BatchInvalid || batch.InvalidCount != 2 || batch.TotalAmount != 1_000 {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	if items[1].Status != ItemInvalid || items[1].Error == "" || items[2].Status != ItemInvalid {
+		t.Fatalf("expected rows 2 and 3 invalid: %+v", items)
+	}
+	if _, err := f.svc.Execute(context.Background(), batch.ID, employer.ID, ""); !errors.Is(err, ErrBatchNotExecutable) {
+		t.Fatalf("expected invalid batch to be refused, got %v", err)
+	}
+}
+
+func TestServiceExecuteBatch(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	ngo, ngoWallet := f.user(t, "+242060000200", 5_000)
+	_, a := f.user(t, "+242060000201", 0)
+	_, b := f.user(t, "+242060000202", 0)
+
+	batch, _, err := f.svc.Create(ctx, CreateInput{
+		OwnerUserID: ngo.ID,
+		Rows: []RowInput{
+			{Recipient: "+242060000201", Amount: "2000", Reference: "stipend"},
+			{Recipient: b.ID, Amount: "2500", Reference: "stipend"},
+		},
+	})
+	if err != nil || batch.Status != BatchValidated {
+		t.Fatalf("create: %+v, %v", batch, err)
+	}
+	funding, err := f.svc.Funding(ctx, batch)
+	if err != nil || funding.Required != 4_500 || funding.Shortfall != 0 {
+		t.Fatalf("unexpected funding: %+v, %v", funding, err)
+	}
+
+	if _, err := f.svc.Execute(ctx, batch.ID, ngo.ID, ""); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	// A concurrent Execute that read the batch while validated cannot start it again.
+	if err := f.repo.StartBatch(ctx, batch.ID, time.Now()); !errors.Is(err, ErrBatchNotExecutable) {
+		t.Fatalf("expected ErrBatchNotExecutable, got %v", err)
+	}
+	f.svc.wg.Wait()
+
+	done, _ := f.svc.Get(ctx, batch.ID, ngo.ID)
+	if done.Status != BatchCompleted || done.SucceededCount != 2 || done.CompletedAt.IsZero() {
+		t.Fatalf("unexpected completed batch: %+v", done)
+	}
+	for code, want := range map[string]int64{ngoWallet.AccountCode: 500, a.AccountCode: 2_000, b.AccountCode: 2_500} {
+		if got, _ := f.led.Balance(ctx, code); got != want {
+			t.Fatalf("balance %s = %d, want %d", code, got, want)
+		}
+	}
+
+	// Re-running an interrupted batch must not pay rows twice.
+	items, _ := f.repo.ListItems(ctx, batch.ID)
+	paidTx := items[0].TransactionID
+	items[0].Status = ItemPending
+	items[0].TransactionID = ""
+	_ = f.repo.UpdateItem(ctx, items[0])
+	done.Status = BatchProcessing
+	_ = f.repo.UpdateBatch(ctx, done)
+	if err := f.svc.Resume(ctx); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	f.svc.wg.Wait()
+	if got, _ := f.led.Balance(ctx, a.AccountCode); got != 2_000 {
+		t.Fatalf("row paid twice: balance %d", got)
+	}
+	if items, _ = f.repo.ListItems(ctx, batch.ID); paidTx == "" || items[0].TransactionID != paidTx {
+		t.Fatalf("expected the earlier transaction %q, got %+v", paidTx, items[0])
+	}
+
+	var buf bytes.Buffer
+	results, _ := f.svc.Items(ctx, batch.ID, ngo.ID, "")
+	if err := WriteResultCSV(&buf, results); err != nil {
+		t.Fatalf("result csv: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 || !strings.Contains(buf.String(), "succeeded") {
+		t.Fatalf("unexpected result file:\n%s", buf.String())
+	}
+}
+
+func TestServiceExecuteRequiresFunding(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, _ := f.user(t, "+242060000300", 1_000)
+	f.user(t, "+242060000301", 0)
+
+	batch, _, err := f.svc.Create(ctx, CreateInput{OwnerUserID: owner.ID, Rows: []RowInput{{Recipient: "+242060000301", Amount: "1500"}}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := f.svc.Execute(ctx, batch.ID, owner.ID, ""); !errors.Is(err, ErrInsufficientFunding) {
+		t.Fatalf("expected ErrInsufficientFunding, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package escrow
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// Handler exposes escrow endpoints for buyers, sellers and back-office staff.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds an escrow HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type createRequest struct {
+	FromWalletID        string `json:"from_wallet_id"`
+	PayeeWalletID       string `json:"payee_wallet_id"`
+	PayeePhone          string `json:"payee_phone"`
+	Amount              int64  `json:"amount"`
+	Description         string `json:"description"`
+	ReleaseAfterSeconds int64  `json:"release_after_seconds"`
+	OTPCode             string `json:"otp_code"`
+}
+
+type disputeRequest struct {
+	Reason string `json:"reason"`
+}
+
+type resolveRequest struct {
+	Resolution string `json:"resolution"`
+	Note       string `json:"note"`
+}
+
+type escrowResponse struct {
+	ID               string     `json:"id"`
+	PayerUserID      string     `json:"payer_user_id"`
+	PayerWalletID    string     `json:"payer_wallet_id"`
+	PayeeUserID      string     `json:"payee_user_id"`
+	PayeeWalletID    string     `json:"payee_wallet_id"`
+	Amount           int64      `json:"amount"`
+	Description      string     `json:"description"`
+	Status           string     `json:"status"`
+	ReleaseAt        time.Time  `json:"release_at"`
+	FundingTxID      string     `json:"funding_transaction_id,omitempty"`
+	DisputedByUserID string     `json:"disputed_by_user_id,omitempty"`
+	DisputeReason    string     `json:"dispute_reason,omitempty"`
+	ResolvedByUserID string     `json:"resolved_by_user_id,omitempty"`
+	ResolutionNote   string     `json:"resolution_note,omitempty"`
+	SettlementTxID   string     `json:"settlement_transaction_id,omitempty"`
+	SettledAt        *time.Time `json:"settled_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+func toResponse(e Escrow) escrowResponse {
+	resp := escrowResponse{
+		ID:               e.ID,
+		PayerUserID:      e.PayerUserID,
+		PayerWalletID:    e.PayerWalletID,
+		PayeeUserID:      e.PayeeUserID,
+		PayeeWalletID:    e.PayeeWalletID,
+		Amount:           e.Amount,
+		Description:      e.Description,
+		Status:           e.Status,
+		ReleaseAt:        e.ReleaseAt,
+		FundingTxID:      e.FundingTxID,
+		DisputedByUserID: e.DisputedByUserID,
+		DisputeReason:    e.DisputeReason,
+		ResolvedByUserID: e.ResolvedByUserID,
+		ResolutionNote:   e.ResolutionNote,
+		SettlementTxID:   e.SettlementTxID,
+		CreatedAt:        e.CreatedAt,
+		UpdatedAt:        e.UpdatedAt,
+	}
+	if !e.SettledAt.IsZero() {
+		settledAt := e.SettledAt
+		resp.SettledAt = &settledAt
+	}
+	return resp
+}
+
+func toResponses(escrows []Escrow) []escrowResponse {
+	out := make([]escrowResponse, 0, len(escrows))
+	for _, e := range escrows {
+		out = append(out, toResponse(e))
+	}
+	return out
+}
+
+// Create funds a new escrow from the authenticated buyer's wallet.
+func (h *Handler) Create(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req createRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	e, err := h.service.Create(c.UserContext(), CreateInput{
+		PayerUserID:   uid,
+		FromWalletID:  req.FromWalletID,
+		PayeeWalletID: req.PayeeWalletID,
+		PayeePhone:    req.PayeePhone,
+		Amount:        req.Amount,
+		Description:   req.Description,
+		ReleaseAfter:  time.Duration(req.ReleaseAfterSeconds) * time.Second,
+		OTPCode:       req.OTPCode,
+	})
+	if err != nil {
+		return escrowError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toResponse(e))
+}
+
+// List returns escrows where the authenticated user is buyer or seller.
+func (h *Handler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	escrows, err := h.service.List(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"escrows": toResponses(escrows)})
+}
+
+// Get returns one escrow to either party.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	e, err := h.service.Get(c.UserContext(), c.Params("escrowId"), uid)
+	if err != nil {
+		return escrowError(err)
+	}
+	return c.JSON(toResponse(e))
+}
+
+// Confirm releases the funds to the seller (buyer only).
+func (h *Handler) Confirm(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	e, err := h.service.Confirm(c.UserContext(), c.Params("escrowId"), uid)
+	if err != nil {
+		return escrowError(err)
+	}
+	return c.JSON(toResponse(e))
+}
+
+// Cancel refunds the buyer (seller only).
+func (h *Handler) Cancel(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	e, err := h.service.Cancel(c.UserContext(), c.Params("escrowId"), uid)
+	if err != nil {
+		return escrowError(err)
+	}
+	return c.JSON(toResponse(e))
+}
+
+// Dispute freezes the escrow pending back-office review (either party).
+func (h *Handler) Dispute(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req disputeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	e, err := h.service.Dispute(c.UserContext(), c.Params("escrowId"), uid, req.Reason)
+	if err != nil {
+		return escrowError(err)
+	}
+	return c.JSON(toResponse(e))
+}
+
+// AdminListDisputed returns escrows awaiting a decision.
+func (h *Handler) AdminListDisputed(c *fiber.Ctx) error {
+	escrows, err := h.service.ListDisputed(c.UserContext())
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(fiber.Map{"escrows": toResponses(escrows)})
+}
+
+// AdminGet returns any escrow.
+func (h *Handler) AdminGet(c *fiber.Ctx) error {
+	e, err := h.service.AdminGet(c.UserContext(), c.Params("escrowId"))
+	if err != nil {
+		return escrowError(err)
+	}
+	return c.JSON(toResponse(e))
+}
+
+// AdminResolve settles a disputed escrow to the seller or back to the buyer.
+func (h *Handler) AdminResolve(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req resolveRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	e, err := h.service.Resolve(c.UserContext(), c.Params("escrowId"), uid, req.Resolution, req.Note)
+	if err != nil {
+		return escrowError(err)
+	}
+	return c.JSON(toResponse(e))
+}
+
+func escrowError(err error) error {
+	switch {
+	case errors.Is(err, ErrEscrowNotFound), errors.Is(err, ErrPayeeNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotAllowed), errors.Is(err, wallet.ErrNotOwner), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return fiber.NewError(http.StatusBadRequest, "insufficient funds")
+	case errors.Is(err, ErrInvalidState), errors.Is(err, ledger.ErrAccountRestricted),
+		errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,105 @@
+package escrow
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mu      sync.RWMutex
+	escrows map[string]Escrow
+}
+
+// NewMemoryRepository builds an in-memory escrow store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{escrows: make(map[string]Escrow)}
+}
+
+func (m *memoryRepository) Create(_ context.Context, e Escrow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.escrows[e.ID] = e
+	return nil
+}
+
+func (m *memoryRepository) Get(_ context.Context, id string) (Escrow, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.escrows[id]
+	if !ok {
+		return Escrow{}, ErrEscrowNotFound
+	}
+	return e, nil
+}
+
+func (m *memoryRepository) filter(match func(Escrow) bool, less func(a, b Escrow) bool, limit int) []Escrow {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Escrow
+	for _, e := range m.escrows {
+		if match(e) {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return less(out[i], out[j]) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (m *memoryRepository) ListByParty(_ context.Context, userID string, limit int) ([]Escrow, error) {
+	return m.filter(func(e Escrow) bool { return e.Party(userID) },
+		func(a, b Escrow) bool { return a.CreatedAt.After(b.CreatedAt) }, limit), nil
+}
+
+func (m *memoryRepository) ListByStatus(_ context.Context, status string, limit int) ([]Escrow, error) {
+	return m.filter(func(e Escrow) bool { return e.Status == status },
+		func(a, b Escrow) bool { return a.UpdatedAt.Before(b.UpdatedAt) }, limit), nil
+}
+
+func (m *memoryRepository) ListDue(_ context.Context, now time.Time, limit int) ([]Escrow, error) {
+	return m.filter(func(e Escrow) bool { return e.Status == StatusHeld && !e.ReleaseAt.After(now) },
+		func(a, b Escrow) bool { return a.ReleaseAt.Before(b.ReleaseAt) }, limit), nil
+}
+
+func (m *memoryRepository) ListUnsettled(_ context.Context, before time.Time, limit int) ([]Escrow, error) {
+	return m.filter(func(e Escrow) bool { return e.Final() && e.SettlementTxID == "" && !e.UpdatedAt.After(before) },
+		func(a, b Escrow) bool { return a.UpdatedAt.Before(b.UpdatedAt) }, limit), nil
+}
+
+func (m *memoryRepository) Transition(_ context.Context, fromStatus string, e Escrow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur, ok := m.escrows[e.ID]
+	if !ok {
+		return ErrEscrowNotFound
+	}
+	if cur.Status != fromStatus {
+		return ErrInvalidState
+	}
+	cur.Status = e.Status
+	cur.DisputedByUserID = e.DisputedByUserID
+	cur.DisputeReason = e.DisputeReason
+	cur.ResolvedByUserID = e.ResolvedByUserID
+	cur.ResolutionNote = e.ResolutionNote
+	cur.UpdatedAt = time.Now().UTC()
+	m.escrows[e.ID] = cur
+	return nil
+}
+
+func (m *memoryRepository) MarkSettled(_ context.Context, id, transactionID string, settledAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cur, ok := m.escrows[id]
+	if !ok {
+		return ErrEscrowNotFound
+	}
+	cur.SettlementTxID = transactionID
+	cur.SettledAt = settledAt
+	cur.UpdatedAt = settledAt
+	m.escrows[id] = cur
+	return nil
+}
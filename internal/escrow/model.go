@@ -0,0 +1,88 @@
+package escrow
+
+import (
+	"errors"
+	"time"
+)
+
+// Escrow statuses. Funds sit in the contract's escrow account while held or disputed;
+// released and refunded are final.
+//
+//	held ──confirm / timeout──▶ released
+//	held ──seller cancel──────▶ refunded
+//	held ──dispute────────────▶ disputed ──admin──▶ released | refunded
+const (
+	StatusHeld     = "held"
+	StatusDisputed = "disputed"
+	StatusReleased = "released"
+	StatusRefunded = "refunded"
+)
+
+// Dispute resolutions chosen by an administrator.
+const (
+	ResolutionRelease = "release"
+	ResolutionRefund  = "refund"
+)
+
+var (
+	// ErrEscrowNotFound indicates no escrow matches the lookup for this user.
+	ErrEscrowNotFound = errors.New("escrow not found")
+	// ErrInvalidState indicates the escrow status does not allow the requested action.
+	ErrInvalidState = errors.New("escrow cannot change from its current status")
+	// ErrNotAllowed indicates the caller's role in the escrow does not permit the action.
+	ErrNotAllowed = errors.New("not allowed on this escrow")
+	// ErrPayeeNotFound indicates the payee phone does not belong to a registered user.
+	ErrPayeeNotFound = errors.New("payee is not a registered user")
+)
+
+// AccountCode returns the ledger account that holds an escrow's funds.
+func AccountCode(escrowID string) string {
+	return "escrow:" + escrowID
+}
+
+// Escrow is a protected payment: the payer's funds are parked in a dedicated ledger account
+// until the payer confirms delivery, the release timeout passes, the payee cancels, or an
+// administrator settles a dispute.
+type Escrow struct {
+	ID            string
+	PayerUserID   string
+	PayerWalletID string
+	PayeeUserID   string
+	PayeeWalletID string
+	Amount        int64
+	Description   string
+	Status        string
+	// ReleaseAt is when held funds are released to the payee without payer confirmation.
+	ReleaseAt        time.Time
+	FundingTxID      string
+	DisputedByUserID string
+	DisputeReason    string
+	ResolvedByUserID string
+	ResolutionNote   string
+	// SettlementTxID is empty for a final escrow until its settlement posting is confirmed.
+	SettlementTxID string
+	SettledAt      time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Final reports whether the escrow has been released or refunded.
+func (e Escrow) Final() bool {
+	return e.Status == StatusReleased || e.Status == StatusRefunded
+}
+
+// Party reports whether the user is the escrow's payer or payee.
+func (e Escrow) Party(userID string) bool {
+	return userID == e.PayerUserID || userID == e.PayeeUserID
+}
+
+// fundingTxID and settlementTxID are the ledger idempotency keys of an escrow. Release and
+// refund have their own keys, so a retried refund is never taken for a release that already
+// paid the payee; the escrow account holds the amount once, so only one of them can post.
+func fundingTxID(escrowID string) string { return "escrow:" + escrowID + ":fund" }
+func settlementTxID(escrowID, status string) string {
+	if status == StatusRefunded {
+		return "escrow:" + escrowID + ":refund"
+	}
+	return "escrow:" + escrowID + ":release"
+}
@@ -0,0 +1,177 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists escrow contracts.
+type Repository interface {
+	Create(ctx context.Context, e Escrow) error
+	Get(ctx context.Context, id string) (Escrow, error)
+	ListByParty(ctx context.Context, userID string, limit int) ([]Escrow, error)
+	ListByStatus(ctx context.Context, status string, limit int) ([]Escrow, error)
+	// ListDue returns held escrows whose release time has passed.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]Escrow, error)
+	// ListUnsettled returns final escrows, last changed before the cutoff, whose settlement
+	// posting was not confirmed.
+	ListUnsettled(ctx context.Context, before time.Time, limit int) ([]Escrow, error)
+	// Transition moves an escrow out of fromStatus, storing its status, dispute and resolution
+	// fields. It returns ErrInvalidState when the stored status no longer matches fromStatus.
+	Transition(ctx context.Context, fromStatus string, e Escrow) error
+	// MarkSettled records the settlement posting of a final escrow.
+	MarkSettled(ctx context.Context, id, transactionID string, settledAt time.Time) error
+}
+
+// PostgresRepository stores escrows in PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed escrow repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const escrowColumns = `id::text, payer_user_id::text, payer_wallet_id::text, payee_user_id::text, payee_wallet_id::text,
+        amount, description, status, release_at, COALESCE(funding_tx_id::text, ''),
+        COALESCE(disputed_by_user_id::text, ''), dispute_reason, COALESCE(resolved_by_user_id::text, ''), resolution_note,
+        COALESCE(settlement_tx_id::text, ''), settled_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEscrow(row rowScanner) (Escrow, error) {
+	var (
+		e         Escrow
+		settledAt *time.Time
+	)
+	err := row.Scan(&e.ID, &e.PayerUserID, &e.PayerWalletID, &e.PayeeUserID, &e.PayeeWalletID,
+		&e.Amount, &e.Description, &e.Status, &e.ReleaseAt, &e.FundingTxID,
+		&e.DisputedByUserID, &e.DisputeReason, &e.ResolvedByUserID, &e.ResolutionNote,
+		&e.SettlementTxID, &settledAt, &e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Escrow{}, ErrEscrowNotFound
+		}
+		return Escrow{}, err
+	}
+	if settledAt != nil {
+		e.SettledAt = settledAt.UTC()
+	}
+	e.ReleaseAt = e.ReleaseAt.UTC()
+	e.CreatedAt = e.CreatedAt.UTC()
+	e.UpdatedAt = e.UpdatedAt.UTC()
+	return e, nil
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Create inserts a funded escrow.
+func (p *PostgresRepository) Create(ctx context.Context, e Escrow) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO escrows
+        (id, payer_user_id, payer_wallet_id, payee_user_id, payee_wallet_id, amount, description, status,
+         release_at, funding_tx_id, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		e.ID, e.PayerUserID, e.PayerWalletID, e.PayeeUserID, e.PayeeWalletID, e.Amount, e.Description, e.Status,
+		e.ReleaseAt.UTC(), nullable(e.FundingTxID), e.CreatedAt.UTC(), e.UpdatedAt.UTC())
+	return err
+}
+
+// Get fetches an escrow by ID.
+func (p *PostgresRepository) Get(ctx context.Context, id string) (Escrow, error) {
+	escrowID, err := uuid.Parse(id)
+	if err != nil {
+		return Escrow{}, ErrEscrowNotFound
+	}
+	return scanEscrow(p.db.QueryRow(ctx, `SELECT `+escrowColumns+` FROM escrows WHERE id = $1`, escrowID))
+}
+
+// ListByParty returns escrows where the user is payer or payee, newest first.
+func (p *PostgresRepository) ListByParty(ctx context.Context, userID string, limit int) ([]Escrow, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	return p.list(ctx, `SELECT `+escrowColumns+` FROM escrows
+        WHERE payer_user_id = $1 OR payee_user_id = $1 ORDER BY created_at DESC LIMIT $2`, uid, limit)
+}
+
+// ListByStatus returns escrows in a status, oldest first.
+func (p *PostgresRepository) ListByStatus(ctx context.Context, status string, limit int) ([]Escrow, error) {
+	return p.list(ctx, `SELECT `+escrowColumns+` FROM escrows
+        WHERE status = $1 ORDER BY updated_at LIMIT $2`, status, limit)
+}
+
+// ListDue returns held escrows past their release time.
+func (p *PostgresRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]Escrow, error) {
+	return p.list(ctx, `SELECT `+escrowColumns+` FROM escrows
+        WHERE status = 'held' AND release_at <= $1 ORDER BY release_at LIMIT $2`, now.UTC(), limit)
+}
+
+// ListUnsettled returns final escrows without a recorded settlement posting.
+func (p *PostgresRepository) ListUnsettled(ctx context.Context, before time.Time, limit int) ([]Escrow, error) {
+	return p.list(ctx, `SELECT `+escrowColumns+` FROM escrows
+        WHERE status IN ('released', 'refunded') AND settlement_tx_id IS NULL AND updated_at <= $1
+        ORDER BY updated_at LIMIT $2`, before.UTC(), limit)
+}
+
+func (p *PostgresRepository) list(ctx context.Context, query string, args ...any) ([]Escrow, error) {
+	rows, err := p.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Escrow
+	for rows.Next() {
+		e, err := scanEscrow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Transition applies a status change guarded by the expected current status.
+func (p *PostgresRepository) Transition(ctx context.Context, fromStatus string, e Escrow) error {
+	escrowID, err := uuid.Parse(e.ID)
+	if err != nil {
+		return ErrEscrowNotFound
+	}
+	cmd, err := p.db.Exec(ctx, `UPDATE escrows
+        SET status = $1, disputed_by_user_id = $2, dispute_reason = $3, resolved_by_user_id = $4,
+            resolution_note = $5, updated_at = $6
+        WHERE id = $7 AND status = $8`,
+		e.Status, nullable(e.DisputedByUserID), e.DisputeReason, nullable(e.ResolvedByUserID), e.ResolutionNote,
+		time.Now().UTC(), escrowID, fromStatus)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrInvalidState
+	}
+	return nil
+}
+
+// MarkSettled stores the settlement transaction of a final escrow.
+func (p *PostgresRepository) MarkSettled(ctx context.Context, id, transactionID string, settledAt time.Time) error {
+	escrowID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrEscrowNotFound
+	}
+	_, err = p.db.Exec(ctx, `UPDATE escrows SET settlement_tx_id = $1, settled_at = $2, updated_at = $2
+        WHERE id = $3`, transactionID, settledAt.UTC(), escrowID)
+	return err
+}
@@ -0,0 +1,410 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const (
+	fundKind             = "escrow_fund"
+	settleKind           = "escrow_settle"
+	maxDescriptionLen    = 140
+	maxReasonLen         = 500
+	minReleaseAfter      = time.Hour
+	maxReleaseAfter      = 30 * 24 * time.Hour
+	listLimit            = 50
+	sweepBatchSize       = 100
+	unsettledGracePeriod = 5 * time.Minute
+)
+
+// Service runs the escrow state machine on top of ledger postings.
+type Service struct {
+	repo         Repository
+	ledger       ledger.Ledger
+	wallets      *wallet.Service
+	users        *identity.Service
+	notifier     notification.Notifier
+	guard        *guard.Guard
+	releaseAfter time.Duration
+	logger       *slog.Logger
+	now          func() time.Time
+}
+
+// NewService constructs an escrow service. releaseAfter is the default delay before held
+// funds are released to the payee without confirmation.
+func NewService(repo Repository, ledgerBackend ledger.Ledger, wallets *wallet.Service, users *identity.Service, notifier notification.Notifier, outgoing *guard.Guard, releaseAfter time.Duration, logger *slog.Logger) *Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if releaseAfter < minReleaseAfter || releaseAfter > maxReleaseAfter {
+		releaseAfter = 7 * 24 * time.Hour
+	}
+	return &Service{
+		repo:         repo,
+		ledger:       ledgerBackend,
+		wallets:      wallets,
+		users:        users,
+		notifier:     notifier,
+		guard:        outgoing,
+		releaseAfter: releaseAfter,
+		logger:       logger,
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// CreateInput captures a new escrow. Exactly one of PayeeWalletID and PayeePhone names the
+// seller; a phone resolves to that user's primary wallet.
+type CreateInput struct {
+	PayerUserID string
+	// FromWalletID defaults to the payer's primary wallet.
+	FromWalletID  string
+	PayeeWalletID string
+	PayeePhone    string
+	Amount        int64
+	Description   string
+	// ReleaseAfter defaults to the service setting and must lie between 1 hour and 30 days.
+	ReleaseAfter time.Duration
+	// OTPCode confirms escrows of at least the high-value amount.
+	OTPCode string
+}
+
+// Create moves the payer's funds into a new escrow account and stores the contract as held.
+func (s *Service) Create(ctx context.Context, input CreateInput) (Escrow, error) {
+	if input.Amount <= 0 {
+		return Escrow{}, fmt.Errorf("amount must be positive")
+	}
+	description := strings.TrimSpace(input.Description)
+	if description == "" || len(description) > maxDescriptionLen {
+		return Escrow{}, fmt.Errorf("description is required and must be at most %d characters", maxDescriptionLen)
+	}
+	releaseAfter := input.ReleaseAfter
+	if releaseAfter == 0 {
+		releaseAfter = s.releaseAfter
+	}
+	if releaseAfter < minReleaseAfter || releaseAfter > maxReleaseAfter {
+		return Escrow{}, fmt.Errorf("release delay must be between %s and %s", minReleaseAfter, maxReleaseAfter)
+	}
+
+	payer, err := s.payerWallet(ctx, input.PayerUserID, input.FromWalletID)
+	if err != nil {
+		return Escrow{}, err
+	}
+	payee, err := s.payeeWallet(ctx, input.PayeeWalletID, input.PayeePhone)
+	if err != nil {
+		return Escrow{}, err
+	}
+	if payee.OwnerID == input.PayerUserID {
+		return Escrow{}, fmt.Errorf("payee must be another user")
+	}
+	if err := payee.CanCredit(); err != nil {
+		return Escrow{}, err
+	}
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: input.PayerUserID, Op: risk.OpPayment, Amount: input.Amount, OTPCode: input.OTPCode}); err != nil {
+		return Escrow{}, err
+	}
+
+	now := s.now()
+	e := Escrow{
+		ID:            uuid.NewString(),
+		PayerUserID:   input.PayerUserID,
+		PayerWalletID: payer.ID,
+		PayeeUserID:   payee.OwnerID,
+		PayeeWalletID: payee.ID,
+		Amount:        input.Amount,
+		Description:   description,
+		Status:        StatusHeld,
+		ReleaseAt:     now.Add(releaseAfter),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	account := AccountCode(e.ID)
+	if err := s.ledger.EnsureAccount(ctx, account); err != nil {
+		return Escrow{}, err
+	}
+	res, err := s.ledger.Post(ctx, fundKind, fundingTxID(e.ID), []ledger.Posting{
+		{AccountCode: payer.AccountCode, Amount: -e.Amount},
+		{AccountCode: account, Amount: e.Amount},
+	})
+	if err != nil {
+		return Escrow{}, err
+	}
+	e.FundingTxID = res.TransactionID
+	if err := s.repo.Create(ctx, e); err != nil {
+		// Without a contract nobody could ever release the funds, so hand them straight back.
+		if _, refundErr := s.ledger.Post(ctx, settleKind, settlementTxID(e.ID, StatusRefunded), []ledger.Posting{
+			{AccountCode: account, Amount: -e.Amount},
+			{AccountCode: payer.AccountCode, Amount: e.Amount},
+		}); refundErr != nil {
+			s.logger.Error("escrow funding stranded", slog.String("escrow_id", e.ID), slog.String("error", refundErr.Error()))
+		}
+		return Escrow{}, err
+	}
+
+	s.notify(ctx, e.PayeeUserID, fmt.Sprintf("%d is held in escrow for %q; it is released on buyer confirmation or %s", e.Amount, e.Description, e.ReleaseAt.Format(time.RFC3339)))
+	return e, nil
+}
+
+func (s *Service) payerWallet(ctx context.Context, userID, walletID string) (wallet.Wallet, error) {
+	var (
+		w   wallet.Wallet
+		err error
+	)
+	if walletID != "" {
+		w, err = s.wallets.Get(ctx, walletID)
+	} else {
+		w, err = s.wallets.GetByOwner(ctx, userID)
+	}
+	if err != nil {
+		return wallet.Wallet{}, err
+	}
+	if w.OwnerID != userID {
+		return wallet.Wallet{}, wallet.ErrNotOwner
+	}
+	return w, w.CanDebit()
+}
+
+func (s *Service) payeeWallet(ctx context.Context, walletID, phone string) (wallet.Wallet, error) {
+	switch {
+	case walletID != "" && phone != "":
+		return wallet.Wallet{}, fmt.Errorf("provide either payee_wallet_id or payee_phone, not both")
+	case walletID != "":
+		return s.wallets.Get(ctx, walletID)
+	case phone != "":
+		user, err := s.users.LookupByPhone(ctx, phone)
+		if err != nil {
+			return wallet.Wallet{}, ErrPayeeNotFound
+		}
+		return s.wallets.GetByOwner(ctx, user.ID)
+	default:
+		return wallet.Wallet{}, fmt.Errorf("payee_wallet_id or payee_phone is required")
+	}
+}
+
+// Get returns an escrow to its payer or payee.
+func (s *Service) Get(ctx context.Context, id, userID string) (Escrow, error) {
+	e, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Escrow{}, err
+	}
+	if !e.Party(userID) {
+		return Escrow{}, ErrEscrowNotFound
+	}
+	return e, nil
+}
+
+// List returns the user's escrows as payer or payee.
+func (s *Service) List(ctx context.Context, userID string) ([]Escrow, error) {
+	return s.repo.ListByParty(ctx, userID, listLimit)
+}
+
+// Confirm releases held funds to the payee on the payer's confirmation of delivery.
+func (s *Service) Confirm(ctx context.Context, id, payerUserID string) (Escrow, error) {
+	e, err := s.Get(ctx, id, payerUserID)
+	if err != nil {
+		return Escrow{}, err
+	}
+	if e.PayerUserID != payerUserID {
+		return Escrow{}, ErrNotAllowed
+	}
+	return s.finish(ctx, e, StatusReleased, "", "")
+}
+
+// Cancel refunds held funds to the payer at the payee's request.
+func (s *Service) Cancel(ctx context.Context, id, payeeUserID string) (Escrow, error) {
+	e, err := s.Get(ctx, id, payeeUserID)
+	if err != nil {
+		return Escrow{}, err
+	}
+	if e.PayeeUserID != payeeUserID {
+		return Escrow{}, ErrNotAllowed
+	}
+	return s.finish(ctx, e, StatusRefunded, "", "")
+}
+
+// Dispute freezes a held escrow until an administrator resolves it; the release timeout no
+// longer applies.
+func (s *Service) Dispute(ctx context.Context, id, userID, reason string) (Escrow, error) {
+	e, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return Escrow{}, err
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxReasonLen {
+		return Escrow{}, fmt.Errorf("reason is required and must be at most %d characters", maxReasonLen)
+	}
+	if e.Status != StatusHeld {
+		return Escrow{}, ErrInvalidState
+	}
+	e.Status = StatusDisputed
+	e.DisputedByUserID = userID
+	e.DisputeReason = reason
+	if err := s.repo.Transition(ctx, StatusHeld, e); err != nil {
+		return Escrow{}, err
+	}
+	body := fmt.Sprintf("Escrow %q is disputed and frozen pending review: %s", e.Description, reason)
+	s.notify(ctx, e.PayerUserID, body)
+	s.notify(ctx, e.PayeeUserID, body)
+	return s.repo.Get(ctx, e.ID)
+}
+
+// ListDisputed returns disputed escrows awaiting an administrator, oldest first.
+func (s *Service) ListDisputed(ctx context.Context) ([]Escrow, error) {
+	return s.repo.ListByStatus(ctx, StatusDisputed, listLimit)
+}
+
+// AdminGet returns any escrow (back-office use).
+func (s *Service) AdminGet(ctx context.Context, id string) (Escrow, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Resolve settles a disputed escrow to the payee (release) or the payer (refund).
+func (s *Service) Resolve(ctx context.Context, id, adminUserID, resolution, note string) (Escrow, error) {
+	var status string
+	switch resolution {
+	case ResolutionRelease:
+		status = StatusReleased
+	case ResolutionRefund:
+		status = StatusRefunded
+	default:
+		return Escrow{}, fmt.Errorf("resolution must be release or refund")
+	}
+	note = strings.TrimSpace(note)
+	if note == "" || len(note) > maxReasonLen {
+		return Escrow{}, fmt.Errorf("note is required and must be at most %d characters", maxReasonLen)
+	}
+	e, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Escrow{}, err
+	}
+	if e.Status != StatusDisputed {
+		return Escrow{}, ErrInvalidState
+	}
+	return s.finish(ctx, e, status, adminUserID, note)
+}
+
+// finish moves an escrow to a final status and then posts its settlement. The status change
+// comes first so that concurrent release and refund attempts cannot both win; if the
+// posting fails the change is rolled back. Once the funds have moved the status stays, and
+// a failure to record the posting is left to RunDue. Only an administrator (resolvedBy) may
+// finish a disputed escrow.
+func (s *Service) finish(ctx context.Context, e Escrow, status, resolvedBy, note string) (Escrow, error) {
+	from := e.Status
+	if from != StatusHeld && !(from == StatusDisputed && resolvedBy != "") {
+		return Escrow{}, ErrInvalidState
+	}
+	prev := e
+	e.Status = status
+	e.ResolvedByUserID = resolvedBy
+	e.ResolutionNote = note
+	if err := s.repo.Transition(ctx, from, e); err != nil {
+		return Escrow{}, err
+	}
+	txID, err := s.post(ctx, e)
+	if err != nil {
+		if rollbackErr := s.repo.Transition(ctx, status, prev); rollbackErr != nil {
+			s.logger.Error("escrow rollback failed", slog.String("escrow_id", e.ID), slog.String("error", rollbackErr.Error()))
+		}
+		return Escrow{}, err
+	}
+	if err := s.repo.MarkSettled(ctx, e.ID, txID, s.now()); err != nil {
+		s.logger.Error("escrow settlement not recorded", slog.String("escrow_id", e.ID), slog.String("error", err.Error()))
+	}
+
+	switch status {
+	case StatusReleased:
+		s.notify(ctx, e.PayeeUserID, fmt.Sprintf("Escrow %q released: %d credited to your wallet", e.Description, e.Amount))
+		s.notify(ctx, e.PayerUserID, fmt.Sprintf("Escrow %q released to the seller", e.Description))
+	case StatusRefunded:
+		s.notify(ctx, e.PayerUserID, fmt.Sprintf("Escrow %q refunded: %d returned to your wallet", e.Description, e.Amount))
+		s.notify(ctx, e.PayeeUserID, fmt.Sprintf("Escrow %q refunded to the buyer", e.Description))
+	}
+	return s.repo.Get(ctx, e.ID)
+}
+
+// post empties the escrow account towards the party the final status designates and
+// returns the ledger transaction. The settlement key makes repeated attempts, including the
+// sweeper's, harmless.
+func (s *Service) post(ctx context.Context, e Escrow) (string, error) {
+	targetWalletID := e.PayeeWalletID
+	if e.Status == StatusRefunded {
+		targetWalletID = e.PayerWalletID
+	}
+	target, err := s.wallets.Get(ctx, targetWalletID)
+	if err != nil {
+		return "", err
+	}
+	res, err := s.ledger.Post(ctx, settleKind, settlementTxID(e.ID, e.Status), []ledger.Posting{
+		{AccountCode: AccountCode(e.ID), Amount: -e.Amount},
+		{AccountCode: target.AccountCode, Amount: e.Amount},
+	})
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		return "", err
+	}
+	return res.TransactionID, nil
+}
+
+// settle posts an escrow's settlement and records it.
+func (s *Service) settle(ctx context.Context, e Escrow) error {
+	txID, err := s.post(ctx, e)
+	if err != nil {
+		return err
+	}
+	return s.repo.MarkSettled(ctx, e.ID, txID, s.now())
+}
+
+// RunDue releases held escrows whose timeout has passed and completes settlements that were
+// interrupted after their status change. It returns how many escrows it acted on.
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.ListDue(ctx, now, sweepBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range due {
+		if err := ctx.Err(); err != nil {
+			return n, err
+		}
+		if _, err := s.finish(ctx, e, StatusReleased, "", "released automatically after the confirmation period"); err != nil {
+			s.logger.Error("escrow auto-release failed", slog.String("escrow_id", e.ID), slog.String("error", err.Error()))
+			continue
+		}
+		n++
+	}
+
+	unsettled, err := s.repo.ListUnsettled(ctx, now.Add(-unsettledGracePeriod), sweepBatchSize)
+	if err != nil {
+		return n, err
+	}
+	for _, e := range unsettled {
+		if err := s.settle(ctx, e); err != nil {
+			s.logger.Error("escrow settlement retry failed", slog.String("escrow_id", e.ID), slog.String("error", err.Error()))
+			continue
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (s *Service) notify(ctx context.Context, userID, body string) {
+	if s.notifier == nil {
+		return
+	}
+	_ = s.notifier.Send(ctx, notification.Message{
+		Kind:        notification.KindEscrow,
+		Destination: userID,
+		Body:        body,
+	})
+}
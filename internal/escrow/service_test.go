@@ -0,0 +1,213 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+type fixture struct {
+	svc     *Service
+	repo    Repository
+	led     ledger.Ledger
+	wallets *wallet.Service
+	users   *identity.Service
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	led := ledger.NewInMemory()
+	wallets := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	repo := NewMemoryRepository()
+	svc := NewService(repo, led, wallets, users, nil, nil, 48*time.Hour, nil)
+	return fixture{svc: svc, repo: repo, led: led, wallets: wallets, users: users}
+}
+
+func (f fixture) user(t *testing.T, phone string, balance int64) (identity.User, wallet.Wallet) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := f.users.Register(ctx, identity.Credentials{Phone: phone, PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register %s: %v", phone, err)
+	}
+	w, err := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: u.ID})
+	if err != nil {
+		t.Fatalf("wallet: %v", err)
+	}
+	ledger.SeedBalance(f.led, w.AccountCode, balance)
+	return u, w
+}
+
+func (f fixture) assertBalance(t *testing.T, code string, want int64) {
+	t.Helper()
+	if got, _ := f.led.Balance(context.Background(), code); got != want {
+		t.Fatalf("balance %s = %d, want %d", code, got, want)
+	}
+}
+
+func TestServiceConfirmReleasesToPayee(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	buyer, buyerWallet := f.user(t, "+242060000001", 10_000)
+	seller, sellerWallet := f.user(t, "+242060000002", 0)
+
+	e, err := f.svc.Create(ctx, CreateInput{PayerUserID: buyer.ID, PayeePhone: "+242060000002", Amount: 7_500, Description: "used phone"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if e.Status != StatusHeld || e.PayeeUserID != seller.ID {
+		t.Fatalf("unexpected escrow: %+v", e)
+	}
+	f.assertBalance(t, buyerWallet.AccountCode, 2_500)
+	f.assertBalance(t, AccountCode(e.ID), 7_500)
+
+	if _, err := f.svc.Confirm(ctx, e.ID, seller.ID); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expected seller confirm to be refused, got %v", err)
+	}
+	released, err := f.svc.Confirm(ctx, e.ID, buyer.ID)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if released.Status != StatusReleased || released.SettlementTxID == "" {
+		t.Fatalf("unexpected released escrow: %+v", released)
+	}
+	f.assertBalance(t, sellerWallet.AccountCode, 7_500)
+	f.assertBalance(t, AccountCode(e.ID), 0)
+
+	if _, err := f.svc.Cancel(ctx, e.ID, seller.ID); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected refund after release to fail, got %v", err)
+	}
+}
+
+func TestServiceSellerCancelRefunds(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	buyer, buyerWallet := f.user(t, "+242060000011", 5_000)
+	seller, sellerWallet := f.user(t, "+242060000012", 0)
+
+	e, err := f.svc.Create(ctx, CreateInput{PayerUserID: buyer.ID, PayeeWalletID: sellerWallet.ID, Amount: 5_000, Description: "sofa"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := f.svc.Cancel(ctx, e.ID, buyer.ID); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expected buyer cancel to be refused, got %v", err)
+	}
+	refunded, err := f.svc.Cancel(ctx, e.ID, seller.ID)
+	if err != nil || refunded.Status != StatusRefunded {
+		t.Fatalf("cancel: %+v, %v", refunded, err)
+	}
+	f.assertBalance(t, buyerWallet.AccountCode, 5_000)
+	f.assertBalance(t, sellerWallet.AccountCode, 0)
+}
+
+func TestServiceDisputeFreezesUntilResolved(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	buyer, buyerWallet := f.user(t, "+242060000021", 3_000)
+	seller, sellerWallet := f.user(t, "+242060000022", 0)
+	admin, _ := f.user(t, "+242060000023", 0)
+
+	e, _ := f.svc.Create(ctx, CreateInput{PayerUserID: buyer.ID, PayeeWalletID: sellerWallet.ID, Amount: 3_000, Description: "shoes"})
+	if _, err := f.svc.Dispute(ctx, e.ID, buyer.ID, "item never arrived"); err != nil {
+		t.Fatalf("dispute: %v", err)
+	}
+
+	// Neither the timeout nor the parties can move disputed funds.
+	if n, err := f.svc.RunDue(ctx, time.Now().UTC().Add(72*time.Hour)); err != nil || n != 0 {
+		t.Fatalf("run due on disputed escrow: %d, %v", n, err)
+	}
+	if _, err := f.svc.Cancel(ctx, e.ID, seller.ID); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected disputed cancel to fail, got %v", err)
+	}
+	f.assertBalance(t, AccountCode(e.ID), 3_000)
+
+	resolved, err := f.svc.Resolve(ctx, e.ID, admin.ID, ResolutionRefund, "courier confirmed loss")
+	if err != nil || resolved.Status != StatusRefunded || resolved.ResolvedByUserID != admin.ID {
+		t.Fatalf("resolve: %+v, %v", resolved, err)
+	}
+	f.assertBalance(t, buyerWallet.AccountCode, 3_000)
+}
+
+func TestServiceTimeoutReleaseAndSettlementRecovery(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	buyer, _ := f.user(t, "+242060000031", 4_000)
+	_, sellerWallet := f.user(t, "+242060000032", 0)
+
+	first, _ := f.svc.Create(ctx, CreateInput{PayerUserID: buyer.ID, PayeeWalletID: sellerWallet.ID, Amount: 1_000, Description: "a"})
+	second, _ := f.svc.Create(ctx, CreateInput{PayerUserID: buyer.ID, PayeeWalletID: sellerWallet.ID, Amount: 2_000, Description: "b", ReleaseAfter: 96 * time.Hour})
+
+	n, err := f.svc.RunDue(ctx, time.Now().UTC().Add(49*time.Hour))
+	if err != nil || n != 1 {
+		t.Fatalf("run due: %d, %v", n, err)
+	}
+	if e, _ := f.repo.Get(ctx, first.ID); e.Status != StatusReleased {
+		t.Fatalf("expected timed-out escrow released, got %s", e.Status)
+	}
+	f.assertBalance(t, sellerWallet.AccountCode, 1_000)
+
+	// Simulate a crash between the status change and the settlement posting.
+	second.Status = StatusReleased
+	_ = f.repo.Transition(ctx, StatusHeld, second)
+	if n, _ := f.svc.RunDue(ctx, time.Now().UTC().Add(10*time.Minute)); n != 1 {
+		t.Fatalf("expected interrupted settlement to be completed, got %d", n)
+	}
+	f.assertBalance(t, sellerWallet.AccountCode, 3_000)
+	if n, _ := f.svc.RunDue(ctx, time.Now().UTC().Add(10*time.Minute)); n != 0 {
+		t.Fatalf("expected nothing left to settle, got %d", n)
+	}
+}
+
+// unrecordedRepository fails to record the first settlement, as if the database went away
+// right after the posting.
+type unrecordedRepository struct {
+	Repository
+	failed bool
+}
+
+func (r *unrecordedRepository) MarkSettled(ctx context.Context, id, txID string, at time.Time) error {
+	if !r.failed {
+		r.failed = true
+		return errors.New("connection reset")
+	}
+	return r.Repository.MarkSettled(ctx, id, txID, at)
+}
+
+func TestServiceReleaseKeepsStatusWhenSettlementIsNotRecorded(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	buyer, buyerWallet := f.user(t, "+242060000041", 5_000)
+	seller, sellerWallet := f.user(t, "+242060000042", 0)
+	f.svc.repo = &unrecordedRepository{Repository: f.repo}
+
+	e, err := f.svc.Create(ctx, CreateInput{PayerUserID: buyer.ID, PayeeWalletID: sellerWallet.ID, Amount: 5_000, Description: "goats"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	released, err := f.svc.Confirm(ctx, e.ID, buyer.ID)
+	if err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	if released.Status != StatusReleased || released.SettlementTxID != "" {
+		t.Fatalf("expected a released escrow awaiting its settlement record, got %+v", released)
+	}
+	f.assertBalance(t, sellerWallet.AccountCode, 5_000)
+
+	if _, err := f.svc.Cancel(ctx, e.ID, seller.ID); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected refund after release to fail, got %v", err)
+	}
+	if n, _ := f.svc.RunDue(ctx, time.Now().UTC().Add(10*time.Minute)); n != 1 {
+		t.Fatalf("expected the settlement to be recorded, got %d", n)
+	}
+	if got, _ := f.repo.Get(ctx, e.ID); got.Status != StatusReleased || got.SettlementTxID == "" {
+		t.Fatalf("unexpected escrow after sweep: %+v", got)
+	}
+	f.assertBalance(t, buyerWallet.AccountCode, 0)
+	f.assertBalance(t, sellerWallet.AccountCode, 5_000)
+}
@@ -14,6 +14,7 @@ type CardOutRequest struct {
 	CardNumber string `json:"card_number"`
 	Amount     int64  `json:"amount_cfa"`
 	ClientTxID string `json:"client_tx_id"`
+	OTPCode    string `json:"otp_code"`
 }
 
 // FundingResponse represents the API response for card funding actions.
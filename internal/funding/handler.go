@@ -6,7 +6,9 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/congo-pay/congo_pay/internal/guard"
 	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
 )
 
 // Handler exposes HTTP endpoints for card funding flows.
@@ -41,6 +43,8 @@ func (h *Handler) CardIn(c *fiber.Ctx) error {
 			return c.Status(http.StatusOK).JSON(toResponse(result))
 		case errors.Is(err, ledger.ErrInsufficientFunds):
 			return fiber.NewError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked), errors.Is(err, ledger.ErrAccountRestricted):
+			return fiber.NewError(http.StatusConflict, err.Error())
 		default:
 			return fiber.NewError(http.StatusBadRequest, err.Error())
 		}
@@ -57,18 +61,25 @@ func (h *Handler) CardOut(c *fiber.Ctx) error {
 		return fiber.NewError(http.StatusBadRequest, err.Error())
 	}
 
+	uid, _ := c.Locals("user_id").(string)
 	result, err := h.service.CardOut(c.UserContext(), CardOutInput{
-		WalletID:   walletID,
-		Amount:     req.Amount,
-		ClientTxID: req.ClientTxID,
-		CardNumber: req.CardNumber,
+		WalletID:    walletID,
+		OwnerUserID: uid,
+		Amount:      req.Amount,
+		ClientTxID:  req.ClientTxID,
+		CardNumber:  req.CardNumber,
+		OTPCode:     req.OTPCode,
 	})
 	if err != nil {
 		switch {
 		case errors.Is(err, ledger.ErrDuplicateTransaction):
 			return c.Status(http.StatusOK).JSON(toResponse(result))
+		case errors.Is(err, wallet.ErrNotOwner), errors.Is(err, guard.ErrDenied):
+			return fiber.NewError(http.StatusForbidden, err.Error())
 		case errors.Is(err, ledger.ErrInsufficientFunds):
 			return fiber.NewError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked), errors.Is(err, ledger.ErrAccountRestricted):
+			return fiber.NewError(http.StatusConflict, err.Error())
 		default:
 			return fiber.NewError(http.StatusBadRequest, err.Error())
 		}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/google/uuid"
 
+	"github.com/congo-pay/congo_pay/internal/guard"
 	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/risk"
 	"github.com/congo-pay/congo_pay/internal/wallet"
 )
 
@@ -18,10 +20,11 @@ type Service struct {
 	ledger   ledger.Ledger
 	wallets  *wallet.Service
 	acquirer Acquirer
+	outgoing *guard.Guard
 }
 
 // NewService prepares a funding service ensuring the card suspense account exists.
-func NewService(ctx context.Context, ledgerBackend ledger.Ledger, wallets *wallet.Service, acquirer Acquirer) (*Service, error) {
+func NewService(ctx context.Context, ledgerBackend ledger.Ledger, wallets *wallet.Service, acquirer Acquirer, outgoing *guard.Guard) (*Service, error) {
 	if wallets == nil {
 		return nil, fmt.Errorf("wallet service is required")
 	}
@@ -31,7 +34,7 @@ func NewService(ctx context.Context, ledgerBackend ledger.Ledger, wallets *walle
 	if err := ledgerBackend.EnsureAccount(ctx, ledger.CardSuspenseAccountCode); err != nil {
 		return nil, err
 	}
-	return &Service{ledger: ledgerBackend, wallets: wallets, acquirer: acquirer}, nil
+	return &Service{ledger: ledgerBackend, wallets: wallets, acquirer: acquirer, outgoing: outgoing}, nil
 }
 
 // CardInInput captures the required data for a card top-up.
@@ -46,10 +49,13 @@ type CardInInput struct {
 
 // CardOutInput captures the required data for a card withdrawal.
 type CardOutInput struct {
-	WalletID   string
-	Amount     int64
-	ClientTxID string
-	CardNumber string
+	WalletID    string
+	OwnerUserID string
+	Amount      int64
+	ClientTxID  string
+	CardNumber  string
+	// OTPCode confirms a high-value withdrawal.
+	OTPCode string
 }
 
 // FundingResult represents the domain outcome of a card operation.
@@ -77,6 +83,9 @@ func (s *Service) CardIn(ctx context.Context, input CardInInput) (FundingResult,
 	if err != nil {
 		return FundingResult{}, err
 	}
+	if err := w.CanCredit(); err != nil {
+		return FundingResult{}, err
+	}
 
 	decision, err := s.acquirer.AuthorizeCardIn(ctx, CardInAuthorization{
 		CardNumber: input.CardNumber,
@@ -127,6 +136,15 @@ func (s *Service) CardOut(ctx context.Context, input CardOutInput) (FundingResul
 	if err != nil {
 		return FundingResult{}, err
 	}
+	if w.OwnerID != input.OwnerUserID {
+		return FundingResult{}, wallet.ErrNotOwner
+	}
+	if err := w.CanDebit(); err != nil {
+		return FundingResult{}, err
+	}
+	if err := s.outgoing.Check(ctx, guard.Outgoing{UserID: w.OwnerID, Op: risk.OpWithdrawal, Amount: input.Amount, OTPCode: input.OTPCode}); err != nil {
+		return FundingResult{}, err
+	}
 
 	decision, err := s.acquirer.AuthorizeCardOut(ctx, CardOutAuthorization{
 		CardNumber: input.CardNumber,
@@ -15,7 +15,7 @@ func TestServiceCardIn(t *testing.T) {
 	ctx := context.Background()
 	ledgerBackend := ledger.NewInMemory()
 	walletRepo := wallet.NewMemoryRepository()
-	walletSvc := wallet.NewService(walletRepo, ledgerBackend)
+	walletSvc := wallet.NewService(walletRepo, ledgerBackend, nil)
 
 	ownerID := uuid.NewString()
 	walletRec, err := walletSvc.Create(ctx, wallet.CreateInput{OwnerID: ownerID, Currency: "XAF"})
@@ -23,7 +23,7 @@ func TestServiceCardIn(t *testing.T) {
 		t.Fatalf("create wallet: %v", err)
 	}
 
-	service, err := NewService(ctx, ledgerBackend, walletSvc, StaticAcquirer{})
+	service, err := NewService(ctx, ledgerBackend, walletSvc, StaticAcquirer{}, nil)
 	if err != nil {
 		t.Fatalf("new service: %v", err)
 	}
@@ -63,7 +63,7 @@ func TestServiceCardOut(t *testing.T) {
 	ctx := context.Background()
 	ledgerBackend := ledger.NewInMemory()
 	walletRepo := wallet.NewMemoryRepository()
-	walletSvc := wallet.NewService(walletRepo, ledgerBackend)
+	walletSvc := wallet.NewService(walletRepo, ledgerBackend, nil)
 
 	ownerID := uuid.NewString()
 	walletRec, err := walletSvc.Create(ctx, wallet.CreateInput{OwnerID: ownerID, Currency: "XAF"})
@@ -73,15 +73,25 @@ func TestServiceCardOut(t *testing.T) {
 
 	ledger.SeedBalance(ledgerBackend, walletRec.AccountCode, 5_000)
 
-	service, err := NewService(ctx, ledgerBackend, walletSvc, StaticAcquirer{})
+	service, err := NewService(ctx, ledgerBackend, walletSvc, StaticAcquirer{}, nil)
 	if err != nil {
 		t.Fatalf("new service: %v", err)
 	}
 
+	if _, err := service.CardOut(ctx, CardOutInput{
+		WalletID:    walletRec.ID,
+		OwnerUserID: uuid.NewString(),
+		Amount:      2_000,
+		CardNumber:  "4111111111111111",
+	}); !errors.Is(err, wallet.ErrNotOwner) {
+		t.Fatalf("expected not owner, got %v", err)
+	}
+
 	res, err := service.CardOut(ctx, CardOutInput{
-		WalletID:   walletRec.ID,
-		Amount:     2_000,
-		CardNumber: "4111111111111111",
+		WalletID:    walletRec.ID,
+		OwnerUserID: ownerID,
+		Amount:      2_000,
+		CardNumber:  "4111111111111111",
 	})
 	if err != nil {
 		t.Fatalf("card out: %v", err)
@@ -91,10 +101,11 @@ func TestServiceCardOut(t *testing.T) {
 	}
 
 	_, err = service.CardOut(ctx, CardOutInput{
-		WalletID:   walletRec.ID,
-		Amount:     10_000,
-		CardNumber: "4111111111111111",
-		ClientTxID: "excess",
+		WalletID:    walletRec.ID,
+		OwnerUserID: ownerID,
+		Amount:      10_000,
+		CardNumber:  "4111111111111111",
+		ClientTxID:  "excess",
 	})
 	if !errors.Is(err, ledger.ErrInsufficientFunds) {
 		t.Fatalf("expected insufficient funds, got %v", err)
@@ -0,0 +1,101 @@
+// Package guard screens money leaving a customer's wallets. Every outgoing flow calls
+// Guard.Check from its service, so protections cannot be skipped by a route that forgot a
+// middleware or by a flow that debits the customer on someone else's request.
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/otp"
+	"github.com/congo-pay/congo_pay/internal/risk"
+)
+
+var (
+	// ErrDenied wraps every refusal, so handlers can map them to 403 in one place. The
+	// underlying cause (for example risk.ErrRestricted) stays reachable with errors.Is.
+	ErrDenied = errors.New("outgoing payment denied")
+	// ErrOTPRequired indicates a high-value movement arrived without a confirmation code.
+	ErrOTPRequired = errors.New("otp_code required for this amount, request one with purpose high_value_transfer")
+)
+
+// Outgoing describes one movement of a customer's money.
+type Outgoing struct {
+	// UserID is the customer whose wallet is debited.
+	UserID string
+	// Op is risk.OpWithdrawal for cash leaving the platform, otherwise risk.OpP2P or
+	// risk.OpPayment.
+	Op     string
+	Amount int64
+	// OTPCode is the high_value_transfer code texted to the customer; it is required from
+	// the high-value amount on.
+	OTPCode string
+	// Preauthorized skips the code for movements the customer confirmed another way:
+	// standing order runs were confirmed when the order was set up, and agent cash-outs
+	// are confirmed in person with the customer's PIN or withdrawal code.
+	Preauthorized bool
+	// Planned marks a movement set up now and made later, such as a standing order. It is
+	// screened without using up a restricted account's allowance, which each run uses.
+	Planned bool
+}
+
+// Guard applies the outgoing protections. A nil *Guard allows everything, which keeps
+// service tests free of risk and OTP wiring.
+type Guard struct {
+	risks *risk.Service
+	otps  *otp.Service
+	users *identity.Service
+	// highValueAmount is the amount from which OTPCode is required; zero disables it.
+	highValueAmount int64
+}
+
+// New builds a guard. risks or otps may be nil to skip SIM swap screening or high-value
+// confirmation.
+func New(risks *risk.Service, otps *otp.Service, users *identity.Service, highValueAmount int64) *Guard {
+	return &Guard{risks: risks, otps: otps, users: users, highValueAmount: highValueAmount}
+}
+
+// Check fails with ErrDenied when the movement must not happen. Risk screening runs first
+// so a refused movement does not use up the customer's code.
+func (g *Guard) Check(ctx context.Context, o Outgoing) error {
+	if g == nil {
+		return nil
+	}
+	if g.risks != nil {
+		check := g.risks.CheckOutgoing
+		if o.Planned {
+			check = g.risks.CheckPlanned
+		}
+		if err := check(ctx, o.UserID, o.Op, o.Amount); err != nil {
+			if errors.Is(err, risk.ErrRestricted) {
+				return fmt.Errorf("%w: %w", ErrDenied, err)
+			}
+			return err
+		}
+	}
+	return g.confirm(ctx, o)
+}
+
+// confirm requires a high_value_transfer code for large movements, so a stolen session
+// alone cannot drain a wallet.
+func (g *Guard) confirm(ctx context.Context, o Outgoing) error {
+	if g.otps == nil || g.highValueAmount <= 0 || o.Amount < g.highValueAmount || o.Preauthorized {
+		return nil
+	}
+	if o.OTPCode == "" {
+		return fmt.Errorf("%w: %w", ErrDenied, ErrOTPRequired)
+	}
+	user, err := g.users.Get(ctx, o.UserID)
+	if err != nil {
+		return err
+	}
+	if err := g.otps.Verify(ctx, otp.PurposeHighValueTransfer, user.Phone, o.OTPCode); err != nil {
+		if errors.Is(err, otp.ErrInvalidCode) {
+			return fmt.Errorf("%w: %w", ErrDenied, err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/logging"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/otp"
+	"github.com/congo-pay/congo_pay/internal/risk"
+)
+
+type outbox struct {
+	sent []notification.Message
+}
+
+func (o *outbox) Send(_ context.Context, msg notification.Message) error {
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+func TestGuardCheck(t *testing.T) {
+	ctx := context.Background()
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	swaps := risk.NewStaticSIMSwapChecker()
+	risks := risk.NewService(risk.NewMemoryRepository(), swaps, users, nil, risk.DefaultPolicy(), logging.Discard())
+	texts := &outbox{}
+	otps := otp.NewService(otp.NewMemoryStore(), texts, "test-secret")
+	g := New(risks, otps, users, 50_000)
+
+	customer, err := users.Register(ctx, identity.Credentials{Phone: "+242060000301", PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	swapped, err := users.Register(ctx, identity.Credentials{Phone: "+242060000302", PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	swaps.Set(swapped.Phone, time.Now().UTC())
+
+	if _, err := otps.Send(ctx, otp.SendInput{Purpose: otp.PurposeHighValueTransfer, Phone: customer.Phone}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	code := strings.Fields(texts.sent[len(texts.sent)-1].Body)[3]
+	wrong := "000000"
+	if wrong == code {
+		wrong = "111111"
+	}
+
+	// Cases run in order: the wrong code is guessed before the right one uses it up.
+	cases := []struct {
+		name  string
+		guard *Guard
+		out   Outgoing
+		want  []error
+	}{
+		{"nil guard", nil, Outgoing{UserID: swapped.ID, Op: risk.OpWithdrawal, Amount: 1_000_000}, nil},
+		{"restricted withdrawal", g, Outgoing{UserID: swapped.ID, Op: risk.OpWithdrawal, Amount: 1_000}, []error{ErrDenied, risk.ErrRestricted}},
+		{"restricted p2p above the cap", g, Outgoing{UserID: swapped.ID, Op: risk.OpP2P, Amount: 20_000, Preauthorized: true}, []error{ErrDenied, risk.ErrRestricted}},
+		{"below the high-value amount", g, Outgoing{UserID: customer.ID, Op: risk.OpP2P, Amount: 49_999}, nil},
+		{"high value without a code", g, Outgoing{UserID: customer.ID, Op: risk.OpP2P, Amount: 50_000}, []error{ErrDenied, ErrOTPRequired}},
+		{"high value preauthorized", g, Outgoing{UserID: customer.ID, Op: risk.OpPayment, Amount: 50_000, Preauthorized: true}, nil},
+		{"high value with a wrong code", g, Outgoing{UserID: customer.ID, Op: risk.OpP2P, Amount: 50_000, OTPCode: wrong}, []error{ErrDenied, otp.ErrInvalidCode}},
+		{"high value with the code", g, Outgoing{UserID: customer.ID, Op: risk.OpP2P, Amount: 50_000, OTPCode: code}, nil},
+		{"high value with a used code", g, Outgoing{UserID: customer.ID, Op: risk.OpP2P, Amount: 50_000, OTPCode: code}, []error{ErrDenied, otp.ErrInvalidCode}},
+	}
+	for _, tc := range cases {
+		err := tc.guard.Check(ctx, tc.out)
+		if tc.want == nil && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		for _, want := range tc.want {
+			if !errors.Is(err, want) {
+				t.Errorf("%s: expected %v, got %v", tc.name, want, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package identity
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// bindDevice trusts a device for the user, reviving it if it was revoked, and makes it the
+// user's current device. coolingOffUntil may be zero.
+func (s *Service) bindDevice(ctx context.Context, user *User, creds Credentials, coolingOffUntil time.Time) error {
+    now := s.now()
+    err := s.repo.SaveDevice(ctx, Device{
+        ID:              uuid.NewString(),
+        UserID:          user.ID,
+        DeviceID:        creds.DeviceID,
+        Name:            strings.TrimSpace(creds.DeviceName),
+        Platform:        strings.ToLower(strings.TrimSpace(creds.Platform)),
+        Trusted:         true,
+        FirstSeenAt:     now,
+        LastSeenAt:      now,
+        CoolingOffUntil: coolingOffUntil,
+    })
+    if err != nil {
+        return err
+    }
+    if user.DeviceID != creds.DeviceID {
+        if err := s.repo.UpdateDevice(ctx, user.ID, creds.DeviceID); err != nil {
+            return err
+        }
+        user.DeviceID = creds.DeviceID
+    }
+    return nil
+}
+
+// Devices lists the user's devices, most recently seen first.
+func (s *Service) Devices(ctx context.Context, userID string) ([]Device, error) {
+    return s.repo.ListDevices(ctx, userID)
+}
+
+// RevokeDevice signs a device out and untrusts it; signing in from it again needs the
+// device-change flow. actor is "user" or "admin:<id>".
+func (s *Service) RevokeDevice(ctx context.Context, userID, deviceID, actor string) (Device, error) {
+    device, err := s.repo.FindDevice(ctx, userID, deviceID)
+    if err != nil {
+        return Device{}, err
+    }
+    if !device.RevokedAt.IsZero() {
+        return device, nil
+    }
+    device.Trusted = false
+    device.RevokedAt = s.now()
+    if err := s.repo.SaveDevice(ctx, device); err != nil {
+        return Device{}, err
+    }
+    s.securityEvent(ctx, userID, EventDeviceRevoked, fmt.Sprintf("revoked %q", deviceID), actor)
+    return device, nil
+}
+
+// ActiveDevice returns the device a session belongs to, failing once it is revoked.
+func (s *Service) ActiveDevice(ctx context.Context, userID, deviceID string) (Device, error) {
+    if deviceID == "" {
+        return Device{}, ErrDeviceRequired
+    }
+    device, err := s.repo.FindDevice(ctx, userID, deviceID)
+    if err != nil {
+        return Device{}, err
+    }
+    if !device.Active() {
+        return Device{}, ErrDeviceMismatch
+    }
+    return device, nil
+}
+
+// CheckOutgoing allows an outgoing transfer from a session on deviceID unless the device
+// is revoked or still cooling off after a device change.
+func (s *Service) CheckOutgoing(ctx context.Context, userID, deviceID string) error {
+    device, err := s.ActiveDevice(ctx, userID, deviceID)
+    if err != nil {
+        return err
+    }
+    if s.now().Before(device.CoolingOffUntil) {
+        return fmt.Errorf("%w until %s", ErrDeviceCoolingOff, device.CoolingOffUntil.Format(time.RFC3339))
+    }
+    return nil
+}
@@ -2,25 +2,29 @@ package identity
 
 import (
     "context"
-    "errors"
+    "sort"
     "sync"
+    "time"
 )
 
 type memoryRepository struct {
-    mu    sync.RWMutex
-    users map[string]User
+    mu      sync.RWMutex
+    users   map[string]User
+    kyc     map[string]KYCProfile
+    events  []SecurityEvent
+    devices map[string][]Device
 }
 
 // NewMemoryRepository builds an in-memory user store for testing.
 func NewMemoryRepository() Repository {
-    return &memoryRepository{users: make(map[string]User)}
+    return &memoryRepository{users: make(map[string]User), kyc: make(map[string]KYCProfile), devices: make(map[string][]Device)}
 }
 
 func (r *memoryRepository) Create(_ context.Context, user User) error {
     r.mu.Lock()
     defer r.mu.Unlock()
     if _, exists := r.users[user.Phone]; exists {
-        return errors.New("user exists")
+        return ErrUserExists
     }
     r.users[user.Phone] = user
     return nil
@@ -31,7 +35,7 @@ func (r *memoryRepository) FindByPhone(_ context.Context, phone string) (User, e
     defer r.mu.RUnlock()
     user, ok := r.users[phone]
     if !ok {
-        return User{}, errors.New("user not found")
+        return User{}, ErrUserNotFound
     }
     return user, nil
 }
@@ -46,7 +50,7 @@ func (r *memoryRepository) UpdateDevice(_ context.Context, id, deviceID string)
             return nil
         }
     }
-    return errors.New("user not found")
+    return ErrUserNotFound
 }
 
 func (r *memoryRepository) FindByID(_ context.Context, id string) (User, error) {
@@ -57,7 +61,7 @@ func (r *memoryRepository) FindByID(_ context.Context, id string) (User, error)
             return user, nil
         }
     }
-    return User{}, errors.New("user not found")
+    return User{}, ErrUserNotFound
 }
 
 func (r *memoryRepository) UpdateTokenVersion(_ context.Context, id string, version int) error {
@@ -70,5 +74,161 @@ func (r *memoryRepository) UpdateTokenVersion(_ context.Context, id string, vers
             return nil
         }
     }
-    return errors.New("user not found")
+    return ErrUserNotFound
+}
+
+func (r *memoryRepository) SaveDevice(_ context.Context, device Device) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    devices := r.devices[device.UserID]
+    for i, d := range devices {
+        if d.DeviceID == device.DeviceID {
+            device.ID, device.FirstSeenAt = d.ID, d.FirstSeenAt
+            devices[i] = device
+            return nil
+        }
+    }
+    r.devices[device.UserID] = append(devices, device)
+    return nil
+}
+
+func (r *memoryRepository) FindDevice(_ context.Context, userID, deviceID string) (Device, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    for _, d := range r.devices[userID] {
+        if d.DeviceID == deviceID {
+            return d, nil
+        }
+    }
+    return Device{}, ErrDeviceNotFound
+}
+
+func (r *memoryRepository) ListDevices(_ context.Context, userID string) ([]Device, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    out := append([]Device(nil), r.devices[userID]...)
+    sort.SliceStable(out, func(i, j int) bool { return out[i].LastSeenAt.After(out[j].LastSeenAt) })
+    return out, nil
+}
+
+func (r *memoryRepository) TouchDevice(_ context.Context, userID, deviceID string, at time.Time) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for i, d := range r.devices[userID] {
+        if d.DeviceID == deviceID {
+            r.devices[userID][i].LastSeenAt = at
+        }
+    }
+    return nil
+}
+
+func (r *memoryRepository) UpdateTier(_ context.Context, id, tier string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for phone, user := range r.users {
+        if user.ID == id {
+            user.Tier = tier
+            r.users[phone] = user
+            return nil
+        }
+    }
+    return ErrUserNotFound
+}
+
+func (r *memoryRepository) UpdatePIN(_ context.Context, id string, hash []byte) (int, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for phone, user := range r.users {
+        if user.ID == id {
+            user.PINHash = hash
+            user.TokenVersion++
+            user.PIN = PINState{Blocked: user.PIN.Blocked}
+            r.users[phone] = user
+            return user.TokenVersion, nil
+        }
+    }
+    return 0, ErrUserNotFound
+}
+
+func (r *memoryRepository) UpdateRoles(_ context.Context, id string, roles []string) (int, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for phone, user := range r.users {
+        if user.ID == id {
+            user.Roles = append([]string(nil), roles...)
+            user.TokenVersion++
+            r.users[phone] = user
+            return user.TokenVersion, nil
+        }
+    }
+    return 0, ErrUserNotFound
+}
+
+func (r *memoryRepository) RecordPINAttempt(_ context.Context, id string, apply func(PINState) (PINState, error)) (PINState, error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for phone, user := range r.users {
+        if user.ID == id {
+            state, err := apply(user.PIN)
+            if err != nil {
+                return user.PIN, err
+            }
+            user.PIN = state
+            r.users[phone] = user
+            return state, nil
+        }
+    }
+    return PINState{}, ErrUserNotFound
+}
+
+func (r *memoryRepository) UpdatePINState(_ context.Context, id string, state PINState) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    for phone, user := range r.users {
+        if user.ID == id {
+            user.PIN = state
+            r.users[phone] = user
+            return nil
+        }
+    }
+    return ErrUserNotFound
+}
+
+func (r *memoryRepository) AddSecurityEvent(_ context.Context, event SecurityEvent) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.events = append(r.events, event)
+    return nil
+}
+
+func (r *memoryRepository) SecurityEvents(_ context.Context, userID string, limit int) ([]SecurityEvent, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    var out []SecurityEvent
+    for i := len(r.events) - 1; i >= 0; i-- {
+        if r.events[i].UserID == userID {
+            out = append(out, r.events[i])
+        }
+        if limit > 0 && len(out) == limit {
+            break
+        }
+    }
+    return out, nil
+}
+
+func (r *memoryRepository) SaveKYC(_ context.Context, profile KYCProfile) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.kyc[profile.UserID] = profile
+    return nil
+}
+
+func (r *memoryRepository) FindKYC(_ context.Context, userID string) (KYCProfile, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    profile, ok := r.kyc[userID]
+    if !ok {
+        return KYCProfile{}, ErrKYCNotFound
+    }
+    return profile, nil
 }
@@ -1,6 +1,84 @@
 package identity
 
-import "time"
+import (
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+)
+
+var (
+    // ErrInvalidPIN indicates the supplied PIN does not match the user's PIN.
+    ErrInvalidPIN = errors.New("invalid PIN")
+    // ErrUserNotFound indicates no user matches the lookup.
+    ErrUserNotFound = errors.New("user not found")
+    // ErrUserExists indicates the phone number is already registered.
+    ErrUserExists = errors.New("user exists")
+    // ErrKYCNotFound indicates the user has no captured KYC profile.
+    ErrKYCNotFound = errors.New("KYC profile not found")
+    // ErrPINLocked indicates too many wrong PINs; the user must wait before trying again.
+    ErrPINLocked = errors.New("PIN temporarily locked")
+    // ErrPINBlocked indicates the PIN was locked repeatedly and needs an agent or support to unlock it.
+    ErrPINBlocked = errors.New("PIN blocked, visit an agent or contact support")
+    // ErrWeakPIN indicates a new PIN that is too easy to guess.
+    ErrWeakPIN = errors.New("PIN is too easy to guess")
+    // ErrDeviceMismatch indicates a login from a device the user has not verified.
+    ErrDeviceMismatch = errors.New("device mismatch, verify this device with a device_change code")
+    // ErrDeviceRequired indicates a request without a device identifier.
+    ErrDeviceRequired = errors.New("device_id is required")
+    // ErrDeviceNotFound indicates the user has no such device.
+    ErrDeviceNotFound = errors.New("device not found")
+    // ErrDeviceCoolingOff indicates outgoing transfers from a newly added device are held.
+    ErrDeviceCoolingOff = errors.New("outgoing transfers from a new device are on hold")
+    // ErrUnknownRole indicates a role outside the known set.
+    ErrUnknownRole = errors.New("unknown role")
+)
+
+// Security event kinds recorded in a user's audit trail.
+const (
+    EventPINFailed     = "pin_failed"
+    EventPINLocked     = "pin_locked"
+    EventPINBlocked    = "pin_blocked"
+    EventPINUnlocked   = "pin_unlocked"
+    EventPINChanged    = "pin_changed"
+    EventPINReset      = "pin_reset"
+    EventDeviceChanged = "device_changed"
+    EventDeviceRevoked = "device_revoked"
+    EventRefreshReused = "refresh_reused"
+    EventRolesChanged  = "roles_changed"
+)
+
+// DeviceCoolingOff is how long outgoing transfers are held on a device added through the
+// device-change flow, so whoever took over the phone number cannot drain the wallet at once.
+const DeviceCoolingOff = 24 * time.Hour
+
+// KYC tiers, from least to most verified. Higher tiers get higher limits.
+const (
+    Tier0 = "tier0"
+    Tier1 = "tier1"
+    Tier2 = "tier2"
+)
+
+// TierRank orders tiers, returning -1 for an unknown tier.
+func TierRank(tier string) int {
+    switch tier {
+    case Tier0:
+        return 0
+    case Tier1:
+        return 1
+    case Tier2:
+        return 2
+    default:
+        return -1
+    }
+}
+
+// Identity document types accepted during KYC capture.
+const (
+    DocumentNationalID       = "national_id"
+    DocumentPassport         = "passport"
+    DocumentResidencePermit  = "residence_permit"
+)
 
 // User represents a registered wallet owner.
 type User struct {
@@ -10,13 +88,101 @@ type User struct {
     PINHash   []byte
     DeviceID  string
     TokenVersion int
+    // OnboardingAgentID is the agent that registered the user in the field, if any.
+    OnboardingAgentID string
+    // Roles always include RoleCustomer; see ScopesFor.
+    Roles     []string
+    PIN       PINState
     LastLogin time.Time
     CreatedAt time.Time
 }
 
+// PINState tracks failed PIN attempts and locks.
+type PINState struct {
+    // FailedAttempts counts consecutive wrong PINs since the last success or lock.
+    FailedAttempts int
+    // LockedUntil rejects PIN checks before it, either as a progressive delay or a lock.
+    LockedUntil time.Time
+    // LockCount counts temporary locks since the last successful PIN.
+    LockCount int
+    // Blocked is set after repeated locks and cleared only by an explicit unlock.
+    Blocked bool
+}
+
+// Device is a phone or other client a user signs in from.
+type Device struct {
+    ID       string
+    UserID   string
+    DeviceID string
+    Name     string
+    Platform string
+    // Trusted devices can sign in with the PIN alone; revoking a device clears it.
+    Trusted     bool
+    FirstSeenAt time.Time
+    LastSeenAt  time.Time
+    // CoolingOffUntil holds outgoing transfers from a device added by device change.
+    CoolingOffUntil time.Time
+    RevokedAt       time.Time
+}
+
+// Active reports whether the device may be used to sign in.
+func (d Device) Active() bool {
+    return d.Trusted && d.RevokedAt.IsZero()
+}
+
+// SecurityEvent is an entry of a user's security audit trail.
+type SecurityEvent struct {
+    ID     string
+    UserID string
+    Kind   string
+    Detail string
+    // Actor is who caused the event: "user", "agent:<id>" or "admin:<id>".
+    Actor     string
+    CreatedAt time.Time
+}
+
 // Credentials request structure.
 type Credentials struct {
     Phone    string
     PIN      string
     DeviceID string
+    // DeviceName and Platform describe the device, e.g. "Galaxy A14" and "android".
+    DeviceName string
+    Platform   string
+}
+
+// KYCProfile is the identity information captured for a user. Photo fields hold references
+// to images uploaded separately, never the images themselves.
+type KYCProfile struct {
+    UserID           string
+    FullName         string
+    DocumentType     string
+    DocumentNumber   string
+    DocumentFrontRef string
+    // DocumentBackRef is optional since passports have no back side.
+    DocumentBackRef  string
+    SelfieRef        string
+    // CapturedByAgentID is set when an agent captured the profile.
+    CapturedByAgentID string
+    CreatedAt        time.Time
+}
+
+// Validate checks that the mandatory KYC fields are present.
+func (k KYCProfile) Validate() error {
+    switch {
+    case strings.TrimSpace(k.FullName) == "":
+        return errors.New("full name is required")
+    case strings.TrimSpace(k.DocumentNumber) == "":
+        return errors.New("document number is required")
+    case k.DocumentFrontRef == "":
+        return errors.New("document photo is required")
+    case k.SelfieRef == "":
+        return errors.New("selfie photo is required")
+    }
+    switch k.DocumentType {
+    case DocumentNationalID, DocumentPassport, DocumentResidencePermit:
+        return nil
+    default:
+        return fmt.Errorf("unknown document type %q", k.DocumentType)
+    }
 }
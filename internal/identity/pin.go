@@ -0,0 +1,165 @@
+package identity
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/google/uuid"
+    "golang.org/x/crypto/bcrypt"
+
+    "github.com/congo-pay/congo_pay/internal/notification"
+)
+
+// PINPolicy bounds PIN guessing. The first FreeAttempts wrong PINs cost nothing, later
+// ones impose a delay doubling from BaseDelay, MaxAttempts wrong PINs lock the PIN for
+// LockDuration and MaxLocks locks without a successful PIN in between block it until an
+// agent or support unlocks it.
+type PINPolicy struct {
+    FreeAttempts int
+    BaseDelay    time.Duration
+    MaxAttempts  int
+    LockDuration time.Duration
+    MaxLocks     int
+}
+
+// DefaultPINPolicy returns the production PIN policy.
+func DefaultPINPolicy() PINPolicy {
+    return PINPolicy{
+        FreeAttempts: 2,
+        BaseDelay:    30 * time.Second,
+        MaxAttempts:  5,
+        LockDuration: 30 * time.Minute,
+        MaxLocks:     3,
+    }
+}
+
+// afterFailure applies a wrong PIN already counted in state.FailedAttempts.
+func (p PINPolicy) afterFailure(state PINState, now time.Time) PINState {
+    switch {
+    case state.FailedAttempts >= p.MaxAttempts:
+        state.FailedAttempts = 0
+        state.LockCount++
+        state.LockedUntil = now.Add(p.LockDuration)
+        if state.LockCount >= p.MaxLocks {
+            state.Blocked = true
+        }
+    case state.FailedAttempts > p.FreeAttempts:
+        state.LockedUntil = now.Add(p.BaseDelay << (state.FailedAttempts - p.FreeAttempts - 1))
+    }
+    return state
+}
+
+// checkPIN verifies pin against the user's hash, enforcing and updating the lockout state.
+// Every attempt is recorded as a failure, lock included, before bcrypt runs and the state
+// is reset only once the PIN matches, so a locked PIN gives no oracle and concurrent
+// guesses cannot exceed MaxAttempts.
+func (s *Service) checkPIN(ctx context.Context, user *User, pin string) error {
+    now := s.now()
+    var counted PINState
+    state, err := s.repo.RecordPINAttempt(ctx, user.ID, func(current PINState) (PINState, error) {
+        if current.Blocked {
+            return current, ErrPINBlocked
+        }
+        if now.Before(current.LockedUntil) {
+            return current, fmt.Errorf("%w until %s", ErrPINLocked, current.LockedUntil.Format(time.RFC3339))
+        }
+        counted = current
+        counted.FailedAttempts++
+        return s.pinPolicy.afterFailure(counted, now), nil
+    })
+    if err != nil {
+        if errors.Is(err, ErrPINBlocked) || errors.Is(err, ErrPINLocked) {
+            user.PIN = state
+        }
+        return err
+    }
+    if err := bcrypt.CompareHashAndPassword(user.PINHash, []byte(pin)); err == nil {
+        if err := s.repo.UpdatePINState(ctx, user.ID, PINState{}); err != nil {
+            return err
+        }
+        user.PIN = PINState{}
+        return nil
+    }
+
+    user.PIN = state
+    s.securityEvent(ctx, user.ID, EventPINFailed, fmt.Sprintf("attempt %d", counted.FailedAttempts), "user")
+    switch {
+    case state.Blocked:
+        s.securityEvent(ctx, user.ID, EventPINBlocked, fmt.Sprintf("lock %d", state.LockCount), "user")
+        s.notifySecurity(ctx, user.ID, "Your Congo Pay PIN is blocked after repeated wrong attempts. Visit an agent with your ID document or contact support to unlock it.")
+        return ErrPINBlocked
+    case state.LockCount > counted.LockCount:
+        s.securityEvent(ctx, user.ID, EventPINLocked, "until "+state.LockedUntil.Format(time.RFC3339), "user")
+        s.notifySecurity(ctx, user.ID, fmt.Sprintf("Your Congo Pay PIN is locked for %d minutes after %d wrong attempts. If this was not you, contact support.",
+            int(s.pinPolicy.LockDuration.Minutes()), s.pinPolicy.MaxAttempts))
+        return fmt.Errorf("%w until %s", ErrPINLocked, state.LockedUntil.Format(time.RFC3339))
+    }
+    return ErrInvalidPIN
+}
+
+// AuthErrorStatus maps an authentication error to its HTTP status: 429 while the PIN is
+// locked, 423 once it is blocked, 400 without a device and 401 otherwise.
+func AuthErrorStatus(err error) int {
+    switch {
+    case errors.Is(err, ErrDeviceRequired):
+        return http.StatusBadRequest
+    case errors.Is(err, ErrPINLocked):
+        return http.StatusTooManyRequests
+    case errors.Is(err, ErrPINBlocked):
+        return http.StatusLocked
+    default:
+        return http.StatusUnauthorized
+    }
+}
+
+// UnlockPIN clears a user's PIN lock or block. actor identifies the agent or support
+// operator, e.g. "agent:<id>" or "admin:<id>", and is kept in the audit trail.
+func (s *Service) UnlockPIN(ctx context.Context, userID, actor, reason string) (User, error) {
+    reason = strings.TrimSpace(reason)
+    if reason == "" {
+        return User{}, fmt.Errorf("reason is required")
+    }
+    user, err := s.repo.FindByID(ctx, userID)
+    if err != nil {
+        return User{}, err
+    }
+    if err := s.repo.UpdatePINState(ctx, user.ID, PINState{}); err != nil {
+        return User{}, err
+    }
+    user.PIN = PINState{}
+    s.securityEvent(ctx, user.ID, EventPINUnlocked, reason, actor)
+    s.notifySecurity(ctx, user.ID, "Your Congo Pay PIN has been unlocked. If you did not ask for this, contact support.")
+    return user, nil
+}
+
+// SecurityEvents returns a user's security audit trail, newest first.
+func (s *Service) SecurityEvents(ctx context.Context, userID string, limit int) ([]SecurityEvent, error) {
+    if _, err := s.repo.FindByID(ctx, userID); err != nil {
+        return nil, err
+    }
+    return s.repo.SecurityEvents(ctx, userID, limit)
+}
+
+// securityEvent records an audit entry. A failed write must not turn a PIN check into a
+// different error, so it is dropped.
+func (s *Service) securityEvent(ctx context.Context, userID, kind, detail, actor string) {
+    _ = s.repo.AddSecurityEvent(ctx, SecurityEvent{
+        ID:        uuid.NewString(),
+        UserID:    userID,
+        Kind:      kind,
+        Detail:    detail,
+        Actor:     actor,
+        CreatedAt: s.now(),
+    })
+}
+
+func (s *Service) notifySecurity(ctx context.Context, userID, body string) {
+    if s.notifier == nil {
+        return
+    }
+    _ = s.notifier.Send(ctx, notification.Message{Kind: notification.KindSecurity, Destination: userID, Body: body})
+}
@@ -0,0 +1,106 @@
+package identity
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+
+    "golang.org/x/crypto/bcrypt"
+)
+
+// weakPINs are common choices not caught by the repeated, sequential or year rules.
+var weakPINs = map[string]bool{
+    "1212": true, "2580": true, "0852": true, "1004": true, "6969": true, "1122": true,
+    "121212": true, "112233": true, "123123": true, "159753": true, "147258": true,
+}
+
+// ValidateNewPIN rejects PINs that are not 4 to 6 digits or are easy to guess: a repeated
+// digit (0000), an ascending or descending run (1234, 9876), a listed common PIN, or a
+// 4-digit year from 1900 to 2099 such as a birth year.
+func ValidateNewPIN(pin string) error {
+    if len(pin) < 4 || len(pin) > 6 {
+        return errors.New("PIN must be 4 to 6 digits")
+    }
+    for _, r := range pin {
+        if r < '0' || r > '9' {
+            return errors.New("PIN must be 4 to 6 digits")
+        }
+    }
+    repeated, ascending, descending := true, true, true
+    for i := 1; i < len(pin); i++ {
+        d := int(pin[i]) - int(pin[i-1])
+        repeated = repeated && d == 0
+        ascending = ascending && (d == 1 || d == -9)
+        descending = descending && (d == -1 || d == 9)
+    }
+    switch {
+    case repeated:
+        return fmt.Errorf("%w: repeated digits", ErrWeakPIN)
+    case ascending, descending:
+        return fmt.Errorf("%w: sequential digits", ErrWeakPIN)
+    case weakPINs[pin]:
+        return fmt.Errorf("%w: common PIN", ErrWeakPIN)
+    case len(pin) == 4 && (strings.HasPrefix(pin, "19") || strings.HasPrefix(pin, "20")):
+        return fmt.Errorf("%w: looks like a year", ErrWeakPIN)
+    }
+    return nil
+}
+
+// ChangePIN replaces the user's PIN after checking the current one. Existing tokens are
+// revoked, so the caller has to log in again.
+func (s *Service) ChangePIN(ctx context.Context, userID, oldPIN, newPIN string) (User, error) {
+    user, err := s.repo.FindByID(ctx, userID)
+    if err != nil {
+        return User{}, err
+    }
+    if err := s.checkPIN(ctx, &user, oldPIN); err != nil {
+        return User{}, err
+    }
+    if oldPIN == newPIN {
+        return User{}, errors.New("new PIN must differ from the current PIN")
+    }
+    if err := s.setPIN(ctx, &user, newPIN); err != nil {
+        return User{}, err
+    }
+    s.securityEvent(ctx, user.ID, EventPINChanged, "", "user")
+    s.notifySecurity(ctx, user.ID, "Your Congo Pay PIN was changed and you were signed out of your devices. If this was not you, contact support.")
+    return user, nil
+}
+
+// ResetPIN sets a new PIN for a user who forgot theirs, clears temporary PIN locks and
+// revokes existing tokens. The caller must already have verified a PIN reset code sent to
+// the phone. A blocked PIN needs an agent or support instead.
+func (s *Service) ResetPIN(ctx context.Context, phone, newPIN string) (User, error) {
+    user, err := s.LookupByPhone(ctx, phone)
+    if err != nil {
+        return User{}, err
+    }
+    if user.PIN.Blocked {
+        return User{}, ErrPINBlocked
+    }
+    if err := s.setPIN(ctx, &user, newPIN); err != nil {
+        return User{}, err
+    }
+    s.securityEvent(ctx, user.ID, EventPINReset, "forgotten PIN reset by SMS code", "user")
+    s.notifySecurity(ctx, user.ID, "Your Congo Pay PIN was reset and you were signed out of your devices. If this was not you, contact support.")
+    return user, nil
+}
+
+func (s *Service) setPIN(ctx context.Context, user *User, pin string) error {
+    if err := ValidateNewPIN(pin); err != nil {
+        return err
+    }
+    hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+    if err != nil {
+        return err
+    }
+    version, err := s.repo.UpdatePIN(ctx, user.ID, hash)
+    if err != nil {
+        return err
+    }
+    user.PINHash = hash
+    user.TokenVersion = version
+    user.PIN = PINState{}
+    return nil
+}
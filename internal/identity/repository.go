@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,7 +17,30 @@ type Repository interface {
     FindByPhone(ctx context.Context, phone string) (User, error)
     FindByID(ctx context.Context, id string) (User, error)
     UpdateDevice(ctx context.Context, id, deviceID string) error
+    // SaveDevice creates the user's device or updates it if already known.
+    SaveDevice(ctx context.Context, device Device) error
+    FindDevice(ctx context.Context, userID, deviceID string) (Device, error)
+    // ListDevices returns a user's devices, most recently seen first.
+    ListDevices(ctx context.Context, userID string) ([]Device, error)
+    TouchDevice(ctx context.Context, userID, deviceID string, at time.Time) error
     UpdateTokenVersion(ctx context.Context, id string, version int) error
+    UpdateTier(ctx context.Context, id, tier string) error
+    // UpdateRoles replaces the user's roles and bumps the token version, returning the new
+    // version.
+    UpdateRoles(ctx context.Context, id string, roles []string) (int, error)
+    // UpdatePIN stores a new PIN hash, clears failed attempts and temporary locks and bumps
+    // the token version, returning the new version.
+    UpdatePIN(ctx context.Context, id string, hash []byte) (int, error)
+    // RecordPINAttempt locks the user's PIN state, replaces it with apply's result and
+    // returns it. If apply fails the state is left unchanged and its error returned, so
+    // concurrent attempts are counted one at a time.
+    RecordPINAttempt(ctx context.Context, id string, apply func(PINState) (PINState, error)) (PINState, error)
+    UpdatePINState(ctx context.Context, id string, state PINState) error
+    AddSecurityEvent(ctx context.Context, event SecurityEvent) error
+    // SecurityEvents returns a user's security events, newest first.
+    SecurityEvents(ctx context.Context, userID string, limit int) ([]SecurityEvent, error)
+    SaveKYC(ctx context.Context, profile KYCProfile) error
+    FindKYC(ctx context.Context, userID string) (KYCProfile, error)
 }
 
 // PostgresRepository implements Repository using PostgreSQL.
@@ -34,24 +59,42 @@ func (r *PostgresRepository) Create(ctx context.Context, user User) error {
     if err != nil {
         return err
     }
-    _, err = r.db.Exec(ctx, `INSERT INTO users (id, phone, tier, pin_hash, device_id, token_version, last_login, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`, userID, user.Phone, user.Tier, user.PINHash, user.DeviceID, user.TokenVersion, user.LastLogin.UTC(), user.CreatedAt.UTC())
+    _, err = r.db.Exec(ctx, `INSERT INTO users (id, phone, tier, pin_hash, device_id, token_version, onboarding_agent_id, roles, last_login, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, userID, user.Phone, user.Tier, user.PINHash, user.DeviceID, user.TokenVersion, nullable(user.OnboardingAgentID), user.Roles, user.LastLogin.UTC(), user.CreatedAt.UTC())
+    var pgErr *pgconn.PgError
+    if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+        return ErrUserExists
+    }
     return err
 }
 
+func nullable(s string) any {
+    if s == "" {
+        return nil
+    }
+    return s
+}
+
 // FindByPhone fetches a user by phone number.
 func (r *PostgresRepository) FindByPhone(ctx context.Context, phone string) (User, error) {
-    row := r.db.QueryRow(ctx, `SELECT id, phone, tier, pin_hash, device_id, token_version, last_login, created_at FROM users WHERE phone = $1`, phone)
+    row := r.db.QueryRow(ctx, `SELECT id, phone, tier, pin_hash, device_id, token_version, COALESCE(onboarding_agent_id::text, ''), roles,
+        failed_pin_attempts, COALESCE(pin_locked_until, 'epoch'::timestamptz), pin_lock_count, pin_blocked, last_login, created_at
+        FROM users WHERE phone = $1`, phone)
     var (
         id        uuid.UUID
         createdAt time.Time
         user      User
     )
     var lastLogin time.Time
-    if err := row.Scan(&id, &user.Phone, &user.Tier, &user.PINHash, &user.DeviceID, &user.TokenVersion, &lastLogin, &createdAt); err != nil {
+    if err := row.Scan(&id, &user.Phone, &user.Tier, &user.PINHash, &user.DeviceID, &user.TokenVersion, &user.OnboardingAgentID, &user.Roles,
+        &user.PIN.FailedAttempts, &user.PIN.LockedUntil, &user.PIN.LockCount, &user.PIN.Blocked, &lastLogin, &createdAt); err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            return User{}, ErrUserNotFound
+        }
         return User{}, err
     }
     user.ID = id.String()
+    user.PIN.LockedUntil = epochToZero(user.PIN.LockedUntil)
     user.LastLogin = lastLogin.UTC()
     user.CreatedAt = createdAt.UTC()
     return user, nil
@@ -63,17 +106,24 @@ func (r *PostgresRepository) FindByID(ctx context.Context, id string) (User, err
     if err != nil {
         return User{}, err
     }
-    row := r.db.QueryRow(ctx, `SELECT id, phone, tier, pin_hash, device_id, token_version, last_login, created_at FROM users WHERE id = $1`, uid)
+    row := r.db.QueryRow(ctx, `SELECT id, phone, tier, pin_hash, device_id, token_version, COALESCE(onboarding_agent_id::text, ''), roles,
+        failed_pin_attempts, COALESCE(pin_locked_until, 'epoch'::timestamptz), pin_lock_count, pin_blocked, last_login, created_at
+        FROM users WHERE id = $1`, uid)
     var (
         uuidVal  uuid.UUID
         createdAt time.Time
         lastLogin time.Time
         user     User
     )
-    if err := row.Scan(&uuidVal, &user.Phone, &user.Tier, &user.PINHash, &user.DeviceID, &user.TokenVersion, &lastLogin, &createdAt); err != nil {
+    if err := row.Scan(&uuidVal, &user.Phone, &user.Tier, &user.PINHash, &user.DeviceID, &user.TokenVersion, &user.OnboardingAgentID, &user.Roles,
+        &user.PIN.FailedAttempts, &user.PIN.LockedUntil, &user.PIN.LockCount, &user.PIN.Blocked, &lastLogin, &createdAt); err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            return User{}, ErrUserNotFound
+        }
         return User{}, err
     }
     user.ID = uuidVal.String()
+    user.PIN.LockedUntil = epochToZero(user.PIN.LockedUntil)
     user.LastLogin = lastLogin.UTC()
     user.CreatedAt = createdAt.UTC()
     return user, nil
@@ -90,7 +140,7 @@ func (r *PostgresRepository) UpdateDevice(ctx context.Context, id, deviceID stri
         return err
     }
     if cmd.RowsAffected() == 0 {
-        return errors.New("user not found")
+        return ErrUserNotFound
     }
     return nil
 }
@@ -106,7 +156,260 @@ func (r *PostgresRepository) UpdateTokenVersion(ctx context.Context, id string,
         return err
     }
     if cmd.RowsAffected() == 0 {
-        return errors.New("user not found")
+        return ErrUserNotFound
+    }
+    return nil
+}
+
+// UpdateTier persists a user's KYC tier.
+func (r *PostgresRepository) UpdateTier(ctx context.Context, id, tier string) error {
+    userID, err := uuid.Parse(id)
+    if err != nil {
+        return err
+    }
+    cmd, err := r.db.Exec(ctx, `UPDATE users SET tier = $1 WHERE id = $2`, tier, userID)
+    if err != nil {
+        return err
+    }
+    if cmd.RowsAffected() == 0 {
+        return ErrUserNotFound
+    }
+    return nil
+}
+
+// UpdatePIN replaces the PIN hash and revokes existing tokens in one statement.
+func (r *PostgresRepository) UpdatePIN(ctx context.Context, id string, hash []byte) (int, error) {
+    userID, err := uuid.Parse(id)
+    if err != nil {
+        return 0, err
+    }
+    var version int
+    err = r.db.QueryRow(ctx, `UPDATE users SET pin_hash = $1, token_version = token_version + 1,
+        failed_pin_attempts = 0, pin_locked_until = NULL, pin_lock_count = 0
+        WHERE id = $2 RETURNING token_version`, hash, userID).Scan(&version)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return 0, ErrUserNotFound
+    }
+    return version, err
+}
+
+// UpdateRoles replaces the user's roles and revokes existing tokens in one statement.
+func (r *PostgresRepository) UpdateRoles(ctx context.Context, id string, roles []string) (int, error) {
+    userID, err := uuid.Parse(id)
+    if err != nil {
+        return 0, err
+    }
+    var version int
+    err = r.db.QueryRow(ctx, `UPDATE users SET roles = $1, token_version = token_version + 1
+        WHERE id = $2 RETURNING token_version`, roles, userID).Scan(&version)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return 0, ErrUserNotFound
+    }
+    return version, err
+}
+
+const deviceColumns = `id::text, user_id::text, device_id, name, platform, trusted, first_seen_at, last_seen_at,
+    COALESCE(cooling_off_until, 'epoch'::timestamptz), COALESCE(revoked_at, 'epoch'::timestamptz)`
+
+func scanDevice(row pgx.Row) (Device, error) {
+    var d Device
+    if err := row.Scan(&d.ID, &d.UserID, &d.DeviceID, &d.Name, &d.Platform, &d.Trusted, &d.FirstSeenAt, &d.LastSeenAt,
+        &d.CoolingOffUntil, &d.RevokedAt); err != nil {
+        return Device{}, err
+    }
+    d.FirstSeenAt, d.LastSeenAt = d.FirstSeenAt.UTC(), d.LastSeenAt.UTC()
+    d.CoolingOffUntil, d.RevokedAt = epochToZero(d.CoolingOffUntil), epochToZero(d.RevokedAt)
+    return d, nil
+}
+
+// SaveDevice upserts a device on (user_id, device_id); the first-seen time is kept.
+func (r *PostgresRepository) SaveDevice(ctx context.Context, d Device) error {
+    _, err := r.db.Exec(ctx, `INSERT INTO devices (id, user_id, device_id, name, platform, trusted, first_seen_at, last_seen_at, cooling_off_until, revoked_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+        ON CONFLICT (user_id, device_id) DO UPDATE SET name = EXCLUDED.name, platform = EXCLUDED.platform,
+            trusted = EXCLUDED.trusted, last_seen_at = EXCLUDED.last_seen_at,
+            cooling_off_until = EXCLUDED.cooling_off_until, revoked_at = EXCLUDED.revoked_at`,
+        d.ID, d.UserID, d.DeviceID, d.Name, d.Platform, d.Trusted, d.FirstSeenAt.UTC(), d.LastSeenAt.UTC(),
+        nullableTime(d.CoolingOffUntil), nullableTime(d.RevokedAt))
+    return err
+}
+
+// FindDevice returns one of the user's devices.
+func (r *PostgresRepository) FindDevice(ctx context.Context, userID, deviceID string) (Device, error) {
+    uid, err := uuid.Parse(userID)
+    if err != nil {
+        return Device{}, ErrDeviceNotFound
+    }
+    d, err := scanDevice(r.db.QueryRow(ctx, `SELECT `+deviceColumns+` FROM devices WHERE user_id = $1 AND device_id = $2`, uid, deviceID))
+    if errors.Is(err, pgx.ErrNoRows) {
+        return Device{}, ErrDeviceNotFound
+    }
+    return d, err
+}
+
+// ListDevices returns a user's devices, most recently seen first.
+func (r *PostgresRepository) ListDevices(ctx context.Context, userID string) ([]Device, error) {
+    uid, err := uuid.Parse(userID)
+    if err != nil {
+        return nil, err
+    }
+    rows, err := r.db.Query(ctx, `SELECT `+deviceColumns+` FROM devices WHERE user_id = $1 ORDER BY last_seen_at DESC`, uid)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []Device
+    for rows.Next() {
+        d, err := scanDevice(rows)
+        if err != nil {
+            return nil, err
+        }
+        out = append(out, d)
+    }
+    return out, rows.Err()
+}
+
+// TouchDevice records that the device was just used.
+func (r *PostgresRepository) TouchDevice(ctx context.Context, userID, deviceID string, at time.Time) error {
+    uid, err := uuid.Parse(userID)
+    if err != nil {
+        return err
+    }
+    _, err = r.db.Exec(ctx, `UPDATE devices SET last_seen_at = $1 WHERE user_id = $2 AND device_id = $3`, at.UTC(), uid, deviceID)
+    return err
+}
+
+func nullableTime(t time.Time) any {
+    if t.IsZero() {
+        return nil
+    }
+    return t.UTC()
+}
+
+func epochToZero(t time.Time) time.Time {
+    if t.Unix() == 0 {
+        return time.Time{}
+    }
+    return t.UTC()
+}
+
+// RecordPINAttempt reads the PIN state with FOR UPDATE so concurrent attempts on the same
+// user are applied in turn.
+func (r *PostgresRepository) RecordPINAttempt(ctx context.Context, id string, apply func(PINState) (PINState, error)) (PINState, error) {
+    userID, err := uuid.Parse(id)
+    if err != nil {
+        return PINState{}, err
+    }
+    tx, err := r.db.Begin(ctx)
+    if err != nil {
+        return PINState{}, err
+    }
+    defer tx.Rollback(ctx)
+    var current PINState
+    err = tx.QueryRow(ctx, `SELECT failed_pin_attempts, COALESCE(pin_locked_until, 'epoch'::timestamptz), pin_lock_count, pin_blocked
+        FROM users WHERE id = $1 FOR UPDATE`, userID).
+        Scan(&current.FailedAttempts, &current.LockedUntil, &current.LockCount, &current.Blocked)
+    if errors.Is(err, pgx.ErrNoRows) {
+        return PINState{}, ErrUserNotFound
+    }
+    if err != nil {
+        return PINState{}, err
+    }
+    current.LockedUntil = epochToZero(current.LockedUntil)
+    state, err := apply(current)
+    if err != nil {
+        return current, err
+    }
+    if _, err := tx.Exec(ctx, `UPDATE users SET failed_pin_attempts = $1, pin_locked_until = $2, pin_lock_count = $3, pin_blocked = $4
+        WHERE id = $5`, state.FailedAttempts, nullableTime(state.LockedUntil), state.LockCount, state.Blocked, userID); err != nil {
+        return PINState{}, err
+    }
+    return state, tx.Commit(ctx)
+}
+
+// UpdatePINState stores a user's PIN attempt state.
+func (r *PostgresRepository) UpdatePINState(ctx context.Context, id string, state PINState) error {
+    userID, err := uuid.Parse(id)
+    if err != nil {
+        return err
+    }
+    cmd, err := r.db.Exec(ctx, `UPDATE users SET failed_pin_attempts = $1, pin_locked_until = $2, pin_lock_count = $3, pin_blocked = $4
+        WHERE id = $5`, state.FailedAttempts, nullableTime(state.LockedUntil), state.LockCount, state.Blocked, userID)
+    if err != nil {
+        return err
+    }
+    if cmd.RowsAffected() == 0 {
+        return ErrUserNotFound
     }
     return nil
 }
+
+// AddSecurityEvent appends to a user's security audit trail.
+func (r *PostgresRepository) AddSecurityEvent(ctx context.Context, e SecurityEvent) error {
+    _, err := r.db.Exec(ctx, `INSERT INTO user_security_events (id, user_id, kind, detail, actor, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`, e.ID, e.UserID, e.Kind, e.Detail, e.Actor, e.CreatedAt.UTC())
+    return err
+}
+
+// SecurityEvents returns a user's security events, newest first.
+func (r *PostgresRepository) SecurityEvents(ctx context.Context, userID string, limit int) ([]SecurityEvent, error) {
+    if limit <= 0 {
+        limit = 50
+    }
+    uid, err := uuid.Parse(userID)
+    if err != nil {
+        return nil, err
+    }
+    rows, err := r.db.Query(ctx, `SELECT id::text, user_id::text, kind, detail, actor, created_at
+        FROM user_security_events WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`, uid, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var out []SecurityEvent
+    for rows.Next() {
+        var e SecurityEvent
+        if err := rows.Scan(&e.ID, &e.UserID, &e.Kind, &e.Detail, &e.Actor, &e.CreatedAt); err != nil {
+            return nil, err
+        }
+        e.CreatedAt = e.CreatedAt.UTC()
+        out = append(out, e)
+    }
+    return out, rows.Err()
+}
+
+// SaveKYC stores, or replaces, the KYC profile of a user.
+func (r *PostgresRepository) SaveKYC(ctx context.Context, k KYCProfile) error {
+    _, err := r.db.Exec(ctx, `INSERT INTO user_kyc_profiles
+        (user_id, full_name, document_type, document_number, document_front_ref, document_back_ref, selfie_ref, captured_by_agent_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        ON CONFLICT (user_id) DO UPDATE SET full_name = EXCLUDED.full_name, document_type = EXCLUDED.document_type,
+            document_number = EXCLUDED.document_number, document_front_ref = EXCLUDED.document_front_ref,
+            document_back_ref = EXCLUDED.document_back_ref, selfie_ref = EXCLUDED.selfie_ref,
+            captured_by_agent_id = EXCLUDED.captured_by_agent_id, created_at = EXCLUDED.created_at`,
+        k.UserID, k.FullName, k.DocumentType, k.DocumentNumber, k.DocumentFrontRef, k.DocumentBackRef, k.SelfieRef,
+        nullable(k.CapturedByAgentID), k.CreatedAt.UTC())
+    return err
+}
+
+// FindKYC fetches the KYC profile of a user.
+func (r *PostgresRepository) FindKYC(ctx context.Context, userID string) (KYCProfile, error) {
+    uid, err := uuid.Parse(userID)
+    if err != nil {
+        return KYCProfile{}, ErrKYCNotFound
+    }
+    var k KYCProfile
+    err = r.db.QueryRow(ctx, `SELECT user_id::text, full_name, document_type, document_number, document_front_ref,
+            document_back_ref, selfie_ref, COALESCE(captured_by_agent_id::text, ''), created_at
+        FROM user_kyc_profiles WHERE user_id = $1`, uid).
+        Scan(&k.UserID, &k.FullName, &k.DocumentType, &k.DocumentNumber, &k.DocumentFrontRef,
+            &k.DocumentBackRef, &k.SelfieRef, &k.CapturedByAgentID, &k.CreatedAt)
+    if err != nil {
+        if errors.Is(err, pgx.ErrNoRows) {
+            return KYCProfile{}, ErrKYCNotFound
+        }
+        return KYCProfile{}, err
+    }
+    k.CreatedAt = k.CreatedAt.UTC()
+    return k, nil
+}
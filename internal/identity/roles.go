@@ -0,0 +1,122 @@
+package identity
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// Roles a user can hold. Every user is a customer; the others are granted by an admin.
+const (
+    RoleCustomer = "customer"
+    RoleAdmin    = "admin"
+    RoleSupport  = "support"
+    RoleAgent    = "agent"
+    RoleMerchant = "merchant"
+    RolePartner  = "partner"
+)
+
+// Scopes carried in access tokens and checked by middleware.RequireScope.
+const (
+    // ScopeBackOffice admits the caller to the /admin routes; each area needs its own scope too.
+    ScopeBackOffice      = "backoffice"
+    ScopeUsersRead       = "users:read"
+    ScopeUsersWrite      = "users:write"
+    ScopeRolesWrite      = "roles:write"
+    ScopeKYCReview       = "kyc:review"
+    ScopeWalletsAdmin    = "wallets:admin"
+    ScopeMerchantsAdmin  = "merchants:admin"
+    ScopeAgentsAdmin     = "agents:admin"
+    ScopeEscrowsAdmin    = "escrows:admin"
+    ScopeRiskReview      = "risk:review"
+    ScopeWallet          = "wallet"
+    ScopeAgentCash       = "agent:cash"
+    ScopeMerchantCollect = "merchant:collect"
+    ScopePartnerAPI      = "partner:api"
+)
+
+// roleScopes grants scopes to roles. Support works customer issues but cannot change
+// roles, wallet states, merchants or agents.
+var roleScopes = map[string][]string{
+    RoleCustomer: {ScopeWallet},
+    RoleAdmin: {ScopeBackOffice, ScopeUsersRead, ScopeUsersWrite, ScopeRolesWrite, ScopeKYCReview, ScopeWalletsAdmin,
+        ScopeMerchantsAdmin, ScopeAgentsAdmin, ScopeEscrowsAdmin, ScopeRiskReview},
+    RoleSupport:  {ScopeBackOffice, ScopeUsersRead, ScopeUsersWrite, ScopeKYCReview, ScopeEscrowsAdmin, ScopeRiskReview},
+    RoleAgent:    {ScopeAgentCash},
+    RoleMerchant: {ScopeMerchantCollect},
+    RolePartner:  {ScopePartnerAPI},
+}
+
+// ValidRole reports whether role is known.
+func ValidRole(role string) bool {
+    _, ok := roleScopes[role]
+    return ok
+}
+
+// ScopesFor returns the sorted union of the scopes granted to roles.
+func ScopesFor(roles []string) []string {
+    set := make(map[string]struct{})
+    for _, role := range roles {
+        for _, scope := range roleScopes[role] {
+            set[scope] = struct{}{}
+        }
+    }
+    out := make([]string, 0, len(set))
+    for scope := range set {
+        out = append(out, scope)
+    }
+    sort.Strings(out)
+    return out
+}
+
+// normalizeRoles validates roles, adds the customer role and returns them sorted without
+// duplicates.
+func normalizeRoles(roles []string) ([]string, error) {
+    set := map[string]struct{}{RoleCustomer: {}}
+    for _, role := range roles {
+        role = strings.ToLower(strings.TrimSpace(role))
+        if !ValidRole(role) {
+            return nil, fmt.Errorf("%w %q", ErrUnknownRole, role)
+        }
+        set[role] = struct{}{}
+    }
+    out := make([]string, 0, len(set))
+    for role := range set {
+        out = append(out, role)
+    }
+    sort.Strings(out)
+    return out, nil
+}
+
+// SetRoles replaces a user's roles and revokes their tokens so the new scopes apply at
+// once. actor is "admin:<id>" or "system".
+func (s *Service) SetRoles(ctx context.Context, userID string, roles []string, actor string) (User, error) {
+    normalized, err := normalizeRoles(roles)
+    if err != nil {
+        return User{}, err
+    }
+    user, err := s.repo.FindByID(ctx, userID)
+    if err != nil {
+        return User{}, err
+    }
+    if strings.Join(user.Roles, ",") == strings.Join(normalized, ",") {
+        return user, nil
+    }
+    version, err := s.repo.UpdateRoles(ctx, user.ID, normalized)
+    if err != nil {
+        return User{}, err
+    }
+    s.securityEvent(ctx, user.ID, EventRolesChanged, fmt.Sprintf("%s -> %s", strings.Join(user.Roles, ","), strings.Join(normalized, ",")), actor)
+    user.Roles, user.TokenVersion = normalized, version
+    return user, nil
+}
+
+// GrantRole adds a role to a user, keeping their other roles.
+func (s *Service) GrantRole(ctx context.Context, userID, role, actor string) (User, error) {
+    user, err := s.repo.FindByID(ctx, userID)
+    if err != nil {
+        return User{}, err
+    }
+    return s.SetRoles(ctx, userID, append(append([]string(nil), user.Roles...), role), actor)
+}
@@ -3,33 +3,104 @@ package identity
 import (
     "context"
     "errors"
+    "fmt"
     "regexp"
     "strings"
     "time"
 
     "github.com/google/uuid"
     "golang.org/x/crypto/bcrypt"
-)
 
-const (
-    tierZero = "tier0"
-    tierOne  = "tier1"
+    "github.com/congo-pay/congo_pay/internal/notification"
 )
 
 // Service manages identity lifecycle.
 type Service struct {
-    repo Repository
+    repo      Repository
+    notifier  notification.Notifier
+    pinPolicy PINPolicy
+    now       func() time.Time
 }
 
-// NewService creates a new identity service.
-func NewService(repo Repository) *Service {
-    return &Service{repo: repo}
+// NewService creates a new identity service. The notifier, which may be nil, tells users
+// when their PIN gets locked.
+func NewService(repo Repository, notifier notification.Notifier) *Service {
+    return &Service{
+        repo:      repo,
+        notifier:  notifier,
+        pinPolicy: DefaultPINPolicy(),
+        now:       func() time.Time { return time.Now().UTC() },
+    }
 }
 
 // Register creates a new Tier0 user and stores a hashed PIN.
 func (s *Service) Register(ctx context.Context, creds Credentials) (User, error) {
-    if len(creds.PIN) < 4 {
-        return User{}, errors.New("PIN must be at least 4 digits")
+    return s.register(ctx, creds, "")
+}
+
+// AssistedRegistration is a registration captured by an agent in the field.
+type AssistedRegistration struct {
+    Credentials
+    OnboardingAgentID string
+    KYC               KYCProfile
+}
+
+// RegisterAssisted creates a Tier0 user linked to the onboarding agent and stores the KYC
+// profile the agent captured. The caller must already have verified the phone number.
+// A user the same agent created in an earlier attempt that failed part way is reused once
+// the PIN matches, and its KYC profile saved if still missing.
+func (s *Service) RegisterAssisted(ctx context.Context, input AssistedRegistration) (User, error) {
+    if input.OnboardingAgentID == "" {
+        return User{}, errors.New("onboarding agent is required")
+    }
+    if err := input.KYC.Validate(); err != nil {
+        return User{}, err
+    }
+    phone, err := normalizePhone(input.Phone)
+    if err != nil {
+        return User{}, err
+    }
+    user, err := s.repo.FindByPhone(ctx, phone)
+    switch {
+    case err == nil && user.OnboardingAgentID == input.OnboardingAgentID:
+        if err := s.checkPIN(ctx, &user, input.PIN); err != nil {
+            return User{}, err
+        }
+        if _, err := s.repo.FindKYC(ctx, user.ID); err == nil {
+            return user, nil
+        } else if !errors.Is(err, ErrKYCNotFound) {
+            return User{}, err
+        }
+    case err == nil:
+        return User{}, ErrUserExists
+    case errors.Is(err, ErrUserNotFound):
+        user, err = s.register(ctx, input.Credentials, input.OnboardingAgentID)
+        if err != nil {
+            return User{}, err
+        }
+    default:
+        return User{}, err
+    }
+    kyc := input.KYC
+    kyc.UserID = user.ID
+    kyc.FullName = strings.TrimSpace(kyc.FullName)
+    kyc.DocumentNumber = strings.TrimSpace(kyc.DocumentNumber)
+    kyc.CapturedByAgentID = input.OnboardingAgentID
+    kyc.CreatedAt = user.CreatedAt
+    if err := s.repo.SaveKYC(ctx, kyc); err != nil {
+        return User{}, err
+    }
+    return user, nil
+}
+
+// KYC returns the KYC profile captured for a user.
+func (s *Service) KYC(ctx context.Context, userID string) (KYCProfile, error) {
+    return s.repo.FindKYC(ctx, userID)
+}
+
+func (s *Service) register(ctx context.Context, creds Credentials, onboardingAgentID string) (User, error) {
+    if err := ValidateNewPIN(creds.PIN); err != nil {
+        return User{}, err
     }
     phone, err := normalizePhone(creds.Phone)
     if err != nil { return User{}, err }
@@ -42,22 +113,54 @@ func (s *Service) Register(ctx context.Context, creds Credentials) (User, error)
     user := User{
         ID:        uuid.New().String(),
         Phone:     phone,
-        Tier:      tierZero,
+        Tier:      Tier0,
         PINHash:   hash,
         DeviceID:  creds.DeviceID,
         TokenVersion: 0,
-        CreatedAt: time.Now().UTC(),
+        OnboardingAgentID: onboardingAgentID,
+        Roles:     []string{RoleCustomer},
+        CreatedAt: s.now(),
     }
 
     if err := s.repo.Create(ctx, user); err != nil {
         return User{}, err
     }
+    if creds.DeviceID != "" {
+        if err := s.bindDevice(ctx, &user, creds, time.Time{}); err != nil {
+            return User{}, err
+        }
+    }
 
     return user, nil
 }
 
-// Authenticate verifies credentials and device binding.
+// Get fetches a user by ID.
+func (s *Service) Get(ctx context.Context, id string) (User, error) {
+    return s.repo.FindByID(ctx, id)
+}
+
+// NormalizePhone canonicalises a phone number the way registration stores it.
+func NormalizePhone(phone string) (string, error) {
+    return normalizePhone(phone)
+}
+
+// LookupByPhone normalises a phone number and fetches the matching user.
+func (s *Service) LookupByPhone(ctx context.Context, phone string) (User, error) {
+    normalized, err := normalizePhone(phone)
+    if err != nil {
+        return User{}, err
+    }
+    return s.repo.FindByPhone(ctx, normalized)
+}
+
+// Authenticate verifies credentials on one of the user's trusted devices. A user without
+// any device yet, e.g. registered by an agent, binds the first device they sign in from;
+// any other new device goes through ChangeDevice. The returned user carries the device
+// signed in from.
 func (s *Service) Authenticate(ctx context.Context, creds Credentials) (User, error) {
+    if creds.DeviceID == "" {
+        return User{}, ErrDeviceRequired
+    }
     phone, err := normalizePhone(creds.Phone)
     if err != nil { return User{}, err }
     user, err := s.repo.FindByPhone(ctx, phone)
@@ -65,27 +168,95 @@ func (s *Service) Authenticate(ctx context.Context, creds Credentials) (User, er
         return User{}, err
     }
 
-    if err := bcrypt.CompareHashAndPassword(user.PINHash, []byte(creds.PIN)); err != nil {
-        return User{}, errors.New("invalid PIN")
+    if err := s.checkPIN(ctx, &user, creds.PIN); err != nil {
+        return User{}, err
+    }
+
+    now := s.now()
+    device, err := s.repo.FindDevice(ctx, user.ID, creds.DeviceID)
+    switch {
+    case err == nil && device.Active():
+        if err := s.repo.TouchDevice(ctx, user.ID, device.DeviceID, now); err != nil {
+            return User{}, err
+        }
+    case errors.Is(err, ErrDeviceNotFound):
+        devices, err := s.repo.ListDevices(ctx, user.ID)
+        if err != nil {
+            return User{}, err
+        }
+        if len(devices) > 0 {
+            return User{}, ErrDeviceMismatch
+        }
+        if err := s.bindDevice(ctx, &user, creds, time.Time{}); err != nil {
+            return User{}, err
+        }
+    case err != nil:
+        return User{}, err
+    default:
+        // Revoked devices must be verified again.
+        return User{}, ErrDeviceMismatch
     }
 
-    if user.DeviceID == "" {
-        if creds.DeviceID == "" {
-            return User{}, errors.New("device binding required")
+    user.DeviceID = creds.DeviceID
+    user.LastLogin = now
+    return user, nil
+}
+
+// ChangeDevice trusts a new device after checking the PIN. The caller must already have
+// verified a device change code sent to the phone. Outgoing transfers from the new device
+// are held for DeviceCoolingOff; the user's other devices stay signed in until revoked.
+func (s *Service) ChangeDevice(ctx context.Context, creds Credentials) (User, error) {
+    if creds.DeviceID == "" {
+        return User{}, ErrDeviceRequired
+    }
+    user, err := s.LookupByPhone(ctx, creds.Phone)
+    if err != nil {
+        return User{}, err
+    }
+    if err := s.checkPIN(ctx, &user, creds.PIN); err != nil {
+        return User{}, err
+    }
+    now := s.now()
+    device, err := s.repo.FindDevice(ctx, user.ID, creds.DeviceID)
+    if err != nil && !errors.Is(err, ErrDeviceNotFound) {
+        return User{}, err
+    }
+    if err == nil && device.Active() {
+        if err := s.repo.TouchDevice(ctx, user.ID, device.DeviceID, now); err != nil {
+            return User{}, err
         }
-        if err := s.repo.UpdateDevice(ctx, user.ID, creds.DeviceID); err != nil {
+    } else {
+        if err := s.bindDevice(ctx, &user, creds, now.Add(DeviceCoolingOff)); err != nil {
             return User{}, err
         }
-        user.DeviceID = creds.DeviceID
-    } else if creds.DeviceID != "" && user.DeviceID != creds.DeviceID {
-        return User{}, errors.New("device mismatch")
+        s.securityEvent(ctx, user.ID, EventDeviceChanged, fmt.Sprintf("added %q (%s)", creds.DeviceID, creds.Platform), "user")
+        s.notifySecurity(ctx, user.ID, fmt.Sprintf("A new device was added to your Congo Pay account. Transfers from it are on hold for %d hours. If this was not you, revoke it and contact support.",
+            int(DeviceCoolingOff.Hours())))
     }
+    user.DeviceID = creds.DeviceID
+    user.LastLogin = now
+    return user, nil
+}
 
-    if user.Tier == tierZero {
-        user.Tier = tierOne
+// SetTier persists a user's KYC tier. Callers own the review that justifies the change.
+func (s *Service) SetTier(ctx context.Context, userID, tier string) error {
+    if TierRank(tier) < 0 {
+        return fmt.Errorf("unknown tier %q", tier)
     }
+    return s.repo.UpdateTier(ctx, userID, tier)
+}
 
-    user.LastLogin = time.Now().UTC()
+// VerifyPIN confirms a money movement with the user's PIN, e.g. a customer keying it
+// into an agent's device during cash-out. Wrong PINs count towards the same lockout as
+// logins.
+func (s *Service) VerifyPIN(ctx context.Context, userID, pin string) (User, error) {
+    user, err := s.repo.FindByID(ctx, userID)
+    if err != nil {
+        return User{}, err
+    }
+    if err := s.checkPIN(ctx, &user, pin); err != nil {
+        return User{}, err
+    }
     return user, nil
 }
 
@@ -2,43 +2,400 @@ package identity
 
 import (
     "context"
+    "errors"
+    "sync"
     "testing"
+    "time"
 )
 
 func TestRegisterAndAuthenticate(t *testing.T) {
     repo := NewMemoryRepository()
-    svc := NewService(repo)
+    svc := NewService(repo, nil)
 
     ctx := context.Background()
-    user, err := svc.Register(ctx, Credentials{Phone: "+237650000000", PIN: "1234", DeviceID: "device-1"})
+    if _, err := svc.Register(ctx, Credentials{Phone: "+237650000000", PIN: "1234", DeviceID: "device-1"}); !errors.Is(err, ErrWeakPIN) {
+        t.Fatalf("expected a weak PIN to be rejected, got %v", err)
+    }
+    assisted := AssistedRegistration{
+        Credentials:       Credentials{Phone: "+237650000000", PIN: "0000"},
+        OnboardingAgentID: "agent-1",
+        KYC:               KYCProfile{FullName: "Awa Mbeki", DocumentType: DocumentNationalID, DocumentNumber: "CM123", DocumentFrontRef: "front", SelfieRef: "selfie"},
+    }
+    if _, err := svc.RegisterAssisted(ctx, assisted); !errors.Is(err, ErrWeakPIN) {
+        t.Fatalf("expected a weak PIN to be rejected for assisted registration, got %v", err)
+    }
+    user, err := svc.Register(ctx, Credentials{Phone: "+237650000000", PIN: "4831", DeviceID: "device-1"})
     if err != nil {
         t.Fatalf("register: %v", err)
     }
 
-    if user.Tier != tierZero {
+    if user.Tier != Tier0 {
         t.Fatalf("expected tier0, got %s", user.Tier)
     }
 
-    authed, err := svc.Authenticate(ctx, Credentials{Phone: user.Phone, PIN: "1234", DeviceID: "device-1"})
+    authed, err := svc.Authenticate(ctx, Credentials{Phone: user.Phone, PIN: "4831", DeviceID: "device-1"})
     if err != nil {
         t.Fatalf("authenticate: %v", err)
     }
-    if authed.Tier != tierOne {
-        t.Fatalf("expected promotion to tier1, got %s", authed.Tier)
+    if authed.Tier != Tier0 {
+        t.Fatalf("login must not change the tier, got %s", authed.Tier)
+    }
+
+    if err := svc.SetTier(ctx, user.ID, Tier1); err != nil {
+        t.Fatalf("set tier: %v", err)
+    }
+    stored, err := svc.Get(ctx, user.ID)
+    if err != nil || stored.Tier != Tier1 {
+        t.Fatalf("stored tier = %q, %v", stored.Tier, err)
+    }
+    if err := svc.SetTier(ctx, user.ID, "gold"); err == nil {
+        t.Fatalf("expected unknown tier to be rejected")
     }
 }
 
 func TestAuthenticateDeviceMismatch(t *testing.T) {
     repo := NewMemoryRepository()
-    svc := NewService(repo)
+    svc := NewService(repo, nil)
     ctx := context.Background()
 
-    _, err := svc.Register(ctx, Credentials{Phone: "123", PIN: "1234", DeviceID: "device-1"})
+    _, err := svc.Register(ctx, Credentials{Phone: "+237650000001", PIN: "4831", DeviceID: "device-1"})
     if err != nil {
         t.Fatalf("register: %v", err)
     }
 
-    if _, err := svc.Authenticate(ctx, Credentials{Phone: "123", PIN: "1234", DeviceID: "device-2"}); err == nil {
+    if _, err := svc.Authenticate(ctx, Credentials{Phone: "+237650000001", PIN: "4831", DeviceID: "device-2"}); err == nil {
         t.Fatalf("expected device mismatch error")
     }
 }
+
+func TestPINLockout(t *testing.T) {
+    repo := NewMemoryRepository()
+    svc := NewService(repo, nil)
+    now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+    svc.now = func() time.Time { return now }
+    ctx := context.Background()
+
+    user, err := svc.Register(ctx, Credentials{Phone: "+237650000002", PIN: "7305", DeviceID: "device-1"})
+    if err != nil {
+        t.Fatalf("register: %v", err)
+    }
+    login := func(pin string) error {
+        _, err := svc.Authenticate(ctx, Credentials{Phone: user.Phone, PIN: pin, DeviceID: "device-1"})
+        return err
+    }
+
+    // Two free attempts, then a delay that must pass before the next guess counts.
+    for i := 0; i < 2; i++ {
+        if err := login("0000"); !errors.Is(err, ErrInvalidPIN) {
+            t.Fatalf("attempt %d: expected invalid PIN, got %v", i+1, err)
+        }
+    }
+    if err := login("0000"); !errors.Is(err, ErrInvalidPIN) {
+        t.Fatalf("attempt 3: expected invalid PIN, got %v", err)
+    }
+    if err := login("7305"); !errors.Is(err, ErrPINLocked) {
+        t.Fatalf("expected delay after third failure, got %v", err)
+    }
+
+    // A wrong PIN during cash-out counts towards the same lock.
+    now = now.Add(time.Minute)
+    if _, err := svc.VerifyPIN(ctx, user.ID, "0000"); !errors.Is(err, ErrInvalidPIN) {
+        t.Fatalf("attempt 4: expected invalid PIN, got %v", err)
+    }
+    now = now.Add(2 * time.Minute)
+    if _, err := svc.VerifyPIN(ctx, user.ID, "0000"); !errors.Is(err, ErrPINLocked) {
+        t.Fatalf("attempt 5: expected lock, got %v", err)
+    }
+    now = now.Add(29 * time.Minute)
+    if err := login("7305"); !errors.Is(err, ErrPINLocked) {
+        t.Fatalf("expected lock to hold, got %v", err)
+    }
+
+    // The correct PIN after the lock expires resets everything.
+    now = now.Add(2 * time.Minute)
+    if err := login("7305"); err != nil {
+        t.Fatalf("login after lock: %v", err)
+    }
+    if stored, _ := svc.Get(ctx, user.ID); stored.PIN != (PINState{}) {
+        t.Fatalf("expected PIN state reset, got %+v", stored.PIN)
+    }
+
+    // Three locks in a row block the PIN until it is unlocked.
+    for lock := 1; lock <= 3; lock++ {
+        for i := 0; i < 5; i++ {
+            now = now.Add(10 * time.Minute)
+            err = login("0000")
+        }
+        if lock < 3 && !errors.Is(err, ErrPINLocked) {
+            t.Fatalf("lock %d: expected lock, got %v", lock, err)
+        }
+        now = now.Add(time.Hour)
+    }
+    if !errors.Is(err, ErrPINBlocked) {
+        t.Fatalf("expected block, got %v", err)
+    }
+    if err := login("7305"); !errors.Is(err, ErrPINBlocked) {
+        t.Fatalf("blocked PIN must reject the correct PIN, got %v", err)
+    }
+    if _, err := svc.UnlockPIN(ctx, user.ID, "admin:ops", ""); err == nil {
+        t.Fatalf("expected unlock without reason to fail")
+    }
+    if _, err := svc.UnlockPIN(ctx, user.ID, "admin:ops", "caller verified"); err != nil {
+        t.Fatalf("unlock: %v", err)
+    }
+    if err := login("7305"); err != nil {
+        t.Fatalf("login after unlock: %v", err)
+    }
+
+    events, err := svc.SecurityEvents(ctx, user.ID, 0)
+    if err != nil {
+        t.Fatalf("security events: %v", err)
+    }
+    kinds := map[string]int{}
+    for _, e := range events {
+        kinds[e.Kind]++
+    }
+    if kinds[EventPINLocked] != 3 || kinds[EventPINBlocked] != 1 || kinds[EventPINUnlocked] != 1 {
+        t.Fatalf("unexpected audit trail %v", kinds)
+    }
+    if events[0].Kind != EventPINUnlocked || events[0].Actor != "admin:ops" {
+        t.Fatalf("expected unlock first, got %+v", events[0])
+    }
+}
+
+func TestPINConcurrentGuesses(t *testing.T) {
+    repo := NewMemoryRepository()
+    svc := NewService(repo, nil)
+    now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+    svc.now = func() time.Time { return now }
+    ctx := context.Background()
+
+    user, err := svc.Register(ctx, Credentials{Phone: "+237650000004", PIN: "7305", DeviceID: "device-1"})
+    if err != nil {
+        t.Fatalf("register: %v", err)
+    }
+
+    // A burst of guesses gets no more comparisons than sequential guesses would: the free
+    // attempts plus the one that starts the delay.
+    var (
+        wg       sync.WaitGroup
+        mu       sync.Mutex
+        compared int
+    )
+    for i := 0; i < 20; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            _, err := svc.VerifyPIN(ctx, user.ID, "0000")
+            if errors.Is(err, ErrInvalidPIN) {
+                mu.Lock()
+                compared++
+                mu.Unlock()
+            } else if !errors.Is(err, ErrPINLocked) {
+                t.Errorf("unexpected error: %v", err)
+            }
+        }()
+    }
+    wg.Wait()
+    if want := svc.pinPolicy.FreeAttempts + 1; compared != want {
+        t.Fatalf("expected %d compared guesses, got %d", want, compared)
+    }
+}
+
+func TestValidateNewPIN(t *testing.T) {
+    for _, pin := range []string{"0000", "1234", "4321", "7890", "123456", "1988", "2004", "2580", "12a4", "123", "1234567"} {
+        if err := ValidateNewPIN(pin); err == nil {
+            t.Errorf("expected %q to be rejected", pin)
+        }
+    }
+    for _, pin := range []string{"4831", "7305", "836194"} {
+        if err := ValidateNewPIN(pin); err != nil {
+            t.Errorf("expected %q to be accepted: %v", pin, err)
+        }
+    }
+}
+
+func TestChangePIN(t *testing.T) {
+    svc := NewService(NewMemoryRepository(), nil)
+    ctx := context.Background()
+    user, err := svc.Register(ctx, Credentials{Phone: "+237650000003", PIN: "7305", DeviceID: "device-1"})
+    if err != nil {
+        t.Fatalf("register: %v", err)
+    }
+
+    if _, err := svc.ChangePIN(ctx, user.ID, "9999", "4831"); !errors.Is(err, ErrInvalidPIN) {
+        t.Fatalf("expected wrong old PIN to fail, got %v", err)
+    }
+    if _, err := svc.ChangePIN(ctx, user.ID, "7305", "1111"); !errors.Is(err, ErrWeakPIN) {
+        t.Fatalf("expected weak PIN to fail, got %v", err)
+    }
+    changed, err := svc.ChangePIN(ctx, user.ID, "7305", "4831")
+    if err != nil {
+        t.Fatalf("change: %v", err)
+    }
+    if changed.TokenVersion != user.TokenVersion+1 {
+        t.Fatalf("expected token version bump, got %d", changed.TokenVersion)
+    }
+    if _, err := svc.VerifyPIN(ctx, user.ID, "7305"); !errors.Is(err, ErrInvalidPIN) {
+        t.Fatalf("old PIN must stop working, got %v", err)
+    }
+    if _, err := svc.VerifyPIN(ctx, user.ID, "4831"); err != nil {
+        t.Fatalf("new PIN: %v", err)
+    }
+}
+
+func TestResetPIN(t *testing.T) {
+    svc := NewService(NewMemoryRepository(), nil)
+    ctx := context.Background()
+    user, err := svc.Register(ctx, Credentials{Phone: "+237650000004", PIN: "7305", DeviceID: "device-1"})
+    if err != nil {
+        t.Fatalf("register: %v", err)
+    }
+
+    for i := 0; i < 3; i++ {
+        _, _ = svc.VerifyPIN(ctx, user.ID, "0000")
+    }
+    if _, err := svc.ResetPIN(ctx, user.Phone, "1234"); !errors.Is(err, ErrWeakPIN) {
+        t.Fatalf("expected weak PIN to fail, got %v", err)
+    }
+    reset, err := svc.ResetPIN(ctx, user.Phone, "4831")
+    if err != nil {
+        t.Fatalf("reset: %v", err)
+    }
+    if reset.TokenVersion != user.TokenVersion+1 {
+        t.Fatalf("expected token version bump, got %d", reset.TokenVersion)
+    }
+    // The reset also lifts the delay the wrong PINs imposed.
+    if _, err := svc.Authenticate(ctx, Credentials{Phone: user.Phone, PIN: "4831", DeviceID: "device-1"}); err != nil {
+        t.Fatalf("login with new PIN: %v", err)
+    }
+}
+
+func TestDevices(t *testing.T) {
+    svc := NewService(NewMemoryRepository(), nil)
+    now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+    svc.now = func() time.Time { return now }
+    ctx := context.Background()
+    user, err := svc.Register(ctx, Credentials{Phone: "+237650000005", PIN: "4831", DeviceID: "device-1", DeviceName: "Galaxy A14", Platform: "Android"})
+    if err != nil {
+        t.Fatalf("register: %v", err)
+    }
+    login := func(deviceID string) error {
+        _, err := svc.Authenticate(ctx, Credentials{Phone: user.Phone, PIN: "4831", DeviceID: deviceID})
+        return err
+    }
+
+    if err := login(""); !errors.Is(err, ErrDeviceRequired) {
+        t.Fatalf("expected a device to be required, got %v", err)
+    }
+    if err := login("device-2"); !errors.Is(err, ErrDeviceMismatch) {
+        t.Fatalf("expected device mismatch, got %v", err)
+    }
+    if err := svc.CheckOutgoing(ctx, user.ID, "device-1"); err != nil {
+        t.Fatalf("registration device must not cool off: %v", err)
+    }
+
+    if _, err := svc.ChangeDevice(ctx, Credentials{Phone: user.Phone, PIN: "7305", DeviceID: "device-2"}); !errors.Is(err, ErrInvalidPIN) {
+        t.Fatalf("expected the PIN to be checked, got %v", err)
+    }
+    moved, err := svc.ChangeDevice(ctx, Credentials{Phone: user.Phone, PIN: "4831", DeviceID: "device-2", Platform: "iOS"})
+    if err != nil {
+        t.Fatalf("change device: %v", err)
+    }
+    if moved.DeviceID != "device-2" || moved.TokenVersion != user.TokenVersion {
+        t.Fatalf("unexpected user after device change: %+v", moved)
+    }
+    if err := login("device-1"); err != nil {
+        t.Fatalf("other devices stay trusted: %v", err)
+    }
+    now = now.Add(time.Minute)
+    if err := login("device-2"); err != nil {
+        t.Fatalf("new device: %v", err)
+    }
+    if err := svc.CheckOutgoing(ctx, user.ID, "device-2"); !errors.Is(err, ErrDeviceCoolingOff) {
+        t.Fatalf("expected cooling-off, got %v", err)
+    }
+    now = now.Add(DeviceCoolingOff)
+    if err := svc.CheckOutgoing(ctx, user.ID, "device-2"); err != nil {
+        t.Fatalf("cooling-off over: %v", err)
+    }
+
+    devices, err := svc.Devices(ctx, user.ID)
+    if err != nil || len(devices) != 2 {
+        t.Fatalf("devices = %+v, %v", devices, err)
+    }
+    if devices[0].DeviceID != "device-2" || devices[0].Platform != "ios" || devices[1].Name != "Galaxy A14" {
+        t.Fatalf("unexpected devices %+v", devices)
+    }
+
+    if _, err := svc.RevokeDevice(ctx, user.ID, "device-1", "user"); err != nil {
+        t.Fatalf("revoke: %v", err)
+    }
+    if _, err := svc.ActiveDevice(ctx, user.ID, "device-1"); !errors.Is(err, ErrDeviceMismatch) {
+        t.Fatalf("revoked device sessions must end, got %v", err)
+    }
+    if err := login("device-1"); !errors.Is(err, ErrDeviceMismatch) {
+        t.Fatalf("revoked device must be verified again, got %v", err)
+    }
+    // Revoking every device must not reopen first-login binding.
+    if _, err := svc.RevokeDevice(ctx, user.ID, "device-2", "user"); err != nil {
+        t.Fatalf("revoke: %v", err)
+    }
+    if err := login("device-3"); !errors.Is(err, ErrDeviceMismatch) {
+        t.Fatalf("expected device mismatch, got %v", err)
+    }
+    if _, err := svc.ChangeDevice(ctx, Credentials{Phone: user.Phone, PIN: "4831", DeviceID: "device-1"}); err != nil {
+        t.Fatalf("re-verify: %v", err)
+    }
+    if err := svc.CheckOutgoing(ctx, user.ID, "device-1"); !errors.Is(err, ErrDeviceCoolingOff) {
+        t.Fatalf("a re-verified device cools off again, got %v", err)
+    }
+}
+
+func TestRoles(t *testing.T) {
+    svc := NewService(NewMemoryRepository(), nil)
+    ctx := context.Background()
+    user, err := svc.Register(ctx, Credentials{Phone: "+237650000006", PIN: "4831", DeviceID: "device-1"})
+    if err != nil {
+        t.Fatalf("register: %v", err)
+    }
+    if len(user.Roles) != 1 || user.Roles[0] != RoleCustomer {
+        t.Fatalf("new users are customers only, got %v", user.Roles)
+    }
+
+    if _, err := svc.SetRoles(ctx, user.ID, []string{"root"}, "admin:x"); !errors.Is(err, ErrUnknownRole) {
+        t.Fatalf("expected unknown role, got %v", err)
+    }
+    updated, err := svc.SetRoles(ctx, user.ID, []string{" Support ", RoleSupport}, "admin:x")
+    if err != nil {
+        t.Fatalf("set roles: %v", err)
+    }
+    if got := updated.Roles; len(got) != 2 || got[0] != RoleCustomer || got[1] != RoleSupport {
+        t.Fatalf("unexpected roles %v", got)
+    }
+    if updated.TokenVersion != user.TokenVersion+1 {
+        t.Fatalf("role changes must revoke tokens, version %d", updated.TokenVersion)
+    }
+    same, err := svc.GrantRole(ctx, user.ID, RoleSupport, "admin:x")
+    if err != nil || same.TokenVersion != updated.TokenVersion {
+        t.Fatalf("granting a held role is a no-op, got %+v, %v", same, err)
+    }
+
+    scopes := ScopesFor(updated.Roles)
+    has := func(scope string) bool {
+        for _, s := range scopes {
+            if s == scope {
+                return true
+            }
+        }
+        return false
+    }
+    if !has(ScopeBackOffice) || !has(ScopeUsersRead) || !has(ScopeWallet) || has(ScopeRolesWrite) {
+        t.Fatalf("unexpected support scopes %v", scopes)
+    }
+
+    events, err := svc.SecurityEvents(ctx, user.ID, 10)
+    if err != nil || len(events) == 0 || events[0].Kind != EventRolesChanged || events[0].Actor != "admin:x" {
+        t.Fatalf("expected a roles_changed event, got %+v, %v", events, err)
+    }
+}
@@ -0,0 +1,283 @@
+package kyc
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+)
+
+// Handler exposes KYC submission and review endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds a KYC HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type submissionResponse struct {
+	ID             string     `json:"id"`
+	UserID         string     `json:"user_id"`
+	CurrentTier    string     `json:"current_tier"`
+	RequestedTier  string     `json:"requested_tier"`
+	FullName       string     `json:"full_name"`
+	DateOfBirth    string     `json:"date_of_birth"`
+	Address        string     `json:"address,omitempty"`
+	DocumentType   string     `json:"document_type"`
+	DocumentNumber string     `json:"document_number"`
+	Documents      []Document `json:"documents"`
+	Status         string     `json:"status"`
+	ReviewerID     string     `json:"reviewer_id,omitempty"`
+	Reason         string     `json:"reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+}
+
+func toSubmissionResponse(s Submission) submissionResponse {
+	resp := submissionResponse{
+		ID:             s.ID,
+		UserID:         s.UserID,
+		CurrentTier:    s.CurrentTier,
+		RequestedTier:  s.RequestedTier,
+		FullName:       s.FullName,
+		DateOfBirth:    s.DateOfBirth,
+		Address:        s.Address,
+		DocumentType:   s.DocumentType,
+		DocumentNumber: s.DocumentNumber,
+		Documents:      s.Documents,
+		Status:         s.Status,
+		ReviewerID:     s.ReviewerID,
+		Reason:         s.Reason,
+		CreatedAt:      s.CreatedAt,
+	}
+	if resp.Documents == nil {
+		resp.Documents = []Document{}
+	}
+	if !s.ReviewedAt.IsZero() {
+		reviewed := s.ReviewedAt
+		resp.ReviewedAt = &reviewed
+	}
+	return resp
+}
+
+func toSubmissionList(subs []Submission) []submissionResponse {
+	out := make([]submissionResponse, 0, len(subs))
+	for _, s := range subs {
+		out = append(out, toSubmissionResponse(s))
+	}
+	return out
+}
+
+type tierChangeResponse struct {
+	FromTier     string    `json:"from_tier"`
+	ToTier       string    `json:"to_tier"`
+	SubmissionID string    `json:"submission_id,omitempty"`
+	ChangedBy    string    `json:"changed_by"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func toTierChangeResponse(c TierChange) tierChangeResponse {
+	return tierChangeResponse{
+		FromTier:     c.FromTier,
+		ToTier:       c.ToTier,
+		SubmissionID: c.SubmissionID,
+		ChangedBy:    c.ChangedBy,
+		Reason:       c.Reason,
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+// Submit accepts a multipart tier upgrade request: form fields for identity data and one
+// file part per document kind (document_front, document_back, selfie, proof_of_address).
+func (h *Handler) Submit(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	form, err := c.MultipartForm()
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "multipart form expected")
+	}
+	var files []multipart.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	var uploads []DocumentUpload
+	for _, kind := range DocumentKinds {
+		headers := form.File[kind]
+		if len(headers) == 0 {
+			continue
+		}
+		f, err := headers[0].Open()
+		if err != nil {
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		}
+		files = append(files, f)
+		uploads = append(uploads, DocumentUpload{Kind: kind, Content: f})
+	}
+	sub, err := h.service.Submit(c.UserContext(), SubmitInput{
+		UserID:         uid,
+		RequestedTier:  c.FormValue("requested_tier"),
+		FullName:       c.FormValue("full_name"),
+		DateOfBirth:    c.FormValue("date_of_birth"),
+		Address:        c.FormValue("address"),
+		DocumentType:   c.FormValue("document_type"),
+		DocumentNumber: c.FormValue("document_number"),
+		Documents:      uploads,
+	})
+	if err != nil {
+		return kycError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toSubmissionResponse(sub))
+}
+
+// Status returns the caller's tier, submissions and tier history.
+func (h *Handler) Status(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	tier, err := h.service.CurrentTier(c.UserContext(), uid)
+	if err != nil {
+		return kycError(err)
+	}
+	subs, err := h.service.Submissions(c.UserContext(), uid, c.QueryInt("limit", 20))
+	if err != nil {
+		return kycError(err)
+	}
+	history, err := h.service.TierHistory(c.UserContext(), uid)
+	if err != nil {
+		return kycError(err)
+	}
+	changes := make([]tierChangeResponse, 0, len(history))
+	for _, ch := range history {
+		changes = append(changes, toTierChangeResponse(ch))
+	}
+	return c.JSON(fiber.Map{
+		"tier":         tier,
+		"submissions":  toSubmissionList(subs),
+		"tier_history": changes,
+	})
+}
+
+// Get returns one of the caller's submissions.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	sub, err := h.service.Submission(c.UserContext(), c.Params("submissionId"), uid)
+	if err != nil {
+		return kycError(err)
+	}
+	return c.JSON(toSubmissionResponse(sub))
+}
+
+// AdminQueue lists submissions awaiting review, oldest first (back-office).
+func (h *Handler) AdminQueue(c *fiber.Ctx) error {
+	subs, err := h.service.Queue(c.UserContext(), c.Query("status"), c.QueryInt("limit", 100))
+	if err != nil {
+		return kycError(err)
+	}
+	return c.JSON(fiber.Map{"submissions": toSubmissionList(subs)})
+}
+
+// AdminGet returns any submission (back-office).
+func (h *Handler) AdminGet(c *fiber.Ctx) error {
+	sub, err := h.service.Get(c.UserContext(), c.Params("submissionId"))
+	if err != nil {
+		return kycError(err)
+	}
+	return c.JSON(toSubmissionResponse(sub))
+}
+
+// AdminDocument streams a submitted document to the reviewer (back-office).
+func (h *Handler) AdminDocument(c *fiber.Ctx) error {
+	doc, rc, err := h.service.OpenDocument(c.UserContext(), c.Params("submissionId"), c.Params("kind"))
+	if err != nil {
+		return kycError(err)
+	}
+	c.Set(fiber.HeaderContentType, doc.ContentType)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	// fasthttp closes the reader once the body is written.
+	return c.SendStream(rc, int(doc.Size))
+}
+
+type reviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+// AdminApprove approves a submission and raises the user's tier (back-office).
+func (h *Handler) AdminApprove(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req reviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		}
+	}
+	sub, err := h.service.Approve(c.UserContext(), c.Params("submissionId"), uid, req.Reason)
+	if err != nil {
+		return kycError(err)
+	}
+	return c.JSON(toSubmissionResponse(sub))
+}
+
+// AdminReject rejects a submission with a reason shown to the user (back-office).
+func (h *Handler) AdminReject(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req reviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	sub, err := h.service.Reject(c.UserContext(), c.Params("submissionId"), uid, req.Reason)
+	if err != nil {
+		return kycError(err)
+	}
+	return c.JSON(toSubmissionResponse(sub))
+}
+
+type tierRequest struct {
+	Tier   string `json:"tier"`
+	Reason string `json:"reason"`
+}
+
+// AdminSetTier overrides a user's tier with a reason (back-office).
+func (h *Handler) AdminSetTier(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req tierRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	change, err := h.service.SetTier(c.UserContext(), c.Params("userId"), req.Tier, uid, req.Reason)
+	if err != nil {
+		return kycError(err)
+	}
+	return c.JSON(toTierChangeResponse(change))
+}
+
+// AdminTierHistory returns a user's tier history (back-office).
+func (h *Handler) AdminTierHistory(c *fiber.Ctx) error {
+	history, err := h.service.TierHistory(c.UserContext(), c.Params("userId"))
+	if err != nil {
+		return kycError(err)
+	}
+	out := make([]tierChangeResponse, 0, len(history))
+	for _, ch := range history {
+		out = append(out, toTierChangeResponse(ch))
+	}
+	return c.JSON(fiber.Map{"user_id": c.Params("userId"), "tier_history": out})
+}
+
+func kycError(err error) error {
+	switch {
+	case errors.Is(err, ErrSubmissionNotFound), errors.Is(err, ErrDocumentNotFound), errors.Is(err, ErrBlobNotFound), errors.Is(err, identity.ErrUserNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotPending), errors.Is(err, ErrAlreadyPending):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, ErrInvalidTier):
+		return fiber.NewError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
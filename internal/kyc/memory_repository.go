@@ -0,0 +1,104 @@
+package kyc
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+type memoryRepository struct {
+	mu          sync.RWMutex
+	submissions map[string]Submission
+	changes     []TierChange
+}
+
+// NewMemoryRepository builds an in-memory KYC store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{submissions: make(map[string]Submission)}
+}
+
+func (r *memoryRepository) Create(_ context.Context, s Submission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.submissions {
+		if other.UserID == s.UserID && other.Status == StatusPending {
+			return ErrAlreadyPending
+		}
+	}
+	s.Documents = append([]Document(nil), s.Documents...)
+	r.submissions[s.ID] = s
+	return nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id string) (Submission, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.submissions[id]
+	if !ok {
+		return Submission{}, ErrSubmissionNotFound
+	}
+	return s, nil
+}
+
+func (r *memoryRepository) list(match func(Submission) bool, newestFirst bool, limit int) []Submission {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Submission
+	for _, s := range r.submissions {
+		if match(s) {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if newestFirst {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (r *memoryRepository) ListByUser(_ context.Context, userID string, limit int) ([]Submission, error) {
+	return r.list(func(s Submission) bool { return s.UserID == userID }, true, limit), nil
+}
+
+func (r *memoryRepository) ListByStatus(_ context.Context, status string, limit int) ([]Submission, error) {
+	return r.list(func(s Submission) bool { return s.Status == status }, false, limit), nil
+}
+
+func (r *memoryRepository) Review(_ context.Context, fromStatus string, s Submission) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.submissions[s.ID]
+	if !ok {
+		return ErrSubmissionNotFound
+	}
+	if current.Status != fromStatus {
+		return ErrNotPending
+	}
+	current.Status, current.ReviewerID, current.Reason, current.ReviewedAt = s.Status, s.ReviewerID, s.Reason, s.ReviewedAt
+	r.submissions[s.ID] = current
+	return nil
+}
+
+func (r *memoryRepository) AddTierChange(_ context.Context, c TierChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, c)
+	return nil
+}
+
+func (r *memoryRepository) TierHistory(_ context.Context, userID string) ([]TierChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []TierChange
+	for i := len(r.changes) - 1; i >= 0; i-- {
+		if r.changes[i].UserID == userID {
+			out = append(out, r.changes[i])
+		}
+	}
+	return out, nil
+}
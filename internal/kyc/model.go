@@ -0,0 +1,89 @@
+package kyc
+
+import (
+	"errors"
+	"time"
+)
+
+// Submission statuses.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Document kinds a submission can carry.
+const (
+	DocumentFront  = "document_front"
+	DocumentBack   = "document_back"
+	Selfie         = "selfie"
+	ProofOfAddress = "proof_of_address"
+)
+
+// DocumentKinds lists every accepted document kind.
+var DocumentKinds = []string{DocumentFront, DocumentBack, Selfie, ProofOfAddress}
+
+var (
+	// ErrSubmissionNotFound indicates no submission matches the lookup.
+	ErrSubmissionNotFound = errors.New("KYC submission not found")
+	// ErrNotPending indicates the submission was already reviewed.
+	ErrNotPending = errors.New("KYC submission is not pending review")
+	// ErrAlreadyPending indicates the user already has a submission under review.
+	ErrAlreadyPending = errors.New("a KYC submission is already under review")
+	// ErrDocumentNotFound indicates the submission has no document of the requested kind.
+	ErrDocumentNotFound = errors.New("document not found")
+	// ErrInvalidTier indicates the requested tier is unknown or not above the user's tier.
+	ErrInvalidTier = errors.New("invalid tier")
+)
+
+// Document is an uploaded file kept in the blob store.
+type Document struct {
+	Kind        string `json:"kind"`
+	Ref         string `json:"ref"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// Submission is a user's request to move to a higher KYC tier, waiting for review.
+type Submission struct {
+	ID            string
+	UserID        string
+	CurrentTier   string
+	RequestedTier string
+	FullName      string
+	// DateOfBirth is YYYY-MM-DD.
+	DateOfBirth    string
+	Address        string
+	DocumentType   string
+	DocumentNumber string
+	Documents      []Document
+	Status         string
+	ReviewerID     string
+	// Reason explains a rejection, or optionally an approval.
+	Reason     string
+	CreatedAt  time.Time
+	ReviewedAt time.Time
+}
+
+// Document returns the submission's document of the given kind.
+func (s Submission) Document(kind string) (Document, bool) {
+	for _, d := range s.Documents {
+		if d.Kind == kind {
+			return d, true
+		}
+	}
+	return Document{}, false
+}
+
+// TierChange is one entry of a user's tier history.
+type TierChange struct {
+	ID       string
+	UserID   string
+	FromTier string
+	ToTier   string
+	// SubmissionID is empty for back-office overrides.
+	SubmissionID string
+	ChangedBy    string
+	Reason       string
+	CreatedAt    time.Time
+}
@@ -0,0 +1,205 @@
+package kyc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists KYC submissions and tier history.
+type Repository interface {
+	// Create stores a submission, failing with ErrAlreadyPending when the user already has
+	// one under review.
+	Create(ctx context.Context, s Submission) error
+	Get(ctx context.Context, id string) (Submission, error)
+	ListByUser(ctx context.Context, userID string, limit int) ([]Submission, error)
+	// ListByStatus returns submissions in a status, oldest first, so reviewers work the
+	// queue in arrival order.
+	ListByStatus(ctx context.Context, status string, limit int) ([]Submission, error)
+	// Review records a decision if the submission is still in fromStatus, failing with
+	// ErrNotPending otherwise.
+	Review(ctx context.Context, fromStatus string, s Submission) error
+
+	AddTierChange(ctx context.Context, c TierChange) error
+	// TierHistory returns a user's tier changes, newest first.
+	TierHistory(ctx context.Context, userID string) ([]TierChange, error)
+}
+
+// PostgresRepository implements Repository using PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed KYC repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const submissionColumns = `id::text, user_id::text, current_tier, requested_tier, full_name, date_of_birth, address,
+        document_type, document_number, documents, status, COALESCE(reviewer_id::text, ''), reason, created_at,
+        COALESCE(reviewed_at, 'epoch'::timestamptz)`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubmission(row rowScanner) (Submission, error) {
+	var (
+		s    Submission
+		docs []byte
+	)
+	err := row.Scan(&s.ID, &s.UserID, &s.CurrentTier, &s.RequestedTier, &s.FullName, &s.DateOfBirth, &s.Address,
+		&s.DocumentType, &s.DocumentNumber, &docs, &s.Status, &s.ReviewerID, &s.Reason, &s.CreatedAt, &s.ReviewedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Submission{}, ErrSubmissionNotFound
+		}
+		return Submission{}, err
+	}
+	if err := json.Unmarshal(docs, &s.Documents); err != nil {
+		return Submission{}, err
+	}
+	s.CreatedAt = s.CreatedAt.UTC()
+	if s.ReviewedAt.Unix() == 0 {
+		s.ReviewedAt = time.Time{}
+	} else {
+		s.ReviewedAt = s.ReviewedAt.UTC()
+	}
+	return s, nil
+}
+
+func collectSubmissions(rows pgx.Rows, err error) ([]Submission, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Submission
+	for rows.Next() {
+		s, err := scanSubmission(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Create inserts a submission; a partial unique index allows one pending submission per user.
+func (r *PostgresRepository) Create(ctx context.Context, s Submission) error {
+	docs, err := json.Marshal(s.Documents)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `INSERT INTO kyc_submissions
+        (id, user_id, current_tier, requested_tier, full_name, date_of_birth, address, document_type, document_number,
+         documents, status, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		s.ID, s.UserID, s.CurrentTier, s.RequestedTier, s.FullName, s.DateOfBirth, s.Address, s.DocumentType, s.DocumentNumber,
+		docs, s.Status, s.CreatedAt.UTC())
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrAlreadyPending
+	}
+	return err
+}
+
+// Get fetches a submission by ID.
+func (r *PostgresRepository) Get(ctx context.Context, id string) (Submission, error) {
+	submissionID, err := uuid.Parse(id)
+	if err != nil {
+		return Submission{}, ErrSubmissionNotFound
+	}
+	return scanSubmission(r.db.QueryRow(ctx, `SELECT `+submissionColumns+` FROM kyc_submissions WHERE id = $1`, submissionID))
+}
+
+// ListByUser returns a user's submissions, newest first.
+func (r *PostgresRepository) ListByUser(ctx context.Context, userID string, limit int) ([]Submission, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+submissionColumns+` FROM kyc_submissions
+        WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2`, uid, limit)
+	return collectSubmissions(rows, err)
+}
+
+// ListByStatus returns submissions in a status, oldest first.
+func (r *PostgresRepository) ListByStatus(ctx context.Context, status string, limit int) ([]Submission, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+submissionColumns+` FROM kyc_submissions
+        WHERE status = $1 ORDER BY created_at LIMIT $2`, status, limit)
+	return collectSubmissions(rows, err)
+}
+
+// Review records a decision if the submission is still in fromStatus.
+func (r *PostgresRepository) Review(ctx context.Context, fromStatus string, s Submission) error {
+	submissionID, err := uuid.Parse(s.ID)
+	if err != nil {
+		return ErrSubmissionNotFound
+	}
+	var reviewedAt any
+	if !s.ReviewedAt.IsZero() {
+		reviewedAt = s.ReviewedAt.UTC()
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE kyc_submissions SET status = $1, reviewer_id = $2, reason = $3, reviewed_at = $4
+        WHERE id = $5 AND status = $6`, s.Status, nullable(s.ReviewerID), s.Reason, reviewedAt, submissionID, fromStatus)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotPending
+	}
+	return nil
+}
+
+// AddTierChange appends to a user's tier history.
+func (r *PostgresRepository) AddTierChange(ctx context.Context, c TierChange) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO user_tier_changes
+        (id, user_id, from_tier, to_tier, submission_id, changed_by, reason, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		c.ID, c.UserID, c.FromTier, c.ToTier, nullable(c.SubmissionID), c.ChangedBy, c.Reason, c.CreatedAt.UTC())
+	return err
+}
+
+// TierHistory returns a user's tier changes, newest first.
+func (r *PostgresRepository) TierHistory(ctx context.Context, userID string) ([]TierChange, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT id::text, user_id::text, from_tier, to_tier, COALESCE(submission_id::text, ''),
+            changed_by::text, reason, created_at
+        FROM user_tier_changes WHERE user_id = $1 ORDER BY created_at DESC`, uid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TierChange
+	for rows.Next() {
+		var c TierChange
+		if err := rows.Scan(&c.ID, &c.UserID, &c.FromTier, &c.ToTier, &c.SubmissionID, &c.ChangedBy, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.CreatedAt = c.CreatedAt.UTC()
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
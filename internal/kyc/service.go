@@ -0,0 +1,369 @@
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/notification"
+)
+
+// MaxDocumentBytes caps the size of a single uploaded document.
+const MaxDocumentBytes = 5 << 20
+
+// allowedContentTypes are the sniffed types accepted for document uploads.
+var allowedContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+// Service runs the KYC tier upgrade workflow: users submit identity data and documents,
+// reviewers approve or reject them and approved submissions move the user's tier.
+type Service struct {
+	repo     Repository
+	blobs    BlobStore
+	users    *identity.Service
+	notifier notification.Notifier
+	now      func() time.Time
+}
+
+// NewService builds a KYC service.
+func NewService(repo Repository, blobs BlobStore, users *identity.Service, notifier notification.Notifier) *Service {
+	return &Service{
+		repo:     repo,
+		blobs:    blobs,
+		users:    users,
+		notifier: notifier,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// DocumentUpload is a document file as received from the client.
+type DocumentUpload struct {
+	Kind    string
+	Content io.Reader
+}
+
+// SubmitInput is a user's tier upgrade request.
+type SubmitInput struct {
+	UserID         string
+	RequestedTier  string
+	FullName       string
+	DateOfBirth    string
+	Address        string
+	DocumentType   string
+	DocumentNumber string
+	Documents      []DocumentUpload
+}
+
+// requirements lists what each tier needs on top of identity data. Tier 2 also needs an
+// address backed by a proof of address.
+func requirements(tier string) []string {
+	switch tier {
+	case identity.Tier1:
+		return []string{DocumentFront, Selfie}
+	case identity.Tier2:
+		return []string{DocumentFront, Selfie, ProofOfAddress}
+	default:
+		return nil
+	}
+}
+
+// Submit validates a tier upgrade request, stores its documents and queues it for review.
+// A user can have one submission under review at a time.
+func (s *Service) Submit(ctx context.Context, input SubmitInput) (Submission, error) {
+	user, err := s.users.Get(ctx, input.UserID)
+	if err != nil {
+		return Submission{}, err
+	}
+	if identity.TierRank(input.RequestedTier) <= identity.TierRank(user.Tier) {
+		return Submission{}, fmt.Errorf("%w: requested %q while at %q", ErrInvalidTier, input.RequestedTier, user.Tier)
+	}
+	sub := Submission{
+		ID:             uuid.NewString(),
+		UserID:         user.ID,
+		CurrentTier:    user.Tier,
+		RequestedTier:  input.RequestedTier,
+		FullName:       strings.TrimSpace(input.FullName),
+		DateOfBirth:    strings.TrimSpace(input.DateOfBirth),
+		Address:        strings.TrimSpace(input.Address),
+		DocumentType:   input.DocumentType,
+		DocumentNumber: strings.TrimSpace(input.DocumentNumber),
+		Status:         StatusPending,
+		CreatedAt:      s.now(),
+	}
+	if err := validate(sub, input.Documents); err != nil {
+		return Submission{}, err
+	}
+
+	for _, up := range input.Documents {
+		doc, err := s.store(ctx, sub, up)
+		if err != nil {
+			s.discard(ctx, sub.Documents)
+			return Submission{}, err
+		}
+		sub.Documents = append(sub.Documents, doc)
+	}
+	if err := s.repo.Create(ctx, sub); err != nil {
+		s.discard(ctx, sub.Documents)
+		return Submission{}, err
+	}
+	return sub, nil
+}
+
+func validate(sub Submission, uploads []DocumentUpload) error {
+	switch {
+	case sub.FullName == "":
+		return fmt.Errorf("full name is required")
+	case sub.DocumentNumber == "":
+		return fmt.Errorf("document number is required")
+	}
+	switch sub.DocumentType {
+	case identity.DocumentNationalID, identity.DocumentPassport, identity.DocumentResidencePermit:
+	default:
+		return fmt.Errorf("unknown document type %q", sub.DocumentType)
+	}
+	dob, err := time.Parse("2006-01-02", sub.DateOfBirth)
+	if err != nil {
+		return fmt.Errorf("date of birth must be YYYY-MM-DD")
+	}
+	if dob.AddDate(18, 0, 0).After(sub.CreatedAt) {
+		return fmt.Errorf("account holders must be at least 18")
+	}
+	if sub.RequestedTier == identity.Tier2 && sub.Address == "" {
+		return fmt.Errorf("address is required for %s", identity.Tier2)
+	}
+
+	seen := make(map[string]bool, len(uploads))
+	for _, up := range uploads {
+		known := false
+		for _, kind := range DocumentKinds {
+			known = known || kind == up.Kind
+		}
+		if !known {
+			return fmt.Errorf("unknown document kind %q", up.Kind)
+		}
+		if seen[up.Kind] {
+			return fmt.Errorf("duplicate %s document", up.Kind)
+		}
+		seen[up.Kind] = true
+	}
+	for _, kind := range requirements(sub.RequestedTier) {
+		if !seen[kind] {
+			return fmt.Errorf("%s document is required for %s", kind, sub.RequestedTier)
+		}
+	}
+	return nil
+}
+
+func (s *Service) store(ctx context.Context, sub Submission, up DocumentUpload) (Document, error) {
+	data, err := io.ReadAll(io.LimitReader(up.Content, MaxDocumentBytes+1))
+	if err != nil {
+		return Document{}, err
+	}
+	if len(data) == 0 {
+		return Document{}, fmt.Errorf("%s document is empty", up.Kind)
+	}
+	if len(data) > MaxDocumentBytes {
+		return Document{}, fmt.Errorf("%s document exceeds %d bytes", up.Kind, MaxDocumentBytes)
+	}
+	contentType := http.DetectContentType(data)
+	if !allowedContentTypes[contentType] {
+		return Document{}, fmt.Errorf("%s document must be a JPEG, PNG or PDF", up.Kind)
+	}
+	ref := "kyc/" + sub.UserID + "/" + sub.ID + "/" + up.Kind
+	if err := s.blobs.Put(ctx, ref, bytes.NewReader(data)); err != nil {
+		return Document{}, err
+	}
+	return Document{Kind: up.Kind, Ref: ref, ContentType: contentType, Size: int64(len(data))}, nil
+}
+
+func (s *Service) discard(ctx context.Context, docs []Document) {
+	for _, d := range docs {
+		_ = s.blobs.Delete(ctx, d.Ref)
+	}
+}
+
+// Submissions lists a user's own submissions, newest first.
+func (s *Service) Submissions(ctx context.Context, userID string, limit int) ([]Submission, error) {
+	return s.repo.ListByUser(ctx, userID, limit)
+}
+
+// Submission returns one of the user's own submissions.
+func (s *Service) Submission(ctx context.Context, id, userID string) (Submission, error) {
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Submission{}, err
+	}
+	if sub.UserID != userID {
+		return Submission{}, ErrSubmissionNotFound
+	}
+	return sub, nil
+}
+
+// CurrentTier returns the user's tier.
+func (s *Service) CurrentTier(ctx context.Context, userID string) (string, error) {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	return user.Tier, nil
+}
+
+// TierHistory returns a user's tier changes, newest first.
+func (s *Service) TierHistory(ctx context.Context, userID string) ([]TierChange, error) {
+	return s.repo.TierHistory(ctx, userID)
+}
+
+// Queue lists submissions for reviewers, oldest first. Status defaults to pending.
+func (s *Service) Queue(ctx context.Context, status string, limit int) ([]Submission, error) {
+	if status == "" {
+		status = StatusPending
+	}
+	return s.repo.ListByStatus(ctx, status, limit)
+}
+
+// Get returns any submission (back-office).
+func (s *Service) Get(ctx context.Context, id string) (Submission, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// OpenDocument streams a submission's document to a reviewer; the caller closes it.
+func (s *Service) OpenDocument(ctx context.Context, id, kind string) (Document, io.ReadCloser, error) {
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Document{}, nil, err
+	}
+	doc, ok := sub.Document(kind)
+	if !ok {
+		return Document{}, nil, ErrDocumentNotFound
+	}
+	rc, err := s.blobs.Open(ctx, doc.Ref)
+	if err != nil {
+		return Document{}, nil, err
+	}
+	return doc, rc, nil
+}
+
+// Approve accepts a pending submission and moves the user to the requested tier.
+func (s *Service) Approve(ctx context.Context, id, reviewerID, reason string) (Submission, error) {
+	sub, err := s.pending(ctx, id)
+	if err != nil {
+		return Submission{}, err
+	}
+	user, err := s.users.Get(ctx, sub.UserID)
+	if err != nil {
+		return Submission{}, err
+	}
+	if identity.TierRank(sub.RequestedTier) <= identity.TierRank(user.Tier) {
+		return Submission{}, fmt.Errorf("%w: user is already at %s", ErrInvalidTier, user.Tier)
+	}
+
+	reviewed := sub
+	reviewed.Status = StatusApproved
+	reviewed.ReviewerID = reviewerID
+	reviewed.Reason = strings.TrimSpace(reason)
+	reviewed.ReviewedAt = s.now()
+	if err := s.repo.Review(ctx, StatusPending, reviewed); err != nil {
+		return Submission{}, err
+	}
+	if _, err := s.changeTier(ctx, user, sub.RequestedTier, sub.ID, reviewerID, reviewed.Reason); err != nil {
+		// Put the submission back in the queue so the approval can be retried.
+		_ = s.repo.Review(ctx, StatusApproved, sub)
+		return Submission{}, err
+	}
+	s.notify(ctx, sub.UserID, fmt.Sprintf("Your identity verification was approved. Your account is now %s.", sub.RequestedTier))
+	return reviewed, nil
+}
+
+// Reject declines a pending submission; the reason is shown to the user.
+func (s *Service) Reject(ctx context.Context, id, reviewerID, reason string) (Submission, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return Submission{}, fmt.Errorf("a rejection reason is required")
+	}
+	sub, err := s.pending(ctx, id)
+	if err != nil {
+		return Submission{}, err
+	}
+	sub.Status = StatusRejected
+	sub.ReviewerID = reviewerID
+	sub.Reason = reason
+	sub.ReviewedAt = s.now()
+	if err := s.repo.Review(ctx, StatusPending, sub); err != nil {
+		return Submission{}, err
+	}
+	s.notify(ctx, sub.UserID, fmt.Sprintf("Your identity verification was rejected: %s. You can submit again.", reason))
+	return sub, nil
+}
+
+// SetTier overrides a user's tier outside the submission workflow, e.g. a downgrade after
+// a compliance review (back-office).
+func (s *Service) SetTier(ctx context.Context, userID, tier, adminID, reason string) (TierChange, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return TierChange{}, fmt.Errorf("a reason is required")
+	}
+	if identity.TierRank(tier) < 0 {
+		return TierChange{}, fmt.Errorf("%w: %q", ErrInvalidTier, tier)
+	}
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return TierChange{}, err
+	}
+	if user.Tier == tier {
+		return TierChange{}, fmt.Errorf("%w: user is already at %s", ErrInvalidTier, tier)
+	}
+	change, err := s.changeTier(ctx, user, tier, "", adminID, reason)
+	if err != nil {
+		return TierChange{}, err
+	}
+	s.notify(ctx, userID, fmt.Sprintf("Your account tier changed from %s to %s.", user.Tier, tier))
+	return change, nil
+}
+
+func (s *Service) pending(ctx context.Context, id string) (Submission, error) {
+	sub, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Submission{}, err
+	}
+	if sub.Status != StatusPending {
+		return Submission{}, ErrNotPending
+	}
+	return sub, nil
+}
+
+func (s *Service) changeTier(ctx context.Context, user identity.User, tier, submissionID, changedBy, reason string) (TierChange, error) {
+	if err := s.users.SetTier(ctx, user.ID, tier); err != nil {
+		return TierChange{}, err
+	}
+	change := TierChange{
+		ID:           uuid.NewString(),
+		UserID:       user.ID,
+		FromTier:     user.Tier,
+		ToTier:       tier,
+		SubmissionID: submissionID,
+		ChangedBy:    changedBy,
+		Reason:       reason,
+		CreatedAt:    s.now(),
+	}
+	if err := s.repo.AddTierChange(ctx, change); err != nil {
+		return TierChange{}, err
+	}
+	return change, nil
+}
+
+func (s *Service) notify(ctx context.Context, userID, body string) {
+	if s.notifier == nil {
+		return
+	}
+	_ = s.notifier.Send(ctx, notification.Message{Kind: notification.KindKYC, Destination: userID, Body: body})
+}
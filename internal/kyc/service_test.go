@@ -0,0 +1,212 @@
+package kyc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/notification"
+)
+
+type recordingNotifier struct {
+	sent []notification.Message
+}
+
+func (n *recordingNotifier) Send(_ context.Context, msg notification.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+type fixture struct {
+	svc      *Service
+	users    *identity.Service
+	blobs    *LocalBlobStore
+	notifier *recordingNotifier
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	blobs, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("blob store: %v", err)
+	}
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	notifier := &recordingNotifier{}
+	return fixture{svc: NewService(NewMemoryRepository(), blobs, users, notifier), users: users, blobs: blobs, notifier: notifier}
+}
+
+func (f fixture) user(t *testing.T, phone string) identity.User {
+	t.Helper()
+	u, err := f.users.Register(context.Background(), identity.Credentials{Phone: phone, PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register %s: %v", phone, err)
+	}
+	return u
+}
+
+var (
+	jpeg = append([]byte{0xFF, 0xD8, 0xFF, 0xE0}, bytes.Repeat([]byte{0x01}, 64)...)
+	pdf  = []byte("%PDF-1.4\n%fake\n")
+)
+
+func upload(kind string, data []byte) DocumentUpload {
+	return DocumentUpload{Kind: kind, Content: bytes.NewReader(data)}
+}
+
+func tier1Input(userID string) SubmitInput {
+	return SubmitInput{
+		UserID:         userID,
+		RequestedTier:  identity.Tier1,
+		FullName:       "Grâce Mabiala",
+		DateOfBirth:    "1990-04-12",
+		DocumentType:   identity.DocumentNationalID,
+		DocumentNumber: "CG-0042-1988",
+		Documents:      []DocumentUpload{upload(DocumentFront, jpeg), upload(Selfie, jpeg)},
+	}
+}
+
+func TestServiceSubmitValidation(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	u := f.user(t, "+242060000001")
+
+	noSelfie := tier1Input(u.ID)
+	noSelfie.Documents = noSelfie.Documents[:1]
+	if _, err := f.svc.Submit(ctx, noSelfie); err == nil || !strings.Contains(err.Error(), Selfie) {
+		t.Fatalf("expected missing selfie error, got %v", err)
+	}
+	text := tier1Input(u.ID)
+	text.Documents[1] = upload(Selfie, []byte("not an image"))
+	if _, err := f.svc.Submit(ctx, text); err == nil {
+		t.Fatalf("expected non-image document to be rejected")
+	}
+	minor := tier1Input(u.ID)
+	minor.DateOfBirth = "2015-01-01"
+	if _, err := f.svc.Submit(ctx, minor); err == nil {
+		t.Fatalf("expected minor to be rejected")
+	}
+	tier2 := tier1Input(u.ID)
+	tier2.RequestedTier = identity.Tier2
+	tier2.Address = "12 rue Mfoa, Poto-Poto"
+	if _, err := f.svc.Submit(ctx, tier2); err == nil || !strings.Contains(err.Error(), ProofOfAddress) {
+		t.Fatalf("expected missing proof of address error, got %v", err)
+	}
+	same := tier1Input(u.ID)
+	same.RequestedTier = identity.Tier0
+	if _, err := f.svc.Submit(ctx, same); !errors.Is(err, ErrInvalidTier) {
+		t.Fatalf("expected ErrInvalidTier, got %v", err)
+	}
+
+	if _, err := f.svc.Submit(ctx, tier1Input(u.ID)); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if _, err := f.svc.Submit(ctx, tier1Input(u.ID)); !errors.Is(err, ErrAlreadyPending) {
+		t.Fatalf("expected ErrAlreadyPending, got %v", err)
+	}
+}
+
+func TestServiceApproveAndReject(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	u := f.user(t, "+242060000001")
+	reviewer := f.user(t, "+242060000009")
+
+	sub, err := f.svc.Submit(ctx, tier1Input(u.ID))
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if sub.Status != StatusPending || sub.CurrentTier != identity.Tier0 || len(sub.Documents) != 2 {
+		t.Fatalf("unexpected submission: %+v", sub)
+	}
+	queue, err := f.svc.Queue(ctx, "", 10)
+	if err != nil || len(queue) != 1 || queue[0].ID != sub.ID {
+		t.Fatalf("queue = %v, %v", queue, err)
+	}
+	doc, rc, err := f.svc.OpenDocument(ctx, sub.ID, Selfie)
+	if err != nil {
+		t.Fatalf("open document: %v", err)
+	}
+	stored, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(stored, jpeg) || doc.ContentType != "image/jpeg" {
+		t.Fatalf("document = %s %d bytes", doc.ContentType, len(stored))
+	}
+
+	if _, err := f.svc.Reject(ctx, sub.ID, reviewer.ID, " "); err == nil {
+		t.Fatalf("expected a rejection without reason to fail")
+	}
+	rejected, err := f.svc.Reject(ctx, sub.ID, reviewer.ID, "selfie is blurred")
+	if err != nil || rejected.Status != StatusRejected {
+		t.Fatalf("reject = %+v, %v", rejected, err)
+	}
+	if _, err := f.svc.Approve(ctx, sub.ID, reviewer.ID, ""); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected ErrNotPending, got %v", err)
+	}
+	if got, _ := f.users.Get(ctx, u.ID); got.Tier != identity.Tier0 {
+		t.Fatalf("tier after rejection = %q", got.Tier)
+	}
+
+	again, err := f.svc.Submit(ctx, tier1Input(u.ID))
+	if err != nil {
+		t.Fatalf("resubmit: %v", err)
+	}
+	approved, err := f.svc.Approve(ctx, again.ID, reviewer.ID, "")
+	if err != nil || approved.Status != StatusApproved || approved.ReviewerID != reviewer.ID {
+		t.Fatalf("approve = %+v, %v", approved, err)
+	}
+	if got, _ := f.users.Get(ctx, u.ID); got.Tier != identity.Tier1 {
+		t.Fatalf("tier after approval = %q", got.Tier)
+	}
+	// Logging in no longer changes the tier on its own.
+	if authed, err := f.users.Authenticate(ctx, identity.Credentials{Phone: u.Phone, PIN: "7305", DeviceID: "d1"}); err != nil || authed.Tier != identity.Tier1 {
+		t.Fatalf("authenticate = %q, %v", authed.Tier, err)
+	}
+
+	history, err := f.svc.TierHistory(ctx, u.ID)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("history = %v, %v", history, err)
+	}
+	if h := history[0]; h.FromTier != identity.Tier0 || h.ToTier != identity.Tier1 || h.SubmissionID != again.ID || h.ChangedBy != reviewer.ID {
+		t.Fatalf("unexpected history entry: %+v", h)
+	}
+
+	if _, err := f.svc.SetTier(ctx, u.ID, identity.Tier0, reviewer.ID, "document reported stolen"); err != nil {
+		t.Fatalf("downgrade: %v", err)
+	}
+	if history, _ = f.svc.TierHistory(ctx, u.ID); len(history) != 2 || history[0].ToTier != identity.Tier0 {
+		t.Fatalf("history after downgrade = %+v", history)
+	}
+
+	var kycMessages int
+	for _, m := range f.notifier.sent {
+		if m.Kind == notification.KindKYC && m.Destination == u.ID {
+			kycMessages++
+		}
+	}
+	if kycMessages != 3 {
+		t.Fatalf("KYC notifications = %d, want 3", kycMessages)
+	}
+}
+
+func TestLocalBlobStoreRejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalBlobStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("blob store: %v", err)
+	}
+	ctx := context.Background()
+	for _, key := range []string{"../outside", "/etc/passwd", "", "a/../../b"} {
+		if err := store.Put(ctx, key, strings.NewReader("x")); err == nil {
+			t.Fatalf("expected key %q to be rejected", key)
+		}
+	}
+	if err := store.Put(ctx, "kyc/u/s/selfie", bytes.NewReader(pdf)); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if _, err := store.Open(ctx, "kyc/u/s/missing"); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("expected ErrBlobNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+)
+
+func buildChain(t *testing.T, n int) []ChainLink {
+	t.Helper()
+	links := make([]ChainLink, 0, n)
+	prev := GenesisHash
+	for i := 1; i <= n; i++ {
+		link := ChainLink{
+			Seq:           int64(i),
+			TransactionID: "tx-" + string(rune('a'+i)),
+			Kind:          "p2p",
+			ClientTxID:    "client-" + string(rune('a'+i)),
+			Entries: []ChainEntry{
+				{AccountCode: "wallet:a", Amount: -int64(i * 100)},
+				{AccountCode: "wallet:b", Amount: int64(i * 100)},
+			},
+			PrevHash: prev,
+		}
+		link.Hash = ComputeChainHash(link)
+		prev = link.Hash
+		links = append(links, link)
+	}
+	return links
+}
+
+func TestComputeChainHash_EntryOrderIndependent(t *testing.T) {
+	a := ChainLink{Seq: 1, TransactionID: "tx", Kind: "p2p", ClientTxID: "c", PrevHash: GenesisHash,
+		Entries: []ChainEntry{{AccountCode: "wallet:a", Amount: -5}, {AccountCode: "wallet:b", Amount: 5}}}
+	b := a
+	b.Entries = []ChainEntry{{AccountCode: "wallet:b", Amount: 5}, {AccountCode: "wallet:a", Amount: -5}}
+	if string(ComputeChainHash(a)) != string(ComputeChainHash(b)) {
+		t.Fatal("expected hash to ignore entry storage order")
+	}
+}
+
+func TestVerifyLinks_IntactChain(t *testing.T) {
+	report := VerifyLinks(buildChain(t, 5))
+	if !report.OK() {
+		t.Fatalf("expected intact chain, got break %+v", report.Break)
+	}
+	if report.Checked != 5 || report.HeadSeq != 5 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestChainReport_UnchainedIsNotOK(t *testing.T) {
+	report := VerifyLinks(buildChain(t, 3))
+	report.Unchained = 1
+	if report.OK() {
+		t.Fatal("expected unchained transactions to fail verification")
+	}
+}
+
+func TestVerifyLinks_DetectsEditedEntry(t *testing.T) {
+	links := buildChain(t, 5)
+	links[2].Entries[1].Amount = 999_999
+
+	report := VerifyLinks(links)
+	if report.OK() {
+		t.Fatal("expected edited entry to break the chain")
+	}
+	if report.Break.Seq != 3 || report.Checked != 2 {
+		t.Fatalf("expected break at seq 3 after 2 links, got %+v (checked %d)", report.Break, report.Checked)
+	}
+}
+
+func TestVerifyLinks_DetectsRemovedLink(t *testing.T) {
+	links := buildChain(t, 5)
+	links = append(links[:1], links[2:]...)
+
+	report := VerifyLinks(links)
+	if report.OK() || !strings.Contains(report.Break.Reason, "sequence gap") {
+		t.Fatalf("expected sequence gap, got %+v", report.Break)
+	}
+}
+
+func TestVerifyLinks_DetectsRehashedLink(t *testing.T) {
+	links := buildChain(t, 4)
+	// An attacker who edits and rehashes one link still breaks the next link's prev hash.
+	links[1].Entries[0].Amount = -1
+	links[1].Hash = ComputeChainHash(links[1])
+
+	report := VerifyLinks(links)
+	if report.OK() || report.Break.Seq != 3 {
+		t.Fatalf("expected break at seq 3, got %+v", report.Break)
+	}
+}
+
+func TestCheckpointSignature(t *testing.T) {
+	signer, err := NewCheckpointSigner("test", strings.Repeat("ab", 32))
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+	links := buildChain(t, 3)
+	cp := signer.Sign(3, links[2].Hash)
+	if err := VerifyCheckpoint(signer.PublicKey(), cp); err != nil {
+		t.Fatalf("verify checkpoint: %v", err)
+	}
+	cp.Seq = 2
+	if err := VerifyCheckpoint(signer.PublicKey(), cp); err != ErrInvalidCheckpointSignature {
+		t.Fatalf("expected invalid signature, got %v", err)
+	}
+}
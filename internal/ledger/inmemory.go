@@ -2,22 +2,30 @@ package ledger
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 )
 
 type inMemoryLedger struct {
 	mu           sync.RWMutex
 	balances     map[string]int64
+	statuses     map[string]string
 	transactions map[string]TransactionResult
+	postings     map[string]PostingResult
 	fundingTx    map[string]FundingResult
+	entries      map[string][]Entry
 }
 
 // NewInMemory creates a concurrency-safe in-memory ledger useful for unit tests.
 func NewInMemory() Ledger {
 	return &inMemoryLedger{
 		balances:     make(map[string]int64),
+		statuses:     make(map[string]string),
 		transactions: make(map[string]TransactionResult),
+		postings:     make(map[string]PostingResult),
 		fundingTx:    make(map[string]FundingResult),
+		entries:      make(map[string][]Entry),
 	}
 }
 
@@ -30,6 +38,16 @@ func (l *inMemoryLedger) EnsureAccount(_ context.Context, code string) error {
 	return nil
 }
 
+func (l *inMemoryLedger) SetAccountStatus(_ context.Context, code, status string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, exists := l.balances[code]; !exists {
+		return fmt.Errorf("account %s not found", code)
+	}
+	l.statuses[code] = status
+	return nil
+}
+
 func (l *inMemoryLedger) Balance(_ context.Context, code string) (int64, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -60,6 +78,9 @@ func (l *inMemoryLedger) Transfer(_ context.Context, fromCode, toCode, kind, cli
 	if !ok {
 		return TransactionResult{}, ErrInsufficientFunds
 	}
+	if !canDebit(l.statuses[fromCode]) || !canCredit(l.statuses[toCode]) {
+		return TransactionResult{}, ErrAccountRestricted
+	}
 
 	if fromBalance < amount {
 		return TransactionResult{}, ErrInsufficientFunds
@@ -70,6 +91,8 @@ func (l *inMemoryLedger) Transfer(_ context.Context, fromCode, toCode, kind, cli
 
 	l.balances[fromCode] = fromBalance
 	l.balances[toCode] = toBalance
+	l.record(kind, clientTxID, kind+":"+clientTxID, fromCode, -amount)
+	l.record(kind, clientTxID, kind+":"+clientTxID, toCode, amount)
 
 	res := TransactionResult{
 		TransactionID: kind + ":" + clientTxID,
@@ -81,6 +104,43 @@ func (l *inMemoryLedger) Transfer(_ context.Context, fromCode, toCode, kind, cli
 	return res, nil
 }
 
+func (l *inMemoryLedger) Post(_ context.Context, kind, clientTxID string, postings []Posting) (PostingResult, error) {
+	if err := validatePostings(postings); err != nil {
+		return PostingResult{}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := kind + ":" + clientTxID
+	if res, exists := l.postings[key]; exists {
+		return res, ErrDuplicateTransaction
+	}
+
+	for _, p := range postings {
+		balance, ok := l.balances[p.AccountCode]
+		if !ok {
+			return PostingResult{}, fmt.Errorf("account %s not found", p.AccountCode)
+		}
+		status := l.statuses[p.AccountCode]
+		if p.Amount < 0 && !canDebit(status) || p.Amount > 0 && !canCredit(status) {
+			return PostingResult{}, ErrAccountRestricted
+		}
+		if p.Amount < 0 && !p.AllowOverdraft && balance+p.Amount < 0 {
+			return PostingResult{}, ErrInsufficientFunds
+		}
+	}
+
+	res := PostingResult{TransactionID: key, Balances: make(map[string]int64, len(postings))}
+	for _, p := range postings {
+		l.balances[p.AccountCode] += p.Amount
+		res.Balances[p.AccountCode] = l.balances[p.AccountCode]
+		l.record(kind, clientTxID, key, p.AccountCode, p.Amount)
+	}
+	l.postings[key] = res
+	return res, nil
+}
+
 func (l *inMemoryLedger) CardIn(_ context.Context, walletCode, clientTxID string, amount int64) (FundingResult, error) {
 	if amount <= 0 {
 		return FundingResult{}, ErrInsufficientFunds
@@ -98,10 +158,15 @@ func (l *inMemoryLedger) CardIn(_ context.Context, walletCode, clientTxID string
 	if !ok {
 		return FundingResult{}, ErrInsufficientFunds
 	}
+	if !canCredit(l.statuses[walletCode]) {
+		return FundingResult{}, ErrAccountRestricted
+	}
 
 	walletBalance += amount
 	l.balances[walletCode] = walletBalance
 	l.balances[CardSuspenseAccountCode] -= amount
+	l.record("card_in", clientTxID, key, walletCode, amount)
+	l.record("card_in", clientTxID, key, CardSuspenseAccountCode, -amount)
 
 	res := FundingResult{
 		TransactionID: key,
@@ -129,6 +194,9 @@ func (l *inMemoryLedger) CardOut(_ context.Context, walletCode, clientTxID strin
 	if !ok {
 		return FundingResult{}, ErrInsufficientFunds
 	}
+	if !canDebit(l.statuses[walletCode]) {
+		return FundingResult{}, ErrAccountRestricted
+	}
 	if walletBalance < amount {
 		return FundingResult{}, ErrInsufficientFunds
 	}
@@ -136,6 +204,8 @@ func (l *inMemoryLedger) CardOut(_ context.Context, walletCode, clientTxID strin
 	walletBalance -= amount
 	l.balances[walletCode] = walletBalance
 	l.balances[CardSuspenseAccountCode] += amount
+	l.record("card_out", clientTxID, key, walletCode, -amount)
+	l.record("card_out", clientTxID, key, CardSuspenseAccountCode, amount)
 
 	res := FundingResult{
 		TransactionID: key,
@@ -145,3 +215,40 @@ func (l *inMemoryLedger) CardOut(_ context.Context, walletCode, clientTxID strin
 	l.fundingTx[key] = res
 	return res, nil
 }
+
+// record appends an entry to an account's history; callers hold the write lock.
+func (l *inMemoryLedger) record(kind, clientTxID, txID, code string, amount int64) {
+	l.entries[code] = append(l.entries[code], Entry{
+		TransactionID: txID,
+		Kind:          kind,
+		ClientTxID:    clientTxID,
+		Amount:        amount,
+		CreatedAt:     time.Now().UTC(),
+	})
+}
+
+// Activity derives the opening balance from the current one so balances seeded in tests
+// count as opening funds.
+func (l *inMemoryLedger) Activity(_ context.Context, code string, from, to time.Time) (Activity, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	balance, ok := l.balances[code]
+	if !ok {
+		return Activity{}, fmt.Errorf("account %s not found", code)
+	}
+	act := Activity{Opening: balance}
+	for _, e := range l.entries[code] {
+		if e.CreatedAt.Before(from) {
+			continue
+		}
+		act.Opening -= e.Amount
+		if e.CreatedAt.Before(to) {
+			act.Entries = append(act.Entries, e)
+		}
+	}
+	act.Closing = act.Opening
+	for _, e := range act.Entries {
+		act.Closing += e.Amount
+	}
+	return act, nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestInMemoryLedger_TransferMaintainsBalance(t *testing.T) {
@@ -130,3 +131,71 @@ func TestInMemoryLedger_CardOut(t *testing.T) {
 		t.Fatalf("expected insufficient funds, got %v", err)
 	}
 }
+
+func TestInMemoryLedger_PostMultiLeg(t *testing.T) {
+	l := NewInMemory()
+	ctx := context.Background()
+	for _, code := range []string{"wallet:payer", "wallet:merchant", "revenue:fees"} {
+		l.EnsureAccount(ctx, code)
+	}
+	SeedBalance(l, "wallet:payer", 10_000)
+
+	legs := []Posting{
+		{AccountCode: "wallet:payer", Amount: -5_000},
+		{AccountCode: "wallet:merchant", Amount: 4_950},
+		{AccountCode: "revenue:fees", Amount: 50},
+	}
+	res, err := l.Post(ctx, "merchant_payment", "pay-1", legs)
+	if err != nil {
+		t.Fatalf("post failed: %v", err)
+	}
+	if res.Balances["wallet:payer"] != 5_000 || res.Balances["wallet:merchant"] != 4_950 || res.Balances["revenue:fees"] != 50 {
+		t.Fatalf("unexpected balances: %+v", res.Balances)
+	}
+	if _, err := l.Post(ctx, "merchant_payment", "pay-1", legs); err != ErrDuplicateTransaction {
+		t.Fatalf("expected duplicate, got %v", err)
+	}
+
+	unbalanced := []Posting{{AccountCode: "wallet:payer", Amount: -10}, {AccountCode: "wallet:merchant", Amount: 9}}
+	if _, err := l.Post(ctx, "merchant_payment", "pay-2", unbalanced); err == nil {
+		t.Fatal("expected unbalanced posting to be rejected")
+	}
+
+	tooMuch := []Posting{{AccountCode: "wallet:payer", Amount: -50_000}, {AccountCode: "wallet:merchant", Amount: 50_000}}
+	if _, err := l.Post(ctx, "merchant_payment", "pay-3", tooMuch); err != ErrInsufficientFunds {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+}
+
+func TestInMemoryLedger_Activity(t *testing.T) {
+	l := NewInMemory()
+	ctx := context.Background()
+	for _, code := range []string{"wallet:a", "wallet:b"} {
+		l.EnsureAccount(ctx, code)
+	}
+	SeedBalance(l, "wallet:a", 10_000)
+	from := time.Now().UTC()
+	if _, err := l.Transfer(ctx, "wallet:a", "wallet:b", "p2p", "t-1", 1_500); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if _, err := l.Post(ctx, "p2p", "t-2", []Posting{{AccountCode: "wallet:b", Amount: -500}, {AccountCode: "wallet:a", Amount: 500}}); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+
+	act, err := l.Activity(ctx, "wallet:a", from, from.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("activity: %v", err)
+	}
+	if act.Opening != 10_000 || act.Closing != 9_000 || len(act.Entries) != 2 {
+		t.Fatalf("unexpected activity: %+v", act)
+	}
+	if act.Entries[0].Amount != -1_500 || act.Entries[1].Amount != 500 || act.Entries[1].ClientTxID != "t-2" {
+		t.Fatalf("unexpected entries: %+v", act.Entries)
+	}
+
+	// A window that ends before the postings sees only the opening balance.
+	act, err = l.Activity(ctx, "wallet:a", from.Add(-time.Hour), from)
+	if err != nil || act.Opening != 10_000 || act.Closing != 10_000 || len(act.Entries) != 0 {
+		t.Fatalf("unexpected earlier activity: %+v, %v", act, err)
+	}
+}
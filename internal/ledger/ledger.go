@@ -3,6 +3,8 @@ package ledger
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 )
 
 var (
@@ -13,6 +15,9 @@ var (
 	// ErrDuplicateTransaction indicates the provided client transaction identifier
 	// already exists and therefore the operation should be treated as idempotent.
 	ErrDuplicateTransaction = errors.New("duplicate transaction")
+
+	// ErrAccountRestricted indicates the account status forbids the requested debit or credit.
+	ErrAccountRestricted = errors.New("account restricted")
 )
 
 const (
@@ -22,8 +27,31 @@ const (
 	FundingStatusCompleted = "completed"
 	// CardSuspenseAccountCode is the ledger account used to park card transactions pre-settlement.
 	CardSuspenseAccountCode = "suspense:card"
+	// PayoutSuspenseAccountCode holds funds swept out of closed wallets pending an external payout.
+	PayoutSuspenseAccountCode = "suspense:payout"
 )
 
+const (
+	// AccountStatusActive allows both debits and credits.
+	AccountStatusActive = "active"
+	// AccountStatusDebitBlocked allows credits only.
+	AccountStatusDebitBlocked = "debit_blocked"
+	// AccountStatusCreditBlocked allows debits only, so an account can be emptied.
+	AccountStatusCreditBlocked = "credit_blocked"
+	// AccountStatusBlocked rejects all postings.
+	AccountStatusBlocked = "blocked"
+	// AccountStatusClosed rejects all postings permanently.
+	AccountStatusClosed = "closed"
+)
+
+func canDebit(status string) bool {
+	return status == "" || status == AccountStatusActive || status == AccountStatusCreditBlocked
+}
+
+func canCredit(status string) bool {
+	return status == "" || status == AccountStatusActive || status == AccountStatusDebitBlocked
+}
+
 // TransactionResult captures the outcome of a ledger posting.
 type TransactionResult struct {
 	TransactionID string
@@ -38,6 +66,60 @@ type FundingResult struct {
 	Status        string
 }
 
+// Posting is one leg of a multi-leg ledger transaction. Negative amounts debit the account.
+type Posting struct {
+	AccountCode string
+	Amount      int64
+	// AllowOverdraft lets an internal account (e.g. an expense or suspense account) go negative.
+	AllowOverdraft bool
+}
+
+// PostingResult captures the outcome of a multi-leg posting.
+type PostingResult struct {
+	TransactionID string
+	Balances      map[string]int64
+}
+
+// Entry is one leg of a posted transaction as seen from a single account.
+type Entry struct {
+	TransactionID string
+	Kind          string
+	ClientTxID    string
+	Amount        int64
+	CreatedAt     time.Time
+}
+
+// Activity describes an account's movements over a period.
+type Activity struct {
+	Opening int64
+	Closing int64
+	// Entries are ordered oldest first.
+	Entries []Entry
+}
+
+// validatePostings checks that legs are non-zero, touch each account once and balance to zero.
+func validatePostings(postings []Posting) error {
+	if len(postings) < 2 {
+		return fmt.Errorf("posting requires at least two legs")
+	}
+	seen := make(map[string]struct{}, len(postings))
+	var sum int64
+	for _, p := range postings {
+		if p.Amount == 0 {
+			return fmt.Errorf("posting leg for %s has zero amount", p.AccountCode)
+		}
+		if _, dup := seen[p.AccountCode]; dup {
+			return fmt.Errorf("account %s appears in more than one leg", p.AccountCode)
+		}
+		seen[p.AccountCode] = struct{}{}
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("posting legs do not balance (sum %d)", sum)
+	}
+	return nil
+}
+
 // Ledger defines the contract implemented by ledger backends (e.g. Postgres).
 type Ledger interface {
 	EnsureAccount(ctx context.Context, code string) error
@@ -45,4 +127,9 @@ type Ledger interface {
 	Transfer(ctx context.Context, fromCode, toCode, kind, clientTxID string, amount int64) (TransactionResult, error)
 	CardIn(ctx context.Context, walletCode, clientTxID string, amount int64) (FundingResult, error)
 	CardOut(ctx context.Context, walletCode, clientTxID string, amount int64) (FundingResult, error)
+	SetAccountStatus(ctx context.Context, code, status string) error
+	Post(ctx context.Context, kind, clientTxID string, postings []Posting) (PostingResult, error)
+	// Activity returns the account's balance at from, its entries in [from, to) and its
+	// balance at to.
+	Activity(ctx context.Context, code string, from, to time.Time) (Activity, error)
 }
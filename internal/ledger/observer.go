@@ -0,0 +1,75 @@
+package ledger
+
+import (
+	"context"
+	"sync"
+)
+
+// BalanceObserver is told the resulting balances of the accounts touched by a committed
+// posting. It runs synchronously on the posting goroutine, so it must be quick.
+type BalanceObserver func(ctx context.Context, kind string, balances map[string]int64)
+
+// ObservedLedger wraps a Ledger and notifies observers after every committed posting.
+// Idempotent replays and failed postings are not reported.
+type ObservedLedger struct {
+	Ledger
+	mu        sync.RWMutex
+	observers []BalanceObserver
+}
+
+// NewObserved wraps inner so observers can be subscribed after services are built.
+func NewObserved(inner Ledger) *ObservedLedger {
+	return &ObservedLedger{Ledger: inner}
+}
+
+// Subscribe registers an observer for subsequent postings.
+func (l *ObservedLedger) Subscribe(o BalanceObserver) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.observers = append(l.observers, o)
+}
+
+func (l *ObservedLedger) notify(ctx context.Context, kind string, balances map[string]int64) {
+	l.mu.RLock()
+	observers := l.observers
+	l.mu.RUnlock()
+	for _, o := range observers {
+		o(ctx, kind, balances)
+	}
+}
+
+// Post records a multi-leg posting and reports the new balances.
+func (l *ObservedLedger) Post(ctx context.Context, kind, clientTxID string, postings []Posting) (PostingResult, error) {
+	res, err := l.Ledger.Post(ctx, kind, clientTxID, postings)
+	if err == nil {
+		l.notify(ctx, kind, res.Balances)
+	}
+	return res, err
+}
+
+// Transfer records a two-account transfer and reports the new balances.
+func (l *ObservedLedger) Transfer(ctx context.Context, fromCode, toCode, kind, clientTxID string, amount int64) (TransactionResult, error) {
+	res, err := l.Ledger.Transfer(ctx, fromCode, toCode, kind, clientTxID, amount)
+	if err == nil {
+		l.notify(ctx, kind, map[string]int64{fromCode: res.FromBalance, toCode: res.ToBalance})
+	}
+	return res, err
+}
+
+// CardIn records a card funding and reports the wallet balance.
+func (l *ObservedLedger) CardIn(ctx context.Context, walletCode, clientTxID string, amount int64) (FundingResult, error) {
+	res, err := l.Ledger.CardIn(ctx, walletCode, clientTxID, amount)
+	if err == nil {
+		l.notify(ctx, "card_in", map[string]int64{walletCode: res.WalletBalance})
+	}
+	return res, err
+}
+
+// CardOut records a card withdrawal and reports the wallet balance.
+func (l *ObservedLedger) CardOut(ctx context.Context, walletCode, clientTxID string, amount int64) (FundingResult, error) {
+	res, err := l.Ledger.CardOut(ctx, walletCode, clientTxID, amount)
+	if err == nil {
+		l.notify(ctx, "card_out", map[string]int64{walletCode: res.WalletBalance})
+	}
+	return res, err
+}
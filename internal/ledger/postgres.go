@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -56,10 +58,11 @@ func (l *PostgresLedger) Transfer(ctx context.Context, fromCode, toCode, kind, c
 	}
 	defer tx.Rollback(ctx) // nolint:errcheck
 
-	accountsQuery := `SELECT id FROM accounts WHERE code = $1 FOR UPDATE`
+	accountsQuery := `SELECT id, status FROM accounts WHERE code = $1 FOR UPDATE`
 
 	var fromAccountID uuid.UUID
-	if err := tx.QueryRow(ctx, accountsQuery, fromCode).Scan(&fromAccountID); err != nil {
+	var fromStatus string
+	if err := tx.QueryRow(ctx, accountsQuery, fromCode).Scan(&fromAccountID, &fromStatus); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return TransactionResult{}, fmt.Errorf("from account %s not found", fromCode)
 		}
@@ -67,7 +70,8 @@ func (l *PostgresLedger) Transfer(ctx context.Context, fromCode, toCode, kind, c
 	}
 
 	var toAccountID uuid.UUID
-	if err := tx.QueryRow(ctx, accountsQuery, toCode).Scan(&toAccountID); err != nil {
+	var toStatus string
+	if err := tx.QueryRow(ctx, accountsQuery, toCode).Scan(&toAccountID, &toStatus); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return TransactionResult{}, fmt.Errorf("to account %s not found", toCode)
 		}
@@ -92,6 +96,13 @@ func (l *PostgresLedger) Transfer(ctx context.Context, fromCode, toCode, kind, c
 		return TransactionResult{TransactionID: existingTxID.String(), FromBalance: fromBal, ToBalance: toBal}, ErrDuplicateTransaction
 	}
 
+	if !canDebit(fromStatus) {
+		return TransactionResult{}, fmt.Errorf("%w: %s cannot be debited", ErrAccountRestricted, fromCode)
+	}
+	if !canCredit(toStatus) {
+		return TransactionResult{}, fmt.Errorf("%w: %s cannot be credited", ErrAccountRestricted, toCode)
+	}
+
 	fromBalance, err := balanceForAccount(ctx, tx, fromAccountID)
 	if err != nil {
 		return TransactionResult{}, err
@@ -111,6 +122,12 @@ func (l *PostgresLedger) Transfer(ctx context.Context, fromCode, toCode, kind, c
 	if _, err := tx.Exec(ctx, `INSERT INTO entries (id, transaction_id, account_id, amount) VALUES ($1, $2, $3, $4)`, uuid.New(), txID, toAccountID, amount); err != nil {
 		return TransactionResult{}, err
 	}
+	if err := appendChainLink(ctx, tx, txID, kind, clientTxID, []ChainEntry{
+		{AccountCode: fromCode, Amount: -amount},
+		{AccountCode: toCode, Amount: amount},
+	}); err != nil {
+		return TransactionResult{}, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return TransactionResult{}, err
@@ -129,6 +146,101 @@ func (l *PostgresLedger) Transfer(ctx context.Context, fromCode, toCode, kind, c
 	return TransactionResult{TransactionID: txID.String(), FromBalance: fromBal, ToBalance: toBal}, nil
 }
 
+// Post records a balanced multi-leg transaction atomically.
+func (l *PostgresLedger) Post(ctx context.Context, kind, clientTxID string, postings []Posting) (PostingResult, error) {
+	if err := validatePostings(postings); err != nil {
+		return PostingResult{}, err
+	}
+
+	tx, err := l.db.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return PostingResult{}, err
+	}
+	defer tx.Rollback(ctx) // nolint:errcheck
+
+	// Lock accounts in a stable order so concurrent multi-leg postings cannot deadlock.
+	ordered := make([]Posting, len(postings))
+	copy(ordered, postings)
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].AccountCode < ordered[j].AccountCode })
+
+	accountIDs := make(map[string]uuid.UUID, len(ordered))
+	statuses := make(map[string]string, len(ordered))
+	for _, p := range ordered {
+		id, status, err := lockAccount(ctx, tx, p.AccountCode)
+		if err != nil {
+			return PostingResult{}, err
+		}
+		accountIDs[p.AccountCode] = id
+		statuses[p.AccountCode] = status
+	}
+
+	balances := func() (map[string]int64, error) {
+		out := make(map[string]int64, len(ordered))
+		for _, p := range ordered {
+			bal, err := balanceForAccount(ctx, tx, accountIDs[p.AccountCode])
+			if err != nil {
+				return nil, err
+			}
+			out[p.AccountCode] = bal
+		}
+		return out, nil
+	}
+
+	const existingTxQuery = `SELECT id FROM transactions WHERE client_tx_id = $1 AND kind = $2`
+	var existingTxID uuid.UUID
+	if err := tx.QueryRow(ctx, existingTxQuery, clientTxID, kind).Scan(&existingTxID); err == nil {
+		current, err := balances()
+		if err != nil {
+			return PostingResult{}, err
+		}
+		return PostingResult{TransactionID: existingTxID.String(), Balances: current}, ErrDuplicateTransaction
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return PostingResult{}, err
+	}
+
+	current, err := balances()
+	if err != nil {
+		return PostingResult{}, err
+	}
+	for _, p := range ordered {
+		status := statuses[p.AccountCode]
+		if p.Amount < 0 && !canDebit(status) {
+			return PostingResult{}, fmt.Errorf("%w: %s cannot be debited", ErrAccountRestricted, p.AccountCode)
+		}
+		if p.Amount > 0 && !canCredit(status) {
+			return PostingResult{}, fmt.Errorf("%w: %s cannot be credited", ErrAccountRestricted, p.AccountCode)
+		}
+		if p.Amount < 0 && !p.AllowOverdraft && current[p.AccountCode]+p.Amount < 0 {
+			return PostingResult{}, ErrInsufficientFunds
+		}
+	}
+
+	txID := uuid.New()
+	if _, err := tx.Exec(ctx, `INSERT INTO transactions (id, client_tx_id, kind, status) VALUES ($1, $2, $3, $4)`, txID, clientTxID, kind, FundingStatusCompleted); err != nil {
+		return PostingResult{}, err
+	}
+	chainEntries := make([]ChainEntry, 0, len(ordered))
+	for _, p := range ordered {
+		if _, err := tx.Exec(ctx, `INSERT INTO entries (id, transaction_id, account_id, amount) VALUES ($1, $2, $3, $4)`, uuid.New(), txID, accountIDs[p.AccountCode], p.Amount); err != nil {
+			return PostingResult{}, err
+		}
+		chainEntries = append(chainEntries, ChainEntry{AccountCode: p.AccountCode, Amount: p.Amount})
+	}
+	if err := appendChainLink(ctx, tx, txID, kind, clientTxID, chainEntries); err != nil {
+		return PostingResult{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return PostingResult{}, err
+	}
+
+	res := PostingResult{TransactionID: txID.String(), Balances: make(map[string]int64, len(ordered))}
+	for _, p := range ordered {
+		res.Balances[p.AccountCode] = current[p.AccountCode] + p.Amount
+	}
+	return res, nil
+}
+
 // CardIn records a card funding authorization and holds it in suspense until settlement.
 func (l *PostgresLedger) CardIn(ctx context.Context, walletCode, clientTxID string, amount int64) (FundingResult, error) {
 	if amount <= 0 {
@@ -141,11 +253,11 @@ func (l *PostgresLedger) CardIn(ctx context.Context, walletCode, clientTxID stri
 	}
 	defer tx.Rollback(ctx) // nolint:errcheck
 
-	walletAccountID, err := accountIDForCode(ctx, tx, walletCode)
+	walletAccountID, walletStatus, err := lockAccount(ctx, tx, walletCode)
 	if err != nil {
 		return FundingResult{}, err
 	}
-	suspenseAccountID, err := accountIDForCode(ctx, tx, CardSuspenseAccountCode)
+	suspenseAccountID, _, err := lockAccount(ctx, tx, CardSuspenseAccountCode)
 	if err != nil {
 		return FundingResult{}, err
 	}
@@ -163,6 +275,10 @@ func (l *PostgresLedger) CardIn(ctx context.Context, walletCode, clientTxID stri
 		return FundingResult{}, err
 	}
 
+	if !canCredit(walletStatus) {
+		return FundingResult{}, fmt.Errorf("%w: %s cannot be credited", ErrAccountRestricted, walletCode)
+	}
+
 	txID := uuid.New()
 	if _, err := tx.Exec(ctx, `INSERT INTO transactions (id, client_tx_id, kind, status) VALUES ($1, $2, $3, $4)`, txID, clientTxID, "card_in", FundingStatusPendingSettlement); err != nil {
 		return FundingResult{}, err
@@ -174,6 +290,12 @@ func (l *PostgresLedger) CardIn(ctx context.Context, walletCode, clientTxID stri
 	if _, err := tx.Exec(ctx, `INSERT INTO entries (id, transaction_id, account_id, amount) VALUES ($1, $2, $3, $4)`, uuid.New(), txID, suspenseAccountID, -amount); err != nil {
 		return FundingResult{}, err
 	}
+	if err := appendChainLink(ctx, tx, txID, "card_in", clientTxID, []ChainEntry{
+		{AccountCode: walletCode, Amount: amount},
+		{AccountCode: CardSuspenseAccountCode, Amount: -amount},
+	}); err != nil {
+		return FundingResult{}, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return FundingResult{}, err
@@ -199,11 +321,11 @@ func (l *PostgresLedger) CardOut(ctx context.Context, walletCode, clientTxID str
 	}
 	defer tx.Rollback(ctx) // nolint:errcheck
 
-	walletAccountID, err := accountIDForCode(ctx, tx, walletCode)
+	walletAccountID, walletStatus, err := lockAccount(ctx, tx, walletCode)
 	if err != nil {
 		return FundingResult{}, err
 	}
-	suspenseAccountID, err := accountIDForCode(ctx, tx, CardSuspenseAccountCode)
+	suspenseAccountID, _, err := lockAccount(ctx, tx, CardSuspenseAccountCode)
 	if err != nil {
 		return FundingResult{}, err
 	}
@@ -221,6 +343,10 @@ func (l *PostgresLedger) CardOut(ctx context.Context, walletCode, clientTxID str
 		return FundingResult{}, err
 	}
 
+	if !canDebit(walletStatus) {
+		return FundingResult{}, fmt.Errorf("%w: %s cannot be debited", ErrAccountRestricted, walletCode)
+	}
+
 	walletBalance, err := balanceForAccount(ctx, tx, walletAccountID)
 	if err != nil {
 		return FundingResult{}, err
@@ -240,6 +366,12 @@ func (l *PostgresLedger) CardOut(ctx context.Context, walletCode, clientTxID str
 	if _, err := tx.Exec(ctx, `INSERT INTO entries (id, transaction_id, account_id, amount) VALUES ($1, $2, $3, $4)`, uuid.New(), txID, suspenseAccountID, amount); err != nil {
 		return FundingResult{}, err
 	}
+	if err := appendChainLink(ctx, tx, txID, "card_out", clientTxID, []ChainEntry{
+		{AccountCode: walletCode, Amount: -amount},
+		{AccountCode: CardSuspenseAccountCode, Amount: amount},
+	}); err != nil {
+		return FundingResult{}, err
+	}
 
 	if err := tx.Commit(ctx); err != nil {
 		return FundingResult{}, err
@@ -253,16 +385,64 @@ func (l *PostgresLedger) CardOut(ctx context.Context, walletCode, clientTxID str
 	return FundingResult{TransactionID: txID.String(), WalletBalance: updatedBalance, Status: FundingStatusPendingSettlement}, nil
 }
 
-func accountIDForCode(ctx context.Context, tx pgx.Tx, code string) (uuid.UUID, error) {
-	const query = `SELECT id FROM accounts WHERE code = $1 FOR UPDATE`
+// SetAccountStatus updates the posting restrictions applied to an account.
+func (l *PostgresLedger) SetAccountStatus(ctx context.Context, code, status string) error {
+	cmd, err := l.db.Exec(ctx, `UPDATE accounts SET status = $1 WHERE code = $2`, status, code)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return fmt.Errorf("account %s not found", code)
+	}
+	return nil
+}
+
+// Activity returns an account's opening balance, entries in [from, to) and closing balance.
+func (l *PostgresLedger) Activity(ctx context.Context, code string, from, to time.Time) (Activity, error) {
+	var accountID uuid.UUID
+	if err := l.db.QueryRow(ctx, `SELECT id FROM accounts WHERE code = $1`, code).Scan(&accountID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Activity{}, fmt.Errorf("account %s not found", code)
+		}
+		return Activity{}, err
+	}
+	var act Activity
+	if err := l.db.QueryRow(ctx, `SELECT COALESCE(SUM(amount), 0) FROM entries
+        WHERE account_id = $1 AND created_at < $2`, accountID, from).Scan(&act.Opening); err != nil {
+		return Activity{}, err
+	}
+	rows, err := l.db.Query(ctx, `SELECT t.id::text, t.kind, t.client_tx_id, e.amount, e.created_at
+        FROM entries e JOIN transactions t ON t.id = e.transaction_id
+        WHERE e.account_id = $1 AND e.created_at >= $2 AND e.created_at < $3
+        ORDER BY e.created_at, e.id`, accountID, from, to)
+	if err != nil {
+		return Activity{}, err
+	}
+	defer rows.Close()
+	act.Closing = act.Opening
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.TransactionID, &e.Kind, &e.ClientTxID, &e.Amount, &e.CreatedAt); err != nil {
+			return Activity{}, err
+		}
+		e.CreatedAt = e.CreatedAt.UTC()
+		act.Closing += e.Amount
+		act.Entries = append(act.Entries, e)
+	}
+	return act, rows.Err()
+}
+
+func lockAccount(ctx context.Context, tx pgx.Tx, code string) (uuid.UUID, string, error) {
+	const query = `SELECT id, status FROM accounts WHERE code = $1 FOR UPDATE`
 	var id uuid.UUID
-	if err := tx.QueryRow(ctx, query, code).Scan(&id); err != nil {
+	var status string
+	if err := tx.QueryRow(ctx, query, code).Scan(&id, &status); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return uuid.Nil, fmt.Errorf("account %s not found", code)
+			return uuid.Nil, "", fmt.Errorf("account %s not found", code)
 		}
-		return uuid.Nil, err
+		return uuid.Nil, "", err
 	}
-	return id, nil
+	return id, status, nil
 }
 
 func balanceForAccount(ctx context.Context, tx pgx.Tx, accountID uuid.UUID) (int64, error) {
@@ -0,0 +1,243 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// appendChainLink links a freshly inserted transaction to the chain head. It must run inside
+// the posting transaction; the head row lock serialises chain appends across writers.
+func appendChainLink(ctx context.Context, tx pgx.Tx, txID uuid.UUID, kind, clientTxID string, entries []ChainEntry) error {
+	var (
+		headSeq  int64
+		headHash []byte
+	)
+	if err := tx.QueryRow(ctx, `SELECT chain_seq, hash FROM ledger_chain_head WHERE id FOR UPDATE`).Scan(&headSeq, &headHash); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("ledger chain head missing")
+		}
+		return err
+	}
+
+	link := ChainLink{
+		Seq:           headSeq + 1,
+		TransactionID: txID.String(),
+		Kind:          kind,
+		ClientTxID:    clientTxID,
+		Entries:       entries,
+		PrevHash:      headHash,
+	}
+	link.Hash = ComputeChainHash(link)
+
+	if _, err := tx.Exec(ctx, `UPDATE transactions SET chain_seq = $1, prev_hash = $2, hash = $3 WHERE id = $4`,
+		link.Seq, link.PrevHash, link.Hash, txID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `UPDATE ledger_chain_head SET chain_seq = $1, hash = $2, updated_at = NOW() WHERE id`, link.Seq, link.Hash)
+	return err
+}
+
+// VerifyChain walks every chained transaction in sequence order, recomputing hashes from the
+// stored entries, and reports the first broken link. When pub is non-nil, stored checkpoints
+// are also verified against it. Everything is read from one repeatable-read snapshot so
+// postings committed mid-walk cannot make the head look ahead of the stored links.
+func (l *PostgresLedger) VerifyChain(ctx context.Context, pub ed25519.PublicKey) (ChainReport, error) {
+	tx, err := l.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return ChainReport{}, err
+	}
+	defer tx.Rollback(ctx) // nolint:errcheck
+
+	checkpoints, err := checkpointsBySeq(ctx, tx)
+	if err != nil {
+		return ChainReport{}, err
+	}
+
+	rows, err := tx.Query(ctx, `
+        SELECT t.id, t.chain_seq, t.kind, t.client_tx_id, t.prev_hash, t.hash, a.code, e.amount
+        FROM transactions t
+        LEFT JOIN entries e ON e.transaction_id = t.id
+        LEFT JOIN accounts a ON a.id = e.account_id
+        WHERE t.chain_seq IS NOT NULL
+        ORDER BY t.chain_seq`)
+	if err != nil {
+		return ChainReport{}, err
+	}
+	defer rows.Close()
+
+	var (
+		report  ChainReport
+		walker  = newChainWalker()
+		current *ChainLink
+	)
+	flush := func() *ChainBreak {
+		if current == nil {
+			return nil
+		}
+		if b := walker.next(*current); b != nil {
+			return b
+		}
+		report.Checked++
+		for _, cp := range checkpoints[current.Seq] {
+			if !bytes.Equal(cp.Hash, current.Hash) {
+				return &ChainBreak{Seq: current.Seq, TransactionID: current.TransactionID, Reason: fmt.Sprintf("checkpoint %s hash does not match link", cp.ID)}
+			}
+			if pub != nil {
+				if err := VerifyCheckpoint(pub, cp); err != nil {
+					return &ChainBreak{Seq: current.Seq, TransactionID: current.TransactionID, Reason: fmt.Sprintf("checkpoint %s: %v", cp.ID, err)}
+				}
+			}
+			report.CheckpointsVerified++
+		}
+		return nil
+	}
+
+	for rows.Next() {
+		var (
+			txID       uuid.UUID
+			seq        int64
+			kind       string
+			clientTxID string
+			prevHash   []byte
+			hash       []byte
+			code       *string
+			amount     *int64
+		)
+		if err := rows.Scan(&txID, &seq, &kind, &clientTxID, &prevHash, &hash, &code, &amount); err != nil {
+			return ChainReport{}, err
+		}
+		if current == nil || current.Seq != seq {
+			if b := flush(); b != nil {
+				report.Break = b
+				break
+			}
+			current = &ChainLink{Seq: seq, TransactionID: txID.String(), Kind: kind, ClientTxID: clientTxID, PrevHash: prevHash, Hash: hash}
+		}
+		if code != nil && amount != nil {
+			current.Entries = append(current.Entries, ChainEntry{AccountCode: *code, Amount: *amount})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return ChainReport{}, err
+	}
+	rows.Close()
+	if report.Break == nil {
+		report.Break = flush()
+	}
+	report.HeadSeq = walker.seq
+	report.HeadHash = walker.hash
+
+	if report.Break == nil {
+		var headSeq int64
+		var headHash []byte
+		if err := tx.QueryRow(ctx, `SELECT chain_seq, hash FROM ledger_chain_head WHERE id`).Scan(&headSeq, &headHash); err != nil {
+			return ChainReport{}, err
+		}
+		if headSeq != walker.seq || !bytes.Equal(headHash, walker.hash) {
+			report.Break = &ChainBreak{Seq: walker.seq + 1, Reason: fmt.Sprintf("chain head records seq %d but stored links end at %d", headSeq, walker.seq)}
+		}
+		for seq, cps := range checkpoints {
+			if seq > walker.seq && (report.Break == nil || seq < report.Break.Seq) {
+				report.Break = &ChainBreak{Seq: seq, Reason: fmt.Sprintf("checkpoint %s references a missing link", cps[0].ID)}
+			}
+		}
+	}
+
+	if err := tx.QueryRow(ctx, `
+        SELECT COUNT(*) FROM transactions
+        WHERE chain_seq IS NULL
+          AND created_at >= (SELECT COALESCE(MIN(created_at), 'infinity') FROM transactions WHERE chain_seq IS NOT NULL)`).Scan(&report.Unchained); err != nil {
+		return ChainReport{}, err
+	}
+	return report, nil
+}
+
+func checkpointsBySeq(ctx context.Context, tx pgx.Tx) (map[int64][]Checkpoint, error) {
+	rows, err := tx.Query(ctx, `SELECT id, chain_seq, hash, key_id, signature, created_at FROM ledger_checkpoints ORDER BY chain_seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[int64][]Checkpoint)
+	for rows.Next() {
+		var (
+			id uuid.UUID
+			cp Checkpoint
+		)
+		if err := rows.Scan(&id, &cp.Seq, &cp.Hash, &cp.KeyID, &cp.Signature, &cp.CreatedAt); err != nil {
+			return nil, err
+		}
+		cp.ID = id.String()
+		cp.CreatedAt = cp.CreatedAt.UTC()
+		out[cp.Seq] = append(out[cp.Seq], cp)
+	}
+	return out, rows.Err()
+}
+
+// Checkpoint signs and stores the current chain head. If the head has not moved since the
+// latest checkpoint, that checkpoint is returned instead of writing a new one.
+func (l *PostgresLedger) Checkpoint(ctx context.Context, signer *CheckpointSigner) (Checkpoint, error) {
+	var (
+		headSeq  int64
+		headHash []byte
+	)
+	if err := l.db.QueryRow(ctx, `SELECT chain_seq, hash FROM ledger_chain_head WHERE id`).Scan(&headSeq, &headHash); err != nil {
+		return Checkpoint{}, err
+	}
+
+	var (
+		lastID uuid.UUID
+		last   Checkpoint
+	)
+	err := l.db.QueryRow(ctx, `SELECT id, chain_seq, hash, key_id, signature, created_at
+        FROM ledger_checkpoints ORDER BY chain_seq DESC, created_at DESC LIMIT 1`).
+		Scan(&lastID, &last.Seq, &last.Hash, &last.KeyID, &last.Signature, &last.CreatedAt)
+	if err == nil && last.Seq == headSeq && last.KeyID == signer.KeyID {
+		last.ID = lastID.String()
+		return last, nil
+	} else if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Checkpoint{}, err
+	}
+
+	cp := signer.Sign(headSeq, headHash)
+	cp.ID = uuid.NewString()
+	cp.CreatedAt = time.Now().UTC()
+	if _, err := l.db.Exec(ctx, `INSERT INTO ledger_checkpoints (id, chain_seq, hash, key_id, signature, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`, uuid.MustParse(cp.ID), cp.Seq, cp.Hash, cp.KeyID, cp.Signature, cp.CreatedAt); err != nil {
+		return Checkpoint{}, err
+	}
+	return cp, nil
+}
+
+// RunCheckpoints writes a signed checkpoint every interval until ctx is cancelled.
+func RunCheckpoints(ctx context.Context, l *PostgresLedger, signer *CheckpointSigner, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cp, err := l.Checkpoint(ctx, signer)
+			if logger == nil {
+				continue
+			}
+			if err != nil {
+				logger.Error("ledger checkpoint failed", slog.Any("error", err))
+				continue
+			}
+			logger.Info("ledger checkpoint", slog.Int64("chain_seq", cp.Seq), slog.String("key_id", cp.KeyID))
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package merchant
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skip2/go-qrcode"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// Handler exposes merchant HTTP endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds a merchant HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type onboardRequest struct {
+	BusinessName       string `json:"business_name"`
+	CategoryCode       string `json:"category_code"`
+	City               string `json:"city"`
+	SettlementWalletID string `json:"settlement_wallet_id"`
+}
+
+type merchantResponse struct {
+	ID                 string    `json:"id"`
+	OwnerUserID        string    `json:"owner_user_id"`
+	BusinessName       string    `json:"business_name"`
+	CategoryCode       string    `json:"category_code"`
+	City               string    `json:"city"`
+	ShortCode          string    `json:"short_code"`
+	SettlementWalletID string    `json:"settlement_wallet_id"`
+	MDRBasisPoints     int       `json:"mdr_bps"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+func toMerchantResponse(m Merchant) merchantResponse {
+	return merchantResponse{
+		ID:                 m.ID,
+		OwnerUserID:        m.OwnerUserID,
+		BusinessName:       m.BusinessName,
+		CategoryCode:       m.CategoryCode,
+		City:               m.City,
+		ShortCode:          m.ShortCode,
+		SettlementWalletID: m.SettlementWalletID,
+		MDRBasisPoints:     m.MDRBasisPoints,
+		Status:             m.Status,
+		CreatedAt:          m.CreatedAt,
+	}
+}
+
+// publicMerchantResponse is what payers see when looking up a merchant before paying.
+type publicMerchantResponse struct {
+	ID           string `json:"id"`
+	BusinessName string `json:"business_name"`
+	CategoryCode string `json:"category_code"`
+	ShortCode    string `json:"short_code"`
+	Status       string `json:"status"`
+}
+
+type receiptResponse struct {
+	ID            string    `json:"id"`
+	MerchantID    string    `json:"merchant_id"`
+	MerchantName  string    `json:"merchant_name"`
+	ShortCode     string    `json:"short_code"`
+	PayerUserID   string    `json:"payer_user_id"`
+	PayerWalletID string    `json:"payer_wallet_id"`
+	Amount        int64     `json:"amount"`
+	Fee           int64     `json:"fee"`
+	NetAmount     int64     `json:"net_amount"`
+	Reference     string    `json:"reference,omitempty"`
+	ClientTxID    string    `json:"client_tx_id"`
+	TransactionID string    `json:"transaction_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func toReceiptResponse(p Payment) receiptResponse {
+	return receiptResponse{
+		ID:            p.ID,
+		MerchantID:    p.MerchantID,
+		MerchantName:  p.MerchantName,
+		ShortCode:     p.ShortCode,
+		PayerUserID:   p.PayerUserID,
+		PayerWalletID: p.PayerWalletID,
+		Amount:        p.Amount,
+		Fee:           p.Fee,
+		NetAmount:     p.NetAmount,
+		Reference:     p.Reference,
+		ClientTxID:    p.ClientTxID,
+		TransactionID: p.TransactionID,
+		CreatedAt:     p.CreatedAt,
+	}
+}
+
+// Onboard registers a merchant owned by the authenticated user.
+func (h *Handler) Onboard(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req onboardRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	m, err := h.service.Onboard(c.UserContext(), OnboardInput{
+		OwnerUserID:        uid,
+		BusinessName:       req.BusinessName,
+		CategoryCode:       req.CategoryCode,
+		City:               req.City,
+		SettlementWalletID: req.SettlementWalletID,
+	})
+	if err != nil {
+		return merchantError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toMerchantResponse(m))
+}
+
+// List returns merchants owned by the authenticated user.
+func (h *Handler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	merchants, err := h.service.ListByOwner(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]merchantResponse, 0, len(merchants))
+	for _, m := range merchants {
+		out = append(out, toMerchantResponse(m))
+	}
+	return c.JSON(fiber.Map{"merchants": out})
+}
+
+// Get returns a merchant; owners see the full record, others the public view.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	m, err := h.service.Get(c.UserContext(), c.Params("merchantId"))
+	if err != nil {
+		return merchantError(err)
+	}
+	if m.OwnerUserID == uid {
+		return c.JSON(toMerchantResponse(m))
+	}
+	return c.JSON(toPublicResponse(m))
+}
+
+// Lookup resolves a merchant short code to its public details.
+func (h *Handler) Lookup(c *fiber.Ctx) error {
+	m, err := h.service.GetByShortCode(c.UserContext(), c.Params("shortCode"))
+	if err != nil {
+		return merchantError(err)
+	}
+	return c.JSON(toPublicResponse(m))
+}
+
+func toPublicResponse(m Merchant) publicMerchantResponse {
+	return publicMerchantResponse{
+		ID:           m.ID,
+		BusinessName: m.BusinessName,
+		CategoryCode: m.CategoryCode,
+		ShortCode:    m.ShortCode,
+		Status:       m.Status,
+	}
+}
+
+// Payments lists payments received by a merchant owned by the authenticated user.
+func (h *Handler) Payments(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	payments, err := h.service.Payments(c.UserContext(), c.Params("merchantId"), uid, c.QueryInt("limit", 50))
+	if err != nil {
+		return merchantError(err)
+	}
+	out := make([]receiptResponse, 0, len(payments))
+	for _, p := range payments {
+		out = append(out, toReceiptResponse(p))
+	}
+	return c.JSON(fiber.Map{"payments": out})
+}
+
+type payRequest struct {
+	FromWalletID string `json:"from_wallet_id"`
+	MerchantID   string `json:"merchant_id"`
+	ShortCode    string `json:"short_code"`
+	Amount       int64  `json:"amount"`
+	Reference    string `json:"reference"`
+	ClientTxID   string `json:"client_tx_id"`
+	OTPCode      string `json:"otp_code"`
+}
+
+// Pay processes a customer-to-merchant payment and returns the receipt.
+func (h *Handler) Pay(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req payRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	payment, err := h.service.Pay(c.UserContext(), PayInput{
+		PayerUserID:  uid,
+		FromWalletID: req.FromWalletID,
+		MerchantID:   req.MerchantID,
+		ShortCode:    req.ShortCode,
+		Amount:       req.Amount,
+		Reference:    req.Reference,
+		ClientTxID:   req.ClientTxID,
+		OTPCode:      req.OTPCode,
+	})
+	if errors.Is(err, ledger.ErrDuplicateTransaction) && payment.ID != "" {
+		// Retries with the same client_tx_id get the original receipt back.
+		return c.Status(http.StatusOK).JSON(toReceiptResponse(payment))
+	}
+	if err != nil {
+		return merchantError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toReceiptResponse(payment))
+}
+
+// Receipt returns a merchant payment receipt to the payer or the merchant owner.
+func (h *Handler) Receipt(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	payment, err := h.service.Receipt(c.UserContext(), c.Params("paymentId"), uid)
+	if err != nil {
+		return merchantError(err)
+	}
+	return c.JSON(toReceiptResponse(payment))
+}
+
+const qrImageSize = 512
+
+// QR returns a merchant QR code. Without an amount the code is static; with one it is a
+// dynamic single-purchase code. format=png renders the payload as an image.
+func (h *Handler) QR(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	code, err := h.service.GenerateQR(c.UserContext(), QRInput{
+		MerchantID:      c.Params("merchantId"),
+		RequestorUserID: uid,
+		Amount:          int64(c.QueryInt("amount", 0)),
+		Reference:       c.Query("reference"),
+	})
+	if err != nil {
+		return merchantError(err)
+	}
+	if c.Query("format") == "png" {
+		png, err := qrcode.Encode(code.Payload, qrcode.Medium, qrImageSize)
+		if err != nil {
+			return fiber.NewError(http.StatusInternalServerError, err.Error())
+		}
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.Send(png)
+	}
+	return c.JSON(fiber.Map{
+		"payload":   code.Payload,
+		"dynamic":   code.Dynamic,
+		"amount":    code.Amount,
+		"reference": code.Reference,
+	})
+}
+
+type decodeQRRequest struct {
+	Payload string `json:"payload"`
+}
+
+type previewResponse struct {
+	MerchantID     string `json:"merchant_id"`
+	BusinessName   string `json:"business_name"`
+	ShortCode      string `json:"short_code"`
+	CategoryCode   string `json:"category_code"`
+	City           string `json:"city"`
+	Dynamic        bool   `json:"dynamic"`
+	Amount         int64  `json:"amount,omitempty"`
+	AmountEditable bool   `json:"amount_editable"`
+	Reference      string `json:"reference,omitempty"`
+}
+
+// DecodeQR turns a scanned payload into a payment preview for POST /payments/merchant.
+func (h *Handler) DecodeQR(c *fiber.Ctx) error {
+	var req decodeQRRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	preview, err := h.service.DecodeQR(c.UserContext(), req.Payload)
+	if err != nil {
+		return merchantError(err)
+	}
+	return c.JSON(previewResponse{
+		MerchantID:     preview.MerchantID,
+		BusinessName:   preview.BusinessName,
+		ShortCode:      preview.ShortCode,
+		CategoryCode:   preview.CategoryCode,
+		City:           preview.City,
+		Dynamic:        preview.Dynamic,
+		Amount:         preview.Amount,
+		AmountEditable: preview.Amount == 0,
+		Reference:      preview.Reference,
+	})
+}
+
+type statusRequest struct {
+	Status string `json:"status"`
+}
+
+// AdminSetStatus changes a merchant status (back-office).
+func (h *Handler) AdminSetStatus(c *fiber.Ctx) error {
+	var req statusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	m, err := h.service.SetStatus(c.UserContext(), c.Params("merchantId"), req.Status)
+	if err != nil {
+		return merchantError(err)
+	}
+	return c.JSON(toMerchantResponse(m))
+}
+
+func merchantError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidQR):
+		return fiber.NewError(http.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, ErrMerchantNotFound), errors.Is(err, ErrPaymentNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotOwner), errors.Is(err, ErrNotParty), errors.Is(err, wallet.ErrNotOwner), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return fiber.NewError(http.StatusBadRequest, "insufficient funds")
+	case errors.Is(err, ledger.ErrDuplicateTransaction):
+		return fiber.NewError(http.StatusConflict, "duplicate transaction")
+	case errors.Is(err, ErrMerchantInactive), errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked), errors.Is(err, ledger.ErrAccountRestricted):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
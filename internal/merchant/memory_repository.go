@@ -0,0 +1,121 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+)
+
+type memoryRepository struct {
+	mu        sync.RWMutex
+	merchants map[string]Merchant
+	payments  map[string]Payment
+}
+
+// NewMemoryRepository builds an in-memory merchant store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{merchants: make(map[string]Merchant), payments: make(map[string]Payment)}
+}
+
+func (r *memoryRepository) Create(_ context.Context, m Merchant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.merchants {
+		if existing.ShortCode == m.ShortCode {
+			return errors.New("short code taken")
+		}
+	}
+	r.merchants[m.ID] = m
+	return nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id string) (Merchant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.merchants[id]
+	if !ok {
+		return Merchant{}, ErrMerchantNotFound
+	}
+	return m, nil
+}
+
+func (r *memoryRepository) GetByShortCode(_ context.Context, code string) (Merchant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.merchants {
+		if m.ShortCode == code {
+			return m, nil
+		}
+	}
+	return Merchant{}, ErrMerchantNotFound
+}
+
+func (r *memoryRepository) ListByOwner(_ context.Context, ownerUserID string) ([]Merchant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Merchant
+	for _, m := range r.merchants {
+		if m.OwnerUserID == ownerUserID {
+			out = append(out, m)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (r *memoryRepository) UpdateStatus(_ context.Context, id, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.merchants[id]
+	if !ok {
+		return ErrMerchantNotFound
+	}
+	m.Status = status
+	r.merchants[id] = m
+	return nil
+}
+
+func (r *memoryRepository) CreatePayment(_ context.Context, p Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.payments[p.ID] = p
+	return nil
+}
+
+func (r *memoryRepository) GetPayment(_ context.Context, id string) (Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.payments[id]
+	if !ok {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return p, nil
+}
+
+func (r *memoryRepository) FindPayment(_ context.Context, payerUserID, clientTxID string) (Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.payments {
+		if p.PayerUserID == payerUserID && p.ClientTxID == clientTxID {
+			return p, nil
+		}
+	}
+	return Payment{}, ErrPaymentNotFound
+}
+
+func (r *memoryRepository) ListPayments(_ context.Context, merchantID string, limit int) ([]Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Payment
+	for _, p := range r.payments {
+		if p.MerchantID == merchantID {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
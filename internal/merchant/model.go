@@ -0,0 +1,61 @@
+package merchant
+
+import (
+	"errors"
+	"time"
+)
+
+// Merchant statuses.
+const (
+	StatusActive    = "active"
+	StatusSuspended = "suspended"
+	StatusClosed    = "closed"
+)
+
+// RevenueAccountCode collects merchant discount rate (MDR) fees.
+const RevenueAccountCode = "revenue:mdr"
+
+var (
+	// ErrMerchantNotFound indicates no merchant matches the lookup.
+	ErrMerchantNotFound = errors.New("merchant not found")
+	// ErrMerchantInactive indicates the merchant cannot accept payments.
+	ErrMerchantInactive = errors.New("merchant not accepting payments")
+	// ErrPaymentNotFound indicates no merchant payment matches the lookup.
+	ErrPaymentNotFound = errors.New("merchant payment not found")
+	// ErrNotOwner indicates the caller does not own the merchant account.
+	ErrNotOwner = errors.New("not owner of merchant")
+	// ErrNotParty indicates the caller is neither the payer nor the merchant on a receipt.
+	ErrNotParty = errors.New("not a party to this payment")
+)
+
+// Merchant is a business able to accept wallet payments into a settlement wallet.
+type Merchant struct {
+	ID           string
+	OwnerUserID  string
+	BusinessName string
+	CategoryCode string
+	// City is printed on merchant QR codes (EMVCo tag 60).
+	City               string
+	ShortCode          string
+	SettlementWalletID string
+	MDRBasisPoints     int
+	Status             string
+	CreatedAt          time.Time
+}
+
+// Payment is the receipt of a customer-to-merchant payment, shared by both parties.
+type Payment struct {
+	ID            string
+	MerchantID    string
+	MerchantName  string
+	ShortCode     string
+	PayerUserID   string
+	PayerWalletID string
+	Amount        int64
+	Fee           int64
+	NetAmount     int64
+	Reference     string
+	ClientTxID    string
+	TransactionID string
+	CreatedAt     time.Time
+}
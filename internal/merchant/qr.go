@@ -0,0 +1,166 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/congo-pay/congo_pay/pkg/emvqr"
+)
+
+// QR payload constants for the CongoPay merchant account template.
+const (
+	// QRGloballyUniqueID identifies CongoPay inside EMVCo merchant account template 26.
+	QRGloballyUniqueID = "cg.congopay"
+	qrAccountTag       = "26"
+	qrShortCodeID      = "01"
+	qrCurrencyXAF      = "950"
+	qrCountryCode      = "CG"
+	maxQRReference     = 25
+)
+
+// ErrInvalidQR indicates a scanned payload is not a usable CongoPay merchant code.
+var ErrInvalidQR = errors.New("invalid merchant QR code")
+
+// QRInput requests a merchant QR payload. A positive Amount produces a dynamic code.
+type QRInput struct {
+	MerchantID      string
+	RequestorUserID string
+	Amount          int64
+	Reference       string
+}
+
+// QRCode is a generated merchant QR payload.
+type QRCode struct {
+	Payload   string
+	Dynamic   bool
+	Amount    int64
+	Reference string
+}
+
+// GenerateQR builds a static or dynamic EMVCo payload for a merchant owned by the requestor.
+func (s *Service) GenerateQR(ctx context.Context, input QRInput) (QRCode, error) {
+	m, err := s.repo.Get(ctx, input.MerchantID)
+	if err != nil {
+		return QRCode{}, err
+	}
+	if m.OwnerUserID != input.RequestorUserID {
+		return QRCode{}, ErrNotOwner
+	}
+	if m.Status != StatusActive {
+		return QRCode{}, ErrMerchantInactive
+	}
+	if input.Amount < 0 {
+		return QRCode{}, fmt.Errorf("amount must not be negative")
+	}
+	reference := strings.TrimSpace(input.Reference)
+	if len(reference) > maxQRReference {
+		return QRCode{}, fmt.Errorf("reference must be at most %d characters", maxQRReference)
+	}
+	if reference != "" && input.Amount == 0 {
+		return QRCode{}, fmt.Errorf("a reference requires an amount")
+	}
+
+	p := emvqr.Payload{
+		Dynamic: input.Amount > 0,
+		MerchantAccounts: []emvqr.MerchantAccount{{
+			Tag:              qrAccountTag,
+			GloballyUniqueID: QRGloballyUniqueID,
+			Fields:           []emvqr.Field{{ID: qrShortCodeID, Value: m.ShortCode}},
+		}},
+		MerchantCategoryCode: m.CategoryCode,
+		Currency:             qrCurrencyXAF,
+		CountryCode:          qrCountryCode,
+		MerchantName:         truncate(m.BusinessName, 25),
+		MerchantCity:         truncate(m.City, 15),
+		Additional:           emvqr.AdditionalData{ReferenceLabel: reference},
+	}
+	if input.Amount > 0 {
+		p.Amount = strconv.FormatInt(input.Amount, 10)
+	}
+	payload, err := emvqr.Encode(p)
+	if err != nil {
+		return QRCode{}, err
+	}
+	return QRCode{Payload: payload, Dynamic: p.Dynamic, Amount: input.Amount, Reference: reference}, nil
+}
+
+// Preview is what a payer sees after scanning a merchant QR code, before calling Pay.
+type Preview struct {
+	MerchantID   string
+	BusinessName string
+	ShortCode    string
+	CategoryCode string
+	City         string
+	Dynamic      bool
+	// Amount is fixed for dynamic codes and zero when the payer must enter it.
+	Amount    int64
+	Reference string
+}
+
+// DecodeQR validates a scanned payload and resolves it to an active merchant.
+func (s *Service) DecodeQR(ctx context.Context, payload string) (Preview, error) {
+	p, err := emvqr.Decode(payload)
+	if err != nil {
+		return Preview{}, fmt.Errorf("%w: %v", ErrInvalidQR, err)
+	}
+	account, ok := p.Account(QRGloballyUniqueID)
+	if !ok || account.Value(qrShortCodeID) == "" {
+		return Preview{}, fmt.Errorf("%w: not a CongoPay merchant code", ErrInvalidQR)
+	}
+	if p.Currency != qrCurrencyXAF {
+		return Preview{}, fmt.Errorf("%w: unsupported currency %s", ErrInvalidQR, p.Currency)
+	}
+	amount, err := parseQRAmount(p.Amount)
+	if err != nil {
+		return Preview{}, err
+	}
+
+	m, err := s.repo.GetByShortCode(ctx, account.Value(qrShortCodeID))
+	if err != nil {
+		return Preview{}, err
+	}
+	if m.Status != StatusActive {
+		return Preview{}, ErrMerchantInactive
+	}
+	return Preview{
+		MerchantID:   m.ID,
+		BusinessName: m.BusinessName,
+		ShortCode:    m.ShortCode,
+		CategoryCode: m.CategoryCode,
+		City:         m.City,
+		Dynamic:      p.Dynamic,
+		Amount:       amount,
+		Reference:    p.Additional.ReferenceLabel,
+	}, nil
+}
+
+// parseQRAmount converts an EMVCo decimal amount into whole XAF, which has no minor unit.
+func parseQRAmount(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	whole, frac, _ := strings.Cut(raw, ".")
+	if strings.Trim(frac, "0") != "" {
+		return 0, fmt.Errorf("%w: XAF amounts cannot have decimals", ErrInvalidQR)
+	}
+	amount, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrInvalidQR, raw)
+	}
+	return amount, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// Back off to a rune boundary so multi-byte names are not split.
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return strings.TrimSpace(s[:n])
+}
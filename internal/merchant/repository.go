@@ -0,0 +1,220 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists merchants and their payment receipts.
+type Repository interface {
+	Create(ctx context.Context, m Merchant) error
+	Get(ctx context.Context, id string) (Merchant, error)
+	GetByShortCode(ctx context.Context, code string) (Merchant, error)
+	ListByOwner(ctx context.Context, ownerUserID string) ([]Merchant, error)
+	UpdateStatus(ctx context.Context, id, status string) error
+	CreatePayment(ctx context.Context, p Payment) error
+	GetPayment(ctx context.Context, id string) (Payment, error)
+	// FindPayment returns the payment a payer made with a client transaction ID.
+	FindPayment(ctx context.Context, payerUserID, clientTxID string) (Payment, error)
+	ListPayments(ctx context.Context, merchantID string, limit int) ([]Payment, error)
+}
+
+// PostgresRepository stores merchants in PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed merchant repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const merchantColumns = `id, owner_user_id, business_name, category_code, short_code, settlement_wallet_id, mdr_bps, status, created_at, city`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMerchant(row rowScanner) (Merchant, error) {
+	var (
+		m        Merchant
+		id       uuid.UUID
+		owner    uuid.UUID
+		walletID uuid.UUID
+	)
+	if err := row.Scan(&id, &owner, &m.BusinessName, &m.CategoryCode, &m.ShortCode, &walletID, &m.MDRBasisPoints, &m.Status, &m.CreatedAt, &m.City); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Merchant{}, ErrMerchantNotFound
+		}
+		return Merchant{}, err
+	}
+	m.ID = id.String()
+	m.OwnerUserID = owner.String()
+	m.SettlementWalletID = walletID.String()
+	m.CreatedAt = m.CreatedAt.UTC()
+	return m, nil
+}
+
+// Create inserts a merchant.
+func (r *PostgresRepository) Create(ctx context.Context, m Merchant) error {
+	ids, err := parseUUIDs(m.ID, m.OwnerUserID, m.SettlementWalletID)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `INSERT INTO merchants (`+merchantColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		ids[0], ids[1], m.BusinessName, m.CategoryCode, m.ShortCode, ids[2], m.MDRBasisPoints, m.Status, m.CreatedAt.UTC(), m.City)
+	return err
+}
+
+func parseUUIDs(values ...string) ([]uuid.UUID, error) {
+	out := make([]uuid.UUID, len(values))
+	for i, v := range values {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = id
+	}
+	return out, nil
+}
+
+// Get fetches a merchant by ID.
+func (r *PostgresRepository) Get(ctx context.Context, id string) (Merchant, error) {
+	merchantID, err := uuid.Parse(id)
+	if err != nil {
+		return Merchant{}, ErrMerchantNotFound
+	}
+	return scanMerchant(r.db.QueryRow(ctx, `SELECT `+merchantColumns+` FROM merchants WHERE id = $1`, merchantID))
+}
+
+// GetByShortCode fetches a merchant by its short code.
+func (r *PostgresRepository) GetByShortCode(ctx context.Context, code string) (Merchant, error) {
+	return scanMerchant(r.db.QueryRow(ctx, `SELECT `+merchantColumns+` FROM merchants WHERE short_code = $1`, code))
+}
+
+// ListByOwner returns merchants owned by a user.
+func (r *PostgresRepository) ListByOwner(ctx context.Context, ownerUserID string) ([]Merchant, error) {
+	owner, err := uuid.Parse(ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+merchantColumns+` FROM merchants WHERE owner_user_id = $1 ORDER BY created_at`, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Merchant
+	for rows.Next() {
+		m, err := scanMerchant(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// UpdateStatus sets a merchant status.
+func (r *PostgresRepository) UpdateStatus(ctx context.Context, id, status string) error {
+	merchantID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrMerchantNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE merchants SET status = $1 WHERE id = $2`, status, merchantID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrMerchantNotFound
+	}
+	return nil
+}
+
+const paymentColumns = `p.id, p.merchant_id, m.business_name, m.short_code, p.payer_user_id, p.payer_wallet_id, p.amount, p.fee, p.net_amount, p.reference, p.client_tx_id, p.transaction_id, p.created_at`
+
+func scanPayment(row rowScanner) (Payment, error) {
+	var (
+		p          Payment
+		id         uuid.UUID
+		merchantID uuid.UUID
+		payer      uuid.UUID
+		wallet     uuid.UUID
+		createdAt  time.Time
+	)
+	if err := row.Scan(&id, &merchantID, &p.MerchantName, &p.ShortCode, &payer, &wallet, &p.Amount, &p.Fee, &p.NetAmount, &p.Reference, &p.ClientTxID, &p.TransactionID, &createdAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Payment{}, ErrPaymentNotFound
+		}
+		return Payment{}, err
+	}
+	p.ID = id.String()
+	p.MerchantID = merchantID.String()
+	p.PayerUserID = payer.String()
+	p.PayerWalletID = wallet.String()
+	p.CreatedAt = createdAt.UTC()
+	return p, nil
+}
+
+// CreatePayment stores a merchant payment receipt.
+func (r *PostgresRepository) CreatePayment(ctx context.Context, p Payment) error {
+	ids, err := parseUUIDs(p.ID, p.MerchantID, p.PayerUserID, p.PayerWalletID)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `INSERT INTO merchant_payments
+        (id, merchant_id, payer_user_id, payer_wallet_id, amount, fee, net_amount, reference, client_tx_id, transaction_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		ids[0], ids[1], ids[2], ids[3], p.Amount, p.Fee, p.NetAmount, p.Reference, p.ClientTxID, p.TransactionID, p.CreatedAt.UTC())
+	return err
+}
+
+// GetPayment fetches a payment receipt by ID.
+func (r *PostgresRepository) GetPayment(ctx context.Context, id string) (Payment, error) {
+	paymentID, err := uuid.Parse(id)
+	if err != nil {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return scanPayment(r.db.QueryRow(ctx, `SELECT `+paymentColumns+`
+        FROM merchant_payments p JOIN merchants m ON m.id = p.merchant_id WHERE p.id = $1`, paymentID))
+}
+
+// FindPayment fetches a payer's receipt by their idempotency key.
+func (r *PostgresRepository) FindPayment(ctx context.Context, payerUserID, clientTxID string) (Payment, error) {
+	payer, err := uuid.Parse(payerUserID)
+	if err != nil {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return scanPayment(r.db.QueryRow(ctx, `SELECT `+paymentColumns+`
+        FROM merchant_payments p JOIN merchants m ON m.id = p.merchant_id
+        WHERE p.payer_user_id = $1 AND p.client_tx_id = $2`, payer, clientTxID))
+}
+
+// ListPayments returns the most recent payments received by a merchant.
+func (r *PostgresRepository) ListPayments(ctx context.Context, merchantID string, limit int) ([]Payment, error) {
+	id, err := uuid.Parse(merchantID)
+	if err != nil {
+		return nil, ErrMerchantNotFound
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+paymentColumns+`
+        FROM merchant_payments p JOIN merchants m ON m.id = p.merchant_id
+        WHERE p.merchant_id = $1 ORDER BY p.created_at DESC LIMIT $2`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,355 @@
+package merchant
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const (
+	paymentKind     = "merchant_payment"
+	shortCodeDigits = 6
+	maxMDRBps       = 1_000
+	defaultCity     = "Brazzaville"
+)
+
+var categoryCodePattern = regexp.MustCompile(`^[0-9]{4}$`)
+
+// Service manages merchant onboarding and customer-to-merchant payments.
+type Service struct {
+	repo       Repository
+	ledger     ledger.Ledger
+	wallets    *wallet.Service
+	notifier   notification.Notifier
+	guard      *guard.Guard
+	defaultMDR int
+}
+
+// NewService prepares a merchant service ensuring the MDR revenue account exists.
+func NewService(ctx context.Context, repo Repository, ledgerBackend ledger.Ledger, wallets *wallet.Service, notifier notification.Notifier, outgoing *guard.Guard, defaultMDRBps int) (*Service, error) {
+	if wallets == nil {
+		return nil, fmt.Errorf("wallet service is required")
+	}
+	if defaultMDRBps < 0 || defaultMDRBps > maxMDRBps {
+		return nil, fmt.Errorf("default MDR must be between 0 and %d basis points", maxMDRBps)
+	}
+	if err := ledgerBackend.EnsureAccount(ctx, RevenueAccountCode); err != nil {
+		return nil, err
+	}
+	return &Service{repo: repo, ledger: ledgerBackend, wallets: wallets, notifier: notifier, guard: outgoing, defaultMDR: defaultMDRBps}, nil
+}
+
+// OnboardInput captures data required to register a merchant.
+type OnboardInput struct {
+	OwnerUserID  string
+	BusinessName string
+	// CategoryCode is the ISO 18245 merchant category code (MCC).
+	CategoryCode string
+	// City defaults to Brazzaville and is printed on the merchant's QR codes.
+	City string
+	// SettlementWalletID is optional; a dedicated wallet is created when empty.
+	SettlementWalletID string
+}
+
+// Onboard registers a merchant, assigning a short code and a settlement wallet.
+func (s *Service) Onboard(ctx context.Context, input OnboardInput) (Merchant, error) {
+	name := strings.TrimSpace(input.BusinessName)
+	if name == "" {
+		return Merchant{}, fmt.Errorf("business name is required")
+	}
+	if !categoryCodePattern.MatchString(input.CategoryCode) {
+		return Merchant{}, fmt.Errorf("category code must be a 4-digit MCC")
+	}
+	city := strings.TrimSpace(input.City)
+	if city == "" {
+		city = defaultCity
+	}
+	if len(city) > 15 {
+		return Merchant{}, fmt.Errorf("city must be at most 15 characters")
+	}
+
+	var settlement wallet.Wallet
+	if input.SettlementWalletID != "" {
+		w, err := s.wallets.Get(ctx, input.SettlementWalletID)
+		if err != nil {
+			return Merchant{}, err
+		}
+		if w.OwnerID != input.OwnerUserID {
+			return Merchant{}, wallet.ErrNotOwner
+		}
+		if w.Kind != wallet.KindMain {
+			return Merchant{}, fmt.Errorf("settlement wallet cannot be a pocket")
+		}
+		settlement = w
+	} else {
+		w, err := s.wallets.Create(ctx, wallet.CreateInput{OwnerID: input.OwnerUserID, Name: name + " settlement"})
+		if err != nil {
+			return Merchant{}, err
+		}
+		settlement = w
+	}
+	if err := settlement.CanCredit(); err != nil {
+		return Merchant{}, err
+	}
+
+	code, err := s.newShortCode(ctx)
+	if err != nil {
+		return Merchant{}, err
+	}
+	m := Merchant{
+		ID:                 uuid.NewString(),
+		OwnerUserID:        input.OwnerUserID,
+		BusinessName:       name,
+		CategoryCode:       input.CategoryCode,
+		City:               city,
+		ShortCode:          code,
+		SettlementWalletID: settlement.ID,
+		MDRBasisPoints:     s.defaultMDR,
+		Status:             StatusActive,
+		CreatedAt:          time.Now().UTC(),
+	}
+	if err := s.repo.Create(ctx, m); err != nil {
+		return Merchant{}, err
+	}
+	return m, nil
+}
+
+func (s *Service) newShortCode(ctx context.Context) (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < shortCodeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	for attempt := 0; attempt < 5; attempt++ {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		code := fmt.Sprintf("%0*d", shortCodeDigits, n.Int64())
+		if _, err := s.repo.GetByShortCode(ctx, code); errors.Is(err, ErrMerchantNotFound) {
+			return code, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("could not allocate a merchant short code")
+}
+
+// Get fetches a merchant by ID.
+func (s *Service) Get(ctx context.Context, id string) (Merchant, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// GetByShortCode fetches a merchant by its short code.
+func (s *Service) GetByShortCode(ctx context.Context, code string) (Merchant, error) {
+	return s.repo.GetByShortCode(ctx, strings.TrimSpace(code))
+}
+
+// ListByOwner returns merchants owned by a user.
+func (s *Service) ListByOwner(ctx context.Context, ownerUserID string) ([]Merchant, error) {
+	return s.repo.ListByOwner(ctx, ownerUserID)
+}
+
+// SetStatus changes a merchant status (back-office use).
+func (s *Service) SetStatus(ctx context.Context, id, status string) (Merchant, error) {
+	switch status {
+	case StatusActive, StatusSuspended, StatusClosed:
+	default:
+		return Merchant{}, fmt.Errorf("unknown merchant status %q", status)
+	}
+	m, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Merchant{}, err
+	}
+	if m.Status == StatusClosed {
+		return Merchant{}, fmt.Errorf("closed merchants cannot change status")
+	}
+	if err := s.repo.UpdateStatus(ctx, id, status); err != nil {
+		return Merchant{}, err
+	}
+	m.Status = status
+	return m, nil
+}
+
+// ComputeMDR returns the fee withheld from a merchant payment, rounded half up to the
+// nearest CFA unit.
+func ComputeMDR(amount int64, bps int) int64 {
+	if bps <= 0 || amount <= 0 {
+		return 0
+	}
+	return (amount*int64(bps) + 5_000) / 10_000
+}
+
+// PayInput captures a customer-to-merchant payment. Either MerchantID or ShortCode identifies
+// the merchant; FromWalletID defaults to the payer's primary wallet.
+type PayInput struct {
+	PayerUserID  string
+	FromWalletID string
+	MerchantID   string
+	ShortCode    string
+	Amount       int64
+	Reference    string
+	ClientTxID   string
+	// OTPCode confirms payments of at least the high-value amount.
+	OTPCode string
+}
+
+// Pay debits the customer, credits the merchant settlement wallet net of MDR and the fee to
+// the revenue account in a single ledger transaction, and stores a shared receipt.
+func (s *Service) Pay(ctx context.Context, input PayInput) (Payment, error) {
+	if input.Amount <= 0 {
+		return Payment{}, fmt.Errorf("amount must be positive")
+	}
+	if input.ClientTxID == "" {
+		input.ClientTxID = uuid.NewString()
+	}
+
+	var (
+		m   Merchant
+		err error
+	)
+	switch {
+	case input.MerchantID != "":
+		m, err = s.repo.Get(ctx, input.MerchantID)
+	case input.ShortCode != "":
+		m, err = s.GetByShortCode(ctx, input.ShortCode)
+	default:
+		return Payment{}, fmt.Errorf("merchant_id or short_code is required")
+	}
+	if err != nil {
+		return Payment{}, err
+	}
+
+	if existing, err := s.repo.FindPayment(ctx, input.PayerUserID, input.ClientTxID); err == nil {
+		return existing, ledger.ErrDuplicateTransaction
+	} else if !errors.Is(err, ErrPaymentNotFound) {
+		return Payment{}, err
+	}
+
+	if m.Status != StatusActive {
+		return Payment{}, ErrMerchantInactive
+	}
+
+	var payerWallet wallet.Wallet
+	if input.FromWalletID != "" {
+		payerWallet, err = s.wallets.Get(ctx, input.FromWalletID)
+	} else {
+		payerWallet, err = s.wallets.GetByOwner(ctx, input.PayerUserID)
+	}
+	if err != nil {
+		return Payment{}, err
+	}
+	if payerWallet.OwnerID != input.PayerUserID {
+		return Payment{}, wallet.ErrNotOwner
+	}
+	if payerWallet.ID == m.SettlementWalletID {
+		return Payment{}, fmt.Errorf("cannot pay a merchant from its own settlement wallet")
+	}
+	if err := payerWallet.CanDebit(); err != nil {
+		return Payment{}, err
+	}
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: input.PayerUserID, Op: risk.OpPayment, Amount: input.Amount, OTPCode: input.OTPCode}); err != nil {
+		return Payment{}, err
+	}
+	settlement, err := s.wallets.Get(ctx, m.SettlementWalletID)
+	if err != nil {
+		return Payment{}, err
+	}
+	if err := settlement.CanCredit(); err != nil {
+		return Payment{}, ErrMerchantInactive
+	}
+
+	fee := ComputeMDR(input.Amount, m.MDRBasisPoints)
+	net := input.Amount - fee
+	legs := []ledger.Posting{
+		{AccountCode: payerWallet.AccountCode, Amount: -input.Amount},
+		{AccountCode: settlement.AccountCode, Amount: net},
+	}
+	if fee > 0 {
+		legs = append(legs, ledger.Posting{AccountCode: RevenueAccountCode, Amount: fee})
+	}
+	// Client transaction IDs are only unique per payer, so the ledger key carries the payer too.
+	res, err := s.ledger.Post(ctx, paymentKind, input.PayerUserID+":"+input.ClientTxID, legs)
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		return Payment{}, err
+	}
+
+	payment := Payment{
+		ID:            uuid.NewString(),
+		MerchantID:    m.ID,
+		MerchantName:  m.BusinessName,
+		ShortCode:     m.ShortCode,
+		PayerUserID:   input.PayerUserID,
+		PayerWalletID: payerWallet.ID,
+		Amount:        input.Amount,
+		Fee:           fee,
+		NetAmount:     net,
+		Reference:     strings.TrimSpace(input.Reference),
+		ClientTxID:    input.ClientTxID,
+		TransactionID: res.TransactionID,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := s.repo.CreatePayment(ctx, payment); err != nil {
+		return Payment{}, err
+	}
+
+	if s.notifier != nil {
+		_ = s.notifier.Send(ctx, notification.Message{
+			Kind:        notification.KindMerchantPayment,
+			Destination: m.OwnerUserID,
+			Body:        fmt.Sprintf("%s received %d (fee %d, net %d) ref %s", m.BusinessName, payment.Amount, payment.Fee, payment.NetAmount, payment.Reference),
+		})
+		_ = s.notifier.Send(ctx, notification.Message{
+			Kind:        notification.KindMerchantPayment,
+			Destination: input.PayerUserID,
+			Body:        fmt.Sprintf("You paid %d to %s (%s)", payment.Amount, m.BusinessName, m.ShortCode),
+		})
+	}
+	return payment, nil
+}
+
+// Receipt returns a payment receipt to either the payer or the merchant owner.
+func (s *Service) Receipt(ctx context.Context, paymentID, requestorUserID string) (Payment, error) {
+	p, err := s.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		return Payment{}, err
+	}
+	if p.PayerUserID == requestorUserID {
+		return p, nil
+	}
+	m, err := s.repo.Get(ctx, p.MerchantID)
+	if err != nil {
+		return Payment{}, err
+	}
+	if m.OwnerUserID != requestorUserID {
+		return Payment{}, ErrNotParty
+	}
+	return p, nil
+}
+
+// Payments lists recent payments received by a merchant owned by the requestor.
+func (s *Service) Payments(ctx context.Context, merchantID, requestorUserID string, limit int) ([]Payment, error) {
+	m, err := s.repo.Get(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if m.OwnerUserID != requestorUserID {
+		return nil, ErrNotOwner
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return s.repo.ListPayments(ctx, merchantID, limit)
+}
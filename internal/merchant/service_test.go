@@ -0,0 +1,183 @@
+package merchant
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+type fixture struct {
+	svc     *Service
+	led     ledger.Ledger
+	wallets *wallet.Service
+}
+
+func newFixture(t *testing.T, mdrBps int) fixture {
+	t.Helper()
+	led := ledger.NewInMemory()
+	wallets := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+	svc, err := NewService(context.Background(), NewMemoryRepository(), led, wallets, nil, nil, mdrBps)
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	return fixture{svc: svc, led: led, wallets: wallets}
+}
+
+func TestComputeMDR(t *testing.T) {
+	cases := []struct {
+		amount int64
+		bps    int
+		want   int64
+	}{
+		{10_000, 100, 100},
+		{149, 100, 1},
+		{150, 100, 2},
+		{49, 100, 0},
+		{5_000, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := ComputeMDR(tc.amount, tc.bps); got != tc.want {
+			t.Errorf("ComputeMDR(%d, %d) = %d, want %d", tc.amount, tc.bps, got, tc.want)
+		}
+	}
+}
+
+func TestServicePayMerchant(t *testing.T) {
+	f := newFixture(t, 150)
+	ctx := context.Background()
+
+	ownerID := uuid.NewString()
+	m, err := f.svc.Onboard(ctx, OnboardInput{OwnerUserID: ownerID, BusinessName: "Boutique Mama", CategoryCode: "5411"})
+	if err != nil {
+		t.Fatalf("onboard: %v", err)
+	}
+	if len(m.ShortCode) != shortCodeDigits || m.SettlementWalletID == "" {
+		t.Fatalf("unexpected merchant: %+v", m)
+	}
+
+	payerID := uuid.NewString()
+	payer, _ := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: payerID})
+	ledger.SeedBalance(f.led, payer.AccountCode, 20_000)
+
+	receipt, err := f.svc.Pay(ctx, PayInput{PayerUserID: payerID, ShortCode: m.ShortCode, Amount: 10_000, Reference: "order 42", ClientTxID: "pay-1"})
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	if receipt.Fee != 150 || receipt.NetAmount != 9_850 || receipt.PayerWalletID != payer.ID {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+
+	settlement, _ := f.wallets.Get(ctx, m.SettlementWalletID)
+	assertBalance(t, f.led, payer.AccountCode, 10_000)
+	assertBalance(t, f.led, settlement.AccountCode, 9_850)
+	assertBalance(t, f.led, RevenueAccountCode, 150)
+
+	// A retry returns the original receipt without moving money again.
+	again, err := f.svc.Pay(ctx, PayInput{PayerUserID: payerID, MerchantID: m.ID, Amount: 10_000, ClientTxID: "pay-1"})
+	if !errors.Is(err, ledger.ErrDuplicateTransaction) || again.ID != receipt.ID {
+		t.Fatalf("expected duplicate with original receipt, got %+v, %v", again, err)
+	}
+	assertBalance(t, f.led, payer.AccountCode, 10_000)
+
+	// Another payer reusing the same client_tx_id pays for themselves and sees only their receipt.
+	otherID := uuid.NewString()
+	other, _ := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: otherID})
+	ledger.SeedBalance(f.led, other.AccountCode, 5_000)
+	own, err := f.svc.Pay(ctx, PayInput{PayerUserID: otherID, MerchantID: m.ID, Amount: 2_000, ClientTxID: "pay-1"})
+	if err != nil || own.ID == receipt.ID || own.PayerUserID != otherID {
+		t.Fatalf("expected a separate payment, got %+v, %v", own, err)
+	}
+	assertBalance(t, f.led, other.AccountCode, 3_000)
+
+	// Both parties can read the receipt; nobody else can.
+	if _, err := f.svc.Receipt(ctx, receipt.ID, payerID); err != nil {
+		t.Fatalf("payer receipt: %v", err)
+	}
+	if _, err := f.svc.Receipt(ctx, receipt.ID, ownerID); err != nil {
+		t.Fatalf("merchant receipt: %v", err)
+	}
+	if _, err := f.svc.Receipt(ctx, receipt.ID, uuid.NewString()); !errors.Is(err, ErrNotParty) {
+		t.Fatalf("expected ErrNotParty, got %v", err)
+	}
+}
+
+func TestServicePayRejectsSuspendedMerchant(t *testing.T) {
+	f := newFixture(t, 100)
+	ctx := context.Background()
+
+	m, err := f.svc.Onboard(ctx, OnboardInput{OwnerUserID: uuid.NewString(), BusinessName: "Kiosk", CategoryCode: "5499"})
+	if err != nil {
+		t.Fatalf("onboard: %v", err)
+	}
+	if _, err := f.svc.SetStatus(ctx, m.ID, StatusSuspended); err != nil {
+		t.Fatalf("suspend: %v", err)
+	}
+
+	payerID := uuid.NewString()
+	payer, _ := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: payerID})
+	ledger.SeedBalance(f.led, payer.AccountCode, 5_000)
+
+	if _, err := f.svc.Pay(ctx, PayInput{PayerUserID: payerID, MerchantID: m.ID, Amount: 1_000}); !errors.Is(err, ErrMerchantInactive) {
+		t.Fatalf("expected ErrMerchantInactive, got %v", err)
+	}
+	assertBalance(t, f.led, payer.AccountCode, 5_000)
+}
+
+func assertBalance(t *testing.T, led ledger.Ledger, code string, want int64) {
+	t.Helper()
+	got, err := led.Balance(context.Background(), code)
+	if err != nil {
+		t.Fatalf("balance %s: %v", code, err)
+	}
+	if got != want {
+		t.Fatalf("balance %s = %d, want %d", code, got, want)
+	}
+}
+
+func TestServiceQRRoundTrip(t *testing.T) {
+	f := newFixture(t, 100)
+	ctx := context.Background()
+
+	ownerID := uuid.NewString()
+	m, err := f.svc.Onboard(ctx, OnboardInput{OwnerUserID: ownerID, BusinessName: "Pharmacie du Plateau", CategoryCode: "5912", City: "Pointe-Noire"})
+	if err != nil {
+		t.Fatalf("onboard: %v", err)
+	}
+
+	static, err := f.svc.GenerateQR(ctx, QRInput{MerchantID: m.ID, RequestorUserID: ownerID})
+	if err != nil {
+		t.Fatalf("static qr: %v", err)
+	}
+	preview, err := f.svc.DecodeQR(ctx, static.Payload)
+	if err != nil {
+		t.Fatalf("decode static: %v", err)
+	}
+	if preview.MerchantID != m.ID || preview.Dynamic || preview.Amount != 0 || preview.City != "Pointe-Noire" {
+		t.Fatalf("unexpected static preview: %+v", preview)
+	}
+
+	dynamic, err := f.svc.GenerateQR(ctx, QRInput{MerchantID: m.ID, RequestorUserID: ownerID, Amount: 7_500, Reference: "RX-19"})
+	if err != nil {
+		t.Fatalf("dynamic qr: %v", err)
+	}
+	preview, err = f.svc.DecodeQR(ctx, dynamic.Payload)
+	if err != nil {
+		t.Fatalf("decode dynamic: %v", err)
+	}
+	if !preview.Dynamic || preview.Amount != 7_500 || preview.Reference != "RX-19" {
+		t.Fatalf("unexpected dynamic preview: %+v", preview)
+	}
+
+	if _, err := f.svc.GenerateQR(ctx, QRInput{MerchantID: m.ID, RequestorUserID: uuid.NewString()}); !errors.Is(err, ErrNotOwner) {
+		t.Fatalf("expected ErrNotOwner, got %v", err)
+	}
+	if _, err := f.svc.DecodeQR(ctx, strings.Replace(dynamic.Payload, "54047500", "54047600", 1)); !errors.Is(err, ErrInvalidQR) {
+		t.Fatalf("expected ErrInvalidQR for corrupted payload, got %v", err)
+	}
+}
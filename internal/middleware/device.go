@@ -0,0 +1,27 @@
+package middleware
+
+import (
+    "errors"
+    "net/http"
+
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/identity"
+)
+
+// DeviceCoolingOff guards routes that move money out of the caller's wallet: it rejects
+// requests from a device still in its cooling-off period after a device change. It must
+// run after JWTAuth.
+func DeviceCoolingOff(ids *identity.Service) fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        uid, _ := c.Locals("user_id").(string)
+        dev, _ := c.Locals("device_id").(string)
+        if err := ids.CheckOutgoing(c.UserContext(), uid, dev); err != nil {
+            if errors.Is(err, identity.ErrDeviceCoolingOff) {
+                return fiber.NewError(http.StatusForbidden, err.Error())
+            }
+            return fiber.NewError(http.StatusUnauthorized, err.Error())
+        }
+        return c.Next()
+    }
+}
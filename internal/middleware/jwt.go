@@ -3,23 +3,24 @@ package middleware
 import (
     "net/http"
     "strings"
+    "time"
 
     "github.com/gofiber/fiber/v2"
 
     "github.com/congo-pay/congo_pay/internal/auth"
-    "github.com/congo-pay/congo_pay/internal/config"
     "github.com/congo-pay/congo_pay/internal/identity"
 )
 
-// JWTAuth returns a middleware that validates JWT access tokens and checks token version.
-func JWTAuth(cfg config.Config, repo identity.Repository) fiber.Handler {
+// JWTAuth returns a middleware that validates JWT access tokens and checks token version
+// and that neither the token's device nor its session has been revoked.
+func JWTAuth(tokens *auth.Service, repo identity.Repository) fiber.Handler {
     return func(c *fiber.Ctx) error {
         authz := c.Get(fiber.HeaderAuthorization)
         if !strings.HasPrefix(strings.ToLower(authz), "bearer ") {
             return fiber.NewError(http.StatusUnauthorized, "missing bearer token")
         }
         tokenStr := strings.TrimSpace(authz[len("Bearer "):])
-        claims, err := auth.ParseAndVerifyHS256(tokenStr, []byte(cfg.JWTSecret))
+        claims, err := tokens.VerifyAccess(c.UserContext(), tokenStr)
         if err != nil {
             return fiber.NewError(http.StatusUnauthorized, "invalid token")
         }
@@ -27,13 +28,41 @@ func JWTAuth(cfg config.Config, repo identity.Repository) fiber.Handler {
         verFloat, _ := claims["ver"].(float64)
         ver := int(verFloat)
 
+        dev, _ := claims["dev"].(string)
+        sid, _ := claims["sid"].(string)
+        scope, _ := claims["scope"].(string)
+        jti, _ := claims["jti"].(string)
+        exp, _ := claims["exp"].(float64)
+        var roles []string
+        if list, ok := claims["roles"].([]any); ok {
+            for _, role := range list {
+                if s, ok := role.(string); ok {
+                    roles = append(roles, s)
+                }
+            }
+        }
+
         user, err := repo.FindByID(c.UserContext(), sub)
         if err != nil || user.TokenVersion != ver {
             return fiber.NewError(http.StatusUnauthorized, "token invalidated")
         }
+        device, err := repo.FindDevice(c.UserContext(), sub, dev)
+        if err != nil || !device.Active() {
+            return fiber.NewError(http.StatusUnauthorized, "device revoked")
+        }
+        if err := tokens.CheckSession(c.UserContext(), sub, sid); err != nil {
+            return fiber.NewError(http.StatusUnauthorized, err.Error())
+        }
 
         c.Locals("user_id", sub)
         c.Locals("token_version", ver)
+        c.Locals("device_id", dev)
+        c.Locals("session_id", sid)
+        c.Locals("jti", jti)
+        c.Locals("token_expires_at", time.Unix(int64(exp), 0))
+        // Role changes bump the token version, so the token's scopes are current here.
+        c.Locals("roles", roles)
+        c.Locals("scopes", strings.Fields(scope))
         return c.Next()
     }
 }
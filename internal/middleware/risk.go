@@ -0,0 +1,19 @@
+package middleware
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/risk"
+)
+
+// SIMSwapHold guards withdrawal routes: it rejects them while the caller's account is
+// restricted after a recent SIM swap. It must run after JWTAuth.
+func SIMSwapHold(risks *risk.Service) fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        uid, _ := c.Locals("user_id").(string)
+        if err := risks.CheckOutgoing(c.UserContext(), uid, risk.OpWithdrawal, 0); err != nil {
+            return fiber.NewError(risk.Status(err), err.Error())
+        }
+        return c.Next()
+    }
+}
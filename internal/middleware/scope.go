@@ -0,0 +1,22 @@
+package middleware
+
+import (
+    "net/http"
+    "slices"
+
+    "github.com/gofiber/fiber/v2"
+)
+
+// RequireScope admits callers whose access token carries every one of scopes.
+// It must run after JWTAuth, which stores the token's scopes in Locals("scopes").
+func RequireScope(scopes ...string) fiber.Handler {
+    return func(c *fiber.Ctx) error {
+        granted, _ := c.Locals("scopes").([]string)
+        for _, scope := range scopes {
+            if !slices.Contains(granted, scope) {
+                return fiber.NewError(http.StatusForbidden, "missing scope "+scope)
+            }
+        }
+        return c.Next()
+    }
+}
@@ -8,6 +8,30 @@ import (
 const (
     // KindP2PTransfer indicates a P2P payment event.
     KindP2PTransfer = "p2p_transfer"
+    // KindMerchantPayment indicates a customer-to-merchant payment event.
+    KindMerchantPayment = "merchant_payment"
+    // KindPaymentRequest indicates a request-to-pay event.
+    KindPaymentRequest = "payment_request"
+    // KindStandingOrder indicates a scheduled transfer outcome.
+    KindStandingOrder = "standing_order"
+    // KindEscrow indicates an escrow funding, release, refund or dispute event.
+    KindEscrow = "escrow"
+    // KindSplitPayment indicates a beneficiary's share of a split payment.
+    KindSplitPayment = "split_payment"
+    // KindBillPayment indicates a bill payment receipt.
+    KindBillPayment = "bill_payment"
+    // KindAgentCash indicates an agent cash-in or cash-out receipt.
+    KindAgentCash = "agent_cash"
+    // KindAgentLiquidity indicates an agent float crossed its low or high threshold.
+    KindAgentLiquidity = "agent_liquidity"
+    // KindAgentRebalance indicates a float rebalancing request or its decision.
+    KindAgentRebalance = "agent_rebalance"
+    // KindKYC indicates a KYC review decision or tier change.
+    KindKYC = "kyc"
+    // KindOTP indicates a one-time password sent by SMS; Destination is a phone number.
+    KindOTP = "otp"
+    // KindSecurity indicates an account security event such as a PIN lock.
+    KindSecurity = "security"
 )
 
 // Message describes a notification payload.
@@ -32,11 +56,16 @@ func NewLoggerNotifier(logger *slog.Logger) *LoggerNotifier {
     return &LoggerNotifier{logger: logger}
 }
 
-// Send writes the message to the structured logger.
+// Send writes the message to the structured logger. One-time codes are never logged; in
+// development they can be read back from the SMSStub instead.
 func (n *LoggerNotifier) Send(_ context.Context, message Message) error {
     if n == nil || n.logger == nil {
         return nil
     }
-    n.logger.Info("notification", "kind", message.Kind, "destination", message.Destination, "body", message.Body)
+    body := message.Body
+    if message.Kind == KindOTP {
+        body = "[redacted]"
+    }
+    n.logger.Info("notification", "kind", message.Kind, "destination", message.Destination, "body", body)
     return nil
 }
@@ -0,0 +1,49 @@
+package notification
+
+import (
+    "context"
+    "sync"
+)
+
+// SMSStub stands in for an SMS provider in development. It forwards every message to the
+// next notifier and keeps the last few SMS per phone number so codes can be read back
+// without a real phone.
+type SMSStub struct {
+    next  Notifier
+    limit int
+
+    mu    sync.Mutex
+    inbox map[string][]Message
+}
+
+// NewSMSStub wraps next, keeping up to limit messages per phone number.
+func NewSMSStub(next Notifier, limit int) *SMSStub {
+    if limit <= 0 {
+        limit = 20
+    }
+    return &SMSStub{next: next, limit: limit, inbox: make(map[string][]Message)}
+}
+
+// Send records SMS messages (KindOTP, addressed to a phone number) and forwards everything.
+func (s *SMSStub) Send(ctx context.Context, message Message) error {
+    if message.Kind == KindOTP {
+        s.mu.Lock()
+        box := append(s.inbox[message.Destination], message)
+        if len(box) > s.limit {
+            box = box[len(box)-s.limit:]
+        }
+        s.inbox[message.Destination] = box
+        s.mu.Unlock()
+    }
+    if s.next == nil {
+        return nil
+    }
+    return s.next.Send(ctx, message)
+}
+
+// Messages returns the SMS kept for a phone number, newest last.
+func (s *SMSStub) Messages(phone string) []Message {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]Message(nil), s.inbox[phone]...)
+}
@@ -0,0 +1,88 @@
+package otp
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+)
+
+// Handler exposes endpoints to request codes. Codes are verified by the endpoints that
+// consume them.
+type Handler struct {
+	service *Service
+	users   *identity.Service
+}
+
+// NewHandler builds an OTP HTTP handler.
+func NewHandler(service *Service, users *identity.Service) *Handler {
+	return &Handler{service: service, users: users}
+}
+
+type sendRequest struct {
+	Purpose string `json:"purpose"`
+	Phone   string `json:"phone"`
+}
+
+type sentResponse struct {
+	Purpose   string    `json:"purpose"`
+	Phone     string    `json:"phone"`
+	ExpiresAt time.Time `json:"expires_at"`
+	ResendAt  time.Time `json:"resend_at"`
+}
+
+func toSentResponse(s Sent) sentResponse {
+	return sentResponse{Purpose: s.Purpose, Phone: s.Phone, ExpiresAt: s.ExpiresAt, ResendAt: s.ResendAt}
+}
+
+// Send texts a code for a public purpose (registration, device change, PIN reset) to any
+// phone. Abuse is bounded by the per-phone and per-IP quotas.
+func (h *Handler) Send(c *fiber.Ctx) error {
+	var req sendRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	if !IsPublic(req.Purpose) {
+		return otpError(ErrUnknownPurpose)
+	}
+	sent, err := h.service.Send(c.UserContext(), SendInput{Purpose: req.Purpose, Phone: req.Phone, IP: c.IP()})
+	if err != nil {
+		return otpError(err)
+	}
+	return c.Status(http.StatusAccepted).JSON(toSentResponse(sent))
+}
+
+// SendToMe texts a code to the authenticated user's own phone, e.g. before a high-value
+// transfer.
+func (h *Handler) SendToMe(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req sendRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	user, err := h.users.Get(c.UserContext(), uid)
+	if err != nil {
+		return otpError(err)
+	}
+	sent, err := h.service.Send(c.UserContext(), SendInput{Purpose: req.Purpose, Phone: user.Phone, IP: c.IP()})
+	if err != nil {
+		return otpError(err)
+	}
+	return c.Status(http.StatusAccepted).JSON(toSentResponse(sent))
+}
+
+func otpError(err error) error {
+	switch {
+	case errors.Is(err, identity.ErrUserNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrResendTooSoon), errors.Is(err, ErrQuotaExceeded):
+		return fiber.NewError(http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, ErrInvalidCode):
+		return fiber.NewError(http.StatusUnprocessableEntity, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,88 @@
+package otp
+
+import (
+	"errors"
+	"time"
+)
+
+// Purposes scope a code to one action so a code sent for one cannot be replayed for another.
+const (
+	PurposeRegistration      = "registration"
+	PurposeDeviceChange      = "device_change"
+	PurposePINReset          = "pin_reset"
+	PurposeHighValueTransfer = "high_value_transfer"
+	// PurposeAgentRegistration codes are read out to the agent registering the customer, so
+	// they are only sent by the agent service.
+	PurposeAgentRegistration = "agent_registration"
+)
+
+// publicPurposes may be requested without a session, for any phone number. The others
+// are sent to the authenticated user's own phone.
+var publicPurposes = map[string]bool{
+	PurposeRegistration: true,
+	PurposeDeviceChange: true,
+	PurposePINReset:     true,
+}
+
+var purposes = map[string]bool{
+	PurposeRegistration:      true,
+	PurposeDeviceChange:      true,
+	PurposePINReset:          true,
+	PurposeHighValueTransfer: true,
+	PurposeAgentRegistration: true,
+}
+
+// IsPublic reports whether a purpose can be requested without a session.
+func IsPublic(purpose string) bool {
+	return publicPurposes[purpose]
+}
+
+var (
+	// ErrUnknownPurpose indicates a purpose not listed above.
+	ErrUnknownPurpose = errors.New("unknown OTP purpose")
+	// ErrInvalidCode indicates a wrong, expired, exhausted or already used code.
+	ErrInvalidCode = errors.New("invalid or expired code")
+	// ErrResendTooSoon indicates a new code was requested within the resend cooldown.
+	ErrResendTooSoon = errors.New("code already sent, wait before requesting another")
+	// ErrQuotaExceeded indicates the phone number or client IP requested too many codes.
+	ErrQuotaExceeded = errors.New("too many codes requested, try again later")
+	// ErrChallengeNotFound indicates no code is outstanding for the purpose and phone.
+	ErrChallengeNotFound = errors.New("OTP challenge not found")
+)
+
+// Challenge is an outstanding code for one purpose and phone. Only a keyed hash of the code
+// is stored.
+type Challenge struct {
+	Purpose   string
+	Phone     string
+	CodeHash  string
+	Attempts  int
+	ExpiresAt time.Time
+	SentAt    time.Time
+}
+
+// Policy bounds code lifetime, guessing and sending.
+type Policy struct {
+	Digits         int
+	TTL            time.Duration
+	MaxAttempts    int
+	ResendCooldown time.Duration
+	// PhoneQuota and IPQuota cap the codes sent per QuotaWindow to a phone number and
+	// from a client IP, across purposes.
+	PhoneQuota  int
+	IPQuota     int
+	QuotaWindow time.Duration
+}
+
+// DefaultPolicy returns the production OTP policy.
+func DefaultPolicy() Policy {
+	return Policy{
+		Digits:         6,
+		TTL:            5 * time.Minute,
+		MaxAttempts:    5,
+		ResendCooldown: time.Minute,
+		PhoneQuota:     5,
+		IPQuota:        20,
+		QuotaWindow:    time.Hour,
+	}
+}
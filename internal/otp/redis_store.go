@@ -0,0 +1,106 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps challenges as Redis hashes expiring with the code, so nothing outlives
+// its TTL.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore builds a Redis-backed store.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func redisChallengeKey(purpose, phone string) string {
+	return "otp:challenge:" + challengeKey(purpose, phone)
+}
+
+// Put replaces the challenge and sets its expiry in one transaction.
+func (s *RedisStore) Put(ctx context.Context, c Challenge, ttl time.Duration) error {
+	key := redisChallengeKey(c.Purpose, c.Phone)
+	_, err := s.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Del(ctx, key)
+		p.HSet(ctx, key,
+			"code_hash", c.CodeHash,
+			"attempts", c.Attempts,
+			"expires_at", c.ExpiresAt.Unix(),
+			"sent_at", c.SentAt.Unix(),
+		)
+		p.Expire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+// Get loads a challenge.
+func (s *RedisStore) Get(ctx context.Context, purpose, phone string) (Challenge, error) {
+	vals, err := s.client.HGetAll(ctx, redisChallengeKey(purpose, phone)).Result()
+	if err != nil {
+		return Challenge{}, err
+	}
+	if len(vals) == 0 {
+		return Challenge{}, ErrChallengeNotFound
+	}
+	attempts, _ := strconv.Atoi(vals["attempts"])
+	expiresAt, _ := strconv.ParseInt(vals["expires_at"], 10, 64)
+	sentAt, _ := strconv.ParseInt(vals["sent_at"], 10, 64)
+	return Challenge{
+		Purpose:   purpose,
+		Phone:     phone,
+		CodeHash:  vals["code_hash"],
+		Attempts:  attempts,
+		ExpiresAt: time.Unix(expiresAt, 0).UTC(),
+		SentAt:    time.Unix(sentAt, 0).UTC(),
+	}, nil
+}
+
+// recordAttempt increments attempts only if the challenge still exists, so a challenge that
+// expired meanwhile is not recreated without a TTL.
+var recordAttempt = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return -1
+end
+return redis.call('HINCRBY', KEYS[1], 'attempts', 1)
+`)
+
+// RecordAttempt counts a guess.
+func (s *RedisStore) RecordAttempt(ctx context.Context, purpose, phone string) (int, error) {
+	n, err := recordAttempt.Run(ctx, s.client, []string{redisChallengeKey(purpose, phone)}).Int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, ErrChallengeNotFound
+	}
+	return n, nil
+}
+
+// Delete removes a challenge; DEL reports whether this caller removed it.
+func (s *RedisStore) Delete(ctx context.Context, purpose, phone string) (bool, error) {
+	n, err := s.client.Del(ctx, redisChallengeKey(purpose, phone)).Result()
+	return n == 1, err
+}
+
+// Hit increments a fixed-window quota counter.
+func (s *RedisStore) Hit(ctx context.Context, key string, window time.Duration) (int64, error) {
+	key = "otp:quota:" + key
+	n, err := s.client.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		if err := s.client.Expire(ctx, key, window).Err(); err != nil && !errors.Is(err, redis.Nil) {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,201 @@
+package otp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/notification"
+)
+
+// Service sends and verifies purpose-scoped one-time codes by SMS.
+type Service struct {
+	store    Store
+	notifier notification.Notifier
+	secret   []byte
+	policy   Policy
+	now      func() time.Time
+}
+
+// NewService builds an OTP service. Codes are stored as an HMAC keyed with secret, so a
+// leaked store does not reveal them even though the code space is small.
+func NewService(store Store, notifier notification.Notifier, secret string) *Service {
+	return &Service{
+		store:    store,
+		notifier: notifier,
+		secret:   []byte(secret),
+		policy:   DefaultPolicy(),
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// SendInput asks for a code. IP is the client address used for the per-IP quota; it may be
+// empty for server-initiated sends.
+type SendInput struct {
+	Purpose string
+	Phone   string
+	IP      string
+}
+
+// Sent describes a code that was just sent.
+type Sent struct {
+	Purpose   string
+	Phone     string
+	ExpiresAt time.Time
+	// ResendAt is the earliest time another code can be requested.
+	ResendAt time.Time
+}
+
+// Send generates a code for the purpose and texts it to the phone, replacing any code still
+// outstanding for the same purpose.
+func (s *Service) Send(ctx context.Context, input SendInput) (Sent, error) {
+	if !purposes[input.Purpose] {
+		return Sent{}, ErrUnknownPurpose
+	}
+	phone, err := identity.NormalizePhone(input.Phone)
+	if err != nil {
+		return Sent{}, err
+	}
+	now := s.now()
+	if existing, err := s.store.Get(ctx, input.Purpose, phone); err == nil {
+		if now.Sub(existing.SentAt) < s.policy.ResendCooldown {
+			return Sent{}, ErrResendTooSoon
+		}
+	} else if !errors.Is(err, ErrChallengeNotFound) {
+		return Sent{}, err
+	}
+	if err := s.quota(ctx, "phone:"+phone, s.policy.PhoneQuota); err != nil {
+		return Sent{}, err
+	}
+	if input.IP != "" {
+		if err := s.quota(ctx, "ip:"+input.IP, s.policy.IPQuota); err != nil {
+			return Sent{}, err
+		}
+	}
+
+	code, err := randomCode(s.policy.Digits)
+	if err != nil {
+		return Sent{}, err
+	}
+	c := Challenge{
+		Purpose:   input.Purpose,
+		Phone:     phone,
+		CodeHash:  s.hash(input.Purpose, phone, code),
+		ExpiresAt: now.Add(s.policy.TTL),
+		SentAt:    now,
+	}
+	if err := s.store.Put(ctx, c, s.policy.TTL); err != nil {
+		return Sent{}, err
+	}
+	if s.notifier != nil {
+		msg := notification.Message{Kind: notification.KindOTP, Destination: phone, Body: messageBody(input.Purpose, code, s.policy.TTL)}
+		if err := s.notifier.Send(ctx, msg); err != nil {
+			return Sent{}, fmt.Errorf("send OTP: %w", err)
+		}
+	}
+	return Sent{Purpose: c.Purpose, Phone: phone, ExpiresAt: c.ExpiresAt, ResendAt: now.Add(s.policy.ResendCooldown)}, nil
+}
+
+// Verify checks a code and consumes it. Every guess counts towards MaxAttempts before the
+// code is compared, so concurrent guesses cannot exceed the limit; once it is used up the
+// code is discarded and a new one must be requested.
+func (s *Service) Verify(ctx context.Context, purpose, phone, code string) error {
+	if !purposes[purpose] {
+		return ErrUnknownPurpose
+	}
+	phone, err := identity.NormalizePhone(phone)
+	if err != nil {
+		return ErrInvalidCode
+	}
+	c, err := s.store.Get(ctx, purpose, phone)
+	if errors.Is(err, ErrChallengeNotFound) {
+		return ErrInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+	if !s.now().Before(c.ExpiresAt) {
+		_, _ = s.store.Delete(ctx, purpose, phone)
+		return ErrInvalidCode
+	}
+	attempts, err := s.store.RecordAttempt(ctx, purpose, phone)
+	if errors.Is(err, ErrChallengeNotFound) {
+		return ErrInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+	if attempts > s.policy.MaxAttempts {
+		_, _ = s.store.Delete(ctx, purpose, phone)
+		return ErrInvalidCode
+	}
+	if !hmac.Equal([]byte(c.CodeHash), []byte(s.hash(purpose, phone, code))) {
+		if attempts == s.policy.MaxAttempts {
+			_, _ = s.store.Delete(ctx, purpose, phone)
+		}
+		return ErrInvalidCode
+	}
+	deleted, err := s.store.Delete(ctx, purpose, phone)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		// A concurrent verification consumed the code first.
+		return ErrInvalidCode
+	}
+	return nil
+}
+
+func (s *Service) quota(ctx context.Context, key string, limit int) error {
+	if limit <= 0 {
+		return nil
+	}
+	n, err := s.store.Hit(ctx, key, s.policy.QuotaWindow)
+	if err != nil {
+		return err
+	}
+	if n > int64(limit) {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+func (s *Service) hash(purpose, phone, code string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(purpose + ":" + phone + ":" + strings.TrimSpace(code)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func messageBody(purpose, code string, ttl time.Duration) string {
+	var action string
+	switch purpose {
+	case PurposeRegistration:
+		action = "to open your Congo Pay account"
+	case PurposeDeviceChange:
+		action = "to sign in to Congo Pay on a new phone"
+	case PurposePINReset:
+		action = "to reset your Congo Pay PIN"
+	case PurposeHighValueTransfer:
+		action = "to confirm your Congo Pay transfer"
+	case PurposeAgentRegistration:
+		return fmt.Sprintf("Your code is %s to let an agent open your Congo Pay account. Valid %d minutes. Give it only to the agent registering you in person.", code, int(ttl.Minutes()))
+	}
+	return fmt.Sprintf("Your code is %s %s. Valid %d minutes. Never share it, not even with an agent.", code, action, int(ttl.Minutes()))
+}
+
+func randomCode(n int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+	v, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", n, v.Int64()), nil
+}
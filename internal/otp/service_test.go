@@ -0,0 +1,161 @@
+package otp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/notification"
+)
+
+type outbox struct {
+	sent []notification.Message
+}
+
+func (o *outbox) Send(_ context.Context, msg notification.Message) error {
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+func (o *outbox) lastCode(t *testing.T) string {
+	t.Helper()
+	if len(o.sent) == 0 {
+		t.Fatalf("no code sent")
+	}
+	return strings.Fields(o.sent[len(o.sent)-1].Body)[3]
+}
+
+type fixture struct {
+	svc    *Service
+	outbox *outbox
+	now    *time.Time
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	o := &outbox{}
+	svc := NewService(newMemoryStore(clock), o, "test-secret")
+	svc.now = clock
+	return fixture{svc: svc, outbox: o, now: &now}
+}
+
+func (f fixture) advance(d time.Duration) {
+	*f.now = f.now.Add(d)
+}
+
+func TestServiceSendAndVerify(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	phone := "+242060000001"
+
+	if _, err := f.svc.Send(ctx, SendInput{Purpose: "login", Phone: phone}); !errors.Is(err, ErrUnknownPurpose) {
+		t.Fatalf("expected ErrUnknownPurpose, got %v", err)
+	}
+	sent, err := f.svc.Send(ctx, SendInput{Purpose: PurposeRegistration, Phone: phone, IP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if sent.Phone != phone || f.outbox.sent[0].Destination != phone || f.outbox.sent[0].Kind != notification.KindOTP {
+		t.Fatalf("unexpected send %+v / %+v", sent, f.outbox.sent[0])
+	}
+	code := f.outbox.lastCode(t)
+
+	// Codes are scoped to their purpose.
+	if err := f.svc.Verify(ctx, PurposePINReset, phone, code); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected a registration code to fail for PIN reset, got %v", err)
+	}
+	if err := f.svc.Verify(ctx, PurposeRegistration, phone, code); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := f.svc.Verify(ctx, PurposeRegistration, phone, code); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("a code must only work once, got %v", err)
+	}
+
+	// Expired codes are refused.
+	f.advance(time.Minute)
+	if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposeRegistration, Phone: phone}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	code = f.outbox.lastCode(t)
+	f.advance(DefaultPolicy().TTL)
+	if err := f.svc.Verify(ctx, PurposeRegistration, phone, code); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected an expired code to fail, got %v", err)
+	}
+}
+
+func TestServiceAttemptsAndThrottling(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	phone := "+242060000002"
+	policy := DefaultPolicy()
+
+	if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposeDeviceChange, Phone: phone}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposeDeviceChange, Phone: phone}); !errors.Is(err, ErrResendTooSoon) {
+		t.Fatalf("expected resend cooldown, got %v", err)
+	}
+	code := f.outbox.lastCode(t)
+	wrong := "000000"
+	if wrong == code {
+		wrong = "111111"
+	}
+	for i := 0; i < policy.MaxAttempts; i++ {
+		if err := f.svc.Verify(ctx, PurposeDeviceChange, phone, wrong); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+	if err := f.svc.Verify(ctx, PurposeDeviceChange, phone, code); !errors.Is(err, ErrInvalidCode) {
+		t.Fatalf("expected an exhausted code to fail, got %v", err)
+	}
+
+	// The right code still works as the last allowed guess.
+	other := "+242060000003"
+	if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposeDeviceChange, Phone: other}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	otherCode := f.outbox.lastCode(t)
+	otherWrong := "000000"
+	if otherWrong == otherCode {
+		otherWrong = "111111"
+	}
+	for i := 1; i < policy.MaxAttempts; i++ {
+		if err := f.svc.Verify(ctx, PurposeDeviceChange, other, otherWrong); !errors.Is(err, ErrInvalidCode) {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+	}
+	if err := f.svc.Verify(ctx, PurposeDeviceChange, other, otherCode); err != nil {
+		t.Fatalf("expected the last allowed guess to pass, got %v", err)
+	}
+
+	// The per-phone quota spans purposes.
+	for i := 1; i < policy.PhoneQuota; i++ {
+		f.advance(policy.ResendCooldown)
+		if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposeDeviceChange, Phone: phone}); err != nil {
+			t.Fatalf("send %d: %v", i+1, err)
+		}
+	}
+	if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposePINReset, Phone: phone}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected the phone quota to apply, got %v", err)
+	}
+	f.advance(policy.QuotaWindow)
+	if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposePINReset, Phone: phone}); err != nil {
+		t.Fatalf("send after window: %v", err)
+	}
+
+	// The per-IP quota spans phone numbers.
+	for i := 0; i < policy.IPQuota; i++ {
+		phone := fmt.Sprintf("+2420610%04d", i)
+		if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposeRegistration, Phone: phone, IP: "10.0.0.9"}); err != nil {
+			t.Fatalf("ip send %d: %v", i+1, err)
+		}
+	}
+	if _, err := f.svc.Send(ctx, SendInput{Purpose: PurposeRegistration, Phone: "+242062000000", IP: "10.0.0.9"}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected the IP quota to apply, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package otp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps outstanding challenges and send quotas. Challenges expire on their own after
+// the TTL given to Put.
+type Store interface {
+	// Put replaces the challenge for c.Purpose and c.Phone.
+	Put(ctx context.Context, c Challenge, ttl time.Duration) error
+	Get(ctx context.Context, purpose, phone string) (Challenge, error)
+	// RecordAttempt atomically counts a guess and returns the attempts so far.
+	RecordAttempt(ctx context.Context, purpose, phone string) (int, error)
+	// Delete removes the challenge and reports whether it existed, so concurrent verifications
+	// of the same code cannot both succeed.
+	Delete(ctx context.Context, purpose, phone string) (bool, error)
+	// Hit counts one use of a quota key within window and returns the count.
+	Hit(ctx context.Context, key string, window time.Duration) (int64, error)
+}
+
+type memoryCounter struct {
+	count   int64
+	resetAt time.Time
+}
+
+type memoryStore struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	challenges map[string]Challenge
+	counters   map[string]memoryCounter
+}
+
+// NewMemoryStore returns an in-process store for development and tests.
+func NewMemoryStore() Store {
+	return newMemoryStore(func() time.Time { return time.Now().UTC() })
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{now: now, challenges: make(map[string]Challenge), counters: make(map[string]memoryCounter)}
+}
+
+func challengeKey(purpose, phone string) string {
+	return purpose + ":" + phone
+}
+
+func (s *memoryStore) Put(_ context.Context, c Challenge, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.challenges[challengeKey(c.Purpose, c.Phone)] = c
+	return nil
+}
+
+// Get treats challenges past their expiry as gone, like Redis does with the key TTL.
+func (s *memoryStore) Get(_ context.Context, purpose, phone string) (Challenge, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.challenges[challengeKey(purpose, phone)]
+	if !ok || !s.now().Before(c.ExpiresAt) {
+		return Challenge{}, ErrChallengeNotFound
+	}
+	return c, nil
+}
+
+func (s *memoryStore) RecordAttempt(_ context.Context, purpose, phone string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := challengeKey(purpose, phone)
+	c, ok := s.challenges[key]
+	if !ok {
+		return 0, ErrChallengeNotFound
+	}
+	c.Attempts++
+	s.challenges[key] = c
+	return c.Attempts, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, purpose, phone string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := challengeKey(purpose, phone)
+	_, ok := s.challenges[key]
+	delete(s.challenges, key)
+	return ok, nil
+}
+
+func (s *memoryStore) Hit(_ context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	c := s.counters[key]
+	if !now.Before(c.resetAt) {
+		c = memoryCounter{resetAt: now.Add(window)}
+	}
+	c.count++
+	s.counters[key] = c
+	return c.count, nil
+}
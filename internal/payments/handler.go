@@ -6,7 +6,9 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/congo-pay/congo_pay/internal/guard"
 	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
 )
 
 // Handler exposes payment endpoints.
@@ -24,6 +26,8 @@ type transferRequest struct {
 	ToWalletID   string `json:"to_wallet_id"`
 	Amount       int64  `json:"amount"`
 	ClientTxID   string `json:"client_tx_id"`
+	// OTPCode confirms transfers of at least the high-value amount.
+	OTPCode string `json:"otp_code"`
 }
 
 // P2P processes a wallet-to-wallet transfer.
@@ -34,13 +38,13 @@ func (h *Handler) P2P(c *fiber.Ctx) error {
     }
     uid, _ := c.Locals("user_id").(string)
 
-    res, err := h.service.Transfer(c.UserContext(), TransferInput{
+    res, err := h.service.P2P(c.UserContext(), TransferInput{
         FromWalletID: req.FromWalletID,
         ToWalletID:   req.ToWalletID,
         Amount:       req.Amount,
         ClientTxID:   req.ClientTxID,
         RequestorUserID: uid,
-    })
+    }, req.OTPCode)
     if err != nil {
         switch {
         case errors.Is(err, ledger.ErrInsufficientFunds):
@@ -49,6 +53,10 @@ func (h *Handler) P2P(c *fiber.Ctx) error {
             return fiber.NewError(http.StatusConflict, "duplicate transaction")
         case errors.Is(err, ErrNotOwner):
             return fiber.NewError(http.StatusForbidden, "not owner of source wallet")
+        case errors.Is(err, guard.ErrDenied):
+            return fiber.NewError(http.StatusForbidden, err.Error())
+        case errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked), errors.Is(err, ledger.ErrAccountRestricted):
+            return fiber.NewError(http.StatusConflict, err.Error())
         default:
             return fiber.NewError(http.StatusInternalServerError, err.Error())
         }
@@ -8,8 +8,10 @@ import (
 
     "github.com/google/uuid"
 
+    "github.com/congo-pay/congo_pay/internal/guard"
     "github.com/congo-pay/congo_pay/internal/ledger"
     "github.com/congo-pay/congo_pay/internal/notification"
+    "github.com/congo-pay/congo_pay/internal/risk"
     "github.com/congo-pay/congo_pay/internal/wallet"
 )
 
@@ -18,11 +20,13 @@ type Service struct {
     ledger        ledger.Ledger
     walletService *wallet.Service
     notifier      notification.Notifier
+    guard         *guard.Guard
 }
 
-// NewService constructs a payment service.
-func NewService(ledger ledger.Ledger, walletService *wallet.Service, notifier notification.Notifier) *Service {
-    return &Service{ledger: ledger, walletService: walletService, notifier: notifier}
+// NewService constructs a payment service. outgoing screens customer-initiated P2P
+// transfers and may be nil.
+func NewService(ledger ledger.Ledger, walletService *wallet.Service, notifier notification.Notifier, outgoing *guard.Guard) *Service {
+    return &Service{ledger: ledger, walletService: walletService, notifier: notifier, guard: outgoing}
 }
 
 // TransferInput captures the data needed to move funds between wallets.
@@ -45,6 +49,16 @@ type TransferResult struct {
 // ErrNotOwner indicates the caller does not own the source wallet.
 var ErrNotOwner = errors.New("not owner of source wallet")
 
+// P2P makes a customer's own transfer after screening it, confirming high-value amounts
+// with otpCode. Services that move money on a customer's behalf screen their own flow and
+// call Transfer.
+func (s *Service) P2P(ctx context.Context, input TransferInput, otpCode string) (TransferResult, error) {
+    if err := s.guard.Check(ctx, guard.Outgoing{UserID: input.RequestorUserID, Op: risk.OpP2P, Amount: input.Amount, OTPCode: otpCode}); err != nil {
+        return TransferResult{}, err
+    }
+    return s.Transfer(ctx, input)
+}
+
 // Transfer posts a balanced ledger entry between two wallets.
 func (s *Service) Transfer(ctx context.Context, input TransferInput) (TransferResult, error) {
     if input.Amount <= 0 {
@@ -65,11 +79,18 @@ func (s *Service) Transfer(ctx context.Context, input TransferInput) (TransferRe
     if err != nil {
         return TransferResult{}, err
     }
+    if err := fromWallet.CanDebit(); err != nil {
+        return TransferResult{}, err
+    }
+    if err := toWallet.CanCredit(); err != nil {
+        return TransferResult{}, err
+    }
 
     res, err := s.ledger.Transfer(ctx, fromWallet.AccountCode, toWallet.AccountCode, "p2p", input.ClientTxID, input.Amount)
     if err != nil {
-        if errors.Is(err, ledger.ErrInsufficientFunds) || errors.Is(err, ledger.ErrDuplicateTransaction) {
-            return TransferResult{}, err
+        if errors.Is(err, ledger.ErrDuplicateTransaction) {
+            // The transfer was already made; report it so callers can finish their own records.
+            return TransferResult{TransactionID: res.TransactionID, FromBalance: res.FromBalance, ToBalance: res.ToBalance}, err
         }
         return TransferResult{}, err
     }
@@ -2,12 +2,17 @@ package payments
 
 import (
     "context"
+    "errors"
+    "strings"
     "testing"
 
     "github.com/google/uuid"
 
+    "github.com/congo-pay/congo_pay/internal/guard"
+    "github.com/congo-pay/congo_pay/internal/identity"
     "github.com/congo-pay/congo_pay/internal/ledger"
     "github.com/congo-pay/congo_pay/internal/notification"
+    "github.com/congo-pay/congo_pay/internal/otp"
     "github.com/congo-pay/congo_pay/internal/wallet"
 )
 
@@ -23,9 +28,9 @@ func (n *testNotifier) Send(_ context.Context, msg notification.Message) error {
 func TestTransferSuccess(t *testing.T) {
     led := ledger.NewInMemory()
     repo := wallet.NewMemoryRepository()
-    walletSvc := wallet.NewService(repo, led)
+    walletSvc := wallet.NewService(repo, led, nil)
     notifier := &testNotifier{}
-    svc := NewService(led, walletSvc, notifier)
+    svc := NewService(led, walletSvc, notifier, nil)
 
     ctx := context.Background()
     from, _ := walletSvc.Create(ctx, wallet.CreateInput{OwnerID: uuid.NewString(), Currency: "XAF"})
@@ -50,8 +55,8 @@ func TestTransferSuccess(t *testing.T) {
 func TestTransferInsufficientFunds(t *testing.T) {
     led := ledger.NewInMemory()
     repo := wallet.NewMemoryRepository()
-    walletSvc := wallet.NewService(repo, led)
-    svc := NewService(led, walletSvc, nil)
+    walletSvc := wallet.NewService(repo, led, nil)
+    svc := NewService(led, walletSvc, nil, nil)
 
     ctx := context.Background()
     from, _ := walletSvc.Create(ctx, wallet.CreateInput{OwnerID: uuid.NewString(), Currency: "XAF"})
@@ -61,3 +66,40 @@ func TestTransferInsufficientFunds(t *testing.T) {
         t.Fatalf("expected insufficient funds, got %v", err)
     }
 }
+
+func TestP2PHighValueRequiresOTP(t *testing.T) {
+    ctx := context.Background()
+    led := ledger.NewInMemory()
+    walletSvc := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+    users := identity.NewService(identity.NewMemoryRepository(), nil)
+    texts := &testNotifier{}
+    otps := otp.NewService(otp.NewMemoryStore(), texts, "test-secret")
+    svc := NewService(led, walletSvc, nil, guard.New(nil, otps, users, 5_000))
+
+    sender, err := users.Register(ctx, identity.Credentials{Phone: "+242060000051", PIN: "7305"})
+    if err != nil {
+        t.Fatalf("register: %v", err)
+    }
+    from, _ := walletSvc.Create(ctx, wallet.CreateInput{OwnerID: sender.ID, Currency: "XAF"})
+    to, _ := walletSvc.Create(ctx, wallet.CreateInput{OwnerID: uuid.NewString(), Currency: "XAF"})
+    ledger.SeedBalance(led, from.AccountCode, 20_000)
+
+    input := TransferInput{FromWalletID: from.ID, ToWalletID: to.ID, Amount: 5_000, ClientTxID: "p2p-high-1", RequestorUserID: sender.ID}
+    if _, err := svc.P2P(ctx, input, ""); !errors.Is(err, guard.ErrOTPRequired) || !errors.Is(err, guard.ErrDenied) {
+        t.Fatalf("expected ErrOTPRequired, got %v", err)
+    }
+    if _, err := svc.P2P(ctx, input, "000000"); !errors.Is(err, guard.ErrDenied) {
+        t.Fatalf("expected a wrong code to be denied, got %v", err)
+    }
+
+    if _, err := otps.Send(ctx, otp.SendInput{Purpose: otp.PurposeHighValueTransfer, Phone: sender.Phone}); err != nil {
+        t.Fatalf("send code: %v", err)
+    }
+    code := strings.Fields(texts.last.Body)[3]
+    if _, err := svc.P2P(ctx, input, code); err != nil {
+        t.Fatalf("confirmed transfer: %v", err)
+    }
+    if _, err := svc.P2P(ctx, TransferInput{FromWalletID: from.ID, ToWalletID: to.ID, Amount: 4_999, ClientTxID: "p2p-low-1", RequestorUserID: sender.ID}, ""); err != nil {
+        t.Fatalf("transfer below the threshold: %v", err)
+    }
+}
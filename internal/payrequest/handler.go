@@ -0,0 +1,254 @@
+package payrequest
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/payments"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// Handler exposes payment request HTTP endpoints and the public link page.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds a payment request HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type createRequest struct {
+	ToWalletID       string `json:"to_wallet_id"`
+	PayerPhone       string `json:"payer_phone"`
+	Amount           int64  `json:"amount"`
+	Memo             string `json:"memo"`
+	ExpiresInSeconds int64  `json:"expires_in_seconds"`
+}
+
+type requestResponse struct {
+	ID                string    `json:"id"`
+	Code              string    `json:"code"`
+	LinkURL           string    `json:"link_url"`
+	RequesterUserID   string    `json:"requester_user_id"`
+	RequesterWalletID string    `json:"requester_wallet_id"`
+	PayerUserID       string    `json:"payer_user_id,omitempty"`
+	PayerPhone        string    `json:"payer_phone,omitempty"`
+	Open              bool      `json:"open"`
+	Amount            int64     `json:"amount"`
+	Memo              string    `json:"memo,omitempty"`
+	Status            string    `json:"status"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	PaidByUserID      string    `json:"paid_by_user_id,omitempty"`
+	TransactionID     string    `json:"transaction_id,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+func (h *Handler) toResponse(r PaymentRequest) requestResponse {
+	return requestResponse{
+		ID:                r.ID,
+		Code:              r.Code,
+		LinkURL:           h.service.LinkURL(r),
+		RequesterUserID:   r.RequesterUserID,
+		RequesterWalletID: r.RequesterWalletID,
+		PayerUserID:       r.PayerUserID,
+		PayerPhone:        r.PayerPhone,
+		Open:              r.Open(),
+		Amount:            r.Amount,
+		Memo:              r.Memo,
+		Status:            r.Status,
+		ExpiresAt:         r.ExpiresAt,
+		PaidByUserID:      r.PaidByUserID,
+		TransactionID:     r.TransactionID,
+		CreatedAt:         r.CreatedAt,
+		UpdatedAt:         r.UpdatedAt,
+	}
+}
+
+// Create issues a payment request from the authenticated user.
+func (h *Handler) Create(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req createRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	r, err := h.service.Create(c.UserContext(), CreateInput{
+		RequesterUserID: uid,
+		ToWalletID:      req.ToWalletID,
+		PayerPhone:      req.PayerPhone,
+		Amount:          req.Amount,
+		Memo:            req.Memo,
+		ExpiresIn:       time.Duration(req.ExpiresInSeconds) * time.Second,
+	})
+	if err != nil {
+		return requestError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(h.toResponse(r))
+}
+
+// List returns incoming (default) or outgoing requests for the authenticated user.
+func (h *Handler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var (
+		requests []PaymentRequest
+		err      error
+	)
+	switch c.Query("direction", "incoming") {
+	case "incoming":
+		requests, err = h.service.ListIncoming(c.UserContext(), uid)
+	case "outgoing":
+		requests, err = h.service.ListOutgoing(c.UserContext(), uid)
+	default:
+		return fiber.NewError(http.StatusBadRequest, "direction must be incoming or outgoing")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]requestResponse, 0, len(requests))
+	for _, r := range requests {
+		out = append(out, h.toResponse(r))
+	}
+	return c.JSON(fiber.Map{"payment_requests": out})
+}
+
+// Get returns a single request.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	r, err := h.service.Get(c.UserContext(), c.Params("requestId"), uid)
+	if err != nil {
+		return requestError(err)
+	}
+	return c.JSON(h.toResponse(r))
+}
+
+// GetByCode resolves a shared link code for an authenticated payer.
+func (h *Handler) GetByCode(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	r, err := h.service.GetByCode(c.UserContext(), c.Params("code"))
+	if err != nil {
+		return requestError(err)
+	}
+	if !canView(r, uid) {
+		return requestError(ErrRequestNotFound)
+	}
+	return c.JSON(h.toResponse(r))
+}
+
+type acceptRequest struct {
+	FromWalletID string `json:"from_wallet_id"`
+	OTPCode      string `json:"otp_code"`
+}
+
+// Accept pays a request from the authenticated user's wallet.
+func (h *Handler) Accept(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req acceptRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(http.StatusBadRequest, err.Error())
+		}
+	}
+	r, err := h.service.Accept(c.UserContext(), AcceptInput{
+		RequestID:    c.Params("requestId"),
+		PayerUserID:  uid,
+		FromWalletID: req.FromWalletID,
+		OTPCode:      req.OTPCode,
+	})
+	if err != nil {
+		return requestError(err)
+	}
+	return c.JSON(h.toResponse(r))
+}
+
+// Decline refuses a request addressed to the authenticated user.
+func (h *Handler) Decline(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	r, err := h.service.Decline(c.UserContext(), c.Params("requestId"), uid)
+	if err != nil {
+		return requestError(err)
+	}
+	return c.JSON(h.toResponse(r))
+}
+
+// Cancel withdraws a request created by the authenticated user.
+func (h *Handler) Cancel(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	r, err := h.service.Cancel(c.UserContext(), c.Params("requestId"), uid)
+	if err != nil {
+		return requestError(err)
+	}
+	return c.JSON(h.toResponse(r))
+}
+
+var linkPage = template.Must(template.New("link").Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>CongoPay payment request</title>
+<style>body{font-family:sans-serif;max-width:28rem;margin:2rem auto;padding:0 1rem;text-align:center}.amount{font-size:2rem;font-weight:bold}.status{color:#555}a.button{display:inline-block;margin-top:1rem;padding:.75rem 1.5rem;background:#0a7d3b;color:#fff;border-radius:.5rem;text-decoration:none}</style>
+</head>
+<body>
+<h1>Payment request</h1>
+<p class="amount">{{.Amount}} XAF</p>
+{{if .Memo}}<p>{{.Memo}}</p>{{end}}
+{{if .Pending}}
+<p class="status">Valid until {{.ExpiresAt}}</p>
+<a class="button" href="congopay://payment-requests/{{.Code}}">Pay with CongoPay</a>
+{{else}}
+<p class="status">{{if eq .Status "accepting"}}This request is being paid.{{else}}This request has {{if eq .Status "expired"}}expired{{else}}been {{.Status}}{{end}}.{{end}}</p>
+{{end}}
+</body>
+</html>
+`))
+
+// LinkPage renders the public confirmation page for a shared link. It shows only the amount,
+// memo and status; paying requires opening the app and authenticating.
+func (h *Handler) LinkPage(c *fiber.Ctx) error {
+	r, err := h.service.GetByCode(c.UserContext(), c.Params("code"))
+	if errors.Is(err, ErrRequestNotFound) {
+		return fiber.NewError(http.StatusNotFound, "payment link not found")
+	}
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	var body strings.Builder
+	err = linkPage.Execute(&body, map[string]any{
+		"Amount":    r.Amount,
+		"Memo":      r.Memo,
+		"Code":      r.Code,
+		"Pending":   r.Status == StatusPending,
+		"ExpiresAt": r.ExpiresAt.Format("2006-01-02 15:04 UTC"),
+		"Status":    r.Status,
+	})
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(body.String())
+}
+
+func requestError(err error) error {
+	switch {
+	case errors.Is(err, ErrRequestNotFound), errors.Is(err, ErrPayerNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotAllowed), errors.Is(err, payments.ErrNotOwner), errors.Is(err, wallet.ErrNotOwner), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrNotPending):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return fiber.NewError(http.StatusBadRequest, "insufficient funds")
+	case errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked), errors.Is(err, ledger.ErrAccountRestricted):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
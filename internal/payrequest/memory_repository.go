@@ -0,0 +1,88 @@
+package payrequest
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mu       sync.RWMutex
+	requests map[string]PaymentRequest
+}
+
+// NewMemoryRepository builds an in-memory payment request store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{requests: make(map[string]PaymentRequest)}
+}
+
+func (m *memoryRepository) Create(_ context.Context, r PaymentRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[r.ID] = r
+	return nil
+}
+
+func (m *memoryRepository) Get(_ context.Context, id string) (PaymentRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.requests[id]
+	if !ok {
+		return PaymentRequest{}, ErrRequestNotFound
+	}
+	return r, nil
+}
+
+func (m *memoryRepository) GetByCode(_ context.Context, code string) (PaymentRequest, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, r := range m.requests {
+		if r.Code == code {
+			return r, nil
+		}
+	}
+	return PaymentRequest{}, ErrRequestNotFound
+}
+
+func (m *memoryRepository) ListByRequester(_ context.Context, userID string, limit int) ([]PaymentRequest, error) {
+	return m.list(func(r PaymentRequest) bool { return r.RequesterUserID == userID }, limit), nil
+}
+
+func (m *memoryRepository) ListByPayer(_ context.Context, userID string, limit int) ([]PaymentRequest, error) {
+	return m.list(func(r PaymentRequest) bool { return r.PayerUserID == userID }, limit), nil
+}
+
+func (m *memoryRepository) list(match func(PaymentRequest) bool, limit int) []PaymentRequest {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []PaymentRequest
+	for _, r := range m.requests {
+		if match(r) {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (m *memoryRepository) Transition(_ context.Context, fromStatus string, r PaymentRequest) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.requests[r.ID]
+	if !ok {
+		return ErrRequestNotFound
+	}
+	if current.Status != fromStatus {
+		return ErrNotPending
+	}
+	current.Status = r.Status
+	current.PaidByUserID = r.PaidByUserID
+	current.TransactionID = r.TransactionID
+	current.UpdatedAt = time.Now().UTC()
+	m.requests[r.ID] = current
+	return nil
+}
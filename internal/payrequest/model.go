@@ -0,0 +1,57 @@
+package payrequest
+
+import (
+	"errors"
+	"time"
+)
+
+// Payment request statuses. Only pending requests can change status, except that a request
+// being accepted is held as accepting while the money moves.
+const (
+	StatusPending   = "pending"
+	StatusAccepting = "accepting"
+	StatusPaid      = "paid"
+	StatusDeclined  = "declined"
+	StatusCancelled = "cancelled"
+	StatusExpired   = "expired"
+)
+
+var (
+	// ErrRequestNotFound indicates no payment request matches the lookup.
+	ErrRequestNotFound = errors.New("payment request not found")
+	// ErrNotPending indicates the request was already paid, declined, cancelled or expired.
+	ErrNotPending = errors.New("payment request is no longer pending")
+	// ErrNotAllowed indicates the caller is not the requester or the addressed payer.
+	ErrNotAllowed = errors.New("not allowed on this payment request")
+	// ErrPayerNotFound indicates the payer phone does not belong to a registered user.
+	ErrPayerNotFound = errors.New("payer is not a registered user")
+)
+
+// PaymentRequest asks a payer, or anyone holding the link when PayerUserID is empty, to
+// send Amount to the requester's wallet.
+type PaymentRequest struct {
+	ID                string
+	Code              string
+	RequesterUserID   string
+	RequesterWalletID string
+	PayerUserID       string
+	PayerPhone        string
+	Amount            int64
+	Memo              string
+	Status            string
+	ExpiresAt         time.Time
+	PaidByUserID      string
+	TransactionID     string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// Open reports whether the request can be paid by anyone holding the link.
+func (r PaymentRequest) Open() bool {
+	return r.PayerUserID == ""
+}
+
+// Expired reports whether a pending request has passed its expiry.
+func (r PaymentRequest) Expired(now time.Time) bool {
+	return r.Status == StatusPending && !now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,139 @@
+package payrequest
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists payment requests.
+type Repository interface {
+	Create(ctx context.Context, r PaymentRequest) error
+	Get(ctx context.Context, id string) (PaymentRequest, error)
+	GetByCode(ctx context.Context, code string) (PaymentRequest, error)
+	ListByRequester(ctx context.Context, userID string, limit int) ([]PaymentRequest, error)
+	ListByPayer(ctx context.Context, userID string, limit int) ([]PaymentRequest, error)
+	// Transition moves a request out of fromStatus, storing its status, payer and transaction
+	// fields. It returns ErrNotPending when the stored status no longer matches fromStatus.
+	Transition(ctx context.Context, fromStatus string, r PaymentRequest) error
+}
+
+// PostgresRepository stores payment requests in PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed payment request repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const requestColumns = `id::text, code, requester_user_id::text, requester_wallet_id::text,
+        COALESCE(payer_user_id::text, ''), payer_phone, amount, memo, status, expires_at,
+        COALESCE(paid_by_user_id::text, ''), COALESCE(transaction_id::text, ''), created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRequest(row rowScanner) (PaymentRequest, error) {
+	var r PaymentRequest
+	err := row.Scan(&r.ID, &r.Code, &r.RequesterUserID, &r.RequesterWalletID, &r.PayerUserID, &r.PayerPhone,
+		&r.Amount, &r.Memo, &r.Status, &r.ExpiresAt, &r.PaidByUserID, &r.TransactionID, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PaymentRequest{}, ErrRequestNotFound
+		}
+		return PaymentRequest{}, err
+	}
+	r.ExpiresAt = r.ExpiresAt.UTC()
+	r.CreatedAt = r.CreatedAt.UTC()
+	r.UpdatedAt = r.UpdatedAt.UTC()
+	return r, nil
+}
+
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// Create inserts a payment request.
+func (p *PostgresRepository) Create(ctx context.Context, r PaymentRequest) error {
+	_, err := p.db.Exec(ctx, `INSERT INTO payment_requests
+        (id, code, requester_user_id, requester_wallet_id, payer_user_id, payer_phone, amount, memo, status, expires_at, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		r.ID, r.Code, r.RequesterUserID, r.RequesterWalletID, nullable(r.PayerUserID), r.PayerPhone,
+		r.Amount, r.Memo, r.Status, r.ExpiresAt.UTC(), r.CreatedAt.UTC(), r.UpdatedAt.UTC())
+	return err
+}
+
+// Get fetches a payment request by ID.
+func (p *PostgresRepository) Get(ctx context.Context, id string) (PaymentRequest, error) {
+	requestID, err := uuid.Parse(id)
+	if err != nil {
+		return PaymentRequest{}, ErrRequestNotFound
+	}
+	return scanRequest(p.db.QueryRow(ctx, `SELECT `+requestColumns+` FROM payment_requests WHERE id = $1`, requestID))
+}
+
+// GetByCode fetches a payment request by its link code.
+func (p *PostgresRepository) GetByCode(ctx context.Context, code string) (PaymentRequest, error) {
+	return scanRequest(p.db.QueryRow(ctx, `SELECT `+requestColumns+` FROM payment_requests WHERE code = $1`, code))
+}
+
+// ListByRequester returns the most recent requests created by a user.
+func (p *PostgresRepository) ListByRequester(ctx context.Context, userID string, limit int) ([]PaymentRequest, error) {
+	return p.list(ctx, `requester_user_id = $1`, userID, limit)
+}
+
+// ListByPayer returns the most recent requests addressed to a user.
+func (p *PostgresRepository) ListByPayer(ctx context.Context, userID string, limit int) ([]PaymentRequest, error) {
+	return p.list(ctx, `payer_user_id = $1`, userID, limit)
+}
+
+func (p *PostgresRepository) list(ctx context.Context, where, userID string, limit int) ([]PaymentRequest, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := p.db.Query(ctx, `SELECT `+requestColumns+` FROM payment_requests WHERE `+where+`
+        ORDER BY created_at DESC LIMIT $2`, uid, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []PaymentRequest
+	for rows.Next() {
+		r, err := scanRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// Transition applies a status change guarded by the expected current status.
+func (p *PostgresRepository) Transition(ctx context.Context, fromStatus string, r PaymentRequest) error {
+	requestID, err := uuid.Parse(r.ID)
+	if err != nil {
+		return ErrRequestNotFound
+	}
+	cmd, err := p.db.Exec(ctx, `UPDATE payment_requests
+        SET status = $1, paid_by_user_id = $2, transaction_id = $3, updated_at = $4
+        WHERE id = $5 AND status = $6`,
+		r.Status, nullable(r.PaidByUserID), nullable(r.TransactionID), time.Now().UTC(), requestID, fromStatus)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrNotPending
+	}
+	return nil
+}
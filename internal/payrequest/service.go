@@ -0,0 +1,383 @@
+package payrequest
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/payments"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const (
+	defaultExpiry = 72 * time.Hour
+	maxExpiry     = 30 * 24 * time.Hour
+	maxMemoLength = 140
+	codeLength    = 8
+	codeAlphabet  = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+	listLimit     = 50
+)
+
+// Service manages request-to-pay flows and their shareable links.
+type Service struct {
+	repo     Repository
+	payments *payments.Service
+	wallets  *wallet.Service
+	users    *identity.Service
+	notifier notification.Notifier
+	guard    *guard.Guard
+	baseURL  string
+	now      func() time.Time
+}
+
+// NewService constructs a payment request service. baseURL prefixes shareable links.
+func NewService(repo Repository, paymentSvc *payments.Service, wallets *wallet.Service, users *identity.Service, notifier notification.Notifier, outgoing *guard.Guard, baseURL string) *Service {
+	return &Service{
+		repo:     repo,
+		payments: paymentSvc,
+		wallets:  wallets,
+		users:    users,
+		notifier: notifier,
+		guard:    outgoing,
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// LinkURL returns the shareable confirmation page URL for a request.
+func (s *Service) LinkURL(r PaymentRequest) string {
+	return s.baseURL + "/r/" + r.Code
+}
+
+// CreateInput captures a new payment request. Leaving PayerPhone empty creates an open link
+// that anyone holding it may pay.
+type CreateInput struct {
+	RequesterUserID string
+	// ToWalletID defaults to the requester's primary wallet.
+	ToWalletID string
+	PayerPhone string
+	Amount     int64
+	Memo       string
+	// ExpiresIn defaults to 72 hours and is capped at 30 days.
+	ExpiresIn time.Duration
+}
+
+// Create stores a pending request and notifies the addressed payer.
+func (s *Service) Create(ctx context.Context, input CreateInput) (PaymentRequest, error) {
+	if input.Amount <= 0 {
+		return PaymentRequest{}, fmt.Errorf("amount must be positive")
+	}
+	memo := strings.TrimSpace(input.Memo)
+	if len(memo) > maxMemoLength {
+		return PaymentRequest{}, fmt.Errorf("memo must be at most %d characters", maxMemoLength)
+	}
+	expiresIn := input.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = defaultExpiry
+	}
+	if expiresIn < time.Minute || expiresIn > maxExpiry {
+		return PaymentRequest{}, fmt.Errorf("expiry must be between 1 minute and %s", maxExpiry)
+	}
+
+	var (
+		target wallet.Wallet
+		err    error
+	)
+	if input.ToWalletID != "" {
+		target, err = s.wallets.Get(ctx, input.ToWalletID)
+	} else {
+		target, err = s.wallets.GetByOwner(ctx, input.RequesterUserID)
+	}
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	if target.OwnerID != input.RequesterUserID {
+		return PaymentRequest{}, wallet.ErrNotOwner
+	}
+	if err := target.CanCredit(); err != nil {
+		return PaymentRequest{}, err
+	}
+
+	var payer identity.User
+	if strings.TrimSpace(input.PayerPhone) != "" {
+		payer, err = s.users.LookupByPhone(ctx, input.PayerPhone)
+		if err != nil {
+			return PaymentRequest{}, ErrPayerNotFound
+		}
+		if payer.ID == input.RequesterUserID {
+			return PaymentRequest{}, fmt.Errorf("cannot request money from yourself")
+		}
+	}
+
+	code, err := s.newCode(ctx)
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	now := s.now()
+	r := PaymentRequest{
+		ID:                uuid.NewString(),
+		Code:              code,
+		RequesterUserID:   input.RequesterUserID,
+		RequesterWalletID: target.ID,
+		PayerUserID:       payer.ID,
+		PayerPhone:        payer.Phone,
+		Amount:            input.Amount,
+		Memo:              memo,
+		Status:            StatusPending,
+		ExpiresAt:         now.Add(expiresIn),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.repo.Create(ctx, r); err != nil {
+		return PaymentRequest{}, err
+	}
+
+	if !r.Open() {
+		s.notify(ctx, notification.KindPaymentRequest, r.PayerUserID,
+			fmt.Sprintf("Payment request of %d: %s %s", r.Amount, r.Memo, s.LinkURL(r)))
+	}
+	return r, nil
+}
+
+func (s *Service) newCode(ctx context.Context) (string, error) {
+	limit := big.NewInt(int64(len(codeAlphabet)))
+	for attempt := 0; attempt < 5; attempt++ {
+		var b strings.Builder
+		for i := 0; i < codeLength; i++ {
+			n, err := rand.Int(rand.Reader, limit)
+			if err != nil {
+				return "", err
+			}
+			b.WriteByte(codeAlphabet[n.Int64()])
+		}
+		code := b.String()
+		if _, err := s.repo.GetByCode(ctx, code); errors.Is(err, ErrRequestNotFound) {
+			return code, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("could not allocate a payment link code")
+}
+
+// refresh marks a pending request expired once its deadline has passed.
+func (s *Service) refresh(ctx context.Context, r PaymentRequest) (PaymentRequest, error) {
+	if !r.Expired(s.now()) {
+		return r, nil
+	}
+	expired := r
+	expired.Status = StatusExpired
+	if err := s.repo.Transition(ctx, StatusPending, expired); err != nil {
+		if errors.Is(err, ErrNotPending) {
+			return s.repo.Get(ctx, r.ID)
+		}
+		return PaymentRequest{}, err
+	}
+	return expired, nil
+}
+
+func canView(r PaymentRequest, userID string) bool {
+	return r.Open() || r.RequesterUserID == userID || r.PayerUserID == userID || r.PaidByUserID == userID
+}
+
+// Get returns a request visible to the requester, the addressed payer, or anyone for open links.
+func (s *Service) Get(ctx context.Context, id, userID string) (PaymentRequest, error) {
+	r, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	if !canView(r, userID) {
+		return PaymentRequest{}, ErrRequestNotFound
+	}
+	return s.refresh(ctx, r)
+}
+
+// GetByCode resolves a link code. It is used by the public confirmation page, so callers must
+// only expose non-sensitive fields.
+func (s *Service) GetByCode(ctx context.Context, code string) (PaymentRequest, error) {
+	r, err := s.repo.GetByCode(ctx, strings.TrimSpace(code))
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	return s.refresh(ctx, r)
+}
+
+// ListOutgoing returns requests created by the user.
+func (s *Service) ListOutgoing(ctx context.Context, userID string) ([]PaymentRequest, error) {
+	requests, err := s.repo.ListByRequester(ctx, userID, listLimit)
+	if err != nil {
+		return nil, err
+	}
+	return s.refreshAll(ctx, requests)
+}
+
+// ListIncoming returns requests addressed to the user.
+func (s *Service) ListIncoming(ctx context.Context, userID string) ([]PaymentRequest, error) {
+	requests, err := s.repo.ListByPayer(ctx, userID, listLimit)
+	if err != nil {
+		return nil, err
+	}
+	return s.refreshAll(ctx, requests)
+}
+
+func (s *Service) refreshAll(ctx context.Context, requests []PaymentRequest) ([]PaymentRequest, error) {
+	for i, r := range requests {
+		refreshed, err := s.refresh(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		requests[i] = refreshed
+	}
+	return requests, nil
+}
+
+// AcceptInput captures a payer accepting a request.
+type AcceptInput struct {
+	RequestID   string
+	PayerUserID string
+	// FromWalletID defaults to the payer's primary wallet.
+	FromWalletID string
+	// OTPCode confirms requests of at least the high-value amount.
+	OTPCode string
+}
+
+// Accept pays a pending request through the P2P transfer flow. The request is claimed before
+// any money moves, so a concurrent accept, cancel, decline or expiry either wins outright or
+// finds it no longer pending; the claim is released if the transfer fails. The request ID
+// doubles as the client transaction ID, so a request is never paid twice: a transfer the
+// ledger reports as a duplicate already paid it.
+func (s *Service) Accept(ctx context.Context, input AcceptInput) (PaymentRequest, error) {
+	r, err := s.Get(ctx, input.RequestID, input.PayerUserID)
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	if r.Status != StatusPending {
+		return r, ErrNotPending
+	}
+	if r.RequesterUserID == input.PayerUserID {
+		return PaymentRequest{}, ErrNotAllowed
+	}
+
+	fromWalletID := input.FromWalletID
+	if fromWalletID == "" {
+		w, err := s.wallets.GetByOwner(ctx, input.PayerUserID)
+		if err != nil {
+			return PaymentRequest{}, err
+		}
+		fromWalletID = w.ID
+	}
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: input.PayerUserID, Op: risk.OpP2P, Amount: r.Amount, OTPCode: input.OTPCode}); err != nil {
+		return PaymentRequest{}, err
+	}
+
+	claimed := r
+	claimed.Status = StatusAccepting
+	claimed.PaidByUserID = input.PayerUserID
+	if err := s.repo.Transition(ctx, StatusPending, claimed); err != nil {
+		if errors.Is(err, ErrNotPending) {
+			// Someone else got there first; report the stored outcome.
+			current, getErr := s.repo.Get(ctx, r.ID)
+			if getErr != nil {
+				return PaymentRequest{}, getErr
+			}
+			return current, ErrNotPending
+		}
+		return PaymentRequest{}, err
+	}
+	res, err := s.payments.Transfer(ctx, payments.TransferInput{
+		FromWalletID:    fromWalletID,
+		ToWalletID:      r.RequesterWalletID,
+		Amount:          r.Amount,
+		ClientTxID:      "payreq:" + r.ID,
+		RequestorUserID: input.PayerUserID,
+	})
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		if relErr := s.repo.Transition(ctx, StatusAccepting, r); relErr != nil {
+			return PaymentRequest{}, fmt.Errorf("%w; release payment request %s: %w", err, r.ID, relErr)
+		}
+		return PaymentRequest{}, err
+	}
+
+	// Only the claimant can move an accepting request on. If this update fails the request
+	// stays accepting with the payer recorded, and the ledger holds the transfer under the
+	// request's client transaction ID.
+	paid := claimed
+	paid.Status = StatusPaid
+	paid.TransactionID = res.TransactionID
+	if err := s.repo.Transition(ctx, StatusAccepting, paid); err != nil {
+		return PaymentRequest{}, err
+	}
+	paid.UpdatedAt = s.now()
+	s.notify(ctx, notification.KindPaymentRequest, r.RequesterUserID,
+		fmt.Sprintf("Your payment request of %d was paid", r.Amount))
+	return paid, nil
+}
+
+// Decline lets the addressed payer refuse a pending request.
+func (s *Service) Decline(ctx context.Context, id, payerUserID string) (PaymentRequest, error) {
+	r, err := s.Get(ctx, id, payerUserID)
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	if r.Open() || r.PayerUserID != payerUserID {
+		return PaymentRequest{}, ErrNotAllowed
+	}
+	declined, err := s.transition(ctx, r, StatusDeclined)
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	s.notify(ctx, notification.KindPaymentRequest, r.RequesterUserID,
+		fmt.Sprintf("Your payment request of %d was declined", r.Amount))
+	return declined, nil
+}
+
+// Cancel lets the requester withdraw a pending request.
+func (s *Service) Cancel(ctx context.Context, id, requesterUserID string) (PaymentRequest, error) {
+	r, err := s.Get(ctx, id, requesterUserID)
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	if r.RequesterUserID != requesterUserID {
+		return PaymentRequest{}, ErrNotAllowed
+	}
+	cancelled, err := s.transition(ctx, r, StatusCancelled)
+	if err != nil {
+		return PaymentRequest{}, err
+	}
+	if !r.Open() {
+		s.notify(ctx, notification.KindPaymentRequest, r.PayerUserID,
+			fmt.Sprintf("A payment request of %d was cancelled", r.Amount))
+	}
+	return cancelled, nil
+}
+
+func (s *Service) transition(ctx context.Context, r PaymentRequest, status string) (PaymentRequest, error) {
+	if r.Status != StatusPending {
+		return r, ErrNotPending
+	}
+	next := r
+	next.Status = status
+	if err := s.repo.Transition(ctx, StatusPending, next); err != nil {
+		return PaymentRequest{}, err
+	}
+	next.UpdatedAt = s.now()
+	return next, nil
+}
+
+func (s *Service) notify(ctx context.Context, kind, destination, body string) {
+	if s.notifier == nil || destination == "" {
+		return
+	}
+	_ = s.notifier.Send(ctx, notification.Message{Kind: kind, Destination: destination, Body: body})
+}
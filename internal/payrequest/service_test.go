@@ -0,0 +1,192 @@
+package payrequest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/payments"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+type fixture struct {
+	svc     *Service
+	led     ledger.Ledger
+	wallets *wallet.Service
+	users   *identity.Service
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	led := ledger.NewInMemory()
+	wallets := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	svc := NewService(NewMemoryRepository(), payments.NewService(led, wallets, nil, nil), wallets, users, nil, nil, "https://pay.example/")
+	return fixture{svc: svc, led: led, wallets: wallets, users: users}
+}
+
+func (f fixture) user(t *testing.T, phone string, balance int64) (identity.User, wallet.Wallet) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := f.users.Register(ctx, identity.Credentials{Phone: phone, PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register %s: %v", phone, err)
+	}
+	w, err := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: u.ID})
+	if err != nil {
+		t.Fatalf("wallet: %v", err)
+	}
+	ledger.SeedBalance(f.led, w.AccountCode, balance)
+	return u, w
+}
+
+func TestServiceRequestAccept(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	requester, requesterWallet := f.user(t, "+242060000001", 0)
+	payer, payerWallet := f.user(t, "+242060000002", 10_000)
+
+	r, err := f.svc.Create(ctx, CreateInput{RequesterUserID: requester.ID, PayerPhone: "+242 06 000 0002", Amount: 4_000, Memo: "dinner"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if r.PayerUserID != payer.ID || r.Status != StatusPending || f.svc.LinkURL(r) != "https://pay.example/r/"+r.Code {
+		t.Fatalf("unexpected request: %+v", r)
+	}
+
+	// Only the addressed payer may accept.
+	stranger, _ := f.user(t, "+242060000003", 10_000)
+	if _, err := f.svc.Accept(ctx, AcceptInput{RequestID: r.ID, PayerUserID: stranger.ID}); !errors.Is(err, ErrRequestNotFound) {
+		t.Fatalf("expected stranger to be refused, got %v", err)
+	}
+
+	paid, err := f.svc.Accept(ctx, AcceptInput{RequestID: r.ID, PayerUserID: payer.ID})
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if paid.Status != StatusPaid || paid.PaidByUserID != payer.ID || paid.TransactionID == "" {
+		t.Fatalf("unexpected paid request: %+v", paid)
+	}
+	if bal, _ := f.led.Balance(ctx, requesterWallet.AccountCode); bal != 4_000 {
+		t.Fatalf("requester balance = %d, want 4000", bal)
+	}
+
+	// A second accept neither charges again nor changes the outcome.
+	if _, err := f.svc.Accept(ctx, AcceptInput{RequestID: r.ID, PayerUserID: payer.ID}); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected ErrNotPending, got %v", err)
+	}
+	if bal, _ := f.led.Balance(ctx, payerWallet.AccountCode); bal != 6_000 {
+		t.Fatalf("payer balance = %d, want 6000", bal)
+	}
+	if _, err := f.svc.Cancel(ctx, r.ID, requester.ID); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected cancelling a paid request to fail, got %v", err)
+	}
+}
+
+func TestServiceAcceptReleasesClaimOnFailedTransfer(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	requester, _ := f.user(t, "+242060000031", 0)
+	payer, _ := f.user(t, "+242060000032", 500)
+
+	r, err := f.svc.Create(ctx, CreateInput{RequesterUserID: requester.ID, PayerPhone: "+242060000032", Amount: 2_000})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := f.svc.Accept(ctx, AcceptInput{RequestID: r.ID, PayerUserID: payer.ID}); !errors.Is(err, ledger.ErrInsufficientFunds) {
+		t.Fatalf("expected ErrInsufficientFunds, got %v", err)
+	}
+	current, err := f.svc.Get(ctx, r.ID, requester.ID)
+	if err != nil || current.Status != StatusPending || current.PaidByUserID != "" {
+		t.Fatalf("expected the claim to be released, got %+v, %v", current, err)
+	}
+
+	// A request claimed by an accept in flight can no longer be cancelled.
+	claimed := current
+	claimed.Status = StatusAccepting
+	claimed.PaidByUserID = payer.ID
+	if err := f.svc.repo.Transition(ctx, StatusPending, claimed); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if _, err := f.svc.Cancel(ctx, r.ID, requester.ID); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected cancelling an accepting request to fail, got %v", err)
+	}
+}
+
+func TestServiceAcceptRecordsEarlierTransfer(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	requester, requesterWallet := f.user(t, "+242060000041", 0)
+	payer, payerWallet := f.user(t, "+242060000042", 5_000)
+
+	r, err := f.svc.Create(ctx, CreateInput{RequesterUserID: requester.ID, PayerPhone: "+242060000042", Amount: 3_000})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// An earlier accept moved the money but crashed before recording it.
+	earlier, err := f.svc.payments.Transfer(ctx, payments.TransferInput{
+		FromWalletID: payerWallet.ID, ToWalletID: requesterWallet.ID, Amount: 3_000, ClientTxID: "payreq:" + r.ID,
+	})
+	if err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+
+	paid, err := f.svc.Accept(ctx, AcceptInput{RequestID: r.ID, PayerUserID: payer.ID})
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if paid.Status != StatusPaid || paid.TransactionID != earlier.TransactionID {
+		t.Fatalf("expected the earlier transfer to be recorded, got %+v", paid)
+	}
+	if bal, _ := f.led.Balance(ctx, payerWallet.AccountCode); bal != 2_000 {
+		t.Fatalf("payer balance = %d, want 2000 after a single payment", bal)
+	}
+}
+
+func TestServiceOpenLinkDeclineAndExpiry(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	requester, _ := f.user(t, "+242060000011", 0)
+	payer, _ := f.user(t, "+242060000012", 10_000)
+
+	open, err := f.svc.Create(ctx, CreateInput{RequesterUserID: requester.ID, Amount: 1_500})
+	if err != nil {
+		t.Fatalf("create open: %v", err)
+	}
+	if !open.Open() {
+		t.Fatal("expected open link request")
+	}
+	if _, err := f.svc.Decline(ctx, open.ID, payer.ID); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expected open links to be non-declinable, got %v", err)
+	}
+	if _, err := f.svc.Accept(ctx, AcceptInput{RequestID: open.ID, PayerUserID: requester.ID}); !errors.Is(err, ErrNotAllowed) {
+		t.Fatalf("expected requester to be unable to pay own request, got %v", err)
+	}
+
+	targeted, _ := f.svc.Create(ctx, CreateInput{RequesterUserID: requester.ID, PayerPhone: "+242060000012", Amount: 700})
+	declined, err := f.svc.Decline(ctx, targeted.ID, payer.ID)
+	if err != nil || declined.Status != StatusDeclined {
+		t.Fatalf("decline: %+v, %v", declined, err)
+	}
+
+	f.svc.now = func() time.Time { return time.Now().UTC().Add(73 * time.Hour) }
+	expired, err := f.svc.GetByCode(ctx, open.Code)
+	if err != nil || expired.Status != StatusExpired {
+		t.Fatalf("expected expired open link, got %+v, %v", expired, err)
+	}
+	if _, err := f.svc.Accept(ctx, AcceptInput{RequestID: open.ID, PayerUserID: payer.ID}); !errors.Is(err, ErrNotPending) {
+		t.Fatalf("expected expired request to refuse payment, got %v", err)
+	}
+}
+
+func TestServiceCreateRejectsUnknownPayer(t *testing.T) {
+	f := newFixture(t)
+	requester, _ := f.user(t, "+242060000021", 0)
+	_, err := f.svc.Create(context.Background(), CreateInput{RequesterUserID: requester.ID, PayerPhone: "+242069999999", Amount: 100})
+	if !errors.Is(err, ErrPayerNotFound) {
+		t.Fatalf("expected ErrPayerNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package risk
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+)
+
+// Handler exposes the back-office risk case queue.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds a risk HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type caseResponse struct {
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	Kind            string     `json:"kind"`
+	Status          string     `json:"status"`
+	Detail          string     `json:"detail"`
+	SwappedAt       time.Time  `json:"swapped_at"`
+	RestrictedUntil time.Time  `json:"restricted_until"`
+	Spent           int64      `json:"spent"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+	ClosedBy        string     `json:"closed_by,omitempty"`
+	Resolution      string     `json:"resolution,omitempty"`
+}
+
+func toCaseResponse(c Case) caseResponse {
+	resp := caseResponse{
+		ID:              c.ID,
+		UserID:          c.UserID,
+		Kind:            c.Kind,
+		Status:          c.Status,
+		Detail:          c.Detail,
+		SwappedAt:       c.SwappedAt,
+		RestrictedUntil: c.RestrictedUntil,
+		Spent:           c.Spent,
+		CreatedAt:       c.CreatedAt,
+		ClosedBy:        c.ClosedBy,
+		Resolution:      c.Resolution,
+	}
+	if !c.ClosedAt.IsZero() {
+		closed := c.ClosedAt
+		resp.ClosedAt = &closed
+	}
+	return resp
+}
+
+func toCaseList(cases []Case) []caseResponse {
+	out := make([]caseResponse, 0, len(cases))
+	for _, c := range cases {
+		out = append(out, toCaseResponse(c))
+	}
+	return out
+}
+
+// AdminQueue lists cases in a status (open by default), oldest first (back-office).
+func (h *Handler) AdminQueue(c *fiber.Ctx) error {
+	cases, err := h.service.Cases(c.UserContext(), c.Query("status"), c.QueryInt("limit", 100))
+	if err != nil {
+		return riskError(err)
+	}
+	return c.JSON(fiber.Map{"cases": toCaseList(cases)})
+}
+
+// AdminGet returns a case (back-office).
+func (h *Handler) AdminGet(c *fiber.Ctx) error {
+	rc, err := h.service.Get(c.UserContext(), c.Params("caseId"))
+	if err != nil {
+		return riskError(err)
+	}
+	return c.JSON(toCaseResponse(rc))
+}
+
+// AdminUserCases lists a user's cases, newest first (back-office).
+func (h *Handler) AdminUserCases(c *fiber.Ctx) error {
+	cases, err := h.service.UserCases(c.UserContext(), c.Params("userId"))
+	if err != nil {
+		return riskError(err)
+	}
+	return c.JSON(fiber.Map{"user_id": c.Params("userId"), "cases": toCaseList(cases)})
+}
+
+type closeRequest struct {
+	Resolution string `json:"resolution"`
+}
+
+// AdminClose resolves a case and lifts its restriction (back-office).
+func (h *Handler) AdminClose(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req closeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	rc, err := h.service.Close(c.UserContext(), c.Params("caseId"), "admin:"+uid, req.Resolution)
+	if err != nil {
+		return riskError(err)
+	}
+	return c.JSON(toCaseResponse(rc))
+}
+
+// Status maps a screening error to its HTTP status: 403 while restricted, 401 for an
+// unknown user and 500 otherwise.
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrRestricted):
+		return http.StatusForbidden
+	case errors.Is(err, identity.ErrUserNotFound):
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func riskError(err error) error {
+	switch {
+	case errors.Is(err, ErrCaseNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrCaseClosed):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package risk
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+type memoryRepository struct {
+	mu    sync.RWMutex
+	cases map[string]Case
+}
+
+// NewMemoryRepository builds an in-memory risk case store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{cases: make(map[string]Case)}
+}
+
+func (r *memoryRepository) Create(_ context.Context, c Case) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, other := range r.cases {
+		if other.UserID == c.UserID && other.Kind == c.Kind && other.SwappedAt.Equal(c.SwappedAt) {
+			return false, nil
+		}
+	}
+	r.cases[c.ID] = c
+	return true, nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id string) (Case, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.cases[id]
+	if !ok {
+		return Case{}, ErrCaseNotFound
+	}
+	return c, nil
+}
+
+func (r *memoryRepository) list(match func(Case) bool, newestFirst bool, limit int) []Case {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Case
+	for _, c := range r.cases {
+		if match(c) {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if newestFirst {
+			return out[i].CreatedAt.After(out[j].CreatedAt)
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (r *memoryRepository) ListByUser(_ context.Context, userID string) ([]Case, error) {
+	return r.list(func(c Case) bool { return c.UserID == userID }, true, 0), nil
+}
+
+func (r *memoryRepository) ListByStatus(_ context.Context, status string, limit int) ([]Case, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return r.list(func(c Case) bool { return c.Status == status }, false, limit), nil
+}
+
+func (r *memoryRepository) Close(_ context.Context, c Case) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.cases[c.ID]
+	if !ok {
+		return ErrCaseNotFound
+	}
+	if current.Status != StatusOpen {
+		return ErrCaseClosed
+	}
+	r.cases[c.ID] = c
+	return nil
+}
+
+func (r *memoryRepository) Spend(_ context.Context, id string, amount, limit int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.cases[id]
+	if !ok {
+		return false, ErrCaseNotFound
+	}
+	if c.Spent+amount > limit {
+		return false, nil
+	}
+	c.Spent += amount
+	r.cases[id] = c
+	return true, nil
+}
@@ -0,0 +1,77 @@
+package risk
+
+import (
+	"errors"
+	"time"
+)
+
+// Case kinds.
+const (
+	KindSIMSwap = "sim_swap"
+)
+
+// Case statuses.
+const (
+	StatusOpen   = "open"
+	StatusClosed = "closed"
+)
+
+// Operations screened against an account's risk restrictions.
+const (
+	OpLogin      = "login"
+	OpWithdrawal = "withdrawal"
+	OpP2P        = "p2p"
+	// OpPayment covers other payments to third parties (merchants, billers, splits,
+	// escrows, payouts, standing orders); it is limited like OpP2P.
+	OpPayment = "payment"
+)
+
+var (
+	// ErrCaseNotFound indicates no risk case matches the lookup.
+	ErrCaseNotFound = errors.New("risk case not found")
+	// ErrCaseClosed indicates the case was already closed.
+	ErrCaseClosed = errors.New("risk case is closed")
+	// ErrRestricted indicates the account is restricted and the operation was refused.
+	ErrRestricted = errors.New("account restricted after a recent SIM change")
+)
+
+// Case is a suspected account takeover for a fraud analyst to work. While an open case's
+// restriction runs, withdrawals are refused and P2P transfers and payments share a total
+// cap.
+type Case struct {
+	ID     string
+	UserID string
+	Kind   string
+	Status string
+	Detail string
+	// SwappedAt is when the operator reports the SIM was swapped; one case is opened per swap.
+	SwappedAt       time.Time
+	RestrictedUntil time.Time
+	// Spent is the total of the P2P transfers and payments allowed under the restriction.
+	Spent      int64
+	CreatedAt  time.Time
+	ClosedAt   time.Time
+	ClosedBy   string
+	Resolution string
+}
+
+// Restricts reports whether the case restricts the account at t.
+func (c Case) Restricts(t time.Time) bool {
+	return c.Status == StatusOpen && t.Before(c.RestrictedUntil)
+}
+
+// Policy tunes SIM swap screening.
+type Policy struct {
+	// Window is how recent a swap must be to restrict the account.
+	Window time.Duration
+	// Restriction is how long the account stays restricted once a swap is detected.
+	Restriction time.Duration
+	// P2PLimit is the total of P2P transfers and payments allowed while restricted.
+	P2PLimit int64
+}
+
+// DefaultPolicy restricts accounts for 48 hours after a swap seen within the last 72 hours
+// and lets through up to 10 000 CFA of P2P transfers in total.
+func DefaultPolicy() Policy {
+	return Policy{Window: 72 * time.Hour, Restriction: 48 * time.Hour, P2PLimit: 10_000}
+}
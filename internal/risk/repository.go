@@ -0,0 +1,157 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists risk cases.
+type Repository interface {
+	// Create stores a case, returning false without storing it when the user already has a
+	// case of the same kind for the same swap.
+	Create(ctx context.Context, c Case) (bool, error)
+	Get(ctx context.Context, id string) (Case, error)
+	// ListByUser returns a user's cases, newest first.
+	ListByUser(ctx context.Context, userID string) ([]Case, error)
+	// ListByStatus returns cases in a status, oldest first.
+	ListByStatus(ctx context.Context, status string, limit int) ([]Case, error)
+	// Close records a case's resolution if it is still open, failing with ErrCaseClosed
+	// otherwise.
+	Close(ctx context.Context, c Case) error
+	// Spend adds amount to the case's Spent total unless that would take it above limit,
+	// reporting whether it did.
+	Spend(ctx context.Context, id string, amount, limit int64) (bool, error)
+}
+
+// PostgresRepository implements Repository using PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed risk case repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const caseColumns = `id::text, user_id::text, kind, status, detail, swapped_at, restricted_until, spent, created_at,
+        COALESCE(closed_at, 'epoch'::timestamptz), closed_by, resolution`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanCase(row rowScanner) (Case, error) {
+	var c Case
+	err := row.Scan(&c.ID, &c.UserID, &c.Kind, &c.Status, &c.Detail, &c.SwappedAt, &c.RestrictedUntil, &c.Spent, &c.CreatedAt,
+		&c.ClosedAt, &c.ClosedBy, &c.Resolution)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Case{}, ErrCaseNotFound
+		}
+		return Case{}, err
+	}
+	c.SwappedAt = c.SwappedAt.UTC()
+	c.RestrictedUntil = c.RestrictedUntil.UTC()
+	c.CreatedAt = c.CreatedAt.UTC()
+	if c.ClosedAt.Unix() == 0 {
+		c.ClosedAt = time.Time{}
+	} else {
+		c.ClosedAt = c.ClosedAt.UTC()
+	}
+	return c, nil
+}
+
+func collectCases(rows pgx.Rows, err error) ([]Case, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Case
+	for rows.Next() {
+		c, err := scanCase(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// Create inserts a case; a unique index on (user_id, kind, swapped_at) drops repeats.
+func (r *PostgresRepository) Create(ctx context.Context, c Case) (bool, error) {
+	cmd, err := r.db.Exec(ctx, `INSERT INTO risk_cases
+        (id, user_id, kind, status, detail, swapped_at, restricted_until, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (user_id, kind, swapped_at) DO NOTHING`,
+		c.ID, c.UserID, c.Kind, c.Status, c.Detail, c.SwappedAt.UTC(), c.RestrictedUntil.UTC(), c.CreatedAt.UTC())
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() == 1, nil
+}
+
+// Get fetches a case by ID.
+func (r *PostgresRepository) Get(ctx context.Context, id string) (Case, error) {
+	caseID, err := uuid.Parse(id)
+	if err != nil {
+		return Case{}, ErrCaseNotFound
+	}
+	return scanCase(r.db.QueryRow(ctx, `SELECT `+caseColumns+` FROM risk_cases WHERE id = $1`, caseID))
+}
+
+// ListByUser returns a user's cases, newest first.
+func (r *PostgresRepository) ListByUser(ctx context.Context, userID string) ([]Case, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+caseColumns+` FROM risk_cases
+        WHERE user_id = $1 ORDER BY created_at DESC`, uid)
+	return collectCases(rows, err)
+}
+
+// ListByStatus returns cases in a status, oldest first.
+func (r *PostgresRepository) ListByStatus(ctx context.Context, status string, limit int) ([]Case, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+caseColumns+` FROM risk_cases
+        WHERE status = $1 ORDER BY created_at LIMIT $2`, status, limit)
+	return collectCases(rows, err)
+}
+
+// Close records a case's resolution if it is still open.
+func (r *PostgresRepository) Close(ctx context.Context, c Case) error {
+	caseID, err := uuid.Parse(c.ID)
+	if err != nil {
+		return ErrCaseNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE risk_cases SET status = $1, closed_at = $2, closed_by = $3, resolution = $4
+        WHERE id = $5 AND status = $6`, c.Status, c.ClosedAt.UTC(), c.ClosedBy, c.Resolution, caseID, StatusOpen)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrCaseClosed
+	}
+	return nil
+}
+
+// Spend adds amount to the case's total in one conditional statement, so concurrent
+// payments cannot take it above limit between them.
+func (r *PostgresRepository) Spend(ctx context.Context, id string, amount, limit int64) (bool, error) {
+	caseID, err := uuid.Parse(id)
+	if err != nil {
+		return false, ErrCaseNotFound
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE risk_cases SET spent = spent + $1 WHERE id = $2 AND spent + $1 <= $3`, amount, caseID, limit)
+	if err != nil {
+		return false, err
+	}
+	return cmd.RowsAffected() == 1, nil
+}
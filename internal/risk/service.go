@@ -0,0 +1,222 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/notification"
+)
+
+// Service screens logins and outgoing transfers for SIM swap takeovers. A swap reported by
+// the operator opens a case and restricts the account for a while; every screening
+// decision is logged.
+type Service struct {
+	repo     Repository
+	checker  SIMSwapChecker
+	users    *identity.Service
+	notifier notification.Notifier
+	policy   Policy
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewService builds a risk service.
+func NewService(repo Repository, checker SIMSwapChecker, users *identity.Service, notifier notification.Notifier, policy Policy, logger *slog.Logger) *Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Service{
+		repo:     repo,
+		checker:  checker,
+		users:    users,
+		notifier: notifier,
+		policy:   policy,
+		logger:   logger,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// CheckLogin screens a user who just authenticated. Logins are never refused; the returned
+// case, if any, is the one restricting the account.
+func (s *Service) CheckLogin(ctx context.Context, user identity.User) (Case, error) {
+	return s.screen(ctx, user, OpLogin, 0, false)
+}
+
+// CheckOutgoing screens an outgoing operation (OpWithdrawal, OpP2P or OpPayment) by userID, failing
+// with ErrRestricted when the account's restriction forbids it. An allowed P2P transfer or
+// payment counts towards the restriction's total whether or not it then goes through, so
+// concurrent payments cannot overshoot the cap.
+func (s *Service) CheckOutgoing(ctx context.Context, userID, op string, amount int64) error {
+	return s.check(ctx, userID, op, amount, true)
+}
+
+// CheckPlanned screens an operation set up now and made later, such as a standing order,
+// against what is left of the cap without using it up; each run is screened again.
+func (s *Service) CheckPlanned(ctx context.Context, userID, op string, amount int64) error {
+	return s.check(ctx, userID, op, amount, false)
+}
+
+func (s *Service) check(ctx context.Context, userID, op string, amount int64, spend bool) error {
+	user, err := s.users.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	_, err = s.screen(ctx, user, op, amount, spend)
+	return err
+}
+
+func (s *Service) screen(ctx context.Context, user identity.User, op string, amount int64, spend bool) (Case, error) {
+	// An unreachable operator must not lock everyone out: detection is skipped, but
+	// restrictions already in place still apply.
+	if err := s.detect(ctx, user); err != nil {
+		s.logger.Error("sim swap check failed", slog.String("user_id", user.ID), slog.String("operation", op), slog.String("error", err.Error()))
+	}
+	restriction, err := s.restriction(ctx, user.ID)
+	if err != nil {
+		return Case{}, err
+	}
+
+	allowed, reason := true, "no restriction"
+	if restriction.ID != "" {
+		switch op {
+		case OpWithdrawal:
+			allowed, reason = false, "withdrawals blocked"
+		case OpP2P, OpPayment:
+			within := restriction.Spent+amount <= s.policy.P2PLimit
+			if within && spend {
+				within, err = s.repo.Spend(ctx, restriction.ID, amount, s.policy.P2PLimit)
+				if err != nil {
+					return Case{}, err
+				}
+			}
+			if within {
+				reason = op + " within restricted limit"
+			} else {
+				allowed, reason = false, fmt.Sprintf("%s beyond %d in total blocked", op, s.policy.P2PLimit)
+			}
+		default:
+			reason = "restricted, operation not limited"
+		}
+	}
+	decision := "allow"
+	if !allowed {
+		decision = "deny"
+	}
+	s.logger.Info("risk decision",
+		slog.String("user_id", user.ID),
+		slog.String("operation", op),
+		slog.Int64("amount", amount),
+		slog.String("decision", decision),
+		slog.String("reason", reason),
+		slog.String("case_id", restriction.ID))
+	if !allowed {
+		return restriction, fmt.Errorf("%w until %s: %s", ErrRestricted, restriction.RestrictedUntil.Format(time.RFC3339), reason)
+	}
+	return restriction, nil
+}
+
+// detect asks the operator about the user's SIM and opens a case for a recent swap not
+// seen before. Swaps from before the account existed are the user's own.
+func (s *Service) detect(ctx context.Context, user identity.User) error {
+	swappedAt, err := s.checker.LastSwap(ctx, user.Phone)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if swappedAt.IsZero() || now.Sub(swappedAt) > s.policy.Window || swappedAt.Before(user.CreatedAt) {
+		return nil
+	}
+	c := Case{
+		ID:              uuid.NewString(),
+		UserID:          user.ID,
+		Kind:            KindSIMSwap,
+		Status:          StatusOpen,
+		Detail:          fmt.Sprintf("SIM for %s swapped at %s", user.Phone, swappedAt.Format(time.RFC3339)),
+		SwappedAt:       swappedAt.UTC(),
+		RestrictedUntil: now.Add(s.policy.Restriction),
+		CreatedAt:       now,
+	}
+	created, err := s.repo.Create(ctx, c)
+	if err != nil || !created {
+		return err
+	}
+	s.logger.Warn("risk case opened",
+		slog.String("case_id", c.ID),
+		slog.String("user_id", user.ID),
+		slog.String("kind", c.Kind),
+		slog.Time("swapped_at", c.SwappedAt),
+		slog.Time("restricted_until", c.RestrictedUntil))
+	s.notify(ctx, user.ID, fmt.Sprintf("Your SIM card was recently changed. To protect your account, withdrawals are paused and transfers are limited to %d CFA in total until %s. Contact support if this was not you.",
+		s.policy.P2PLimit, c.RestrictedUntil.Format("02/01 15:04")))
+	return nil
+}
+
+// restriction returns the open case currently restricting the user, or a zero Case.
+func (s *Service) restriction(ctx context.Context, userID string) (Case, error) {
+	cases, err := s.repo.ListByUser(ctx, userID)
+	if err != nil {
+		return Case{}, err
+	}
+	now := s.now()
+	for _, c := range cases {
+		if c.Restricts(now) {
+			return c, nil
+		}
+	}
+	return Case{}, nil
+}
+
+// Get returns a case by ID.
+func (s *Service) Get(ctx context.Context, id string) (Case, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// Cases lists cases in a status, oldest first, so analysts work the queue in order.
+func (s *Service) Cases(ctx context.Context, status string, limit int) ([]Case, error) {
+	if status == "" {
+		status = StatusOpen
+	}
+	return s.repo.ListByStatus(ctx, status, limit)
+}
+
+// UserCases lists a user's cases, newest first.
+func (s *Service) UserCases(ctx context.Context, userID string) ([]Case, error) {
+	return s.repo.ListByUser(ctx, userID)
+}
+
+// Close resolves a case and lifts its restriction. actor is "admin:<id>".
+func (s *Service) Close(ctx context.Context, id, actor, resolution string) (Case, error) {
+	resolution = strings.TrimSpace(resolution)
+	if resolution == "" {
+		return Case{}, fmt.Errorf("resolution is required")
+	}
+	c, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Case{}, err
+	}
+	if c.Status != StatusOpen {
+		return Case{}, ErrCaseClosed
+	}
+	c.Status = StatusClosed
+	c.ClosedAt = s.now()
+	c.ClosedBy = actor
+	c.Resolution = resolution
+	if err := s.repo.Close(ctx, c); err != nil {
+		return Case{}, err
+	}
+	s.logger.Info("risk case closed", slog.String("case_id", c.ID), slog.String("user_id", c.UserID), slog.String("actor", actor))
+	return c, nil
+}
+
+func (s *Service) notify(ctx context.Context, userID, body string) {
+	if s.notifier == nil {
+		return
+	}
+	_ = s.notifier.Send(ctx, notification.Message{Kind: notification.KindSecurity, Destination: userID, Body: body})
+}
@@ -0,0 +1,159 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/notification"
+)
+
+type recordingNotifier struct {
+	sent []notification.Message
+}
+
+func (n *recordingNotifier) Send(_ context.Context, msg notification.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestSIMSwapRestriction(t *testing.T) {
+	ctx := context.Background()
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	swaps := NewStaticSIMSwapChecker()
+	notifier := &recordingNotifier{}
+	svc := NewService(NewMemoryRepository(), swaps, users, notifier, DefaultPolicy(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	now := time.Now().UTC().Add(time.Hour)
+	svc.now = func() time.Time { return now }
+
+	user, err := users.Register(ctx, identity.Credentials{Phone: "+242060000041", PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := svc.CheckOutgoing(ctx, user.ID, OpWithdrawal, 0); err != nil {
+		t.Fatalf("withdrawal without swap: %v", err)
+	}
+
+	swaps.Set("+242060000041", now.Add(-30*time.Minute))
+	restriction, err := svc.CheckLogin(ctx, user)
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if restriction.Kind != KindSIMSwap || !restriction.RestrictedUntil.Equal(now.Add(48*time.Hour)) {
+		t.Fatalf("expected a 48h sim swap restriction, got %+v", restriction)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Kind != notification.KindSecurity {
+		t.Fatalf("expected one security notice, got %+v", notifier.sent)
+	}
+	if _, err := svc.CheckLogin(ctx, user); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if cases, _ := svc.UserCases(ctx, user.ID); len(cases) != 1 {
+		t.Fatalf("expected one case per swap, got %d", len(cases))
+	}
+
+	if err := svc.CheckOutgoing(ctx, user.ID, OpWithdrawal, 0); !errors.Is(err, ErrRestricted) {
+		t.Fatalf("expected withdrawal to be restricted, got %v", err)
+	}
+	if err := svc.CheckOutgoing(ctx, user.ID, OpP2P, 10_001); !errors.Is(err, ErrRestricted) {
+		t.Fatalf("expected large p2p to be restricted, got %v", err)
+	}
+	// The limit is a total for the whole restriction, shared by transfers and payments.
+	if err := svc.CheckPlanned(ctx, user.ID, OpPayment, 10_000); err != nil {
+		t.Fatalf("planned payment while restricted: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := svc.CheckOutgoing(ctx, user.ID, OpP2P, 4_000); err != nil {
+			t.Fatalf("small p2p %d while restricted: %v", i+1, err)
+		}
+	}
+	if err := svc.CheckOutgoing(ctx, user.ID, OpPayment, 4_000); !errors.Is(err, ErrRestricted) {
+		t.Fatalf("expected a payment beyond the total to be restricted, got %v", err)
+	}
+	if err := svc.CheckPlanned(ctx, user.ID, OpPayment, 4_000); !errors.Is(err, ErrRestricted) {
+		t.Fatalf("expected a planned payment beyond the total to be restricted, got %v", err)
+	}
+	if err := svc.CheckOutgoing(ctx, user.ID, OpPayment, 2_000); err != nil {
+		t.Fatalf("payment up to the total: %v", err)
+	}
+	if c, _ := svc.Get(ctx, restriction.ID); c.Spent != 10_000 {
+		t.Fatalf("expected 10000 spent, got %d", c.Spent)
+	}
+
+	now = now.Add(48 * time.Hour)
+	if err := svc.CheckOutgoing(ctx, user.ID, OpWithdrawal, 0); err != nil {
+		t.Fatalf("withdrawal after the restriction: %v", err)
+	}
+
+	// A swap outside the window is history, not a takeover.
+	other, err := users.Register(ctx, identity.Credentials{Phone: "+242060000042", PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	swaps.Set(other.Phone, now.Add(-73*time.Hour))
+	if err := svc.CheckOutgoing(ctx, other.ID, OpWithdrawal, 0); err != nil {
+		t.Fatalf("withdrawal after an old swap: %v", err)
+	}
+
+	// Closing the case lifts the restriction early.
+	swaps.Set(other.Phone, now.Add(-time.Hour))
+	if err := svc.CheckOutgoing(ctx, other.ID, OpWithdrawal, 0); !errors.Is(err, ErrRestricted) {
+		t.Fatalf("expected withdrawal to be restricted, got %v", err)
+	}
+	open, err := svc.Cases(ctx, "", 0)
+	if err != nil || len(open) != 2 {
+		t.Fatalf("expected two open cases, got %d (%v)", len(open), err)
+	}
+	if _, err := svc.Close(ctx, open[1].ID, "admin:1", ""); err == nil {
+		t.Fatal("expected a resolution to be required")
+	}
+	closed, err := svc.Close(ctx, open[1].ID, "admin:1", "customer confirmed the new SIM in branch")
+	if err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if closed.Status != StatusClosed || closed.ClosedBy != "admin:1" {
+		t.Fatalf("unexpected closed case %+v", closed)
+	}
+	if _, err := svc.Close(ctx, closed.ID, "admin:1", "again"); !errors.Is(err, ErrCaseClosed) {
+		t.Fatalf("expected ErrCaseClosed, got %v", err)
+	}
+	if err := svc.CheckOutgoing(ctx, other.ID, OpWithdrawal, 0); err != nil {
+		t.Fatalf("withdrawal after closing the case: %v", err)
+	}
+}
+
+func TestFileSIMSwapChecker(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "swaps.json")
+	if err := os.WriteFile(path, []byte(`{"swaps": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	checker, err := NewFileSIMSwapChecker(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if at, err := checker.LastSwap(ctx, "+242060000043"); err != nil || !at.IsZero() {
+		t.Fatalf("expected no swap, got %v (%v)", at, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"swaps": [{"phone": "+242 06 000 0043", "swapped_at": "2026-10-18T08:00:00Z"}]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	at, err := checker.LastSwap(ctx, "+242060000043")
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if want := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC); !at.Equal(want) {
+		t.Fatalf("expected swap at %v, got %v", want, at)
+	}
+}
@@ -0,0 +1,130 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+)
+
+// SIMSwapChecker asks the mobile network operator when a phone number's SIM card was last
+// replaced. It returns the zero time when no swap is known.
+type SIMSwapChecker interface {
+	LastSwap(ctx context.Context, phone string) (time.Time, error)
+}
+
+// StaticSIMSwapChecker answers from an in-memory list of swaps. Without entries it is the
+// stub used when no operator integration is configured.
+type StaticSIMSwapChecker struct {
+	mu    sync.RWMutex
+	swaps map[string]time.Time
+}
+
+// NewStaticSIMSwapChecker builds a checker that knows no swaps.
+func NewStaticSIMSwapChecker() *StaticSIMSwapChecker {
+	return &StaticSIMSwapChecker{swaps: make(map[string]time.Time)}
+}
+
+// Set records a swap of phone at t.
+func (c *StaticSIMSwapChecker) Set(phone string, t time.Time) {
+	if p, err := identity.NormalizePhone(phone); err == nil {
+		phone = p
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.swaps[phone] = t.UTC()
+}
+
+// LastSwap returns the recorded swap for phone.
+func (c *StaticSIMSwapChecker) LastSwap(_ context.Context, phone string) (time.Time, error) {
+	if p, err := identity.NormalizePhone(phone); err == nil {
+		phone = p
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.swaps[phone], nil
+}
+
+// FileSIMSwapChecker reads swaps from a JSON file of the form
+// {"swaps": [{"phone": "+242060000001", "swapped_at": "2026-10-18T08:00:00Z"}]}. The file
+// is reloaded whenever it changes, so swaps can be simulated without a restart.
+type FileSIMSwapChecker struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	static  *StaticSIMSwapChecker
+}
+
+// NewFileSIMSwapChecker builds a checker over the file at path and loads it once.
+func NewFileSIMSwapChecker(path string) (*FileSIMSwapChecker, error) {
+	c := &FileSIMSwapChecker{path: path}
+	if _, err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LastSwap returns the swap listed for phone, reloading the file first if it changed.
+func (c *FileSIMSwapChecker) LastSwap(ctx context.Context, phone string) (time.Time, error) {
+	static, err := c.load()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return static.LastSwap(ctx, phone)
+}
+
+func (c *FileSIMSwapChecker) load() (*StaticSIMSwapChecker, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return nil, err
+	}
+	if c.static != nil && info.ModTime().Equal(c.modTime) {
+		return c.static, nil
+	}
+	f, err := os.Open(c.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	static, err := ParseSIMSwaps(f)
+	if err != nil {
+		return nil, err
+	}
+	c.static, c.modTime = static, info.ModTime()
+	return static, nil
+}
+
+// ParseSIMSwaps decodes a JSON swap list into a static checker.
+func ParseSIMSwaps(r io.Reader) (*StaticSIMSwapChecker, error) {
+	var file struct {
+		Swaps []struct {
+			Phone     string    `json:"phone"`
+			SwappedAt time.Time `json:"swapped_at"`
+		} `json:"swaps"`
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("sim swap file: %w", err)
+	}
+	c := NewStaticSIMSwapChecker()
+	for _, s := range file.Swaps {
+		c.Set(s.Phone, s.SwappedAt)
+	}
+	return c, nil
+}
+
+// LoadSIMSwapChecker returns a checker over the file at path, or a stub that knows no
+// swaps when path is empty.
+func LoadSIMSwapChecker(path string) (SIMSwapChecker, error) {
+	if path == "" {
+		return NewStaticSIMSwapChecker(), nil
+	}
+	return NewFileSIMSwapChecker(path)
+}
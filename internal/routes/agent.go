@@ -0,0 +1,47 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/agent"
+    "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/middleware"
+)
+
+// RegisterAgentRoutes wires agent onboarding, assisted customer registration, cash-in/cash-out
+// and float rebalancing endpoints.
+func RegisterAgentRoutes(r fiber.Router, h *agent.Handler, outgoing, withdrawal fiber.Handler) {
+    r.Post("/agents", h.Onboard)
+    r.Get("/agents", h.List)
+    r.Get("/agents/by-code/:agentCode", h.Lookup)
+    r.Get("/agents/:agentId", h.Get)
+    r.Post("/agents/:agentId/cash-in", h.CashIn)
+    r.Post("/agents/:agentId/cash-out", h.CashOut)
+    r.Get("/agents/:agentId/transactions", h.Transactions)
+    r.Get("/agents/:agentId/statements/:date", h.Statement)
+    r.Put("/agents/:agentId/float-thresholds", h.SetThresholds)
+    r.Post("/agents/:agentId/registrations", h.StartRegistration)
+    r.Get("/agents/:agentId/registrations", h.Registrations)
+    r.Post("/agents/:agentId/registrations/:registrationId/confirm", h.ConfirmRegistration)
+    r.Post("/agents/:agentId/customers/pin-unlock", h.UnlockCustomerPIN)
+    r.Post("/agents/:agentId/rebalance-requests", h.RequestRebalance)
+    r.Get("/agents/:agentId/rebalance-requests", h.Rebalances)
+    r.Get("/rebalance-requests/:requestId", h.GetRebalance)
+    r.Post("/rebalance-requests/:requestId/approve", h.ApproveRebalance)
+    r.Post("/rebalance-requests/:requestId/reject", h.RejectRebalance)
+    r.Post("/rebalance-requests/:requestId/cancel", h.CancelRebalance)
+    r.Post("/cash/withdrawal-codes", outgoing, withdrawal, h.IssueWithdrawalCode)
+    r.Get("/cash/transactions", h.CustomerTransactions)
+    r.Get("/cash/transactions/:transactionId", h.Receipt)
+}
+
+// RegisterAgentAdminRoutes wires back-office agent endpoints.
+func RegisterAgentAdminRoutes(r fiber.Router, h *agent.Handler) {
+    guard := middleware.RequireScope(identity.ScopeAgentsAdmin)
+    r.Get("/agents/:agentId", guard, h.AdminGet)
+    r.Post("/agents/:agentId/status", guard, h.AdminSetStatus)
+    r.Post("/agents/:agentId/super-agent", guard, h.AdminSetSuperAgent)
+    r.Get("/rebalance-requests", guard, h.AdminRebalances)
+    r.Post("/rebalance-requests/:requestId/approve", guard, h.AdminApproveRebalance)
+    r.Post("/rebalance-requests/:requestId/reject", guard, h.AdminRejectRebalance)
+}
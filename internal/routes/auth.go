@@ -11,9 +11,22 @@ func RegisterAuthRoutes(r fiber.Router, h *auth.Handler, rateLimiter fiber.Handl
     group := r.Group("/auth")
     if rateLimiter != nil {
         group.Post("/login", rateLimiter, h.Login)
+        group.Post("/device-change", rateLimiter, h.DeviceChange)
     } else {
         group.Post("/login", h.Login)
+        group.Post("/device-change", h.DeviceChange)
     }
     group.Post("/refresh", h.Refresh)
-    group.Post("/logout", h.Logout)
+}
+
+// RegisterJWKSRoute publishes the access token verification keys for other services.
+func RegisterJWKSRoute(app fiber.Router, h *auth.Handler) {
+    app.Get("/.well-known/jwks.json", h.JWKS)
+}
+
+// RegisterSessionRoutes wires the authenticated user's session list and logout.
+func RegisterSessionRoutes(r fiber.Router, h *auth.Handler) {
+    r.Post("/auth/logout", h.Logout)
+    r.Get("/me/sessions", h.Sessions)
+    r.Delete("/me/sessions/:id", h.RevokeSession)
 }
@@ -0,0 +1,17 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/billers"
+)
+
+// RegisterBillerRoutes wires the biller catalog and bill payment endpoints.
+func RegisterBillerRoutes(r fiber.Router, h *billers.Handler, outgoing fiber.Handler) {
+    r.Get("/billers", h.List)
+    r.Get("/billers/:billerId", h.Get)
+    r.Post("/billers/:billerId/validate", h.Validate)
+    r.Post("/bills/pay", outgoing, h.Pay)
+    r.Get("/bills/payments", h.Payments)
+    r.Get("/bills/payments/:paymentId", h.Receipt)
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/notification"
+)
+
+// RegisterDevRoutes wires development-only helpers. They must never be mounted outside dev.
+func RegisterDevRoutes(r fiber.Router, sms *notification.SMSStub) {
+    // Read back the SMS, including one-time codes, sent to a phone number.
+    r.Get("/dev/sms/:phone", func(c *fiber.Ctx) error {
+        phone, err := identity.NormalizePhone(c.Params("phone"))
+        if err != nil {
+            return fiber.NewError(fiber.StatusBadRequest, err.Error())
+        }
+        out := make([]fiber.Map, 0)
+        for _, m := range sms.Messages(phone) {
+            out = append(out, fiber.Map{"kind": m.Kind, "body": m.Body})
+        }
+        return c.JSON(fiber.Map{"phone": phone, "messages": out})
+    })
+}
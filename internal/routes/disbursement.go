@@ -0,0 +1,17 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/disbursement"
+)
+
+// RegisterDisbursementRoutes wires bulk payout batch endpoints.
+func RegisterDisbursementRoutes(r fiber.Router, h *disbursement.Handler, outgoing fiber.Handler) {
+    r.Post("/disbursements", h.Create)
+    r.Get("/disbursements", h.List)
+    r.Get("/disbursements/:batchId", h.Get)
+    r.Get("/disbursements/:batchId/items", h.Items)
+    r.Get("/disbursements/:batchId/result", h.Result)
+    r.Post("/disbursements/:batchId/execute", outgoing, h.Execute)
+}
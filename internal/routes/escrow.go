@@ -0,0 +1,27 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/escrow"
+    "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/middleware"
+)
+
+// RegisterEscrowRoutes wires protected-payment endpoints for buyers and sellers.
+func RegisterEscrowRoutes(r fiber.Router, h *escrow.Handler, outgoing fiber.Handler) {
+    r.Post("/escrows", outgoing, h.Create)
+    r.Get("/escrows", h.List)
+    r.Get("/escrows/:escrowId", h.Get)
+    r.Post("/escrows/:escrowId/confirm", h.Confirm)
+    r.Post("/escrows/:escrowId/cancel", h.Cancel)
+    r.Post("/escrows/:escrowId/dispute", h.Dispute)
+}
+
+// RegisterEscrowAdminRoutes wires back-office dispute resolution endpoints.
+func RegisterEscrowAdminRoutes(r fiber.Router, h *escrow.Handler) {
+    guard := middleware.RequireScope(identity.ScopeEscrowsAdmin)
+    r.Get("/escrows/disputed", guard, h.AdminListDisputed)
+    r.Get("/escrows/:escrowId", guard, h.AdminGet)
+    r.Post("/escrows/:escrowId/resolve", guard, h.AdminResolve)
+}
@@ -7,8 +7,8 @@ import (
 )
 
 // RegisterFundingRoutes wires card funding/withdrawal endpoints.
-func RegisterFundingRoutes(r fiber.Router, h *funding.Handler) {
+func RegisterFundingRoutes(r fiber.Router, h *funding.Handler, outgoing, withdrawal fiber.Handler) {
     r.Post("/wallets/:walletId/fund/card", h.CardIn)
-    r.Post("/wallets/:walletId/withdraw/card", h.CardOut)
+    r.Post("/wallets/:walletId/withdraw/card", outgoing, withdrawal, h.CardOut)
 }
 
@@ -1,28 +1,49 @@
 package routes
 
 import (
+    "errors"
     "log/slog"
     "net/http"
+    "time"
 
     "github.com/gofiber/fiber/v2"
 
     "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/middleware"
+    "github.com/congo-pay/congo_pay/internal/otp"
     "github.com/congo-pay/congo_pay/internal/wallet"
 )
 
 // RegisterIdentityRoutes wires identity endpoints and auto‑provisions a wallet on registration.
-func RegisterIdentityRoutes(r fiber.Router, ids *identity.Service, wallets *wallet.Service, logger *slog.Logger) {
+// Registration and PIN reset require a code from POST /otp/send proving the phone is the caller's.
+func RegisterIdentityRoutes(r fiber.Router, ids *identity.Service, otps *otp.Service, wallets *wallet.Service, logger *slog.Logger) {
     // Register with auto-provisioned wallet
     r.Post("/identity/register", func(c *fiber.Ctx) error {
         var req struct {
-            Phone    string `json:"phone"`
-            PIN      string `json:"pin"`
-            DeviceID string `json:"device_id"`
+            Phone      string `json:"phone"`
+            PIN        string `json:"pin"`
+            DeviceID   string `json:"device_id"`
+            DeviceName string `json:"device_name"`
+            Platform   string `json:"platform"`
+            OTPCode    string `json:"otp_code"`
         }
         if err := c.BodyParser(&req); err != nil {
             return fiber.NewError(http.StatusBadRequest, err.Error())
         }
-        user, err := ids.Register(c.UserContext(), identity.Credentials{Phone: req.Phone, PIN: req.PIN, DeviceID: req.DeviceID})
+        if req.DeviceID == "" {
+            return fiber.NewError(http.StatusBadRequest, identity.ErrDeviceRequired.Error())
+        }
+        if err := identity.ValidateNewPIN(req.PIN); err != nil {
+            return identityError(err)
+        }
+        if _, err := ids.LookupByPhone(c.UserContext(), req.Phone); err == nil {
+            return fiber.NewError(http.StatusConflict, identity.ErrUserExists.Error())
+        }
+        // Checked last so a rejected request does not burn the code.
+        if err := otps.Verify(c.UserContext(), otp.PurposeRegistration, req.Phone, req.OTPCode); err != nil {
+            return identityError(err)
+        }
+        user, err := ids.Register(c.UserContext(), identity.Credentials{Phone: req.Phone, PIN: req.PIN, DeviceID: req.DeviceID, DeviceName: req.DeviceName, Platform: req.Platform})
         if err != nil {
             return fiber.NewError(http.StatusBadRequest, err.Error())
         }
@@ -60,7 +81,7 @@ func RegisterIdentityRoutes(r fiber.Router, ids *identity.Service, wallets *wall
         }
         user, err := ids.Authenticate(c.UserContext(), identity.Credentials{Phone: req.Phone, PIN: req.PIN, DeviceID: req.DeviceID})
         if err != nil {
-            return fiber.NewError(http.StatusUnauthorized, err.Error())
+            return fiber.NewError(identity.AuthErrorStatus(err), err.Error())
         }
         return c.Status(http.StatusOK).JSON(fiber.Map{
             "user_id":   user.ID,
@@ -69,4 +90,206 @@ func RegisterIdentityRoutes(r fiber.Router, ids *identity.Service, wallets *wall
             "device_id": user.DeviceID,
         })
     })
+
+    // Forgotten PIN: set a new PIN with a pin_reset code from POST /otp/send.
+    r.Post("/identity/pin-reset", func(c *fiber.Ctx) error {
+        var req struct {
+            Phone  string `json:"phone"`
+            Code   string `json:"code"`
+            NewPIN string `json:"new_pin"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return fiber.NewError(http.StatusBadRequest, err.Error())
+        }
+        if err := identity.ValidateNewPIN(req.NewPIN); err != nil {
+            return identityError(err)
+        }
+        if err := otps.Verify(c.UserContext(), otp.PurposePINReset, req.Phone, req.Code); err != nil {
+            return identityError(err)
+        }
+        user, err := ids.ResetPIN(c.UserContext(), req.Phone, req.NewPIN)
+        if err != nil {
+            return identityError(err)
+        }
+        return c.JSON(fiber.Map{"user_id": user.ID, "token_version": user.TokenVersion})
+    })
+}
+
+// RegisterPINRoutes wires PIN management for the authenticated user.
+func RegisterPINRoutes(r fiber.Router, ids *identity.Service) {
+    r.Post("/me/pin", func(c *fiber.Ctx) error {
+        uid, _ := c.Locals("user_id").(string)
+        var req struct {
+            OldPIN string `json:"old_pin"`
+            NewPIN string `json:"new_pin"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return fiber.NewError(http.StatusBadRequest, err.Error())
+        }
+        user, err := ids.ChangePIN(c.UserContext(), uid, req.OldPIN, req.NewPIN)
+        if err != nil {
+            return identityError(err)
+        }
+        // Tokens were revoked; the client must log in again with the new PIN.
+        return c.JSON(fiber.Map{"user_id": user.ID, "token_version": user.TokenVersion})
+    })
+}
+
+// RegisterDeviceRoutes wires listing and revoking the authenticated user's devices.
+func RegisterDeviceRoutes(r fiber.Router, ids *identity.Service) {
+    r.Get("/me/devices", func(c *fiber.Ctx) error {
+        uid, _ := c.Locals("user_id").(string)
+        current, _ := c.Locals("device_id").(string)
+        devices, err := ids.Devices(c.UserContext(), uid)
+        if err != nil {
+            return identityError(err)
+        }
+        return c.JSON(fiber.Map{"devices": deviceList(devices, current)})
+    })
+
+    // Revoking the current device signs this session out too.
+    r.Delete("/me/devices/:deviceId", func(c *fiber.Ctx) error {
+        uid, _ := c.Locals("user_id").(string)
+        current, _ := c.Locals("device_id").(string)
+        device, err := ids.RevokeDevice(c.UserContext(), uid, c.Params("deviceId"), "user")
+        if err != nil {
+            return identityError(err)
+        }
+        return c.JSON(deviceJSON(device, current))
+    })
+}
+
+func deviceJSON(d identity.Device, current string) fiber.Map {
+    out := fiber.Map{
+        "device_id":     d.DeviceID,
+        "name":          d.Name,
+        "platform":      d.Platform,
+        "trusted":       d.Trusted,
+        "current":       d.DeviceID == current,
+        "first_seen_at": d.FirstSeenAt.Format(time.RFC3339),
+        "last_seen_at":  d.LastSeenAt.Format(time.RFC3339),
+    }
+    if !d.CoolingOffUntil.IsZero() {
+        out["cooling_off_until"] = d.CoolingOffUntil.Format(time.RFC3339)
+    }
+    if !d.RevokedAt.IsZero() {
+        out["revoked_at"] = d.RevokedAt.Format(time.RFC3339)
+    }
+    return out
+}
+
+func deviceList(devices []identity.Device, current string) []fiber.Map {
+    out := make([]fiber.Map, 0, len(devices))
+    for _, d := range devices {
+        out = append(out, deviceJSON(d, current))
+    }
+    return out
+}
+
+func identityError(err error) error {
+    switch {
+    case errors.Is(err, identity.ErrUserNotFound), errors.Is(err, identity.ErrDeviceNotFound):
+        return fiber.NewError(http.StatusNotFound, err.Error())
+    case errors.Is(err, identity.ErrInvalidPIN), errors.Is(err, identity.ErrDeviceMismatch):
+        return fiber.NewError(http.StatusUnauthorized, err.Error())
+    case errors.Is(err, identity.ErrDeviceCoolingOff):
+        return fiber.NewError(http.StatusForbidden, err.Error())
+    case errors.Is(err, identity.ErrPINLocked):
+        return fiber.NewError(http.StatusTooManyRequests, err.Error())
+    case errors.Is(err, identity.ErrPINBlocked):
+        return fiber.NewError(http.StatusLocked, err.Error())
+    case errors.Is(err, identity.ErrWeakPIN), errors.Is(err, otp.ErrInvalidCode):
+        return fiber.NewError(http.StatusUnprocessableEntity, err.Error())
+    default:
+        return fiber.NewError(http.StatusBadRequest, err.Error())
+    }
+}
+
+// RegisterIdentityAdminRoutes wires back-office PIN unlock, device, role and security audit
+// endpoints.
+func RegisterIdentityAdminRoutes(r fiber.Router, ids *identity.Service) {
+    read := middleware.RequireScope(identity.ScopeUsersRead)
+    write := middleware.RequireScope(identity.ScopeUsersWrite)
+    rolesWrite := middleware.RequireScope(identity.ScopeRolesWrite)
+
+    r.Post("/users/:userId/pin-unlock", write, func(c *fiber.Ctx) error {
+        uid, _ := c.Locals("user_id").(string)
+        var req struct {
+            Reason string `json:"reason"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return fiber.NewError(http.StatusBadRequest, err.Error())
+        }
+        user, err := ids.UnlockPIN(c.UserContext(), c.Params("userId"), "admin:"+uid, req.Reason)
+        if err != nil {
+            return identityError(err)
+        }
+        return c.JSON(fiber.Map{"user_id": user.ID, "pin_unlocked": true})
+    })
+
+    r.Get("/users/:userId/security-events", read, func(c *fiber.Ctx) error {
+        events, err := ids.SecurityEvents(c.UserContext(), c.Params("userId"), c.QueryInt("limit", 50))
+        if err != nil {
+            return identityError(err)
+        }
+        out := make([]fiber.Map, 0, len(events))
+        for _, e := range events {
+            out = append(out, fiber.Map{
+                "id":         e.ID,
+                "kind":       e.Kind,
+                "detail":     e.Detail,
+                "actor":      e.Actor,
+                "created_at": e.CreatedAt.Format(time.RFC3339),
+            })
+        }
+        return c.JSON(fiber.Map{"user_id": c.Params("userId"), "events": out})
+    })
+
+    r.Get("/users/:userId/devices", read, func(c *fiber.Ctx) error {
+        devices, err := ids.Devices(c.UserContext(), c.Params("userId"))
+        if err != nil {
+            return identityError(err)
+        }
+        return c.JSON(fiber.Map{"user_id": c.Params("userId"), "devices": deviceList(devices, "")})
+    })
+
+    r.Post("/users/:userId/devices/:deviceId/revoke", write, func(c *fiber.Ctx) error {
+        uid, _ := c.Locals("user_id").(string)
+        device, err := ids.RevokeDevice(c.UserContext(), c.Params("userId"), c.Params("deviceId"), "admin:"+uid)
+        if err != nil {
+            return identityError(err)
+        }
+        return c.JSON(deviceJSON(device, ""))
+    })
+
+    r.Get("/users/:userId/roles", read, func(c *fiber.Ctx) error {
+        user, err := ids.Get(c.UserContext(), c.Params("userId"))
+        if err != nil {
+            return identityError(err)
+        }
+        return c.JSON(rolesJSON(user))
+    })
+
+    // Replacing roles revokes the user's tokens; the customer role is always kept.
+    r.Put("/users/:userId/roles", rolesWrite, func(c *fiber.Ctx) error {
+        uid, _ := c.Locals("user_id").(string)
+        var req struct {
+            Roles []string `json:"roles"`
+        }
+        if err := c.BodyParser(&req); err != nil {
+            return fiber.NewError(http.StatusBadRequest, err.Error())
+        }
+        if c.Params("userId") == uid {
+            return fiber.NewError(http.StatusForbidden, "cannot change your own roles")
+        }
+        user, err := ids.SetRoles(c.UserContext(), c.Params("userId"), req.Roles, "admin:"+uid)
+        if err != nil {
+            return identityError(err)
+        }
+        return c.JSON(rolesJSON(user))
+    })
+}
+
+func rolesJSON(user identity.User) fiber.Map {
+    return fiber.Map{"user_id": user.ID, "roles": user.Roles, "scopes": identity.ScopesFor(user.Roles)}
 }
@@ -0,0 +1,28 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/kyc"
+    "github.com/congo-pay/congo_pay/internal/middleware"
+)
+
+// RegisterKYCRoutes wires KYC submission endpoints for the authenticated user.
+func RegisterKYCRoutes(r fiber.Router, h *kyc.Handler) {
+    r.Get("/kyc", h.Status)
+    r.Post("/kyc/submissions", h.Submit)
+    r.Get("/kyc/submissions/:submissionId", h.Get)
+}
+
+// RegisterKYCAdminRoutes wires the back-office KYC review queue and tier management.
+func RegisterKYCAdminRoutes(r fiber.Router, h *kyc.Handler) {
+    guard := middleware.RequireScope(identity.ScopeKYCReview)
+    r.Get("/kyc/submissions", guard, h.AdminQueue)
+    r.Get("/kyc/submissions/:submissionId", guard, h.AdminGet)
+    r.Get("/kyc/submissions/:submissionId/documents/:kind", guard, h.AdminDocument)
+    r.Post("/kyc/submissions/:submissionId/approve", guard, h.AdminApprove)
+    r.Post("/kyc/submissions/:submissionId/reject", guard, h.AdminReject)
+    r.Get("/users/:userId/tier-history", guard, h.AdminTierHistory)
+    r.Post("/users/:userId/tier", guard, h.AdminSetTier)
+}
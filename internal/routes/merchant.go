@@ -0,0 +1,28 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/merchant"
+    "github.com/congo-pay/congo_pay/internal/middleware"
+)
+
+// RegisterMerchantRoutes wires merchant onboarding and pay-merchant endpoints.
+func RegisterMerchantRoutes(r fiber.Router, h *merchant.Handler, outgoing fiber.Handler) {
+    r.Post("/merchants", h.Onboard)
+    r.Get("/merchants", h.List)
+    r.Get("/merchants/by-code/:shortCode", h.Lookup)
+    r.Get("/merchants/:merchantId", h.Get)
+    r.Get("/merchants/:merchantId/payments", h.Payments)
+    r.Get("/merchants/:merchantId/qr", h.QR)
+    r.Post("/payments/merchant", outgoing, h.Pay)
+    r.Post("/payments/merchant/qr/decode", h.DecodeQR)
+    r.Get("/payments/merchant/:paymentId", h.Receipt)
+}
+
+// RegisterMerchantAdminRoutes wires back-office merchant endpoints.
+func RegisterMerchantAdminRoutes(r fiber.Router, h *merchant.Handler) {
+    guard := middleware.RequireScope(identity.ScopeMerchantsAdmin)
+    r.Post("/merchants/:merchantId/status", guard, h.AdminSetStatus)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/otp"
+)
+
+// RegisterOTPRoutes wires the public code request used before registration, device change
+// and PIN reset.
+func RegisterOTPRoutes(r fiber.Router, h *otp.Handler) {
+    r.Post("/otp/send", h.Send)
+}
+
+// RegisterOTPMeRoutes wires code requests sent to the authenticated user's own phone.
+func RegisterOTPMeRoutes(r fiber.Router, h *otp.Handler) {
+    r.Post("/me/otp", h.SendToMe)
+}
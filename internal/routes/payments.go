@@ -7,7 +7,7 @@ import (
 )
 
 // RegisterPaymentRoutes wires payment endpoints.
-func RegisterPaymentRoutes(r fiber.Router, h *payments.Handler) {
-    r.Post("/payments/p2p", h.P2P)
+func RegisterPaymentRoutes(r fiber.Router, h *payments.Handler, outgoing fiber.Handler) {
+    r.Post("/payments/p2p", outgoing, h.P2P)
 }
 
@@ -0,0 +1,23 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/payrequest"
+)
+
+// RegisterPaymentRequestRoutes wires request-to-pay endpoints.
+func RegisterPaymentRequestRoutes(r fiber.Router, h *payrequest.Handler, outgoing fiber.Handler) {
+    r.Post("/payment-requests", h.Create)
+    r.Get("/payment-requests", h.List)
+    r.Get("/payment-requests/links/:code", h.GetByCode)
+    r.Get("/payment-requests/:requestId", h.Get)
+    r.Post("/payment-requests/:requestId/accept", outgoing, h.Accept)
+    r.Post("/payment-requests/:requestId/decline", h.Decline)
+    r.Post("/payment-requests/:requestId/cancel", h.Cancel)
+}
+
+// RegisterPaymentLinkPage serves the public confirmation page behind shared payment links.
+func RegisterPaymentLinkPage(app *fiber.App, h *payrequest.Handler) {
+    app.Get("/r/:code", h.LinkPage)
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/middleware"
+    "github.com/congo-pay/congo_pay/internal/risk"
+)
+
+// RegisterRiskAdminRoutes wires the back-office risk case queue.
+func RegisterRiskAdminRoutes(r fiber.Router, h *risk.Handler) {
+    guard := middleware.RequireScope(identity.ScopeRiskReview)
+    r.Get("/risk-cases", guard, h.AdminQueue)
+    r.Get("/risk-cases/:caseId", guard, h.AdminGet)
+    r.Post("/risk-cases/:caseId/close", guard, h.AdminClose)
+    r.Get("/users/:userId/risk-cases", guard, h.AdminUserCases)
+}
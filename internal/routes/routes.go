@@ -14,14 +14,26 @@ import (
     "github.com/jackc/pgx/v5/pgxpool"
     "github.com/redis/go-redis/v9"
 
-    "github.com/congo-pay/congo_pay/internal/config"
+    "github.com/congo-pay/congo_pay/internal/agent"
     "github.com/congo-pay/congo_pay/internal/auth"
+    "github.com/congo-pay/congo_pay/internal/billers"
+    "github.com/congo-pay/congo_pay/internal/config"
+    "github.com/congo-pay/congo_pay/internal/disbursement"
+    "github.com/congo-pay/congo_pay/internal/escrow"
     "github.com/congo-pay/congo_pay/internal/funding"
+    "github.com/congo-pay/congo_pay/internal/guard"
     "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/kyc"
     "github.com/congo-pay/congo_pay/internal/ledger"
+    "github.com/congo-pay/congo_pay/internal/merchant"
     "github.com/congo-pay/congo_pay/internal/middleware"
     "github.com/congo-pay/congo_pay/internal/notification"
+    "github.com/congo-pay/congo_pay/internal/otp"
     "github.com/congo-pay/congo_pay/internal/payments"
+    "github.com/congo-pay/congo_pay/internal/payrequest"
+    "github.com/congo-pay/congo_pay/internal/risk"
+    "github.com/congo-pay/congo_pay/internal/scheduler"
+    "github.com/congo-pay/congo_pay/internal/split"
     "github.com/congo-pay/congo_pay/internal/wallet"
 )
 
@@ -31,6 +43,8 @@ type Deps struct {
     DB     *pgxpool.Pool
     Cache  *redis.Client
     Logger *slog.Logger
+    // Ctx bounds background workers started during Setup; it is cancelled on shutdown.
+    Ctx    context.Context
 }
 
 // Setup configures middlewares and all application routes.
@@ -57,43 +71,236 @@ func Setup(app *fiber.App, d Deps) error {
         app.Use(middleware.Idempotency(d.Cache, d.Cfg.IdempotencyTTL, d.Logger))
     }
 
+    if d.Ctx == nil {
+        d.Ctx = context.Background()
+    }
+
     // Health
     RegisterHealthRoutes(app, d)
 
     // Services and handlers
     var ledgerBackend ledger.Ledger
     if d.DB != nil {
-        ledgerBackend = ledger.NewPostgresLedger(d.DB)
+        pgLedger := ledger.NewPostgresLedger(d.DB)
+        ledgerBackend = pgLedger
+        if d.Cfg.LedgerSigningKey != "" {
+            signer, err := ledger.NewCheckpointSigner(d.Cfg.LedgerSigningKeyID, d.Cfg.LedgerSigningKey)
+            if err != nil {
+                return err
+            }
+            go ledger.RunCheckpoints(d.Ctx, pgLedger, signer, d.Cfg.LedgerCheckpointInterval, d.Logger)
+        }
     } else {
         ledgerBackend = ledger.NewInMemory()
         _ = ledgerBackend.EnsureAccount(context.Background(), ledger.CardSuspenseAccountCode)
     }
+    // Wrap the backend so watchers (agent float liquidity) see every committed posting.
+    observedLedger := ledger.NewObserved(ledgerBackend)
+    ledgerBackend = observedLedger
 
+    var notifier notification.Notifier = notification.NewLoggerNotifier(d.Logger)
+    // Without an SMS provider in dev, keep texts in memory so codes can be read back.
+    var smsStub *notification.SMSStub
+    if isDev(d.Cfg.Env) && d.Cfg.SMSProvider == "" {
+        smsStub = notification.NewSMSStub(notifier, 20)
+        notifier = smsStub
+    }
+    var identityRepo identity.Repository
+    if d.DB != nil {
+        identityRepo = identity.NewPostgresRepository(d.DB)
+    } else {
+        identityRepo = identity.NewMemoryRepository()
+    }
+    identitySvc := identity.NewService(identityRepo, notifier)
+    for _, id := range d.Cfg.AdminUserIDs {
+        if _, err := identitySvc.GrantRole(context.Background(), id, identity.RoleAdmin, "system"); err != nil {
+            d.Logger.Warn("admin bootstrap skipped", "user_id", id, "err", err)
+        }
+    }
+    var sessionRepo auth.Repository
+    if d.DB != nil {
+        sessionRepo = auth.NewPostgresRepository(d.DB)
+    } else {
+        sessionRepo = auth.NewMemoryRepository()
+    }
+    if d.Cfg.JWTKeyOverlap < d.Cfg.AccessTokenTTL {
+        return fmt.Errorf("JWT_KEY_OVERLAP (%s) must be at least ACCESS_TTL (%s)", d.Cfg.JWTKeyOverlap, d.Cfg.AccessTokenTTL)
+    }
+    var keyStore auth.KeyStore
+    if d.DB != nil {
+        pgKeys, err := auth.NewPostgresKeyStore(d.DB, d.Cfg.JWTKeySecret)
+        if err != nil {
+            return err
+        }
+        keyStore = pgKeys
+    } else {
+        keyStore = auth.NewMemoryKeyStore()
+    }
+    keyRing, err := auth.NewKeyRing(keyStore, auth.KeyPolicy{Algorithm: d.Cfg.JWTAlgorithm, RotateEvery: d.Cfg.JWTKeyRotation, Overlap: d.Cfg.JWTKeyOverlap})
+    if err != nil {
+        return err
+    }
+    if err := keyRing.Rotate(context.Background()); err != nil {
+        return err
+    }
+    go auth.RunKeyRotation(d.Ctx, keyRing, time.Minute, d.Logger)
+    var denylist auth.Denylist
+    if d.Cache != nil {
+        denylist = auth.NewRedisDenylist(d.Cache)
+    } else {
+        denylist = auth.NewMemoryDenylist()
+    }
+    authSvc := auth.NewService(d.Cfg, identityRepo, sessionRepo, keyRing, denylist)
+    var otpStore otp.Store
+    if d.Cache != nil {
+        otpStore = otp.NewRedisStore(d.Cache)
+    } else {
+        otpStore = otp.NewMemoryStore()
+    }
+    otpSvc := otp.NewService(otpStore, notifier, d.Cfg.OTPSecret)
+    otpHandler := otp.NewHandler(otpSvc, identitySvc)
+    var riskRepo risk.Repository
+    if d.DB != nil {
+        riskRepo = risk.NewPostgresRepository(d.DB)
+    } else {
+        riskRepo = risk.NewMemoryRepository()
+    }
+    simSwaps, err := risk.LoadSIMSwapChecker(d.Cfg.SIMSwapFile)
+    if err != nil {
+        return err
+    }
+    riskPolicy := risk.Policy{Window: d.Cfg.SIMSwapWindow, Restriction: d.Cfg.SIMSwapRestriction, P2PLimit: d.Cfg.SIMSwapP2PLimit}
+    riskSvc := risk.NewService(riskRepo, simSwaps, identitySvc, notifier, riskPolicy, d.Logger)
+    // Every service that debits a customer screens the movement through this guard, which
+    // also asks for a high_value_transfer code on large amounts.
+    outgoingGuard := guard.New(riskSvc, otpSvc, identitySvc, d.Cfg.HighValueTransferAmount)
     var walletRepo wallet.Repository
     if d.DB != nil {
         walletRepo = wallet.NewPostgresRepository(d.DB)
     } else {
         walletRepo = wallet.NewMemoryRepository()
     }
-    walletSvc := wallet.NewService(walletRepo, ledgerBackend)
-    notifier := notification.NewLoggerNotifier(d.Logger)
-    paymentSvc := payments.NewService(ledgerBackend, walletSvc, notifier)
-    var identityRepo identity.Repository
+    walletSvc := wallet.NewService(walletRepo, ledgerBackend, outgoingGuard)
+    paymentSvc := payments.NewService(ledgerBackend, walletSvc, notifier, outgoingGuard)
+    authHandler := auth.NewHandler(identitySvc, authSvc, walletSvc, otpSvc, riskSvc)
+    fundingSvc, err := funding.NewService(context.Background(), ledgerBackend, walletSvc, nil, outgoingGuard)
+    if err != nil {
+        return err
+    }
+
+    var merchantRepo merchant.Repository
     if d.DB != nil {
-        identityRepo = identity.NewPostgresRepository(d.DB)
+        merchantRepo = merchant.NewPostgresRepository(d.DB)
     } else {
-        identityRepo = identity.NewMemoryRepository()
+        merchantRepo = merchant.NewMemoryRepository()
+    }
+    merchantSvc, err := merchant.NewService(context.Background(), merchantRepo, ledgerBackend, walletSvc, notifier, outgoingGuard, d.Cfg.MerchantMDRBasisPoints)
+    if err != nil {
+        return err
+    }
+
+    var payRequestRepo payrequest.Repository
+    if d.DB != nil {
+        payRequestRepo = payrequest.NewPostgresRepository(d.DB)
+    } else {
+        payRequestRepo = payrequest.NewMemoryRepository()
+    }
+    payRequestSvc := payrequest.NewService(payRequestRepo, paymentSvc, walletSvc, identitySvc, notifier, outgoingGuard, d.Cfg.PublicBaseURL)
+
+    var disbursementRepo disbursement.Repository
+    if d.DB != nil {
+        disbursementRepo = disbursement.NewPostgresRepository(d.DB)
+    } else {
+        disbursementRepo = disbursement.NewMemoryRepository()
+    }
+    disbursementSvc := disbursement.NewService(d.Ctx, disbursementRepo, paymentSvc, walletSvc, identitySvc, outgoingGuard, d.Logger)
+    if err := disbursementSvc.Resume(context.Background()); err != nil {
+        return err
+    }
+
+    var standingOrderRepo scheduler.Repository
+    if d.DB != nil {
+        standingOrderRepo = scheduler.NewPostgresRepository(d.DB)
+    } else {
+        standingOrderRepo = scheduler.NewMemoryRepository()
+    }
+    standingOrderSvc := scheduler.NewService(standingOrderRepo, paymentSvc, walletSvc, identitySvc, notifier, outgoingGuard, d.Logger)
+    go scheduler.RunWorker(d.Ctx, standingOrderSvc, d.Cfg.SchedulerInterval, d.Logger)
+
+    var escrowRepo escrow.Repository
+    if d.DB != nil {
+        escrowRepo = escrow.NewPostgresRepository(d.DB)
+    } else {
+        escrowRepo = escrow.NewMemoryRepository()
+    }
+    escrowSvc := escrow.NewService(escrowRepo, ledgerBackend, walletSvc, identitySvc, notifier, outgoingGuard, d.Cfg.EscrowReleaseAfter, d.Logger)
+    go escrow.RunWorker(d.Ctx, escrowSvc, d.Cfg.SchedulerInterval, d.Logger)
+
+    var splitRepo split.Repository
+    if d.DB != nil {
+        splitRepo = split.NewPostgresRepository(d.DB)
+    } else {
+        splitRepo = split.NewMemoryRepository()
+    }
+    splitSvc := split.NewService(splitRepo, ledgerBackend, walletSvc, notifier, outgoingGuard)
+
+    billerRegistry := billers.NewRegistry()
+    if err := billers.LoadCatalogFile(d.Cfg.BillersFile, billerRegistry); err != nil {
+        return err
+    }
+    var billRepo billers.Repository
+    if d.DB != nil {
+        billRepo = billers.NewPostgresRepository(d.DB)
+    } else {
+        billRepo = billers.NewMemoryRepository()
+    }
+    billSvc, err := billers.NewService(context.Background(), billerRegistry, billRepo, ledgerBackend, walletSvc, notifier, outgoingGuard, d.Logger)
+    if err != nil {
+        return err
+    }
+    go billers.RunWorker(d.Ctx, billSvc, d.Cfg.SchedulerInterval, d.Logger)
+
+    var agentRepo agent.Repository
+    if d.DB != nil {
+        agentRepo = agent.NewPostgresRepository(d.DB)
+    } else {
+        agentRepo = agent.NewMemoryRepository()
     }
-    identitySvc := identity.NewService(identityRepo)
-    authSvc := auth.NewService(d.Cfg, identityRepo)
-    authHandler := auth.NewHandler(identitySvc, authSvc, walletSvc)
-    fundingSvc, err := funding.NewService(context.Background(), ledgerBackend, walletSvc, nil)
+    commissions, err := agent.LoadCommissionSchedule(d.Cfg.AgentCommissionsFile)
     if err != nil {
         return err
     }
+    agentSvc, err := agent.NewService(context.Background(), agentRepo, ledgerBackend, walletSvc, identitySvc, otpSvc, notifier, outgoingGuard, commissions, d.Cfg.AgentCommissionMode)
+    if err != nil {
+        return err
+    }
+    observedLedger.Subscribe(agentSvc.WatchBalances)
+    go agent.RunWorker(d.Ctx, agentSvc, d.Cfg.SchedulerInterval, d.Logger)
+
+    var kycRepo kyc.Repository
+    if d.DB != nil {
+        kycRepo = kyc.NewPostgresRepository(d.DB)
+    } else {
+        kycRepo = kyc.NewMemoryRepository()
+    }
+    kycBlobs, err := kyc.NewLocalBlobStore(d.Cfg.KYCStorageDir)
+    if err != nil {
+        return err
+    }
+    kycSvc := kyc.NewService(kycRepo, kycBlobs, identitySvc, notifier)
 
     fundingHandler := funding.NewHandler(fundingSvc)
+    merchantHandler := merchant.NewHandler(merchantSvc)
+    payRequestHandler := payrequest.NewHandler(payRequestSvc)
+    disbursementHandler := disbursement.NewHandler(disbursementSvc)
+    standingOrderHandler := scheduler.NewHandler(standingOrderSvc)
+    escrowHandler := escrow.NewHandler(escrowSvc)
+    splitHandler := split.NewHandler(splitSvc)
+    billHandler := billers.NewHandler(billSvc)
+    agentHandler := agent.NewHandler(agentSvc)
+    kycHandler := kyc.NewHandler(kycSvc)
     paymentHandler := payments.NewHandler(paymentSvc)
+    riskHandler := risk.NewHandler(riskSvc)
     walletHandler := wallet.NewHandler(walletSvc)
     // identityHandler not needed; using service directly for register/auth
 
@@ -109,12 +316,18 @@ func Setup(app *fiber.App, d Deps) error {
     })
 
     // Public routes
-    RegisterIdentityRoutes(api, identitySvc, walletSvc, d.Logger)
+    RegisterJWKSRoute(app, authHandler)
+    RegisterPaymentLinkPage(app, payRequestHandler)
+    RegisterOTPRoutes(api, otpHandler)
+    RegisterIdentityRoutes(api, identitySvc, otpSvc, walletSvc, d.Logger)
+    if smsStub != nil {
+        RegisterDevRoutes(api, smsStub)
+    }
     rateLimiter := middleware.LoginRateLimit(d.Cache, 5)
     RegisterAuthRoutes(api, authHandler, rateLimiter)
 
     // Protected routes
-    jwtmw := middleware.JWTAuth(d.Cfg, identityRepo)
+    jwtmw := middleware.JWTAuth(authSvc, identityRepo)
     protected := api.Group("", jwtmw)
     RegisterWalletMeRoute(protected, walletSvc, identityRepo)
     // Profile endpoint
@@ -133,9 +346,36 @@ func Setup(app *fiber.App, d Deps) error {
             "last_login": user.LastLogin,
         })
     })
-    RegisterWalletRoutes(protected, walletHandler)
-    RegisterFundingRoutes(protected, fundingHandler)
-    RegisterPaymentRoutes(protected, paymentHandler)
+    // Outgoing transfers are held on devices still cooling off after a device change.
+    outgoing := middleware.DeviceCoolingOff(identitySvc)
+    // Withdrawals are also held while the account is restricted after a SIM swap.
+    withdrawal := middleware.SIMSwapHold(riskSvc)
+    RegisterPINRoutes(protected, identitySvc)
+    RegisterDeviceRoutes(protected, identitySvc)
+    RegisterSessionRoutes(protected, authHandler)
+    RegisterOTPMeRoutes(protected, otpHandler)
+    RegisterWalletRoutes(protected, walletHandler, outgoing, withdrawal)
+    RegisterFundingRoutes(protected, fundingHandler, outgoing, withdrawal)
+    RegisterPaymentRoutes(protected, paymentHandler, outgoing)
+    RegisterMerchantRoutes(protected, merchantHandler, outgoing)
+    RegisterPaymentRequestRoutes(protected, payRequestHandler, outgoing)
+    RegisterDisbursementRoutes(protected, disbursementHandler, outgoing)
+    RegisterStandingOrderRoutes(protected, standingOrderHandler, outgoing)
+    RegisterEscrowRoutes(protected, escrowHandler, outgoing)
+    RegisterSplitRoutes(protected, splitHandler, outgoing)
+    RegisterBillerRoutes(protected, billHandler, outgoing)
+    RegisterAgentRoutes(protected, agentHandler, outgoing, withdrawal)
+    RegisterKYCRoutes(protected, kycHandler)
+
+    // Back-office routes
+    admin := protected.Group("/admin", middleware.RequireScope(identity.ScopeBackOffice))
+    RegisterWalletAdminRoutes(admin, walletHandler)
+    RegisterMerchantAdminRoutes(admin, merchantHandler)
+    RegisterEscrowAdminRoutes(admin, escrowHandler)
+    RegisterAgentAdminRoutes(admin, agentHandler)
+    RegisterKYCAdminRoutes(admin, kycHandler)
+    RegisterIdentityAdminRoutes(admin, identitySvc)
+    RegisterRiskAdminRoutes(admin, riskHandler)
 
     return nil
 }
@@ -0,0 +1,19 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/scheduler"
+)
+
+// RegisterStandingOrderRoutes wires scheduled and recurring transfer endpoints.
+func RegisterStandingOrderRoutes(r fiber.Router, h *scheduler.Handler, outgoing fiber.Handler) {
+    r.Post("/standing-orders", outgoing, h.Create)
+    r.Get("/standing-orders", h.List)
+    r.Get("/standing-orders/:orderId", h.Get)
+    r.Patch("/standing-orders/:orderId", outgoing, h.Update)
+    r.Delete("/standing-orders/:orderId", h.Cancel)
+    r.Post("/standing-orders/:orderId/pause", h.Pause)
+    r.Post("/standing-orders/:orderId/resume", outgoing, h.Resume)
+    r.Get("/standing-orders/:orderId/executions", h.Executions)
+}
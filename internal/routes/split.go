@@ -0,0 +1,20 @@
+package routes
+
+import (
+    "github.com/gofiber/fiber/v2"
+
+    "github.com/congo-pay/congo_pay/internal/split"
+)
+
+// RegisterSplitRoutes wires split template and split payment endpoints.
+func RegisterSplitRoutes(r fiber.Router, h *split.Handler, outgoing fiber.Handler) {
+    r.Post("/split-templates", h.CreateTemplate)
+    r.Get("/split-templates", h.ListTemplates)
+    r.Get("/split-templates/:templateId", h.GetTemplate)
+    r.Post("/split-templates/:templateId/status", h.SetTemplateStatus)
+    r.Post("/payments/split/quote", h.Quote)
+    r.Post("/payments/split", outgoing, h.Pay)
+    r.Get("/payments/split", h.List)
+    r.Get("/payments/split/:paymentId", h.Get)
+    r.Get("/payments/split/:paymentId/receipts/:index", h.Receipt)
+}
@@ -3,12 +3,31 @@ package routes
 import (
     "github.com/gofiber/fiber/v2"
 
+    "github.com/congo-pay/congo_pay/internal/identity"
+    "github.com/congo-pay/congo_pay/internal/middleware"
     "github.com/congo-pay/congo_pay/internal/wallet"
 )
 
-// RegisterWalletRoutes wires wallet-related endpoints.
-func RegisterWalletRoutes(r fiber.Router, h *wallet.Handler) {
-    // Wallets are auto-created on registration; expose GET to retrieve metadata
+// RegisterWalletRoutes wires wallet-related endpoints. Closing a funded wallet moves its
+// balance, so it runs behind the outgoing and withdrawal guards.
+func RegisterWalletRoutes(r fiber.Router, h *wallet.Handler, outgoing, withdrawal fiber.Handler) {
+    // The primary wallet is auto-created on registration; owners may add wallets and pockets.
+    r.Get("/wallets", h.List)
+    r.Post("/wallets", h.Create)
+    r.Post("/wallets/moves", h.Move)
     r.Get("/wallets/:walletId", h.Get)
+    r.Patch("/wallets/:walletId", h.Update)
+    r.Post("/wallets/:walletId/primary", h.SetPrimary)
     r.Get("/wallets/:walletId/balance", h.Balance)
+    r.Get("/wallets/:walletId/status-history", h.StatusHistory)
+    r.Post("/wallets/:walletId/close", outgoing, withdrawal, h.Close)
+}
+
+// RegisterWalletAdminRoutes wires back-office wallet lifecycle endpoints.
+func RegisterWalletAdminRoutes(r fiber.Router, h *wallet.Handler) {
+    guard := middleware.RequireScope(identity.ScopeWalletsAdmin)
+    r.Post("/wallets/:walletId/freeze", guard, h.Freeze)
+    r.Post("/wallets/:walletId/unfreeze", guard, h.Unfreeze)
+    r.Post("/wallets/:walletId/close", guard, h.AdminClose)
+    r.Get("/wallets/:walletId/status-history", guard, h.AdminStatusHistory)
 }
@@ -0,0 +1,241 @@
+package scheduler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/payments"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// Handler exposes standing order endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds a standing order HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type createRequest struct {
+	FromWalletID string     `json:"from_wallet_id"`
+	ToWalletID   string     `json:"to_wallet_id"`
+	ToPhone      string     `json:"to_phone"`
+	Amount       int64      `json:"amount"`
+	Memo         string     `json:"memo"`
+	Frequency    string     `json:"frequency"`
+	Interval     int        `json:"interval"`
+	Cron         string     `json:"cron"`
+	StartAt      *time.Time `json:"start_at"`
+	EndAt        *time.Time `json:"end_at"`
+	OTPCode      string     `json:"otp_code"`
+}
+
+type updateRequest struct {
+	Amount     *int64     `json:"amount"`
+	Memo       *string    `json:"memo"`
+	EndAt      *time.Time `json:"end_at"`
+	ClearEndAt bool       `json:"clear_end_at"`
+	OTPCode    string     `json:"otp_code"`
+}
+
+type orderResponse struct {
+	ID           string     `json:"id"`
+	FromWalletID string     `json:"from_wallet_id"`
+	ToWalletID   string     `json:"to_wallet_id"`
+	Amount       int64      `json:"amount"`
+	Memo         string     `json:"memo,omitempty"`
+	Frequency    string     `json:"frequency"`
+	Interval     int        `json:"interval,omitempty"`
+	Cron         string     `json:"cron,omitempty"`
+	StartAt      time.Time  `json:"start_at"`
+	EndAt        *time.Time `json:"end_at,omitempty"`
+	Status       string     `json:"status"`
+	NextRunAt    *time.Time `json:"next_run_at,omitempty"`
+	Attempts     int        `json:"attempts"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func toOrderResponse(o StandingOrder) orderResponse {
+	resp := orderResponse{
+		ID:           o.ID,
+		FromWalletID: o.FromWalletID,
+		ToWalletID:   o.ToWalletID,
+		Amount:       o.Amount,
+		Memo:         o.Memo,
+		Frequency:    o.Rule.Frequency,
+		Interval:     o.Rule.Interval,
+		Cron:         o.Rule.Cron,
+		StartAt:      o.StartAt,
+		EndAt:        optionalTime(o.EndAt),
+		Status:       o.Status,
+		Attempts:     o.Attempts,
+		LastRunAt:    optionalTime(o.LastRunAt),
+		CreatedAt:    o.CreatedAt,
+		UpdatedAt:    o.UpdatedAt,
+	}
+	if o.Status == StatusActive {
+		resp.NextRunAt = optionalTime(o.NextRunAt)
+	}
+	return resp
+}
+
+// Create schedules a new standing order for the authenticated user.
+func (h *Handler) Create(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req createRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	input := CreateInput{
+		OwnerUserID:  uid,
+		FromWalletID: req.FromWalletID,
+		ToWalletID:   req.ToWalletID,
+		ToPhone:      req.ToPhone,
+		Amount:       req.Amount,
+		Memo:         req.Memo,
+		Rule:         Rule{Frequency: req.Frequency, Interval: req.Interval, Cron: req.Cron},
+		OTPCode:      req.OTPCode,
+	}
+	if input.Rule.Interval == 0 && input.Rule.Frequency != FrequencyCron {
+		input.Rule.Interval = 1
+	}
+	if req.StartAt != nil {
+		input.StartAt = *req.StartAt
+	}
+	if req.EndAt != nil {
+		input.EndAt = *req.EndAt
+	}
+	o, err := h.service.Create(c.UserContext(), input)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toOrderResponse(o))
+}
+
+// List returns the authenticated user's standing orders.
+func (h *Handler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	orders, err := h.service.List(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]orderResponse, 0, len(orders))
+	for _, o := range orders {
+		out = append(out, toOrderResponse(o))
+	}
+	return c.JSON(fiber.Map{"standing_orders": out})
+}
+
+// Get returns one of the user's standing orders.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	o, err := h.service.Get(c.UserContext(), c.Params("orderId"), uid)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(toOrderResponse(o))
+}
+
+// Update changes the amount, memo or end date of a standing order.
+func (h *Handler) Update(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req updateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	input := UpdateInput{Amount: req.Amount, Memo: req.Memo, EndAt: req.EndAt, OTPCode: req.OTPCode}
+	if req.ClearEndAt {
+		input.EndAt = &time.Time{}
+	}
+	o, err := h.service.Update(c.UserContext(), c.Params("orderId"), uid, input)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(toOrderResponse(o))
+}
+
+// Pause suspends a standing order.
+func (h *Handler) Pause(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	o, err := h.service.Pause(c.UserContext(), c.Params("orderId"), uid)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(toOrderResponse(o))
+}
+
+// Resume reactivates a paused standing order from its next future occurrence.
+func (h *Handler) Resume(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	o, err := h.service.Resume(c.UserContext(), c.Params("orderId"), uid)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(toOrderResponse(o))
+}
+
+// Cancel permanently stops a standing order.
+func (h *Handler) Cancel(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	o, err := h.service.Cancel(c.UserContext(), c.Params("orderId"), uid)
+	if err != nil {
+		return orderError(err)
+	}
+	return c.JSON(toOrderResponse(o))
+}
+
+// Executions lists the order's recent execution attempts.
+func (h *Handler) Executions(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	execs, err := h.service.Executions(c.UserContext(), c.Params("orderId"), uid)
+	if err != nil {
+		return orderError(err)
+	}
+	out := make([]fiber.Map, 0, len(execs))
+	for _, e := range execs {
+		item := fiber.Map{
+			"id":         e.ID,
+			"occurrence": e.Occurrence,
+			"attempt":    e.Attempt,
+			"status":     e.Status,
+			"created_at": e.CreatedAt,
+		}
+		if e.Error != "" {
+			item["error"] = e.Error
+		}
+		if e.TransactionID != "" {
+			item["transaction_id"] = e.TransactionID
+		}
+		out = append(out, item)
+	}
+	return c.JSON(fiber.Map{"executions": out})
+}
+
+func orderError(err error) error {
+	switch {
+	case errors.Is(err, ErrOrderNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, wallet.ErrNotOwner), errors.Is(err, payments.ErrNotOwner), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrInvalidState), errors.Is(err, ErrOrderChanged),
+		errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,118 @@
+package scheduler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mu         sync.RWMutex
+	orders     map[string]StandingOrder
+	executions map[string][]Execution
+}
+
+// NewMemoryRepository builds an in-memory standing order store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{orders: make(map[string]StandingOrder), executions: make(map[string][]Execution)}
+}
+
+func (m *memoryRepository) Create(_ context.Context, o StandingOrder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders[o.ID] = o
+	return nil
+}
+
+func (m *memoryRepository) Get(_ context.Context, id string) (StandingOrder, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	o, ok := m.orders[id]
+	if !ok {
+		return StandingOrder{}, ErrOrderNotFound
+	}
+	return o, nil
+}
+
+func (m *memoryRepository) ListByOwner(_ context.Context, ownerUserID string) ([]StandingOrder, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []StandingOrder
+	for _, o := range m.orders {
+		if o.OwnerUserID == ownerUserID {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (m *memoryRepository) ListDue(_ context.Context, now time.Time, limit int) ([]StandingOrder, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []StandingOrder
+	for _, o := range m.orders {
+		if o.Status == StatusActive && !o.NextRunAt.After(now) {
+			out = append(out, o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].NextRunAt.Before(out[j].NextRunAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (m *memoryRepository) Update(_ context.Context, o StandingOrder) error {
+	return m.update(o.ID, o.Version, func(current *StandingOrder) {
+		lastRunAt := current.LastRunAt
+		*current = o
+		current.LastRunAt = lastRunAt
+	})
+}
+
+func (m *memoryRepository) RecordRun(_ context.Context, o StandingOrder) error {
+	return m.update(o.ID, o.Version, func(current *StandingOrder) {
+		current.Status = o.Status
+		current.CurrentOccurrence = o.CurrentOccurrence
+		current.NextRunAt = o.NextRunAt
+		current.Attempts = o.Attempts
+		current.LastRunAt = o.LastRunAt
+	})
+}
+
+func (m *memoryRepository) update(id string, version int, apply func(*StandingOrder)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.orders[id]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if current.Version != version {
+		return ErrOrderChanged
+	}
+	apply(&current)
+	current.Version = version + 1
+	current.UpdatedAt = time.Now().UTC()
+	m.orders[id] = current
+	return nil
+}
+
+func (m *memoryRepository) AddExecution(_ context.Context, e Execution) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.executions[e.OrderID] = append(m.executions[e.OrderID], e)
+	return nil
+}
+
+func (m *memoryRepository) ListExecutions(_ context.Context, orderID string, limit int) ([]Execution, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	src := m.executions[orderID]
+	out := make([]Execution, 0, len(src))
+	for i := len(src) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, src[i])
+	}
+	return out, nil
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"errors"
+	"time"
+)
+
+// Standing order statuses.
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+	StatusCompleted = "completed"
+)
+
+// Execution outcomes.
+const (
+	ExecutionSucceeded = "succeeded"
+	ExecutionRetrying  = "retrying"
+	ExecutionFailed    = "failed"
+)
+
+var (
+	// ErrOrderNotFound indicates no standing order matches the lookup for this owner.
+	ErrOrderNotFound = errors.New("standing order not found")
+	// ErrInvalidState indicates the order status does not allow the requested change.
+	ErrInvalidState = errors.New("standing order cannot change from its current status")
+	// ErrOrderChanged indicates the order was changed by someone else since it was read.
+	ErrOrderChanged = errors.New("standing order was changed concurrently, reload and retry")
+)
+
+// StandingOrder is a recurring transfer from one of the owner's wallets.
+type StandingOrder struct {
+	ID           string
+	OwnerUserID  string
+	FromWalletID string
+	ToWalletID   string
+	Amount       int64
+	Memo         string
+	Rule         Rule
+	StartAt      time.Time
+	// EndAt is optional; no occurrence is scheduled after it.
+	EndAt  time.Time
+	Status string
+	// CurrentOccurrence is the scheduled time being executed; its ClientTxID is derived from it.
+	CurrentOccurrence time.Time
+	// NextRunAt is when the worker next attempts CurrentOccurrence, later than it while retrying.
+	NextRunAt time.Time
+	// Attempts counts failed attempts for CurrentOccurrence.
+	Attempts  int
+	LastRunAt time.Time
+	// Version increases with every stored change; writes made from an older read are refused.
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ClientTxID derives a deterministic ledger idempotency key for one occurrence, so an
+// occurrence is never paid twice however many times the worker retries it.
+func (o StandingOrder) ClientTxID(occurrence time.Time) string {
+	return "standing:" + o.ID + ":" + occurrence.UTC().Format("20060102T1504")
+}
+
+// Execution records one attempt to pay an occurrence.
+type Execution struct {
+	ID            string
+	OrderID       string
+	Occurrence    time.Time
+	Attempt       int
+	Status        string
+	Error         string
+	TransactionID string
+	CreatedAt     time.Time
+}
@@ -0,0 +1,208 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists standing orders and their execution history.
+type Repository interface {
+	Create(ctx context.Context, o StandingOrder) error
+	Get(ctx context.Context, id string) (StandingOrder, error)
+	ListByOwner(ctx context.Context, ownerUserID string) ([]StandingOrder, error)
+	ListDue(ctx context.Context, now time.Time, limit int) ([]StandingOrder, error)
+	// Update stores an owner's change to o only while the stored order is still at
+	// o.Version, and returns ErrOrderChanged otherwise.
+	Update(ctx context.Context, o StandingOrder) error
+	// RecordRun stores the outcome of a worker run (status, occurrence, next run, attempts and
+	// last run) under the same version check. A run that raced an owner's change is dropped,
+	// and the occurrence runs again against the changed order.
+	RecordRun(ctx context.Context, o StandingOrder) error
+	AddExecution(ctx context.Context, e Execution) error
+	ListExecutions(ctx context.Context, orderID string, limit int) ([]Execution, error)
+}
+
+// PostgresRepository stores standing orders in PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed standing order repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const orderColumns = `id::text, owner_user_id::text, from_wallet_id::text, to_wallet_id::text, amount, memo,
+        frequency, interval, cron, start_at, end_at, status, current_occurrence, next_run_at, attempts,
+        last_run_at, version, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row rowScanner) (StandingOrder, error) {
+	var (
+		o                StandingOrder
+		endAt, lastRunAt *time.Time
+	)
+	err := row.Scan(&o.ID, &o.OwnerUserID, &o.FromWalletID, &o.ToWalletID, &o.Amount, &o.Memo,
+		&o.Rule.Frequency, &o.Rule.Interval, &o.Rule.Cron, &o.StartAt, &endAt, &o.Status, &o.CurrentOccurrence,
+		&o.NextRunAt, &o.Attempts, &lastRunAt, &o.Version, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return StandingOrder{}, ErrOrderNotFound
+		}
+		return StandingOrder{}, err
+	}
+	if endAt != nil {
+		o.EndAt = endAt.UTC()
+	}
+	if lastRunAt != nil {
+		o.LastRunAt = lastRunAt.UTC()
+	}
+	o.StartAt = o.StartAt.UTC()
+	o.CurrentOccurrence = o.CurrentOccurrence.UTC()
+	o.NextRunAt = o.NextRunAt.UTC()
+	o.CreatedAt = o.CreatedAt.UTC()
+	o.UpdatedAt = o.UpdatedAt.UTC()
+	return o, nil
+}
+
+func nullableTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// Create inserts a standing order.
+func (r *PostgresRepository) Create(ctx context.Context, o StandingOrder) error {
+	_, err := r.db.Exec(ctx, `INSERT INTO standing_orders
+        (id, owner_user_id, from_wallet_id, to_wallet_id, amount, memo, frequency, interval, cron, start_at, end_at,
+         status, current_occurrence, next_run_at, attempts, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`,
+		o.ID, o.OwnerUserID, o.FromWalletID, o.ToWalletID, o.Amount, o.Memo, o.Rule.Frequency, o.Rule.Interval, o.Rule.Cron,
+		o.StartAt.UTC(), nullableTime(o.EndAt), o.Status, o.CurrentOccurrence.UTC(), o.NextRunAt.UTC(), o.Attempts,
+		o.CreatedAt.UTC(), o.UpdatedAt.UTC())
+	return err
+}
+
+// Get fetches a standing order by ID.
+func (r *PostgresRepository) Get(ctx context.Context, id string) (StandingOrder, error) {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return StandingOrder{}, ErrOrderNotFound
+	}
+	return scanOrder(r.db.QueryRow(ctx, `SELECT `+orderColumns+` FROM standing_orders WHERE id = $1`, orderID))
+}
+
+// ListByOwner returns an owner's standing orders, newest first.
+func (r *PostgresRepository) ListByOwner(ctx context.Context, ownerUserID string) ([]StandingOrder, error) {
+	ownerID, err := uuid.Parse(ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	return r.list(ctx, `SELECT `+orderColumns+` FROM standing_orders
+        WHERE owner_user_id = $1 ORDER BY created_at DESC`, ownerID)
+}
+
+// ListDue returns active orders whose next run is due, oldest first.
+func (r *PostgresRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]StandingOrder, error) {
+	return r.list(ctx, `SELECT `+orderColumns+` FROM standing_orders
+        WHERE status = 'active' AND next_run_at <= $1 ORDER BY next_run_at LIMIT $2`, now.UTC(), limit)
+}
+
+func (r *PostgresRepository) list(ctx context.Context, query string, args ...any) ([]StandingOrder, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []StandingOrder
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// Update stores an owner's change to a standing order if it has not changed since it was read.
+func (r *PostgresRepository) Update(ctx context.Context, o StandingOrder) error {
+	return r.update(ctx, `UPDATE standing_orders
+        SET amount = $3, memo = $4, end_at = $5, status = $6, current_occurrence = $7, next_run_at = $8,
+            attempts = $9, version = version + 1, updated_at = $10
+        WHERE id = $1 AND version = $2`, o.ID, o.Version,
+		o.Amount, o.Memo, nullableTime(o.EndAt), o.Status, o.CurrentOccurrence.UTC(), o.NextRunAt.UTC(),
+		o.Attempts, time.Now().UTC())
+}
+
+// RecordRun stores the outcome of a worker run if the order has not changed since it was read.
+func (r *PostgresRepository) RecordRun(ctx context.Context, o StandingOrder) error {
+	return r.update(ctx, `UPDATE standing_orders
+        SET status = $3, current_occurrence = $4, next_run_at = $5, attempts = $6, last_run_at = $7,
+            version = version + 1, updated_at = $8
+        WHERE id = $1 AND version = $2`, o.ID, o.Version,
+		o.Status, o.CurrentOccurrence.UTC(), o.NextRunAt.UTC(), o.Attempts, nullableTime(o.LastRunAt), time.Now().UTC())
+}
+
+func (r *PostgresRepository) update(ctx context.Context, query, id string, version int, args ...any) error {
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		return ErrOrderNotFound
+	}
+	cmd, err := r.db.Exec(ctx, query, append([]any{orderID, version}, args...)...)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrOrderChanged
+	}
+	return nil
+}
+
+// AddExecution records an execution attempt.
+func (r *PostgresRepository) AddExecution(ctx context.Context, e Execution) error {
+	var txID any
+	if e.TransactionID != "" {
+		txID = e.TransactionID
+	}
+	_, err := r.db.Exec(ctx, `INSERT INTO standing_order_executions
+        (id, order_id, occurrence, attempt, status, error, transaction_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		e.ID, e.OrderID, e.Occurrence.UTC(), e.Attempt, e.Status, e.Error, txID, e.CreatedAt.UTC())
+	return err
+}
+
+// ListExecutions returns an order's most recent execution attempts.
+func (r *PostgresRepository) ListExecutions(ctx context.Context, orderID string, limit int) ([]Execution, error) {
+	id, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT id::text, order_id::text, occurrence, attempt, status, error,
+        COALESCE(transaction_id::text, ''), created_at
+        FROM standing_order_executions WHERE order_id = $1 ORDER BY created_at DESC LIMIT $2`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Execution
+	for rows.Next() {
+		var e Execution
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.Occurrence, &e.Attempt, &e.Status, &e.Error, &e.TransactionID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Occurrence = e.Occurrence.UTC()
+		e.CreatedAt = e.CreatedAt.UTC()
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,221 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/clock"
+)
+
+// Rule frequencies.
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+	FrequencyMonthly = "monthly"
+	FrequencyCron    = "cron"
+)
+
+// maxOccurrenceScan bounds the search for the next occurrence of a rule.
+const maxOccurrenceScan = 10_000
+
+// Rule describes when a standing order repeats. Calendar frequencies repeat every Interval
+// days, weeks or months from the order's start; FrequencyCron uses a five-field expression
+// (minute hour day-of-month month day-of-week) evaluated in WAT.
+type Rule struct {
+	Frequency string
+	Interval  int
+	Cron      string
+}
+
+// Validate checks the rule is well formed.
+func (r Rule) Validate() error {
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		if r.Interval < 1 || r.Interval > 12 {
+			return fmt.Errorf("interval must be between 1 and 12")
+		}
+		return nil
+	case FrequencyCron:
+		_, err := parseCron(r.Cron)
+		return err
+	default:
+		return fmt.Errorf("frequency must be daily, weekly, monthly or cron")
+	}
+}
+
+// First returns the first occurrence at or after start.
+func (r Rule) First(start time.Time) (time.Time, error) {
+	if r.Frequency == FrequencyCron {
+		return r.Next(start, start.Add(-time.Minute))
+	}
+	return start, nil
+}
+
+// Next returns the first occurrence strictly after the given time. Calendar occurrences are
+// computed from the anchor so month-end clamping does not drift (31 Jan, 28 Feb, 31 Mar).
+func (r Rule) Next(anchor, after time.Time) (time.Time, error) {
+	if r.Frequency == FrequencyCron {
+		expr, err := parseCron(r.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return expr.next(after)
+	}
+	anchor = anchor.In(clock.WAT)
+	for k := 0; k < maxOccurrenceScan; k++ {
+		occ := r.occurrence(anchor, k)
+		if occ.After(after) {
+			return occ.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no occurrence found")
+}
+
+func (r Rule) occurrence(anchor time.Time, k int) time.Time {
+	switch r.Frequency {
+	case FrequencyDaily:
+		return anchor.AddDate(0, 0, k*r.Interval)
+	case FrequencyWeekly:
+		return anchor.AddDate(0, 0, 7*k*r.Interval)
+	default:
+		months := anchor.Month() + time.Month(k*r.Interval)
+		first := time.Date(anchor.Year(), months, 1, anchor.Hour(), anchor.Minute(), 0, 0, clock.WAT)
+		day := anchor.Day()
+		if last := daysIn(first); day > last {
+			day = last
+		}
+		return first.AddDate(0, 0, day-1)
+	}
+}
+
+func daysIn(firstOfMonth time.Time) int {
+	return firstOfMonth.AddDate(0, 1, -1).Day()
+}
+
+// cronExpr holds the allowed values of each cron field.
+type cronExpr struct {
+	minute, hour, dom, month, dow [64]bool
+	domAny, dowAny                bool
+}
+
+func parseCron(s string) (cronExpr, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 5 {
+		return cronExpr{}, fmt.Errorf("cron expression must have 5 fields")
+	}
+	var (
+		e   cronExpr
+		err error
+	)
+	specs := []struct {
+		set      *[64]bool
+		min, max int
+	}{
+		{&e.minute, 0, 59}, {&e.hour, 0, 23}, {&e.dom, 1, 31}, {&e.month, 1, 12}, {&e.dow, 0, 7},
+	}
+	for i, spec := range specs {
+		if err = parseCronField(fields[i], spec.min, spec.max, spec.set); err != nil {
+			return cronExpr{}, fmt.Errorf("cron field %d: %w", i+1, err)
+		}
+	}
+	if e.dow[7] {
+		e.dow[0] = true
+	}
+	e.domAny = fields[2] == "*"
+	e.dowAny = fields[4] == "*"
+	// Sub-daily schedules would let a standing order drain a wallet within hours.
+	if count(e.minute) != 1 || count(e.hour) != 1 {
+		return cronExpr{}, fmt.Errorf("cron expression must run at most once a day")
+	}
+	return e, nil
+}
+
+func count(set [64]bool) int {
+	n := 0
+	for _, ok := range set {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+func parseCronField(field string, min, max int, set *[64]bool) error {
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = n
+		}
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			n, err := strconv.Atoi(from)
+			if err != nil {
+				return fmt.Errorf("invalid value %q", from)
+			}
+			lo, hi = n, n
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return fmt.Errorf("invalid value %q", to)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return fmt.Errorf("value out of range %d-%d", min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return nil
+}
+
+func (e cronExpr) dayMatches(t time.Time) bool {
+	dom, dow := e.dom[t.Day()], e.dow[int(t.Weekday())]
+	switch {
+	case e.domAny && e.dowAny:
+		return true
+	case e.domAny:
+		return dow
+	case e.dowAny:
+		return dom
+	default:
+		// Standard cron semantics: either restricted day field may match.
+		return dom || dow
+	}
+}
+
+// next finds the first matching minute after t, scanning day by day.
+func (e cronExpr) next(after time.Time) (time.Time, error) {
+	t := after.In(clock.WAT).Truncate(time.Minute).Add(time.Minute)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, clock.WAT)
+	for i := 0; i < 366*5; i++ {
+		if e.month[int(day.Month())] && e.dayMatches(day) {
+			for h := 0; h < 24; h++ {
+				if !e.hour[h] {
+					continue
+				}
+				for m := 0; m < 60; m++ {
+					if !e.minute[m] {
+						continue
+					}
+					candidate := day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+					if !candidate.Before(t) {
+						return candidate.UTC(), nil
+					}
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}, fmt.Errorf("cron expression never matches")
+}
@@ -0,0 +1,462 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/payments"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const (
+	maxMemoLength    = 140
+	executionsLimit  = 50
+	dueBatchSize     = 100
+	maxActivePerUser = 20
+	// RetryDelay is how long the worker waits before retrying an occurrence that failed for
+	// insufficient funds.
+	RetryDelay = time.Hour
+	// MaxAttempts bounds how often one occurrence is tried before it is skipped.
+	MaxAttempts = 3
+)
+
+// Service manages standing orders and executes their due occurrences.
+type Service struct {
+	repo     Repository
+	payments *payments.Service
+	wallets  *wallet.Service
+	users    *identity.Service
+	notifier notification.Notifier
+	guard    *guard.Guard
+	logger   *slog.Logger
+	now      func() time.Time
+}
+
+// NewService constructs a standing order service.
+func NewService(repo Repository, paymentSvc *payments.Service, wallets *wallet.Service, users *identity.Service, notifier notification.Notifier, outgoing *guard.Guard, logger *slog.Logger) *Service {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Service{
+		repo:     repo,
+		payments: paymentSvc,
+		wallets:  wallets,
+		users:    users,
+		notifier: notifier,
+		guard:    outgoing,
+		logger:   logger,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// CreateInput captures a new standing order. Exactly one of ToWalletID and ToPhone names the
+// beneficiary; a phone resolves to that user's primary wallet.
+type CreateInput struct {
+	OwnerUserID string
+	// FromWalletID defaults to the owner's primary wallet.
+	FromWalletID string
+	ToWalletID   string
+	ToPhone      string
+	Amount       int64
+	Memo         string
+	Rule         Rule
+	// StartAt defaults to now; EndAt is optional.
+	StartAt time.Time
+	EndAt   time.Time
+	// OTPCode confirms orders of at least the high-value amount. Runs are not confirmed
+	// again, so the code given here authorizes every occurrence.
+	OTPCode string
+}
+
+// Create validates and stores an active standing order.
+func (s *Service) Create(ctx context.Context, input CreateInput) (StandingOrder, error) {
+	if input.Amount <= 0 {
+		return StandingOrder{}, fmt.Errorf("amount must be positive")
+	}
+	memo := strings.TrimSpace(input.Memo)
+	if len(memo) > maxMemoLength {
+		return StandingOrder{}, fmt.Errorf("memo must be at most %d characters", maxMemoLength)
+	}
+	if err := input.Rule.Validate(); err != nil {
+		return StandingOrder{}, err
+	}
+	now := s.now()
+	start := input.StartAt
+	if start.IsZero() {
+		start = now
+	}
+	start = start.UTC().Truncate(time.Minute)
+	if start.Before(now.Add(-time.Minute)) {
+		return StandingOrder{}, fmt.Errorf("start_at must not be in the past")
+	}
+	if !input.EndAt.IsZero() && !input.EndAt.After(start) {
+		return StandingOrder{}, fmt.Errorf("end_at must be after start_at")
+	}
+
+	existing, err := s.repo.ListByOwner(ctx, input.OwnerUserID)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	active := 0
+	for _, o := range existing {
+		if o.Status == StatusActive || o.Status == StatusPaused {
+			active++
+		}
+	}
+	if active >= maxActivePerUser {
+		return StandingOrder{}, fmt.Errorf("at most %d standing orders may be active", maxActivePerUser)
+	}
+
+	from, err := s.sourceWallet(ctx, input.OwnerUserID, input.FromWalletID)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	to, err := s.beneficiary(ctx, input.ToWalletID, input.ToPhone)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	if to.ID == from.ID {
+		return StandingOrder{}, fmt.Errorf("beneficiary is the source wallet")
+	}
+	if err := to.CanCredit(); err != nil {
+		return StandingOrder{}, err
+	}
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: input.OwnerUserID, Op: risk.OpPayment, Amount: input.Amount, OTPCode: input.OTPCode, Planned: true}); err != nil {
+		return StandingOrder{}, err
+	}
+
+	first, err := input.Rule.First(start)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	if !input.EndAt.IsZero() && first.After(input.EndAt) {
+		return StandingOrder{}, fmt.Errorf("no occurrence falls before end_at")
+	}
+	o := StandingOrder{
+		ID:                uuid.NewString(),
+		OwnerUserID:       input.OwnerUserID,
+		FromWalletID:      from.ID,
+		ToWalletID:        to.ID,
+		Amount:            input.Amount,
+		Memo:              memo,
+		Rule:              input.Rule,
+		StartAt:           start,
+		EndAt:             input.EndAt.UTC(),
+		Status:            StatusActive,
+		CurrentOccurrence: first,
+		NextRunAt:         first,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := s.repo.Create(ctx, o); err != nil {
+		return StandingOrder{}, err
+	}
+	return o, nil
+}
+
+func (s *Service) sourceWallet(ctx context.Context, ownerUserID, walletID string) (wallet.Wallet, error) {
+	var (
+		w   wallet.Wallet
+		err error
+	)
+	if walletID != "" {
+		w, err = s.wallets.Get(ctx, walletID)
+	} else {
+		w, err = s.wallets.GetByOwner(ctx, ownerUserID)
+	}
+	if err != nil {
+		return wallet.Wallet{}, err
+	}
+	if w.OwnerID != ownerUserID {
+		return wallet.Wallet{}, wallet.ErrNotOwner
+	}
+	return w, w.CanDebit()
+}
+
+func (s *Service) beneficiary(ctx context.Context, walletID, phone string) (wallet.Wallet, error) {
+	switch {
+	case walletID != "" && phone != "":
+		return wallet.Wallet{}, fmt.Errorf("provide either to_wallet_id or to_phone, not both")
+	case walletID != "":
+		return s.wallets.Get(ctx, walletID)
+	case phone != "":
+		user, err := s.users.LookupByPhone(ctx, phone)
+		if err != nil {
+			return wallet.Wallet{}, fmt.Errorf("no registered user for phone %s", phone)
+		}
+		return s.wallets.GetByOwner(ctx, user.ID)
+	default:
+		return wallet.Wallet{}, fmt.Errorf("to_wallet_id or to_phone is required")
+	}
+}
+
+// Get returns a standing order owned by the user.
+func (s *Service) Get(ctx context.Context, orderID, ownerUserID string) (StandingOrder, error) {
+	o, err := s.repo.Get(ctx, orderID)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	if o.OwnerUserID != ownerUserID {
+		return StandingOrder{}, ErrOrderNotFound
+	}
+	return o, nil
+}
+
+// List returns the user's standing orders.
+func (s *Service) List(ctx context.Context, ownerUserID string) ([]StandingOrder, error) {
+	return s.repo.ListByOwner(ctx, ownerUserID)
+}
+
+// UpdateInput carries optional changes to an order; nil fields are left unchanged. A zero
+// EndAt removes the end date.
+type UpdateInput struct {
+	Amount *int64
+	Memo   *string
+	EndAt  *time.Time
+	// OTPCode confirms raising the amount to the high-value amount or above.
+	OTPCode string
+}
+
+// Update changes the amount, memo or end date of an active or paused order. The change
+// applies from the next occurrence not yet paid.
+func (s *Service) Update(ctx context.Context, orderID, ownerUserID string, input UpdateInput) (StandingOrder, error) {
+	o, err := s.Get(ctx, orderID, ownerUserID)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	if o.Status != StatusActive && o.Status != StatusPaused {
+		return o, ErrInvalidState
+	}
+	if input.Amount != nil {
+		if *input.Amount <= 0 {
+			return StandingOrder{}, fmt.Errorf("amount must be positive")
+		}
+		if *input.Amount > o.Amount {
+			if err := s.guard.Check(ctx, guard.Outgoing{UserID: ownerUserID, Op: risk.OpPayment, Amount: *input.Amount, OTPCode: input.OTPCode, Planned: true}); err != nil {
+				return StandingOrder{}, err
+			}
+		}
+		o.Amount = *input.Amount
+	}
+	if input.Memo != nil {
+		memo := strings.TrimSpace(*input.Memo)
+		if len(memo) > maxMemoLength {
+			return StandingOrder{}, fmt.Errorf("memo must be at most %d characters", maxMemoLength)
+		}
+		o.Memo = memo
+	}
+	if input.EndAt != nil {
+		end := input.EndAt.UTC()
+		if !end.IsZero() && end.Before(o.CurrentOccurrence) {
+			return StandingOrder{}, fmt.Errorf("end_at must not precede the next occurrence")
+		}
+		o.EndAt = end
+	}
+	if err := s.repo.Update(ctx, o); err != nil {
+		return StandingOrder{}, err
+	}
+	o.Version++
+	return o, nil
+}
+
+// Pause stops an active order from running until it is resumed.
+func (s *Service) Pause(ctx context.Context, orderID, ownerUserID string) (StandingOrder, error) {
+	o, err := s.Get(ctx, orderID, ownerUserID)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	if o.Status != StatusActive {
+		return o, ErrInvalidState
+	}
+	o.Status = StatusPaused
+	if err := s.repo.Update(ctx, o); err != nil {
+		return StandingOrder{}, err
+	}
+	o.Version++
+	return o, nil
+}
+
+// Resume reactivates a paused order. Occurrences missed while paused are skipped rather than
+// paid in a burst.
+func (s *Service) Resume(ctx context.Context, orderID, ownerUserID string) (StandingOrder, error) {
+	o, err := s.Get(ctx, orderID, ownerUserID)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	if o.Status != StatusPaused {
+		return o, ErrInvalidState
+	}
+	now := s.now()
+	if o.CurrentOccurrence.Before(now) {
+		next, err := o.Rule.Next(o.StartAt, now)
+		if err != nil {
+			return StandingOrder{}, err
+		}
+		o.CurrentOccurrence = next
+		o.Attempts = 0
+	}
+	o.NextRunAt = o.CurrentOccurrence
+	o.Status = StatusActive
+	if !o.EndAt.IsZero() && o.CurrentOccurrence.After(o.EndAt) {
+		o.Status = StatusCompleted
+	}
+	if err := s.repo.Update(ctx, o); err != nil {
+		return StandingOrder{}, err
+	}
+	o.Version++
+	return o, nil
+}
+
+// Cancel permanently stops an order.
+func (s *Service) Cancel(ctx context.Context, orderID, ownerUserID string) (StandingOrder, error) {
+	o, err := s.Get(ctx, orderID, ownerUserID)
+	if err != nil {
+		return StandingOrder{}, err
+	}
+	if o.Status != StatusActive && o.Status != StatusPaused {
+		return o, ErrInvalidState
+	}
+	o.Status = StatusCancelled
+	if err := s.repo.Update(ctx, o); err != nil {
+		return StandingOrder{}, err
+	}
+	o.Version++
+	return o, nil
+}
+
+// Executions returns the order's recent execution attempts, newest first.
+func (s *Service) Executions(ctx context.Context, orderID, ownerUserID string) ([]Execution, error) {
+	if _, err := s.Get(ctx, orderID, ownerUserID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListExecutions(ctx, orderID, executionsLimit)
+}
+
+// RunDue executes every order due at now and returns how many were attempted.
+func (s *Service) RunDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.repo.ListDue(ctx, now, dueBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, o := range due {
+		if err := ctx.Err(); err != nil {
+			return i, err
+		}
+		if err := s.execute(ctx, o, now); err != nil {
+			s.logger.Error("standing order execution failed", slog.String("order_id", o.ID), slog.String("error", err.Error()))
+		}
+	}
+	return len(due), nil
+}
+
+// execute pays the order's current occurrence. The ledger key is derived from the
+// occurrence, so a crash between the transfer and the order update cannot pay it twice.
+// The run only stores its outcome while the order is unchanged since it was listed; an owner
+// who paused, cancelled or edited it meanwhile keeps their change, and an order still due
+// runs the occurrence again, which the ledger key turns into a no-op payment.
+// Each run is screened again, so an account restricted since the order was set up stops
+// paying out; the occurrence is skipped like any other failure.
+func (s *Service) execute(ctx context.Context, o StandingOrder, now time.Time) error {
+	occurrence := o.CurrentOccurrence
+	var res payments.TransferResult
+	err := s.guard.Check(ctx, guard.Outgoing{UserID: o.OwnerUserID, Op: risk.OpPayment, Amount: o.Amount, Preauthorized: true})
+	if err == nil {
+		res, err = s.payments.Transfer(ctx, payments.TransferInput{
+			FromWalletID:    o.FromWalletID,
+			ToWalletID:      o.ToWalletID,
+			Amount:          o.Amount,
+			ClientTxID:      o.ClientTxID(occurrence),
+			RequestorUserID: o.OwnerUserID,
+		})
+	}
+	if errors.Is(err, ledger.ErrDuplicateTransaction) {
+		err = nil
+	}
+
+	o.Attempts++
+	o.LastRunAt = now
+	exec := Execution{
+		ID:            uuid.NewString(),
+		OrderID:       o.ID,
+		Occurrence:    occurrence,
+		Attempt:       o.Attempts,
+		TransactionID: res.TransactionID,
+		CreatedAt:     now,
+	}
+	advance := true
+	switch {
+	case err == nil:
+		exec.Status = ExecutionSucceeded
+		s.notify(ctx, o, fmt.Sprintf("Standing order paid %d to wallet %s", o.Amount, o.ToWalletID))
+	case errors.Is(err, ledger.ErrInsufficientFunds) && o.Attempts < MaxAttempts:
+		exec.Status = ExecutionRetrying
+		exec.Error = err.Error()
+		o.NextRunAt = now.Add(RetryDelay)
+		advance = false
+		s.notify(ctx, o, fmt.Sprintf("Standing order of %d could not be paid: insufficient funds. We will retry in %s", o.Amount, RetryDelay))
+	case errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked),
+		errors.Is(err, wallet.ErrNotOwner), errors.Is(err, payments.ErrNotOwner):
+		// Retrying cannot succeed until the owner acts, so stop rather than skip occurrences.
+		exec.Status = ExecutionFailed
+		exec.Error = err.Error()
+		o.Status = StatusPaused
+		advance = false
+		s.notify(ctx, o, fmt.Sprintf("Standing order paused: %v", err))
+	default:
+		exec.Status = ExecutionFailed
+		exec.Error = err.Error()
+		s.notify(ctx, o, fmt.Sprintf("Standing order of %d was skipped: %v", o.Amount, err))
+	}
+	if advance {
+		if err := s.advance(&o); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.AddExecution(ctx, exec); err != nil {
+		return err
+	}
+	if err := s.repo.RecordRun(ctx, o); err != nil {
+		if errors.Is(err, ErrOrderChanged) {
+			s.logger.Info("standing order changed during its run", slog.String("order_id", o.ID))
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) advance(o *StandingOrder) error {
+	next, err := o.Rule.Next(o.StartAt, o.CurrentOccurrence)
+	if err != nil {
+		return err
+	}
+	o.CurrentOccurrence = next
+	o.NextRunAt = next
+	o.Attempts = 0
+	if !o.EndAt.IsZero() && next.After(o.EndAt) {
+		o.Status = StatusCompleted
+	}
+	return nil
+}
+
+func (s *Service) notify(ctx context.Context, o StandingOrder, body string) {
+	if s.notifier == nil {
+		return
+	}
+	_ = s.notifier.Send(ctx, notification.Message{
+		Kind:        notification.KindStandingOrder,
+		Destination: o.OwnerUserID,
+		Body:        body,
+	})
+}
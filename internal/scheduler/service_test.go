@@ -0,0 +1,248 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/congo-pay/congo_pay/internal/clock"
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/payments"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+type fixture struct {
+	svc     *Service
+	repo    Repository
+	led     ledger.Ledger
+	wallets *wallet.Service
+	users   *identity.Service
+	now     time.Time
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	led := ledger.NewInMemory()
+	wallets := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	repo := NewMemoryRepository()
+	f := &fixture{repo: repo, led: led, wallets: wallets, users: users, now: time.Date(2025, 1, 30, 8, 0, 0, 0, time.UTC)}
+	f.svc = NewService(repo, payments.NewService(led, wallets, nil, nil), wallets, users, nil, nil, nil)
+	f.svc.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *fixture) user(t *testing.T, phone string, balance int64) (identity.User, wallet.Wallet) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := f.users.Register(ctx, identity.Credentials{Phone: phone, PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register %s: %v", phone, err)
+	}
+	w, err := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: u.ID})
+	if err != nil {
+		t.Fatalf("wallet: %v", err)
+	}
+	ledger.SeedBalance(f.led, w.AccountCode, balance)
+	return u, w
+}
+
+func TestRuleMonthlyClampsToMonthEnd(t *testing.T) {
+	r := Rule{Frequency: FrequencyMonthly, Interval: 1}
+	anchor := time.Date(2025, 1, 31, 9, 0, 0, 0, clock.WAT)
+	want := []string{"2025-02-28", "2025-03-31", "2025-04-30"}
+	after := anchor
+	for _, w := range want {
+		next, err := r.Next(anchor, after)
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		if got := next.In(clock.WAT).Format("2006-01-02"); got != w {
+			t.Fatalf("next after %s = %s, want %s", after.In(clock.WAT).Format("2006-01-02"), got, w)
+		}
+		after = next
+	}
+}
+
+func TestRuleCron(t *testing.T) {
+	r := Rule{Frequency: FrequencyCron, Cron: "30 9 1,15 * *"}
+	if err := r.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	next, err := r.Next(time.Time{}, time.Date(2025, 3, 1, 9, 30, 0, 0, clock.WAT))
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	if want := time.Date(2025, 3, 15, 9, 30, 0, 0, clock.WAT); !next.Equal(want) {
+		t.Fatalf("next = %s, want %s", next.In(clock.WAT), want)
+	}
+
+	for _, expr := range []string{"* 9 * * *", "0 9-17 * * *", "0 9 * *", "60 9 * * *"} {
+		if err := (Rule{Frequency: FrequencyCron, Cron: expr}).Validate(); err == nil {
+			t.Fatalf("expected %q to be rejected", expr)
+		}
+	}
+}
+
+func TestServiceRunDueExecutesAndRetries(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, ownerWallet := f.user(t, "+242060000001", 5_000)
+	_, landlord := f.user(t, "+242060000002", 0)
+
+	o, err := f.svc.Create(ctx, CreateInput{
+		OwnerUserID: owner.ID,
+		ToPhone:     "+242060000002",
+		Amount:      4_000,
+		Memo:        "rent",
+		Rule:        Rule{Frequency: FrequencyMonthly, Interval: 1},
+		StartAt:     f.now,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if o.ToWalletID != landlord.ID || !o.NextRunAt.Equal(f.now) {
+		t.Fatalf("unexpected order: %+v", o)
+	}
+
+	if n, err := f.svc.RunDue(ctx, f.now); err != nil || n != 1 {
+		t.Fatalf("run due: %d, %v", n, err)
+	}
+	if bal, _ := f.led.Balance(ctx, landlord.AccountCode); bal != 4_000 {
+		t.Fatalf("landlord balance = %d, want 4000", bal)
+	}
+	o, _ = f.svc.Get(ctx, o.ID, owner.ID)
+	if want := f.now.AddDate(0, 1, -2); !o.NextRunAt.Equal(want) {
+		t.Fatalf("next run = %s, want %s (clamped to 28 Feb)", o.NextRunAt, want)
+	}
+
+	// Re-running the same occurrence, as after a crash before the order update, pays nothing.
+	stale := o
+	stale.CurrentOccurrence, stale.NextRunAt = f.now, f.now
+	_ = f.repo.Update(ctx, stale)
+	if _, err := f.svc.RunDue(ctx, f.now); err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if bal, _ := f.led.Balance(ctx, ownerWallet.AccountCode); bal != 1_000 {
+		t.Fatalf("occurrence paid twice: owner balance %d", bal)
+	}
+
+	// The second occurrence lacks funds: it retries after RetryDelay, then gives up.
+	f.now = o.CurrentOccurrence
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		if _, err := f.svc.RunDue(ctx, f.now); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		f.now = f.now.Add(RetryDelay)
+	}
+	execs, _ := f.svc.Executions(ctx, o.ID, owner.ID)
+	if len(execs) != 5 || execs[0].Status != ExecutionFailed || execs[1].Status != ExecutionRetrying {
+		t.Fatalf("unexpected executions: %+v", execs)
+	}
+	o, _ = f.svc.Get(ctx, o.ID, owner.ID)
+	if o.Status != StatusActive || o.Attempts != 0 || o.NextRunAt.In(clock.WAT).Day() != 30 {
+		t.Fatalf("expected order to move on to the March occurrence: %+v", o)
+	}
+}
+
+func TestServicePauseResumeAndCompletion(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, _ := f.user(t, "+242060000011", 10_000)
+	_, school := f.user(t, "+242060000012", 0)
+
+	o, err := f.svc.Create(ctx, CreateInput{
+		OwnerUserID: owner.ID,
+		ToWalletID:  school.ID,
+		Amount:      1_000,
+		Rule:        Rule{Frequency: FrequencyWeekly, Interval: 1},
+		StartAt:     f.now,
+		EndAt:       f.now.AddDate(0, 0, 10),
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := f.svc.Pause(ctx, o.ID, owner.ID); err != nil {
+		t.Fatalf("pause: %v", err)
+	}
+	if n, _ := f.svc.RunDue(ctx, f.now); n != 0 {
+		t.Fatal("paused order must not run")
+	}
+
+	// Resuming after the first occurrence skips it instead of paying late.
+	f.now = f.now.Add(time.Hour)
+	o, err = f.svc.Resume(ctx, o.ID, owner.ID)
+	if err != nil || o.Status != StatusActive || !o.NextRunAt.Equal(o.StartAt.AddDate(0, 0, 7)) {
+		t.Fatalf("resume: %+v, %v", o, err)
+	}
+
+	f.now = o.NextRunAt
+	if _, err := f.svc.RunDue(ctx, f.now); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	o, _ = f.svc.Get(ctx, o.ID, owner.ID)
+	if o.Status != StatusCompleted {
+		t.Fatalf("expected completion after end_at, got %s", o.Status)
+	}
+	if _, err := f.svc.Cancel(ctx, o.ID, owner.ID); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected completed order to refuse cancel, got %v", err)
+	}
+}
+
+func TestServiceRunKeepsConcurrentCancel(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	owner, _ := f.user(t, "+242060000021", 10_000)
+	_, landlord := f.user(t, "+242060000022", 0)
+
+	o, err := f.svc.Create(ctx, CreateInput{
+		OwnerUserID: owner.ID,
+		ToWalletID:  landlord.ID,
+		Amount:      1_000,
+		Rule:        Rule{Frequency: FrequencyWeekly, Interval: 1},
+		StartAt:     f.now,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// The worker picked the order up, then the owner raised the amount before the run finished.
+	listed := o
+	amount := int64(1_500)
+	if _, err := f.svc.Update(ctx, o.ID, owner.ID, UpdateInput{Amount: &amount}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := f.svc.execute(ctx, listed, f.now); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	o, _ = f.svc.Get(ctx, o.ID, owner.ID)
+	if o.Amount != 1_500 || !o.CurrentOccurrence.Equal(f.now) {
+		t.Fatalf("run overwrote the owner's change: %+v", o)
+	}
+	// Running the occurrence again records it without paying it twice.
+	if err := f.svc.execute(ctx, o, f.now); err != nil {
+		t.Fatalf("rerun: %v", err)
+	}
+	if bal, _ := f.led.Balance(ctx, landlord.AccountCode); bal != 1_000 {
+		t.Fatalf("landlord balance = %d, want the occurrence paid once", bal)
+	}
+
+	// A cancel made meanwhile survives the run.
+	listed, _ = f.svc.Get(ctx, o.ID, owner.ID)
+	if _, err := f.svc.Cancel(ctx, o.ID, owner.ID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := f.svc.execute(ctx, listed, f.now); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	o, _ = f.svc.Get(ctx, o.ID, owner.ID)
+	if o.Status != StatusCancelled {
+		t.Fatalf("run overwrote the cancel: status %s", o.Status)
+	}
+
+	// Owner changes made from a stale read are refused rather than applied.
+	if err := f.repo.Update(ctx, listed); !errors.Is(err, ErrOrderChanged) {
+		t.Fatalf("expected ErrOrderChanged, got %v", err)
+	}
+}
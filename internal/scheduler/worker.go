@@ -0,0 +1,34 @@
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RunWorker executes due standing orders every interval until ctx is cancelled.
+func RunWorker(ctx context.Context, svc *Service, interval time.Duration, logger *slog.Logger) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.RunDue(ctx, svc.now())
+			if logger == nil {
+				continue
+			}
+			if err != nil {
+				logger.Error("standing order run failed", slog.Any("error", err))
+				continue
+			}
+			if n > 0 {
+				logger.Info("standing orders executed", slog.Int("count", n))
+			}
+		}
+	}
+}
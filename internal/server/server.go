@@ -19,6 +19,8 @@ type Server struct {
     cfg   config.Config
     db    *pgxpool.Pool
     cache *redis.Client
+    // stop cancels background workers started during route setup.
+    stop  context.CancelFunc
 }
 
 // New instantiates the HTTP server and delegates route wiring to routes.Setup.
@@ -27,13 +29,17 @@ func New(cfg config.Config, db *pgxpool.Pool, cache *redis.Client, logger *slog.
         AppName:      cfg.AppName,
         ReadTimeout:  30 * time.Second,
         WriteTimeout: 30 * time.Second,
+        // KYC submissions carry several document photos in one multipart request.
+        BodyLimit:    24 << 20,
     })
 
-    if err := routes.Setup(app, routes.Deps{Cfg: cfg, DB: db, Cache: cache, Logger: logger}); err != nil {
+    workers, stop := context.WithCancel(context.Background())
+    if err := routes.Setup(app, routes.Deps{Cfg: cfg, DB: db, Cache: cache, Logger: logger, Ctx: workers}); err != nil {
+        stop()
         return nil, err
     }
 
-    return &Server{app: app, cfg: cfg, db: db, cache: cache}, nil
+    return &Server{app: app, cfg: cfg, db: db, cache: cache, stop: stop}, nil
 }
 
 // Listen starts the HTTP server.
@@ -43,5 +49,6 @@ func (s *Server) Listen() error {
 
 // Shutdown gracefully stops the HTTP server.
 func (s *Server) Shutdown(ctx context.Context) error {
+    s.stop()
     return s.app.ShutdownWithContext(ctx)
 }
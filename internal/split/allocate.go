@@ -0,0 +1,133 @@
+package split
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const fullBasisPoints = 10_000
+
+// Validate checks a split definition independently of any amount.
+func Validate(shares []Share, rounding string, remainderShare int) error {
+	if len(shares) == 0 {
+		return fmt.Errorf("at least one share is required")
+	}
+	if len(shares) > MaxShares {
+		return fmt.Errorf("at most %d shares are allowed", MaxShares)
+	}
+	seen := make(map[string]struct{}, len(shares))
+	bps, percentShares := 0, 0
+	for i, sh := range shares {
+		if sh.WalletID == "" {
+			return fmt.Errorf("share %d: wallet_id is required", i+1)
+		}
+		if _, dup := seen[sh.WalletID]; dup {
+			return fmt.Errorf("share %d: wallet %s appears more than once", i+1, sh.WalletID)
+		}
+		seen[sh.WalletID] = struct{}{}
+		if len(strings.TrimSpace(sh.Label)) > 60 {
+			return fmt.Errorf("share %d: label must be at most 60 characters", i+1)
+		}
+		switch sh.Kind {
+		case ShareFixed:
+			if sh.Amount <= 0 || sh.BasisPoints != 0 {
+				return fmt.Errorf("share %d: fixed shares need a positive amount and no basis points", i+1)
+			}
+		case SharePercent:
+			if sh.BasisPoints <= 0 || sh.BasisPoints > fullBasisPoints || sh.Amount != 0 {
+				return fmt.Errorf("share %d: percent shares need 1-%d basis points and no amount", i+1, fullBasisPoints)
+			}
+			bps += sh.BasisPoints
+			percentShares++
+		default:
+			return fmt.Errorf("share %d: kind must be fixed or percent", i+1)
+		}
+	}
+	if percentShares > 0 && bps != fullBasisPoints {
+		return fmt.Errorf("percent shares must add up to %d basis points, got %d", fullBasisPoints, bps)
+	}
+	switch rounding {
+	case RoundingLargestRemainder:
+	case RoundingToShare:
+		if remainderShare < 0 || remainderShare >= len(shares) {
+			return fmt.Errorf("remainder share must index one of the shares")
+		}
+	default:
+		return fmt.Errorf("rounding must be largest_remainder or to_share")
+	}
+	return nil
+}
+
+// FixedTotal returns the sum of fixed shares, which is also the full price of a split made
+// only of fixed shares.
+func FixedTotal(shares []Share) int64 {
+	var total int64
+	for _, sh := range shares {
+		if sh.Kind == ShareFixed {
+			total += sh.Amount
+		}
+	}
+	return total
+}
+
+func hasPercent(shares []Share) bool {
+	for _, sh := range shares {
+		if sh.Kind == SharePercent {
+			return true
+		}
+	}
+	return false
+}
+
+// Allocate divides amount across the shares. Fixed shares are paid in full first and
+// percentage shares divide the rest; the result always sums to amount exactly. A split with
+// only fixed shares requires amount to equal their total.
+func Allocate(amount int64, shares []Share, rounding string, remainderShare int) ([]int64, error) {
+	if err := Validate(shares, rounding, remainderShare); err != nil {
+		return nil, err
+	}
+	fixed := FixedTotal(shares)
+	if !hasPercent(shares) {
+		if amount != fixed {
+			return nil, fmt.Errorf("amount must equal the fixed total of %d", fixed)
+		}
+	} else if amount <= fixed {
+		return nil, fmt.Errorf("amount must exceed the fixed total of %d", fixed)
+	}
+
+	out := make([]int64, len(shares))
+	rest := amount - fixed
+	type fraction struct {
+		index     int
+		remainder int64
+	}
+	var (
+		fractions []fraction
+		allocated int64
+	)
+	for i, sh := range shares {
+		if sh.Kind == ShareFixed {
+			out[i] = sh.Amount
+			continue
+		}
+		// rest*bps stays far below the int64 range for any realistic payment.
+		exact := rest * int64(sh.BasisPoints)
+		out[i] = exact / fullBasisPoints
+		allocated += out[i]
+		fractions = append(fractions, fraction{index: i, remainder: exact % fullBasisPoints})
+	}
+	leftover := rest - allocated
+	if leftover == 0 {
+		return out, nil
+	}
+	if rounding == RoundingToShare {
+		out[remainderShare] += leftover
+		return out, nil
+	}
+	sort.SliceStable(fractions, func(a, b int) bool { return fractions[a].remainder > fractions[b].remainder })
+	for k := int64(0); k < leftover; k++ {
+		out[fractions[k].index]++
+	}
+	return out, nil
+}
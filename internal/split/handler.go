@@ -0,0 +1,297 @@
+package split
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+// Handler exposes split template and split payment endpoints.
+type Handler struct {
+	service *Service
+}
+
+// NewHandler builds a split payment HTTP handler.
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+type templateRequest struct {
+	Name           string  `json:"name"`
+	Shares         []Share `json:"shares"`
+	Rounding       string  `json:"rounding"`
+	RemainderShare int     `json:"remainder_share"`
+}
+
+type statusRequest struct {
+	Status string `json:"status"`
+}
+
+type templateResponse struct {
+	ID             string    `json:"id"`
+	OwnerUserID    string    `json:"owner_user_id"`
+	Name           string    `json:"name"`
+	Shares         []Share   `json:"shares"`
+	FixedTotal     int64     `json:"fixed_total"`
+	Rounding       string    `json:"rounding"`
+	RemainderShare *int      `json:"remainder_share,omitempty"`
+	Status         string    `json:"status"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func toTemplateResponse(t Template) templateResponse {
+	resp := templateResponse{
+		ID:          t.ID,
+		OwnerUserID: t.OwnerUserID,
+		Name:        t.Name,
+		Shares:      t.Shares,
+		FixedTotal:  FixedTotal(t.Shares),
+		Rounding:    t.Rounding,
+		Status:      t.Status,
+		CreatedAt:   t.CreatedAt,
+		UpdatedAt:   t.UpdatedAt,
+	}
+	if t.Rounding == RoundingToShare {
+		idx := t.RemainderShare
+		resp.RemainderShare = &idx
+	}
+	return resp
+}
+
+type splitRequest struct {
+	TemplateID     string  `json:"template_id"`
+	Shares         []Share `json:"shares"`
+	Rounding       string  `json:"rounding"`
+	RemainderShare int     `json:"remainder_share"`
+	Amount         int64   `json:"amount"`
+}
+
+func (r splitRequest) input() SplitInput {
+	return SplitInput{TemplateID: r.TemplateID, Shares: r.Shares, Rounding: r.Rounding, RemainderShare: r.RemainderShare, Amount: r.Amount}
+}
+
+type payRequest struct {
+	splitRequest
+	FromWalletID string `json:"from_wallet_id"`
+	Reference    string `json:"reference"`
+	ClientTxID   string `json:"client_tx_id"`
+	OTPCode      string `json:"otp_code"`
+}
+
+type legResponse struct {
+	Index               int    `json:"index"`
+	BeneficiaryWalletID string `json:"beneficiary_wallet_id"`
+	BeneficiaryUserID   string `json:"beneficiary_user_id,omitempty"`
+	Label               string `json:"label,omitempty"`
+	Amount              int64  `json:"amount"`
+}
+
+func toLegResponses(legs []Leg) []legResponse {
+	out := make([]legResponse, 0, len(legs))
+	for _, l := range legs {
+		out = append(out, legResponse{
+			Index:               l.Index,
+			BeneficiaryWalletID: l.BeneficiaryWalletID,
+			BeneficiaryUserID:   l.BeneficiaryUserID,
+			Label:               l.Label,
+			Amount:              l.Amount,
+		})
+	}
+	return out
+}
+
+type paymentResponse struct {
+	ID            string        `json:"id"`
+	TemplateID    string        `json:"template_id,omitempty"`
+	PayerUserID   string        `json:"payer_user_id"`
+	PayerWalletID string        `json:"payer_wallet_id"`
+	Amount        int64         `json:"amount"`
+	Reference     string        `json:"reference,omitempty"`
+	ClientTxID    string        `json:"client_tx_id"`
+	TransactionID string        `json:"transaction_id,omitempty"`
+	Legs          []legResponse `json:"legs"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+func toPaymentResponse(p Payment) paymentResponse {
+	return paymentResponse{
+		ID:            p.ID,
+		TemplateID:    p.TemplateID,
+		PayerUserID:   p.PayerUserID,
+		PayerWalletID: p.PayerWalletID,
+		Amount:        p.Amount,
+		Reference:     p.Reference,
+		ClientTxID:    p.ClientTxID,
+		TransactionID: p.TransactionID,
+		Legs:          toLegResponses(p.Legs),
+		CreatedAt:     p.CreatedAt,
+	}
+}
+
+// CreateTemplate stores a reusable split owned by the authenticated user.
+func (h *Handler) CreateTemplate(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req templateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	t, err := h.service.CreateTemplate(c.UserContext(), TemplateInput{
+		OwnerUserID:    uid,
+		Name:           req.Name,
+		Shares:         req.Shares,
+		Rounding:       req.Rounding,
+		RemainderShare: req.RemainderShare,
+	})
+	if err != nil {
+		return splitError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toTemplateResponse(t))
+}
+
+// ListTemplates returns the authenticated user's templates.
+func (h *Handler) ListTemplates(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	templates, err := h.service.ListTemplates(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]templateResponse, 0, len(templates))
+	for _, t := range templates {
+		out = append(out, toTemplateResponse(t))
+	}
+	return c.JSON(fiber.Map{"templates": out})
+}
+
+// GetTemplate returns a template so a payer can see how a payment will be divided.
+func (h *Handler) GetTemplate(c *fiber.Ctx) error {
+	t, err := h.service.GetTemplate(c.UserContext(), c.Params("templateId"))
+	if err != nil {
+		return splitError(err)
+	}
+	return c.JSON(toTemplateResponse(t))
+}
+
+// SetTemplateStatus activates or deactivates a template.
+func (h *Handler) SetTemplateStatus(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req statusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	t, err := h.service.SetTemplateStatus(c.UserContext(), c.Params("templateId"), uid, req.Status)
+	if err != nil {
+		return splitError(err)
+	}
+	return c.JSON(toTemplateResponse(t))
+}
+
+// Quote previews the per-beneficiary amounts of a split without moving funds.
+func (h *Handler) Quote(c *fiber.Ctx) error {
+	var req splitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	legs, amount, err := h.service.Quote(c.UserContext(), req.input())
+	if err != nil {
+		return splitError(err)
+	}
+	return c.JSON(fiber.Map{"amount": amount, "legs": toLegResponses(legs)})
+}
+
+// Pay posts a split payment from the authenticated user's wallet.
+func (h *Handler) Pay(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req payRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid payload")
+	}
+	p, err := h.service.Pay(c.UserContext(), PayInput{
+		SplitInput:   req.input(),
+		PayerUserID:  uid,
+		FromWalletID: req.FromWalletID,
+		Reference:    req.Reference,
+		ClientTxID:   req.ClientTxID,
+		OTPCode:      req.OTPCode,
+	})
+	if errors.Is(err, ledger.ErrDuplicateTransaction) && p.ID != "" {
+		// Retries with the same client_tx_id get the original payment back.
+		return c.Status(http.StatusOK).JSON(toPaymentResponse(p))
+	}
+	if err != nil {
+		return splitError(err)
+	}
+	return c.Status(http.StatusCreated).JSON(toPaymentResponse(p))
+}
+
+// List returns split payments the user made or received.
+func (h *Handler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	payments, err := h.service.List(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]paymentResponse, 0, len(payments))
+	for _, p := range payments {
+		out = append(out, toPaymentResponse(p))
+	}
+	return c.JSON(fiber.Map{"payments": out})
+}
+
+// Get returns a split payment; beneficiaries only see their own legs.
+func (h *Handler) Get(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	p, err := h.service.Get(c.UserContext(), c.Params("paymentId"), uid)
+	if err != nil {
+		return splitError(err)
+	}
+	return c.JSON(toPaymentResponse(p))
+}
+
+// Receipt returns a single beneficiary's receipt for a split payment.
+func (h *Handler) Receipt(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	index, err := strconv.Atoi(c.Params("index"))
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, "invalid leg index")
+	}
+	p, leg, err := h.service.Receipt(c.UserContext(), c.Params("paymentId"), index, uid)
+	if err != nil {
+		return splitError(err)
+	}
+	return c.JSON(fiber.Map{
+		"payment_id":            p.ID,
+		"transaction_id":        p.TransactionID,
+		"payer_user_id":         p.PayerUserID,
+		"reference":             p.Reference,
+		"total_amount":          p.Amount,
+		"index":                 leg.Index,
+		"label":                 leg.Label,
+		"beneficiary_wallet_id": leg.BeneficiaryWalletID,
+		"amount":                leg.Amount,
+		"created_at":            p.CreatedAt,
+	})
+}
+
+func splitError(err error) error {
+	switch {
+	case errors.Is(err, ErrTemplateNotFound), errors.Is(err, ErrPaymentNotFound):
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrNotOwner), errors.Is(err, wallet.ErrNotOwner), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, ledger.ErrInsufficientFunds):
+		return fiber.NewError(http.StatusBadRequest, "insufficient funds")
+	case errors.Is(err, ErrTemplateInactive), errors.Is(err, ledger.ErrAccountRestricted),
+		errors.Is(err, wallet.ErrWalletFrozen), errors.Is(err, wallet.ErrWalletClosed), errors.Is(err, wallet.ErrWalletLocked):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,108 @@
+package split
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mu        sync.RWMutex
+	templates map[string]Template
+	payments  map[string]Payment
+}
+
+// NewMemoryRepository builds an in-memory split store for tests and local development.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{templates: make(map[string]Template), payments: make(map[string]Payment)}
+}
+
+func (m *memoryRepository) CreateTemplate(_ context.Context, t Template) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.Shares = append([]Share(nil), t.Shares...)
+	m.templates[t.ID] = t
+	return nil
+}
+
+func (m *memoryRepository) GetTemplate(_ context.Context, id string) (Template, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.templates[id]
+	if !ok {
+		return Template{}, ErrTemplateNotFound
+	}
+	t.Shares = append([]Share(nil), t.Shares...)
+	return t, nil
+}
+
+func (m *memoryRepository) ListTemplates(_ context.Context, ownerUserID string) ([]Template, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Template
+	for _, t := range m.templates {
+		if t.OwnerUserID == ownerUserID {
+			out = append(out, t)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (m *memoryRepository) UpdateTemplate(_ context.Context, t Template) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.templates[t.ID]; !ok {
+		return ErrTemplateNotFound
+	}
+	t.Shares = append([]Share(nil), t.Shares...)
+	t.UpdatedAt = time.Now().UTC()
+	m.templates[t.ID] = t
+	return nil
+}
+
+func (m *memoryRepository) CreatePayment(_ context.Context, p Payment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p.Legs = append([]Leg(nil), p.Legs...)
+	m.payments[p.ID] = p
+	return nil
+}
+
+func (m *memoryRepository) GetPayment(_ context.Context, id string) (Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	p, ok := m.payments[id]
+	if !ok {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return p, nil
+}
+
+func (m *memoryRepository) FindPayment(_ context.Context, payerUserID, clientTxID string) (Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, p := range m.payments {
+		if p.PayerUserID == payerUserID && p.ClientTxID == clientTxID {
+			return p, nil
+		}
+	}
+	return Payment{}, ErrPaymentNotFound
+}
+
+func (m *memoryRepository) ListPayments(_ context.Context, userID string, limit int) ([]Payment, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []Payment
+	for _, p := range m.payments {
+		if p.PayerUserID == userID || p.beneficiary(userID) {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+package split
+
+import (
+	"errors"
+	"time"
+)
+
+// Share kinds.
+const (
+	// ShareFixed takes a fixed amount off the top of the payment.
+	ShareFixed = "fixed"
+	// SharePercent takes a proportion, in basis points, of what remains after fixed shares.
+	SharePercent = "percent"
+)
+
+// Rounding rules for percentage shares. The CFA franc has no minor unit in circulation, so
+// every share is a whole number of francs and the units lost to flooring must go somewhere.
+const (
+	// RoundingLargestRemainder gives leftover units, one each, to the shares with the largest
+	// fractional parts (ties go to the earlier share).
+	RoundingLargestRemainder = "largest_remainder"
+	// RoundingToShare gives all leftover units to the share at Template.RemainderShare.
+	RoundingToShare = "to_share"
+)
+
+// Template statuses.
+const (
+	TemplateActive   = "active"
+	TemplateInactive = "inactive"
+)
+
+// MaxShares bounds the number of beneficiaries of a single payment.
+const MaxShares = 10
+
+var (
+	// ErrTemplateNotFound indicates no split template matches the lookup.
+	ErrTemplateNotFound = errors.New("split template not found")
+	// ErrTemplateInactive indicates the template no longer accepts payments.
+	ErrTemplateInactive = errors.New("split template is inactive")
+	// ErrNotOwner indicates the caller does not own the split template.
+	ErrNotOwner = errors.New("not owner of split template")
+	// ErrPaymentNotFound indicates no split payment is visible to the caller.
+	ErrPaymentNotFound = errors.New("split payment not found")
+)
+
+// Share is one beneficiary of a split.
+type Share struct {
+	WalletID    string `json:"wallet_id"`
+	Label       string `json:"label"`
+	Kind        string `json:"kind"`
+	Amount      int64  `json:"amount,omitempty"`
+	BasisPoints int    `json:"basis_points,omitempty"`
+}
+
+// Template is a reusable split, such as a permit fee divided between treasury, municipality
+// and a service fee. Any user may pay through an active template.
+type Template struct {
+	ID          string
+	OwnerUserID string
+	Name        string
+	Shares      []Share
+	Rounding    string
+	// RemainderShare is the index of the share receiving leftover units under RoundingToShare.
+	RemainderShare int
+	Status         string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Payment is one payer debit split across several beneficiaries in a single ledger
+// transaction.
+type Payment struct {
+	ID            string
+	TemplateID    string
+	PayerUserID   string
+	PayerWalletID string
+	Amount        int64
+	Reference     string
+	ClientTxID    string
+	TransactionID string
+	Legs          []Leg
+	CreatedAt     time.Time
+}
+
+// Leg is a beneficiary's part of a split payment; it doubles as that beneficiary's receipt.
+type Leg struct {
+	Index               int
+	BeneficiaryWalletID string
+	BeneficiaryUserID   string
+	Label               string
+	Amount              int64
+}
+
+func (p Payment) beneficiary(userID string) bool {
+	for _, l := range p.Legs {
+		if l.BeneficiaryUserID == userID {
+			return true
+		}
+	}
+	return false
+}
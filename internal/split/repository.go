@@ -0,0 +1,257 @@
+package split
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Repository persists split templates and payments.
+type Repository interface {
+	CreateTemplate(ctx context.Context, t Template) error
+	GetTemplate(ctx context.Context, id string) (Template, error)
+	ListTemplates(ctx context.Context, ownerUserID string) ([]Template, error)
+	UpdateTemplate(ctx context.Context, t Template) error
+	CreatePayment(ctx context.Context, p Payment) error
+	GetPayment(ctx context.Context, id string) (Payment, error)
+	// FindPayment returns the payment a payer made with a client transaction ID.
+	FindPayment(ctx context.Context, payerUserID, clientTxID string) (Payment, error)
+	// ListPayments returns payments the user made or received a leg of, newest first.
+	ListPayments(ctx context.Context, userID string, limit int) ([]Payment, error)
+}
+
+// PostgresRepository stores split templates and payments in PostgreSQL.
+type PostgresRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewPostgresRepository builds a Postgres-backed split repository.
+func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+const templateColumns = `id::text, owner_user_id::text, name, shares, rounding, remainder_share, status, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTemplate(row rowScanner) (Template, error) {
+	var (
+		t      Template
+		shares []byte
+	)
+	err := row.Scan(&t.ID, &t.OwnerUserID, &t.Name, &shares, &t.Rounding, &t.RemainderShare, &t.Status, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Template{}, ErrTemplateNotFound
+		}
+		return Template{}, err
+	}
+	if err := json.Unmarshal(shares, &t.Shares); err != nil {
+		return Template{}, err
+	}
+	t.CreatedAt = t.CreatedAt.UTC()
+	t.UpdatedAt = t.UpdatedAt.UTC()
+	return t, nil
+}
+
+// CreateTemplate inserts a split template.
+func (r *PostgresRepository) CreateTemplate(ctx context.Context, t Template) error {
+	shares, err := json.Marshal(t.Shares)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `INSERT INTO split_templates
+        (id, owner_user_id, name, shares, rounding, remainder_share, status, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		t.ID, t.OwnerUserID, t.Name, shares, t.Rounding, t.RemainderShare, t.Status, t.CreatedAt.UTC(), t.UpdatedAt.UTC())
+	return err
+}
+
+// GetTemplate fetches a template by ID.
+func (r *PostgresRepository) GetTemplate(ctx context.Context, id string) (Template, error) {
+	templateID, err := uuid.Parse(id)
+	if err != nil {
+		return Template{}, ErrTemplateNotFound
+	}
+	return scanTemplate(r.db.QueryRow(ctx, `SELECT `+templateColumns+` FROM split_templates WHERE id = $1`, templateID))
+}
+
+// ListTemplates returns the templates a user owns, newest first.
+func (r *PostgresRepository) ListTemplates(ctx context.Context, ownerUserID string) ([]Template, error) {
+	ownerID, err := uuid.Parse(ownerUserID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+templateColumns+` FROM split_templates
+        WHERE owner_user_id = $1 ORDER BY created_at DESC`, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Template
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// UpdateTemplate stores a template's name, split and status.
+func (r *PostgresRepository) UpdateTemplate(ctx context.Context, t Template) error {
+	templateID, err := uuid.Parse(t.ID)
+	if err != nil {
+		return ErrTemplateNotFound
+	}
+	shares, err := json.Marshal(t.Shares)
+	if err != nil {
+		return err
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE split_templates
+        SET name = $1, shares = $2, rounding = $3, remainder_share = $4, status = $5, updated_at = $6
+        WHERE id = $7`,
+		t.Name, shares, t.Rounding, t.RemainderShare, t.Status, time.Now().UTC(), templateID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// CreatePayment stores a split payment and its legs.
+func (r *PostgresRepository) CreatePayment(ctx context.Context, p Payment) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var templateID any
+	if p.TemplateID != "" {
+		templateID = p.TemplateID
+	}
+	_, err = tx.Exec(ctx, `INSERT INTO split_payments
+        (id, template_id, payer_user_id, payer_wallet_id, amount, reference, client_tx_id, transaction_id, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		p.ID, templateID, p.PayerUserID, p.PayerWalletID, p.Amount, p.Reference, p.ClientTxID, p.TransactionID, p.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+	rows := make([][]any, 0, len(p.Legs))
+	for _, l := range p.Legs {
+		rows = append(rows, []any{p.ID, l.Index, l.BeneficiaryWalletID, l.BeneficiaryUserID, l.Label, l.Amount})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"split_payment_legs"},
+		[]string{"payment_id", "leg_index", "beneficiary_wallet_id", "beneficiary_user_id", "label", "amount"},
+		pgx.CopyFromRows(rows))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+const paymentColumns = `id::text, COALESCE(template_id::text, ''), payer_user_id::text, payer_wallet_id::text,
+        amount, reference, client_tx_id, COALESCE(transaction_id::text, ''), created_at`
+
+func (r *PostgresRepository) scanPayment(ctx context.Context, row rowScanner) (Payment, error) {
+	var p Payment
+	err := row.Scan(&p.ID, &p.TemplateID, &p.PayerUserID, &p.PayerWalletID, &p.Amount, &p.Reference, &p.ClientTxID, &p.TransactionID, &p.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Payment{}, ErrPaymentNotFound
+		}
+		return Payment{}, err
+	}
+	p.CreatedAt = p.CreatedAt.UTC()
+	p.Legs, err = r.legs(ctx, p.ID)
+	return p, err
+}
+
+func (r *PostgresRepository) legs(ctx context.Context, paymentID string) ([]Leg, error) {
+	id, err := uuid.Parse(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT leg_index, beneficiary_wallet_id::text, beneficiary_user_id::text, label, amount
+        FROM split_payment_legs WHERE payment_id = $1 ORDER BY leg_index`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Leg
+	for rows.Next() {
+		var l Leg
+		if err := rows.Scan(&l.Index, &l.BeneficiaryWalletID, &l.BeneficiaryUserID, &l.Label, &l.Amount); err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// GetPayment fetches a split payment with its legs.
+func (r *PostgresRepository) GetPayment(ctx context.Context, id string) (Payment, error) {
+	paymentID, err := uuid.Parse(id)
+	if err != nil {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return r.scanPayment(ctx, r.db.QueryRow(ctx, `SELECT `+paymentColumns+` FROM split_payments WHERE id = $1`, paymentID))
+}
+
+// FindPayment fetches a payer's split payment by client transaction ID.
+func (r *PostgresRepository) FindPayment(ctx context.Context, payerUserID, clientTxID string) (Payment, error) {
+	payerID, err := uuid.Parse(payerUserID)
+	if err != nil {
+		return Payment{}, ErrPaymentNotFound
+	}
+	return r.scanPayment(ctx, r.db.QueryRow(ctx, `SELECT `+paymentColumns+` FROM split_payments
+        WHERE payer_user_id = $1 AND client_tx_id = $2`, payerID, clientTxID))
+}
+
+// ListPayments returns payments made by the user or paying one of their wallets.
+func (r *PostgresRepository) ListPayments(ctx context.Context, userID string, limit int) ([]Payment, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT p.id::text FROM split_payments p
+        WHERE p.payer_user_id = $1
+           OR EXISTS (SELECT 1 FROM split_payment_legs l WHERE l.payment_id = p.id AND l.beneficiary_user_id = $1)
+        ORDER BY p.created_at DESC LIMIT $2`, uid, limit)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]Payment, 0, len(ids))
+	for _, id := range ids {
+		p, err := r.GetPayment(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
@@ -0,0 +1,354 @@
+package split
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/notification"
+	"github.com/congo-pay/congo_pay/internal/risk"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+const (
+	paymentKind     = "split_payment"
+	maxNameLength   = 80
+	maxReferenceLen = 140
+	listLimit       = 50
+)
+
+// Service manages split templates and posts split payments.
+type Service struct {
+	repo     Repository
+	ledger   ledger.Ledger
+	wallets  *wallet.Service
+	notifier notification.Notifier
+	guard    *guard.Guard
+}
+
+// NewService constructs a split payment service.
+func NewService(repo Repository, ledgerBackend ledger.Ledger, wallets *wallet.Service, notifier notification.Notifier, outgoing *guard.Guard) *Service {
+	return &Service{repo: repo, ledger: ledgerBackend, wallets: wallets, notifier: notifier, guard: outgoing}
+}
+
+// TemplateInput captures a split template definition.
+type TemplateInput struct {
+	OwnerUserID string
+	Name        string
+	Shares      []Share
+	// Rounding defaults to RoundingLargestRemainder.
+	Rounding       string
+	RemainderShare int
+}
+
+// CreateTemplate validates and stores a reusable split.
+func (s *Service) CreateTemplate(ctx context.Context, input TemplateInput) (Template, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > maxNameLength {
+		return Template{}, fmt.Errorf("name is required and must be at most %d characters", maxNameLength)
+	}
+	shares, rounding, err := s.normalize(ctx, input.Shares, input.Rounding, input.RemainderShare)
+	if err != nil {
+		return Template{}, err
+	}
+	now := time.Now().UTC()
+	t := Template{
+		ID:             uuid.NewString(),
+		OwnerUserID:    input.OwnerUserID,
+		Name:           name,
+		Shares:         shares,
+		Rounding:       rounding,
+		RemainderShare: input.RemainderShare,
+		Status:         TemplateActive,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.repo.CreateTemplate(ctx, t); err != nil {
+		return Template{}, err
+	}
+	return t, nil
+}
+
+// normalize trims labels, applies the default rounding rule, validates the split and checks
+// every beneficiary wallet can receive funds.
+func (s *Service) normalize(ctx context.Context, shares []Share, rounding string, remainderShare int) ([]Share, string, error) {
+	if rounding == "" {
+		rounding = RoundingLargestRemainder
+	}
+	out := make([]Share, len(shares))
+	for i, sh := range shares {
+		sh.Label = strings.TrimSpace(sh.Label)
+		out[i] = sh
+	}
+	if err := Validate(out, rounding, remainderShare); err != nil {
+		return nil, "", err
+	}
+	for i, sh := range out {
+		w, err := s.wallets.Get(ctx, sh.WalletID)
+		if err != nil {
+			return nil, "", fmt.Errorf("share %d: %w", i+1, err)
+		}
+		if err := w.CanCredit(); err != nil {
+			return nil, "", fmt.Errorf("share %d: %w", i+1, err)
+		}
+	}
+	return out, rounding, nil
+}
+
+// GetTemplate returns a template; templates are visible to every payer.
+func (s *Service) GetTemplate(ctx context.Context, id string) (Template, error) {
+	return s.repo.GetTemplate(ctx, id)
+}
+
+// ListTemplates returns the templates a user owns.
+func (s *Service) ListTemplates(ctx context.Context, ownerUserID string) ([]Template, error) {
+	return s.repo.ListTemplates(ctx, ownerUserID)
+}
+
+// SetTemplateStatus activates or deactivates a template owned by the user.
+func (s *Service) SetTemplateStatus(ctx context.Context, id, ownerUserID, status string) (Template, error) {
+	if status != TemplateActive && status != TemplateInactive {
+		return Template{}, fmt.Errorf("status must be active or inactive")
+	}
+	t, err := s.repo.GetTemplate(ctx, id)
+	if err != nil {
+		return Template{}, err
+	}
+	if t.OwnerUserID != ownerUserID {
+		return Template{}, ErrNotOwner
+	}
+	t.Status = status
+	if err := s.repo.UpdateTemplate(ctx, t); err != nil {
+		return Template{}, err
+	}
+	return t, nil
+}
+
+// SplitInput selects the split to apply: a stored template, or inline shares when
+// TemplateID is empty.
+type SplitInput struct {
+	TemplateID     string
+	Shares         []Share
+	Rounding       string
+	RemainderShare int
+	// Amount may be left zero for a template made only of fixed shares.
+	Amount int64
+}
+
+// Quote previews how an amount would be divided without moving funds.
+func (s *Service) Quote(ctx context.Context, input SplitInput) ([]Leg, int64, error) {
+	_, legs, amount, err := s.resolve(ctx, input)
+	if err != nil {
+		return nil, 0, err
+	}
+	return legs, amount, nil
+}
+
+func (s *Service) resolve(ctx context.Context, input SplitInput) (string, []Leg, int64, error) {
+	var (
+		shares         []Share
+		rounding       string
+		remainderShare int
+		templateID     string
+	)
+	if input.TemplateID != "" {
+		t, err := s.repo.GetTemplate(ctx, input.TemplateID)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		if t.Status != TemplateActive {
+			return "", nil, 0, ErrTemplateInactive
+		}
+		templateID, shares, rounding, remainderShare = t.ID, t.Shares, t.Rounding, t.RemainderShare
+	} else {
+		var err error
+		shares, rounding, err = s.normalize(ctx, input.Shares, input.Rounding, input.RemainderShare)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		remainderShare = input.RemainderShare
+	}
+
+	amount := input.Amount
+	if amount == 0 && !hasPercent(shares) {
+		amount = FixedTotal(shares)
+	}
+	if amount <= 0 {
+		return "", nil, 0, fmt.Errorf("amount must be positive")
+	}
+	alloc, err := Allocate(amount, shares, rounding, remainderShare)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	legs := make([]Leg, 0, len(shares))
+	for i, sh := range shares {
+		if alloc[i] == 0 {
+			// A percentage too small to yield a whole franc; the ledger rejects empty legs.
+			continue
+		}
+		legs = append(legs, Leg{Index: i, BeneficiaryWalletID: sh.WalletID, Label: sh.Label, Amount: alloc[i]})
+	}
+	return templateID, legs, amount, nil
+}
+
+// PayInput captures a split payment. FromWalletID defaults to the payer's primary wallet.
+type PayInput struct {
+	SplitInput
+	PayerUserID  string
+	FromWalletID string
+	Reference    string
+	ClientTxID   string
+	// OTPCode confirms splits of at least the high-value amount.
+	OTPCode string
+}
+
+// Pay debits the payer once and credits every beneficiary in a single ledger transaction.
+// A repeated ClientTxID returns the original payment with ledger.ErrDuplicateTransaction.
+func (s *Service) Pay(ctx context.Context, input PayInput) (Payment, error) {
+	reference := strings.TrimSpace(input.Reference)
+	if len(reference) > maxReferenceLen {
+		return Payment{}, fmt.Errorf("reference must be at most %d characters", maxReferenceLen)
+	}
+	if input.ClientTxID == "" {
+		input.ClientTxID = uuid.NewString()
+	}
+	if existing, err := s.repo.FindPayment(ctx, input.PayerUserID, input.ClientTxID); err == nil {
+		return existing, ledger.ErrDuplicateTransaction
+	} else if !errors.Is(err, ErrPaymentNotFound) {
+		return Payment{}, err
+	}
+
+	templateID, legs, amount, err := s.resolve(ctx, input.SplitInput)
+	if err != nil {
+		return Payment{}, err
+	}
+
+	var payer wallet.Wallet
+	if input.FromWalletID != "" {
+		payer, err = s.wallets.Get(ctx, input.FromWalletID)
+	} else {
+		payer, err = s.wallets.GetByOwner(ctx, input.PayerUserID)
+	}
+	if err != nil {
+		return Payment{}, err
+	}
+	if payer.OwnerID != input.PayerUserID {
+		return Payment{}, wallet.ErrNotOwner
+	}
+	if err := payer.CanDebit(); err != nil {
+		return Payment{}, err
+	}
+	if err := s.guard.Check(ctx, guard.Outgoing{UserID: input.PayerUserID, Op: risk.OpPayment, Amount: amount, OTPCode: input.OTPCode}); err != nil {
+		return Payment{}, err
+	}
+
+	postings := []ledger.Posting{{AccountCode: payer.AccountCode, Amount: -amount}}
+	for i, l := range legs {
+		if l.BeneficiaryWalletID == payer.ID {
+			return Payment{}, fmt.Errorf("cannot pay a split that credits the paying wallet")
+		}
+		w, err := s.wallets.Get(ctx, l.BeneficiaryWalletID)
+		if err != nil {
+			return Payment{}, err
+		}
+		if err := w.CanCredit(); err != nil {
+			return Payment{}, fmt.Errorf("beneficiary %q cannot receive funds: %w", l.Label, err)
+		}
+		legs[i].BeneficiaryUserID = w.OwnerID
+		postings = append(postings, ledger.Posting{AccountCode: w.AccountCode, Amount: l.Amount})
+	}
+	// Scoping the ledger key to the payer keeps two users' client IDs from colliding.
+	res, err := s.ledger.Post(ctx, paymentKind, input.PayerUserID+":"+input.ClientTxID, postings)
+	if err != nil && !errors.Is(err, ledger.ErrDuplicateTransaction) {
+		return Payment{}, err
+	}
+
+	p := Payment{
+		ID:            uuid.NewString(),
+		TemplateID:    templateID,
+		PayerUserID:   input.PayerUserID,
+		PayerWalletID: payer.ID,
+		Amount:        amount,
+		Reference:     reference,
+		ClientTxID:    input.ClientTxID,
+		TransactionID: res.TransactionID,
+		Legs:          legs,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := s.repo.CreatePayment(ctx, p); err != nil {
+		return Payment{}, err
+	}
+
+	if s.notifier != nil {
+		for _, l := range legs {
+			_ = s.notifier.Send(ctx, notification.Message{
+				Kind:        notification.KindSplitPayment,
+				Destination: l.BeneficiaryUserID,
+				Body:        fmt.Sprintf("You received %d (%s) from a split payment ref %s", l.Amount, l.Label, p.Reference),
+			})
+		}
+	}
+	return p, nil
+}
+
+// Get returns a split payment as the user may see it: the payer sees every leg, a
+// beneficiary only the legs paid to them.
+func (s *Service) Get(ctx context.Context, paymentID, userID string) (Payment, error) {
+	p, err := s.repo.GetPayment(ctx, paymentID)
+	if err != nil {
+		return Payment{}, err
+	}
+	return visibleTo(p, userID)
+}
+
+// Receipt returns one beneficiary leg of a payment.
+func (s *Service) Receipt(ctx context.Context, paymentID string, legIndex int, userID string) (Payment, Leg, error) {
+	p, err := s.Get(ctx, paymentID, userID)
+	if err != nil {
+		return Payment{}, Leg{}, err
+	}
+	for _, l := range p.Legs {
+		if l.Index == legIndex {
+			return p, l, nil
+		}
+	}
+	return Payment{}, Leg{}, ErrPaymentNotFound
+}
+
+// List returns split payments the user made or received.
+func (s *Service) List(ctx context.Context, userID string) ([]Payment, error) {
+	payments, err := s.repo.ListPayments(ctx, userID, listLimit)
+	if err != nil {
+		return nil, err
+	}
+	out := payments[:0]
+	for _, p := range payments {
+		if visible, err := visibleTo(p, userID); err == nil {
+			out = append(out, visible)
+		}
+	}
+	return out, nil
+}
+
+func visibleTo(p Payment, userID string) (Payment, error) {
+	if p.PayerUserID == userID {
+		return p, nil
+	}
+	var own []Leg
+	for _, l := range p.Legs {
+		if l.BeneficiaryUserID == userID {
+			own = append(own, l)
+		}
+	}
+	if len(own) == 0 {
+		return Payment{}, ErrPaymentNotFound
+	}
+	p.Legs = own
+	return p, nil
+}
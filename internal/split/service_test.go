@@ -0,0 +1,161 @@
+package split
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/congo-pay/congo_pay/internal/identity"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/wallet"
+)
+
+type fixture struct {
+	svc     *Service
+	led     ledger.Ledger
+	wallets *wallet.Service
+	users   *identity.Service
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	led := ledger.NewInMemory()
+	wallets := wallet.NewService(wallet.NewMemoryRepository(), led, nil)
+	users := identity.NewService(identity.NewMemoryRepository(), nil)
+	return fixture{svc: NewService(NewMemoryRepository(), led, wallets, nil, nil), led: led, wallets: wallets, users: users}
+}
+
+func (f fixture) user(t *testing.T, phone string, balance int64) (identity.User, wallet.Wallet) {
+	t.Helper()
+	ctx := context.Background()
+	u, err := f.users.Register(ctx, identity.Credentials{Phone: phone, PIN: "7305"})
+	if err != nil {
+		t.Fatalf("register %s: %v", phone, err)
+	}
+	w, err := f.wallets.Create(ctx, wallet.CreateInput{OwnerID: u.ID})
+	if err != nil {
+		t.Fatalf("wallet: %v", err)
+	}
+	ledger.SeedBalance(f.led, w.AccountCode, balance)
+	return u, w
+}
+
+func TestAllocateRounding(t *testing.T) {
+	shares := []Share{
+		{WalletID: "fee", Kind: ShareFixed, Amount: 100},
+		{WalletID: "a", Kind: SharePercent, BasisPoints: 3_333},
+		{WalletID: "b", Kind: SharePercent, BasisPoints: 3_333},
+		{WalletID: "c", Kind: SharePercent, BasisPoints: 3_334},
+	}
+	// 1000 after the fixed fee: exact shares 333.3, 333.3, 333.4 leave one franc over.
+	got, err := Allocate(1_100, shares, RoundingLargestRemainder, 0)
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+	if want := []int64{100, 333, 333, 334}; !equal(got, want) {
+		t.Fatalf("largest remainder = %v, want %v", got, want)
+	}
+	got, _ = Allocate(1_101, shares, RoundingToShare, 1)
+	if want := []int64{100, 335, 333, 333}; !equal(got, want) {
+		t.Fatalf("to share = %v, want %v", got, want)
+	}
+
+	if _, err := Allocate(1_000, shares[:1], RoundingLargestRemainder, 0); err == nil {
+		t.Fatal("expected fixed-only split to require the exact fixed total")
+	}
+	if err := Validate([]Share{{WalletID: "a", Kind: SharePercent, BasisPoints: 5_000}}, RoundingLargestRemainder, 0); err == nil {
+		t.Fatal("expected percentages not summing to 100% to be rejected")
+	}
+}
+
+func equal(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestServicePayTemplate(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	admin, _ := f.user(t, "+242060000001", 0)
+	citizen, citizenWallet := f.user(t, "+242060000002", 20_000)
+	treasuryUser, treasury := f.user(t, "+242060000003", 0)
+	_, municipality := f.user(t, "+242060000004", 0)
+	_, operator := f.user(t, "+242060000005", 0)
+
+	tpl, err := f.svc.CreateTemplate(ctx, TemplateInput{
+		OwnerUserID: admin.ID,
+		Name:        "Building permit",
+		Shares: []Share{
+			{WalletID: treasury.ID, Label: "Treasury", Kind: SharePercent, BasisPoints: 7_000},
+			{WalletID: municipality.ID, Label: "Municipality", Kind: SharePercent, BasisPoints: 3_000},
+			{WalletID: operator.ID, Label: "Service fee", Kind: ShareFixed, Amount: 250},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create template: %v", err)
+	}
+
+	p, err := f.svc.Pay(ctx, PayInput{SplitInput: SplitInput{TemplateID: tpl.ID, Amount: 10_250}, PayerUserID: citizen.ID, Reference: "permit 42", ClientTxID: "c-1"})
+	if err != nil {
+		t.Fatalf("pay: %v", err)
+	}
+	for code, want := range map[string]int64{
+		citizenWallet.AccountCode: 9_750, treasury.AccountCode: 7_000, municipality.AccountCode: 3_000, operator.AccountCode: 250,
+	} {
+		if got, _ := f.led.Balance(ctx, code); got != want {
+			t.Fatalf("balance %s = %d, want %d", code, got, want)
+		}
+	}
+
+	// A retry returns the original payment without debiting again.
+	again, err := f.svc.Pay(ctx, PayInput{SplitInput: SplitInput{TemplateID: tpl.ID, Amount: 10_250}, PayerUserID: citizen.ID, ClientTxID: "c-1"})
+	if !errors.Is(err, ledger.ErrDuplicateTransaction) || again.ID != p.ID {
+		t.Fatalf("expected duplicate to return original payment, got %+v, %v", again, err)
+	}
+
+	// Beneficiaries only see their own leg.
+	seen, err := f.svc.Get(ctx, p.ID, treasuryUser.ID)
+	if err != nil || len(seen.Legs) != 1 || seen.Legs[0].Amount != 7_000 {
+		t.Fatalf("treasury view: %+v, %v", seen, err)
+	}
+	if _, _, err := f.svc.Receipt(ctx, p.ID, 1, treasuryUser.ID); !errors.Is(err, ErrPaymentNotFound) {
+		t.Fatalf("expected another beneficiary's receipt to be hidden, got %v", err)
+	}
+
+	if _, err := f.svc.SetTemplateStatus(ctx, tpl.ID, admin.ID, TemplateInactive); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if _, err := f.svc.Pay(ctx, PayInput{SplitInput: SplitInput{TemplateID: tpl.ID, Amount: 10_250}, PayerUserID: citizen.ID}); !errors.Is(err, ErrTemplateInactive) {
+		t.Fatalf("expected inactive template to be refused, got %v", err)
+	}
+}
+
+func TestServicePayIsAtomic(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	payer, payerWallet := f.user(t, "+242060000011", 1_000)
+	_, a := f.user(t, "+242060000012", 0)
+	_, b := f.user(t, "+242060000013", 0)
+
+	_, err := f.svc.Pay(ctx, PayInput{
+		SplitInput: SplitInput{
+			Shares: []Share{{WalletID: a.ID, Kind: ShareFixed, Amount: 600}, {WalletID: b.ID, Kind: ShareFixed, Amount: 600}},
+		},
+		PayerUserID: payer.ID,
+	})
+	if !errors.Is(err, ledger.ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	for code, want := range map[string]int64{payerWallet.AccountCode: 1_000, a.AccountCode: 0, b.AccountCode: 0} {
+		if got, _ := f.led.Balance(ctx, code); got != want {
+			t.Fatalf("partial posting: balance %s = %d, want %d", code, got, want)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package wallet
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
 )
 
 // Handler exposes wallet HTTP endpoints.
@@ -17,34 +22,148 @@ func NewHandler(service *Service) *Handler {
 }
 
 type createRequest struct {
-	OwnerID  string `json:"owner_id"`
-	Currency string `json:"currency"`
+	Currency    string     `json:"currency"`
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	LockedUntil *time.Time `json:"locked_until"`
 }
 
 type walletResponse struct {
-    ID          string `json:"id"`
-    OwnerID     string `json:"owner_id"`
-    AccountCode string `json:"account_code"`
-    Currency    string `json:"currency"`
-    Status      string `json:"status"`
+    ID          string     `json:"id"`
+    OwnerID     string     `json:"owner_id"`
+    AccountCode string     `json:"account_code"`
+    Currency    string     `json:"currency"`
+    Status      string     `json:"status"`
+    Name        string     `json:"name"`
+    Kind        string     `json:"kind"`
+    IsPrimary   bool       `json:"is_primary"`
+    LockedUntil *time.Time `json:"locked_until,omitempty"`
+    Balance     *int64     `json:"balance,omitempty"`
+}
+
+func toWalletResponse(w Wallet) walletResponse {
+	resp := walletResponse{
+		ID:          w.ID,
+		OwnerID:     w.OwnerID,
+		AccountCode: w.AccountCode,
+		Currency:    w.Currency,
+		Status:      w.Status,
+		Name:        w.Name,
+		Kind:        w.Kind,
+		IsPrimary:   w.IsPrimary,
+	}
+	if !w.LockedUntil.IsZero() {
+		lockedUntil := w.LockedUntil
+		resp.LockedUntil = &lockedUntil
+	}
+	return resp
 }
 
-// Create provisions a wallet for the authenticated owner.
+// Create provisions an additional wallet or savings pocket for the authenticated owner.
 func (h *Handler) Create(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
 	var req createRequest
 	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(http.StatusBadRequest, err.Error())
 	}
-	wallet, err := h.service.Create(c.UserContext(), CreateInput{OwnerID: req.OwnerID, Currency: req.Currency})
+	input := CreateInput{OwnerID: uid, Currency: req.Currency, Name: req.Name, Kind: req.Kind}
+	if req.LockedUntil != nil {
+		input.LockedUntil = *req.LockedUntil
+	}
+	wallet, err := h.service.Create(c.UserContext(), input)
+	if err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	return c.Status(http.StatusCreated).JSON(toWalletResponse(wallet))
+}
+
+// List returns the authenticated owner's wallets and pockets with balances.
+func (h *Handler) List(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	wallets, err := h.service.ListByOwner(c.UserContext(), uid)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]walletResponse, 0, len(wallets))
+	for _, w := range wallets {
+		resp := toWalletResponse(w)
+		if bal, err := h.service.Balance(c.UserContext(), w.ID); err == nil {
+			resp.Balance = &bal.Amount
+		}
+		out = append(out, resp)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"wallets": out})
+}
+
+type updateRequest struct {
+	Name        *string    `json:"name"`
+	LockedUntil *time.Time `json:"locked_until"`
+}
+
+// Update renames a wallet or extends a pocket lock.
+func (h *Handler) Update(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req updateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	w, err := h.service.Update(c.UserContext(), UpdateInput{
+		WalletID:    c.Params("walletId"),
+		OwnerID:     uid,
+		Name:        req.Name,
+		LockedUntil: req.LockedUntil,
+	})
+	if err != nil {
+		return lifecycleError(err)
+	}
+	return c.Status(http.StatusOK).JSON(toWalletResponse(w))
+}
+
+// SetPrimary makes the wallet the caller's primary wallet.
+func (h *Handler) SetPrimary(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	w, err := h.service.SetPrimary(c.UserContext(), uid, c.Params("walletId"))
 	if err != nil {
+		return lifecycleError(err)
+	}
+	return c.Status(http.StatusOK).JSON(toWalletResponse(w))
+}
+
+type moveRequest struct {
+	FromWalletID string `json:"from_wallet_id"`
+	ToWalletID   string `json:"to_wallet_id"`
+	Amount       int64  `json:"amount"`
+	ClientTxID   string `json:"client_tx_id"`
+}
+
+// Move transfers funds between two of the caller's wallets or pockets without fees.
+func (h *Handler) Move(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	var req moveRequest
+	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(http.StatusBadRequest, err.Error())
 	}
-	return c.Status(http.StatusCreated).JSON(walletResponse{
-		ID:          wallet.ID,
-		OwnerID:     wallet.OwnerID,
-		AccountCode: wallet.AccountCode,
-		Currency:    wallet.Currency,
-		Status:      wallet.Status,
+	res, err := h.service.Move(c.UserContext(), MoveInput{
+		OwnerID:      uid,
+		FromWalletID: req.FromWalletID,
+		ToWalletID:   req.ToWalletID,
+		Amount:       req.Amount,
+		ClientTxID:   req.ClientTxID,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ledger.ErrInsufficientFunds):
+			return fiber.NewError(http.StatusBadRequest, "insufficient funds")
+		case errors.Is(err, ledger.ErrDuplicateTransaction):
+			return fiber.NewError(http.StatusConflict, "duplicate transaction")
+		default:
+			return lifecycleError(err)
+		}
+	}
+	return c.Status(http.StatusCreated).JSON(fiber.Map{
+		"transaction_id": res.TransactionID,
+		"from_balance":   res.FromBalance,
+		"to_balance":     res.ToBalance,
 	})
 }
 
@@ -55,13 +174,7 @@ func (h *Handler) Get(c *fiber.Ctx) error {
     if err != nil {
         return fiber.NewError(http.StatusNotFound, err.Error())
     }
-    return c.Status(http.StatusOK).JSON(walletResponse{
-        ID:          w.ID,
-        OwnerID:     w.OwnerID,
-        AccountCode: w.AccountCode,
-        Currency:    w.Currency,
-        Status:      w.Status,
-    })
+    return c.Status(http.StatusOK).JSON(toWalletResponse(w))
 }
 
 // Balance returns the wallet balance.
@@ -77,3 +190,152 @@ func (h *Handler) Balance(c *fiber.Ctx) error {
 		"timestamp": balance.AsOf,
 	})
 }
+
+type statusChangeRequest struct {
+	Mode   string `json:"mode"`
+	Reason string `json:"reason"`
+}
+
+type closeRequest struct {
+	Reason          string `json:"reason"`
+	SweepToWalletID string `json:"sweep_to_wallet_id"`
+	Payout          bool   `json:"payout"`
+	OTPCode         string `json:"otp_code"`
+}
+
+type statusChangeResponse struct {
+	ID         string    `json:"id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	Reason     string    `json:"reason"`
+	Actor      string    `json:"actor"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Close closes one of the caller's wallets, sweeping any balance to the requested target.
+func (h *Handler) Close(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	return h.close(c, uid, "user:"+uid)
+}
+
+// AdminClose closes any wallet on behalf of back-office staff.
+func (h *Handler) AdminClose(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	return h.close(c, "", "admin:"+uid)
+}
+
+func (h *Handler) close(c *fiber.Ctx, requestor, actor string) error {
+	var req closeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	res, err := h.service.Close(c.UserContext(), CloseInput{
+		WalletID:        c.Params("walletId"),
+		Reason:          req.Reason,
+		Actor:           actor,
+		SweepToWalletID: req.SweepToWalletID,
+		Payout:          req.Payout,
+		RequestorUserID: requestor,
+		OTPCode:         req.OTPCode,
+	})
+	if err != nil {
+		return lifecycleError(err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"wallet_id":            res.Wallet.ID,
+		"status":               res.Wallet.Status,
+		"swept_amount":         res.SweptAmount,
+		"sweep_transaction_id": res.SweepTransactionID,
+		"paid_out":             res.PaidOut,
+	})
+}
+
+// Freeze restricts a wallet: mode "debit" blocks outgoing funds, "all" blocks every movement.
+func (h *Handler) Freeze(c *fiber.Ctx) error {
+	var req statusChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	var status string
+	switch req.Mode {
+	case "debit":
+		status = StatusFrozenDebit
+	case "all", "":
+		status = StatusFrozenAll
+	default:
+		return fiber.NewError(http.StatusBadRequest, "mode must be debit or all")
+	}
+	return h.changeStatus(c, status, req.Reason)
+}
+
+// Unfreeze returns a frozen wallet to active.
+func (h *Handler) Unfreeze(c *fiber.Ctx) error {
+	var req statusChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+	return h.changeStatus(c, StatusActive, req.Reason)
+}
+
+func (h *Handler) changeStatus(c *fiber.Ctx, status, reason string) error {
+	uid, _ := c.Locals("user_id").(string)
+	w, err := h.service.ChangeStatus(c.UserContext(), StatusChangeInput{
+		WalletID: c.Params("walletId"),
+		Status:   status,
+		Reason:   reason,
+		Actor:    "admin:" + uid,
+	})
+	if err != nil {
+		return lifecycleError(err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"wallet_id": w.ID, "status": w.Status})
+}
+
+// StatusHistory lists status changes for one of the caller's wallets.
+func (h *Handler) StatusHistory(c *fiber.Ctx) error {
+	uid, _ := c.Locals("user_id").(string)
+	w, err := h.service.Get(c.UserContext(), c.Params("walletId"))
+	if err != nil {
+		return fiber.NewError(http.StatusNotFound, err.Error())
+	}
+	if w.OwnerID != uid {
+		return fiber.NewError(http.StatusForbidden, ErrNotOwner.Error())
+	}
+	return h.history(c, w.ID)
+}
+
+// AdminStatusHistory lists status changes for any wallet.
+func (h *Handler) AdminStatusHistory(c *fiber.Ctx) error {
+	return h.history(c, c.Params("walletId"))
+}
+
+func (h *Handler) history(c *fiber.Ctx, walletID string) error {
+	changes, err := h.service.StatusHistory(c.UserContext(), walletID)
+	if err != nil {
+		return fiber.NewError(http.StatusInternalServerError, err.Error())
+	}
+	out := make([]statusChangeResponse, 0, len(changes))
+	for _, ch := range changes {
+		out = append(out, statusChangeResponse{
+			ID:         ch.ID,
+			FromStatus: ch.FromStatus,
+			ToStatus:   ch.ToStatus,
+			Reason:     ch.Reason,
+			Actor:      ch.Actor,
+			CreatedAt:  ch.CreatedAt,
+		})
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"wallet_id": walletID, "history": out})
+}
+
+func lifecycleError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotOwner), errors.Is(err, guard.ErrDenied):
+		return fiber.NewError(http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrInvalidStatusTransition), errors.Is(err, ErrBalanceNotZero),
+		errors.Is(err, ErrWalletFrozen), errors.Is(err, ErrWalletClosed), errors.Is(err, ErrWalletLocked), errors.Is(err, ledger.ErrAccountRestricted):
+		return fiber.NewError(http.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(http.StatusBadRequest, err.Error())
+	}
+}
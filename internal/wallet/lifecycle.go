@@ -0,0 +1,327 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/congo-pay/congo_pay/internal/guard"
+	"github.com/congo-pay/congo_pay/internal/ledger"
+	"github.com/congo-pay/congo_pay/internal/risk"
+)
+
+// Wallet statuses. Transitions are validated by Service.ChangeStatus.
+const (
+	StatusActive      = "active"
+	StatusFrozenDebit = "frozen_debit"
+	StatusFrozenAll   = "frozen_all"
+	// StatusClosing blocks every movement except the close's own sweep.
+	StatusClosing = "closing"
+	StatusClosed  = "closed"
+)
+
+var (
+	// ErrWalletFrozen indicates the wallet status forbids the requested movement.
+	ErrWalletFrozen = errors.New("wallet frozen")
+	// ErrWalletClosed indicates the wallet has been closed.
+	ErrWalletClosed = errors.New("wallet closed")
+	// ErrInvalidStatusTransition indicates the requested status change is not allowed.
+	ErrInvalidStatusTransition = errors.New("invalid wallet status transition")
+	// ErrBalanceNotZero indicates a close was requested on a funded wallet without a sweep target.
+	ErrBalanceNotZero = errors.New("wallet balance must be zero or swept before closing")
+	// ErrWalletLocked indicates a savings pocket is locked until a future date.
+	ErrWalletLocked = errors.New("wallet locked")
+	// ErrNotOwner indicates the caller does not own the wallet.
+	ErrNotOwner = errors.New("not owner of wallet")
+)
+
+// CanDebit reports whether funds may leave the wallet.
+func (w Wallet) CanDebit() error {
+	switch w.Status {
+	case StatusActive, "":
+		if !w.LockedUntil.IsZero() && time.Now().Before(w.LockedUntil) {
+			return ErrWalletLocked
+		}
+		return nil
+	case StatusClosing, StatusClosed:
+		return ErrWalletClosed
+	default:
+		return ErrWalletFrozen
+	}
+}
+
+// CanCredit reports whether funds may enter the wallet.
+func (w Wallet) CanCredit() error {
+	switch w.Status {
+	case StatusActive, StatusFrozenDebit, "":
+		return nil
+	case StatusClosing, StatusClosed:
+		return ErrWalletClosed
+	default:
+		return ErrWalletFrozen
+	}
+}
+
+// StatusChange is an audit record of a wallet status transition.
+type StatusChange struct {
+	ID         string
+	WalletID   string
+	FromStatus string
+	ToStatus   string
+	Reason     string
+	Actor      string
+	CreatedAt  time.Time
+}
+
+// A wallet is only closed through closing, so its final balance is read once credits stop.
+// A close that cannot finish returns the wallet to the status it had.
+var allowedTransitions = map[string][]string{
+	StatusActive:      {StatusFrozenDebit, StatusFrozenAll, StatusClosing},
+	StatusFrozenDebit: {StatusActive, StatusFrozenAll, StatusClosing},
+	StatusFrozenAll:   {StatusActive, StatusFrozenDebit, StatusClosing},
+	StatusClosing:     {StatusActive, StatusFrozenDebit, StatusFrozenAll, StatusClosed},
+}
+
+func transitionAllowed(from, to string) bool {
+	for _, s := range allowedTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ledgerStatus maps a wallet status onto the posting restriction enforced by the ledger.
+func ledgerStatus(status string) string {
+	switch status {
+	case StatusFrozenDebit:
+		return ledger.AccountStatusDebitBlocked
+	case StatusFrozenAll:
+		return ledger.AccountStatusBlocked
+	case StatusClosing:
+		return ledger.AccountStatusCreditBlocked
+	case StatusClosed:
+		return ledger.AccountStatusClosed
+	default:
+		return ledger.AccountStatusActive
+	}
+}
+
+// StatusChangeInput captures a requested wallet status transition.
+type StatusChangeInput struct {
+	WalletID string
+	Status   string
+	Reason   string
+	Actor    string
+}
+
+// ChangeStatus moves a wallet to a new status, mirrors the restriction onto its ledger
+// account and records the change in the wallet's status history. The ledger restriction is
+// applied first and can be reapplied, so a retry after a failed write is safe; the wallet
+// status and its history row are stored together.
+func (s *Service) ChangeStatus(ctx context.Context, input StatusChangeInput) (Wallet, error) {
+	if strings.TrimSpace(input.Reason) == "" {
+		return Wallet{}, fmt.Errorf("reason is required")
+	}
+	if strings.TrimSpace(input.Actor) == "" {
+		return Wallet{}, fmt.Errorf("actor is required")
+	}
+	w, err := s.repo.Get(ctx, input.WalletID)
+	if err != nil {
+		return Wallet{}, err
+	}
+	if !transitionAllowed(w.Status, input.Status) {
+		return Wallet{}, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, w.Status, input.Status)
+	}
+
+	if err := s.ledger.SetAccountStatus(ctx, w.AccountCode, ledgerStatus(input.Status)); err != nil {
+		return Wallet{}, err
+	}
+	change := StatusChange{
+		ID:         uuid.NewString(),
+		WalletID:   w.ID,
+		FromStatus: w.Status,
+		ToStatus:   input.Status,
+		Reason:     input.Reason,
+		Actor:      input.Actor,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := s.repo.ChangeStatus(ctx, change); err != nil {
+		return Wallet{}, err
+	}
+	w.Status = input.Status
+	return w, nil
+}
+
+// StatusHistory lists the status changes recorded for a wallet, oldest first.
+func (s *Service) StatusHistory(ctx context.Context, walletID string) ([]StatusChange, error) {
+	return s.repo.ListStatusChanges(ctx, walletID)
+}
+
+// CloseInput captures a wallet close request. A funded wallet is either swept to
+// SweepToWalletID, which must belong to the same owner, or paid out: its balance moves to the
+// payout suspense account until operations settle it externally.
+type CloseInput struct {
+	WalletID        string
+	Reason          string
+	Actor           string
+	SweepToWalletID string
+	Payout          bool
+	// RequestorUserID, when set, must match the wallet owner.
+	RequestorUserID string
+	// OTPCode confirms an owner's close sweeping at least the high-value amount.
+	OTPCode string
+}
+
+// CloseResult describes the outcome of a wallet close.
+type CloseResult struct {
+	Wallet             Wallet
+	SweptAmount        int64
+	SweepTransactionID string
+	// PaidOut reports that the swept amount went to the payout suspense account.
+	PaidOut bool
+}
+
+// Close closes a wallet. The wallet first moves to closing, which stops credits, so the
+// balance read afterwards is final. A funded wallet is then swept or paid out, and the wallet
+// is closed once its balance is zero. A close that cannot finish puts the previous status
+// back; one interrupted part-way can simply be retried. Frozen wallets can only be closed
+// once empty.
+func (s *Service) Close(ctx context.Context, input CloseInput) (CloseResult, error) {
+	if strings.TrimSpace(input.Reason) == "" || strings.TrimSpace(input.Actor) == "" {
+		return CloseResult{}, fmt.Errorf("reason and actor are required")
+	}
+	w, err := s.repo.Get(ctx, input.WalletID)
+	if err != nil {
+		return CloseResult{}, err
+	}
+	if input.RequestorUserID != "" && w.OwnerID != input.RequestorUserID {
+		return CloseResult{}, ErrNotOwner
+	}
+	if w.Status == StatusClosed {
+		return CloseResult{}, ErrWalletClosed
+	}
+	if w.IsPrimary {
+		siblings, err := s.repo.ListByOwner(ctx, w.OwnerID)
+		if err != nil {
+			return CloseResult{}, err
+		}
+		for _, other := range siblings {
+			if other.ID != w.ID && other.Kind == KindMain && other.Status != StatusClosed {
+				return CloseResult{}, fmt.Errorf("%w: choose another primary wallet before closing this one", ErrInvalidStatusTransition)
+			}
+		}
+	}
+
+	prev := w
+	if w.Status == StatusClosing {
+		if prev.Status, err = s.statusBeforeClosing(ctx, w.ID); err != nil {
+			return CloseResult{}, err
+		}
+	} else if _, err := s.ChangeStatus(ctx, StatusChangeInput{WalletID: w.ID, Status: StatusClosing, Reason: input.Reason, Actor: input.Actor}); err != nil {
+		return CloseResult{}, err
+	}
+
+	result, err := s.empty(ctx, prev, input)
+	if err != nil {
+		if _, reopenErr := s.ChangeStatus(ctx, StatusChangeInput{WalletID: w.ID, Status: prev.Status, Reason: "close abandoned: " + err.Error(), Actor: input.Actor}); reopenErr != nil {
+			return CloseResult{}, fmt.Errorf("%w; reopen wallet %s: %w", err, w.ID, reopenErr)
+		}
+		return CloseResult{}, err
+	}
+	closed, err := s.ChangeStatus(ctx, StatusChangeInput{
+		WalletID: w.ID,
+		Status:   StatusClosed,
+		Reason:   input.Reason,
+		Actor:    input.Actor,
+	})
+	if err != nil {
+		return CloseResult{}, err
+	}
+	result.Wallet = closed
+	return result, nil
+}
+
+// empty moves what is left in a closing wallet to the sweep target or the payout suspense
+// account and confirms nothing remains. w carries the status the wallet had before closing.
+func (s *Service) empty(ctx context.Context, w Wallet, input CloseInput) (CloseResult, error) {
+	balance, err := s.ledger.Balance(ctx, w.AccountCode)
+	if err != nil {
+		return CloseResult{}, err
+	}
+
+	var result CloseResult
+	if balance > 0 {
+		if input.SweepToWalletID == "" && !input.Payout {
+			return CloseResult{}, ErrBalanceNotZero
+		}
+		if err := w.CanDebit(); err != nil {
+			return CloseResult{}, err
+		}
+		destCode, kind := ledger.PayoutSuspenseAccountCode, "wallet_close_payout"
+		if input.Payout {
+			if err := s.ledger.EnsureAccount(ctx, destCode); err != nil {
+				return CloseResult{}, err
+			}
+		} else {
+			dest, err := s.repo.Get(ctx, input.SweepToWalletID)
+			if err != nil {
+				return CloseResult{}, err
+			}
+			if dest.ID == w.ID {
+				return CloseResult{}, fmt.Errorf("sweep target must be a different wallet")
+			}
+			// A close is not a transfer: the balance may only move to another of the owner's wallets.
+			if dest.OwnerID != w.OwnerID {
+				return CloseResult{}, ErrNotOwner
+			}
+			if err := dest.CanCredit(); err != nil {
+				return CloseResult{}, err
+			}
+			destCode, kind = dest.AccountCode, "wallet_close_sweep"
+		}
+		// Back-office closes are not screened; an owner's close is treated as a withdrawal.
+		if input.RequestorUserID != "" {
+			if err := s.guard.Check(ctx, guard.Outgoing{UserID: w.OwnerID, Op: risk.OpWithdrawal, Amount: balance, OTPCode: input.OTPCode}); err != nil {
+				return CloseResult{}, err
+			}
+		}
+		// Credits stopped before the balance was read, so each attempt sweeps exactly what is
+		// left and gets its own key; a retry finds the balance an earlier attempt moved gone.
+		res, err := s.ledger.Transfer(ctx, w.AccountCode, destCode, kind, uuid.NewString(), balance)
+		if err != nil {
+			return CloseResult{}, err
+		}
+		result.SweptAmount = balance
+		result.SweepTransactionID = res.TransactionID
+		result.PaidOut = input.Payout
+	}
+
+	remaining, err := s.ledger.Balance(ctx, w.AccountCode)
+	if err != nil {
+		return CloseResult{}, err
+	}
+	if remaining != 0 {
+		return CloseResult{}, ErrBalanceNotZero
+	}
+	return result, nil
+}
+
+// statusBeforeClosing finds the status a closing wallet had, so an interrupted close that
+// cannot finish reopens it as it was.
+func (s *Service) statusBeforeClosing(ctx context.Context, walletID string) (string, error) {
+	changes, err := s.repo.ListStatusChanges(ctx, walletID)
+	if err != nil {
+		return "", err
+	}
+	for i := len(changes) - 1; i >= 0; i-- {
+		if changes[i].ToStatus == StatusClosing {
+			return changes[i].FromStatus, nil
+		}
+	}
+	return StatusActive, nil
+}
@@ -9,12 +9,13 @@ import (
 type memoryRepository struct {
     mu      sync.RWMutex
     storage map[string]Wallet
-    byOwner map[string]string
+    byOwner map[string][]string
+    history map[string][]StatusChange
 }
 
 // NewMemoryRepository constructs an in-memory repository for tests.
 func NewMemoryRepository() Repository {
-    return &memoryRepository{storage: make(map[string]Wallet), byOwner: make(map[string]string)}
+    return &memoryRepository{storage: make(map[string]Wallet), byOwner: make(map[string][]string), history: make(map[string][]StatusChange)}
 }
 
 func (r *memoryRepository) Create(_ context.Context, wallet Wallet) error {
@@ -25,7 +26,7 @@ func (r *memoryRepository) Create(_ context.Context, wallet Wallet) error {
     }
     r.storage[wallet.ID] = wallet
     if wallet.OwnerID != "" {
-        r.byOwner[wallet.OwnerID] = wallet.ID
+        r.byOwner[wallet.OwnerID] = append(r.byOwner[wallet.OwnerID], wallet.ID)
     }
     return nil
 }
@@ -43,13 +44,81 @@ func (r *memoryRepository) Get(_ context.Context, id string) (Wallet, error) {
 func (r *memoryRepository) FindByOwner(_ context.Context, ownerID string) (Wallet, error) {
     r.mu.RLock()
     defer r.mu.RUnlock()
-    id, ok := r.byOwner[ownerID]
-    if !ok {
+    ids := r.byOwner[ownerID]
+    if len(ids) == 0 {
         return Wallet{}, errors.New("wallet not found")
     }
-    w, ok := r.storage[id]
+    for _, id := range ids {
+        if w := r.storage[id]; w.IsPrimary {
+            return w, nil
+        }
+    }
+    return r.storage[ids[0]], nil
+}
+
+func (r *memoryRepository) ListByOwner(_ context.Context, ownerID string) ([]Wallet, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    var out []Wallet
+    for _, id := range r.byOwner[ownerID] {
+        w := r.storage[id]
+        if w.IsPrimary {
+            out = append([]Wallet{w}, out...)
+            continue
+        }
+        out = append(out, w)
+    }
+    return out, nil
+}
+
+func (r *memoryRepository) Update(_ context.Context, wallet Wallet) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    w, ok := r.storage[wallet.ID]
     if !ok {
-        return Wallet{}, errors.New("wallet not found")
+        return errors.New("wallet not found")
+    }
+    w.Name = wallet.Name
+    w.LockedUntil = wallet.LockedUntil
+    r.storage[wallet.ID] = w
+    return nil
+}
+
+func (r *memoryRepository) SetPrimary(_ context.Context, ownerID, id string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    target, ok := r.storage[id]
+    if !ok || target.OwnerID != ownerID {
+        return errors.New("wallet not found")
+    }
+    for _, wid := range r.byOwner[ownerID] {
+        w := r.storage[wid]
+        w.IsPrimary = wid == id
+        r.storage[wid] = w
+    }
+    return nil
+}
+
+func (r *memoryRepository) ChangeStatus(_ context.Context, change StatusChange) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    w, ok := r.storage[change.WalletID]
+    if !ok {
+        return errors.New("wallet not found")
     }
-    return w, nil
+    if w.Status != change.FromStatus {
+        return ErrInvalidStatusTransition
+    }
+    w.Status = change.ToStatus
+    r.storage[change.WalletID] = w
+    r.history[change.WalletID] = append(r.history[change.WalletID], change)
+    return nil
+}
+
+func (r *memoryRepository) ListStatusChanges(_ context.Context, walletID string) ([]StatusChange, error) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+    out := make([]StatusChange, len(r.history[walletID]))
+    copy(out, r.history[walletID])
+    return out, nil
 }
@@ -9,12 +9,28 @@ type Wallet struct {
     AccountCode string
     Currency    string
     Status      string
+    Name        string
+    Kind        string
+    IsPrimary   bool
+    // LockedUntil blocks outgoing funds from a savings pocket until the given time.
+    LockedUntil time.Time
     CreatedAt   time.Time
 }
 
+// Wallet kinds.
+const (
+    KindMain   = "main"
+    KindPocket = "pocket"
+)
+
 // Balance encapsulates available funds for a wallet.
 type Balance struct {
     WalletID string
     Amount   int64
     AsOf     time.Time
 }
+
+// AccountCode is the ledger account backing a wallet.
+func AccountCode(walletID string) string {
+    return "wallet:" + walletID
+}
@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,14 @@ type Repository interface {
     Create(ctx context.Context, wallet Wallet) error
     Get(ctx context.Context, id string) (Wallet, error)
     FindByOwner(ctx context.Context, ownerID string) (Wallet, error)
+    ListByOwner(ctx context.Context, ownerID string) ([]Wallet, error)
+    Update(ctx context.Context, wallet Wallet) error
+    SetPrimary(ctx context.Context, ownerID, id string) error
+    // ChangeStatus moves a wallet from change.FromStatus to change.ToStatus and appends the
+    // change to its history in one transaction. It returns ErrInvalidStatusTransition when
+    // the wallet is no longer in change.FromStatus.
+    ChangeStatus(ctx context.Context, change StatusChange) error
+    ListStatusChanges(ctx context.Context, walletID string) ([]StatusChange, error)
 }
 
 // PostgresRepository stores wallets in PostgreSQL.
@@ -25,6 +34,40 @@ func NewPostgresRepository(db *pgxpool.Pool) *PostgresRepository {
 	return &PostgresRepository{db: db}
 }
 
+const walletColumns = `id, owner_id, account_code, currency, status, name, kind, is_primary, locked_until, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWallet(row rowScanner) (Wallet, error) {
+	var (
+		w           Wallet
+		idVal       uuid.UUID
+		ownerID     uuid.UUID
+		lockedUntil *time.Time
+		createdAt   time.Time
+	)
+	if err := row.Scan(&idVal, &ownerID, &w.AccountCode, &w.Currency, &w.Status, &w.Name, &w.Kind, &w.IsPrimary, &lockedUntil, &createdAt); err != nil {
+		return Wallet{}, err
+	}
+	w.ID = idVal.String()
+	w.OwnerID = ownerID.String()
+	if lockedUntil != nil {
+		w.LockedUntil = lockedUntil.UTC()
+	}
+	w.CreatedAt = createdAt.UTC()
+	return w, nil
+}
+
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
 // Create inserts a wallet record.
 func (r *PostgresRepository) Create(ctx context.Context, wallet Wallet) error {
 	walletID, err := uuid.Parse(wallet.ID)
@@ -35,8 +78,9 @@ func (r *PostgresRepository) Create(ctx context.Context, wallet Wallet) error {
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Exec(ctx, `INSERT INTO wallets (id, owner_id, account_code, currency, status, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6)`, walletID, ownerID, wallet.AccountCode, wallet.Currency, wallet.Status, wallet.CreatedAt.UTC())
+	_, err = r.db.Exec(ctx, `INSERT INTO wallets (id, owner_id, account_code, currency, status, name, kind, is_primary, locked_until, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`, walletID, ownerID, wallet.AccountCode, wallet.Currency, wallet.Status,
+		wallet.Name, wallet.Kind, wallet.IsPrimary, nullableTime(wallet.LockedUntil), wallet.CreatedAt.UTC())
 	return err
 }
 
@@ -46,38 +90,141 @@ func (r *PostgresRepository) Get(ctx context.Context, id string) (Wallet, error)
 	if err != nil {
 		return Wallet{}, err
 	}
-	row := r.db.QueryRow(ctx, `SELECT id, owner_id, account_code, currency, status, created_at
-        FROM wallets WHERE id = $1`, walletUUID)
-	var w Wallet
-	var createdAt time.Time
-	var idVal uuid.UUID
-	var ownerID uuid.UUID
-	if err := row.Scan(&idVal, &ownerID, &w.AccountCode, &w.Currency, &w.Status, &createdAt); err != nil {
+	return scanWallet(r.db.QueryRow(ctx, `SELECT `+walletColumns+` FROM wallets WHERE id = $1`, walletUUID))
+}
+
+// FindByOwner returns the owner's primary wallet, falling back to the oldest one.
+func (r *PostgresRepository) FindByOwner(ctx context.Context, ownerID string) (Wallet, error) {
+	ownerUUID, err := uuid.Parse(ownerID)
+	if err != nil {
 		return Wallet{}, err
 	}
-	w.ID = idVal.String()
-	w.OwnerID = ownerID.String()
-	w.CreatedAt = createdAt.UTC()
-	return w, nil
+	return scanWallet(r.db.QueryRow(ctx, `SELECT `+walletColumns+`
+        FROM wallets WHERE owner_id = $1 ORDER BY is_primary DESC, created_at LIMIT 1`, ownerUUID))
 }
 
-// FindByOwner returns the first wallet for an owner.
-func (r *PostgresRepository) FindByOwner(ctx context.Context, ownerID string) (Wallet, error) {
-    ownerUUID, err := uuid.Parse(ownerID)
-    if err != nil {
-        return Wallet{}, err
-    }
-    row := r.db.QueryRow(ctx, `SELECT id, owner_id, account_code, currency, status, created_at
-        FROM wallets WHERE owner_id = $1 LIMIT 1`, ownerUUID)
-    var w Wallet
-    var createdAt time.Time
-    var idVal uuid.UUID
-    var owner uuid.UUID
-    if err := row.Scan(&idVal, &owner, &w.AccountCode, &w.Currency, &w.Status, &createdAt); err != nil {
-        return Wallet{}, err
-    }
-    w.ID = idVal.String()
-    w.OwnerID = owner.String()
-    w.CreatedAt = createdAt.UTC()
-    return w, nil
+// ListByOwner returns every wallet and pocket of an owner, primary first.
+func (r *PostgresRepository) ListByOwner(ctx context.Context, ownerID string) ([]Wallet, error) {
+	ownerUUID, err := uuid.Parse(ownerID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT `+walletColumns+`
+        FROM wallets WHERE owner_id = $1 ORDER BY is_primary DESC, created_at`, ownerUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Wallet
+	for rows.Next() {
+		w, err := scanWallet(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// Update persists a wallet's name and lock date.
+func (r *PostgresRepository) Update(ctx context.Context, wallet Wallet) error {
+	walletID, err := uuid.Parse(wallet.ID)
+	if err != nil {
+		return err
+	}
+	cmd, err := r.db.Exec(ctx, `UPDATE wallets SET name = $1, locked_until = $2 WHERE id = $3`, wallet.Name, nullableTime(wallet.LockedUntil), walletID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New("wallet not found")
+	}
+	return nil
+}
+
+// SetPrimary marks one wallet as the owner's primary and clears the flag on the others.
+func (r *PostgresRepository) SetPrimary(ctx context.Context, ownerID, id string) error {
+	ownerUUID, err := uuid.Parse(ownerID)
+	if err != nil {
+		return err
+	}
+	walletID, err := uuid.Parse(id)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // nolint:errcheck
+	if _, err := tx.Exec(ctx, `UPDATE wallets SET is_primary = FALSE WHERE owner_id = $1 AND is_primary`, ownerUUID); err != nil {
+		return err
+	}
+	cmd, err := tx.Exec(ctx, `UPDATE wallets SET is_primary = TRUE WHERE id = $1 AND owner_id = $2`, walletID, ownerUUID)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return errors.New("wallet not found")
+	}
+	return tx.Commit(ctx)
+}
+
+// ChangeStatus sets the wallet lifecycle status and records it in the status history.
+func (r *PostgresRepository) ChangeStatus(ctx context.Context, change StatusChange) error {
+	changeID, err := uuid.Parse(change.ID)
+	if err != nil {
+		return err
+	}
+	walletID, err := uuid.Parse(change.WalletID)
+	if err != nil {
+		return err
+	}
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	cmd, err := tx.Exec(ctx, `UPDATE wallets SET status = $1 WHERE id = $2 AND status = $3`, change.ToStatus, walletID, change.FromStatus)
+	if err != nil {
+		return err
+	}
+	if cmd.RowsAffected() == 0 {
+		return ErrInvalidStatusTransition
+	}
+	if _, err := tx.Exec(ctx, `INSERT INTO wallet_status_history (id, wallet_id, from_status, to_status, reason, actor, created_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`, changeID, walletID, change.FromStatus, change.ToStatus, change.Reason, change.Actor, change.CreatedAt.UTC()); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListStatusChanges returns a wallet's status history, oldest first.
+func (r *PostgresRepository) ListStatusChanges(ctx context.Context, walletID string) ([]StatusChange, error) {
+	walletUUID, err := uuid.Parse(walletID)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query(ctx, `SELECT id, wallet_id, from_status, to_status, reason, actor, created_at
+        FROM wallet_status_history WHERE wallet_id = $1 ORDER BY created_at`, walletUUID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []StatusChange
+	for rows.Next() {
+		var (
+			c        StatusChange
+			id       uuid.UUID
+			walletID uuid.UUID
+		)
+		if err := rows.Scan(&id, &walletID, &c.FromStatus, &c.ToStatus, &c.Reason, &c.Actor, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.ID = id.String()
+		c.WalletID = walletID.String()
+		c.CreatedAt = c.CreatedAt.UTC()
+		out = append(out, c)
+	}
+	return out, rows.Err()
 }
@@ -3,38 +3,41 @@ package wallet
 import (
     "context"
     "fmt"
+    "strings"
     "time"
 
     "github.com/google/uuid"
 
+    "github.com/congo-pay/congo_pay/internal/guard"
     "github.com/congo-pay/congo_pay/internal/ledger"
 )
 
-const (
-    statusActive = "active"
-)
-
 // Service exposes wallet operations backed by the ledger.
 type Service struct {
     repo   Repository
     ledger ledger.Ledger
+    guard  *guard.Guard
 }
 
-// NewService builds a wallet service instance.
-func NewService(repo Repository, ledger ledger.Ledger) *Service {
-    return &Service{repo: repo, ledger: ledger}
+// NewService builds a wallet service instance. outgoing screens closes requested by the
+// owner and may be nil.
+func NewService(repo Repository, ledger ledger.Ledger, outgoing *guard.Guard) *Service {
+    return &Service{repo: repo, ledger: ledger, guard: outgoing}
 }
 
-// CreateInput captures data required to create a wallet.
+// CreateInput captures data required to create a wallet or savings pocket.
 type CreateInput struct {
-    OwnerID  string
-    Currency string
+    OwnerID     string
+    Currency    string
+    Name        string
+    Kind        string
+    LockedUntil time.Time
 }
 
 // Create provisions a wallet and associated ledger account.
 func (s *Service) Create(ctx context.Context, input CreateInput) (Wallet, error) {
     walletID := uuid.New().String()
-    accountCode := fmt.Sprintf("wallet:%s", walletID)
+    accountCode := AccountCode(walletID)
 
     if _, err := uuid.Parse(input.OwnerID); err != nil {
         return Wallet{}, err
@@ -48,13 +51,47 @@ func (s *Service) Create(ctx context.Context, input CreateInput) (Wallet, error)
     if currency == "" {
         currency = "XAF"
     }
+    kind := input.Kind
+    if kind == "" {
+        kind = KindMain
+    }
+    name := strings.TrimSpace(input.Name)
+    switch kind {
+    case KindMain:
+        if name == "" {
+            name = "Main"
+        }
+        if !input.LockedUntil.IsZero() {
+            return Wallet{}, fmt.Errorf("only pockets can be locked")
+        }
+    case KindPocket:
+        if name == "" {
+            return Wallet{}, fmt.Errorf("pocket name is required")
+        }
+    default:
+        return Wallet{}, fmt.Errorf("unknown wallet kind %q", kind)
+    }
+
+    // An owner's first main wallet becomes the primary one.
+    isPrimary := false
+    if kind == KindMain {
+        existing, err := s.repo.ListByOwner(ctx, input.OwnerID)
+        if err != nil {
+            return Wallet{}, err
+        }
+        isPrimary = len(existing) == 0
+    }
 
     wallet := Wallet{
         ID:          walletID,
         OwnerID:     input.OwnerID,
         AccountCode: accountCode,
         Currency:    currency,
-        Status:      statusActive,
+        Status:      StatusActive,
+        Name:        name,
+        Kind:        kind,
+        IsPrimary:   isPrimary,
+        LockedUntil: input.LockedUntil.UTC(),
         CreatedAt:   time.Now().UTC(),
     }
 
@@ -83,7 +120,132 @@ func (s *Service) Balance(ctx context.Context, id string) (Balance, error) {
     return Balance{WalletID: wallet.ID, Amount: amount, AsOf: time.Now().UTC()}, nil
 }
 
-// GetByOwner retrieves a wallet using the owner identifier.
+// GetByOwner retrieves the owner's primary wallet.
 func (s *Service) GetByOwner(ctx context.Context, ownerID string) (Wallet, error) {
     return s.repo.FindByOwner(ctx, ownerID)
 }
+
+// ListByOwner returns all wallets and pockets belonging to an owner, primary first.
+func (s *Service) ListByOwner(ctx context.Context, ownerID string) ([]Wallet, error) {
+    return s.repo.ListByOwner(ctx, ownerID)
+}
+
+// UpdateInput captures owner edits to a wallet. Nil fields are left unchanged.
+type UpdateInput struct {
+    WalletID    string
+    OwnerID     string
+    Name        *string
+    LockedUntil *time.Time
+}
+
+// Update renames a wallet or extends a pocket lock. Locks can only be extended, never shortened.
+func (s *Service) Update(ctx context.Context, input UpdateInput) (Wallet, error) {
+    w, err := s.ownedWallet(ctx, input.OwnerID, input.WalletID)
+    if err != nil {
+        return Wallet{}, err
+    }
+    if w.Status == StatusClosing || w.Status == StatusClosed {
+        return Wallet{}, ErrWalletClosed
+    }
+    if input.Name != nil {
+        name := strings.TrimSpace(*input.Name)
+        if name == "" {
+            return Wallet{}, fmt.Errorf("name cannot be empty")
+        }
+        w.Name = name
+    }
+    if input.LockedUntil != nil {
+        if w.Kind != KindPocket {
+            return Wallet{}, fmt.Errorf("only pockets can be locked")
+        }
+        if input.LockedUntil.Before(w.LockedUntil) {
+            return Wallet{}, ErrWalletLocked
+        }
+        w.LockedUntil = input.LockedUntil.UTC()
+    }
+    if err := s.repo.Update(ctx, w); err != nil {
+        return Wallet{}, err
+    }
+    return w, nil
+}
+
+// SetPrimary makes an active main wallet the owner's primary wallet.
+func (s *Service) SetPrimary(ctx context.Context, ownerID, walletID string) (Wallet, error) {
+    w, err := s.ownedWallet(ctx, ownerID, walletID)
+    if err != nil {
+        return Wallet{}, err
+    }
+    if w.Kind != KindMain {
+        return Wallet{}, fmt.Errorf("pockets cannot be primary")
+    }
+    if w.Status != StatusActive {
+        return Wallet{}, fmt.Errorf("only active wallets can be primary")
+    }
+    if err := s.repo.SetPrimary(ctx, ownerID, walletID); err != nil {
+        return Wallet{}, err
+    }
+    w.IsPrimary = true
+    return w, nil
+}
+
+// MoveInput captures a fee-free move between two wallets of the same owner.
+type MoveInput struct {
+    OwnerID      string
+    FromWalletID string
+    ToWalletID   string
+    Amount       int64
+    ClientTxID   string
+}
+
+// MoveResult describes an internal move outcome.
+type MoveResult struct {
+    TransactionID string
+    FromBalance   int64
+    ToBalance     int64
+}
+
+// Move transfers funds between two wallets or pockets owned by the same user without fees.
+func (s *Service) Move(ctx context.Context, input MoveInput) (MoveResult, error) {
+    if input.Amount <= 0 {
+        return MoveResult{}, fmt.Errorf("amount must be positive")
+    }
+    if input.FromWalletID == input.ToWalletID {
+        return MoveResult{}, fmt.Errorf("source and destination must differ")
+    }
+    if input.ClientTxID == "" {
+        input.ClientTxID = uuid.NewString()
+    }
+    from, err := s.ownedWallet(ctx, input.OwnerID, input.FromWalletID)
+    if err != nil {
+        return MoveResult{}, err
+    }
+    to, err := s.ownedWallet(ctx, input.OwnerID, input.ToWalletID)
+    if err != nil {
+        return MoveResult{}, err
+    }
+    if err := from.CanDebit(); err != nil {
+        return MoveResult{}, err
+    }
+    if err := to.CanCredit(); err != nil {
+        return MoveResult{}, err
+    }
+    if from.Currency != to.Currency {
+        return MoveResult{}, fmt.Errorf("currency mismatch")
+    }
+    res, err := s.ledger.Transfer(ctx, from.AccountCode, to.AccountCode, "internal_move", input.ClientTxID, input.Amount)
+    if err != nil {
+        return MoveResult{}, err
+    }
+    return MoveResult{TransactionID: res.TransactionID, FromBalance: res.FromBalance, ToBalance: res.ToBalance}, nil
+}
+
+func (s *Service) ownedWallet(ctx context.Context, ownerID, walletID string) (Wallet, error) {
+    w, err := s.repo.Get(ctx, walletID)
+    if err != nil {
+        return Wallet{}, err
+    }
+    if w.OwnerID != ownerID {
+        return Wallet{}, ErrNotOwner
+    }
+    return w, nil
+}
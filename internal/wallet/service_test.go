@@ -2,7 +2,9 @@ package wallet
 
 import (
     "context"
+    "errors"
     "testing"
+    "time"
 
     "github.com/google/uuid"
 
@@ -12,7 +14,7 @@ import (
 func TestServiceCreateAndBalance(t *testing.T) {
     repo := NewMemoryRepository()
     led := ledger.NewInMemory()
-    svc := NewService(repo, led)
+    svc := NewService(repo, led, nil)
 
     ctx := context.Background()
     ownerID := uuid.NewString()
@@ -39,3 +41,205 @@ func TestServiceCreateAndBalance(t *testing.T) {
         t.Fatalf("expected balance 2500, got %d", balance.Amount)
     }
 }
+
+func TestServiceFreezeAndUnfreeze(t *testing.T) {
+    led := ledger.NewInMemory()
+    svc := NewService(NewMemoryRepository(), led, nil)
+    ctx := context.Background()
+
+    w, _ := svc.Create(ctx, CreateInput{OwnerID: uuid.NewString()})
+    other, _ := svc.Create(ctx, CreateInput{OwnerID: uuid.NewString()})
+    ledger.SeedBalance(led, w.AccountCode, 1_000)
+
+    frozen, err := svc.ChangeStatus(ctx, StatusChangeInput{WalletID: w.ID, Status: StatusFrozenDebit, Reason: "suspected fraud", Actor: "admin:1"})
+    if err != nil {
+        t.Fatalf("freeze: %v", err)
+    }
+    if err := frozen.CanDebit(); err != ErrWalletFrozen {
+        t.Fatalf("expected frozen wallet to refuse debits, got %v", err)
+    }
+    if err := frozen.CanCredit(); err != nil {
+        t.Fatalf("expected frozen_debit wallet to accept credits, got %v", err)
+    }
+    // The ledger enforces the restriction even if a caller skips the wallet check.
+    if _, err := led.Transfer(ctx, w.AccountCode, other.AccountCode, "p2p", "bypass", 100); err != ledger.ErrAccountRestricted {
+        t.Fatalf("expected ledger restriction, got %v", err)
+    }
+
+    if _, err := svc.ChangeStatus(ctx, StatusChangeInput{WalletID: w.ID, Status: StatusActive, Reason: "cleared", Actor: "admin:1"}); err != nil {
+        t.Fatalf("unfreeze: %v", err)
+    }
+    if _, err := led.Transfer(ctx, w.AccountCode, other.AccountCode, "p2p", "after", 100); err != nil {
+        t.Fatalf("transfer after unfreeze: %v", err)
+    }
+
+    history, err := svc.StatusHistory(ctx, w.ID)
+    if err != nil {
+        t.Fatalf("history: %v", err)
+    }
+    if len(history) != 2 || history[0].ToStatus != StatusFrozenDebit || history[1].ToStatus != StatusActive {
+        t.Fatalf("unexpected history: %+v", history)
+    }
+}
+
+func TestServiceCloseWithSweep(t *testing.T) {
+    led := ledger.NewInMemory()
+    svc := NewService(NewMemoryRepository(), led, nil)
+    ctx := context.Background()
+    owner := uuid.NewString()
+
+    target, _ := svc.Create(ctx, CreateInput{OwnerID: owner})
+    w, _ := svc.Create(ctx, CreateInput{OwnerID: owner})
+    ledger.SeedBalance(led, w.AccountCode, 4_000)
+
+    if _, err := svc.Close(ctx, CloseInput{WalletID: target.ID, Reason: "leaving", Actor: "user:" + owner}); !errors.Is(err, ErrInvalidStatusTransition) {
+        t.Fatalf("expected primary wallet close to be refused while another wallet is open, got %v", err)
+    }
+
+    if _, err := svc.Close(ctx, CloseInput{WalletID: w.ID, Reason: "leaving", Actor: "user:" + owner}); err != ErrBalanceNotZero {
+        t.Fatalf("expected balance error, got %v", err)
+    }
+
+    stranger, _ := svc.Create(ctx, CreateInput{OwnerID: uuid.NewString()})
+    if _, err := svc.Close(ctx, CloseInput{WalletID: w.ID, Reason: "leaving", Actor: "user:" + owner, SweepToWalletID: stranger.ID, RequestorUserID: owner}); !errors.Is(err, ErrNotOwner) {
+        t.Fatalf("expected sweeps to other owners to be refused, got %v", err)
+    }
+
+    res, err := svc.Close(ctx, CloseInput{WalletID: w.ID, Reason: "leaving", Actor: "user:" + owner, SweepToWalletID: target.ID, RequestorUserID: owner})
+    if err != nil {
+        t.Fatalf("close: %v", err)
+    }
+    if res.SweptAmount != 4_000 || res.Wallet.Status != StatusClosed {
+        t.Fatalf("unexpected close result: %+v", res)
+    }
+    bal, _ := svc.Balance(ctx, target.ID)
+    if bal.Amount != 4_000 {
+        t.Fatalf("expected swept balance 4000, got %d", bal.Amount)
+    }
+    if _, err := svc.ChangeStatus(ctx, StatusChangeInput{WalletID: w.ID, Status: StatusActive, Reason: "reopen", Actor: "admin:1"}); !errors.Is(err, ErrInvalidStatusTransition) {
+        t.Fatalf("expected closed wallet to stay closed, got %v", err)
+    }
+}
+
+func TestServiceCloseWithPayout(t *testing.T) {
+    led := ledger.NewInMemory()
+    svc := NewService(NewMemoryRepository(), led, nil)
+    ctx := context.Background()
+    owner := uuid.NewString()
+
+    w, _ := svc.Create(ctx, CreateInput{OwnerID: owner})
+    ledger.SeedBalance(led, w.AccountCode, 2_500)
+
+    res, err := svc.Close(ctx, CloseInput{WalletID: w.ID, Reason: "leaving", Actor: "user:" + owner, Payout: true, RequestorUserID: owner})
+    if err != nil {
+        t.Fatalf("close: %v", err)
+    }
+    if !res.PaidOut || res.SweptAmount != 2_500 || res.Wallet.Status != StatusClosed {
+        t.Fatalf("unexpected close result: %+v", res)
+    }
+    if bal, _ := led.Balance(ctx, ledger.PayoutSuspenseAccountCode); bal != 2_500 {
+        t.Fatalf("expected 2500 awaiting payout, got %d", bal)
+    }
+}
+
+func TestServiceCloseBlocksCreditsAndReopensOnFailure(t *testing.T) {
+    led := ledger.NewInMemory()
+    svc := NewService(NewMemoryRepository(), led, nil)
+    ctx := context.Background()
+    owner := uuid.NewString()
+
+    target, _ := svc.Create(ctx, CreateInput{OwnerID: owner})
+    w, _ := svc.Create(ctx, CreateInput{OwnerID: owner})
+    ledger.SeedBalance(led, w.AccountCode, 1_000)
+    if _, err := svc.ChangeStatus(ctx, StatusChangeInput{WalletID: target.ID, Status: StatusFrozenAll, Reason: "review", Actor: "admin:1"}); err != nil {
+        t.Fatalf("freeze target: %v", err)
+    }
+
+    // The sweep target cannot be credited, so the close is abandoned and the wallet reopened.
+    if _, err := svc.Close(ctx, CloseInput{WalletID: w.ID, Reason: "leaving", Actor: "user:" + owner, SweepToWalletID: target.ID}); !errors.Is(err, ErrWalletFrozen) {
+        t.Fatalf("expected frozen target to be refused, got %v", err)
+    }
+    reopened, _ := svc.Get(ctx, w.ID)
+    if reopened.Status != StatusActive {
+        t.Fatalf("expected wallet reopened, got %s", reopened.Status)
+    }
+
+    // A close interrupted after it stopped credits resumes where it left off.
+    if _, err := svc.ChangeStatus(ctx, StatusChangeInput{WalletID: w.ID, Status: StatusClosing, Reason: "leaving", Actor: "user:" + owner}); err != nil {
+        t.Fatalf("closing: %v", err)
+    }
+    if _, err := svc.ChangeStatus(ctx, StatusChangeInput{WalletID: target.ID, Status: StatusActive, Reason: "cleared", Actor: "admin:1"}); err != nil {
+        t.Fatalf("unfreeze target: %v", err)
+    }
+    ledger.SeedBalance(led, target.AccountCode, 500)
+    if _, err := led.Transfer(ctx, target.AccountCode, w.AccountCode, "p2p", "late", 100); !errors.Is(err, ledger.ErrAccountRestricted) {
+        t.Fatalf("expected credits to a closing wallet to be refused, got %v", err)
+    }
+    res, err := svc.Close(ctx, CloseInput{WalletID: w.ID, Reason: "leaving", Actor: "user:" + owner, SweepToWalletID: target.ID})
+    if err != nil {
+        t.Fatalf("close: %v", err)
+    }
+    if res.SweptAmount != 1_000 || res.Wallet.Status != StatusClosed {
+        t.Fatalf("unexpected close result: %+v", res)
+    }
+    if bal, _ := svc.Balance(ctx, w.ID); bal.Amount != 0 {
+        t.Fatalf("expected closed wallet to be empty, got %d", bal.Amount)
+    }
+}
+
+func TestServiceMultipleWalletsAndPockets(t *testing.T) {
+    led := ledger.NewInMemory()
+    svc := NewService(NewMemoryRepository(), led, nil)
+    ctx := context.Background()
+    owner := uuid.NewString()
+
+    main, err := svc.Create(ctx, CreateInput{OwnerID: owner})
+    if err != nil {
+        t.Fatalf("create main: %v", err)
+    }
+    if !main.IsPrimary {
+        t.Fatal("expected first wallet to be primary")
+    }
+    business, _ := svc.Create(ctx, CreateInput{OwnerID: owner, Name: "Shop"})
+    if business.IsPrimary {
+        t.Fatal("expected second wallet not to be primary")
+    }
+    pocket, err := svc.Create(ctx, CreateInput{OwnerID: owner, Kind: KindPocket, Name: "School fees", LockedUntil: time.Now().Add(24 * time.Hour)})
+    if err != nil {
+        t.Fatalf("create pocket: %v", err)
+    }
+
+    wallets, _ := svc.ListByOwner(ctx, owner)
+    if len(wallets) != 3 || wallets[0].ID != main.ID {
+        t.Fatalf("expected 3 wallets with primary first, got %+v", wallets)
+    }
+
+    ledger.SeedBalance(led, main.AccountCode, 10_000)
+    if _, err := svc.Move(ctx, MoveInput{OwnerID: owner, FromWalletID: main.ID, ToWalletID: pocket.ID, Amount: 3_000}); err != nil {
+        t.Fatalf("move into pocket: %v", err)
+    }
+    if _, err := svc.Move(ctx, MoveInput{OwnerID: owner, FromWalletID: pocket.ID, ToWalletID: main.ID, Amount: 1_000}); err != ErrWalletLocked {
+        t.Fatalf("expected locked pocket, got %v", err)
+    }
+    if _, err := svc.Move(ctx, MoveInput{OwnerID: uuid.NewString(), FromWalletID: main.ID, ToWalletID: business.ID, Amount: 1_000}); err != ErrNotOwner {
+        t.Fatalf("expected ownership check, got %v", err)
+    }
+
+    name := "Savings"
+    renamed, err := svc.Update(ctx, UpdateInput{WalletID: pocket.ID, OwnerID: owner, Name: &name})
+    if err != nil || renamed.Name != "Savings" {
+        t.Fatalf("rename: %v %+v", err, renamed)
+    }
+    earlier := time.Now()
+    if _, err := svc.Update(ctx, UpdateInput{WalletID: pocket.ID, OwnerID: owner, LockedUntil: &earlier}); err != ErrWalletLocked {
+        t.Fatalf("expected lock shortening to be refused, got %v", err)
+    }
+
+    if _, err := svc.SetPrimary(ctx, owner, business.ID); err != nil {
+        t.Fatalf("set primary: %v", err)
+    }
+    primary, _ := svc.GetByOwner(ctx, owner)
+    if primary.ID != business.ID {
+        t.Fatalf("expected business wallet to be primary, got %s", primary.ID)
+    }
+}
@@ -0,0 +1,36 @@
+-- +migrate Up
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS chain_seq BIGINT UNIQUE,
+    ADD COLUMN IF NOT EXISTS prev_hash BYTEA,
+    ADD COLUMN IF NOT EXISTS hash BYTEA;
+
+-- Single-row table holding the latest link; locked by every posting to serialise appends.
+CREATE TABLE IF NOT EXISTS ledger_chain_head (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    chain_seq BIGINT NOT NULL,
+    hash BYTEA NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO ledger_chain_head (id, chain_seq, hash)
+VALUES (TRUE, 0, decode(repeat('00', 32), 'hex'))
+ON CONFLICT (id) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS ledger_checkpoints (
+    id UUID PRIMARY KEY,
+    chain_seq BIGINT NOT NULL,
+    hash BYTEA NOT NULL,
+    key_id TEXT NOT NULL,
+    signature BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_checkpoints_seq ON ledger_checkpoints(chain_seq);
+
+-- +migrate Down
+DROP TABLE IF EXISTS ledger_checkpoints;
+DROP TABLE IF EXISTS ledger_chain_head;
+ALTER TABLE transactions
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS chain_seq;
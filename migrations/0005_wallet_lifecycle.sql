@@ -0,0 +1,23 @@
+-- +migrate Up
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+
+CREATE TABLE IF NOT EXISTS wallet_status_history (
+    id UUID PRIMARY KEY,
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_status_history_wallet ON wallet_status_history(wallet_id, created_at);
+
+INSERT INTO accounts (id, code)
+SELECT uuid_generate_v4(), 'suspense:payout'
+WHERE NOT EXISTS (SELECT 1 FROM accounts WHERE code = 'suspense:payout');
+
+-- +migrate Down
+DROP TABLE IF EXISTS wallet_status_history;
+ALTER TABLE accounts DROP COLUMN IF EXISTS status;
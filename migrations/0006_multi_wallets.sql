@@ -0,0 +1,24 @@
+-- +migrate Up
+ALTER TABLE wallets
+    ADD COLUMN IF NOT EXISTS name TEXT NOT NULL DEFAULT 'Main',
+    ADD COLUMN IF NOT EXISTS kind TEXT NOT NULL DEFAULT 'main',
+    ADD COLUMN IF NOT EXISTS is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS locked_until TIMESTAMPTZ;
+
+-- Existing owners had exactly one wallet in practice; make the oldest one primary.
+UPDATE wallets w SET is_primary = TRUE
+WHERE w.id = (
+    SELECT id FROM wallets o WHERE o.owner_id = w.owner_id ORDER BY o.created_at LIMIT 1
+) AND NOT EXISTS (SELECT 1 FROM wallets p WHERE p.owner_id = w.owner_id AND p.is_primary);
+
+CREATE INDEX IF NOT EXISTS idx_wallets_owner ON wallets(owner_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallets_owner_primary ON wallets(owner_id) WHERE is_primary;
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_wallets_owner_primary;
+DROP INDEX IF EXISTS idx_wallets_owner;
+ALTER TABLE wallets
+    DROP COLUMN IF EXISTS locked_until,
+    DROP COLUMN IF EXISTS is_primary,
+    DROP COLUMN IF EXISTS kind,
+    DROP COLUMN IF EXISTS name;
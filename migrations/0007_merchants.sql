@@ -0,0 +1,38 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS merchants (
+    id UUID PRIMARY KEY,
+    owner_user_id UUID NOT NULL REFERENCES users(id),
+    business_name TEXT NOT NULL,
+    category_code TEXT NOT NULL,
+    short_code TEXT NOT NULL UNIQUE,
+    settlement_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    mdr_bps INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'active',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchants_owner ON merchants(owner_user_id);
+
+CREATE TABLE IF NOT EXISTS merchant_payments (
+    id UUID PRIMARY KEY,
+    merchant_id UUID NOT NULL REFERENCES merchants(id),
+    payer_user_id UUID NOT NULL REFERENCES users(id),
+    payer_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    fee BIGINT NOT NULL,
+    net_amount BIGINT NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    client_tx_id TEXT NOT NULL UNIQUE,
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_merchant_payments_merchant ON merchant_payments(merchant_id, created_at DESC);
+
+INSERT INTO accounts (id, code)
+SELECT uuid_generate_v4(), 'revenue:mdr'
+WHERE NOT EXISTS (SELECT 1 FROM accounts WHERE code = 'revenue:mdr');
+
+-- +migrate Down
+DROP TABLE IF EXISTS merchant_payments;
+DROP TABLE IF EXISTS merchants;
@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE merchants
+    ADD COLUMN IF NOT EXISTS city TEXT NOT NULL DEFAULT 'Brazzaville';
+
+-- +migrate Down
+ALTER TABLE merchants DROP COLUMN IF EXISTS city;
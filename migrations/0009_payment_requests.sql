@@ -0,0 +1,23 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS payment_requests (
+    id UUID PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+    requester_user_id UUID NOT NULL REFERENCES users(id),
+    requester_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    payer_user_id UUID REFERENCES users(id),
+    payer_phone TEXT NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    memo TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    paid_by_user_id UUID REFERENCES users(id),
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_requests_requester ON payment_requests(requester_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_requests_payer ON payment_requests(payer_user_id, created_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS payment_requests;
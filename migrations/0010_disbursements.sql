@@ -0,0 +1,37 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS disbursement_batches (
+    id UUID PRIMARY KEY,
+    owner_user_id UUID NOT NULL REFERENCES users(id),
+    source_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    name TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    total_amount BIGINT NOT NULL,
+    row_count INTEGER NOT NULL,
+    invalid_count INTEGER NOT NULL DEFAULT 0,
+    succeeded_count INTEGER NOT NULL DEFAULT 0,
+    failed_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_disbursement_batches_owner ON disbursement_batches(owner_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_disbursement_batches_processing ON disbursement_batches(status) WHERE status = 'processing';
+
+CREATE TABLE IF NOT EXISTS disbursement_items (
+    batch_id UUID NOT NULL REFERENCES disbursement_batches(id),
+    row_number INTEGER NOT NULL,
+    recipient TEXT NOT NULL,
+    wallet_id UUID REFERENCES wallets(id),
+    amount BIGINT NOT NULL,
+    reference TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    transaction_id UUID REFERENCES transactions(id),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (batch_id, row_number)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS disbursement_items;
+DROP TABLE IF EXISTS disbursement_batches;
@@ -0,0 +1,41 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS standing_orders (
+    id UUID PRIMARY KEY,
+    owner_user_id UUID NOT NULL REFERENCES users(id),
+    from_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    to_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    memo TEXT NOT NULL DEFAULT '',
+    frequency TEXT NOT NULL,
+    interval INTEGER NOT NULL DEFAULT 1,
+    cron TEXT NOT NULL DEFAULT '',
+    start_at TIMESTAMPTZ NOT NULL,
+    end_at TIMESTAMPTZ,
+    status TEXT NOT NULL,
+    current_occurrence TIMESTAMPTZ NOT NULL,
+    next_run_at TIMESTAMPTZ NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_run_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_standing_orders_owner ON standing_orders(owner_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_standing_orders_due ON standing_orders(next_run_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS standing_order_executions (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES standing_orders(id),
+    occurrence TIMESTAMPTZ NOT NULL,
+    attempt INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_standing_order_executions_order ON standing_order_executions(order_id, created_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS standing_order_executions;
+DROP TABLE IF EXISTS standing_orders;
@@ -0,0 +1,30 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS escrows (
+    id UUID PRIMARY KEY,
+    payer_user_id UUID NOT NULL REFERENCES users(id),
+    payer_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    payee_user_id UUID NOT NULL REFERENCES users(id),
+    payee_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    description TEXT NOT NULL,
+    status TEXT NOT NULL,
+    release_at TIMESTAMPTZ NOT NULL,
+    funding_tx_id UUID REFERENCES transactions(id),
+    disputed_by_user_id UUID REFERENCES users(id),
+    dispute_reason TEXT NOT NULL DEFAULT '',
+    resolved_by_user_id UUID REFERENCES users(id),
+    resolution_note TEXT NOT NULL DEFAULT '',
+    settlement_tx_id UUID REFERENCES transactions(id),
+    settled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_escrows_payer ON escrows(payer_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_escrows_payee ON escrows(payee_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_escrows_due ON escrows(release_at) WHERE status = 'held';
+CREATE INDEX IF NOT EXISTS idx_escrows_unsettled ON escrows(updated_at)
+    WHERE status IN ('released', 'refunded') AND settlement_tx_id IS NULL;
+
+-- +migrate Down
+DROP TABLE IF EXISTS escrows;
@@ -0,0 +1,46 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS split_templates (
+    id UUID PRIMARY KEY,
+    owner_user_id UUID NOT NULL REFERENCES users(id),
+    name TEXT NOT NULL,
+    shares JSONB NOT NULL,
+    rounding TEXT NOT NULL,
+    remainder_share INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_templates_owner ON split_templates(owner_user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS split_payments (
+    id UUID PRIMARY KEY,
+    template_id UUID REFERENCES split_templates(id),
+    payer_user_id UUID NOT NULL REFERENCES users(id),
+    payer_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    reference TEXT NOT NULL DEFAULT '',
+    client_tx_id TEXT NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (payer_user_id, client_tx_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_payments_payer ON split_payments(payer_user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS split_payment_legs (
+    payment_id UUID NOT NULL REFERENCES split_payments(id),
+    leg_index INTEGER NOT NULL,
+    beneficiary_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    beneficiary_user_id UUID NOT NULL REFERENCES users(id),
+    label TEXT NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (payment_id, leg_index)
+);
+
+CREATE INDEX IF NOT EXISTS idx_split_payment_legs_beneficiary ON split_payment_legs(beneficiary_user_id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS split_payment_legs;
+DROP TABLE IF EXISTS split_payments;
+DROP TABLE IF EXISTS split_templates;
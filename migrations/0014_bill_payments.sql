@@ -0,0 +1,25 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS bill_payments (
+    id UUID PRIMARY KEY,
+    biller_id TEXT NOT NULL,
+    biller_name TEXT NOT NULL,
+    payer_user_id UUID NOT NULL REFERENCES users(id),
+    payer_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    reference TEXT NOT NULL,
+    customer_name TEXT NOT NULL DEFAULT '',
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    fee BIGINT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL,
+    receipt_token TEXT NOT NULL DEFAULT '',
+    failure_reason TEXT NOT NULL DEFAULT '',
+    client_tx_id TEXT NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (payer_user_id, client_tx_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_bill_payments_payer ON bill_payments(payer_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bill_payments_biller ON bill_payments(biller_id, created_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS bill_payments;
@@ -0,0 +1,53 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS agents (
+    id UUID PRIMARY KEY,
+    agent_code TEXT NOT NULL UNIQUE,
+    owner_user_id UUID NOT NULL REFERENCES users(id),
+    business_name TEXT NOT NULL,
+    location TEXT NOT NULL,
+    city TEXT NOT NULL,
+    float_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    status TEXT NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_agents_owner ON agents(owner_user_id);
+
+CREATE TABLE IF NOT EXISTS agent_cash_transactions (
+    id UUID PRIMARY KEY,
+    kind TEXT NOT NULL CHECK (kind IN ('cash_in', 'cash_out')),
+    agent_id UUID NOT NULL REFERENCES agents(id),
+    customer_user_id UUID NOT NULL REFERENCES users(id),
+    customer_phone TEXT NOT NULL,
+    customer_wallet_id UUID NOT NULL REFERENCES wallets(id),
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    method TEXT NOT NULL DEFAULT '',
+    client_tx_id TEXT NOT NULL,
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (agent_id, client_tx_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_cash_agent ON agent_cash_transactions(agent_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_agent_cash_customer ON agent_cash_transactions(customer_user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS agent_withdrawal_codes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    wallet_id UUID NOT NULL REFERENCES wallets(id),
+    code_hash TEXT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    status TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A customer holds at most one redeemable code at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_agent_withdrawal_codes_active
+    ON agent_withdrawal_codes(user_id) WHERE status = 'active';
+
+-- +migrate Down
+DROP TABLE IF EXISTS agent_withdrawal_codes;
+DROP TABLE IF EXISTS agent_cash_transactions;
+DROP TABLE IF EXISTS agents;
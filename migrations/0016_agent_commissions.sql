@@ -0,0 +1,17 @@
+-- +migrate Up
+ALTER TABLE agent_cash_transactions
+    ADD COLUMN IF NOT EXISTS commission BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS commission_tx_id UUID REFERENCES transactions(id);
+
+CREATE INDEX IF NOT EXISTS idx_agent_cash_commission_owed ON agent_cash_transactions(created_at)
+    WHERE commission > 0 AND commission_tx_id IS NULL;
+
+INSERT INTO accounts (id, code)
+SELECT uuid_generate_v4(), 'expense:agent_commissions'
+WHERE NOT EXISTS (SELECT 1 FROM accounts WHERE code = 'expense:agent_commissions');
+
+-- +migrate Down
+DROP INDEX IF EXISTS idx_agent_cash_commission_owed;
+ALTER TABLE agent_cash_transactions
+    DROP COLUMN IF EXISTS commission_tx_id,
+    DROP COLUMN IF EXISTS commission;
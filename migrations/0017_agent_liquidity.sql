@@ -0,0 +1,44 @@
+-- +migrate Up
+ALTER TABLE agents
+    ADD COLUMN IF NOT EXISTS min_float BIGINT NOT NULL DEFAULT 0 CHECK (min_float >= 0),
+    ADD COLUMN IF NOT EXISTS max_float BIGINT NOT NULL DEFAULT 0 CHECK (max_float >= 0),
+    ADD COLUMN IF NOT EXISTS liquidity_state TEXT NOT NULL DEFAULT 'ok',
+    ADD COLUMN IF NOT EXISTS super_agent_id UUID REFERENCES agents(id);
+
+CREATE INDEX IF NOT EXISTS idx_agents_super_agent ON agents(super_agent_id) WHERE super_agent_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS agent_rebalance_requests (
+    id UUID PRIMARY KEY,
+    agent_id UUID NOT NULL REFERENCES agents(id),
+    source TEXT NOT NULL,
+    super_agent_id UUID REFERENCES agents(id),
+    direction TEXT NOT NULL,
+    amount BIGINT NOT NULL CHECK (amount > 0),
+    note TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    requested_by UUID NOT NULL REFERENCES users(id),
+    decided_by UUID REFERENCES users(id),
+    decision_note TEXT NOT NULL DEFAULT '',
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decided_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_rebalance_agent ON agent_rebalance_requests(agent_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_agent_rebalance_super_agent ON agent_rebalance_requests(super_agent_id, created_at DESC)
+    WHERE super_agent_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_agent_rebalance_pending ON agent_rebalance_requests(source, created_at)
+    WHERE status = 'pending';
+
+INSERT INTO accounts (id, code)
+SELECT uuid_generate_v4(), 'settlement:bank_float'
+WHERE NOT EXISTS (SELECT 1 FROM accounts WHERE code = 'settlement:bank_float');
+
+-- +migrate Down
+DROP TABLE IF EXISTS agent_rebalance_requests;
+DROP INDEX IF EXISTS idx_agents_super_agent;
+ALTER TABLE agents
+    DROP COLUMN IF EXISTS super_agent_id,
+    DROP COLUMN IF EXISTS liquidity_state,
+    DROP COLUMN IF EXISTS max_float,
+    DROP COLUMN IF EXISTS min_float;
@@ -0,0 +1,47 @@
+-- +migrate Up
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS onboarding_agent_id UUID REFERENCES agents(id);
+
+CREATE INDEX IF NOT EXISTS idx_users_onboarding_agent ON users(onboarding_agent_id) WHERE onboarding_agent_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS user_kyc_profiles (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    full_name TEXT NOT NULL,
+    document_type TEXT NOT NULL,
+    document_number TEXT NOT NULL,
+    document_front_ref TEXT NOT NULL,
+    document_back_ref TEXT NOT NULL DEFAULT '',
+    selfie_ref TEXT NOT NULL,
+    captured_by_agent_id UUID REFERENCES agents(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS agent_registrations (
+    id UUID PRIMARY KEY,
+    agent_id UUID NOT NULL REFERENCES agents(id),
+    operator_user_id UUID NOT NULL REFERENCES users(id),
+    phone TEXT NOT NULL,
+    full_name TEXT NOT NULL,
+    document_type TEXT NOT NULL,
+    document_number TEXT NOT NULL,
+    document_front_ref TEXT NOT NULL,
+    document_back_ref TEXT NOT NULL DEFAULT '',
+    selfie_ref TEXT NOT NULL,
+    otp_hash TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'pending',
+    user_id UUID REFERENCES users(id),
+    wallet_id UUID REFERENCES wallets(id),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_agent_registrations_agent ON agent_registrations(agent_id, created_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_agent_registrations_pending_phone ON agent_registrations(phone) WHERE status = 'pending';
+
+-- +migrate Down
+DROP TABLE IF EXISTS agent_registrations;
+DROP TABLE IF EXISTS user_kyc_profiles;
+DROP INDEX IF EXISTS idx_users_onboarding_agent;
+ALTER TABLE users DROP COLUMN IF EXISTS onboarding_agent_id;
@@ -0,0 +1,39 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    current_tier TEXT NOT NULL,
+    requested_tier TEXT NOT NULL,
+    full_name TEXT NOT NULL,
+    date_of_birth TEXT NOT NULL,
+    address TEXT NOT NULL DEFAULT '',
+    document_type TEXT NOT NULL,
+    document_number TEXT NOT NULL,
+    documents JSONB NOT NULL DEFAULT '[]',
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewer_id UUID REFERENCES users(id),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_user ON kyc_submissions(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_kyc_submissions_queue ON kyc_submissions(status, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_kyc_submissions_one_pending ON kyc_submissions(user_id) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS user_tier_changes (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    from_tier TEXT NOT NULL,
+    to_tier TEXT NOT NULL,
+    submission_id UUID REFERENCES kyc_submissions(id),
+    changed_by UUID NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tier_changes_user ON user_tier_changes(user_id, created_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS user_tier_changes;
+DROP TABLE IF EXISTS kyc_submissions;
@@ -0,0 +1,23 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN IF NOT EXISTS failed_pin_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pin_locked_until TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pin_lock_count INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pin_blocked BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS user_security_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    kind TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_security_events_user ON user_security_events(user_id, created_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS user_security_events;
+ALTER TABLE users DROP COLUMN IF EXISTS pin_blocked;
+ALTER TABLE users DROP COLUMN IF EXISTS pin_lock_count;
+ALTER TABLE users DROP COLUMN IF EXISTS pin_locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_pin_attempts;
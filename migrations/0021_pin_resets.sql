@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS pin_resets (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    code_hash TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    send_count INT NOT NULL DEFAULT 0,
+    window_start TIMESTAMPTZ NOT NULL,
+    last_sent_at TIMESTAMPTZ NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS pin_resets;
@@ -0,0 +1,14 @@
+-- +migrate Up
+-- Forgotten-PIN codes moved to the OTP service (Redis).
+DROP TABLE IF EXISTS pin_resets;
+
+-- +migrate Down
+CREATE TABLE IF NOT EXISTS pin_resets (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    code_hash TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    send_count INT NOT NULL DEFAULT 0,
+    window_start TIMESTAMPTZ NOT NULL,
+    last_sent_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,25 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS devices (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    device_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    platform TEXT NOT NULL DEFAULT '',
+    trusted BOOLEAN NOT NULL DEFAULT FALSE,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    cooling_off_until TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    UNIQUE (user_id, device_id)
+);
+CREATE INDEX IF NOT EXISTS idx_devices_user ON devices (user_id, last_seen_at DESC);
+
+-- Existing single-device bindings become the user's trusted device.
+INSERT INTO devices (id, user_id, device_id, trusted, first_seen_at, last_seen_at)
+SELECT uuid_generate_v4(), id, device_id, TRUE, created_at, COALESCE(last_login, created_at)
+FROM users
+WHERE COALESCE(device_id, '') <> ''
+ON CONFLICT (user_id, device_id) DO NOTHING;
+
+-- +migrate Down
+DROP TABLE IF EXISTS devices;
@@ -0,0 +1,20 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS risk_cases (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open',
+    detail TEXT NOT NULL DEFAULT '',
+    swapped_at TIMESTAMPTZ NOT NULL,
+    restricted_until TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    closed_at TIMESTAMPTZ,
+    closed_by TEXT NOT NULL DEFAULT '',
+    resolution TEXT NOT NULL DEFAULT '',
+    UNIQUE (user_id, kind, swapped_at)
+);
+CREATE INDEX IF NOT EXISTS idx_risk_cases_status ON risk_cases (status, created_at);
+CREATE INDEX IF NOT EXISTS idx_risk_cases_user ON risk_cases (user_id, created_at DESC);
+
+-- +migrate Down
+DROP TABLE IF EXISTS risk_cases;
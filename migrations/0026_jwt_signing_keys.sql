@@ -0,0 +1,14 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS jwt_signing_keys (
+    kid TEXT PRIMARY KEY,
+    alg TEXT NOT NULL,
+    -- PKCS#8 private key sealed with AES-GCM under JWT_KEY_SECRET.
+    private_key BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    activates_at TIMESTAMPTZ NOT NULL,
+    retires_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS jwt_signing_keys;
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE users ADD COLUMN roles TEXT[] NOT NULL DEFAULT '{customer}';
+CREATE INDEX users_roles_idx ON users USING GIN (roles);
+
+-- +migrate Down
+DROP INDEX IF EXISTS users_roles_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS roles;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE standing_orders ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE standing_orders DROP COLUMN IF EXISTS version;
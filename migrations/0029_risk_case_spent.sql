@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE risk_cases ADD COLUMN IF NOT EXISTS spent BIGINT NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE risk_cases DROP COLUMN IF EXISTS spent;
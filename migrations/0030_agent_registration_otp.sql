@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE agent_registrations DROP COLUMN IF EXISTS otp_hash, DROP COLUMN IF EXISTS attempts;
+
+-- +migrate Down
+ALTER TABLE agent_registrations
+    ADD COLUMN IF NOT EXISTS otp_hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE merchant_payments DROP CONSTRAINT IF EXISTS merchant_payments_client_tx_id_key;
+ALTER TABLE merchant_payments ADD CONSTRAINT merchant_payments_payer_client_tx_id_key UNIQUE (payer_user_id, client_tx_id);
+
+-- +migrate Down
+ALTER TABLE merchant_payments DROP CONSTRAINT IF EXISTS merchant_payments_payer_client_tx_id_key;
+ALTER TABLE merchant_payments ADD CONSTRAINT merchant_payments_client_tx_id_key UNIQUE (client_tx_id);
@@ -0,0 +1,25 @@
+package emvqr
+
+import "fmt"
+
+// crc16 computes CRC-16/CCITT-FALSE (polynomial 0x1021, initial value 0xFFFF) as required
+// by EMVCo for the payload checksum in tag 63.
+func crc16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// checksum returns the 4-character uppercase hex CRC of data.
+func checksum(data string) string {
+	return fmt.Sprintf("%04X", crc16([]byte(data)))
+}
@@ -0,0 +1,283 @@
+// Package emvqr encodes and decodes EMVCo Merchant-Presented Mode QR payloads.
+//
+// A payload is a sequence of ID/length/value data objects terminated by a CRC-16/CCITT
+// checksum in tag 63. Static codes carry only the merchant identity; dynamic codes are
+// meant for a single purchase and also carry the amount and a reference.
+package emvqr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Top-level data object IDs defined by the EMVCo MPM specification.
+const (
+	idPayloadFormat      = "00"
+	idPointOfInitiation  = "01"
+	idMerchantAccountMin = "26"
+	idMerchantAccountMax = "51"
+	idCategoryCode       = "52"
+	idCurrency           = "53"
+	idAmount             = "54"
+	idCountryCode        = "58"
+	idMerchantName       = "59"
+	idMerchantCity       = "60"
+	idPostalCode         = "61"
+	idAdditionalData     = "62"
+	idCRC                = "63"
+
+	payloadFormatVersion = "01"
+	initiationStatic     = "11"
+	initiationDynamic    = "12"
+
+	// idGloballyUniqueID identifies the scheme inside a merchant account template.
+	idGloballyUniqueID = "00"
+)
+
+// Additional data field template (tag 62) sub-IDs.
+const (
+	idBillNumber     = "01"
+	idMobileNumber   = "02"
+	idStoreLabel     = "03"
+	idReferenceLabel = "05"
+	idTerminalLabel  = "07"
+	idPurpose        = "08"
+)
+
+var (
+	// ErrMalformed indicates the payload is not valid TLV or misses mandatory fields.
+	ErrMalformed = errors.New("malformed EMV QR payload")
+	// ErrInvalidCRC indicates the checksum in tag 63 does not match the payload.
+	ErrInvalidCRC = errors.New("EMV QR checksum mismatch")
+)
+
+// MerchantAccount is a merchant account information template (tags 26-51). The globally
+// unique ID names the payment scheme; the remaining fields are scheme specific.
+type MerchantAccount struct {
+	Tag              string
+	GloballyUniqueID string
+	Fields           []Field
+}
+
+// Value returns the value of a scheme-specific sub-field, or "" when absent.
+func (a MerchantAccount) Value(id string) string {
+	for _, f := range a.Fields {
+		if f.ID == id {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// AdditionalData is the additional data field template (tag 62).
+type AdditionalData struct {
+	BillNumber     string
+	MobileNumber   string
+	StoreLabel     string
+	ReferenceLabel string
+	TerminalLabel  string
+	Purpose        string
+}
+
+func (d AdditionalData) fields() []Field {
+	return []Field{
+		{ID: idBillNumber, Value: d.BillNumber},
+		{ID: idMobileNumber, Value: d.MobileNumber},
+		{ID: idStoreLabel, Value: d.StoreLabel},
+		{ID: idReferenceLabel, Value: d.ReferenceLabel},
+		{ID: idTerminalLabel, Value: d.TerminalLabel},
+		{ID: idPurpose, Value: d.Purpose},
+	}
+}
+
+// Payload is a decoded Merchant-Presented Mode QR code.
+type Payload struct {
+	// Dynamic marks a single-use code (point of initiation "12"); static codes use "11".
+	Dynamic          bool
+	MerchantAccounts []MerchantAccount
+	// MerchantCategoryCode is the ISO 18245 MCC.
+	MerchantCategoryCode string
+	// Currency is the ISO 4217 numeric currency code, e.g. "950" for XAF.
+	Currency string
+	// Amount is the transaction amount as a decimal string; empty lets the payer enter it.
+	Amount       string
+	CountryCode  string
+	MerchantName string
+	MerchantCity string
+	PostalCode   string
+	Additional   AdditionalData
+}
+
+// Account returns the merchant account template for a scheme's globally unique ID.
+func (p Payload) Account(guid string) (MerchantAccount, bool) {
+	for _, a := range p.MerchantAccounts {
+		if strings.EqualFold(a.GloballyUniqueID, guid) {
+			return a, true
+		}
+	}
+	return MerchantAccount{}, false
+}
+
+func (p Payload) validate() error {
+	switch {
+	case len(p.MerchantAccounts) == 0:
+		return fmt.Errorf("%w: at least one merchant account is required", ErrMalformed)
+	case len(p.MerchantCategoryCode) != 4 || !isDigits(p.MerchantCategoryCode):
+		return fmt.Errorf("%w: merchant category code must be 4 digits", ErrMalformed)
+	case len(p.Currency) != 3 || !isDigits(p.Currency):
+		return fmt.Errorf("%w: currency must be a 3-digit ISO 4217 code", ErrMalformed)
+	case len(p.CountryCode) != 2:
+		return fmt.Errorf("%w: country code must be 2 characters", ErrMalformed)
+	case p.MerchantName == "" || len(p.MerchantName) > 25:
+		return fmt.Errorf("%w: merchant name must be 1-25 characters", ErrMalformed)
+	case p.MerchantCity == "" || len(p.MerchantCity) > 15:
+		return fmt.Errorf("%w: merchant city must be 1-15 characters", ErrMalformed)
+	case p.Dynamic && p.Amount == "":
+		return fmt.Errorf("%w: dynamic codes require an amount", ErrMalformed)
+	}
+	if p.Amount != "" && !validAmount(p.Amount) {
+		return fmt.Errorf("%w: invalid amount %q", ErrMalformed, p.Amount)
+	}
+	for _, a := range p.MerchantAccounts {
+		if a.Tag < idMerchantAccountMin || a.Tag > idMerchantAccountMax || !isDigits(a.Tag) {
+			return fmt.Errorf("%w: merchant account tag %q outside 26-51", ErrMalformed, a.Tag)
+		}
+		if a.GloballyUniqueID == "" {
+			return fmt.Errorf("%w: merchant account %s needs a globally unique ID", ErrMalformed, a.Tag)
+		}
+	}
+	return nil
+}
+
+func validAmount(s string) bool {
+	if len(s) > 13 {
+		return false
+	}
+	whole, frac, hasDot := strings.Cut(s, ".")
+	if !isDigits(whole) {
+		return false
+	}
+	return !hasDot || isDigits(frac)
+}
+
+// Encode serialises a payload and appends its CRC.
+func Encode(p Payload) (string, error) {
+	if err := p.validate(); err != nil {
+		return "", err
+	}
+	initiation := initiationStatic
+	if p.Dynamic {
+		initiation = initiationDynamic
+	}
+	fields := []Field{
+		{ID: idPayloadFormat, Value: payloadFormatVersion},
+		{ID: idPointOfInitiation, Value: initiation},
+		{ID: idCategoryCode, Value: p.MerchantCategoryCode},
+		{ID: idCurrency, Value: p.Currency},
+		{ID: idAmount, Value: p.Amount},
+		{ID: idCountryCode, Value: p.CountryCode},
+		{ID: idMerchantName, Value: p.MerchantName},
+		{ID: idMerchantCity, Value: p.MerchantCity},
+		{ID: idPostalCode, Value: p.PostalCode},
+	}
+	for _, a := range p.MerchantAccounts {
+		inner, err := encodeFields(append([]Field{{ID: idGloballyUniqueID, Value: a.GloballyUniqueID}}, a.Fields...))
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, Field{ID: a.Tag, Value: inner})
+	}
+	additional, err := encodeFields(p.Additional.fields())
+	if err != nil {
+		return "", err
+	}
+	fields = append(fields, Field{ID: idAdditionalData, Value: additional})
+
+	body, err := encodeFields(fields)
+	if err != nil {
+		return "", err
+	}
+	body += idCRC + "04"
+	return body + checksum(body), nil
+}
+
+// Decode verifies the CRC of a scanned payload and parses it.
+func Decode(s string) (Payload, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 12 || s[len(s)-8:len(s)-4] != idCRC+"04" {
+		return Payload{}, fmt.Errorf("%w: missing CRC", ErrMalformed)
+	}
+	if !strings.EqualFold(checksum(s[:len(s)-4]), s[len(s)-4:]) {
+		return Payload{}, ErrInvalidCRC
+	}
+	fields, err := parseFields(s[:len(s)-8])
+	if err != nil {
+		return Payload{}, err
+	}
+	if len(fields) == 0 || fields[0].ID != idPayloadFormat || fields[0].Value != payloadFormatVersion {
+		return Payload{}, fmt.Errorf("%w: payload format indicator must come first", ErrMalformed)
+	}
+
+	var p Payload
+	for _, f := range fields[1:] {
+		switch {
+		case f.ID == idPointOfInitiation:
+			p.Dynamic = f.Value == initiationDynamic
+		case f.ID >= idMerchantAccountMin && f.ID <= idMerchantAccountMax:
+			inner, err := parseFields(f.Value)
+			if err != nil {
+				return Payload{}, err
+			}
+			account := MerchantAccount{Tag: f.ID}
+			for _, sub := range inner {
+				if sub.ID == idGloballyUniqueID {
+					account.GloballyUniqueID = sub.Value
+				} else {
+					account.Fields = append(account.Fields, sub)
+				}
+			}
+			p.MerchantAccounts = append(p.MerchantAccounts, account)
+		case f.ID == idCategoryCode:
+			p.MerchantCategoryCode = f.Value
+		case f.ID == idCurrency:
+			p.Currency = f.Value
+		case f.ID == idAmount:
+			p.Amount = f.Value
+		case f.ID == idCountryCode:
+			p.CountryCode = f.Value
+		case f.ID == idMerchantName:
+			p.MerchantName = f.Value
+		case f.ID == idMerchantCity:
+			p.MerchantCity = f.Value
+		case f.ID == idPostalCode:
+			p.PostalCode = f.Value
+		case f.ID == idAdditionalData:
+			inner, err := parseFields(f.Value)
+			if err != nil {
+				return Payload{}, err
+			}
+			for _, sub := range inner {
+				switch sub.ID {
+				case idBillNumber:
+					p.Additional.BillNumber = sub.Value
+				case idMobileNumber:
+					p.Additional.MobileNumber = sub.Value
+				case idStoreLabel:
+					p.Additional.StoreLabel = sub.Value
+				case idReferenceLabel:
+					p.Additional.ReferenceLabel = sub.Value
+				case idTerminalLabel:
+					p.Additional.TerminalLabel = sub.Value
+				case idPurpose:
+					p.Additional.Purpose = sub.Value
+				}
+			}
+		}
+		// Unknown IDs (reserved or other templates) are ignored so newer codes still decode.
+	}
+	if p.MerchantName == "" || len(p.MerchantAccounts) == 0 {
+		return Payload{}, fmt.Errorf("%w: merchant name and account are mandatory", ErrMalformed)
+	}
+	return p, nil
+}
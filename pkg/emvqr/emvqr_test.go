@@ -0,0 +1,89 @@
+package emvqr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func samplePayload() Payload {
+	return Payload{
+		MerchantAccounts: []MerchantAccount{{
+			Tag:              "26",
+			GloballyUniqueID: "cg.congopay",
+			Fields:           []Field{{ID: "01", Value: "123456"}},
+		}},
+		MerchantCategoryCode: "5411",
+		Currency:             "950",
+		CountryCode:          "CG",
+		MerchantName:         "Boutique Mama",
+		MerchantCity:         "Brazzaville",
+	}
+}
+
+func TestCRC16CheckValue(t *testing.T) {
+	if got := crc16([]byte("123456789")); got != 0x29B1 {
+		t.Fatalf("crc16 check value = %04X, want 29B1", got)
+	}
+}
+
+func TestEncodeDecodeStatic(t *testing.T) {
+	encoded, err := Encode(samplePayload())
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if !strings.HasPrefix(encoded, "000201010211") {
+		t.Fatalf("expected static header, got %s", encoded)
+	}
+
+	decoded, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	account, ok := decoded.Account("CG.CONGOPAY")
+	if !ok || account.Value("01") != "123456" {
+		t.Fatalf("merchant account not round-tripped: %+v", decoded.MerchantAccounts)
+	}
+	if decoded.Dynamic || decoded.Amount != "" || decoded.MerchantName != "Boutique Mama" {
+		t.Fatalf("unexpected payload: %+v", decoded)
+	}
+}
+
+func TestEncodeDecodeDynamic(t *testing.T) {
+	p := samplePayload()
+	p.Dynamic = true
+	p.Amount = "15000"
+	p.Additional.ReferenceLabel = "INV-42"
+
+	encoded, err := Encode(p)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	decoded, err := Decode(encoded)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !decoded.Dynamic || decoded.Amount != "15000" || decoded.Additional.ReferenceLabel != "INV-42" {
+		t.Fatalf("unexpected payload: %+v", decoded)
+	}
+
+	p.Amount = ""
+	if _, err := Encode(p); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("expected dynamic code without amount to fail, got %v", err)
+	}
+}
+
+func TestDecodeRejectsTampering(t *testing.T) {
+	p := samplePayload()
+	p.Dynamic = true
+	p.Amount = "15000"
+	encoded, _ := Encode(p)
+
+	tampered := strings.Replace(encoded, "540515000", "540595000", 1)
+	if _, err := Decode(tampered); !errors.Is(err, ErrInvalidCRC) {
+		t.Fatalf("expected ErrInvalidCRC, got %v", err)
+	}
+	if _, err := Decode(encoded[:len(encoded)-8]); !errors.Is(err, ErrMalformed) {
+		t.Fatalf("expected ErrMalformed without CRC, got %v", err)
+	}
+}
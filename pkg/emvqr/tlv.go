@@ -0,0 +1,74 @@
+package emvqr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Field is a single EMVCo data object: a two-digit ID, a two-digit length and a value.
+type Field struct {
+	ID    string
+	Value string
+}
+
+func (f Field) encode(b *strings.Builder) error {
+	if len(f.ID) != 2 || !isDigits(f.ID) {
+		return fmt.Errorf("%w: invalid field id %q", ErrMalformed, f.ID)
+	}
+	if len(f.Value) == 0 {
+		return nil
+	}
+	if len(f.Value) > 99 {
+		return fmt.Errorf("%w: field %s exceeds 99 characters", ErrMalformed, f.ID)
+	}
+	b.WriteString(f.ID)
+	fmt.Fprintf(b, "%02d", len(f.Value))
+	b.WriteString(f.Value)
+	return nil
+}
+
+// encodeFields serialises fields in ascending ID order, skipping empty values.
+func encodeFields(fields []Field) (string, error) {
+	sorted := append([]Field(nil), fields...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	var b strings.Builder
+	for _, f := range sorted {
+		if err := f.encode(&b); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+// parseFields splits a TLV string into its data objects, preserving order.
+func parseFields(s string) ([]Field, error) {
+	var fields []Field
+	for i := 0; i < len(s); {
+		if len(s)-i < 4 {
+			return nil, fmt.Errorf("%w: truncated header at offset %d", ErrMalformed, i)
+		}
+		id, rawLen := s[i:i+2], s[i+2:i+4]
+		if !isDigits(id) || !isDigits(rawLen) {
+			return nil, fmt.Errorf("%w: invalid header %q at offset %d", ErrMalformed, s[i:i+4], i)
+		}
+		n, _ := strconv.Atoi(rawLen)
+		start := i + 4
+		if start+n > len(s) {
+			return nil, fmt.Errorf("%w: field %s overruns payload", ErrMalformed, id)
+		}
+		fields = append(fields, Field{ID: id, Value: s[start : start+n]})
+		i = start + n
+	}
+	return fields, nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}